@@ -4,19 +4,27 @@ import {
     type AgeGroup,
     type Category,
     type CategoryFilter,
+    type ChatWorkspace,
+    type ChatWorkspaceDto,
     type CreateCategoryDto,
     type CreateTaskDto,
+    type Envelope,
+    type GenerateQuotaResponse,
     type GenerateRequest,
     type GenerateTasksResponse,
+    type GlossaryTerm,
+    type GlossaryTermDto,
     type Language,
     LANGUAGES,
     type PaginatedResponse,
+    type SearchResponse,
+    type SignedURLResponse,
     type SuccessResponse,
     type Task,
     type TaskFilter
 } from '../types';
 
-const API_BASE_URL = (import.meta.env.VITE_API_URL || 'http://localhost:8080') + '/api/v1';
+const API_BASE_URL = (import.meta.env.VITE_API_URL || 'http://localhost:8080') + '/api/v2';
 
 // Create axios instance
 const api: AxiosInstance = axios.create({
@@ -58,7 +66,7 @@ export const handleApiError = (error: unknown): string => {
             window.location.href = '/login';
             return 'Unauthorized. Please login again.';
         }
-        return error.response?.data?.message || error.message;
+        return error.response?.data?.error?.message || error.message;
     }
     return 'An unexpected error occurred';
 };
@@ -77,13 +85,13 @@ export const verifyAuth = async (): Promise<boolean> => {
 // ============ LANGUAGE APIs ============
 
 export const getLanguages = async (): Promise<{ code: Language; name: string }[]> => {
-    const response = await api.get<{ languages: { code: Language; name: string }[] }>('/languages');
-    return response.data.languages;
+    const response = await api.get<Envelope<{ code: Language; name: string }[]>>('/languages');
+    return response.data.data;
 };
 
 export const getAgeGroups = async (): Promise<{ value: AgeGroup; label: string; min_age: number; max_age: number }[]> => {
-    const response = await api.get<{ age_groups: { value: AgeGroup; label: string; min_age: number; max_age: number }[] }>('/age-groups');
-    return response.data.age_groups;
+    const response = await api.get<Envelope<{ value: AgeGroup; label: string; min_age: number; max_age: number }[]>>('/age-groups');
+    return response.data.data;
 };
 
 // Static versions (fallback)
@@ -101,23 +109,23 @@ export const getCategories = async (filter?: CategoryFilter): Promise<Category[]
         params.set('active', String(filter.active));
     }
 
-    const response = await api.get<{ data: Category[] }>(`/categories?${params.toString()}`);
+    const response = await api.get<Envelope<Category[]>>(`/categories?${params.toString()}`);
     return response.data.data;
 };
 
 export const getCategoryById = async (id: string): Promise<Category> => {
-    const response = await api.get<Category>(`/categories/${id}`);
-    return response.data;
+    const response = await api.get<Envelope<Category>>(`/categories/${id}`);
+    return response.data.data;
 };
 
 export const createCategory = async (data: CreateCategoryDto): Promise<Category> => {
-    const response = await api.post<Category>('/categories', data);
-    return response.data;
+    const response = await api.post<Envelope<Category>>('/categories', data);
+    return response.data.data;
 };
 
 export const updateCategory = async (id: string, data: Partial<CreateCategoryDto>): Promise<Category> => {
-    const response = await api.put<Category>(`/categories/${id}`, data);
-    return response.data;
+    const response = await api.put<Envelope<Category>>(`/categories/${id}`, data);
+    return response.data.data;
 };
 
 export const getCategoryCount = async (filter?: CategoryFilter): Promise<number> => {
@@ -129,8 +137,8 @@ export const getCategoryCount = async (filter?: CategoryFilter): Promise<number>
         params.set('active', String(filter.active));
     }
 
-    const response = await api.get<{ count: number }>(`/categories/count?${params.toString()}`);
-    return response.data.count;
+    const response = await api.get<Envelope<{ count: number }>>(`/categories/count?${params.toString()}`);
+    return response.data.data.count;
 };
 
 export const reorderCategories = async (items: { id: string; sort_order: number }[]): Promise<void> => {
@@ -160,28 +168,29 @@ export const getTasks = async (filter?: TaskFilter): Promise<PaginatedResponse<T
         params.set('limit', String(filter.page_size));
     }
 
-    const response = await api.get<PaginatedResponse<Task>>(`/tasks?${params.toString()}`);
-    return response.data;
+    const response = await api.get<Envelope<Task[]>>(`/tasks?${params.toString()}`);
+    const { data, meta } = response.data;
+    return { ...meta, data } as PaginatedResponse<Task>;
 };
 
 export const getTaskById = async (id: string): Promise<Task> => {
-    const response = await api.get<Task>(`/tasks/${id}`);
-    return response.data;
+    const response = await api.get<Envelope<Task>>(`/tasks/${id}`);
+    return response.data.data;
 };
 
 export const createTask = async (data: CreateTaskDto): Promise<Task> => {
-    const response = await api.post<Task>('/tasks', data);
-    return response.data;
+    const response = await api.post<Envelope<Task>>('/tasks', data);
+    return response.data.data;
 };
 
 export const updateTask = async (id: string, data: Partial<CreateTaskDto>): Promise<Task> => {
-    const response = await api.put<Task>(`/tasks/${id}`, data);
-    return response.data;
+    const response = await api.put<Envelope<Task>>(`/tasks/${id}`, data);
+    return response.data.data;
 };
 
 export const deleteTask = async (id: string): Promise<SuccessResponse> => {
-    const response = await api.delete<SuccessResponse>(`/tasks/${id}`);
-    return response.data;
+    const response = await api.delete<Envelope<SuccessResponse>>(`/tasks/${id}`);
+    return response.data.data;
 };
 
 export const getTaskCount = async (filter?: TaskFilter): Promise<number> => {
@@ -199,8 +208,71 @@ export const getTaskCount = async (filter?: TaskFilter): Promise<number> => {
         params.set('languages', filter.languages.join(','));
     }
 
-    const response = await api.get<{ count: number }>(`/tasks/count?${params.toString()}`);
-    return response.data.count;
+    const response = await api.get<Envelope<{ count: number }>>(`/tasks/count?${params.toString()}`);
+    return response.data.data.count;
+};
+
+// ============ GLOSSARY API ============
+
+export const getGlossary = async (): Promise<GlossaryTerm[]> => {
+    const response = await api.get<Envelope<GlossaryTerm[]>>('/glossary');
+    return response.data.data;
+};
+
+export const createGlossaryTerm = async (data: GlossaryTermDto): Promise<GlossaryTerm> => {
+    const response = await api.post<Envelope<GlossaryTerm>>('/glossary', data);
+    return response.data.data;
+};
+
+export const updateGlossaryTerm = async (id: string, data: GlossaryTermDto): Promise<GlossaryTerm> => {
+    const response = await api.put<Envelope<GlossaryTerm>>(`/glossary/${id}`, data);
+    return response.data.data;
+};
+
+export const deleteGlossaryTerm = async (id: string): Promise<SuccessResponse> => {
+    const response = await api.delete<Envelope<SuccessResponse>>(`/glossary/${id}`);
+    return response.data.data;
+};
+
+// ============ SEARCH API ============
+
+export const searchAdmin = async (q: string, limit?: number): Promise<SearchResponse> => {
+    const params = new URLSearchParams({ q });
+    if (limit !== undefined) {
+        params.set('limit', String(limit));
+    }
+    const response = await api.get<Envelope<SearchResponse>>(`/admin/search?${params.toString()}`);
+    return response.data.data;
+};
+
+// ============ SIGNED URL API ============
+
+// Sign an embeddable path such as /api/v2/embed/daily-task?language=es
+export const createSignedUrl = async (path: string, ttlSeconds?: number): Promise<SignedURLResponse> => {
+    const response = await api.post<Envelope<SignedURLResponse>>('/signed-urls', { path, ttl_seconds: ttlSeconds });
+    return response.data.data;
+};
+
+// ============ CHAT WORKSPACES API ============
+
+export const getChatWorkspaces = async (): Promise<ChatWorkspace[]> => {
+    const response = await api.get<Envelope<ChatWorkspace[]>>('/integrations/workspaces');
+    return response.data.data;
+};
+
+export const createChatWorkspace = async (data: ChatWorkspaceDto): Promise<ChatWorkspace> => {
+    const response = await api.post<Envelope<ChatWorkspace>>('/integrations/workspaces', data);
+    return response.data.data;
+};
+
+export const updateChatWorkspace = async (id: string, data: ChatWorkspaceDto): Promise<ChatWorkspace> => {
+    const response = await api.put<Envelope<ChatWorkspace>>(`/integrations/workspaces/${id}`, data);
+    return response.data.data;
+};
+
+export const deleteChatWorkspace = async (id: string): Promise<SuccessResponse> => {
+    const response = await api.delete<Envelope<SuccessResponse>>(`/integrations/workspaces/${id}`);
+    return response.data.data;
 };
 
 // ============ GENERATE API ============
@@ -210,10 +282,15 @@ export const getTaskCount = async (filter?: TaskFilter): Promise<number> => {
 const GENERATE_TIMEOUT_MS = 30 * 60 * 1000; // 30 minutes
 
 export const generateTasks = async (data: GenerateRequest): Promise<GenerateTasksResponse> => {
-    const response = await api.post<GenerateTasksResponse>('/generate', data, {
+    const response = await api.post<Envelope<GenerateTasksResponse>>('/generate', data, {
         timeout: GENERATE_TIMEOUT_MS,
     });
-    return response.data;
+    return response.data.data;
+};
+
+export const getGenerateQuota = async (): Promise<GenerateQuotaResponse> => {
+    const response = await api.get<Envelope<GenerateQuotaResponse>>('/generate/quota');
+    return response.data.data;
 };
 
 /**
@@ -244,11 +321,11 @@ export const generateCategoryLabels = async (
     categoryName: string,
     languages?: string[]
 ): Promise<GenerateCategoryLabelsResponse> => {
-    const response = await api.post<GenerateCategoryLabelsResponse>('/generate/category-labels', {
+    const response = await api.post<Envelope<GenerateCategoryLabelsResponse>>('/generate/category-labels', {
         category_name: categoryName,
         languages,
     });
-    return response.data;
+    return response.data.data;
 };
 
 export default api;
@@ -32,9 +32,12 @@ export interface Category {
     emoji: string;
     age_group: AgeGroup;
     label: MultilingualText;
+    instructions?: MultilingualText;
     requires_consent: boolean;
     is_active: boolean;
     sort_order: number;
+    license?: string;
+    attribution?: string;
     created_at: string;
     updated_at: string;
 }
@@ -42,11 +45,16 @@ export interface Category {
 // Task type
 export interface Task {
     id: string;
+    short_code?: string;
     category_id: string;
     category?: Category;
     type: TaskType;
     text: string;
     language: Language;
+    requires_consent: boolean;
+    license?: string;
+    attribution?: string;
+    machine_languages?: Language[];
     created_at: string;
     updated_at: string;
 }
@@ -56,9 +64,12 @@ export interface CreateCategoryDto {
     emoji: string;
     age_group: AgeGroup;
     label: MultilingualText;
+    instructions?: MultilingualText;
     requires_consent: boolean;
     is_active: boolean;
     sort_order: number;
+    license?: string;
+    attribution?: string;
 }
 
 export interface CreateTaskDto {
@@ -66,6 +77,9 @@ export interface CreateTaskDto {
     type: TaskType;
     text: string;
     language: Language;
+    requires_consent?: boolean;
+    license?: string;
+    attribution?: string;
 }
 
 // API response types
@@ -82,6 +96,13 @@ export interface ErrorResponse {
     message: string;
 }
 
+// Every v2 response comes in this envelope: data on success, error on failure
+export interface Envelope<T> {
+    data: T;
+    error: { code: string; message: string; fields?: { field: string; message: string }[]; details?: Record<string, unknown> } | null;
+    meta: Record<string, unknown>;
+}
+
 export interface SuccessResponse {
     success: boolean;
     message: string;
@@ -95,6 +116,77 @@ export interface GenerateTasksResponse {
     total_truths_count: number;
     total_dares_count: number;
     combinations_count: number;
+    run_id: string;
+}
+
+// Generation quota of the calling admin key today (UTC); limits of 0 are disabled
+export interface GenerateQuotaResponse {
+    runs: number;
+    run_limit: number;
+    requested: number;
+    requested_limit: number;
+    resets_at: string;
+}
+
+// Signed URL for embedding a read-only route on a third-party page
+export interface SignedURLResponse {
+    /** Path and query relative to the API host, with expires and signature */
+    url: string;
+    expires_at: string;
+}
+
+// Slack workspace, Discord server or Telegram group allowed to draw tasks, locked to one age group
+export type ChatPlatform = 'slack' | 'discord' | 'telegram';
+
+export interface ChatWorkspace {
+    id: string;
+    platform: ChatPlatform;
+    /** Slack team ID, Discord guild ID or Telegram chat ID */
+    workspace_id: string;
+    name?: string;
+    age_group: AgeGroup;
+    language: Language;
+    /** Draws are limited to these categories; empty allows all */
+    category_ids?: string[];
+    /** Draws only from categories for kids */
+    safe_mode: boolean;
+    is_active: boolean;
+    created_at: string;
+    updated_at: string;
+}
+
+export interface ChatWorkspaceDto {
+    platform: ChatPlatform;
+    workspace_id: string;
+    name?: string;
+    age_group: AgeGroup;
+    language?: Language;
+    category_ids?: string[];
+    safe_mode?: boolean;
+    is_active?: boolean;
+}
+
+// Admin search response - matches grouped by kind
+export interface SearchResponse {
+    query: string;
+    categories: (Category & { matched_languages: Language[] })[];
+    tasks: (Task & { matched_field: 'id' | 'text' | 'hint'; matched_language: Language })[];
+}
+
+// Glossary term - approved translation per language; missing languages keep the term
+export interface GlossaryTerm {
+    id: string;
+    term: string;
+    translations: MultilingualText;
+    note?: string;
+    created_at: string;
+    updated_at: string;
+}
+
+export interface GlossaryTermDto {
+    term: string;
+    translations: MultilingualText;
+    note?: string;
 }
 
 // Generate request type - null values mean "all"
@@ -1,20 +1,30 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/truthordare/backend/internal/config"
 	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/encryption"
+	"github.com/truthordare/backend/internal/errtrack"
 	"github.com/truthordare/backend/internal/scheduler"
 	"github.com/truthordare/backend/internal/server"
+	"github.com/truthordare/backend/internal/version"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "Run database migrations and seeding, then exit")
+	anonymizeTo := flag.String("anonymize", "", "Write an anonymized copy of the database to this path for staging, then exit")
+	rotateEncryption := flag.Bool("rotate-encryption", false, "Re-encrypt encrypted columns with the first ENCRYPTION_KEYS key, then exit")
+	flag.Parse()
+
 	// Load .env file if exists
 	_ = godotenv.Load()
 
@@ -27,20 +37,72 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
 
+	// Report panics, server errors and job failures when configured
+	tracker, err := errtrack.New(cfg.ErrorTrackingDSN, cfg.Env, version.Version)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error tracking disabled")
+	}
+	errtrack.SetDefault(tracker)
+
+	// Keys for encrypted columns
+	keyring, err := encryption.Load(cfg.EncryptionKeys, cfg.EncryptionKeysFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load encryption keys")
+	}
+	encryption.SetDefault(keyring)
+
 	// Initialize database
 	db, err := database.Initialize(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize database")
 	}
 
-	// Run migrations
-	if err := database.Migrate(db); err != nil {
-		log.Fatal().Err(err).Msg("Failed to run migrations")
+	// Copy the database with client data scrubbed, without migrating it
+	if *anonymizeTo != "" {
+		if _, err := database.Anonymize(db, *anonymizeTo); err != nil {
+			log.Fatal().Err(err).Msg("Failed to anonymize database")
+		}
+		return
+	}
+
+	// Run migrations, one instance at a time
+	if *migrateOnly || cfg.Database.AutoMigrate {
+		lockTimeout := time.Duration(cfg.Database.MigrationLockTimeoutSeconds) * time.Second
+		err := database.WithMigrationLock(db, lockTimeout, func() error {
+			if err := database.Migrate(db); err != nil {
+				return err
+			}
+
+			// Seed initial data if needed
+			if err := database.Seed(db); err != nil {
+				log.Warn().Err(err).Msg("Failed to seed database")
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to run migrations")
+		}
+	}
+
+	// Move every encrypted value to the primary key, so old keys can be
+	// dropped
+	if *rotateEncryption {
+		changed, err := database.RotateEncryption(db, keyring)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to rotate encryption keys")
+		}
+		log.Info().Interface("changed", changed).Str("key", keyring.Primary()).Msg("Encryption keys rotated, exiting (--rotate-encryption)")
+		return
+	}
+
+	if *migrateOnly {
+		log.Info().Msg("Migrations complete, exiting (--migrate-only)")
+		return
 	}
 
-	// Seed initial data if needed
-	if err := database.Seed(db); err != nil {
-		log.Warn().Err(err).Msg("Failed to seed database")
+	// Refuse to serve against a schema this build does not understand
+	if err := database.Preflight(db); err != nil {
+		log.Fatal().Err(err).Msg("Pre-flight checks failed")
 	}
 
 	// Setup and start scheduler
@@ -64,11 +126,14 @@ func main() {
 		<-ctx.Done()
 
 		log.Info().Msg("Scheduler stopped")
+
+		if err := srv.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to flush task serve counts")
+		}
+		errtrack.Flush(5 * time.Second)
 		os.Exit(0)
 	}()
 
-	log.Info().Str("port", cfg.Port).Msg("Starting server")
-
 	if err := srv.Start(); err != nil {
 		log.Fatal().Err(err).Msg("Server failed to start")
 	}
@@ -80,6 +145,9 @@ func setupLogger() {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
+	// Tag every log line with the build, to tell versions apart during rollouts
+	log.Logger = log.With().Str("version", version.Version).Logger()
+
 	// Set log level
 	level := os.Getenv("LOG_LEVEL")
 	switch level {
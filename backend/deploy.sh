@@ -37,8 +37,13 @@ fi
 export DOCKER_BUILDKIT=1
 export COMPOSE_DOCKER_CLI_BUILD=1
 
-echo "🏗️  Building Docker image..."
-sudo docker-compose build --progress=plain
+# Build information baked into the binary
+export COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+export VERSION=${VERSION:-$(git describe --tags --always 2>/dev/null || echo dev)}
+export BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+
+echo "🏗️  Building Docker image ($VERSION, $COMMIT)..."
+sudo -E docker-compose build --progress=plain
 
 echo ""
 echo "🚀 Starting container..."
@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the provider while the
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("AI provider unavailable: circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests through
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe request through after the cooldown
+	BreakerHalfOpen
+	// BreakerOpen rejects requests until the cooldown has passed
+	BreakerOpen
+)
+
+// String returns the state name used in health checks and logs
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerStatus is a point-in-time view of a circuit breaker
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// CircuitBreaker stops calling the provider after a run of consecutive
+// failures. After the cooldown one probe request is allowed through; its
+// outcome closes the breaker again or restarts the cooldown.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold consecutive
+// failures. A threshold of zero or less returns nil, which never trips.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a request may be sent now. It returns an error
+// wrapping ErrCircuitOpen while the breaker is open or a probe is in flight.
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		remaining := b.openedAt.Add(b.cooldown).Sub(b.now())
+		if remaining > 0 {
+			return fmt.Errorf("%w; retry in %s", ErrCircuitOpen, remaining.Round(time.Second))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w; recovery probe in progress", ErrCircuitOpen)
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success records a successful request and closes the breaker
+func (b *CircuitBreaker) Success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed request, opening the breaker when the threshold
+// is reached or when a half-open probe fails
+func (b *CircuitBreaker) Failure() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Abandon records a request the caller cancelled. It frees a half-open probe
+// slot without counting as a success or a failure.
+func (b *CircuitBreaker) Abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.probing {
+		b.probing = false
+		b.state = BreakerOpen
+	}
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Status returns the current breaker state with failure details
+func (b *CircuitBreaker) Status() BreakerStatus {
+	if b == nil {
+		return BreakerStatus{State: BreakerClosed.String()}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state == BreakerOpen {
+		until := b.openedAt.Add(b.cooldown)
+		status.OpenUntil = &until
+	}
+	return status
+}
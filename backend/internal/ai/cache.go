@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache status values reported to API callers
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// defaultCacheMaxEntries bounds memory use of the response cache
+const defaultCacheMaxEntries = 1000
+
+// ResponseCache is an in-memory TTL cache of completion contents
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	content   string
+	expiresAt time.Time
+}
+
+// NewResponseCache creates a cache that keeps entries for ttl.
+// A zero ttl returns nil, which disables caching.
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &ResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the cached content for key if present and not expired
+func (c *ResponseCache) Get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.content, true
+}
+
+// Set stores content under key, evicting expired entries when full
+func (c *ResponseCache) Set(key, content string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	c.entries[key] = cacheEntry{content: content, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *ResponseCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear drops every entry
+func (c *ResponseCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+// evictLocked drops expired entries, or the entry closest to expiry if none
+// have expired. The caller must hold c.mu.
+func (c *ResponseCache) evictLocked() {
+	now := time.Now()
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// cacheKey combines the prompt identity with the sampling parameters that
+// change the response
+func cacheKey(promptKey string, req CompletionRequest) string {
+	h := sha256.New()
+	h.Write([]byte(promptKey))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatFloat(req.Temperature, 'g', -1, 64)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(req.MaxTokens)))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with -record and GROQ_API_KEY set to re-record the cassettes marked
+// live from the real provider. Failure cassettes are written by hand.
+var record = flag.Bool("record", false, "re-record live cassettes in testdata/cassettes from the provider")
+
+// cassette is a recorded exchange with the completions endpoint, replayed
+// in order by newCassetteServer
+type cassette struct {
+	Description  string        `json:"description"`
+	Live         bool          `json:"live,omitempty"` // Recorded from the provider; re-recorded with -record
+	Interactions []interaction `json:"interactions"`
+}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+// recordedRequest holds the parts of a request that are checked on replay
+type recordedRequest struct {
+	Model string `json:"model"`
+}
+
+type recordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body"`
+	DelayMS int               `json:"delay_ms,omitempty"` // Stalls the reply to exercise timeouts
+}
+
+// cassetteServer replays a cassette and counts the requests it served
+type cassetteServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	cassette cassette
+	served   int
+}
+
+// Calls returns the number of requests the server received
+func (s *cassetteServer) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.served
+}
+
+// newCassetteServer serves testdata/cassettes/<name>.json. Requests beyond
+// the recorded interactions, or for another model, fail the test.
+func newCassetteServer(t *testing.T, name string) *cassetteServer {
+	t.Helper()
+
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+	s := &cassetteServer{}
+	if err := json.Unmarshal(data, &s.cassette); err != nil {
+		t.Fatalf("Failed to parse cassette %s: %v", name, err)
+	}
+
+	if *record && s.cassette.Live {
+		return s.startRecording(t, path)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Cassette %s: request body is not a completion request: %v", name, err)
+		}
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("Cassette %s: request without Authorization header", name)
+		}
+
+		s.mu.Lock()
+		i := s.served
+		s.served++
+		s.mu.Unlock()
+		if i >= len(s.cassette.Interactions) {
+			t.Errorf("Cassette %s: unexpected request %d, only %d recorded", name, i+1, len(s.cassette.Interactions))
+			http.Error(w, "cassette exhausted", http.StatusInternalServerError)
+			return
+		}
+
+		it := s.cassette.Interactions[i]
+		if it.Request.Model != "" && req.Model != it.Request.Model {
+			t.Errorf("Cassette %s: request %d for model %q, recorded %q", name, i+1, req.Model, it.Request.Model)
+		}
+		if it.Response.DelayMS > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Duration(it.Response.DelayMS) * time.Millisecond):
+			}
+		}
+		for key, value := range it.Response.Headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(it.Response.Status)
+		w.Write(it.Response.Body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// startRecording proxies requests to the provider and rewrites the cassette
+// with the exchanges once the test ends. The API key is never written.
+func (s *cassetteServer) startRecording(t *testing.T, path string) *cassetteServer {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	if cfg.APIKey == "" {
+		t.Skip("GROQ_API_KEY is required to record cassettes")
+	}
+	s.cassette.Interactions = nil
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req CompletionRequest
+		_ = json.Unmarshal(body, &req)
+
+		upstream, err := http.NewRequestWithContext(r.Context(), "POST", cfg.APIURL, bytes.NewReader(body))
+		if err != nil {
+			t.Errorf("Failed to create upstream request: %v", err)
+			return
+		}
+		upstream.Header.Set("Content-Type", "application/json")
+		upstream.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+		resp, err := http.DefaultClient.Do(upstream)
+		if err != nil {
+			t.Errorf("Upstream request failed: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+
+		recorded := recordedResponse{Status: resp.StatusCode, Body: respBody}
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			recorded.Headers = map[string]string{"Retry-After": retryAfter}
+		}
+		s.mu.Lock()
+		s.served++
+		s.cassette.Interactions = append(s.cassette.Interactions, interaction{
+			Request:  recordedRequest{Model: req.Model},
+			Response: recorded,
+		})
+		s.mu.Unlock()
+
+		w.WriteHeader(resp.StatusCode)
+		w.Write(respBody)
+	}))
+
+	t.Cleanup(func() {
+		s.Close()
+		data, err := json.MarshalIndent(s.cassette, "", "  ")
+		if err != nil {
+			t.Errorf("Failed to encode cassette: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Errorf("Failed to write cassette: %v", err)
+		}
+	})
+	return s
+}
@@ -10,29 +10,79 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/truthordare/backend/internal/chaos"
+	"github.com/truthordare/backend/internal/metrics"
+	"github.com/truthordare/backend/internal/prompts"
+)
+
+// Per-request sampling limits accepted by the provider
+const (
+	MinTemperature = 0.0
+	MaxTemperature = 2.0
+	MaxMaxTokens   = 8000
 )
 
+// maxRetryAfter is the longest Retry-After CompleteJSON waits out before
+// retrying; a provider asking for longer fails the call instead
+const maxRetryAfter = 30 * time.Second
+
 // Client represents an AI API client
 type Client struct {
-	apiKey     string
-	apiURL     string
-	model      string
-	httpClient *http.Client
+	mock          *MockProvider // Set when AI_PROVIDER=mock; replaces HTTP calls
+	apiKey        string
+	apiURL        string
+	model         string
+	allowedModels []string
+	cache         *ResponseCache
+	breaker       *CircuitBreaker
+	limiter       *RateLimiter
+	timeout       time.Duration
+	retryBackoff  time.Duration
+	httpClient    *http.Client
+	chaos         atomic.Pointer[chaos.Injector] // Development fault injection, nil when off
 }
 
 // ClientConfig holds configuration for creating an AI client
 type ClientConfig struct {
-	APIKey  string        // API key for authentication
-	APIURL  string        // Base URL for the API
-	Model   string        // Model to use for completions
-	Timeout time.Duration // HTTP client timeout
+	Provider      string        // "groq" (any OpenAI-compatible API) or "mock"
+	MockFixtures  string        // Directory of <template>.json responses for the mock provider
+	APIKey        string        // API key for authentication
+	APIURL        string        // Base URL for the API
+	Model         string        // Model to use for completions
+	AllowedModels []string      // Extra models callers may request per completion
+	CacheTTL      time.Duration // How long cached responses are reused; 0 disables caching
+	Timeout       time.Duration // Default per-request timeout, overridable with WithTimeout
+	RetryBackoff  time.Duration // Delay before a CompleteJSON retry, times the attempt; defaults to 1s
+
+	BreakerThreshold int           // Consecutive failures before the circuit opens; 0 disables the breaker
+	BreakerCooldown  time.Duration // How long the circuit stays open before a probe
+
+	RequestsPerMinute int // Provider calls allowed per minute across all callers; 0 is unlimited
+	TokensPerMinute   int // Provider tokens allowed per minute across all callers; 0 is unlimited
+}
+
+// APIError is returned when the provider responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, 0 when absent
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("AI API error (status %d): %s", e.StatusCode, e.Body)
 }
 
 // Message represents a chat message
@@ -47,6 +97,17 @@ type CompletionRequest struct {
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float64   `json:"temperature,omitempty"`
+
+	ctx     context.Context // Cancels the request and retry backoff
+	timeout time.Duration   // Caps a single HTTP attempt
+	prompt  promptRef       // Template the messages were rendered from
+	usage   *Usage          // Accumulates the tokens of every attempt
+}
+
+// promptRef names the prompt template and values behind a request
+type promptRef struct {
+	Name   string
+	Values map[string]string
 }
 
 // CompletionResponse represents the API response
@@ -59,11 +120,21 @@ type CompletionResponse struct {
 		Index   int     `json:"index"`
 		Message Message `json:"message"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage Usage `json:"usage"`
+}
+
+// Usage counts the tokens spent on completions
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add adds the tokens of other to u
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
 }
 
 var (
@@ -71,6 +142,18 @@ var (
 	clientOnce    sync.Once
 )
 
+var (
+	aiRequests = metrics.NewCounter("tod_ai_requests_total",
+		"AI completion requests by result (success, failure, rejected)")
+	_ = metrics.NewGaugeFunc("tod_ai_circuit_state",
+		"AI provider circuit breaker state (0 closed, 1 half-open, 2 open)",
+		func() float64 { return float64(GetClient().breaker.State()) })
+	aiRateLimitWaits = metrics.NewCounter("tod_ai_rate_limit_waits_total",
+		"AI completion requests delayed by the rate limiter")
+	aiRateLimitWaitSeconds = metrics.NewCounter("tod_ai_rate_limit_wait_seconds_total",
+		"Time AI completion requests were delayed by the rate limiter")
+)
+
 func DefaultConfig() ClientConfig {
 	apiKey := os.Getenv("GROQ_API_KEY")
 
@@ -84,11 +167,55 @@ func DefaultConfig() ClientConfig {
 		model = "llama-3.3-70b-versatile"
 	}
 
+	var allowedModels []string
+	for _, m := range strings.Split(os.Getenv("GROQ_ALLOWED_MODELS"), ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			allowedModels = append(allowedModels, m)
+		}
+	}
+
+	cacheTTL := time.Hour
+	if v, err := strconv.Atoi(os.Getenv("AI_CACHE_TTL_SECONDS")); err == nil && v >= 0 {
+		cacheTTL = time.Duration(v) * time.Second
+	}
+
+	breakerThreshold := 5
+	if v, err := strconv.Atoi(os.Getenv("AI_BREAKER_THRESHOLD")); err == nil {
+		breakerThreshold = v
+	}
+	breakerCooldown := 30 * time.Second
+	if v, err := strconv.Atoi(os.Getenv("AI_BREAKER_COOLDOWN_SECONDS")); err == nil && v > 0 {
+		breakerCooldown = time.Duration(v) * time.Second
+	}
+
+	requestsPerMinute := 0
+	if v, err := strconv.Atoi(os.Getenv("AI_REQUESTS_PER_MINUTE")); err == nil && v >= 0 {
+		requestsPerMinute = v
+	}
+	tokensPerMinute := 0
+	if v, err := strconv.Atoi(os.Getenv("AI_TOKENS_PER_MINUTE")); err == nil && v >= 0 {
+		tokensPerMinute = v
+	}
+
+	provider := os.Getenv("AI_PROVIDER")
+	if provider == "" {
+		provider = ProviderGroq
+	}
+
 	return ClientConfig{
-		APIKey:  apiKey,
-		APIURL:  apiURL,
-		Model:   model,
-		Timeout: 120 * time.Second, // Increased for slower networks
+		Provider:         provider,
+		MockFixtures:     os.Getenv("AI_MOCK_FIXTURES"),
+		APIKey:           apiKey,
+		APIURL:           apiURL,
+		Model:            model,
+		AllowedModels:    allowedModels,
+		CacheTTL:         cacheTTL,
+		Timeout:          120 * time.Second, // Increased for slower networks
+		BreakerThreshold: breakerThreshold,
+		BreakerCooldown:  breakerCooldown,
+
+		RequestsPerMinute: requestsPerMinute,
+		TokensPerMinute:   tokensPerMinute,
 	}
 }
 
@@ -99,13 +226,35 @@ func NewClient(config ClientConfig) *Client {
 		timeout = 60 * time.Second
 	}
 
+	retryBackoff := config.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = time.Second
+	}
+
+	var mock *MockProvider
+	limiter := NewRateLimiter(config.RequestsPerMinute, config.TokensPerMinute)
+	if config.Provider == ProviderMock {
+		// The mock provider has no limits to respect
+		limiter = nil
+		mock = &MockProvider{FixturesDir: config.MockFixtures}
+		if config.Model == "" {
+			config.Model = mockModel
+		}
+	}
+
 	return &Client{
-		apiKey: config.APIKey,
-		apiURL: config.APIURL,
-		model:  config.Model,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
+		mock:          mock,
+		timeout:       timeout,
+		retryBackoff:  retryBackoff,
+		apiKey:        config.APIKey,
+		apiURL:        config.APIURL,
+		model:         config.Model,
+		allowedModels: config.AllowedModels,
+		cache:         NewResponseCache(config.CacheTTL, defaultCacheMaxEntries),
+		breaker:       NewCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
+		limiter:       limiter,
+		// Timeouts are applied per request through the context
+		httpClient: &http.Client{},
 	}
 }
 
@@ -117,9 +266,72 @@ func GetClient() *Client {
 	return defaultClient
 }
 
-// IsConfigured returns true if the client has a valid API key
+// IsConfigured returns true if the client has a valid API key or uses the mock provider
 func (c *Client) IsConfigured() bool {
-	return c.apiKey != ""
+	return c.mock != nil || c.apiKey != ""
+}
+
+// Ping checks that the provider is reachable and accepts the API key by
+// listing its models, which spends no tokens. It returns the provider's
+// clock from the Date header, zero when absent. The mock provider always
+// answers.
+func (c *Client) Ping(ctx context.Context) (time.Time, error) {
+	if c.mock != nil {
+		return time.Time{}, nil
+	}
+
+	// OpenAI-compatible APIs list models next to chat completions
+	modelsURL := strings.TrimSuffix(c.apiURL, "/chat/completions") + "/models"
+	req, err := http.NewRequestWithContext(ctx, "GET", modelsURL, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	date, _ := http.ParseTime(resp.Header.Get("Date"))
+	if resp.StatusCode != http.StatusOK {
+		return date, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return date, nil
+}
+
+// IsMock returns true if completions come from the mock provider
+func (c *Client) IsMock() bool {
+	return c.mock != nil
+}
+
+// Model returns the default model used for completions
+func (c *Client) Model() string {
+	return c.model
+}
+
+// AllowedModels returns the models that may be requested with WithModel,
+// starting with the default model
+func (c *Client) AllowedModels() []string {
+	models := []string{c.model}
+	for _, m := range c.allowedModels {
+		if m != c.model {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// IsModelAllowed reports whether a model may be requested with WithModel
+func (c *Client) IsModelAllowed(model string) bool {
+	for _, m := range c.AllowedModels() {
+		if m == model {
+			return true
+		}
+	}
+	return false
 }
 
 // Complete sends a chat completion request and returns the response
@@ -128,20 +340,94 @@ func (c *Client) Complete(messages []Message, opts ...CompletionOption) (*Comple
 		return nil, fmt.Errorf("AI client not configured: missing API key")
 	}
 
-	// Build request with defaults
+	if err := c.breaker.Allow(); err != nil {
+		aiRequests.IncWith(metrics.Labels{"result": "rejected"})
+		return nil, err
+	}
+
+	req := c.buildRequest(messages, opts)
+	estimate := estimateTokens(req)
+	if err := c.limiter.Wait(req.ctx, estimate); err != nil {
+		// Given up while queued; the provider was never asked
+		c.breaker.Abandon()
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	used := 0
+	if resp != nil {
+		used = resp.Usage.TotalTokens
+	}
+	c.limiter.Settle(estimate, used)
+	if resp != nil && req.usage != nil {
+		req.usage.Add(resp.Usage)
+	}
+	if errors.Is(err, context.Canceled) {
+		// The caller gave up; says nothing about provider health
+		c.breaker.Abandon()
+		return nil, err
+	}
+	if err != nil && isProviderFailure(err) {
+		c.breaker.Failure()
+		aiRequests.IncWith(metrics.Labels{"result": "failure"})
+		return nil, err
+	}
+
+	// Any other response, including a 4xx for a bad request, shows the provider is up
+	c.breaker.Success()
+	aiRequests.IncWith(metrics.Labels{"result": "success"})
+	return resp, err
+}
+
+// SetChaos injects the faults rolled by injector into provider calls, for
+// resilience testing in development: latency, 503 errors, and dropped
+// connections reported as network errors. nil switches injection off.
+func (c *Client) SetChaos(injector *chaos.Injector) {
+	c.chaos.Store(injector)
+}
+
+// BreakerStatus returns the state of the client's circuit breaker
+func (c *Client) BreakerStatus() BreakerStatus {
+	return c.breaker.Status()
+}
+
+// RateLimitStatus returns the limits of the client's rate limiter and what
+// is left of them
+func (c *Client) RateLimitStatus() RateLimitStatus {
+	return c.limiter.Status()
+}
+
+// ClearCache drops every cached response
+func (c *Client) ClearCache() {
+	c.cache.Clear()
+}
+
+// isProviderFailure reports whether err means the provider is unhealthy:
+// network errors, timeouts, rate limiting and 5xx responses
+func isProviderFailure(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// buildRequest applies the options on top of the client defaults
+func (c *Client) buildRequest(messages []Message, opts []CompletionOption) CompletionRequest {
 	req := CompletionRequest{
 		Model:       c.model,
 		Messages:    messages,
 		MaxTokens:   2000,
 		Temperature: 0.7,
+		ctx:         context.Background(),
+		timeout:     c.timeout,
 	}
 
-	// Apply options
 	for _, opt := range opts {
 		opt(&req)
 	}
 
-	return c.doRequest(req)
+	return req
 }
 
 // CompleteWithSystem is a convenience method that sends a system prompt and user message
@@ -165,13 +451,21 @@ func (c *Client) CompleteJSON(messages []Message, target interface{}, opts ...Co
 	maxRetries := 3
 	var lastErr error
 	var lastContent string
+	ctx := c.buildRequest(messages, opts).ctx
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		resp, err := c.Complete(messages, opts...)
 		if err != nil {
+			// Fail fast instead of sleeping through retries the breaker will reject
+			if errors.Is(err, ErrCircuitOpen) || ctx.Err() != nil {
+				return err
+			}
 			lastErr = err
-			if attempt < maxRetries {
-				time.Sleep(time.Duration(attempt) * time.Second) // Backoff: 1s, 2s, 3s
+			delay, ok := c.retryDelay(err, attempt)
+			if attempt < maxRetries && ok {
+				if err := sleepContext(ctx, delay); err != nil {
+					return err
+				}
 				continue
 			}
 			return err
@@ -183,7 +477,9 @@ func (c *Client) CompleteJSON(messages []Message, target interface{}, opts ...Co
 		if err := json.Unmarshal([]byte(content), target); err != nil {
 			lastErr = fmt.Errorf("failed to parse AI response as JSON: %w (attempt %d/%d)", err, attempt, maxRetries)
 			if attempt < maxRetries {
-				time.Sleep(time.Duration(attempt) * time.Second)
+				if err := sleepContext(ctx, time.Duration(attempt)*c.retryBackoff); err != nil {
+					return err
+				}
 				continue
 			}
 			return fmt.Errorf("%w (content: %s)", lastErr, content)
@@ -196,14 +492,69 @@ func (c *Client) CompleteJSON(messages []Message, target interface{}, opts ...Co
 	return fmt.Errorf("%w (final content: %s)", lastErr, lastContent)
 }
 
-// doRequest performs the actual HTTP request
+// retryDelay returns how long to wait before retrying after err: the
+// backoff for the attempt (1s, 2s with the default), or the provider's
+// Retry-After when longer. ok is false when the provider asks to wait
+// longer than maxRetryAfter.
+func (c *Client) retryDelay(err error, attempt int) (delay time.Duration, ok bool) {
+	delay = time.Duration(attempt) * c.retryBackoff
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+		if apiErr.RetryAfter > maxRetryAfter {
+			return 0, false
+		}
+		delay = apiErr.RetryAfter
+	}
+	return delay, true
+}
+
+// CompleteJSONCached behaves like CompleteJSON but reuses a previous response
+// for the same prompt and sampling parameters while it is within the cache TTL.
+// promptKey identifies the rendered prompt, usually via prompts.Key.
+// The returned status is CacheHit or CacheMiss.
+func (c *Client) CompleteJSONCached(promptKey string, messages []Message, target interface{}, opts ...CompletionOption) (string, error) {
+	key := cacheKey(promptKey, c.buildRequest(messages, opts))
+
+	if content, ok := c.cache.Get(key); ok {
+		if err := json.Unmarshal([]byte(content), target); err == nil {
+			return CacheHit, nil
+		}
+	}
+
+	var raw json.RawMessage
+	if err := c.CompleteJSON(messages, &raw, opts...); err != nil {
+		return CacheMiss, err
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return CacheMiss, fmt.Errorf("failed to parse AI response as JSON: %w", err)
+	}
+
+	c.cache.Set(key, string(raw))
+	return CacheMiss, nil
+}
+
+// doRequest performs the actual HTTP request, or asks the mock provider
 func (c *Client) doRequest(req CompletionRequest) (*CompletionResponse, error) {
+	ctx := req.ctx
+	if req.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.timeout)
+		defer cancel()
+	}
+
+	if err := c.injectFault(ctx); err != nil {
+		return nil, err
+	}
+	if c.mock != nil {
+		return c.mock.Complete(req)
+	}
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -223,7 +574,11 @@ func (c *Client) doRequest(req CompletionRequest) (*CompletionResponse, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("AI API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	var completionResp CompletionResponse
@@ -234,6 +589,54 @@ func (c *Client) doRequest(req CompletionRequest) (*CompletionResponse, error) {
 	return &completionResp, nil
 }
 
+// injectFault applies the chaos fault rolled for a provider call, if any
+func (c *Client) injectFault(ctx context.Context) error {
+	fault := c.chaos.Load().Pick()
+	if fault.Delay > 0 {
+		if err := sleepContext(ctx, fault.Delay); err != nil {
+			return fmt.Errorf("HTTP request failed: %w", err)
+		}
+	}
+	switch {
+	case fault.Drop:
+		return fmt.Errorf("HTTP request failed: %w", chaos.ErrInjected)
+	case fault.Error:
+		return &APIError{StatusCode: http.StatusServiceUnavailable, Body: chaos.ErrInjected.Error()}
+	}
+	return nil
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+// It returns 0 when the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // GetContent returns the content from the first choice
 func (r *CompletionResponse) GetContent() string {
 	if len(r.Choices) == 0 {
@@ -265,3 +668,39 @@ func WithModel(model string) CompletionOption {
 		r.Model = model
 	}
 }
+
+// WithContext makes the request, and any retry backoff, stop when ctx is done
+func WithContext(ctx context.Context) CompletionOption {
+	return func(r *CompletionRequest) {
+		r.ctx = ctx
+	}
+}
+
+// WithTimeout caps a single HTTP attempt at d instead of the client default.
+// Retries in CompleteJSON each get their own timeout; use WithContext to
+// bound the whole call.
+func WithTimeout(d time.Duration) CompletionOption {
+	return func(r *CompletionRequest) {
+		r.timeout = d
+	}
+}
+
+// WithUsage adds the tokens spent on the request to u, including those of
+// retried attempts. u is not safe for concurrent requests.
+func WithUsage(u *Usage) CompletionOption {
+	return func(r *CompletionRequest) {
+		r.usage = u
+	}
+}
+
+// WithPrompt records the template and placeholder values the messages were
+// rendered from. The mock provider uses them to build its response.
+func WithPrompt(name string, placeholders ...prompts.Placeholder) CompletionOption {
+	values := make(map[string]string, len(placeholders))
+	for _, p := range placeholders {
+		values[p.Key] = p.Value
+	}
+	return func(r *CompletionRequest) {
+		r.prompt = promptRef{Name: name, Values: values}
+	}
+}
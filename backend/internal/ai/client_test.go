@@ -0,0 +1,451 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/chaos"
+	"github.com/truthordare/backend/internal/prompts"
+)
+
+// newTestServer returns a fake completions endpoint that replies with content
+// and counts the requests it receives
+func newTestServer(t *testing.T, content string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		resp := CompletionResponse{}
+		resp.Choices = append(resp.Choices, struct {
+			Index   int     `json:"index"`
+			Message Message `json:"message"`
+		}{Message: Message{Role: "assistant", Content: content}})
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func TestClient_CompleteJSONCached(t *testing.T) {
+	srv, calls := newTestServer(t, `{"en": "Travel"}`)
+	client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, Model: "base", CacheTTL: time.Minute})
+
+	messages := []Message{{Role: "user", Content: "translate"}}
+
+	var first map[string]string
+	status, err := client.CompleteJSONCached("labels\x00travel", messages, &first)
+	require.NoError(t, err)
+	assert.Equal(t, CacheMiss, status)
+	assert.Equal(t, "Travel", first["en"])
+
+	var second map[string]string
+	status, err = client.CompleteJSONCached("labels\x00travel", messages, &second)
+	require.NoError(t, err)
+	assert.Equal(t, CacheHit, status)
+	assert.Equal(t, first, second)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+
+	t.Run("different temperature misses", func(t *testing.T) {
+		var out map[string]string
+		status, err := client.CompleteJSONCached("labels\x00travel", messages, &out, WithTemperature(0.1))
+		require.NoError(t, err)
+		assert.Equal(t, CacheMiss, status)
+	})
+
+	t.Run("different prompt misses", func(t *testing.T) {
+		var out map[string]string
+		status, err := client.CompleteJSONCached("labels\x00food", messages, &out)
+		require.NoError(t, err)
+		assert.Equal(t, CacheMiss, status)
+	})
+
+	t.Run("cleared cache misses", func(t *testing.T) {
+		client.ClearCache()
+		var out map[string]string
+		status, err := client.CompleteJSONCached("labels\x00travel", messages, &out)
+		require.NoError(t, err)
+		assert.Equal(t, CacheMiss, status)
+	})
+}
+
+func TestClient_CompleteJSONCached_Disabled(t *testing.T) {
+	srv, calls := newTestServer(t, `{"en": "Travel"}`)
+	client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, Model: "base"})
+
+	for i := 0; i < 2; i++ {
+		var out map[string]string
+		status, err := client.CompleteJSONCached("labels", nil, &out)
+		require.NoError(t, err)
+		assert.Equal(t, CacheMiss, status)
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestResponseCache_Expiry(t *testing.T) {
+	cache := NewResponseCache(10*time.Millisecond, 2)
+
+	cache.Set("a", "1")
+	content, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", content)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+}
+
+func TestResponseCache_Bounded(t *testing.T) {
+	cache := NewResponseCache(time.Minute, 2)
+
+	cache.Set("a", "1")
+	cache.Set("b", "2")
+	cache.Set("c", "3")
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok := cache.Get("c")
+	assert.True(t, ok)
+}
+
+func TestClient_IsModelAllowed(t *testing.T) {
+	client := NewClient(ClientConfig{Model: "base", AllowedModels: []string{"small", "base"}})
+
+	assert.True(t, client.IsModelAllowed("base"))
+	assert.True(t, client.IsModelAllowed("small"))
+	assert.False(t, client.IsModelAllowed("large"))
+	assert.Equal(t, []string{"base", "small"}, client.AllowedModels())
+}
+
+func TestClient_Ping(t *testing.T) {
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		if auth != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient(ClientConfig{APIKey: "good-key", APIURL: srv.URL + "/openai/v1/chat/completions"})
+	date, err := client.Ping(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "/openai/v1/models", path)
+	assert.WithinDuration(t, time.Now(), date, 2*time.Second, "the provider's clock")
+
+	client = NewClient(ClientConfig{APIKey: "bad-key", APIURL: srv.URL + "/openai/v1/chat/completions"})
+	_, err = client.Ping(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	require.NoError(t, breaker.Allow())
+	breaker.Failure()
+	assert.Equal(t, BreakerClosed, breaker.State())
+
+	require.NoError(t, breaker.Allow())
+	breaker.Failure()
+	assert.Equal(t, BreakerOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	t.Run("half-opens after cooldown with a single probe", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		require.NoError(t, breaker.Allow())
+		assert.Equal(t, BreakerHalfOpen, breaker.State())
+		assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	})
+
+	t.Run("failed probe reopens", func(t *testing.T) {
+		breaker.Failure()
+		assert.Equal(t, BreakerOpen, breaker.State())
+		assert.NotNil(t, breaker.Status().OpenUntil)
+	})
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		require.NoError(t, breaker.Allow())
+		breaker.Success()
+		assert.Equal(t, BreakerClosed, breaker.State())
+		assert.Equal(t, 0, breaker.Status().ConsecutiveFailures)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(2, 1000)
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, limiter.Wait(ctx, 400))
+	require.NoError(t, limiter.Wait(ctx, 400))
+	assert.Equal(t, 0, limiter.Status().RequestsAvailable)
+	assert.Equal(t, 200, limiter.Status().TokensAvailable)
+
+	t.Run("settling returns unused tokens", func(t *testing.T) {
+		limiter.Settle(400, 100)
+		assert.Equal(t, 500, limiter.Status().TokensAvailable)
+	})
+
+	t.Run("waits for the request bucket to refill", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, limiter.Wait(canceled, 100), context.Canceled)
+		// The abandoned call gives its share back
+		assert.Equal(t, 0, limiter.Status().RequestsAvailable)
+		assert.Equal(t, 500, limiter.Status().TokensAvailable)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+		status := limiter.Status()
+		assert.Equal(t, 1, status.RequestsAvailable)
+		assert.Equal(t, 1000, status.TokensAvailable, "buckets hold at most a minute's allowance")
+		require.NoError(t, limiter.Wait(ctx, 100))
+	})
+
+	t.Run("nil limiter is unlimited", func(t *testing.T) {
+		assert.Nil(t, NewRateLimiter(0, 0))
+		var unlimited *RateLimiter
+		assert.NoError(t, unlimited.Wait(ctx, 1<<20))
+		assert.Equal(t, RateLimitStatus{}, unlimited.Status())
+	})
+}
+
+func TestClient_RateLimitSharedAcrossCallers(t *testing.T) {
+	srv, calls := newTestServer(t, "hi")
+	client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, RequestsPerMinute: 1})
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	_, err := client.Complete(messages)
+	require.NoError(t, err)
+
+	// The second call has to wait a minute for its turn
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Complete(messages, WithContext(ctx))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls), "queued call must not reach the provider")
+	assert.Equal(t, "closed", client.BreakerStatus().State, "waiting is not a provider failure")
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient(ClientConfig{
+		APIKey:           "test",
+		APIURL:           srv.URL,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Complete(messages)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	}
+
+	_, err := client.Complete(messages)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls), "open circuit must not reach the provider")
+	assert.Equal(t, "open", client.BreakerStatus().State)
+
+	t.Run("client errors do not trip the breaker", func(t *testing.T) {
+		badRequest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad model", http.StatusBadRequest)
+		}))
+		t.Cleanup(badRequest.Close)
+
+		client := NewClient(ClientConfig{APIKey: "test", APIURL: badRequest.URL, BreakerThreshold: 1})
+		for i := 0; i < 3; i++ {
+			_, err := client.Complete(messages)
+			assert.NotErrorIs(t, err, ErrCircuitOpen)
+		}
+		assert.Equal(t, "closed", client.BreakerStatus().State)
+	})
+}
+
+func TestClient_Timeouts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	t.Run("per-request timeout", func(t *testing.T) {
+		client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, Timeout: time.Minute})
+
+		start := time.Now()
+		_, err := client.Complete(messages, WithTimeout(50*time.Millisecond))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("cancelled context stops retries", func(t *testing.T) {
+		client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, BreakerThreshold: 1})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		var out map[string]string
+		err := client.CompleteJSON(messages, &out, WithContext(ctx))
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("caller cancellation does not trip the breaker", func(t *testing.T) {
+		client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, BreakerThreshold: 1})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		_, err := client.Complete(messages, WithContext(ctx))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, "closed", client.BreakerStatus().State)
+	})
+}
+
+func TestClient_WithUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := CompletionResponse{Usage: Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}
+		resp.Choices = append(resp.Choices, struct {
+			Index   int     `json:"index"`
+			Message Message `json:"message"`
+		}{Message: Message{Role: "assistant", Content: `{"ok": true}`}})
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, Model: "base"})
+
+	var usage Usage
+	var out map[string]bool
+	require.NoError(t, client.CompleteJSON(nil, &out, WithUsage(&usage)))
+	require.NoError(t, client.CompleteJSON(nil, &out, WithUsage(&usage)))
+	assert.Equal(t, Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30}, usage)
+
+	t.Run("mock provider estimates usage", func(t *testing.T) {
+		client := NewClient(ClientConfig{Provider: ProviderMock})
+		var usage Usage
+		var out map[string]string
+		messages := []Message{{Role: "user", Content: "Translate the label"}}
+		require.NoError(t, client.CompleteJSON(messages, &out, WithUsage(&usage), WithPrompt("category_labels",
+			prompts.P("CATEGORY_NAME", "Party"), prompts.P("LANGUAGES", "en"))))
+		assert.Equal(t, 5, usage.PromptTokens)
+		assert.Positive(t, usage.CompletionTokens)
+		assert.Equal(t, usage.PromptTokens+usage.CompletionTokens, usage.TotalTokens)
+	})
+}
+
+func TestMockProvider(t *testing.T) {
+	client := NewClient(ClientConfig{Provider: ProviderMock})
+	require.True(t, client.IsConfigured())
+	require.True(t, client.IsMock())
+
+	t.Run("tasks", func(t *testing.T) {
+		var out struct {
+			Truths []string `json:"truths"`
+			Dares  []string `json:"dares"`
+		}
+		err := client.CompleteJSON(nil, &out, WithPrompt("generate_tasks",
+			prompts.P("COUNT", "3"), prompts.P("LANGUAGE", "hi"), prompts.P("CATEGORY", "Party")))
+		require.NoError(t, err)
+		assert.Len(t, out.Truths, 3)
+		assert.Len(t, out.Dares, 3)
+		assert.Equal(t, "[mock hi] Truth 1 about Party?", out.Truths[0])
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		var out map[string]string
+		err := client.CompleteJSON(nil, &out, WithPrompt("category_labels",
+			prompts.P("CATEGORY_NAME", "Party"), prompts.P("LANGUAGES", "en, hi")))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"en": "Party", "hi": "[hi] Party"}, out)
+	})
+
+	t.Run("hints", func(t *testing.T) {
+		var out struct {
+			Hints []struct {
+				ID   string            `json:"id"`
+				Hint map[string]string `json:"hint"`
+			} `json:"hints"`
+		}
+		err := client.CompleteJSON(nil, &out, WithPrompt("generate_hints",
+			prompts.P("TASKS", `[{"id":"t1","type":"truth","text":"Why?"}]`), prompts.P("LANGUAGES", "en")))
+		require.NoError(t, err)
+		require.Len(t, out.Hints, 1)
+		assert.Equal(t, "t1", out.Hints[0].ID)
+		assert.NotEmpty(t, out.Hints[0].Hint["en"])
+	})
+
+	t.Run("unknown prompt", func(t *testing.T) {
+		_, err := client.Complete(nil, WithPrompt("unknown"))
+		assert.Error(t, err)
+	})
+
+	t.Run("fixture file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "category_labels.json"), []byte(`{"en": "From fixture"}`), 0o644))
+
+		client := NewClient(ClientConfig{Provider: ProviderMock, MockFixtures: dir})
+		var out map[string]string
+		require.NoError(t, client.CompleteJSON(nil, &out, WithPrompt("category_labels")))
+		assert.Equal(t, "From fixture", out["en"])
+	})
+}
+
+func TestClient_Chaos(t *testing.T) {
+	srv, calls := newTestServer(t, `{"ok": true}`)
+	client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	messages := []Message{{Role: "user", Content: "hello"}}
+
+	client.SetChaos(chaos.New(chaos.Faults{ErrorRate: 1}))
+	_, err := client.Complete(messages)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	client.SetChaos(chaos.New(chaos.Faults{DropRate: 1}))
+	_, err = client.Complete(messages)
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+	assert.Equal(t, "open", client.BreakerStatus().State, "injected faults count as provider failures")
+	assert.EqualValues(t, 0, atomic.LoadInt32(calls))
+
+	t.Run("off", func(t *testing.T) {
+		client := NewClient(ClientConfig{APIKey: "test", APIURL: srv.URL})
+		client.SetChaos(nil)
+		_, err := client.Complete(messages)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+	})
+}
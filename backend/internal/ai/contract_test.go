@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Contract tests: the client against recorded provider exchanges in
+// testdata/cassettes, covering the failure modes retries and the circuit
+// breaker are built for.
+
+const cassetteModel = "llama-3.3-70b-versatile"
+
+func newCassetteClient(srv *cassetteServer, threshold int) *Client {
+	return NewClient(ClientConfig{
+		APIKey:           "test",
+		APIURL:           srv.URL,
+		Model:            cassetteModel,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: threshold,
+		BreakerCooldown:  time.Minute,
+	})
+}
+
+type generatedTasks struct {
+	Truths []string `json:"truths"`
+	Dares  []string `json:"dares"`
+}
+
+func TestContract_Success(t *testing.T) {
+	srv := newCassetteServer(t, "success")
+	client := newCassetteClient(srv, 5)
+
+	messages := []Message{
+		{Role: "system", Content: "You write truth or dare prompts. Answer with JSON only."},
+		{Role: "user", Content: `Write 2 truths and 2 dares for a party, as {"truths": [...], "dares": [...]}.`},
+	}
+	var usage Usage
+	var out generatedTasks
+	require.NoError(t, client.CompleteJSON(messages, &out, WithUsage(&usage)))
+	assert.Len(t, out.Truths, 2)
+	assert.Len(t, out.Dares, 2)
+	assert.Equal(t, 270, usage.TotalTokens)
+	assert.Equal(t, 1, srv.Calls())
+	assert.Equal(t, "closed", client.BreakerStatus().State)
+}
+
+func TestContract_RateLimited(t *testing.T) {
+	t.Run("waits out a short Retry-After", func(t *testing.T) {
+		srv := newCassetteServer(t, "rate_limited")
+		client := newCassetteClient(srv, 5)
+
+		start := time.Now()
+		var out map[string]string
+		require.NoError(t, client.CompleteJSON(nil, &out))
+		assert.Equal(t, "Road Trip", out["en"])
+		assert.Equal(t, 2, srv.Calls())
+		assert.GreaterOrEqual(t, time.Since(start), time.Second, "retry must honour Retry-After over the backoff")
+	})
+
+	t.Run("fails fast on a long Retry-After", func(t *testing.T) {
+		srv := newCassetteServer(t, "rate_limited_long")
+		client := newCassetteClient(srv, 5)
+
+		var out generatedTasks
+		err := client.CompleteJSON(nil, &out)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+		assert.Equal(t, 10*time.Minute, apiErr.RetryAfter)
+		assert.Equal(t, 1, srv.Calls())
+	})
+
+	t.Run("repeated rate limiting opens the circuit", func(t *testing.T) {
+		srv := newCassetteServer(t, "rate_limited_long")
+		client := newCassetteClient(srv, 2)
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Complete(nil)
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+		}
+		_, err := client.Complete(nil)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, srv.Calls())
+	})
+}
+
+func TestContract_MalformedJSON(t *testing.T) {
+	t.Run("retries until the output parses", func(t *testing.T) {
+		srv := newCassetteServer(t, "malformed_json_recovers")
+		client := newCassetteClient(srv, 5)
+
+		var usage Usage
+		var out generatedTasks
+		require.NoError(t, client.CompleteJSON(nil, &out, WithUsage(&usage)))
+		assert.Equal(t, []string{"Speak in a whisper until your next turn."}, out.Dares)
+		assert.Equal(t, 2, srv.Calls())
+		assert.Equal(t, 2212+243, usage.TotalTokens, "tokens of discarded attempts count too")
+	})
+
+	t.Run("gives up after three attempts", func(t *testing.T) {
+		srv := newCassetteServer(t, "malformed_json")
+		client := newCassetteClient(srv, 1)
+
+		var out generatedTasks
+		err := client.CompleteJSON(nil, &out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse AI response as JSON")
+		assert.Equal(t, 3, srv.Calls())
+		assert.Equal(t, "closed", client.BreakerStatus().State, "a provider that answers is healthy")
+	})
+}
+
+func TestContract_Timeout(t *testing.T) {
+	t.Run("each attempt times out", func(t *testing.T) {
+		srv := newCassetteServer(t, "timeout")
+		client := newCassetteClient(srv, 5)
+
+		var out generatedTasks
+		err := client.CompleteJSON(nil, &out, WithTimeout(50*time.Millisecond))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 3, srv.Calls())
+		assert.Equal(t, 3, client.BreakerStatus().ConsecutiveFailures)
+	})
+
+	t.Run("open circuit stops the retries", func(t *testing.T) {
+		srv := newCassetteServer(t, "timeout")
+		client := newCassetteClient(srv, 2)
+
+		var out generatedTasks
+		err := client.CompleteJSON(nil, &out, WithTimeout(50*time.Millisecond))
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, srv.Calls())
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Thu, 15 Oct 2026 12:01:30 GMT", now))
+	assert.Zero(t, parseRetryAfter("Thu, 15 Oct 2026 11:00:00 GMT", now))
+	assert.Zero(t, parseRetryAfter("", now))
+	assert.Zero(t, parseRetryAfter("-5", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+}
@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ImageClient generates images through an OpenAI-compatible images API
+type ImageClient struct {
+	mock       bool
+	apiKey     string
+	apiURL     string
+	model      string
+	size       string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// ImageConfig holds configuration for creating an image client
+type ImageConfig struct {
+	Provider string        // "groq"/"openai" for the HTTP API, or "mock"
+	APIKey   string        // API key for authentication
+	APIURL   string        // Images generation endpoint
+	Model    string        // Image model
+	Size     string        // Output size, e.g. "1024x1024"
+	Timeout  time.Duration // Per-request timeout
+}
+
+// Image is a generated image
+type Image struct {
+	Data        []byte
+	ContentType string
+}
+
+var (
+	defaultImageClient *ImageClient
+	imageClientOnce    sync.Once
+)
+
+// DefaultImageConfig reads the image provider settings from the environment
+func DefaultImageConfig() ImageConfig {
+	apiURL := os.Getenv("IMAGE_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.openai.com/v1/images/generations"
+	}
+
+	model := os.Getenv("IMAGE_MODEL")
+	if model == "" {
+		model = "dall-e-3"
+	}
+
+	size := os.Getenv("IMAGE_SIZE")
+	if size == "" {
+		size = "1024x1024"
+	}
+
+	return ImageConfig{
+		Provider: os.Getenv("AI_PROVIDER"),
+		APIKey:   os.Getenv("IMAGE_API_KEY"),
+		APIURL:   apiURL,
+		Model:    model,
+		Size:     size,
+		Timeout:  120 * time.Second,
+	}
+}
+
+// NewImageClient creates a new image client with the given configuration
+func NewImageClient(config ImageConfig) *ImageClient {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &ImageClient{
+		mock:       config.Provider == ProviderMock,
+		apiKey:     config.APIKey,
+		apiURL:     config.APIURL,
+		model:      config.Model,
+		size:       config.Size,
+		timeout:    timeout,
+		httpClient: &http.Client{},
+	}
+}
+
+// GetImageClient returns the singleton default image client
+func GetImageClient() *ImageClient {
+	imageClientOnce.Do(func() {
+		defaultImageClient = NewImageClient(DefaultImageConfig())
+	})
+	return defaultImageClient
+}
+
+// IsConfigured returns true if the client has an API key or uses the mock provider
+func (c *ImageClient) IsConfigured() bool {
+	return c.mock || c.apiKey != ""
+}
+
+// Generate creates a single image from the prompt
+func (c *ImageClient) Generate(ctx context.Context, prompt string) (*Image, error) {
+	if !c.IsConfigured() {
+		return nil, errors.New("image client not configured: missing API key")
+	}
+	if c.mock {
+		return mockImage(prompt)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model":           c.model,
+		"prompt":          prompt,
+		"n":               1,
+		"size":            c.size,
+		"response_format": "b64_json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var imagesResp struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &imagesResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(imagesResp.Data) == 0 || imagesResp.Data[0].B64JSON == "" {
+		return nil, errors.New("image API returned no image")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(imagesResp.Data[0].B64JSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return &Image{Data: data, ContentType: http.DetectContentType(data)}, nil
+}
+
+// mockImage renders a small solid-colour PNG derived from the prompt, so
+// the same prompt always yields the same image
+func mockImage(prompt string) (*Image, error) {
+	h := fnv.New32a()
+	h.Write([]byte(prompt))
+	sum := h.Sum32()
+	fill := color.RGBA{R: uint8(sum >> 16), G: uint8(sum >> 8), B: uint8(sum), A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return &Image{Data: buf.Bytes(), ContentType: "image/png"}, nil
+}
@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// charsPerToken estimates prompt tokens from message length before the
+// provider counts them
+const charsPerToken = 4
+
+// RateLimiter keeps a client's calls within the provider's per-minute
+// request and token limits. Every caller of the client draws from the same
+// buckets, so manual generations and scheduled jobs running at once
+// together stay under the limits. A call takes its tokens up front, as the
+// estimated prompt plus max_tokens, and settles on the actual usage once
+// the provider answers.
+type RateLimiter struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	requests bucket
+	tokens   bucket
+}
+
+// RateLimitStatus is a point-in-time view of a rate limiter. Available
+// amounts may be negative while callers wait.
+type RateLimitStatus struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	TokensPerMinute   int `json:"tokens_per_minute"`
+	RequestsAvailable int `json:"requests_available"`
+	TokensAvailable   int `json:"tokens_available"`
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerMinute requests and
+// tokensPerMinute tokens, each refilled continuously and allowed to burst
+// up to a minute's worth. A limit of zero or less is not enforced; with
+// neither enforced it returns nil, which never waits.
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	now := time.Now()
+	return &RateLimiter{
+		now:      time.Now,
+		requests: newBucket(requestsPerMinute, now),
+		tokens:   newBucket(tokensPerMinute, now),
+	}
+}
+
+// Wait takes a request and tokens from the buckets, waiting until the
+// buckets have refilled enough to cover them. Callers are served in the
+// order they arrive. When ctx ends first the take is undone and the
+// context's error returned.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	delay := max(l.requests.take(now, 1), l.tokens.take(now, float64(tokens)))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	aiRateLimitWaits.Inc()
+	aiRateLimitWaitSeconds.Add(delay.Seconds())
+	if err := sleepContext(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.requests.give(1)
+		l.tokens.give(float64(tokens))
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Settle corrects the tokens taken for a call to the tokens it used:
+// unused ones go back, and extra ones are owed by the next callers.
+func (l *RateLimiter) Settle(taken, used int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if diff := float64(taken - used); diff > 0 {
+		l.tokens.give(diff)
+	} else {
+		l.tokens.take(l.now(), -diff)
+	}
+}
+
+// Status returns the limits and what is available now
+func (l *RateLimiter) Status() RateLimitStatus {
+	if l == nil {
+		return RateLimitStatus{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.requests.refill(now)
+	l.tokens.refill(now)
+	return RateLimitStatus{
+		RequestsPerMinute: int(l.requests.capacity),
+		TokensPerMinute:   int(l.tokens.capacity),
+		RequestsAvailable: int(math.Round(l.requests.level)),
+		TokensAvailable:   int(math.Round(l.tokens.level)),
+	}
+}
+
+// bucket is a token bucket holding up to a minute's allowance. A bucket
+// with no capacity is not enforced.
+type bucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+func newBucket(perMinute int, now time.Time) bucket {
+	if perMinute <= 0 {
+		return bucket{}
+	}
+	return bucket{capacity: float64(perMinute), level: float64(perMinute), updated: now}
+}
+
+// refill adds the allowance accrued since the last update
+func (b *bucket) refill(now time.Time) {
+	if b.capacity == 0 {
+		return
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.level = min(b.capacity, b.level+elapsed.Minutes()*b.capacity)
+		b.updated = now
+	}
+}
+
+// take removes n from the bucket, going into debt if needed, and returns
+// how long until the debt is paid off
+func (b *bucket) take(now time.Time, n float64) time.Duration {
+	if b.capacity == 0 {
+		return 0
+	}
+	b.refill(now)
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.capacity * float64(time.Minute))
+}
+
+// give returns n to the bucket
+func (b *bucket) give(n float64) {
+	if b.capacity == 0 {
+		return
+	}
+	b.level = min(b.capacity, b.level+n)
+}
+
+// estimateTokens is the tokens a request may use: its prompt, estimated
+// from its length, and the most it may complete
+func estimateTokens(req CompletionRequest) int {
+	chars := 0
+	for _, m := range req.Messages {
+		chars += len(m.Content)
+	}
+	return chars/charsPerToken + req.MaxTokens
+}
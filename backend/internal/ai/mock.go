@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Provider names accepted in AI_PROVIDER
+const (
+	ProviderGroq = "groq"
+	ProviderMock = "mock"
+)
+
+// mockModel is reported as the model name of mock completions
+const mockModel = "mock"
+
+// MockProvider returns deterministic canned completions without network
+// access. It is selected with AI_PROVIDER=mock for local development and
+// tests.
+//
+// Requests must name their prompt template with WithPrompt. If FixturesDir
+// contains <template>.json, that file is returned verbatim; otherwise a
+// response is built from the prompt's placeholder values.
+type MockProvider struct {
+	FixturesDir string
+}
+
+// Complete returns the canned completion for the request's prompt template
+func (m *MockProvider) Complete(req CompletionRequest) (*CompletionResponse, error) {
+	if err := req.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.prompt.Name == "" {
+		return nil, errors.New("mock AI provider: request has no prompt template; use ai.WithPrompt")
+	}
+
+	content, err := m.fixture(req.prompt.Name)
+	if err != nil {
+		return nil, err
+	}
+	if content == "" {
+		content, err = m.canned(req.prompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &CompletionResponse{ID: "mock-" + req.prompt.Name, Object: "chat.completion", Model: mockModel}
+	resp.Choices = append(resp.Choices, struct {
+		Index   int     `json:"index"`
+		Message Message `json:"message"`
+	}{Message: Message{Role: "assistant", Content: content}})
+	resp.Usage = mockUsage(req.Messages, content)
+
+	return resp, nil
+}
+
+// mockUsage estimates token counts at four bytes per token, so callers
+// accounting for spend see plausible, deterministic figures
+func mockUsage(messages []Message, content string) Usage {
+	prompt := 0
+	for _, m := range messages {
+		prompt += len(m.Content)
+	}
+	usage := Usage{PromptTokens: (prompt + 3) / 4, CompletionTokens: (len(content) + 3) / 4}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
+
+// fixture returns the fixture file for the template, or "" if there is none
+func (m *MockProvider) fixture(name string) (string, error) {
+	if m.FixturesDir == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.FixturesDir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("mock AI provider: failed to read fixture: %w", err)
+	}
+	return string(data), nil
+}
+
+// canned builds a response shaped like the real template's expected output
+func (m *MockProvider) canned(prompt promptRef) (string, error) {
+	var out interface{}
+
+	switch prompt.Name {
+	case "generate_tasks":
+		count, err := strconv.Atoi(prompt.Values["COUNT"])
+		if err != nil || count <= 0 {
+			count = 5
+		}
+		lang := prompt.Values["LANGUAGE"]
+		category := prompt.Values["CATEGORY"]
+
+		truths := make([]string, count)
+		dares := make([]string, count)
+		for i := 0; i < count; i++ {
+			truths[i] = fmt.Sprintf("[mock %s] Truth %d about %s?", lang, i+1, category)
+			dares[i] = fmt.Sprintf("[mock %s] Dare %d about %s.", lang, i+1, category)
+		}
+		out = map[string][]string{"truths": truths, "dares": dares}
+
+	case "category_labels":
+		name := prompt.Values["CATEGORY_NAME"]
+		labels := map[string]string{}
+		for _, lang := range splitList(prompt.Values["LANGUAGES"]) {
+			labels[lang] = fmt.Sprintf("[%s] %s", lang, name)
+		}
+		labels["en"] = name
+		out = labels
+
+	case "generate_hints":
+		var tasks []struct {
+			ID   string `json:"id"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal([]byte(prompt.Values["TASKS"]), &tasks); err != nil {
+			return "", fmt.Errorf("mock AI provider: invalid TASKS placeholder: %w", err)
+		}
+
+		type hint struct {
+			ID   string            `json:"id"`
+			Hint map[string]string `json:"hint"`
+		}
+		hints := make([]hint, 0, len(tasks))
+		for _, task := range tasks {
+			h := hint{ID: task.ID, Hint: map[string]string{}}
+			for _, lang := range splitList(prompt.Values["LANGUAGES"]) {
+				h.Hint[lang] = fmt.Sprintf("[mock %s] Hint for: %s", lang, task.Text)
+			}
+			hints = append(hints, h)
+		}
+		out = map[string]interface{}{"hints": hints}
+
+	case "translate_task":
+		translations := map[string]string{}
+		for _, lang := range splitList(prompt.Values["LANGUAGES"]) {
+			translations[lang] = fmt.Sprintf("[mock %s] %s", lang, prompt.Values["TEXT"])
+		}
+		out = map[string]interface{}{"translations": translations}
+
+	default:
+		return "", fmt.Errorf("mock AI provider: no canned response for prompt %q", prompt.Name)
+	}
+
+	content, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// splitList splits a ", " separated placeholder value
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+// Package chaos injects faults (latency, errors and dropped connections)
+// for resilience testing in development: how clients retry, how timeouts
+// fire and when the AI circuit breaker opens. It is never enabled in
+// production.
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected is returned by calls an Injector decided to fail.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Faults sets the probability, from 0 to 1, of each fault per call.
+type Faults struct {
+	// Latency is added to a share LatencyRate of calls.
+	Latency     time.Duration
+	LatencyRate float64
+	// ErrorRate is the share of calls that fail.
+	ErrorRate float64
+	// DropRate is the share of calls whose connection is dropped without
+	// a response. Only HTTP routes drop; elsewhere it counts as an error.
+	DropRate float64
+}
+
+// Active reports whether any fault has a chance of being injected.
+func (f Faults) Active() bool {
+	return (f.Latency > 0 && f.LatencyRate > 0) || f.ErrorRate > 0 || f.DropRate > 0
+}
+
+// Fault is what to inject into one call.
+type Fault struct {
+	Delay time.Duration
+	Error bool
+	Drop  bool
+}
+
+// Injector rolls the faults for each call. It is safe for concurrent use.
+// A nil *Injector never injects anything.
+type Injector struct {
+	faults Faults
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// New creates an Injector for faults.
+func New(faults Faults) *Injector {
+	return &Injector{faults: faults, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Faults returns the configured faults.
+func (i *Injector) Faults() Faults {
+	if i == nil {
+		return Faults{}
+	}
+	return i.faults
+}
+
+// Pick rolls the faults for one call. A dropped call is not also failed.
+func (i *Injector) Pick() Fault {
+	if i == nil {
+		return Fault{}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var fault Fault
+	if i.faults.Latency > 0 && i.rand.Float64() < i.faults.LatencyRate {
+		fault.Delay = i.faults.Latency
+	}
+	roll := i.rand.Float64()
+	switch {
+	case roll < i.faults.DropRate:
+		fault.Drop = true
+	case roll < i.faults.DropRate+i.faults.ErrorRate:
+		fault.Error = true
+	}
+	return fault
+}
+
+// Seed makes the rolls repeatable, for tests.
+func (i *Injector) Seed(seed int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rand = rand.New(rand.NewSource(seed))
+}
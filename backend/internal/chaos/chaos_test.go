@@ -0,0 +1,47 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjector_Pick(t *testing.T) {
+	injector := New(Faults{Latency: time.Second, LatencyRate: 0.5, ErrorRate: 0.2, DropRate: 0.1})
+	injector.Seed(1)
+
+	var delayed, failed, dropped int
+	const calls = 10000
+	for i := 0; i < calls; i++ {
+		fault := injector.Pick()
+		if fault.Delay > 0 {
+			delayed++
+		}
+		if fault.Error {
+			failed++
+		}
+		if fault.Drop {
+			dropped++
+		}
+		assert.False(t, fault.Error && fault.Drop, "a call is either failed or dropped")
+	}
+
+	assert.InDelta(t, 0.5, float64(delayed)/calls, 0.03)
+	assert.InDelta(t, 0.2, float64(failed)/calls, 0.03)
+	assert.InDelta(t, 0.1, float64(dropped)/calls, 0.03)
+}
+
+func TestInjector_Nil(t *testing.T) {
+	var injector *Injector
+	assert.Equal(t, Fault{}, injector.Pick())
+	assert.False(t, injector.Faults().Active())
+}
+
+func TestFaults_Active(t *testing.T) {
+	assert.False(t, Faults{}.Active())
+	assert.False(t, Faults{Latency: time.Second}.Active(), "latency without a rate never fires")
+	assert.True(t, Faults{Latency: time.Second, LatencyRate: 0.1}.Active())
+	assert.True(t, Faults{ErrorRate: 0.1}.Active())
+	assert.True(t, Faults{DropRate: 0.1}.Active())
+}
@@ -4,6 +4,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/truthordare/backend/internal/models"
 )
 
 // Config holds all configuration for the application.
@@ -11,14 +13,240 @@ type Config struct {
 	Port string
 	Env  string
 
-	DBPath string
+	DBPath   string
+	Database DatabaseConfig
+
+	APIPrefix string
 
-	APIPrefix  string
-	APIVersion string
+	// APIV1Disabled stops serving the deprecated /v1 routes, leaving /v2.
+	// APIV1Sunset is the planned removal date of v1 (YYYY-MM-DD), sent in
+	// the Sunset header of v1 responses; empty sends none.
+	APIV1Disabled bool
+	APIV1Sunset   string
 
 	CORSOrigins []string
 
-	Scheduler SchedulerConfig
+	// ListenSocket is a Unix domain socket path served instead of PORT, for
+	// reverse proxies on the same host. A systemd-activated socket takes
+	// precedence over both.
+	ListenSocket     string
+	ListenSocketMode os.FileMode
+
+	// AdminAddr moves the restricted routes and /metrics to a separate
+	// listener (e.g. 127.0.0.1:9090), so network policy can isolate
+	// management traffic. Empty serves them on the main port.
+	AdminAddr string
+
+	// TrustedProxies lists the load balancer IPs or CIDRs whose forwarding
+	// headers are believed. Empty trusts none, so the client IP is the
+	// connection address. ClientIPHeaders are read in order from trusted
+	// proxies only.
+	TrustedProxies  []string
+	ClientIPHeaders []string
+
+	// ReadOnly starts the instance in read-only mode, rejecting mutating
+	// requests and skipping scheduler jobs that write. It can be toggled at
+	// runtime through the settings API.
+	ReadOnly bool
+
+	Maintenance MaintenanceConfig
+
+	// ErrorTrackingDSN is a Sentry DSN, or any URL receiving error events as
+	// JSON. Empty disables error reporting.
+	ErrorTrackingDSN string
+
+	// EncryptionKeys are the id:key pairs encrypting sensitive columns, the
+	// first encrypting and the rest only decrypting. EncryptionKeysFile,
+	// when set, names a file holding them instead.
+	EncryptionKeys     string
+	EncryptionKeysFile string
+
+	// SignedURLSecret signs embed URLs; empty uses a random secret per
+	// process. SignedURLMaxTTLHours caps their lifetime.
+	SignedURLSecret      string
+	SignedURLMaxTTLHours int
+
+	// PublicURL is the scheme and host clients reach the API on, e.g.
+	// https://api.example.com, for absolute links in embeds. Empty uses the
+	// host of each request.
+	PublicURL string
+
+	// DiagnosticsPort serves pprof and expvar on 127.0.0.1 only.
+	// 0 disables the diagnostics listener.
+	DiagnosticsPort int
+
+	// RequestTimeoutSeconds bounds ordinary requests; LongRequestTimeoutSeconds
+	// bounds AI generation, batch and export endpoints. 0 disables a budget.
+	RequestTimeoutSeconds     int
+	LongRequestTimeoutSeconds int
+
+	// MinAppVersion is the oldest client app version still supported. It
+	// is served in the client configuration; empty sets no minimum.
+	MinAppVersion string
+
+	// MinClientVersion is the oldest X-Client-Version the public API
+	// accepts; older clients get 426 Upgrade Required. Empty accepts all.
+	MinClientVersion string
+
+	Scheduler  SchedulerConfig
+	Generation GenerationConfig
+	Storage    StorageConfig
+	Moderation ModerationConfig
+	Chaos      ChaosConfig
+	Chat       ChatConfig
+	Game       GameConfig
+}
+
+// GameConfig holds the server-side game sessions.
+type GameConfig struct {
+	// SessionTTLHours is how long a session lives after its last draw.
+	SessionTTLHours int
+	// SessionHistory is how many of a session's last tasks are excluded
+	// from its next draw.
+	SessionHistory int
+	// JoinURL is the link session QR codes encode, with {code} replaced by
+	// the room code. Empty links to GET /api/v1/sessions/{code} on
+	// PublicURL or the request's host.
+	JoinURL string
+}
+
+// ChatConfig holds the Slack, Discord and Telegram bot integrations. A
+// platform is disabled while its secret or key is empty.
+type ChatConfig struct {
+	// SlackSigningSecret verifies Slack slash command requests.
+	SlackSigningSecret string
+	// DiscordPublicKey verifies Discord interactions, as hex.
+	DiscordPublicKey string
+	// TelegramWebhookSecret is the secret token the Telegram webhook was
+	// registered with, which Telegram sends with every update.
+	TelegramWebhookSecret string
+	// TelegramAgeGroup is the age group Telegram groups draw from when safe
+	// mode is off. Groups register themselves with safe mode on.
+	TelegramAgeGroup string
+	// ChannelHistory is how many of a channel's last draws are excluded
+	// from its next one.
+	ChannelHistory int
+}
+
+// ChaosConfig holds fault injection for resilience testing. It only takes
+// effect in development (APP_ENV=development). Rates are probabilities
+// from 0 to 1 per request or AI call.
+type ChaosConfig struct {
+	Enabled bool
+	// Routes are the path prefixes faults are injected into, e.g.
+	// "/api/v1/tasks". Empty selects every route.
+	Routes []string
+
+	LatencyMS   int
+	LatencyRate float64
+	ErrorRate   float64
+	DropRate    float64
+
+	// AI faults are injected into AI provider calls, from the generate
+	// endpoints and the scheduler alike.
+	AILatencyMS   int
+	AILatencyRate float64
+	AIErrorRate   float64
+	AIDropRate    float64
+}
+
+// MaintenanceConfig holds the scheduled maintenance windows.
+type MaintenanceConfig struct {
+	// Windows is a semicolon-separated list of cron expressions, each
+	// opening a maintenance window. Empty disables maintenance windows.
+	Windows string
+	// WindowMinutes is how long each window stays open.
+	WindowMinutes int
+}
+
+// ModerationConfig holds settings for player reports on tasks.
+type ModerationConfig struct {
+	// ReportThreshold is the number of unresolved reports within
+	// ReportWindowHours that deactivates a task and queues it for review.
+	// 0 disables automatic deactivation.
+	ReportThreshold int
+	// ReportWindowHours is the sliding window reports are counted over.
+	ReportWindowHours int
+	// NotifyWebhookURL receives admin notifications. When empty they are logged.
+	NotifyWebhookURL string
+	// ConsentPolicyVersion is the terms/consent policy clients must accept
+	// before playing categories that require consent.
+	ConsentPolicyVersion string
+	// BlockedWords are refused in text players write, such as the custom
+	// tasks of game sessions, on top of a built-in list of profanity.
+	BlockedWords []string
+}
+
+// StorageConfig holds settings for stored media such as category images.
+type StorageConfig struct {
+	// Dir is the local directory files are written to.
+	Dir string
+	// BaseURL is the URL prefix files are served from. A path such as
+	// "/media" is served by this server; a full URL points at a CDN or
+	// proxy serving Dir.
+	BaseURL string
+}
+
+// GenerationConfig holds AI task generation settings shared by the
+// generate endpoint and the auto-generate job.
+type GenerationConfig struct {
+	// ExampleCount is the number of existing truths and of dares injected
+	// into the prompt as few-shot examples. 0 disables examples.
+	ExampleCount int
+	// ExampleStrategy selects the examples: "random" or "recent".
+	ExampleStrategy string
+	// RolloutPercent is the share of random draws newly generated tasks are
+	// eligible for until the rollout-promote job moves them to full rotation.
+	// 100 (or any value outside 1-99) serves new tasks everywhere at once.
+	RolloutPercent int
+	// FreshnessSLAHours is the age the newest active task of each
+	// category+language should stay under. Older content is reported as
+	// stale by the freshness endpoint and metrics.
+	FreshnessSLAHours int
+	// LanguageCheck detects the language of generated texts: "reject" drops
+	// texts in another language than requested, "flag" keeps them with a
+	// reviewer note, "off" or empty skips detection.
+	LanguageCheck string
+	// TextRules limit task length and readability per age group of the
+	// category. They reject hand-written tasks and filter generated ones.
+	TextRules models.AgeGroupTextRules
+	// DailyRunQuota and DailyCountQuota bound the generate endpoint per
+	// admin key and UTC day: runs started, and tasks requested (count per
+	// combination times combinations). 0 disables a quota. They guard
+	// against queueing huge runs by accident, not against abuse.
+	DailyRunQuota   int
+	DailyCountQuota int
+}
+
+// InitialRollout returns the rollout percentage for newly generated tasks.
+func (g *GenerationConfig) InitialRollout() int {
+	if g.RolloutPercent <= 0 || g.RolloutPercent > 100 {
+		return 100
+	}
+	return g.RolloutPercent
+}
+
+// DatabaseConfig holds GORM performance settings.
+type DatabaseConfig struct {
+	// PrepareStmt caches prepared statements for reuse across queries.
+	PrepareStmt bool
+	// SkipDefaultTransaction disables the implicit transaction GORM wraps
+	// around every single-row create/update/delete.
+	SkipDefaultTransaction bool
+	// CreateBatchSize is the number of rows inserted per statement in batch creates.
+	CreateBatchSize int
+	// ServeStatsFlushSeconds is how often buffered task serve counts are
+	// written to the database.
+	ServeStatsFlushSeconds int
+	// AutoMigrate runs migrations at startup. Disable it when migrations
+	// run separately, e.g. with --migrate-only in an init container.
+	AutoMigrate bool
+	// MigrationLockTimeoutSeconds is how long to wait for another instance
+	// holding the migration lock.
+	MigrationLockTimeoutSeconds int
+	// SlowQueryThresholdMs records queries taking this long or longer for
+	// the index advisor. 0 disables the record.
+	SlowQueryThresholdMs int
 }
 
 // SchedulerConfig holds scheduler-related configuration.
@@ -30,12 +258,45 @@ type SchedulerConfig struct {
 	CleanupCron            string
 	CleanupRetentionMonths int
 
+	// CleanupEvictOverCap lets the cleanup job retire inactive tasks from
+	// category+language combinations above TaskCapPerCategoryLanguage.
+	CleanupEvictOverCap bool
+
+	// TaskCapPerCategoryLanguage is the most tasks a category+language
+	// combination may hold. The auto-generate job skips full combinations.
+	// 0 disables the cap.
+	TaskCapPerCategoryLanguage int
+
 	// Auto-generate job settings
-	AutoGenerateEnabled           bool
-	AutoGenerateCron              string
-	AutoGenerateCount             int
-	AutoGenerateRetryMax          int
-	AutoGenerateRetryDelaySeconds int
+	AutoGenerateEnabled        bool
+	AutoGenerateCron           string
+	AutoGenerateCount          int
+	AutoGenerateRetryMax       int
+	AutoGenerateTimeoutSeconds int
+
+	// Rollout-promote job settings
+	RolloutPromoteEnabled    bool
+	RolloutPromoteCron       string
+	RolloutPromoteAfterHours int
+
+	// Weekly digest job settings. The digest is sent through the admin
+	// notifier (NOTIFY_WEBHOOK_URL) addressed to DigestRecipients.
+	DigestEnabled     bool
+	DigestCron        string
+	DigestRecipients  []string
+	DigestTopReported int
+
+	// Category-rank job settings. The job ranks categories by the tasks
+	// served over the last CategoryRankWindowDays for order=smart.
+	CategoryRankEnabled    bool
+	CategoryRankCron       string
+	CategoryRankWindowDays int
+
+	// Disk guard job settings. Below DiskGuardMinFreeMB free on the
+	// database's disk the job switches the instance to read-only mode.
+	DiskGuardEnabled   bool
+	DiskGuardCron      string
+	DiskGuardMinFreeMB int
 }
 
 // Load loads configuration from environment variables.
@@ -43,22 +304,129 @@ func Load() (*Config, error) {
 	corsOrigins := getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:8080")
 
 	cfg := &Config{
-		Port:        getEnv("PORT", "8080"),
-		Env:         getEnv("APP_ENV", "development"),
-		DBPath:      getEnv("DB_PATH", "truthordare.db"),
-		APIPrefix:   getEnv("API_PREFIX", "/api"),
-		APIVersion:  getEnv("API_VERSION", "v1"),
-		CORSOrigins: strings.Split(corsOrigins, ","),
+		Port:   getEnv("PORT", "8080"),
+		Env:    getEnv("APP_ENV", "development"),
+		DBPath: getEnv("DB_PATH", "truthordare.db"),
+		Database: DatabaseConfig{
+			PrepareStmt:                 getEnvBool("DB_PREPARE_STMT", false),
+			SkipDefaultTransaction:      getEnvBool("DB_SKIP_DEFAULT_TRANSACTION", false),
+			CreateBatchSize:             getEnvInt("DB_CREATE_BATCH_SIZE", 100),
+			ServeStatsFlushSeconds:      getEnvInt("SERVE_STATS_FLUSH_SECONDS", 10),
+			AutoMigrate:                 getEnvBool("DB_AUTO_MIGRATE", true),
+			MigrationLockTimeoutSeconds: getEnvInt("MIGRATION_LOCK_TIMEOUT_SECONDS", 120),
+			SlowQueryThresholdMs:        getEnvInt("SLOW_QUERY_THRESHOLD_MS", 200),
+		},
+		APIPrefix:                 getEnv("API_PREFIX", "/api"),
+		APIV1Disabled:             getEnvBool("API_V1_DISABLED", false),
+		APIV1Sunset:               getEnv("API_V1_SUNSET", ""),
+		CORSOrigins:               strings.Split(corsOrigins, ","),
+		ListenSocket:              getEnv("LISTEN_SOCKET", ""),
+		ListenSocketMode:          getEnvFileMode("LISTEN_SOCKET_MODE", 0660),
+		AdminAddr:                 getEnv("ADMIN_ADDR", ""),
+		TrustedProxies:            splitList(getEnv("TRUSTED_PROXIES", "")),
+		ClientIPHeaders:           splitList(getEnv("CLIENT_IP_HEADERS", "X-Forwarded-For,X-Real-IP")),
+		ReadOnly:                  getEnvBool("READ_ONLY", false),
+		ErrorTrackingDSN:          getEnv("ERROR_TRACKING_DSN", ""),
+		EncryptionKeys:            getEnv("ENCRYPTION_KEYS", ""),
+		EncryptionKeysFile:        getEnv("ENCRYPTION_KEYS_FILE", ""),
+		SignedURLSecret:           getEnv("SIGNED_URL_SECRET", ""),
+		SignedURLMaxTTLHours:      getEnvInt("SIGNED_URL_MAX_TTL_HOURS", 720),
+		PublicURL:                 strings.TrimSuffix(getEnv("PUBLIC_URL", ""), "/"),
+		DiagnosticsPort:           getEnvInt("DIAGNOSTICS_PORT", 0),
+		RequestTimeoutSeconds:     getEnvInt("REQUEST_TIMEOUT_SECONDS", 5),
+		LongRequestTimeoutSeconds: getEnvInt("LONG_REQUEST_TIMEOUT_SECONDS", 120),
+		MinAppVersion:             getEnv("MIN_APP_VERSION", ""),
+		MinClientVersion:          getEnv("MIN_CLIENT_VERSION", ""),
+		Maintenance: MaintenanceConfig{
+			Windows:       getEnv("MAINTENANCE_WINDOWS", ""),
+			WindowMinutes: getEnvInt("MAINTENANCE_WINDOW_MINUTES", 30),
+		},
 		Scheduler: SchedulerConfig{
-			Enabled:                       getEnvBool("SCHEDULER_ENABLED", true),
-			CleanupEnabled:                getEnvBool("CLEANUP_ENABLED", true),
-			CleanupCron:                   getEnv("CLEANUP_CRON", "0 0 * * 0"),
-			CleanupRetentionMonths:        getEnvInt("CLEANUP_RETENTION_MONTHS", 2),
-			AutoGenerateEnabled:           getEnvBool("AUTO_GENERATE_ENABLED", true),
-			AutoGenerateCron:              getEnv("AUTO_GENERATE_CRON", "0 2 * * 0"),
-			AutoGenerateCount:             getEnvInt("AUTO_GENERATE_COUNT", 5),
-			AutoGenerateRetryMax:          getEnvInt("AUTO_GENERATE_RETRY_MAX", 3),
-			AutoGenerateRetryDelaySeconds: getEnvInt("AUTO_GENERATE_RETRY_DELAY_SECONDS", 60),
+			Enabled:                    getEnvBool("SCHEDULER_ENABLED", true),
+			CleanupEnabled:             getEnvBool("CLEANUP_ENABLED", true),
+			CleanupCron:                getEnv("CLEANUP_CRON", "0 0 * * 0"),
+			CleanupRetentionMonths:     getEnvInt("CLEANUP_RETENTION_MONTHS", 2),
+			CleanupEvictOverCap:        getEnvBool("CLEANUP_EVICT_OVER_CAP", true),
+			TaskCapPerCategoryLanguage: getEnvInt("TASK_CAP_PER_CATEGORY_LANGUAGE", 500),
+			AutoGenerateEnabled:        getEnvBool("AUTO_GENERATE_ENABLED", true),
+			AutoGenerateCron:           getEnv("AUTO_GENERATE_CRON", "0 2 * * 0"),
+			AutoGenerateCount:          getEnvInt("AUTO_GENERATE_COUNT", 5),
+			AutoGenerateRetryMax:       getEnvInt("AUTO_GENERATE_RETRY_MAX", 3),
+			AutoGenerateTimeoutSeconds: getEnvInt("AUTO_GENERATE_TIMEOUT_SECONDS", 120),
+			RolloutPromoteEnabled:      getEnvBool("ROLLOUT_PROMOTE_ENABLED", true),
+			RolloutPromoteCron:         getEnv("ROLLOUT_PROMOTE_CRON", "0 * * * *"),
+			RolloutPromoteAfterHours:   getEnvInt("ROLLOUT_PROMOTE_AFTER_HOURS", 48),
+			DigestEnabled:              getEnvBool("DIGEST_ENABLED", false),
+			DigestCron:                 getEnv("DIGEST_CRON", "0 8 * * 1"),
+			DigestRecipients:           splitList(getEnv("DIGEST_RECIPIENTS", "")),
+			DigestTopReported:          getEnvInt("DIGEST_TOP_REPORTED", 5),
+			CategoryRankEnabled:        getEnvBool("CATEGORY_RANK_ENABLED", true),
+			CategoryRankCron:           getEnv("CATEGORY_RANK_CRON", "0 3 * * *"),
+			CategoryRankWindowDays:     getEnvInt("CATEGORY_RANK_WINDOW_DAYS", 30),
+			DiskGuardEnabled:           getEnvBool("DISK_GUARD_ENABLED", true),
+			DiskGuardCron:              getEnv("DISK_GUARD_CRON", "@every 1m"),
+			DiskGuardMinFreeMB:         getEnvInt("DISK_GUARD_MIN_FREE_MB", 512),
+		},
+		Storage: StorageConfig{
+			Dir:     getEnv("STORAGE_DIR", "uploads"),
+			BaseURL: getEnv("STORAGE_BASE_URL", "/media"),
+		},
+		Moderation: ModerationConfig{
+			ReportThreshold:      getEnvInt("REPORT_THRESHOLD", 3),
+			ReportWindowHours:    getEnvInt("REPORT_WINDOW_HOURS", 24),
+			NotifyWebhookURL:     getEnv("NOTIFY_WEBHOOK_URL", ""),
+			ConsentPolicyVersion: getEnv("CONSENT_POLICY_VERSION", "1"),
+			BlockedWords:         splitList(getEnv("BLOCKED_WORDS", "")),
+		},
+		Chaos: ChaosConfig{
+			Enabled:       getEnvBool("CHAOS_ENABLED", false),
+			Routes:        splitList(getEnv("CHAOS_ROUTES", "")),
+			LatencyMS:     getEnvInt("CHAOS_LATENCY_MS", 0),
+			LatencyRate:   getEnvFloat("CHAOS_LATENCY_RATE", 0),
+			ErrorRate:     getEnvFloat("CHAOS_ERROR_RATE", 0),
+			DropRate:      getEnvFloat("CHAOS_DROP_RATE", 0),
+			AILatencyMS:   getEnvInt("CHAOS_AI_LATENCY_MS", 0),
+			AILatencyRate: getEnvFloat("CHAOS_AI_LATENCY_RATE", 0),
+			AIErrorRate:   getEnvFloat("CHAOS_AI_ERROR_RATE", 0),
+			AIDropRate:    getEnvFloat("CHAOS_AI_DROP_RATE", 0),
+		},
+		Chat: ChatConfig{
+			SlackSigningSecret:    getEnv("SLACK_SIGNING_SECRET", ""),
+			DiscordPublicKey:      getEnv("DISCORD_PUBLIC_KEY", ""),
+			TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+			TelegramAgeGroup:      getEnv("TELEGRAM_AGE_GROUP", models.AgeGroupTeen),
+			ChannelHistory:        getEnvInt("CHAT_CHANNEL_HISTORY", 50),
+		},
+		Game: GameConfig{
+			SessionTTLHours: getEnvInt("GAME_SESSION_TTL_HOURS", 24),
+			SessionHistory:  getEnvInt("GAME_SESSION_HISTORY", 500),
+			JoinURL:         getEnv("GAME_JOIN_URL", ""),
+		},
+		Generation: GenerationConfig{
+			ExampleCount:      getEnvInt("GENERATE_EXAMPLE_COUNT", 5),
+			ExampleStrategy:   getEnv("GENERATE_EXAMPLE_STRATEGY", "random"),
+			RolloutPercent:    getEnvInt("GENERATE_ROLLOUT_PERCENT", 25),
+			FreshnessSLAHours: getEnvInt("FRESHNESS_SLA_HOURS", 168),
+			LanguageCheck:     getEnv("GENERATE_LANGUAGE_CHECK", models.LanguageCheckReject),
+			DailyRunQuota:     getEnvInt("GENERATE_DAILY_RUN_QUOTA", 20),
+			DailyCountQuota:   getEnvInt("GENERATE_DAILY_COUNT_QUOTA", 2000),
+			TextRules: models.AgeGroupTextRules{
+				models.AgeGroupKids: {
+					MaxLength:           getEnvInt("TASK_MAX_LENGTH_KIDS", 150),
+					MaxSentences:        getEnvInt("TASK_MAX_SENTENCES_KIDS", 2),
+					MaxWordsPerSentence: getEnvInt("TASK_MAX_SENTENCE_WORDS_KIDS", 20),
+				},
+				models.AgeGroupTeen: {
+					MaxLength:           getEnvInt("TASK_MAX_LENGTH_TEEN", 250),
+					MaxSentences:        getEnvInt("TASK_MAX_SENTENCES_TEEN", 3),
+					MaxWordsPerSentence: getEnvInt("TASK_MAX_SENTENCE_WORDS_TEEN", 30),
+				},
+				models.AgeGroupAdults: {
+					MaxLength:           getEnvInt("TASK_MAX_LENGTH_ADULTS", models.MaxTaskTextLength),
+					MaxSentences:        getEnvInt("TASK_MAX_SENTENCES_ADULTS", 0),
+					MaxWordsPerSentence: getEnvInt("TASK_MAX_SENTENCE_WORDS_ADULTS", 0),
+				},
+			},
 		},
 	}
 
@@ -69,6 +437,16 @@ func (c *Config) DSN() string {
 	return c.DBPath
 }
 
+// DBFile returns the SQLite database file named by DB_PATH, without a
+// file: prefix or query, or empty for an in-memory database.
+func (c *Config) DBFile() string {
+	path, _, _ := strings.Cut(strings.TrimPrefix(c.DSN(), "file:"), "?")
+	if path == "" || path == ":memory:" {
+		return ""
+	}
+	return path
+}
+
 // IsDevelopment returns true if running in development mode.
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
@@ -86,6 +464,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList splits a comma-separated value, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value, exists := os.LookupEnv(key); exists {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -95,6 +484,25 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFileMode reads an octal permission value such as 0660
+func getEnvFileMode(key string, defaultValue os.FileMode) os.FileMode {
+	if value, exists := os.LookupEnv(key); exists {
+		if mode, err := strconv.ParseUint(value, 8, 32); err == nil {
+			return os.FileMode(mode)
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value, exists := os.LookupEnv(key); exists {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
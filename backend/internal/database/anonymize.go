@@ -0,0 +1,156 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrAnonymizeTarget is returned when the anonymized copy would overwrite
+// an existing file.
+var ErrAnonymizeTarget = errors.New("anonymized copy target already exists")
+
+// pseudonymColumns hold identifiers replaced by pseudonyms. The same value
+// gets the same pseudonym in every column, so a client's reports and
+// consents still line up in the copy.
+var pseudonymColumns = []struct {
+	Table, Column, Prefix string
+}{
+	{"task_reports", "client_id", "client"},
+	{"consent_records", "client_id", "client"},
+	{"consent_records", "session_id", "session"},
+	{"privacy_audits", "client_id_hash", "client"},
+	{"tasks", "assigned_to", "reviewer"},
+}
+
+// clearedColumns hold free text written by players and reviewers, which
+// may contain anything, and are emptied.
+var clearedColumns = []struct {
+	Table, Column string
+}{
+	{"task_reports", "comment"},
+	{"tasks", "reviewer_notes"},
+}
+
+// droppedTables hold operational rows naming hosts, and games naming
+// players, and are emptied.
+var droppedTables = []string{"migration_locks", "game_events", "game_sessions"}
+
+// Anonymize writes a copy of db to path with client identifiers replaced by
+// pseudonyms and free text cleared, so it can be handed to developers or
+// restored into staging. Content (categories, tasks, glossary) is kept as
+// is. db itself is only read. It returns the rows changed per column.
+func Anonymize(db *gorm.DB, path string) (map[string]int64, error) {
+	if db.Dialector.Name() != "sqlite" {
+		return nil, fmt.Errorf("anonymize supports sqlite databases, not %s", db.Dialector.Name())
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAnonymizeTarget, path)
+	}
+
+	log.Info().Str("path", path).Msg("Copying database for anonymization")
+	if err := db.Exec("VACUUM INTO ?", path).Error; err != nil {
+		return nil, fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	copyDB, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := copyDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+
+	changed, err := scrub(copyDB)
+	if err != nil {
+		// Never leave a half-scrubbed copy behind
+		sqlDB.Close()
+		os.Remove(path)
+		return nil, err
+	}
+
+	// Rewrite the file so scrubbed values do not linger in free pages
+	if err := copyDB.Exec("VACUUM").Error; err != nil {
+		sqlDB.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to compact anonymized copy: %w", err)
+	}
+
+	log.Info().Str("path", path).Interface("changed", changed).Msg("Anonymized copy written")
+	return changed, nil
+}
+
+// scrub anonymizes the columns in one transaction
+func scrub(db *gorm.DB) (map[string]int64, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]int64)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, col := range pseudonymColumns {
+			if !tx.Migrator().HasColumn(col.Table, col.Column) {
+				continue
+			}
+			var values []string
+			err := tx.Table(col.Table).Distinct(col.Column).
+				Where(col.Column+" IS NOT NULL AND "+col.Column+" <> ''").
+				Pluck(col.Column, &values).Error
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				res := tx.Table(col.Table).Where(col.Column+" = ?", value).
+					Update(col.Column, pseudonym(key, col.Prefix, value))
+				if res.Error != nil {
+					return res.Error
+				}
+				changed[col.Table+"."+col.Column] += res.RowsAffected
+			}
+		}
+
+		for _, col := range clearedColumns {
+			if !tx.Migrator().HasColumn(col.Table, col.Column) {
+				continue
+			}
+			res := tx.Table(col.Table).Where(col.Column+" <> ''").Update(col.Column, "")
+			if res.Error != nil {
+				return res.Error
+			}
+			changed[col.Table+"."+col.Column] = res.RowsAffected
+		}
+
+		for _, table := range droppedTables {
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+			res := tx.Exec("DELETE FROM " + table)
+			if res.Error != nil {
+				return res.Error
+			}
+			changed[table] = res.RowsAffected
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// pseudonym maps value to a stable stand-in under key. The key is random
+// per run and discarded, so pseudonyms cannot be traced back.
+func pseudonym(key []byte, prefix, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAnonymize(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}}
+	db.Create(category)
+	task := &models.Task{CategoryID: category.ID, Type: models.TaskTypeTruth, Text: "Who do you miss?", Language: "en",
+		AssignedTo: "alice", ReviewerNotes: "Alice thinks this is fine"}
+	db.Create(task)
+	db.Create(&models.TaskReport{TaskID: task.ID, ClientID: "device-123", Reason: "offensive", Comment: "I'm Bob, call me on 555-0100"})
+	db.Create(&models.ConsentRecord{ClientID: "device-123", SessionID: "game-1", PolicyVersion: "1", AgeConfirmed: true})
+	db.Create(&models.ConsentRecord{ClientID: "device-456", PolicyVersion: "1", AgeConfirmed: true})
+	db.AutoMigrate(&migrationLock{})
+	db.Create(&migrationLock{Name: migrationLockName, Owner: "prod-host-1:42"})
+
+	path := filepath.Join(t.TempDir(), "staging.db")
+	changed, err := Anonymize(db, path)
+	if err != nil {
+		t.Fatalf("Anonymize failed: %v", err)
+	}
+	if changed["consent_records.client_id"] != 2 || changed["task_reports.comment"] != 1 || changed["migration_locks"] != 1 {
+		t.Errorf("Unexpected changed rows: %v", changed)
+	}
+
+	// The source is untouched
+	var report models.TaskReport
+	db.First(&report)
+	if report.ClientID != "device-123" || report.Comment == "" {
+		t.Errorf("Expected the source database unchanged, got %+v", report)
+	}
+
+	copyDB, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open copy: %v", err)
+	}
+
+	var copied models.TaskReport
+	copyDB.First(&copied)
+	if copied.Comment != "" || !strings.HasPrefix(copied.ClientID, "client-") {
+		t.Errorf("Expected the report scrubbed, got client %q comment %q", copied.ClientID, copied.Comment)
+	}
+
+	// A client keeps one pseudonym across tables
+	var consents []models.ConsentRecord
+	copyDB.Order("client_id").Find(&consents)
+	var matched int
+	for _, consent := range consents {
+		if consent.ClientID == copied.ClientID {
+			matched++
+			if consent.SessionID == "game-1" || !strings.HasPrefix(consent.SessionID, "session-") {
+				t.Errorf("Expected the session pseudonymized, got %q", consent.SessionID)
+			}
+		}
+	}
+	if matched != 1 {
+		t.Errorf("Expected the reporting client's consent under the same pseudonym, matched %d", matched)
+	}
+
+	var copiedTask models.Task
+	copyDB.First(&copiedTask)
+	if copiedTask.Text != task.Text {
+		t.Errorf("Expected content kept, got %q", copiedTask.Text)
+	}
+	if copiedTask.ReviewerNotes != "" || !strings.HasPrefix(copiedTask.AssignedTo, "reviewer-") {
+		t.Errorf("Expected reviewer data scrubbed, got %q / %q", copiedTask.AssignedTo, copiedTask.ReviewerNotes)
+	}
+
+	var locks int64
+	copyDB.Model(&migrationLock{}).Count(&locks)
+	if locks != 0 {
+		t.Errorf("Expected migration locks dropped, got %d", locks)
+	}
+
+	// Scrubbed values must not survive in free pages of the file
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read copy: %v", err)
+	}
+	for _, secret := range []string{"device-123", "555-0100", "prod-host-1", "Alice thinks"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("Expected %q gone from the copy file", secret)
+		}
+	}
+
+	// An existing file is never overwritten
+	if _, err := Anonymize(db, path); !errors.Is(err, ErrAnonymizeTarget) {
+		t.Errorf("Expected ErrAnonymizeTarget, got %v", err)
+	}
+}
@@ -3,10 +3,14 @@ package database
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/game"
 	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/slowquery"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -38,15 +42,28 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 	if cfg.IsDevelopment() {
 		gormLogger = logger.Default.LogMode(logger.Info)
 	}
+	if cfg.Database.SlowQueryThresholdMs > 0 {
+		gormLogger = slowquery.New(gormLogger, time.Duration(cfg.Database.SlowQueryThresholdMs)*time.Millisecond)
+	}
 
 	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: gormLogger,
+		Logger:                 gormLogger,
+		PrepareStmt:            cfg.Database.PrepareStmt,
+		SkipDefaultTransaction: cfg.Database.SkipDefaultTransaction,
+		CreateBatchSize:        cfg.Database.CreateBatchSize,
 	})
 	if err != nil {
 		log.Error().Err(err).Str("db_path", dbPath).Msg("Failed to open database")
 		return nil, err
 	}
 
+	// New databases start in incremental auto_vacuum mode so the cleanup job
+	// can reclaim space without a blocking full VACUUM. On an existing
+	// database this is a no-op until the cleanup job converts it.
+	if err := db.Exec("PRAGMA auto_vacuum = INCREMENTAL").Error; err != nil {
+		log.Warn().Err(err).Msg("Failed to set auto_vacuum mode")
+	}
+
 	// Verify database file exists after connection
 	if info, err := os.Stat(dbPath); err == nil {
 		log.Info().Str("db_path", dbPath).Int64("size", info.Size()).Msg("Database file created/opened")
@@ -59,18 +76,134 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// migratedModels are the models Migrate creates tables for
+var migratedModels = []interface{}{
+	&models.Category{},
+	&models.Task{},
+	&models.TaskReport{},
+	&models.TelemetryRollup{},
+	&models.PrivacyAudit{},
+	&models.ConsentRecord{},
+	&models.FeatureFlag{},
+	&models.GenerationRun{},
+	&models.GlossaryTerm{},
+	&models.CategoryRank{},
+	&models.LanguageFreeze{},
+	&models.ImportJob{},
+	&models.ChatWorkspace{},
+	&models.ChatDraw{},
+	&game.GameSession{},
+	&game.Event{},
+}
+
+// SoftDeleteTables returns the tables of the migrated models that soft
+// delete rows, in reverse migration order so dependents come before the
+// tables they reference.
+func SoftDeleteTables(db *gorm.DB) ([]string, error) {
+	var tables []string
+	for i := len(migratedModels) - 1; i >= 0; i-- {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(migratedModels[i]); err != nil {
+			return nil, err
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+				tables = append(tables, stmt.Schema.Table)
+				break
+			}
+		}
+	}
+	return tables, nil
+}
+
 // Migrate runs database migrations.
 func Migrate(db *gorm.DB) error {
 	log.Info().Msg("Running database migrations")
 
-	err := db.AutoMigrate(
-		&models.Category{},
-		&models.Task{},
-	)
+	if err := db.AutoMigrate(migratedModels...); err != nil {
+		return err
+	}
+
+	if err := createIndexes(db); err != nil {
+		return err
+	}
+
+	// Tasks created before short codes existed get one
+	assigned, err := models.AssignShortCodes(db)
 	if err != nil {
 		return err
 	}
+	if assigned > 0 {
+		log.Info().Int("tasks", assigned).Msg("Assigned task short codes")
+	}
+
+	if err := recordSchemaVersion(db); err != nil {
+		return err
+	}
 
 	log.Info().Msg("Database migrations completed")
 	return nil
 }
+
+// index describes a secondary index created outside of AutoMigrate.
+// An empty dialect means the index applies to every database.
+type index struct {
+	Name    string
+	Dialect string
+	SQL     string
+}
+
+// indexes are the composite and expression indexes backing the hot query
+// paths (task listing, random selection and availability counts). They are
+// created with IF NOT EXISTS so re-running migrations is safe.
+var indexes = []index{
+	{
+		Name: "idx_tasks_category_type_active",
+		SQL:  "CREATE INDEX IF NOT EXISTS idx_tasks_category_type_active ON tasks (category_id, type, is_active)",
+	},
+	{
+		Name: "idx_tasks_language_type",
+		SQL:  "CREATE INDEX IF NOT EXISTS idx_tasks_language_type ON tasks (language, type)",
+	},
+	{
+		Name: "idx_tasks_created_at",
+		SQL:  "CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks (created_at)",
+	},
+	{
+		Name: "idx_categories_active_sort",
+		SQL:  "CREATE INDEX IF NOT EXISTS idx_categories_active_sort ON categories (is_active, sort_order)",
+	},
+	{
+		Name:    "idx_categories_label_gin",
+		Dialect: "postgres",
+		SQL:     "CREATE INDEX IF NOT EXISTS idx_categories_label_gin ON categories USING GIN ((label::jsonb))",
+	},
+}
+
+// droppedIndexes were created by earlier migrations and since replaced
+var droppedIndexes = []string{
+	"idx_tasks_category_type_language",
+}
+
+// createIndexes drops replaced indexes and creates the indexes applicable
+// to the active dialect.
+func createIndexes(db *gorm.DB) error {
+	for _, name := range droppedIndexes {
+		if err := db.Exec("DROP INDEX IF EXISTS " + name).Error; err != nil {
+			log.Error().Err(err).Str("index", name).Msg("Failed to drop index")
+			return err
+		}
+	}
+
+	dialect := db.Dialector.Name()
+	for _, idx := range indexes {
+		if idx.Dialect != "" && idx.Dialect != dialect {
+			continue
+		}
+		if err := db.Exec(idx.SQL).Error; err != nil {
+			log.Error().Err(err).Str("index", idx.Name).Msg("Failed to create index")
+			return err
+		}
+	}
+	return nil
+}
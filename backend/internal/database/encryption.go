@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/encryption"
+	"gorm.io/gorm"
+)
+
+// rotateBatchSize is the number of values re-encrypted per query
+const rotateBatchSize = 500
+
+// RotateEncryption re-encrypts every value of the encrypted columns that is
+// plain text or under a key other than the primary one, so retired keys can
+// be dropped from ENCRYPTION_KEYS. It returns the values changed per column.
+func RotateEncryption(db *gorm.DB, keyring *encryption.Keyring) (map[string]int64, error) {
+	return rotateEncryption(db, keyring, migratedModels)
+}
+
+func rotateEncryption(db *gorm.DB, keyring *encryption.Keyring, models []interface{}) (map[string]int64, error) {
+	if keyring == nil {
+		return nil, encryption.ErrNoKeys
+	}
+
+	changed := make(map[string]int64)
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return changed, err
+		}
+		table := stmt.Schema.Table
+		primary := stmt.Schema.PrioritizedPrimaryField
+		for _, field := range stmt.Schema.Fields {
+			if field.TagSettings["SERIALIZER"] != encryption.SerializerName {
+				continue
+			}
+			if primary == nil {
+				return changed, fmt.Errorf("%s has encrypted columns but no primary key", table)
+			}
+			count, err := rotateColumn(db, keyring, table, primary.DBName, field.DBName)
+			if count > 0 {
+				changed[table+"."+field.DBName] = count
+				log.Info().Str("column", table+"."+field.DBName).Int64("values", count).Msg("Re-encrypted column")
+			}
+			if err != nil {
+				return changed, err
+			}
+		}
+	}
+	return changed, nil
+}
+
+// rotateColumn re-encrypts the values of one column, reading them raw so
+// the serializer does not decrypt them
+func rotateColumn(db *gorm.DB, keyring *encryption.Keyring, table, pk, column string) (int64, error) {
+	current := encryption.Prefix + keyring.Primary() + ":%"
+	aad := encryption.AAD(table, column)
+
+	var count int64
+	for {
+		var rows []struct {
+			ID    string
+			Value string
+		}
+		err := db.Table(table).
+			Select(fmt.Sprintf("CAST(%s AS TEXT) AS id, %s AS value", db.Statement.Quote(pk), db.Statement.Quote(column))).
+			Where(fmt.Sprintf("%s <> '' AND %s NOT LIKE ?", db.Statement.Quote(column), db.Statement.Quote(column)), current).
+			Limit(rotateBatchSize).
+			Scan(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return count, err
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				rotated, _, err := keyring.Rotate(row.Value, aad)
+				if err != nil {
+					return fmt.Errorf("%s %s: %w", aad, row.ID, err)
+				}
+				if err := tx.Table(table).Where(fmt.Sprintf("%s = ?", tx.Statement.Quote(pk)), row.ID).UpdateColumn(column, rotated).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+		count += int64(len(rows))
+	}
+}
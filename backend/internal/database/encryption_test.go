@@ -0,0 +1,76 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/truthordare/backend/internal/encryption"
+)
+
+// encryptedSetting is a row with an encrypted column
+type encryptedSetting struct {
+	ID    string `gorm:"primaryKey"`
+	Value string `gorm:"serializer:encrypted"`
+}
+
+func testKey(t *testing.T) string {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestRotateEncryption(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&encryptedSetting{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	oldKey := testKey(t)
+	old, _ := encryption.Parse("old:" + oldKey)
+	encryption.SetDefault(old)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	for i := 0; i < rotateBatchSize+5; i++ {
+		db.Create(&encryptedSetting{ID: fmt.Sprintf("secret-%d", i), Value: "value"})
+	}
+	db.Create(&encryptedSetting{ID: "empty"})
+	db.Exec("INSERT INTO encrypted_settings (id, value) VALUES ('legacy', 'plain')")
+
+	if _, err := rotateEncryption(db, nil, []interface{}{&encryptedSetting{}}); !errors.Is(err, encryption.ErrNoKeys) {
+		t.Errorf("Expected ErrNoKeys without keys, got %v", err)
+	}
+
+	newKey := testKey(t)
+	rotated, _ := encryption.Parse("new:" + newKey + ",old:" + oldKey)
+	changed, err := rotateEncryption(db, rotated, []interface{}{&encryptedSetting{}})
+	if err != nil {
+		t.Fatalf("Rotation failed: %v", err)
+	}
+	if changed["encrypted_settings.value"] != rotateBatchSize+6 {
+		t.Errorf("Expected %d values rotated, got %v", rotateBatchSize+6, changed)
+	}
+
+	var stale int64
+	db.Table("encrypted_settings").Where("value <> '' AND value NOT LIKE 'enc:v1:new:%'").Count(&stale)
+	if stale != 0 {
+		t.Errorf("Expected every value under the new key, %d are not", stale)
+	}
+
+	// The old key can now be dropped
+	newOnly, _ := encryption.Parse("new:" + newKey)
+	encryption.SetDefault(newOnly)
+	var setting encryptedSetting
+	if err := db.First(&setting, "id = ?", "legacy").Error; err != nil || setting.Value != "plain" {
+		t.Errorf("Expected the legacy value to decrypt, got %q (%v)", setting.Value, err)
+	}
+
+	changed, err = rotateEncryption(db, rotated, []interface{}{&encryptedSetting{}})
+	if err != nil || len(changed) != 0 {
+		t.Errorf("Expected a second rotation to change nothing, got %v (%v)", changed, err)
+	}
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Schema versions. Bump SchemaVersion with every migration change. Raise
+// SchemaCompatibleFrom when a migration breaks builds expecting an older
+// schema (dropped or renamed columns), so those builds refuse to start
+// instead of failing on queries.
+const (
+	SchemaVersion        = 23
+	SchemaCompatibleFrom = 1
+)
+
+// migrationLockName is the single lock row guarding migrations
+const migrationLockName = "migrate"
+
+// Migration lock timing. A lock older than migrationLockTTL is considered
+// left behind by a crashed instance and may be taken over.
+const (
+	migrationLockTTL  = 10 * time.Minute
+	migrationLockPoll = time.Second
+)
+
+// ErrMigrationLocked is returned when another instance holds the
+// migration lock past the wait timeout.
+var ErrMigrationLocked = errors.New("migrations are locked by another instance")
+
+// migrationLock is an advisory lock row taken while migrating
+type migrationLock struct {
+	Name       string    `gorm:"type:varchar(50);primaryKey"`
+	Owner      string    `gorm:"type:varchar(100);not null"`
+	AcquiredAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+}
+
+// TableName returns the table name for migrationLock.
+func (migrationLock) TableName() string {
+	return "migration_locks"
+}
+
+// schemaVersion records the schema the last migration produced
+type schemaVersion struct {
+	ID             uint `gorm:"primaryKey"`
+	Version        int  `gorm:"not null"`
+	CompatibleFrom int  `gorm:"not null"`
+	AppliedAt      time.Time
+}
+
+// TableName returns the table name for schemaVersion.
+func (schemaVersion) TableName() string {
+	return "schema_versions"
+}
+
+// WithMigrationLock runs fn while holding the migration lock, so only one
+// instance migrates at a time. It waits up to timeout for the lock.
+func WithMigrationLock(db *gorm.DB, timeout time.Duration, fn func() error) error {
+	if err := db.AutoMigrate(&migrationLock{}); err != nil {
+		return err
+	}
+
+	owner := lockOwner()
+	if err := acquireMigrationLock(db, owner, timeout); err != nil {
+		return err
+	}
+	defer func() {
+		err := db.Where("name = ? AND owner = ?", migrationLockName, owner).Delete(&migrationLock{}).Error
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to release migration lock")
+		}
+	}()
+
+	log.Info().Str("owner", owner).Msg("Migration lock acquired")
+	return fn()
+}
+
+// acquireMigrationLock takes the lock row, or a stale one, polling until
+// the timeout passes.
+func acquireMigrationLock(db *gorm.DB, owner string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		now := time.Now()
+		lock := migrationLock{
+			Name:       migrationLockName,
+			Owner:      owner,
+			AcquiredAt: now,
+			ExpiresAt:  now.Add(migrationLockTTL),
+		}
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil
+		}
+
+		result = db.Model(&migrationLock{}).
+			Where("name = ? AND expires_at < ?", migrationLockName, now).
+			Updates(map[string]interface{}{
+				"owner":       owner,
+				"acquired_at": now,
+				"expires_at":  lock.ExpiresAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			log.Warn().Str("owner", owner).Msg("Took over a stale migration lock")
+			return nil
+		}
+
+		if now.After(deadline) {
+			return ErrMigrationLocked
+		}
+		log.Info().Msg("Waiting for another instance to finish migrations")
+		time.Sleep(migrationLockPoll)
+	}
+}
+
+// lockOwner identifies this process in the lock row
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// recordSchemaVersion stores the schema version after a migration
+func recordSchemaVersion(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaVersion{}); err != nil {
+		return err
+	}
+
+	// An older build migrating during a rolling deploy must not lower the
+	// version a newer build already recorded
+	var current schemaVersion
+	err := db.Limit(1).Find(&current, 1).Error
+	if err != nil {
+		return err
+	}
+	if current.Version > SchemaVersion {
+		return nil
+	}
+
+	return db.Save(&schemaVersion{
+		ID:             1,
+		Version:        SchemaVersion,
+		CompatibleFrom: SchemaCompatibleFrom,
+		AppliedAt:      time.Now().UTC(),
+	}).Error
+}
+
+// CurrentSchemaVersion returns the schema version the last migration
+// recorded, or 0 when the database has not been migrated.
+func CurrentSchemaVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&schemaVersion{}) {
+		return 0, nil
+	}
+	var current schemaVersion
+	if err := db.Limit(1).Find(&current, 1).Error; err != nil {
+		return 0, err
+	}
+	return current.Version, nil
+}
+
+// Preflight verifies the database is reachable and its schema is one this
+// build can serve. Run it before accepting traffic.
+func Preflight(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.Ping(); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+
+	if !db.Migrator().HasTable(&schemaVersion{}) {
+		return fmt.Errorf("database schema is not versioned; run migrations first")
+	}
+
+	var current schemaVersion
+	if err := db.First(&current, 1).Error; err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if current.Version < SchemaVersion {
+		return fmt.Errorf("database schema v%d is older than this build (v%d); run migrations first", current.Version, SchemaVersion)
+	}
+	if current.CompatibleFrom > SchemaVersion {
+		return fmt.Errorf("database schema v%d requires a build with schema v%d or newer (this build: v%d)", current.Version, current.CompatibleFrom, SchemaVersion)
+	}
+
+	log.Info().
+		Int("schema_version", current.Version).
+		Int("build_schema_version", SchemaVersion).
+		Msg("Pre-flight checks passed")
+	return nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestPreflight(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Preflight(db); err == nil {
+		t.Fatal("Expected pre-flight to fail on an unmigrated database")
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := Preflight(db); err != nil {
+		t.Fatalf("Expected pre-flight to pass after migrating, got %v", err)
+	}
+
+	// A newer build raised the compatibility floor past this build
+	db.Model(&schemaVersion{}).Where("id = 1").Updates(map[string]interface{}{
+		"version":         SchemaVersion + 1,
+		"compatible_from": SchemaVersion + 1,
+	})
+	if err := Preflight(db); err == nil {
+		t.Fatal("Expected pre-flight to fail against an incompatible newer schema")
+	}
+
+	// Migrating with this build must not lower the recorded version
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	var current schemaVersion
+	db.First(&current, 1)
+	if current.Version != SchemaVersion+1 {
+		t.Errorf("Expected schema version %d to be kept, got %d", SchemaVersion+1, current.Version)
+	}
+}
+
+func TestWithMigrationLock(t *testing.T) {
+	db := openTestDB(t)
+
+	ran := false
+	err := WithMigrationLock(db, 0, func() error {
+		// A second instance cannot take the lock while it is held
+		if err := acquireMigrationLock(db, "other:1", 0); !errors.Is(err, ErrMigrationLocked) {
+			t.Errorf("Expected ErrMigrationLocked, got %v", err)
+		}
+		ran = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !ran {
+		t.Fatal("Expected the locked function to run")
+	}
+
+	var count int64
+	db.Model(&migrationLock{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the lock to be released, found %d rows", count)
+	}
+
+	// A lock left behind by a crashed instance is taken over once expired
+	db.Create(&migrationLock{
+		Name:       migrationLockName,
+		Owner:      "crashed:1",
+		AcquiredAt: time.Now().Add(-2 * migrationLockTTL),
+		ExpiresAt:  time.Now().Add(-migrationLockTTL),
+	})
+	if err := WithMigrationLock(db, 0, func() error { return nil }); err != nil {
+		t.Fatalf("Expected a stale lock to be taken over, got %v", err)
+	}
+}
+
+func TestMigrateIndexes(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	// A database migrated before the index was replaced still has it
+	if err := db.Exec("CREATE INDEX idx_tasks_category_type_language ON tasks (category_id, type, language)").Error; err != nil {
+		t.Fatalf("Failed to create the replaced index: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	migrator := db.Migrator()
+	if migrator.HasIndex("tasks", "idx_tasks_category_type_language") {
+		t.Error("Expected the replaced index to be dropped")
+	}
+	for _, name := range []string{"idx_tasks_category_type_active", "idx_tasks_created_at"} {
+		if !migrator.HasIndex("tasks", name) {
+			t.Errorf("Expected index %s", name)
+		}
+	}
+}
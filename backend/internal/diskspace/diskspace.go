@@ -0,0 +1,32 @@
+// Package diskspace reports free space on the filesystem holding a path,
+// such as the SQLite database file.
+package diskspace
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// ErrUnsupported is returned on platforms where free space cannot be read.
+var ErrUnsupported = errors.New("disk space is not available on this platform")
+
+// Usage is the space of a filesystem, in bytes. Free counts only the space
+// available to unprivileged processes.
+type Usage struct {
+	Total uint64 `json:"total_bytes"`
+	Free  uint64 `json:"free_bytes"`
+}
+
+// FreePercent returns the free space as a percentage of the total.
+func (u Usage) FreePercent() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Free) / float64(u.Total) * 100
+}
+
+// Of returns the usage of the filesystem holding path. The path need not
+// exist yet; its directory must.
+func Of(path string) (Usage, error) {
+	return statfs(filepath.Dir(path))
+}
@@ -0,0 +1,26 @@
+package diskspace
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	usage, err := Of(filepath.Join(t.TempDir(), "test.db"))
+	if err == ErrUnsupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if usage.Total == 0 || usage.Free > usage.Total {
+		t.Errorf("Expected free space within a non-zero total, got %+v", usage)
+	}
+	if p := usage.FreePercent(); p < 0 || p > 100 {
+		t.Errorf("Expected a percentage, got %v", p)
+	}
+
+	if _, err := Of("/does/not/exist/test.db"); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
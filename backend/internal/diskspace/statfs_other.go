@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package diskspace
+
+// statfs is unsupported on this platform
+func statfs(dir string) (Usage, error) {
+	return Usage{}, ErrUnsupported
+}
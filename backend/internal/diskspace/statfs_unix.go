@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package diskspace
+
+import "syscall"
+
+// statfs reads the usage of the filesystem holding dir
+func statfs(dir string) (Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return Usage{}, err
+	}
+	return Usage{
+		Total: uint64(st.Blocks) * uint64(st.Bsize),
+		Free:  uint64(st.Bavail) * uint64(st.Bsize),
+	}, nil
+}
@@ -0,0 +1,249 @@
+// Package encryption encrypts sensitive columns at rest with AES-256-GCM.
+//
+// Keys come from ENCRYPTION_KEYS, or from the file ENCRYPTION_KEYS_FILE
+// names, as a comma-separated list of id:key pairs where key is 32 bytes in
+// base64. The first key encrypts; the others only decrypt, so a key is
+// rotated by putting a new one first, running the server with
+// -rotate-encryption to re-encrypt stored values, then dropping the old key.
+// The file form lets a KMS or secret manager agent write the keys.
+//
+// Tagging a string field `gorm:"serializer:encrypted"` stores it as
+// "enc:v1:<key id>:<base64 nonce and ciphertext>". The table and column are
+// bound to the ciphertext, so a value copied into another column does not
+// decrypt. Values written before the column was encrypted are read as
+// plain text until they are rewritten or rotated.
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// Prefix starts every encrypted value
+const Prefix = "enc:v1:"
+
+// SerializerName is the GORM serializer encrypting tagged fields
+const SerializerName = "encrypted"
+
+// keySize is the AES-256 key length in bytes
+const keySize = 32
+
+// keyIDPattern limits key IDs to characters that need no escaping in
+// values or LIKE patterns
+var keyIDPattern = regexp.MustCompile(`^[a-z0-9]{1,16}$`)
+
+var (
+	// ErrNoKeys is returned when a value must be encrypted or decrypted and
+	// no keys are configured.
+	ErrNoKeys = errors.New("no encryption keys configured")
+	// ErrUnknownKey is returned when a value was encrypted with a key that
+	// is not configured.
+	ErrUnknownKey = errors.New("value encrypted with an unknown key")
+	// ErrMalformed is returned for encrypted values that cannot be parsed
+	// or fail authentication.
+	ErrMalformed = errors.New("malformed or tampered encrypted value")
+)
+
+// Keyring holds the encryption keys. A nil *Keyring has no keys: it reads
+// plain text values and refuses everything else.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// Parse reads a comma-separated list of id:base64-key pairs, the first
+// being the primary key. An empty spec returns a nil Keyring.
+func Parse(spec string) (*Keyring, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	k := &Keyring{aeads: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("encryption key %q: expected id:key", entry)
+		}
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("encryption key id %q: use 1-16 lowercase letters and digits", id)
+		}
+		if _, exists := k.aeads[id]; exists {
+			return nil, fmt.Errorf("encryption key id %q is listed twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("encryption key %q: expected %d bytes in base64", id, keySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[id] = aead
+		if k.primary == "" {
+			k.primary = id
+		}
+	}
+	return k, nil
+}
+
+// Load parses the keys in the file at path when it is set, or else spec.
+func Load(spec, path string) (*Keyring, error) {
+	if path == "" {
+		return Parse(spec)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read encryption keys: %w", err)
+	}
+	return Parse(string(data))
+}
+
+// Primary returns the ID of the key new values are encrypted with, or ""
+// without keys.
+func (k *Keyring) Primary() string {
+	if k == nil {
+		return ""
+	}
+	return k.primary
+}
+
+// IsEncrypted reports whether a stored value is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt encrypts plaintext with the primary key, binding it to aad.
+func (k *Keyring) Encrypt(plaintext, aad string) (string, error) {
+	if k == nil {
+		return "", ErrNoKeys
+	}
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return Prefix + k.primary + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plain text of a stored value. Values that are not
+// encrypted are returned unchanged.
+func (k *Keyring) Decrypt(value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if k == nil {
+		return "", ErrNoKeys
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// Rotate re-encrypts a stored value with the primary key when it is plain
+// text or under another key, and reports whether it changed. Empty values
+// are left empty.
+func (k *Keyring) Rotate(value, aad string) (string, bool, error) {
+	if k == nil {
+		return "", false, ErrNoKeys
+	}
+	if value == "" || strings.HasPrefix(value, Prefix+k.primary+":") {
+		return value, false, nil
+	}
+	plaintext, err := k.Decrypt(value, aad)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := k.Encrypt(plaintext, aad)
+	return rotated, err == nil, err
+}
+
+// defaultKeyring is the keyring the serializer uses
+var defaultKeyring atomic.Pointer[Keyring]
+
+// SetDefault sets the keyring encrypted columns use.
+func SetDefault(k *Keyring) {
+	defaultKeyring.Store(k)
+}
+
+// Default returns the keyring encrypted columns use.
+func Default() *Keyring {
+	return defaultKeyring.Load()
+}
+
+// AAD returns the additional data binding a column's values to it.
+func AAD(table, column string) string {
+	return table + "." + column
+}
+
+func init() {
+	schema.RegisterSerializer(SerializerName, serializer{})
+}
+
+// serializer encrypts string fields with the default keyring
+type serializer struct{}
+
+// Scan decrypts a column value into the field
+func (serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("encrypted field %s: unsupported database value %T", field.Name, dbValue)
+	}
+
+	plaintext, err := Default().Decrypt(value, AAD(field.Schema.Table, field.DBName))
+	if err != nil {
+		return fmt.Errorf("decrypt %s.%s: %w", field.Schema.Table, field.DBName, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value encrypts the field for the column. Empty strings are stored as is.
+func (serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s: only strings can be encrypted, not %T", field.Name, fieldValue)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+	return Default().Encrypt(plaintext, AAD(field.Schema.Table, field.DBName))
+}
@@ -0,0 +1,171 @@
+package encryption_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/encryption"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newKey(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestParse(t *testing.T) {
+	keyring, err := encryption.Parse("")
+	require.NoError(t, err)
+	assert.Nil(t, keyring, "no keys configured")
+
+	keyring, err = encryption.Parse(" k2:" + newKey(t) + ", k1:" + newKey(t))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keyring.Primary())
+
+	for _, spec := range []string{
+		"k1",
+		"k1:not-base64!",
+		"k1:" + base64.StdEncoding.EncodeToString([]byte("too short")),
+		"Key_1:" + newKey(t),
+		"k1:" + newKey(t) + ",k1:" + newKey(t),
+	} {
+		_, err := encryption.Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(path, []byte("file:"+newKey(t)+"\n"), 0600))
+
+	keyring, err := encryption.Load("env:"+newKey(t), path)
+	require.NoError(t, err)
+	assert.Equal(t, "file", keyring.Primary(), "the file takes precedence")
+
+	_, err = encryption.Load("", filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestKeyring(t *testing.T) {
+	oldKey, newKeyValue := newKey(t), newKey(t)
+	old, err := encryption.Parse("old:" + oldKey)
+	require.NoError(t, err)
+
+	value, err := old.Encrypt("hunter2", "settings.value")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "enc:v1:old:"))
+	assert.NotContains(t, value, "hunter2")
+
+	other, err := old.Encrypt("hunter2", "settings.value")
+	require.NoError(t, err)
+	assert.NotEqual(t, value, other, "every value gets its own nonce")
+
+	plaintext, err := old.Decrypt(value, "settings.value")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	t.Run("bound to its column", func(t *testing.T) {
+		_, err := old.Decrypt(value, "webhooks.secret")
+		assert.ErrorIs(t, err, encryption.ErrMalformed)
+	})
+
+	t.Run("tampering is detected", func(t *testing.T) {
+		tampered := value[:len(value)-2] + "AA"
+		if tampered == value {
+			tampered = value[:len(value)-2] + "BB"
+		}
+		_, err := old.Decrypt(tampered, "settings.value")
+		assert.ErrorIs(t, err, encryption.ErrMalformed)
+	})
+
+	t.Run("plain text is read as is", func(t *testing.T) {
+		plaintext, err := old.Decrypt("legacy", "settings.value")
+		require.NoError(t, err)
+		assert.Equal(t, "legacy", plaintext)
+
+		var none *encryption.Keyring
+		plaintext, err = none.Decrypt("legacy", "settings.value")
+		require.NoError(t, err)
+		assert.Equal(t, "legacy", plaintext)
+		_, err = none.Decrypt(value, "settings.value")
+		assert.ErrorIs(t, err, encryption.ErrNoKeys)
+		_, err = none.Encrypt("hunter2", "settings.value")
+		assert.ErrorIs(t, err, encryption.ErrNoKeys)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		rotated, err := encryption.Parse("new:" + newKeyValue + ",old:" + oldKey)
+		require.NoError(t, err)
+
+		plaintext, err := rotated.Decrypt(value, "settings.value")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", plaintext, "retired keys still decrypt")
+
+		next, changed, err := rotated.Rotate(value, "settings.value")
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.True(t, strings.HasPrefix(next, "enc:v1:new:"))
+
+		same, changed, err := rotated.Rotate(next, "settings.value")
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, next, same)
+
+		encrypted, changed, err := rotated.Rotate("legacy", "settings.value")
+		require.NoError(t, err)
+		assert.True(t, changed, "plain text is encrypted")
+		assert.True(t, encryption.IsEncrypted(encrypted))
+
+		newOnly, err := encryption.Parse("new:" + newKeyValue)
+		require.NoError(t, err)
+		_, err = newOnly.Decrypt(value, "settings.value")
+		assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+	})
+}
+
+// secretSetting is a row with an encrypted column
+type secretSetting struct {
+	ID    string `gorm:"primaryKey"`
+	Value string `gorm:"serializer:encrypted"`
+}
+
+func TestSerializer(t *testing.T) {
+	keyring, err := encryption.Parse("k1:" + newKey(t))
+	require.NoError(t, err)
+	encryption.SetDefault(keyring)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&secretSetting{}))
+
+	require.NoError(t, db.Create(&secretSetting{ID: "webhook", Value: "s3cret"}).Error)
+	require.NoError(t, db.Create(&secretSetting{ID: "empty"}).Error)
+
+	var stored string
+	require.NoError(t, db.Raw("SELECT value FROM secret_settings WHERE id = ?", "webhook").Scan(&stored).Error)
+	assert.True(t, strings.HasPrefix(stored, "enc:v1:k1:"), "stored encrypted")
+
+	var setting secretSetting
+	require.NoError(t, db.First(&setting, "id = ?", "webhook").Error)
+	assert.Equal(t, "s3cret", setting.Value)
+
+	var empty secretSetting
+	require.NoError(t, db.First(&empty, "id = ?", "empty").Error)
+	assert.Empty(t, empty.Value)
+
+	// Rows written before the column was encrypted still read
+	require.NoError(t, db.Exec("INSERT INTO secret_settings (id, value) VALUES (?, ?)", "legacy", "plain").Error)
+	var legacy secretSetting
+	require.NoError(t, db.First(&legacy, "id = ?", "legacy").Error)
+	assert.Equal(t, "plain", legacy.Value)
+}
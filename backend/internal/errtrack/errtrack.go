@@ -0,0 +1,239 @@
+// Package errtrack reports panics, server errors and job failures to an
+// error tracker.
+//
+// ERROR_TRACKING_DSN selects the backend: a Sentry DSN
+// (https://<key>@<host>/<project>) sends events to Sentry, any other URL
+// receives each event as JSON, and an empty value disables reporting.
+// Errors are always logged as well, so reporting is purely additive.
+//
+// Events are queued and delivered in the background, so capturing never
+// blocks a request. When the queue is full new events are dropped.
+package errtrack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event levels
+const (
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// queueSize bounds the events waiting for delivery
+const queueSize = 100
+
+// deliveryTimeout bounds delivery of a single event
+const deliveryTimeout = 10 * time.Second
+
+// Request is the HTTP request an event happened in
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Route  string `json:"route,omitempty"`
+	Status int    `json:"status,omitempty"`
+	IP     string `json:"ip,omitempty"`
+}
+
+// Event is an error report
+type Event struct {
+	Level       string            `json:"level"`
+	Message     string            `json:"message"`
+	Error       string            `json:"error,omitempty"`
+	Stack       string            `json:"stack,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Request     *Request          `json:"request,omitempty"`
+	Time        time.Time         `json:"time"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+}
+
+// Reporter delivers events to an error tracker
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
+
+// Tracker queues events for a Reporter. A nil *Tracker discards events.
+type Tracker struct {
+	reporter    Reporter
+	environment string
+	release     string
+
+	queue   chan Event
+	pending sync.WaitGroup
+}
+
+// NewTracker creates a Tracker delivering to reporter in the background.
+func NewTracker(reporter Reporter, environment, release string) *Tracker {
+	t := &Tracker{
+		reporter:    reporter,
+		environment: environment,
+		release:     release,
+		queue:       make(chan Event, queueSize),
+	}
+	go t.run()
+	return t
+}
+
+// New creates a Tracker for dsn, or returns nil when dsn is empty.
+func New(dsn, environment, release string) (*Tracker, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("errtrack: invalid DSN")
+	}
+
+	var reporter Reporter
+	if parsed.User != nil {
+		reporter, err = NewSentryReporter(dsn)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		reporter = NewWebhookReporter(dsn)
+	}
+	return NewTracker(reporter, environment, release), nil
+}
+
+// Capture queues an event for delivery.
+func (t *Tracker) Capture(event Event) {
+	if t == nil {
+		return
+	}
+
+	if event.Level == "" {
+		event.Level = LevelError
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	event.Environment = t.environment
+	event.Release = t.release
+
+	t.pending.Add(1)
+	select {
+	case t.queue <- event:
+	default:
+		t.pending.Done()
+		log.Warn().Str("message", event.Message).Msg("Error tracking queue full, dropping event")
+	}
+}
+
+// Flush waits up to timeout for queued events to be delivered.
+func (t *Tracker) Flush(timeout time.Duration) {
+	if t == nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		t.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Warn().Msg("Timed out flushing error tracking events")
+	}
+}
+
+// run delivers queued events one at a time
+func (t *Tracker) run() {
+	for event := range t.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		if err := t.reporter.Report(ctx, event); err != nil {
+			log.Error().Err(err).Msg("Failed to report error event")
+		}
+		cancel()
+		t.pending.Done()
+	}
+}
+
+// defaultTracker receives events captured through the package functions
+var (
+	defaultMu      sync.RWMutex
+	defaultTracker *Tracker
+)
+
+// SetDefault sets the tracker used by the package-level functions.
+func SetDefault(t *Tracker) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultTracker = t
+}
+
+// Default returns the tracker used by the package-level functions.
+func Default() *Tracker {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracker
+}
+
+// Capture queues an event on the default tracker.
+func Capture(event Event) {
+	Default().Capture(event)
+}
+
+// Flush waits for the default tracker's queued events.
+func Flush(timeout time.Duration) {
+	Default().Flush(timeout)
+}
+
+// WebhookReporter posts events as JSON to a URL
+type WebhookReporter struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookReporter creates a reporter posting to url
+func NewWebhookReporter(url string) *WebhookReporter {
+	return &WebhookReporter{
+		url:        url,
+		httpClient: &http.Client{Timeout: deliveryTimeout},
+	}
+}
+
+// Report posts the event. Any non-2xx response is an error.
+func (r *WebhookReporter) Report(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("errtrack: failed to marshal event: %w", err)
+	}
+	return post(ctx, r.httpClient, r.url, body, nil)
+}
+
+// post sends a JSON body and checks for a 2xx response
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("errtrack: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("errtrack: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("errtrack: tracker returned status %d", resp.StatusCode)
+	}
+	return nil
+}
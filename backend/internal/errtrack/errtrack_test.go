@@ -0,0 +1,102 @@
+package errtrack_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/errtrack"
+)
+
+// capture records the requests a test tracker endpoint receives
+type capture struct {
+	mu       sync.Mutex
+	paths    []string
+	auth     []string
+	payloads []map[string]interface{}
+}
+
+func (c *capture) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		c.mu.Lock()
+		c.paths = append(c.paths, r.URL.Path)
+		c.auth = append(c.auth, r.Header.Get("X-Sentry-Auth"))
+		c.payloads = append(c.payloads, payload)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNew(t *testing.T) {
+	tracker, err := errtrack.New("", "test", "dev")
+	require.NoError(t, err)
+	assert.Nil(t, tracker)
+
+	// A nil tracker discards events
+	tracker.Capture(errtrack.Event{Message: "ignored"})
+	tracker.Flush(time.Second)
+
+	_, err = errtrack.New("not a url", "test", "dev")
+	assert.Error(t, err)
+}
+
+func TestTracker_Sentry(t *testing.T) {
+	var got capture
+	srv := got.server(t)
+
+	dsn := strings.Replace(srv.URL, "http://", "http://publickey@", 1) + "/42"
+	tracker, err := errtrack.New(dsn, "production", "1.2.3")
+	require.NoError(t, err)
+
+	tracker.Capture(errtrack.Event{
+		Message: "Scheduled job failed: cleanup",
+		Error:   errors.New("disk full").Error(),
+		Tags:    map[string]string{"job": "cleanup"},
+	})
+	tracker.Flush(5 * time.Second)
+
+	got.mu.Lock()
+	defer got.mu.Unlock()
+	require.Len(t, got.payloads, 1)
+	assert.Equal(t, "/api/42/store/", got.paths[0])
+	assert.Contains(t, got.auth[0], "sentry_key=publickey")
+
+	payload := got.payloads[0]
+	assert.Equal(t, "error", payload["level"])
+	assert.Equal(t, "production", payload["environment"])
+	assert.Equal(t, "1.2.3", payload["release"])
+	assert.Len(t, payload["event_id"], 32)
+	assert.Contains(t, payload["exception"], "values")
+}
+
+func TestTracker_Webhook(t *testing.T) {
+	var got capture
+	srv := got.server(t)
+
+	tracker, err := errtrack.New(srv.URL+"/errors", "staging", "dev")
+	require.NoError(t, err)
+
+	tracker.Capture(errtrack.Event{
+		Message: "HTTP 500 from GET /api/v1/tasks",
+		Request: &errtrack.Request{Method: "GET", URL: "/api/v1/tasks", Status: 500},
+	})
+	tracker.Flush(5 * time.Second)
+
+	got.mu.Lock()
+	defer got.mu.Unlock()
+	require.Len(t, got.payloads, 1)
+	assert.Equal(t, "/errors", got.paths[0])
+	assert.Equal(t, "HTTP 500 from GET /api/v1/tasks", got.payloads[0]["message"])
+	assert.Equal(t, "staging", got.payloads[0]["environment"])
+}
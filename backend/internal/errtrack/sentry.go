@@ -0,0 +1,118 @@
+package errtrack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// sentryClient identifies this reporter to Sentry
+const sentryClient = "truthordare-errtrack/1.0"
+
+// SentryReporter sends events to Sentry's store endpoint
+type SentryReporter struct {
+	storeURL   string
+	auth       string
+	httpClient *http.Client
+}
+
+// NewSentryReporter creates a reporter from a Sentry DSN of the form
+// https://<public_key>@<host>/<project_id>
+func NewSentryReporter(dsn string) (*SentryReporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("errtrack: invalid Sentry DSN: %w", err)
+	}
+
+	key := parsed.User.Username()
+	path := strings.Trim(parsed.Path, "/")
+	if key == "" || path == "" {
+		return nil, fmt.Errorf("errtrack: Sentry DSN must include a key and project ID")
+	}
+
+	// Self-hosted Sentry may live under a path prefix: /prefix/<project>
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+
+	return &SentryReporter{
+		storeURL:   fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, prefix, project),
+		auth:       fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, key),
+		httpClient: &http.Client{Timeout: deliveryTimeout},
+	}, nil
+}
+
+// sentryEvent is the subset of Sentry's event payload this reporter fills
+type sentryEvent struct {
+	EventID     string             `json:"event_id"`
+	Timestamp   string             `json:"timestamp"`
+	Level       string             `json:"level"`
+	Platform    string             `json:"platform"`
+	Logger      string             `json:"logger"`
+	Message     string             `json:"message"`
+	Environment string             `json:"environment,omitempty"`
+	Release     string             `json:"release,omitempty"`
+	Tags        map[string]string  `json:"tags,omitempty"`
+	Extra       map[string]string  `json:"extra,omitempty"`
+	Exception   *sentryExceptions  `json:"exception,omitempty"`
+	Request     *sentryRequestInfo `json:"request,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sentryRequestInfo struct {
+	URL    string `json:"url"`
+	Method string `json:"method"`
+}
+
+// Report sends the event to Sentry.
+func (r *SentryReporter) Report(ctx context.Context, event Event) error {
+	payload := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   event.Time.UTC().Format("2006-01-02T15:04:05Z"),
+		Level:       event.Level,
+		Platform:    "go",
+		Logger:      "errtrack",
+		Message:     event.Message,
+		Environment: event.Environment,
+		Release:     event.Release,
+		Tags:        event.Tags,
+	}
+	if event.Error != "" {
+		payload.Exception = &sentryExceptions{Values: []sentryException{{Type: "error", Value: event.Error}}}
+	}
+	if event.Stack != "" {
+		payload.Extra = map[string]string{"stack": event.Stack}
+	}
+	if event.Request != nil {
+		payload.Request = &sentryRequestInfo{URL: event.Request.URL, Method: event.Request.Method}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("errtrack: failed to marshal event: %w", err)
+	}
+	return post(ctx, r.httpClient, r.storeURL, body, map[string]string{"X-Sentry-Auth": r.auth})
+}
+
+// newEventID returns a random 32 character hex ID
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strings.Repeat("0", 32)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,280 @@
+// Package featureflags gates risky new behaviors behind flags that can be
+// rolled out to a percentage of traffic and flipped at runtime.
+//
+// Every flag is declared in Definitions with a default. The default can be
+// overridden per environment with FEATURE_<NAME> (true, false or a rollout
+// percentage 0-100), and at runtime through the admin API, which stores the
+// override in the feature_flags table. Runtime overrides win over the
+// environment, which wins over the declared default.
+package featureflags
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Flag names
+const (
+	WeightedRandom     = "weighted_random"
+	ModerationPipeline = "moderation_pipeline"
+	GraphQL            = "graphql"
+)
+
+// Definition declares a flag and its default state.
+type Definition struct {
+	Name           string
+	Description    string
+	Enabled        bool
+	RolloutPercent int
+	// Client flags change app behavior and are served to clients in their
+	// runtime configuration.
+	Client bool
+}
+
+// Definitions lists every known flag. Flags not listed here cannot be set.
+var Definitions = []Definition{
+	{Name: WeightedRandom, Description: "Weight random task selection instead of picking uniformly", RolloutPercent: 100},
+	{Name: ModerationPipeline, Description: "Route reports and generated tasks through the new moderation pipeline", RolloutPercent: 100},
+	{Name: GraphQL, Description: "Serve the GraphQL endpoint", RolloutPercent: 100, Client: true},
+}
+
+// refreshInterval bounds how stale runtime overrides made on another
+// instance can be
+const refreshInterval = 30 * time.Second
+
+// ErrUnknownFlag is returned when setting a flag missing from Definitions.
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// State is the effective state of a flag.
+type State struct {
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Enabled        bool   `json:"enabled"`
+	RolloutPercent int    `json:"rollout_percent"`
+	Source         string `json:"source"` // "default", "env" or "runtime"
+}
+
+// Store resolves flags from defaults, the environment and runtime
+// overrides. It is safe for concurrent use. A nil *Store reports every
+// flag as disabled.
+type Store struct {
+	db *gorm.DB
+
+	mu       sync.RWMutex
+	states   map[string]State
+	loadedAt time.Time
+}
+
+// New creates a Store reading runtime overrides from db. With a nil db
+// only defaults and the environment apply.
+func New(db *gorm.DB) *Store {
+	s := &Store{db: db}
+	s.reload()
+	return s
+}
+
+// Enabled reports whether the flag is on for subject. Subjects (a client
+// or task ID) are bucketed by hash, so a partial rollout gives the same
+// subject the same answer every time. An empty subject is bucketed at random.
+func (s *Store) Enabled(name, subject string) bool {
+	if s == nil {
+		return false
+	}
+
+	state, ok := s.state(name)
+	if !ok || !state.Enabled {
+		return false
+	}
+	if state.RolloutPercent >= 100 {
+		return true
+	}
+	return bucket(name, subject) < state.RolloutPercent
+}
+
+// ClientFlags reports whether each client flag is on for subject, as
+// Enabled does.
+func (s *Store) ClientFlags(subject string) map[string]bool {
+	flags := make(map[string]bool)
+	for _, def := range Definitions {
+		if def.Client {
+			flags[def.Name] = s.Enabled(def.Name, subject)
+		}
+	}
+	return flags
+}
+
+// All returns the effective state of every flag, sorted by name.
+func (s *Store) All() []State {
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make([]State, 0, len(s.states))
+	for _, state := range s.states {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// Set stores a runtime override for a flag.
+func (s *Store) Set(name string, enabled bool, rolloutPercent int) (State, error) {
+	if _, ok := definition(name); !ok {
+		return State{}, ErrUnknownFlag
+	}
+
+	flag := models.FeatureFlag{Name: name, Enabled: enabled, RolloutPercent: clampPercent(rolloutPercent)}
+	err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&flag).Error
+	if err != nil {
+		return State{}, err
+	}
+
+	s.reload()
+	state, _ := s.state(name)
+	return state, nil
+}
+
+// Reset removes the runtime override, so the flag falls back to the
+// environment or its default.
+func (s *Store) Reset(name string) (State, error) {
+	if _, ok := definition(name); !ok {
+		return State{}, ErrUnknownFlag
+	}
+
+	if err := s.db.Delete(&models.FeatureFlag{}, "name = ?", name).Error; err != nil {
+		return State{}, err
+	}
+
+	s.reload()
+	state, _ := s.state(name)
+	return state, nil
+}
+
+// state returns the effective state of one flag
+func (s *Store) state(name string) (State, bool) {
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.states[name]
+	return state, ok
+}
+
+// refreshIfStale reloads runtime overrides once refreshInterval has passed
+func (s *Store) refreshIfStale() {
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) > refreshInterval
+	s.mu.RUnlock()
+
+	if stale {
+		s.reload()
+	}
+}
+
+// reload rebuilds the flag states from defaults, env and the database
+func (s *Store) reload() {
+	states := make(map[string]State, len(Definitions))
+	for _, def := range Definitions {
+		states[def.Name] = fromEnv(def)
+	}
+
+	if s.db != nil {
+		var overrides []models.FeatureFlag
+		if err := s.db.Find(&overrides).Error; err != nil {
+			// Keep serving the last known states rather than resetting flags
+			log.Error().Err(err).Msg("Failed to load feature flag overrides")
+			s.mu.Lock()
+			s.loadedAt = time.Now()
+			s.mu.Unlock()
+			return
+		}
+		for _, override := range overrides {
+			state, ok := states[override.Name]
+			if !ok {
+				continue
+			}
+			state.Enabled = override.Enabled
+			state.RolloutPercent = clampPercent(override.RolloutPercent)
+			state.Source = "runtime"
+			states[override.Name] = state
+		}
+	}
+
+	s.mu.Lock()
+	s.states = states
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// fromEnv applies a FEATURE_<NAME> override to a definition
+func fromEnv(def Definition) State {
+	state := State{
+		Name:           def.Name,
+		Description:    def.Description,
+		Enabled:        def.Enabled,
+		RolloutPercent: clampPercent(def.RolloutPercent),
+		Source:         "default",
+	}
+
+	value, ok := os.LookupEnv("FEATURE_" + strings.ToUpper(def.Name))
+	if !ok || value == "" {
+		return state
+	}
+
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		state.Enabled = enabled
+		state.RolloutPercent = 100
+		state.Source = "env"
+	} else if percent, err := strconv.Atoi(value); err == nil {
+		state.Enabled = percent > 0
+		state.RolloutPercent = clampPercent(percent)
+		state.Source = "env"
+	} else {
+		log.Warn().Str("flag", def.Name).Str("value", value).Msg("Ignoring invalid feature flag environment value")
+	}
+	return state
+}
+
+// definition looks up a declared flag
+func definition(name string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// bucket maps a subject to 0-99 for percentage rollouts. The flag name is
+// part of the hash so rollouts of different flags are independent.
+func bucket(name, subject string) int {
+	if subject == "" {
+		return rand.Intn(100)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + subject))
+	return int(h.Sum32() % 100)
+}
+
+func clampPercent(percent int) int {
+	if percent < 0 {
+		return 0
+	}
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
@@ -0,0 +1,94 @@
+package featureflags_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupStore(t *testing.T) *featureflags.Store {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.FeatureFlag{}))
+	return featureflags.New(db)
+}
+
+func stateOf(store *featureflags.Store, name string) featureflags.State {
+	for _, state := range store.All() {
+		if state.Name == name {
+			return state
+		}
+	}
+	return featureflags.State{}
+}
+
+func TestStore(t *testing.T) {
+	t.Setenv("FEATURE_GRAPHQL", "true")
+	t.Setenv("FEATURE_MODERATION_PIPELINE", "25")
+	store := setupStore(t)
+
+	t.Run("defaults are off", func(t *testing.T) {
+		assert.False(t, store.Enabled(featureflags.WeightedRandom, "client"))
+		assert.Equal(t, "default", stateOf(store, featureflags.WeightedRandom).Source)
+	})
+
+	t.Run("env overrides defaults", func(t *testing.T) {
+		assert.True(t, store.Enabled(featureflags.GraphQL, ""))
+
+		state := stateOf(store, featureflags.ModerationPipeline)
+		assert.Equal(t, "env", state.Source)
+		assert.True(t, state.Enabled)
+		assert.Equal(t, 25, state.RolloutPercent)
+	})
+
+	t.Run("runtime overrides env", func(t *testing.T) {
+		state, err := store.Set(featureflags.GraphQL, false, 100)
+		require.NoError(t, err)
+		assert.Equal(t, "runtime", state.Source)
+		assert.False(t, store.Enabled(featureflags.GraphQL, ""))
+
+		state, err = store.Reset(featureflags.GraphQL)
+		require.NoError(t, err)
+		assert.Equal(t, "env", state.Source)
+		assert.True(t, store.Enabled(featureflags.GraphQL, ""))
+	})
+
+	t.Run("partial rollout is stable per subject", func(t *testing.T) {
+		_, err := store.Set(featureflags.WeightedRandom, true, 30)
+		require.NoError(t, err)
+
+		enabled := 0
+		for i := 0; i < 1000; i++ {
+			subject := fmt.Sprintf("client-%d", i)
+			first := store.Enabled(featureflags.WeightedRandom, subject)
+			assert.Equal(t, first, store.Enabled(featureflags.WeightedRandom, subject))
+			if first {
+				enabled++
+			}
+		}
+		assert.InDelta(t, 300, enabled, 60)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		_, err := store.Set("nope", true, 100)
+		assert.ErrorIs(t, err, featureflags.ErrUnknownFlag)
+		assert.False(t, store.Enabled("nope", ""))
+	})
+
+	t.Run("client flags leave out server-side flags", func(t *testing.T) {
+		flags := store.ClientFlags("client")
+		assert.Equal(t, map[string]bool{featureflags.GraphQL: true}, flags)
+	})
+
+	t.Run("nil store is off", func(t *testing.T) {
+		var nilStore *featureflags.Store
+		assert.False(t, nilStore.Enabled(featureflags.GraphQL, ""))
+		assert.Equal(t, map[string]bool{featureflags.GraphQL: false}, nilStore.ClientFlags(""))
+	})
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+)
+
+// Session event types
+const (
+	EventDrawn     = "drawn"
+	EventSkipped   = "skipped"
+	EventCompleted = "completed"
+	// Timed turns
+	EventTurnExpired = "turn_expired"
+	EventForfeit     = "forfeit"
+	// Host operations
+	EventKicked            = "kicked"
+	EventLocked            = "locked"
+	EventUnlocked          = "unlocked"
+	EventCategoriesChanged = "categories_changed"
+	EventEnded             = "ended"
+	EventCustomTaskAdded   = "custom_task_added"
+	EventExclusionsChanged = "exclusions_changed"
+)
+
+// Event is something that happened in a game session. Events are numbered
+// from 1 per session, so a device that lost its connection can ask for the
+// ones it missed. They are deleted with their session.
+type Event struct {
+	SessionID string `gorm:"type:varchar(36);primaryKey" json:"-"`
+	Seq       int    `gorm:"primaryKey;autoIncrement:false" json:"id"`
+	Type      string `gorm:"type:varchar(20);not null" json:"type"`
+	// Player is who the event's task is for, or who was kicked
+	Player string `gorm:"type:varchar(100)" json:"player,omitempty"`
+	// Task is kept as it was drawn, so later edits of the task do not
+	// change the session's history
+	Task *models.Task `gorm:"type:json;serializer:json" json:"task,omitempty"`
+	// Deadline is when the turn of a drawn task expires, in timed games
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Players and CategoryIDs are set by the host operations changing them
+	Players     models.StringArray `gorm:"type:json" json:"players,omitempty"`
+	CategoryIDs models.StringArray `gorm:"type:json" json:"category_ids,omitempty"`
+	// Round and CurrentPlayer describe the game after the event
+	Round         int       `gorm:"not null;default:0" json:"round"`
+	CurrentPlayer string    `gorm:"type:varchar(100)" json:"current_player,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for Event.
+func (Event) TableName() string {
+	return "game_events"
+}
@@ -0,0 +1,327 @@
+// Package game keeps game sessions on the server: the players taking
+// turns, the categories, languages and age groups a game draws from, and
+// the tasks it has already served. Clients draw the next task of a session
+// instead of passing a growing exclude list to GET /tasks/random.
+package game
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// Session limits
+const (
+	MaxPlayers        = 20
+	MaxPlayerNameRune = 40
+	// Timed turns last MinTurnSeconds to MaxTurnSeconds
+	MinTurnSeconds = 5
+	MaxTurnSeconds = 600
+	// MaxCustomTasks bounds the custom tasks waiting to be drawn
+	MaxCustomTasks = 50
+	// MaxExclusions bounds the tasks, and the categories, a session excludes
+	MaxExclusions = 500
+)
+
+// Room codes are short enough to read out across a table and skip
+// characters that are easily confused, such as 0 and O.
+const (
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	codeLength   = 6
+)
+
+// GameSession is a game in progress. Players take turns in order; each
+// draw goes to the current player and passes the turn on. The device that
+// starts a session gets a host token for host-only operations, such as
+// kicking players or ending the game. In timed games the server expires
+// a turn whose task was not skipped or completed in time. Sessions expire
+// a while after their last draw.
+type GameSession struct {
+	models.BaseModel
+	// Code lets other devices join the session's room.
+	Code        string             `gorm:"size:8;index" json:"code"`
+	Players     models.StringArray `gorm:"type:json;not null" json:"players"`
+	CategoryIDs models.StringArray `gorm:"type:json" json:"category_ids,omitempty"`
+	Languages   models.StringArray `gorm:"type:json" json:"languages,omitempty"`
+	AgeGroups   models.StringArray `gorm:"type:json" json:"age_groups,omitempty"`
+	// RequiresConsent limits draws to tasks that do (true) or do not
+	// (false) require consent; nil allows both, as on GET /tasks/random.
+	RequiresConsent *bool `json:"requires_consent,omitempty"`
+	// Round counts the draws so far; the current player is
+	// Players[(Round + TurnOffset) % len(Players)].
+	Round int `gorm:"not null;default:0" json:"round"`
+	// ServedTaskIDs are the session's last draws, oldest first.
+	ServedTaskIDs models.StringArray `gorm:"type:json" json:"-"`
+	ExpiresAt     time.Time          `gorm:"not null;index" json:"expires_at"`
+	// LastEventID is the number of the session's last event.
+	LastEventID int `gorm:"not null;default:0" json:"last_event_id"`
+	// OpenDrawID is the event of the task drawn last, until it is skipped
+	// or completed; 0 when no task is open.
+	OpenDrawID int `gorm:"not null;default:0" json:"-"`
+	// TurnOffset keeps the turn order when players are kicked.
+	TurnOffset int `gorm:"not null;default:0" json:"-"`
+	// Locked rooms take no more devices.
+	Locked  bool       `gorm:"not null;default:false" json:"locked"`
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+	// HostToken is only set on a session just created; the hash is stored.
+	HostToken     string `gorm:"-" json:"host_token,omitempty"`
+	HostTokenHash string `gorm:"type:varchar(64)" json:"-"`
+	// TurnSeconds is how long a player has to skip or complete a drawn
+	// task before the turn expires; 0 leaves turns untimed.
+	TurnSeconds int `gorm:"not null;default:0" json:"turn_seconds,omitempty"`
+	// ForfeitOnTimeout draws a dare for a player whose turn expired.
+	ForfeitOnTimeout bool `gorm:"not null;default:false" json:"forfeit_on_timeout,omitempty"`
+	// TurnDeadline is when the open task's turn expires, in timed games.
+	TurnDeadline *time.Time `json:"turn_deadline,omitempty"`
+	// CustomTasks are the host's custom tasks not drawn yet, oldest first.
+	CustomTasks CustomTasks `gorm:"type:json" json:"-"`
+	// ExcludedTaskIDs and ExcludedCategoryIDs are the tasks and categories
+	// the host hid from the session's draws.
+	ExcludedTaskIDs     models.StringArray `gorm:"type:json" json:"excluded_task_ids,omitempty"`
+	ExcludedCategoryIDs models.StringArray `gorm:"type:json" json:"excluded_category_ids,omitempty"`
+}
+
+// CustomTask is a one-off prompt the host added to a session. It is drawn
+// once, before the session's other tasks of its type, and is only kept
+// with the session, never as a task.
+type CustomTask struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Text     string    `json:"text"`
+	Language string    `json:"language,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// CustomTasks are custom tasks stored as a JSON column
+type CustomTasks []CustomTask
+
+// Value implements the driver.Valuer interface.
+func (t CustomTasks) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan implements the sql.Scanner interface.
+func (t *CustomTasks) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("failed to unmarshal CustomTasks")
+	}
+	return json.Unmarshal(data, t)
+}
+
+// task returns the custom task as a task to draw
+func (t CustomTask) task() *models.Task {
+	return &models.Task{
+		BaseModel: models.BaseModel{ID: t.ID, CreatedAt: t.AddedAt, UpdatedAt: t.AddedAt},
+		Type:      t.Type,
+		Text:      t.Text,
+		Language:  t.Language,
+		IsActive:  true,
+	}
+}
+
+// TableName returns the table name for GameSession.
+func (GameSession) TableName() string {
+	return "game_sessions"
+}
+
+// Validate checks the players, languages and age groups.
+func (s GameSession) Validate() []models.FieldError {
+	var errs []models.FieldError
+	if len(s.Players) == 0 || len(s.Players) > MaxPlayers {
+		errs = append(errs, models.FieldError{Field: "players", Message: "must name 1-20 players"})
+	}
+	seen := make(map[string]bool, len(s.Players))
+	for _, player := range s.Players {
+		if player == "" || utf8.RuneCountInString(player) > MaxPlayerNameRune {
+			errs = append(errs, models.FieldError{Field: "players", Message: "names must be 1-40 characters"})
+			break
+		}
+		if seen[player] {
+			errs = append(errs, models.FieldError{Field: "players", Message: "names must be unique"})
+			break
+		}
+		seen[player] = true
+	}
+	if s.TurnSeconds != 0 && (s.TurnSeconds < MinTurnSeconds || s.TurnSeconds > MaxTurnSeconds) {
+		errs = append(errs, models.FieldError{Field: "turn_seconds", Message: "must be 0 or 5-600 seconds"})
+	}
+	for _, language := range s.Languages {
+		if !models.IsValidLanguage(language) {
+			errs = append(errs, models.FieldError{Field: "languages", Message: "unsupported language " + language})
+		}
+	}
+	for _, group := range s.AgeGroups {
+		if !models.IsValidAgeGroup(group) {
+			errs = append(errs, models.FieldError{Field: "age_groups", Message: "must be kids, teen or adults"})
+			break
+		}
+	}
+	return errs
+}
+
+// CurrentPlayer returns the player whose turn it is.
+func (s GameSession) CurrentPlayer() string {
+	if len(s.Players) == 0 {
+		return ""
+	}
+	return s.Players[(s.Round+s.TurnOffset)%len(s.Players)]
+}
+
+// IsHost reports whether token is the session's host token.
+func (s GameSession) IsHost(token string) bool {
+	if token == "" || s.HostTokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashHostToken(token)), []byte(s.HostTokenHash)) == 1
+}
+
+// TimeLeft returns the time until a turn deadline, 0 when it is nil or
+// past.
+func TimeLeft(deadline *time.Time) time.Duration {
+	if deadline == nil {
+		return 0
+	}
+	return max(time.Until(*deadline), 0)
+}
+
+// Ended reports whether the host ended the game.
+func (s GameSession) Ended() bool {
+	return s.EndedAt != nil
+}
+
+// kick removes a player, keeping the turn with the player it was with or,
+// when the kicked player had the turn, passing it to the next one
+func (s *GameSession) kick(player string) bool {
+	kicked := -1
+	for i, p := range s.Players {
+		if p == player {
+			kicked = i
+			break
+		}
+	}
+	if kicked < 0 {
+		return false
+	}
+
+	current := (s.Round + s.TurnOffset) % len(s.Players)
+	if kicked < current {
+		current--
+	}
+	s.Players = append(append(models.StringArray(nil), s.Players[:kicked]...), s.Players[kicked+1:]...)
+	current %= len(s.Players)
+	s.TurnOffset = ((current-s.Round)%len(s.Players) + len(s.Players)) % len(s.Players)
+	return true
+}
+
+// NormalizeCode returns a room code as generated, ignoring case and
+// surrounding space.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newHostToken returns a random host token
+func newHostToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashHostToken returns the form of a host token stored
+func hashHostToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newCode returns a random room code
+func newCode() (string, error) {
+	b := make([]byte, codeLength)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// filter returns the task filter of the session's next draw, excluding
+// the tasks it already served and those the host excluded
+func (s GameSession) filter(taskType string) *repository.TaskFilter {
+	active := true
+	roll := rand.Intn(models.FullRollout)
+	return &repository.TaskFilter{
+		CategoryIDs:        s.CategoryIDs,
+		Languages:          s.Languages,
+		AgeGroups:          s.AgeGroups,
+		Type:               taskType,
+		RequiresConsent:    s.RequiresConsent,
+		IsActive:           &active,
+		ExcludeIDs:         append(append([]string(nil), s.ServedTaskIDs...), s.ExcludedTaskIDs...),
+		ExcludeCategoryIDs: s.ExcludedCategoryIDs,
+		RolloutRoll:        &roll,
+	}
+}
+
+// exclude adds tasks and categories to the session's exclusions, once
+// each
+func (s *GameSession) exclude(taskIDs, categoryIDs []string) {
+	s.ExcludedTaskIDs = appendNew(s.ExcludedTaskIDs, taskIDs)
+	s.ExcludedCategoryIDs = appendNew(s.ExcludedCategoryIDs, categoryIDs)
+}
+
+// appendNew appends the IDs not in list yet
+func appendNew(list models.StringArray, ids []string) models.StringArray {
+	seen := make(map[string]bool, len(list))
+	for _, id := range list {
+		seen[id] = true
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			list = append(list, id)
+			seen[id] = true
+		}
+	}
+	return list
+}
+
+// takeCustom removes and returns the oldest custom task of taskType, or
+// either type when empty, nil when there is none
+func (s *GameSession) takeCustom(taskType string) *CustomTask {
+	for i, custom := range s.CustomTasks {
+		if taskType == "" || custom.Type == taskType {
+			s.CustomTasks = append(append(CustomTasks(nil), s.CustomTasks[:i]...), s.CustomTasks[i+1:]...)
+			return &custom
+		}
+	}
+	return nil
+}
+
+// served appends a drawn task to the history, keeping the last keep
+func (s *GameSession) served(taskID string, keep int) {
+	s.ServedTaskIDs = append(s.ServedTaskIDs, taskID)
+	if over := len(s.ServedTaskIDs) - max(keep, 1); over > 0 {
+		s.ServedTaskIDs = append(models.StringArray(nil), s.ServedTaskIDs[over:]...)
+	}
+}
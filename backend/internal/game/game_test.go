@@ -0,0 +1,393 @@
+package game_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err, "failed to open test database")
+	require.NoError(t, db.AutoMigrate(&models.Category{}, &models.Task{}, &game.GameSession{}, &game.Event{}))
+	return db
+}
+
+func TestGameSession_Validate(t *testing.T) {
+	valid := game.GameSession{Players: models.StringArray{"Ana", "Ben"}, Languages: models.StringArray{"en"}, AgeGroups: models.StringArray{"teen"}}
+	assert.Empty(t, valid.Validate())
+
+	for name, session := range map[string]game.GameSession{
+		"no players":     {},
+		"empty name":     {Players: models.StringArray{"Ana", ""}},
+		"duplicate name": {Players: models.StringArray{"Ana", "Ana"}},
+		"language":       {Players: models.StringArray{"Ana"}, Languages: models.StringArray{"xx"}},
+		"age group":      {Players: models.StringArray{"Ana"}, AgeGroups: models.StringArray{"toddlers"}},
+		"turn seconds":   {Players: models.StringArray{"Ana"}, TurnSeconds: 2},
+	} {
+		assert.NotEmpty(t, session.Validate(), name)
+	}
+}
+
+func TestService_Next(t *testing.T) {
+	db := setupTestDB(t)
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+	dares := map[string]bool{}
+	for _, text := range []string{"Dare one", "Dare two", "Dare three"} {
+		task := &models.Task{Text: text, Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+		require.NoError(t, db.Create(task).Error)
+		dares[task.ID] = true
+	}
+	require.NoError(t, db.Create(&models.Task{Text: "Verdad", Language: "es", Type: models.TaskTypeTruth, CategoryID: category.ID}).Error)
+
+	mode := maintenance.New(false)
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, mode)
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}, Languages: models.StringArray{"en"}}
+	require.NoError(t, service.Create(session))
+	assert.Equal(t, "Ana", session.CurrentPlayer())
+	assert.Len(t, session.Code, 6)
+
+	t.Run("room codes", func(t *testing.T) {
+		found, err := service.FindByCode(" " + strings.ToLower(session.Code))
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, found.ID)
+		_, err = service.FindByCode("NOPE")
+		assert.ErrorIs(t, err, game.ErrNotFound)
+	})
+
+	t.Run("players take turns and tasks do not repeat", func(t *testing.T) {
+		seen := map[string]bool{}
+		for i, want := range []string{"Ana", "Ben", "Ana"} {
+			task, player, updated, err := service.Next(session.ID, "")
+			require.NoError(t, err)
+			assert.Equal(t, want, player)
+			assert.Equal(t, i+1, updated.Round)
+			assert.True(t, dares[task.ID], "only tasks in the session's languages")
+			assert.False(t, seen[task.ID], "repeated %s", task.Text)
+			seen[task.ID] = true
+		}
+
+		task, player, _, err := service.Next(session.ID, models.TaskTypeDare)
+		require.NoError(t, err, "history starts over once every task was drawn")
+		assert.Equal(t, "Ben", player)
+		assert.True(t, dares[task.ID])
+
+		_, _, _, err = service.Next(session.ID, models.TaskTypeTruth)
+		assert.ErrorIs(t, err, game.ErrNoTask)
+	})
+
+	t.Run("read-only mode", func(t *testing.T) {
+		mode.Set(true, "test")
+		defer mode.Set(false, "")
+		_, _, _, err := service.Next(session.ID, "")
+		assert.ErrorIs(t, err, maintenance.ErrReadOnly)
+	})
+
+	t.Run("expired sessions", func(t *testing.T) {
+		expired := &game.GameSession{Players: models.StringArray{"Cy"}}
+		require.NoError(t, service.Create(expired))
+		require.NoError(t, db.Model(expired).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		_, err := service.Find(expired.ID)
+		assert.ErrorIs(t, err, game.ErrNotFound)
+		_, err = service.FindByCode(expired.Code)
+		assert.ErrorIs(t, err, game.ErrNotFound)
+		_, _, _, err = service.Next(expired.ID, "")
+		assert.ErrorIs(t, err, game.ErrNotFound)
+
+		require.NoError(t, service.Create(&game.GameSession{Players: models.StringArray{"Di"}}))
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&game.GameSession{}).Where("id = ?", expired.ID).Count(&count).Error)
+		assert.Zero(t, count, "expired sessions are deleted when new ones are created")
+	})
+}
+
+func TestService_Events(t *testing.T) {
+	db := setupTestDB(t)
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+	task := &models.Task{Text: "Dance", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	require.NoError(t, db.Create(task).Error)
+
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, nil)
+	var published []game.Event
+	service.Listen(func(event game.Event) { published = append(published, event) })
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}}
+	require.NoError(t, service.Create(session))
+
+	_, err := service.Resolve(session.ID, game.EventSkipped)
+	assert.ErrorIs(t, err, game.ErrNoOpenTask)
+
+	_, _, drawnSession, err := service.Next(session.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, drawnSession.LastEventID)
+	drawn, err := service.OpenDraw(drawnSession)
+	require.NoError(t, err)
+	require.NotNil(t, drawn)
+	assert.Equal(t, "Ana", drawn.Player)
+
+	// The history keeps the task as drawn
+	require.NoError(t, db.Model(task).Update("text", "Dance badly").Error)
+	completed, err := service.Resolve(session.ID, game.EventCompleted)
+	require.NoError(t, err)
+	assert.Equal(t, 2, completed.Seq)
+	assert.Equal(t, "Ana", completed.Player)
+	assert.Equal(t, "Ben", completed.CurrentPlayer)
+	assert.Equal(t, "Dance", completed.Task.Text)
+	_, err = service.Resolve(session.ID, game.EventCompleted)
+	assert.ErrorIs(t, err, game.ErrNoOpenTask, "a task is completed once")
+
+	events, err := service.Events(session.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, game.EventDrawn, events[0].Type)
+	assert.Equal(t, game.EventCompleted, events[1].Type)
+	require.Len(t, published, 2, "every event is passed to the listener")
+	assert.Equal(t, game.EventCompleted, published[1].Type)
+	assert.Equal(t, 2, published[1].Seq)
+	events, err = service.Events(session.ID, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 2, events[0].Seq)
+
+	// Events are deleted with their session
+	require.NoError(t, db.Model(&game.GameSession{}).Where("id = ?", session.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	require.NoError(t, service.Create(&game.GameSession{Players: models.StringArray{"Cy"}}))
+	events, err = service.Events(session.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestService_HostControls(t *testing.T) {
+	db := setupTestDB(t)
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Dance", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}).Error)
+
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben", "Cy", "Di"}}
+	require.NoError(t, service.Create(session))
+	require.NotEmpty(t, session.HostToken)
+
+	found, err := service.Lookup(session.Code)
+	require.NoError(t, err)
+	assert.Empty(t, found.HostToken, "the token is only returned on creation")
+	assert.True(t, found.IsHost(session.HostToken))
+	assert.False(t, found.IsHost("guess"))
+	assert.False(t, found.IsHost(""))
+
+	t.Run("kicks keep the turn order", func(t *testing.T) {
+		// Ben's turn
+		_, _, found, err := service.Next(session.ID, "")
+		require.NoError(t, err)
+		require.Equal(t, "Ben", found.CurrentPlayer())
+
+		require.NoError(t, service.Kick(found, "Ana"))
+		assert.Equal(t, "Ben", found.CurrentPlayer(), "kicking an earlier player keeps the turn")
+		require.NoError(t, service.Kick(found, "Ben"))
+		assert.Equal(t, "Cy", found.CurrentPlayer(), "kicking the current player passes the turn on")
+		assert.ErrorIs(t, service.Kick(found, "Ben"), game.ErrUnknownPlayer)
+
+		_, player, found, err := service.Next(session.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, "Cy", player)
+		assert.Equal(t, "Di", found.CurrentPlayer())
+		require.NoError(t, service.Kick(found, "Di"))
+		assert.Equal(t, "Cy", found.CurrentPlayer(), "the turn wraps around")
+		assert.ErrorIs(t, service.Kick(found, "Cy"), game.ErrLastPlayer)
+
+		events, err := service.Events(session.ID, 0, 10)
+		require.NoError(t, err)
+		last := events[len(events)-1]
+		assert.Equal(t, game.EventKicked, last.Type)
+		assert.Equal(t, "Di", last.Player)
+		assert.Equal(t, models.StringArray{"Cy"}, last.Players)
+	})
+
+	t.Run("ended games", func(t *testing.T) {
+		found, err := service.Find(session.ID)
+		require.NoError(t, err)
+		require.NoError(t, service.Lock(found, true))
+		assert.True(t, found.Locked)
+		require.NoError(t, service.End(found))
+
+		found, err = service.Find(session.ID)
+		require.NoError(t, err, "ended games can be read")
+		assert.True(t, found.Ended())
+		_, _, _, err = service.Next(session.ID, "")
+		assert.ErrorIs(t, err, game.ErrEnded)
+		assert.ErrorIs(t, service.SetCategories(found, nil), game.ErrEnded)
+	})
+}
+
+func TestService_TurnTimer(t *testing.T) {
+	db := setupTestDB(t)
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Tell a secret", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Dance", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}).Error)
+
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}, TurnSeconds: 30, ForfeitOnTimeout: true}
+	require.NoError(t, service.Create(session))
+
+	_, _, found, err := service.Next(session.ID, models.TaskTypeTruth)
+	require.NoError(t, err)
+	require.NotNil(t, found.TurnDeadline)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), *found.TurnDeadline, 5*time.Second)
+	drawID := found.LastEventID
+
+	t.Run("expiring", func(t *testing.T) {
+		expired, forfeit, err := service.Expire(session.ID, drawID)
+		require.NoError(t, err)
+		assert.Equal(t, game.EventTurnExpired, expired.Type)
+		assert.Equal(t, "Ana", expired.Player)
+		require.NotNil(t, forfeit)
+		assert.Equal(t, game.EventForfeit, forfeit.Type)
+		assert.Equal(t, "Ana", forfeit.Player)
+		assert.Equal(t, models.TaskTypeDare, forfeit.Task.Type)
+		assert.Equal(t, "Ben", forfeit.CurrentPlayer, "a forfeit keeps the turn order")
+
+		_, _, err = service.Expire(session.ID, drawID)
+		assert.ErrorIs(t, err, game.ErrNoOpenTask, "a turn expires once")
+
+		// The forfeit is untimed and can be completed
+		completed, err := service.Resolve(session.ID, game.EventCompleted)
+		require.NoError(t, err)
+		assert.Equal(t, "Dance", completed.Task.Text)
+	})
+
+	t.Run("overdue turns expire on resolve", func(t *testing.T) {
+		_, _, _, err := service.Next(session.ID, models.TaskTypeTruth)
+		require.NoError(t, err)
+		require.NoError(t, db.Model(&game.GameSession{}).Where("id = ?", session.ID).Update("turn_deadline", time.Now().Add(-time.Second)).Error)
+
+		_, err = service.Resolve(session.ID, game.EventCompleted)
+		assert.ErrorIs(t, err, game.ErrTurnExpired)
+		found, err := service.Find(session.ID)
+		require.NoError(t, err)
+		events, err := service.Events(session.ID, found.LastEventID-2, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, game.EventTurnExpired, events[0].Type)
+		assert.Equal(t, game.EventForfeit, events[1].Type)
+		assert.Nil(t, found.TurnDeadline)
+	})
+}
+
+func TestService_CustomTasks(t *testing.T) {
+	db := setupTestDB(t)
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Dance", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}).Error)
+
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}}
+	require.NoError(t, service.Create(session))
+
+	custom := &game.CustomTask{Type: models.TaskTypeDare, Text: "Sing the office anthem"}
+	require.NoError(t, service.AddCustomTask(session, custom))
+	require.NotEmpty(t, custom.ID)
+
+	found, err := service.Find(session.ID)
+	require.NoError(t, err)
+	require.Len(t, found.CustomTasks, 1, "custom tasks are kept with the session")
+
+	_, _, _, err = service.Next(session.ID, models.TaskTypeTruth)
+	assert.ErrorIs(t, err, game.ErrNoTask, "custom tasks keep to their type")
+	task, player, found, err := service.Next(session.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Ana", player)
+	assert.Equal(t, custom.ID, task.ID)
+	assert.Equal(t, "Sing the office anthem", task.Text)
+	assert.Empty(t, found.CustomTasks)
+	assert.Empty(t, found.ServedTaskIDs, "custom tasks are not in the history")
+
+	task, _, _, err = service.Next(session.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Dance", task.Text, "custom tasks are drawn once")
+
+	var count int64
+	require.NoError(t, db.Model(&models.Task{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "custom tasks are not stored as tasks")
+}
+
+func TestService_Exclude(t *testing.T) {
+	db := setupTestDB(t)
+	party := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	work := &models.Category{Label: models.MultilingualText{"en": "Work"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(party).Error)
+	require.NoError(t, db.Create(work).Error)
+	dance := &models.Task{Text: "Dance", Language: "en", Type: models.TaskTypeDare, CategoryID: party.ID}
+	sing := &models.Task{Text: "Sing", Language: "en", Type: models.TaskTypeDare, CategoryID: party.ID}
+	boss := &models.Task{Text: "Call your boss", Language: "en", Type: models.TaskTypeDare, CategoryID: work.ID}
+	for _, task := range []*models.Task{dance, sing, boss} {
+		require.NoError(t, db.Create(task).Error)
+	}
+
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana"}}
+	require.NoError(t, service.Create(session))
+
+	assert.ErrorIs(t, service.Exclude(session, []string{"missing"}, nil), game.ErrUnknownTask)
+	require.NoError(t, service.Exclude(session, []string{sing.ID}, []string{work.ID}))
+	require.NoError(t, service.Exclude(session, []string{sing.ID}, nil))
+	assert.Equal(t, models.StringArray{sing.ID}, session.ExcludedTaskIDs, "exclusions are added once")
+
+	// Starting over after every task was served keeps the exclusions
+	for i := 0; i < 5; i++ {
+		task, _, _, err := service.Next(session.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, dance.ID, task.ID)
+	}
+}
+
+func TestService_Summary(t *testing.T) {
+	db := setupTestDB(t)
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Tell a secret", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Dance", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}).Error)
+
+	service := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}, TurnSeconds: 30}
+	require.NoError(t, service.Create(session))
+
+	_, _, _, err := service.Next(session.ID, models.TaskTypeTruth)
+	require.NoError(t, err)
+	_, err = service.Resolve(session.ID, game.EventCompleted)
+	require.NoError(t, err)
+	_, _, found, err := service.Next(session.ID, models.TaskTypeDare)
+	require.NoError(t, err)
+	_, _, err = service.Expire(session.ID, found.LastEventID)
+	require.NoError(t, err)
+	_, _, found, err = service.Next(session.ID, models.TaskTypeDare)
+	require.NoError(t, err)
+	require.NoError(t, service.End(found))
+
+	summary, err := service.Summary(found)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Rounds)
+	assert.NotNil(t, summary.EndedAt)
+	assert.Equal(t, game.Counts{Draws: 3, Truths: 1, Dares: 2, Completed: 1, Expired: 1}, summary.Counts)
+	require.Len(t, summary.PlayerCounts, 2)
+	assert.Equal(t, game.PlayerCounts{Player: "Ana", Counts: game.Counts{Draws: 2, Truths: 1, Dares: 1, Completed: 1}}, summary.PlayerCounts[0])
+	assert.Equal(t, game.PlayerCounts{Player: "Ben", Counts: game.Counts{Draws: 1, Dares: 1, Expired: 1}}, summary.PlayerCounts[1])
+
+	require.Len(t, summary.Turns, 3)
+	assert.Equal(t, game.OutcomeCompleted, summary.Turns[0].Outcome)
+	assert.Equal(t, "Ben", summary.Turns[1].Player)
+	assert.Equal(t, game.OutcomeExpired, summary.Turns[1].Outcome)
+	assert.Empty(t, summary.Turns[2].Outcome, "the game ended before the last task was resolved")
+}
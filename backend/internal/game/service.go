@@ -0,0 +1,564 @@
+package game
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned for unknown and expired sessions.
+	ErrNotFound = errors.New("game session not found")
+	// ErrNoTask is returned when no task matches a session's settings.
+	ErrNoTask = errors.New("no matching task found")
+	// ErrConflict is returned when another change of the same session,
+	// such as a draw for the same turn, finished first.
+	ErrConflict = errors.New("game session changed concurrently")
+	// ErrNoOpenTask is returned when no drawn task waits to be skipped or
+	// completed.
+	ErrNoOpenTask = errors.New("no drawn task is open")
+	// ErrEnded is returned when a session the host ended is changed.
+	ErrEnded = errors.New("game session has ended")
+	// ErrUnknownPlayer is returned when kicking a player not in the game.
+	ErrUnknownPlayer = errors.New("player is not in the game")
+	// ErrLastPlayer is returned when kicking the only player left.
+	ErrLastPlayer = errors.New("the last player cannot be kicked")
+	// ErrTooManyCustomTasks is returned when adding a custom task to a
+	// session with MaxCustomTasks waiting.
+	ErrTooManyCustomTasks = errors.New("too many custom tasks waiting")
+	// ErrUnknownTask is returned when excluding tasks that do not exist.
+	ErrUnknownTask = errors.New("task not found")
+	// ErrTooManyExclusions is returned when a session would exclude more
+	// than MaxExclusions tasks or categories.
+	ErrTooManyExclusions = errors.New("too many exclusions")
+	// ErrTurnExpired is returned when skipping or completing a task after
+	// its turn expired.
+	ErrTurnExpired = errors.New("turn expired")
+)
+
+// Service stores game sessions, draws their tasks and expires the turns of
+// timed games.
+type Service struct {
+	db     *gorm.DB
+	tasks  *repository.TaskRepository
+	served *repository.ServeRecorder
+	cfg    *config.GameConfig
+	mode   *maintenance.Mode
+	// listen receives the events recorded, if set
+	listen func(Event)
+}
+
+// NewService creates a new Service. Draws are counted through served,
+// which may be nil. While mode is read-only, sessions can be read but not
+// drawn from.
+func NewService(db *gorm.DB, tasks *repository.TaskRepository, served *repository.ServeRecorder, cfg *config.GameConfig, mode *maintenance.Mode) *Service {
+	return &Service{db: db, tasks: tasks, served: served, cfg: cfg, mode: mode}
+}
+
+// Listen passes every event recorded from now on to fn, once it is
+// stored. fn runs on the goroutine recording the event. Set it before
+// serving requests.
+func (s *Service) Listen(fn func(Event)) {
+	s.listen = fn
+}
+
+// codeAttempts bounds the retries for a room code no live session uses
+const codeAttempts = 5
+
+// Create stores a new session, starting with its first player, under a
+// room code no live session uses, and sets its host token. Expired
+// sessions are deleted on the way.
+func (s *Service) Create(session *GameSession) error {
+	return s.CreateWithConsent(session, nil)
+}
+
+// CreateWithConsent stores a new session like Create, together with the
+// consent given for it, if any, recorded against the session.
+func (s *Service) CreateWithConsent(session *GameSession, consent *models.ConsentRecord) error {
+	now := time.Now()
+	expired := s.db.Unscoped().Model(&GameSession{}).Select("id").Where("expires_at <= ?", now)
+	if err := s.db.Where("session_id IN (?)", expired).Delete(&Event{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Unscoped().Where("expires_at <= ?", now).Delete(&GameSession{}).Error; err != nil {
+		return err
+	}
+	code, err := s.freeCode()
+	if err != nil {
+		return err
+	}
+	token, err := newHostToken()
+	if err != nil {
+		return err
+	}
+	session.Code = code
+	session.HostToken = token
+	session.HostTokenHash = hashHostToken(token)
+	session.Round = 0
+	session.ServedTaskIDs = nil
+	session.LastEventID = 0
+	session.OpenDrawID = 0
+	session.TurnOffset = 0
+	session.Locked = false
+	session.EndedAt = nil
+	session.TurnDeadline = nil
+	session.ExpiresAt = now.Add(s.ttl())
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		if consent == nil {
+			return nil
+		}
+		consent.SessionID = session.ID
+		return tx.Create(consent).Error
+	})
+}
+
+// Find retrieves a session that has not expired.
+func (s *Service) Find(id string) (*GameSession, error) {
+	var session GameSession
+	err := s.db.First(&session, "id = ? AND expires_at > ?", id, time.Now()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindByCode retrieves the session that has not expired with a room code.
+func (s *Service) FindByCode(code string) (*GameSession, error) {
+	var session GameSession
+	err := s.db.First(&session, "code = ? AND expires_at > ?", NormalizeCode(code), time.Now()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Lookup retrieves a session that has not expired by its ID or room code.
+func (s *Service) Lookup(ref string) (*GameSession, error) {
+	if len(strings.TrimSpace(ref)) <= codeLength {
+		return s.FindByCode(ref)
+	}
+	return s.Find(ref)
+}
+
+// Next draws a task of taskType, or either type when empty, for the
+// current player of a session, by its ID or room code, and passes the turn
+// on. The host's custom tasks come first, each drawn once; other tasks the
+// session served recently are skipped until every matching task has been
+// drawn. In timed games the turn expires TurnSeconds later
+// unless the task is skipped or completed. It returns the player the task
+// is for and the updated session.
+func (s *Service) Next(id, taskType string) (*models.Task, string, *GameSession, error) {
+	if s.mode.ReadOnly() {
+		return nil, "", nil, maintenance.ErrReadOnly
+	}
+	session, err := s.Lookup(id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if session.Ended() {
+		return nil, "", nil, ErrEnded
+	}
+
+	updates := map[string]any{}
+	var task *models.Task
+	if custom := session.takeCustom(taskType); custom != nil {
+		task = custom.task()
+		updates["custom_tasks"] = session.CustomTasks
+	} else {
+		task, err = s.draw(session, taskType)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		session.served(task.ID, s.cfg.SessionHistory)
+		updates["served_task_ids"] = session.ServedTaskIDs
+	}
+
+	player := session.CurrentPlayer()
+	session.Round++
+	now := time.Now()
+	session.ExpiresAt = now.Add(s.ttl())
+	session.TurnDeadline = nil
+	if session.TurnSeconds > 0 {
+		deadline := now.Add(time.Duration(session.TurnSeconds) * time.Second)
+		session.TurnDeadline = &deadline
+	}
+	// The draw is the session's next event and stays open until it is
+	// skipped or completed
+	session.OpenDrawID = session.LastEventID + 1
+	event := &Event{Type: EventDrawn, Player: player, Task: task, Deadline: session.TurnDeadline}
+	updates["round"] = session.Round
+	updates["expires_at"] = session.ExpiresAt
+	updates["open_draw_id"] = session.OpenDrawID
+	updates["turn_deadline"] = session.TurnDeadline
+	if err := s.record(session, event, updates); err != nil {
+		return nil, "", nil, err
+	}
+	if _, ok := updates["served_task_ids"]; ok {
+		s.served.Record(task.ID)
+	}
+	s.expireAt(session.ID, event.Seq, session.TurnDeadline)
+	return task, player, session, nil
+}
+
+// draw picks a task of taskType, or either type when empty, for the
+// session, skipping the tasks it served recently until every matching task
+// has been drawn
+func (s *Service) draw(session *GameSession, taskType string) (*models.Task, error) {
+	filter := session.filter(taskType)
+	task, err := s.tasks.FindRandom(filter)
+	if errors.Is(err, gorm.ErrRecordNotFound) && len(session.ServedTaskIDs) > 0 {
+		// The session has seen every matching task; start over, still
+		// without the tasks the host excluded
+		filter.ExcludeIDs = session.ExcludedTaskIDs
+		task, err = s.tasks.FindRandom(filter)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoTask
+	}
+	return task, err
+}
+
+// Resolve records the task drawn last in a session, by its ID or room
+// code, as skipped or completed, by eventType, and returns the event. A
+// task whose turn expired is expired instead, if its timer has not done
+// so yet, and ErrTurnExpired is returned.
+func (s *Service) Resolve(id, eventType string) (*Event, error) {
+	if s.mode.ReadOnly() {
+		return nil, maintenance.ErrReadOnly
+	}
+	session, err := s.Lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Ended() {
+		return nil, ErrEnded
+	}
+	drawn, err := s.OpenDraw(session)
+	if err != nil {
+		return nil, err
+	}
+	if drawn == nil {
+		return nil, ErrNoOpenTask
+	}
+	if session.TurnDeadline != nil && !time.Now().Before(*session.TurnDeadline) {
+		if _, _, err := s.expire(session, drawn); err != nil {
+			return nil, err
+		}
+		return nil, ErrTurnExpired
+	}
+
+	session.OpenDrawID = 0
+	session.TurnDeadline = nil
+	event := &Event{Type: eventType, Player: drawn.Player, Task: drawn.Task}
+	if err := s.record(session, event, map[string]any{"open_draw_id": 0, "turn_deadline": nil}); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Expire expires the turn of the task drawn by the event numbered drawID
+// in a session, if it is still open: it records a turn_expired event and,
+// when the session forfeits on timeout, draws a dare for the same player
+// without passing the turn on, recorded as a forfeit event. The forfeit is
+// open like a draw but untimed. It returns the events recorded, and
+// ErrNoOpenTask when the task was skipped or completed already.
+func (s *Service) Expire(id string, drawID int) (expired, forfeit *Event, err error) {
+	if s.mode.ReadOnly() {
+		return nil, nil, maintenance.ErrReadOnly
+	}
+	session, err := s.Find(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.Ended() || session.TurnDeadline == nil || session.OpenDrawID != drawID {
+		return nil, nil, ErrNoOpenTask
+	}
+	drawn, err := s.OpenDraw(session)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.expire(session, drawn)
+}
+
+// expire records the open draw of a session as expired and, when the
+// session forfeits on timeout, draws the forfeit
+func (s *Service) expire(session *GameSession, drawn *Event) (expired, forfeit *Event, err error) {
+	session.OpenDrawID = 0
+	session.TurnDeadline = nil
+	expired = &Event{Type: EventTurnExpired, Player: drawn.Player, Task: drawn.Task}
+	if err := s.record(session, expired, map[string]any{"open_draw_id": 0, "turn_deadline": nil}); err != nil {
+		return nil, nil, err
+	}
+	if !session.ForfeitOnTimeout {
+		return expired, nil, nil
+	}
+
+	task, err := s.draw(session, models.TaskTypeDare)
+	if errors.Is(err, ErrNoTask) {
+		// No dare to forfeit; the turn expires all the same
+		return expired, nil, nil
+	}
+	if err != nil {
+		return expired, nil, err
+	}
+	session.served(task.ID, s.cfg.SessionHistory)
+	session.OpenDrawID = session.LastEventID + 1
+	forfeit = &Event{Type: EventForfeit, Player: drawn.Player, Task: task}
+	err = s.record(session, forfeit, map[string]any{
+		"served_task_ids": session.ServedTaskIDs,
+		"open_draw_id":    session.OpenDrawID,
+	})
+	if err != nil {
+		return expired, nil, err
+	}
+	s.served.Record(task.ID)
+	return expired, forfeit, nil
+}
+
+// ScheduleTimers restarts the timers of the turns open in timed games,
+// lost when the server stopped. Turns that expired meanwhile expire at
+// once. Call it on startup.
+func (s *Service) ScheduleTimers() error {
+	var sessions []GameSession
+	err := s.db.Select("id", "open_draw_id", "turn_deadline").
+		Where("turn_deadline IS NOT NULL AND open_draw_id > 0 AND ended_at IS NULL AND expires_at > ?", time.Now()).
+		Find(&sessions).Error
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		s.expireAt(session.ID, session.OpenDrawID, session.TurnDeadline)
+	}
+	return nil
+}
+
+// expireAt expires the turn of a draw at its deadline, if it is still
+// open then. Every instance that served the draw, or restarted since, runs
+// a timer; only one records the expiry.
+func (s *Service) expireAt(id string, drawID int, deadline *time.Time) {
+	if deadline == nil {
+		return
+	}
+	time.AfterFunc(time.Until(*deadline), func() {
+		_, _, err := s.Expire(id, drawID)
+		if err != nil && !errors.Is(err, ErrNoOpenTask) && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrConflict) {
+			log.Error().Err(err).Str("session_id", id).Int("draw_id", drawID).Msg("Failed to expire game turn")
+		}
+	})
+}
+
+// Kick removes a player from a session. The turn stays with the player
+// it was with, or passes on when the kicked player had it.
+func (s *Service) Kick(session *GameSession, player string) error {
+	if err := s.checkChange(session); err != nil {
+		return err
+	}
+	if len(session.Players) == 1 && session.Players[0] == player {
+		return ErrLastPlayer
+	}
+	if !session.kick(player) {
+		return ErrUnknownPlayer
+	}
+	event := &Event{Type: EventKicked, Player: player, Players: session.Players}
+	return s.record(session, event, map[string]any{
+		"players":     session.Players,
+		"turn_offset": session.TurnOffset,
+	})
+}
+
+// Lock stops or, when locked is false, lets devices join a session's room.
+func (s *Service) Lock(session *GameSession, locked bool) error {
+	if err := s.checkChange(session); err != nil {
+		return err
+	}
+	session.Locked = locked
+	event := &Event{Type: EventUnlocked}
+	if locked {
+		event.Type = EventLocked
+	}
+	return s.record(session, event, map[string]any{"locked": locked})
+}
+
+// SetCategories changes the categories a session draws from, all when
+// empty. The caller checks the categories exist.
+func (s *Service) SetCategories(session *GameSession, categoryIDs []string) error {
+	if err := s.checkChange(session); err != nil {
+		return err
+	}
+	session.CategoryIDs = categoryIDs
+	event := &Event{Type: EventCategoriesChanged, CategoryIDs: session.CategoryIDs}
+	return s.record(session, event, map[string]any{"category_ids": session.CategoryIDs})
+}
+
+// AddCustomTask adds a one-off prompt of the host's to a session, to be
+// drawn before its other tasks of the same type. The caller validates it.
+func (s *Service) AddCustomTask(session *GameSession, custom *CustomTask) error {
+	if err := s.checkChange(session); err != nil {
+		return err
+	}
+	if len(session.CustomTasks) >= MaxCustomTasks {
+		return ErrTooManyCustomTasks
+	}
+	custom.ID = uuid.New().String()
+	custom.AddedAt = time.Now()
+	session.CustomTasks = append(session.CustomTasks, *custom)
+	// The event does not carry the task, so it stays a surprise
+	return s.record(session, &Event{Type: EventCustomTaskAdded}, map[string]any{"custom_tasks": session.CustomTasks})
+}
+
+// Exclude hides tasks and categories from a session's draws from the
+// next draw on, on top of those hidden before. The caller checks the
+// categories exist.
+func (s *Service) Exclude(session *GameSession, taskIDs, categoryIDs []string) error {
+	if err := s.checkChange(session); err != nil {
+		return err
+	}
+	if len(taskIDs) > 0 {
+		tasks, err := s.tasks.FindByIDs(taskIDs)
+		if err != nil {
+			return err
+		}
+		if len(tasks) != len(appendNew(nil, taskIDs)) {
+			return ErrUnknownTask
+		}
+	}
+	session.exclude(taskIDs, categoryIDs)
+	if len(session.ExcludedTaskIDs) > MaxExclusions || len(session.ExcludedCategoryIDs) > MaxExclusions {
+		return ErrTooManyExclusions
+	}
+	// The event leaves out what was excluded, as the host may hide
+	// topics from the players
+	return s.record(session, &Event{Type: EventExclusionsChanged}, map[string]any{
+		"excluded_task_ids":     session.ExcludedTaskIDs,
+		"excluded_category_ids": session.ExcludedCategoryIDs,
+	})
+}
+
+// End ends a session: it can no longer be drawn from or changed, but can
+// be read until it expires.
+func (s *Service) End(session *GameSession) error {
+	if err := s.checkChange(session); err != nil {
+		return err
+	}
+	now := time.Now()
+	session.EndedAt = &now
+	session.OpenDrawID = 0
+	session.TurnDeadline = nil
+	return s.record(session, &Event{Type: EventEnded}, map[string]any{
+		"ended_at":      session.EndedAt,
+		"open_draw_id":  0,
+		"turn_deadline": nil,
+	})
+}
+
+// checkChange refuses changes to a session in read-only mode or after it
+// ended
+func (s *Service) checkChange(session *GameSession) error {
+	if s.mode.ReadOnly() {
+		return maintenance.ErrReadOnly
+	}
+	if session.Ended() {
+		return ErrEnded
+	}
+	return nil
+}
+
+// OpenDraw returns the draw event of the session's open task, nil when
+// the task drawn last was skipped or completed.
+func (s *Service) OpenDraw(session *GameSession) (*Event, error) {
+	if session.OpenDrawID == 0 {
+		return nil, nil
+	}
+	var event Event
+	if err := s.db.First(&event, "session_id = ? AND seq = ?", session.ID, session.OpenDrawID).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Events returns up to limit events of a session recorded after the event
+// numbered after, oldest first.
+func (s *Service) Events(id string, after, limit int) ([]Event, error) {
+	var events []Event
+	err := s.db.Where("session_id = ? AND seq > ?", id, after).
+		Order("seq").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// record stores an event of a session together with the session's updates,
+// unless another event was recorded since the session was read, and passes
+// it to the listener. The event takes the session's next number and its
+// round and current player.
+func (s *Service) record(session *GameSession, event *Event, updates map[string]any) error {
+	last := session.LastEventID
+	event.SessionID = session.ID
+	event.Seq = last + 1
+	event.Round = session.Round
+	event.CurrentPlayer = session.CurrentPlayer()
+	updates["last_event_id"] = event.Seq
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Only one of two concurrent changes, such as draws for the same
+		// turn, may win
+		result := tx.Model(&GameSession{}).
+			Where("id = ? AND last_event_id = ?", session.ID, last).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return err
+	}
+	session.LastEventID = event.Seq
+	if s.listen != nil {
+		s.listen(*event)
+	}
+	return nil
+}
+
+// freeCode returns a room code no live session uses
+func (s *Service) freeCode() (string, error) {
+	for i := 0; i < codeAttempts; i++ {
+		code, err := newCode()
+		if err != nil {
+			return "", err
+		}
+		_, err = s.FindByCode(code)
+		if errors.Is(err, ErrNotFound) {
+			return code, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("no free room code found")
+}
+
+// ttl returns how long sessions live after their last draw
+func (s *Service) ttl() time.Duration {
+	return time.Duration(max(s.cfg.SessionTTLHours, 1)) * time.Hour
+}
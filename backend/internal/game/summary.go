@@ -0,0 +1,134 @@
+package game
+
+import (
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+)
+
+// Turn outcomes
+const (
+	OutcomeCompleted = "completed"
+	OutcomeSkipped   = "skipped"
+	OutcomeExpired   = "expired"
+)
+
+// Summary recaps a game session from its events: the tasks each player
+// got, what came of them and how often. It is kept as long as the session.
+type Summary struct {
+	Code      string     `json:"code"`
+	Players   []string   `json:"players"`
+	Rounds    int        `json:"rounds"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Counts
+	// PlayerCounts are per player, kicked players included, in turn order
+	PlayerCounts []PlayerCounts `json:"player_counts"`
+	// Turns are left to callers to send, with tasks mapped to the API
+	// version
+	Turns []Turn `json:"-"`
+}
+
+// Counts counts the tasks drawn in a game and their outcomes. Forfeits
+// count as dares.
+type Counts struct {
+	Draws     int `json:"draws"`
+	Truths    int `json:"truths"`
+	Dares     int `json:"dares"`
+	Completed int `json:"completed"`
+	Skipped   int `json:"skipped"`
+	Expired   int `json:"expired"`
+	Forfeits  int `json:"forfeits"`
+}
+
+// PlayerCounts counts one player's tasks
+type PlayerCounts struct {
+	Player string `json:"player"`
+	Counts
+}
+
+// Turn is a task drawn for a player and its outcome, empty while it is
+// open or when the next draw or the end of the game left it unresolved.
+type Turn struct {
+	Player  string       `json:"player"`
+	Task    *models.Task `json:"task"`
+	Forfeit bool         `json:"forfeit,omitempty"`
+	Outcome string       `json:"outcome,omitempty"`
+}
+
+// Summary returns the recap of a session so far.
+func (s *Service) Summary(session *GameSession) (*Summary, error) {
+	var events []Event
+	if err := s.db.Where("session_id = ?", session.ID).Order("seq").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return summarize(session, events), nil
+}
+
+// summarize builds the recap of a session from its events, oldest first
+func summarize(session *GameSession, events []Event) *Summary {
+	summary := &Summary{
+		Code:      session.Code,
+		Players:   session.Players,
+		Rounds:    session.Round,
+		StartedAt: session.CreatedAt,
+		EndedAt:   session.EndedAt,
+		Turns:     []Turn{},
+	}
+	players := map[string]*Counts{}
+	var order []string
+	counts := func(player string) *Counts {
+		if players[player] == nil {
+			players[player] = &Counts{}
+			order = append(order, player)
+		}
+		return players[player]
+	}
+	for _, player := range session.Players {
+		counts(player)
+	}
+
+	for _, event := range events {
+		switch event.Type {
+		case EventDrawn, EventForfeit:
+			turn := Turn{Player: event.Player, Task: event.Task, Forfeit: event.Type == EventForfeit}
+			summary.Turns = append(summary.Turns, turn)
+			for _, c := range []*Counts{&summary.Counts, counts(event.Player)} {
+				c.Draws++
+				if turn.Forfeit {
+					c.Forfeits++
+				}
+				if event.Task.Type == models.TaskTypeTruth {
+					c.Truths++
+				} else {
+					c.Dares++
+				}
+			}
+		case EventCompleted, EventSkipped, EventTurnExpired:
+			// Only the task drawn last can be resolved
+			if len(summary.Turns) == 0 {
+				continue
+			}
+			turn := &summary.Turns[len(summary.Turns)-1]
+			for _, c := range []*Counts{&summary.Counts, counts(turn.Player)} {
+				switch event.Type {
+				case EventCompleted:
+					turn.Outcome = OutcomeCompleted
+					c.Completed++
+				case EventSkipped:
+					turn.Outcome = OutcomeSkipped
+					c.Skipped++
+				default:
+					turn.Outcome = OutcomeExpired
+					c.Expired++
+				}
+			}
+		}
+	}
+
+	summary.PlayerCounts = make([]PlayerCounts, 0, len(order))
+	for _, player := range order {
+		summary.PlayerCounts = append(summary.PlayerCounts, PlayerCounts{Player: player, Counts: *players[player]})
+	}
+	return summary
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// responseMapper maps models to the response schema of one API version
+type responseMapper struct {
+	task     func(*models.Task) models.TaskResponse
+	category func(*models.Category) models.CategoryResponse
+}
+
+// responseMappers holds the mapper of each API version. v2 matches v1
+// until the task schema overhaul changes it.
+var responseMappers = map[string]responseMapper{
+	"v1": {task: (*models.Task).ToResponse, category: (*models.Category).ToResponse},
+	"v2": {task: (*models.Task).ToResponse, category: (*models.Category).ToResponse},
+}
+
+// mapperFor returns the response mapper of the API version the request was
+// routed through, or v1's when it was routed without one
+func mapperFor(c *gin.Context) responseMapper {
+	if mapper, ok := responseMappers[c.GetString(middleware.APIVersionKey)]; ok {
+		return mapper
+	}
+	return responseMappers["v1"]
+}
+
+// MapTask returns the task mapper of the request's API version, for
+// responses sent outside the handlers of this package
+func MapTask(c *gin.Context) func(*models.Task) models.TaskResponse {
+	return mapperFor(c).task
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// AttributionHandler lists the licenses content is redistributed under
+type AttributionHandler struct {
+	repo *repository.TaskRepository
+}
+
+// NewAttributionHandler creates a new AttributionHandler
+func NewAttributionHandler(repo *repository.TaskRepository) *AttributionHandler {
+	return &AttributionHandler{repo: repo}
+}
+
+// List godoc
+// @Summary List content attributions
+// @Description List every license and attribution active content falls under, with the number of categories and tasks each covers, most tasks first. A task without a license of its own falls under its category's; unlicensed content is left out. Clients redistributing content show these credits.
+// @Tags attributions
+// @Produce json
+// @Success 200 {object} map[string][]repository.Attribution
+// @Failure 500 {object} models.ErrorResponse
+// @Router /attributions [get]
+func (h *AttributionHandler) List(c *gin.Context) {
+	attributions, err := h.repo.FindAttributions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch attributions",
+		})
+		return
+	}
+	if attributions == nil {
+		attributions = []repository.Attribution{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": attributions})
+}
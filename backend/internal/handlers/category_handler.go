@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -23,17 +24,28 @@ func NewCategoryHandler(repo *repository.CategoryRepository) *CategoryHandler {
 
 // List godoc
 // @Summary List categories
-// @Description Get all categories with optional filters (no pagination)
+// @Description Get all categories with optional filters (no pagination). Categories come in their static sort_order unless order=smart, which puts the categories played most over the recent window first for the player's language and age group (a single age_groups value), as ranked nightly from telemetry.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param age_groups query string false "Comma-separated age groups (kids,teen,adults)"
+// @Param order query string false "sort_order (default) or smart"
+// @Param language query string false "Player language the smart order ranks plays for (default all languages)"
 // @Param requires_consent query bool false "Filter by consent requirement"
 // @Param active query bool false "Filter by active status"
+// @Param fields query string false "Comma-separated response fields to return (id is always included)"
+// @Param text_languages query string false "Comma-separated languages kept in labels"
+// @Param plain query bool false "Strip emoji and markdown formatting from labels (for voice and plain-text clients)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /categories [get]
 func (h *CategoryHandler) List(c *gin.Context) {
+	sel, ok := parseFieldSelection[models.CategoryResponse](c)
+	if !ok {
+		return
+	}
+
 	filter := &repository.CategoryFilter{}
 
 	// Parse age_groups (comma-separated)
@@ -59,6 +71,30 @@ func (h *CategoryHandler) List(c *gin.Context) {
 		log.Printf("[DEBUG] Category List - no active filter, showing all categories")
 	}
 
+	switch c.DefaultQuery("order", "sort_order") {
+	case "sort_order":
+	case "smart":
+		filter.SmartOrder = true
+		filter.SmartLanguage = c.Query("language")
+		if filter.SmartLanguage != "" && !models.IsValidLanguage(filter.SmartLanguage) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "Invalid language: " + filter.SmartLanguage,
+			})
+			return
+		}
+		// Ranks are kept per age group, so several groups rank over all
+		if len(filter.AgeGroups) == 1 {
+			filter.SmartAgeGroup = filter.AgeGroups[0]
+		}
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "order must be sort_order or smart",
+		})
+		return
+	}
+
 	categories, err := h.repo.FindAll(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -73,7 +109,25 @@ func (h *CategoryHandler) List(c *gin.Context) {
 	// Convert to response format
 	response := make([]models.CategoryResponse, len(categories))
 	for i, cat := range categories {
-		response[i] = cat.ToResponse()
+		response[i] = mapperFor(c).category(&cat)
+		response[i].Label = sel.text(response[i].Label)
+		response[i].Instructions = sel.text(response[i].Instructions)
+	}
+
+	if sel.sparse() {
+		data, err := sparseItems(sel, response)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to shape response",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data":  data,
+			"total": len(data),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -104,7 +158,7 @@ func (h *CategoryHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, category.ToResponse())
+	c.JSON(http.StatusOK, mapperFor(c).category(category))
 }
 
 // CreateCategoryRequest is the request body for creating a category.
@@ -112,20 +166,26 @@ type CreateCategoryRequest struct {
 	Emoji           string                  `json:"emoji"`
 	AgeGroup        string                  `json:"age_group" binding:"required"`
 	Label           models.MultilingualText `json:"label" binding:"required"`
+	Instructions    models.MultilingualText `json:"instructions"`
 	RequiresConsent bool                    `json:"requires_consent"`
 	SortOrder       int                     `json:"sort_order"`
 	IsActive        bool                    `json:"is_active"`
+	// License and Attribution mark a third-party pack; they cover the
+	// category's tasks without a license of their own
+	License     string `json:"license"`
+	Attribution string `json:"attribution"`
 }
 
 // Create godoc
 // @Summary Create category
-// @Description Create a new category
+// @Description Create a new category. Labels must be unique per language, ignoring case.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param category body CreateCategoryRequest true "Category data"
 // @Success 201 {object} models.CategoryResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /categories [post]
 func (h *CategoryHandler) Create(c *gin.Context) {
@@ -138,6 +198,16 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		return
 	}
 
+	errs := append(validateLabel(req.Label, true), models.ValidateLicense("", req.License, req.Attribution)...)
+	errs = append(errs, req.Instructions.Validate("instructions", models.MaxInstructionsLength)...)
+	if req.Emoji != "" {
+		req.Emoji, errs = validateEmoji(req.Emoji, errs)
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
 	// Validate age group
 	if !models.IsValidAgeGroup(req.AgeGroup) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -149,19 +219,25 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 
 	// Set defaults
 	if req.Emoji == "" {
-		req.Emoji = "📝"
+		req.Emoji = models.DefaultEmojiForAgeGroup(req.AgeGroup)
 	}
 
 	category := &models.Category{
 		Emoji:           req.Emoji,
 		AgeGroup:        req.AgeGroup,
 		Label:           req.Label,
+		Instructions:    req.Instructions,
 		RequiresConsent: req.RequiresConsent,
 		IsActive:        true,
 		SortOrder:       req.SortOrder,
+		License:         strings.TrimSpace(req.License),
+		Attribution:     strings.TrimSpace(req.Attribution),
 	}
 
 	if err := h.repo.Create(category); err != nil {
+		if respondLabelConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "database_error",
 			Message: "Failed to create category",
@@ -169,12 +245,12 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, category.ToResponse())
+	c.JSON(http.StatusCreated, mapperFor(c).category(category))
 }
 
 // Update godoc
 // @Summary Update category
-// @Description Update an existing category
+// @Description Update an existing category. Labels must be unique per language, ignoring case.
 // @Tags categories
 // @Accept json
 // @Produce json
@@ -183,6 +259,7 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 // @Success 200 {object} models.CategoryResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /categories/{id} [put]
 func (h *CategoryHandler) Update(c *gin.Context) {
@@ -206,6 +283,16 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		return
 	}
 
+	errs := append(validateLabel(req.Label, false), models.ValidateLicense("", req.License, req.Attribution)...)
+	errs = append(errs, req.Instructions.Validate("instructions", models.MaxInstructionsLength)...)
+	if req.Emoji != "" {
+		req.Emoji, errs = validateEmoji(req.Emoji, errs)
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
 	// Validate age group
 	if req.AgeGroup != "" && !models.IsValidAgeGroup(req.AgeGroup) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -225,11 +312,17 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 	if len(req.Label) > 0 {
 		category.Label = req.Label
 	}
+	category.Instructions = req.Instructions
 	category.RequiresConsent = req.RequiresConsent
 	category.SortOrder = req.SortOrder
 	category.IsActive = req.IsActive
+	category.License = strings.TrimSpace(req.License)
+	category.Attribution = strings.TrimSpace(req.Attribution)
 
-	if err := h.repo.Update(category); err != nil {
+	if err := h.repo.UpdateUnique(category); err != nil {
+		if respondLabelConflict(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "database_error",
 			Message: "Failed to update category",
@@ -237,7 +330,29 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, category.ToResponse())
+	c.JSON(http.StatusOK, mapperFor(c).category(category))
+}
+
+// respondLabelConflict sends a 409 when err is a label conflict
+func respondLabelConflict(c *gin.Context, err error) bool {
+	var conflict *repository.LabelConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	fields := make([]models.FieldError, len(conflict.Conflicts))
+	for i, cc := range conflict.Conflicts {
+		fields[i] = models.FieldError{
+			Field:   "label." + cc.Language,
+			Message: "already used by category " + cc.CategoryID,
+		}
+	}
+	c.JSON(http.StatusConflict, models.ErrorResponse{
+		Error:   "label_conflict",
+		Message: conflict.Error(),
+		Fields:  fields,
+	})
+	return true
 }
 
 // Count godoc
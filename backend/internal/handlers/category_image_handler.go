@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/prompts"
+	"github.com/truthordare/backend/internal/repository"
+	"github.com/truthordare/backend/internal/storage"
+)
+
+// CategoryImageHandler handles AI cover image generation for categories
+type CategoryImageHandler struct {
+	imageClient  *ai.ImageClient
+	promptLoader *prompts.PromptLoader
+	categoryRepo *repository.CategoryRepository
+	store        storage.Store
+}
+
+// NewCategoryImageHandler creates a new handler instance
+func NewCategoryImageHandler(categoryRepo *repository.CategoryRepository, store storage.Store) *CategoryImageHandler {
+	return &CategoryImageHandler{
+		imageClient:  ai.GetImageClient(),
+		promptLoader: prompts.GetLoader(),
+		categoryRepo: categoryRepo,
+		store:        store,
+	}
+}
+
+// imageExtensions maps generated content types to file extensions
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// GenerateImage godoc
+// @Summary Generate a category cover image using AI
+// @Description Generate a cover illustration for a category, store it and save its URL on the category
+// @Tags generate
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} models.Category
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /categories/{id}/generate-image [post]
+func (h *CategoryImageHandler) GenerateImage(c *gin.Context) {
+	id := c.Param("id")
+
+	category, err := h.categoryRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Category not found",
+		})
+		return
+	}
+
+	// Check if image generation is configured
+	if !h.imageClient.IsConfigured() {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "configuration_error",
+			Message: "Image generation is not configured. Please set IMAGE_API_KEY.",
+		})
+		return
+	}
+
+	prompt, err := h.promptLoader.LoadAndReplace(
+		"category_image",
+		prompts.P("CATEGORY", category.Label.Get("en")),
+		prompts.P("AGE_GROUP", category.AgeGroup),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to load prompt template: " + err.Error(),
+		})
+		return
+	}
+
+	img, err := h.imageClient.Generate(c.Request.Context(), prompt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "ai_error",
+			Message: "Failed to generate image: " + err.Error(),
+		})
+		return
+	}
+
+	ext, ok := imageExtensions[img.ContentType]
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "ai_error",
+			Message: "Image API returned unsupported content type " + img.ContentType,
+		})
+		return
+	}
+
+	url, err := h.store.Put(c.Request.Context(), path.Join("categories", category.ID+ext), img.Data, img.ContentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "storage_error",
+			Message: "Failed to store image",
+		})
+		return
+	}
+
+	category.ImageURL = url
+	if err := h.categoryRepo.Update(category); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to save image URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/integrations"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxChatBody bounds the body of bot requests
+const maxChatBody = 64 << 10
+
+// Discord interaction and response types
+const (
+	discordPing               = 1
+	discordApplicationCommand = 2
+	discordPong               = 1
+	discordChannelMessage     = 4
+	discordEphemeral          = 1 << 6
+)
+
+// chatUsage explains the bot command arguments
+const chatUsage = "Ask for `truth`, `dare` or either, optionally with a language code such as `es`."
+
+// errChatUsage is returned for bot command arguments that cannot be read
+var errChatUsage = errors.New(chatUsage)
+
+// ChatHandler serves the Slack, Discord and Telegram bots drawing tasks for
+// chat channels, and manages the workspaces allowed to use them
+type ChatHandler struct {
+	chats      *repository.ChatRepository
+	tasks      *repository.TaskRepository
+	categories *repository.CategoryRepository
+	served     *repository.ServeRecorder
+	cfg        *config.ChatConfig
+	discordKey ed25519.PublicKey
+}
+
+// NewChatHandler creates a new ChatHandler. Tasks drawn are counted through
+// served, which may be nil.
+func NewChatHandler(chats *repository.ChatRepository, tasks *repository.TaskRepository, categories *repository.CategoryRepository,
+	served *repository.ServeRecorder, cfg *config.ChatConfig) *ChatHandler {
+	discordKey, err := integrations.ParseDiscordKey(cfg.DiscordPublicKey)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid DISCORD_PUBLIC_KEY, Discord integration disabled")
+	}
+	return &ChatHandler{chats: chats, tasks: tasks, categories: categories, served: served, cfg: cfg, discordKey: discordKey}
+}
+
+// ChatWorkspaceRequest represents the request body for creating or updating
+// a chat workspace
+type ChatWorkspaceRequest struct {
+	Platform    string   `json:"platform" binding:"required"`
+	WorkspaceID string   `json:"workspace_id" binding:"required"`
+	Name        string   `json:"name"`
+	AgeGroup    string   `json:"age_group" binding:"required"`
+	Language    string   `json:"language"`
+	CategoryIDs []string `json:"category_ids"`
+	SafeMode    bool     `json:"safe_mode"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// SlackCommandResponse is the message a Slack slash command replies with
+type SlackCommandResponse struct {
+	ResponseType string `json:"response_type"` // "in_channel" or "ephemeral"
+	Text         string `json:"text"`
+}
+
+// DiscordInteractionResponse is the reply to a Discord interaction
+type DiscordInteractionResponse struct {
+	Type int                     `json:"type"`
+	Data *DiscordResponseMessage `json:"data,omitempty"`
+}
+
+// DiscordResponseMessage is a message posted in reply to a Discord command
+type DiscordResponseMessage struct {
+	Content string `json:"content"`
+	Flags   int    `json:"flags,omitempty"`
+}
+
+// discordInteraction is the part of a Discord interaction the bot reads
+type discordInteraction struct {
+	Type      int    `json:"type"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+// chatRequest is what a player asked a bot for
+type chatRequest struct {
+	taskType string
+	language string
+}
+
+// SlackCommand godoc
+// @Summary Draw a task for a Slack channel
+// @Description Slash command endpoint for a Slack app. The request must carry a valid Slack signature under SLACK_SIGNING_SECRET. The command text may name truth or dare and a language code. Draws are locked to the age group configured for the workspace, never need consent, and skip the channel's recent draws. Replies are Slack messages: in the channel for a task, only to the player for errors.
+// @Tags integrations
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} SlackCommandResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /integrations/slack/command [post]
+func (h *ChatHandler) SlackCommand(c *gin.Context) {
+	middleware.SkipEnvelope(c)
+	if h.cfg.SlackSigningSecret == "" {
+		respondChatDisabled(c, "Slack")
+		return
+	}
+	body, ok := readChatBody(c)
+	if !ok {
+		return
+	}
+	if err := integrations.VerifySlack(h.cfg.SlackSigningSecret, c.Request.Header, body, time.Now()); err != nil {
+		respondChatUnauthorized(c, err)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid slash command payload",
+		})
+		return
+	}
+	req, err := parseChatArgs(strings.Fields(form.Get("text")))
+	if err != nil {
+		c.JSON(http.StatusOK, SlackCommandResponse{ResponseType: "ephemeral", Text: err.Error()})
+		return
+	}
+
+	workspace, err := h.workspace(models.ChatPlatformSlack, form.Get("team_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, SlackCommandResponse{ResponseType: "ephemeral", Text: err.Error()})
+		return
+	}
+	text, err := h.draw(workspace, form.Get("channel_id"), req, "*")
+	if err != nil {
+		c.JSON(http.StatusOK, SlackCommandResponse{ResponseType: "ephemeral", Text: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, SlackCommandResponse{ResponseType: "in_channel", Text: text})
+}
+
+// DiscordInteraction godoc
+// @Summary Draw a task for a Discord channel
+// @Description Interactions endpoint for a Discord application. The request must carry a valid Ed25519 signature under DISCORD_PUBLIC_KEY. Answers pings, and application commands named truth or dare, or any command with type and language options. Draws are locked to the age group configured for the server, never need consent, and skip the channel's recent draws.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Success 200 {object} DiscordInteractionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /integrations/discord/interactions [post]
+func (h *ChatHandler) DiscordInteraction(c *gin.Context) {
+	middleware.SkipEnvelope(c)
+	if h.discordKey == nil {
+		respondChatDisabled(c, "Discord")
+		return
+	}
+	body, ok := readChatBody(c)
+	if !ok {
+		return
+	}
+	if err := integrations.VerifyDiscord(h.discordKey, c.Request.Header, body, time.Now()); err != nil {
+		respondChatUnauthorized(c, err)
+		return
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid interaction payload",
+		})
+		return
+	}
+
+	switch interaction.Type {
+	case discordPing:
+		c.JSON(http.StatusOK, DiscordInteractionResponse{Type: discordPong})
+		return
+	case discordApplicationCommand:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: fmt.Sprintf("Unsupported interaction type %d", interaction.Type),
+		})
+		return
+	}
+
+	reply := func(content string, flags int) {
+		c.JSON(http.StatusOK, DiscordInteractionResponse{
+			Type: discordChannelMessage,
+			Data: &DiscordResponseMessage{Content: content, Flags: flags},
+		})
+	}
+	if interaction.GuildID == "" {
+		reply("Draw tasks in a server channel, not in direct messages.", discordEphemeral)
+		return
+	}
+
+	var args []string
+	if name := strings.ToLower(interaction.Data.Name); name == models.TaskTypeTruth || name == models.TaskTypeDare {
+		args = append(args, name)
+	}
+	for _, option := range interaction.Data.Options {
+		if value, ok := option.Value.(string); ok {
+			args = append(args, value)
+		}
+	}
+	req, err := parseChatArgs(args)
+	if err != nil {
+		reply(err.Error(), discordEphemeral)
+		return
+	}
+
+	workspace, err := h.workspace(models.ChatPlatformDiscord, interaction.GuildID)
+	if err != nil {
+		reply(err.Error(), discordEphemeral)
+		return
+	}
+	text, err := h.draw(workspace, interaction.ChannelID, req, "**")
+	if err != nil {
+		reply(err.Error(), discordEphemeral)
+		return
+	}
+	reply(text, 0)
+}
+
+// workspace returns the enabled workspace of a platform's team or server.
+// Errors are messages for the player.
+func (h *ChatHandler) workspace(platform, workspaceID string) (*models.ChatWorkspace, error) {
+	workspace, err := h.chats.FindWorkspace(platform, workspaceID)
+	if err != nil || !workspace.IsActive {
+		return nil, fmt.Errorf("This workspace (%s) is not enabled for Truth or Dare. Ask an admin to add it.", workspaceID)
+	}
+	return workspace, nil
+}
+
+// draw picks a task for a channel of a workspace and records it in the
+// channel's history. bold is the platform's bold markup. Errors are
+// messages for the player.
+func (h *ChatHandler) draw(workspace *models.ChatWorkspace, channelID string, req chatRequest, bold string) (string, error) {
+	platform, workspaceID := workspace.Platform, workspace.WorkspaceID
+	language := req.language
+	if language == "" {
+		language = workspace.Language
+	}
+	active, consent := true, false
+	filter := &repository.TaskFilter{
+		AgeGroups:       workspace.DrawAgeGroups(),
+		CategoryIDs:     workspace.CategoryIDs,
+		Type:            req.taskType,
+		Language:        language,
+		IsActive:        &active,
+		RequiresConsent: &consent,
+		RolloutRoll:     rolloutRoll(),
+	}
+
+	recent, err := h.chats.RecentDraws(platform, workspaceID, channelID, h.cfg.ChannelHistory)
+	if err != nil {
+		return "", errors.New("Could not draw a task, please try again.")
+	}
+	filter.ExcludeIDs = recent
+	task, err := h.tasks.FindRandom(filter)
+	if errors.Is(err, gorm.ErrRecordNotFound) && len(recent) > 0 {
+		// The channel has seen every matching task; start over
+		filter.ExcludeIDs = nil
+		task, err = h.tasks.FindRandom(filter)
+	}
+	if err != nil {
+		return "", errors.New("No matching task found. " + chatUsage)
+	}
+
+	draw := &models.ChatDraw{Platform: platform, WorkspaceID: workspaceID, ChannelID: channelID, TaskID: task.ID}
+	if err := h.chats.RecordDraw(draw, max(h.cfg.ChannelHistory, 1)); err != nil {
+		log.Error().Err(err).Str("platform", platform).Str("workspace_id", workspaceID).Msg("Failed to record chat draw")
+	}
+	h.served.Record(task.ID)
+
+	label := cardTypeLabels[task.Type].Get(language)
+	return fmt.Sprintf("%s%s%s · %s\n%s", bold, label, bold, task.ShortCode, task.Text), nil
+}
+
+// parseChatArgs reads the task type and language a player asked for
+func parseChatArgs(args []string) (chatRequest, error) {
+	var req chatRequest
+	for _, arg := range args {
+		arg = strings.ToLower(strings.TrimSpace(arg))
+		switch {
+		case arg == "" || arg == "any" || arg == "random":
+		case arg == models.TaskTypeTruth || arg == models.TaskTypeDare:
+			req.taskType = arg
+		case models.IsValidLanguage(arg):
+			req.language = arg
+		default:
+			return chatRequest{}, errChatUsage
+		}
+	}
+	return req, nil
+}
+
+// readChatBody reads a bot request body, which is verified before parsing
+func readChatBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxChatBody))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error:   "payload_too_large",
+			Message: fmt.Sprintf("Request body exceeds %d bytes", maxChatBody),
+		})
+		return nil, false
+	}
+	return body, true
+}
+
+// respondChatDisabled reports a platform whose secret is not configured
+func respondChatDisabled(c *gin.Context, platform string) {
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error:   "not_found",
+		Message: platform + " integration is not configured",
+	})
+}
+
+// respondChatUnauthorized rejects a bot request whose signature fails
+func respondChatUnauthorized(c *gin.Context, err error) {
+	c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		Error:   "unauthorized",
+		Message: "Invalid request signature: " + err.Error(),
+	})
+}
+
+// ListWorkspaces godoc
+// @Summary List chat workspaces
+// @Description Get the Slack workspaces, Discord servers and Telegram groups allowed to draw tasks, with their locked age group, categories, safe mode and default language
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} map[string][]models.ChatWorkspace
+// @Failure 500 {object} models.ErrorResponse
+// @Router /integrations/workspaces [get]
+func (h *ChatHandler) ListWorkspaces(c *gin.Context) {
+	workspaces, err := h.chats.FindWorkspaces()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch chat workspaces",
+		})
+		return
+	}
+	if workspaces == nil {
+		workspaces = []models.ChatWorkspace{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": workspaces})
+}
+
+// CreateWorkspace godoc
+// @Summary Create chat workspace
+// @Description Allow a Slack workspace (by team ID) or Discord server (by guild ID) to draw tasks, locked to one age group, optionally limited to category_ids, or to kids categories with safe_mode. language defaults to en and is_active to true. Telegram groups register themselves, but may be added ahead.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param request body ChatWorkspaceRequest true "Chat workspace"
+// @Success 201 {object} models.ChatWorkspace
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /integrations/workspaces [post]
+func (h *ChatHandler) CreateWorkspace(c *gin.Context) {
+	workspace := &models.ChatWorkspace{IsActive: true}
+	if !h.bindWorkspace(c, workspace) {
+		return
+	}
+
+	if err := h.chats.CreateWorkspace(workspace); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create chat workspace",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, workspace)
+}
+
+// UpdateWorkspace godoc
+// @Summary Update chat workspace
+// @Description Replace a chat workspace's settings. Omitting is_active keeps it.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat workspace ID"
+// @Param request body ChatWorkspaceRequest true "Chat workspace"
+// @Success 200 {object} models.ChatWorkspace
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /integrations/workspaces/{id} [put]
+func (h *ChatHandler) UpdateWorkspace(c *gin.Context) {
+	workspace, err := h.chats.FindWorkspaceByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Chat workspace not found",
+		})
+		return
+	}
+	if !h.bindWorkspace(c, workspace) {
+		return
+	}
+
+	if err := h.chats.UpdateWorkspace(workspace); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update chat workspace",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// DeleteWorkspace godoc
+// @Summary Delete chat workspace
+// @Description Stop a workspace from drawing tasks and forget its channels' draw history
+// @Tags integrations
+// @Produce json
+// @Param id path string true "Chat workspace ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /integrations/workspaces/{id} [delete]
+func (h *ChatHandler) DeleteWorkspace(c *gin.Context) {
+	workspace, err := h.chats.FindWorkspaceByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Chat workspace not found",
+		})
+		return
+	}
+
+	if err := h.chats.DeleteWorkspace(workspace); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to delete chat workspace",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Chat workspace deleted successfully",
+	})
+}
+
+// bindWorkspace reads the request into workspace and validates it,
+// responding with the error when it fails
+func (h *ChatHandler) bindWorkspace(c *gin.Context, workspace *models.ChatWorkspace) bool {
+	var req ChatWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	workspace.Platform = req.Platform
+	workspace.WorkspaceID = strings.TrimSpace(req.WorkspaceID)
+	workspace.Name = strings.TrimSpace(req.Name)
+	workspace.AgeGroup = req.AgeGroup
+	workspace.Language = req.Language
+	if workspace.Language == "" {
+		workspace.Language = "en"
+	}
+	workspace.CategoryIDs = req.CategoryIDs
+	workspace.SafeMode = req.SafeMode
+	if req.IsActive != nil {
+		workspace.IsActive = *req.IsActive
+	}
+	if errs := workspace.Validate(); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return false
+	}
+
+	if len(workspace.CategoryIDs) > 0 {
+		categories, err := h.categories.FindByIDs(workspace.CategoryIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to check categories",
+			})
+			return false
+		}
+		if len(categories) != len(workspace.CategoryIDs) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_category",
+				Message: "One or more categories do not exist",
+			})
+			return false
+		}
+	}
+
+	exists, err := h.chats.ExistsWorkspace(workspace.Platform, workspace.WorkspaceID, workspace.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check chat workspace",
+		})
+		return false
+	}
+	if exists {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Chat workspace already exists: " + workspace.Platform + " " + workspace.WorkspaceID,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// ClientConfigHandler serves the runtime configuration of client apps
+type ClientConfigHandler struct {
+	snapshotRepo  *repository.SnapshotRepository
+	flags         *featureflags.Store
+	minAppVersion string
+}
+
+// NewClientConfigHandler creates a new ClientConfigHandler
+func NewClientConfigHandler(snapshotRepo *repository.SnapshotRepository, flags *featureflags.Store, minAppVersion string) *ClientConfigHandler {
+	return &ClientConfigHandler{
+		snapshotRepo:  snapshotRepo,
+		flags:         flags,
+		minAppVersion: minAppVersion,
+	}
+}
+
+// ClientConfigResponse is the runtime configuration of client apps. It
+// holds nothing sensitive.
+type ClientConfigResponse struct {
+	Languages []models.LanguageInfo `json:"languages"`
+	AgeGroups []models.AgeGroupInfo `json:"age_groups"`
+	// FeatureFlags holds the flags that change app behavior, resolved for
+	// the client
+	FeatureFlags map[string]bool `json:"feature_flags"`
+	// MinAppVersion is the oldest supported app version; older apps should
+	// ask players to update
+	MinAppVersion string `json:"min_app_version,omitempty"`
+	// ContentRevision changes whenever categories or tasks change
+	ContentRevision string `json:"content_revision"`
+}
+
+// Get godoc
+// @Summary Get client configuration
+// @Description Get the runtime configuration of client apps: supported languages, age groups, client feature flags, the minimum supported app version and the content revision. Partial flag rollouts are resolved per client_id; without one they are bucketed at random.
+// @Tags config
+// @Produce json
+// @Param client_id query string false "Anonymous client ID flag rollouts are bucketed by"
+// @Success 200 {object} ClientConfigResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /client-config [get]
+func (h *ClientConfigHandler) Get(c *gin.Context) {
+	revision, err := h.snapshotRepo.Revision()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to compute content revision",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ClientConfigResponse{
+		Languages:       models.Languages,
+		AgeGroups:       models.AgeGroups,
+		FeatureFlags:    h.flags.ClientFlags(c.Query("client_id")),
+		MinAppVersion:   h.minAppVersion,
+		ContentRevision: revision,
+	})
+}
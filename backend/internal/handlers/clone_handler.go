@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/prompts"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// CloneHandler copies tasks into other categories and languages
+type CloneHandler struct {
+	aiClient     *ai.Client
+	promptLoader *prompts.PromptLoader
+	taskRepo     *repository.TaskRepository
+	categoryRepo *repository.CategoryRepository
+	glossaryRepo *repository.GlossaryRepository
+	languageRepo *repository.LanguageRepository
+	cfg          *config.GenerationConfig
+}
+
+// NewCloneHandler creates a new CloneHandler
+func NewCloneHandler(taskRepo *repository.TaskRepository, categoryRepo *repository.CategoryRepository, glossaryRepo *repository.GlossaryRepository, languageRepo *repository.LanguageRepository, cfg *config.GenerationConfig) *CloneHandler {
+	return &CloneHandler{
+		aiClient:     ai.GetClient(),
+		promptLoader: prompts.GetLoader(),
+		taskRepo:     taskRepo,
+		categoryRepo: categoryRepo,
+		glossaryRepo: glossaryRepo,
+		languageRepo: languageRepo,
+		cfg:          cfg,
+	}
+}
+
+// CloneTaskRequest represents the request body for cloning a task
+type CloneTaskRequest struct {
+	// CategoryID is the category of the clones; empty keeps the task's own
+	CategoryID string `json:"category_id,omitempty"`
+	// Languages lists the languages to clone into; empty keeps the task's own
+	Languages []string `json:"languages,omitempty"`
+	// Texts supplies translations; languages missing here are translated by AI
+	Texts models.MultilingualText `json:"texts,omitempty"`
+}
+
+// CloneTaskResponse holds the created clones
+type CloneTaskResponse struct {
+	Data []models.TaskResponse `json:"data"`
+}
+
+// Clone godoc
+// @Summary Clone a task
+// @Description Copy a task into another category and/or other languages. Texts for new languages come from the request or are translated by AI; AI translations follow the glossary, start pending review at the initial rollout like generated tasks, and carry a reviewer note when they break the glossary. The hint is copied as is. AI translation into a frozen language is a conflict; texts supplied for it are accepted.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body CloneTaskRequest true "Clone targets"
+// @Success 201 {object} CloneTaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /tasks/{id}/clone [post]
+func (h *CloneHandler) Clone(c *gin.Context) {
+	task, err := h.taskRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	var req CloneTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if req.CategoryID == "" && len(req.Languages) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Either category_id or languages is required",
+		})
+		return
+	}
+
+	categoryID := task.CategoryID
+	if req.CategoryID != "" {
+		if _, err := h.categoryRepo.FindByID(req.CategoryID); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "Category not found",
+			})
+			return
+		}
+		categoryID = req.CategoryID
+	}
+
+	languages := dedupe(req.Languages)
+	if len(languages) == 0 {
+		languages = []string{task.Language}
+	}
+
+	var errs []models.FieldError
+	for _, lang := range languages {
+		if !models.IsValidLanguage(lang) {
+			errs = append(errs, models.FieldError{Field: "languages", Message: "unsupported language code " + lang})
+		}
+	}
+	errs = append(errs, req.Texts.Validate("texts", models.MaxTaskTextLength)...)
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	// Cloning within the same category and language would only duplicate it
+	if categoryID == task.CategoryID && len(languages) == 1 && languages[0] == task.Language {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Clone needs a different category or language",
+		})
+		return
+	}
+
+	texts := models.MultilingualText{task.Language: task.Text}
+	for lang, text := range req.Texts {
+		texts[lang] = text
+	}
+
+	var missing []string
+	for _, lang := range languages {
+		if _, ok := texts[lang]; !ok {
+			missing = append(missing, lang)
+		}
+	}
+
+	translated := models.MultilingualText{}
+	cacheStatus := ""
+	var glossary models.Glossary
+	if len(missing) > 0 {
+		if !checkNotFrozen(c, h.languageRepo, missing...) {
+			return
+		}
+		if !h.aiClient.IsConfigured() {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "configuration_error",
+				Message: "AI service is not configured. Please set GROQ_API_KEY, or pass texts for every language.",
+			})
+			return
+		}
+
+		glossary, err = h.glossaryRepo.FindAll()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to load glossary",
+			})
+			return
+		}
+
+		translated, cacheStatus, err = h.translate(c.Request.Context(), task, missing, glossary)
+		if err != nil {
+			respondAIError(c, err, "Failed to translate task")
+			return
+		}
+		for _, lang := range missing {
+			if len(models.ValidateText(lang, translated[lang], models.MaxTaskTextLength)) > 0 {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "ai_error",
+					Message: "AI response did not include a usable translation for " + lang,
+				})
+				return
+			}
+		}
+	}
+
+	clones := make([]models.Task, len(languages))
+	for i, lang := range languages {
+		clone := models.Task{
+			CategoryID: categoryID,
+			Type:       task.Type,
+			Language:   lang,
+			Hint:       task.Hint,
+			IsActive:   true,
+			// An explicit task stays explicit in every language
+			RequiresConsent: task.RequiresConsent,
+		}
+		if text, ok := texts[lang]; ok {
+			clone.Text = text
+			clone.RolloutPercent = models.FullRollout
+		} else {
+			// Machine translations go through review like generated tasks
+			clone.Text = translated[lang]
+			clone.ReviewState = models.ReviewStatePending
+			clone.RolloutPercent = h.cfg.InitialRollout()
+			clone.MarkMachine(lang)
+			glossary.Flag(&clone, task.Text, task.Language)
+		}
+		clones[i] = clone
+	}
+
+	if err := h.taskRepo.CreateBatch(clones); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create clones",
+		})
+		return
+	}
+
+	response := CloneTaskResponse{Data: make([]models.TaskResponse, len(clones))}
+	for i := range clones {
+		response.Data[i] = mapperFor(c).task(&clones[i])
+	}
+
+	if cacheStatus != "" {
+		c.Header("X-Cache", cacheStatus)
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// translatedTask represents the AI response structure for task translation
+type translatedTask struct {
+	Translations models.MultilingualText `json:"translations"`
+}
+
+// translate asks the model for the task text in each language, following
+// the glossary
+func (h *CloneHandler) translate(ctx context.Context, task *models.Task, languages []string, glossary models.Glossary) (models.MultilingualText, string, error) {
+	systemPrompt, err := h.promptLoader.Load("translate_task_system")
+	if err != nil {
+		return nil, ai.CacheMiss, err
+	}
+
+	placeholders := []prompts.Placeholder{
+		prompts.P("TYPE", task.Type),
+		prompts.P("SOURCE_LANGUAGE", task.Language),
+		prompts.P("LANGUAGES", strings.Join(languages, ", ")),
+		prompts.P("TEXT", task.Text),
+		prompts.P("GLOSSARY", glossary.Format(languages...)),
+	}
+	userPrompt, err := h.promptLoader.LoadAndReplace("translate_task", placeholders...)
+	if err != nil {
+		return nil, ai.CacheMiss, err
+	}
+
+	messages := []ai.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	}
+
+	var content translatedTask
+	cacheStatus, err := h.aiClient.CompleteJSONCached(prompts.Key("translate_task", placeholders...), messages, &content,
+		ai.WithContext(ctx),
+		ai.WithTimeout(aiRequestTimeout),
+		ai.WithPrompt("translate_task", placeholders...),
+		ai.WithTemperature(0.3),
+		ai.WithMaxTokens(2000),
+	)
+	if err != nil {
+		return nil, cacheStatus, err
+	}
+	return content.Translations, cacheStatus, nil
+}
+
+// dedupe returns values without repeats, keeping the first occurrence
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// ConsentHandler records the consent given before playing categories that
+// require it, and serves those records for audits
+type ConsentHandler struct {
+	consentRepo  *repository.ConsentRepository
+	categoryRepo *repository.CategoryRepository
+	games        *game.Service
+	cfg          *config.ModerationConfig
+}
+
+// NewConsentHandler creates a new ConsentHandler
+func NewConsentHandler(
+	consentRepo *repository.ConsentRepository,
+	categoryRepo *repository.CategoryRepository,
+	games *game.Service,
+	cfg *config.ModerationConfig,
+) *ConsentHandler {
+	return &ConsentHandler{
+		consentRepo:  consentRepo,
+		categoryRepo: categoryRepo,
+		games:        games,
+		cfg:          cfg,
+	}
+}
+
+// ConsentPolicyResponse is the consent policy clients must accept
+type ConsentPolicyResponse struct {
+	PolicyVersion string `json:"policy_version"`
+}
+
+// RecordConsentRequest represents the request body for recording consent
+type RecordConsentRequest struct {
+	ClientID      string   `json:"client_id" binding:"required,max=64"`
+	SessionID     string   `json:"session_id,omitempty" binding:"max=64"`
+	PolicyVersion string   `json:"policy_version" binding:"required,max=20"`
+	AgeConfirmed  bool     `json:"age_confirmed"`
+	CategoryIDs   []string `json:"category_ids" binding:"required,min=1,max=50"`
+}
+
+// Policy godoc
+// @Summary Get the current consent policy
+// @Description Get the terms/consent policy version clients must accept before starting a game with categories that require consent
+// @Tags consent
+// @Produce json
+// @Success 200 {object} ConsentPolicyResponse
+// @Router /consent/policy [get]
+func (h *ConsentHandler) Policy(c *gin.Context) {
+	c.JSON(http.StatusOK, ConsentPolicyResponse{PolicyVersion: h.cfg.ConsentPolicyVersion})
+}
+
+// Record godoc
+// @Summary Record consent for a game
+// @Description Record the consent given when a game with categories that require consent is started. The policy version must be the current one, and age_confirmed must be true when any category requires consent. A session_id must name a game session that has not expired; sessions take their consent when they are created, see POST /sessions.
+// @Tags consent
+// @Accept json
+// @Produce json
+// @Param request body RecordConsentRequest true "Consent"
+// @Success 201 {object} models.ConsentRecord
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /consent [post]
+func (h *ConsentHandler) Record(c *gin.Context) {
+	var req RecordConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if req.SessionID != "" {
+		_, err := h.games.Find(req.SessionID)
+		if errors.Is(err, game.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_session",
+				Message: "Game session not found or expired",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to fetch game session",
+			})
+			return
+		}
+	}
+
+	categories, err := h.categoryRepo.FindByIDs(req.CategoryIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch categories",
+		})
+		return
+	}
+
+	found := make(map[string]bool, len(categories))
+	requiresConsent := false
+	for _, category := range categories {
+		found[category.ID] = true
+		if category.RequiresConsent {
+			requiresConsent = true
+		}
+	}
+	for _, id := range req.CategoryIDs {
+		if !found[id] {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_category",
+				Message: "Category not found: " + id,
+			})
+			return
+		}
+	}
+
+	if !checkConsent(c, h.cfg, req.PolicyVersion, req.AgeConfirmed, requiresConsent) {
+		return
+	}
+
+	record := &models.ConsentRecord{
+		ClientID:      req.ClientID,
+		SessionID:     req.SessionID,
+		PolicyVersion: req.PolicyVersion,
+		AgeConfirmed:  req.AgeConfirmed,
+		CategoryIDs:   req.CategoryIDs,
+	}
+	if err := h.consentRepo.Create(record); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record consent",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, record)
+}
+
+// checkConsent responds with an error and returns false unless consent is
+// given for the current policy, with the players' age confirmed when the
+// game may include content that requires consent
+func checkConsent(c *gin.Context, cfg *config.ModerationConfig, policyVersion string, ageConfirmed, requiresConsent bool) bool {
+	if policyVersion != cfg.ConsentPolicyVersion {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "policy_outdated",
+			Message: fmt.Sprintf("Consent must be given for policy version %s", cfg.ConsentPolicyVersion),
+		})
+		return false
+	}
+	if requiresConsent && !ageConfirmed {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "age_confirmation_required",
+			Message: "Players must confirm their age to play categories that require consent",
+		})
+		return false
+	}
+	return true
+}
+
+// List godoc
+// @Summary List consent records
+// @Description Get recorded consents for audits, newest first
+// @Tags consent
+// @Produce json
+// @Param client_id query string false "Client ID"
+// @Param session_id query string false "Session ID"
+// @Param policy_version query string false "Policy version"
+// @Param created_after query string false "Recorded at or after (RFC3339)"
+// @Param created_before query string false "Recorded at or before (RFC3339)"
+// @Param limit query int false "Limit results (default 50)"
+// @Param offset query int false "Offset for pagination"
+// @Success 200 {object} models.PaginatedResponse[models.ConsentRecord]
+// @Failure 500 {object} models.ErrorResponse
+// @Router /consent/records [get]
+func (h *ConsentHandler) List(c *gin.Context) {
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = 50
+	}
+	offset := parseNonNegativeInt(c.Query("offset"))
+
+	records, total, err := h.consentRepo.FindAll(&repository.ConsentFilter{
+		ClientID:      c.Query("client_id"),
+		SessionID:     c.Query("session_id"),
+		PolicyVersion: c.Query("policy_version"),
+		CreatedAfter:  parseTimeParam(c.Query("created_after")),
+		CreatedBefore: parseTimeParam(c.Query("created_before")),
+		Limit:         limit,
+		Offset:        offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch consent records",
+		})
+		return
+	}
+
+	totalPages := 1
+	if total > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+	c.JSON(http.StatusOK, models.PaginatedResponse[models.ConsentRecord]{
+		Data:       records,
+		Total:      total,
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	})
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"github.com/truthordare/backend/internal/signedurl"
+	"gorm.io/gorm"
+)
+
+// Default size of the task card iframe in pixels
+const (
+	cardWidth  = 400
+	cardHeight = 240
+)
+
+// OEmbedSizeParams are the query parameters oEmbed consumers add to a
+// card URL to bound its size; signatures do not cover them
+var OEmbedSizeParams = []string{"maxwidth", "maxheight"}
+
+// cardTypeLabels names the task types on cards, per language
+var cardTypeLabels = map[string]models.MultilingualText{
+	models.TaskTypeTruth: {
+		"en": "Truth", "zh": "真心话", "es": "Verdad", "hi": "सच", "ar": "حقيقة",
+		"fr": "Vérité", "pt": "Verdade", "bn": "সত্য", "ru": "Правда", "ur": "سچ",
+	},
+	models.TaskTypeDare: {
+		"en": "Dare", "zh": "大冒险", "es": "Reto", "hi": "हिम्मत", "ar": "جرأة",
+		"fr": "Action", "pt": "Desafio", "bn": "সাহস", "ru": "Действие", "ur": "ہمت",
+	},
+}
+
+// EmbedHandler serves content for widgets on third-party sites, behind
+// signed URLs
+type EmbedHandler struct {
+	tasks      *repository.TaskRepository
+	categories *repository.CategoryRepository
+	signer     *signedurl.Signer
+	publicURL  string
+}
+
+// NewEmbedHandler creates a new EmbedHandler. publicURL prefixes absolute
+// links in cards; empty uses the request's host.
+func NewEmbedHandler(tasks *repository.TaskRepository, categories *repository.CategoryRepository,
+	signer *signedurl.Signer, publicURL string) *EmbedHandler {
+	return &EmbedHandler{tasks: tasks, categories: categories, signer: signer, publicURL: publicURL}
+}
+
+// DailyTaskResponse is the task of the day
+type DailyTaskResponse struct {
+	Date string              `json:"date"`
+	Task models.TaskResponse `json:"task"`
+}
+
+// DailyTask godoc
+// @Summary Get the task of the day
+// @Description Get one task per UTC day for a widget: the same task all day for the same filters, a different one the next. Only active, fully rolled out tasks that need no consent are picked. Requires a signed URL from POST /signed-urls, or the admin key.
+// @Tags embed
+// @Produce json
+// @Param language query string false "Language code" default(en)
+// @Param category_id query string false "Category ID"
+// @Param type query string false "Task type (truth, dare)"
+// @Param expires query int false "Expiry of the signed URL (Unix seconds)"
+// @Param signature query string false "Signature of the signed URL"
+// @Success 200 {object} DailyTaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /embed/daily-task [get]
+func (h *EmbedHandler) DailyTask(c *gin.Context) {
+	language := c.DefaultQuery("language", "en")
+	if !models.IsValidLanguage(language) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_language",
+			Message: "Unsupported language: " + language,
+		})
+		return
+	}
+	taskType := c.Query("type")
+	if taskType != "" && taskType != models.TaskTypeTruth && taskType != models.TaskTypeDare {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "type must be truth or dare",
+		})
+		return
+	}
+
+	active, consent, roll := true, false, models.FullRollout-1
+	filter := &repository.TaskFilter{
+		CategoryID:      c.Query("category_id"),
+		Type:            taskType,
+		Language:        language,
+		IsActive:        &active,
+		RequiresConsent: &consent,
+		RolloutRoll:     &roll,
+	}
+
+	today := time.Now().UTC()
+	task, err := h.tasks.FindDaily(filter, today, filter.CategoryID+"|"+taskType+"|"+language)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "No matching task found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, DailyTaskResponse{
+		Date: today.Format("2006-01-02"),
+		Task: mapperFor(c).task(task),
+	})
+}
+
+// OEmbedResponse is an oEmbed 1.0 rich response embedding a task card
+type OEmbedResponse struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	Title        string `json:"title"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	CacheAge     int    `json:"cache_age,omitempty"`
+}
+
+// taskCard is what the card template renders
+type taskCard struct {
+	Lang, Dir         string
+	TaskLang, TaskDir string
+	Type, TypeLabel   string
+	Emoji, Category   string
+	Title, Text, Hint string
+	ShortCode         string
+	URL, OEmbedURL    string
+}
+
+// Task godoc
+// @Summary Get a task card
+// @Description Render a task as a small HTML card for iframes, with OpenGraph tags and an oEmbed link for chat app unfurls, or with format=json as an oEmbed rich response whose iframe points at the card. lang picks the language of labels and hint and defaults to the task's. Links in the card are signed with the same expiry as the request. Only active tasks needing no consent are shown. Requires a signed URL from POST /signed-urls, or the admin key.
+// @Tags embed
+// @Produce html
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param lang query string false "Language of labels and hint"
+// @Param format query string false "html (default) or json for oEmbed"
+// @Param maxwidth query int false "oEmbed maximum width"
+// @Param maxheight query int false "oEmbed maximum height"
+// @Param expires query int false "Expiry of the signed URL (Unix seconds)"
+// @Param signature query string false "Signature of the signed URL"
+// @Success 200 {object} OEmbedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /embed/task/{id} [get]
+func (h *EmbedHandler) Task(c *gin.Context) {
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "json" {
+		respondFieldErrors(c, []models.FieldError{{Field: "format", Message: "must be html or json"}})
+		return
+	}
+	width, height, ok := cardSize(c)
+	if !ok {
+		return
+	}
+
+	task, category, err := h.shownTask(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	lang := c.DefaultQuery("lang", task.Language)
+	if !models.IsValidLanguage(lang) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_language",
+			Message: "Unsupported language: " + lang,
+		})
+		return
+	}
+
+	// Links in the card expire with the request's; admin previews get
+	// links of the default lifetime
+	expires := time.Now().Add(defaultSignedURLTTL).Truncate(time.Second)
+	if signedExpiry, ok := c.Get(middleware.SignedURLExpiresKey); ok {
+		expires = signedExpiry.(time.Time)
+	}
+	base := baseURL(c, h.publicURL)
+	query := c.Request.URL.Query()
+	for _, param := range append([]string{"format", signedurl.ExpiresParam, signedurl.SignatureParam}, OEmbedSizeParams...) {
+		query.Del(param)
+	}
+	cardURL := base + h.signer.Sign(c.Request.URL.Path, query, expires)
+
+	typeLabel := cardTypeLabels[task.Type].Get(lang)
+	title := typeLabel + " · " + category.Label.Get(lang)
+
+	if format == "json" {
+		middleware.SkipEnvelope(c)
+		c.JSON(http.StatusOK, OEmbedResponse{
+			Version:      "1.0",
+			Type:         "rich",
+			ProviderName: "Truth or Dare",
+			ProviderURL:  base,
+			Title:        title,
+			HTML: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" title="%s" style="border:0" loading="lazy" sandbox></iframe>`,
+				html.EscapeString(cardURL), width, height, html.EscapeString(title)),
+			Width:    width,
+			Height:   height,
+			CacheAge: max(int(time.Until(expires).Seconds()), 0),
+		})
+		return
+	}
+
+	query.Set("format", "json")
+	card := taskCard{
+		Lang:      lang,
+		Dir:       textDirection(lang),
+		TaskLang:  task.Language,
+		TaskDir:   textDirection(task.Language),
+		Type:      task.Type,
+		TypeLabel: typeLabel,
+		Emoji:     category.Emoji,
+		Category:  category.Label.Get(lang),
+		Title:     title,
+		Text:      task.Text,
+		Hint:      task.Hint.Get(lang),
+		ShortCode: task.ShortCode,
+		URL:       cardURL,
+		OEmbedURL: base + h.signer.Sign(c.Request.URL.Path, query, expires),
+	}
+	// The card has no scripts and may be framed by any site
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := cardTemplate.Execute(c.Writer, card); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// shownTask loads a task and its category when the card may show them:
+// both active and neither needing consent, unless an admin previews it
+func (h *EmbedHandler) shownTask(c *gin.Context, id string) (*models.Task, *models.Category, error) {
+	task, err := h.tasks.FindByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	category, err := h.categories.FindByID(task.CategoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !middleware.IsAdmin(c) && (!task.IsActive || task.RequiresConsent || !category.IsActive || category.RequiresConsent) {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	return task, category, nil
+}
+
+// baseURL returns the scheme and host absolute links start with:
+// publicURL, or the request's when empty
+func baseURL(c *gin.Context, publicURL string) string {
+	if publicURL != "" {
+		return publicURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// cardSize returns the card size bounded by the oEmbed maxwidth and
+// maxheight parameters, responding with an error when they are invalid
+func cardSize(c *gin.Context) (width, height int, ok bool) {
+	width, height = cardWidth, cardHeight
+	for _, bound := range []struct {
+		param string
+		size  *int
+	}{{"maxwidth", &width}, {"maxheight", &height}} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			respondFieldErrors(c, []models.FieldError{{Field: bound.param, Message: "must be a positive integer"}})
+			return 0, 0, false
+		}
+		*bound.size = min(*bound.size, limit)
+	}
+	return width, height, true
+}
+
+// textDirection returns the HTML dir of text in a language
+func textDirection(lang string) string {
+	if lang == "ar" || lang == "ur" {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// cardTemplate renders a task card page
+var cardTemplate = template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}" dir="{{.Dir}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="Truth or Dare">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Text}}">
+<meta property="og:url" content="{{.URL}}">
+<meta name="twitter:card" content="summary">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Text}}">
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}" title="{{.Title}}">
+<style>
+body{margin:0;font-family:system-ui,sans-serif;background:#fff;color:#1f2937}
+.card{box-sizing:border-box;height:100vh;padding:16px;display:flex;flex-direction:column;gap:12px;border-top:6px solid #2563eb}
+.card.dare{border-top-color:#dc2626}
+header{display:flex;gap:8px;align-items:center;font-size:14px;color:#6b7280}
+.type{margin-inline-start:auto;font-weight:600;text-transform:uppercase;color:#1f2937}
+.text{margin:0;font-size:20px;line-height:1.4;flex:1}
+.hint{margin:0;font-size:14px;color:#6b7280}
+footer{font-size:12px;color:#9ca3af}
+</style>
+</head>
+<body>
+<article class="card {{.Type}}">
+<header><span>{{.Emoji}}</span><span>{{.Category}}</span><span class="type">{{.TypeLabel}}</span></header>
+<p class="text" lang="{{.TaskLang}}" dir="{{.TaskDir}}">{{.Text}}</p>
+{{with .Hint}}<p class="hint">{{.}}</p>{{end}}
+<footer>{{.ShortCode}}</footer>
+</article>
+</body>
+</html>
+`))
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/repository"
+	"github.com/truthordare/backend/internal/version"
+)
+
+// exportBatchSize is the number of rows read and flushed at a time
+const exportBatchSize = 1000
+
+// ExportRecord is one line of the NDJSON export. Type is "header" first,
+// then "category", "task" and "feature_flag" lines, and "end" last; an
+// export that failed part way ends with an "error" line instead.
+type ExportRecord struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// ExportHeader opens an export
+type ExportHeader struct {
+	FormatVersion int       `json:"format_version"`
+	SchemaVersion int       `json:"schema_version"`
+	AppVersion    string    `json:"app_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ExportSummary closes a complete export with its row counts. An export
+// without it was cut short.
+type ExportSummary struct {
+	Categories   int `json:"categories"`
+	Tasks        int `json:"tasks"`
+	FeatureFlags int `json:"feature_flags"`
+}
+
+// exportStream writes records as lines, compressed when asked, and flushes
+// them to the client after each batch
+type exportStream struct {
+	c   *gin.Context
+	gz  *gzip.Writer
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newExportStream(c *gin.Context, compress bool) *exportStream {
+	var w io.Writer = c.Writer
+	s := &exportStream{c: c}
+	if compress {
+		s.gz = gzip.NewWriter(c.Writer)
+		w = s.gz
+	}
+	s.buf = bufio.NewWriterSize(w, 64*1024)
+	s.enc = json.NewEncoder(s.buf)
+	s.enc.SetEscapeHTML(false)
+	return s
+}
+
+func (s *exportStream) write(recordType string, data interface{}) error {
+	return s.enc.Encode(ExportRecord{Type: recordType, Data: data})
+}
+
+// flush sends everything written so far
+func (s *exportStream) flush() error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+// close ends the stream, writing the gzip footer
+func (s *exportStream) close() error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if s.gz != nil {
+		return s.gz.Close()
+	}
+	return nil
+}
+
+// Stream godoc
+// @Summary Stream instance content
+// @Description Stream every category, task and runtime feature flag override as newline-delimited JSON, one record per line, for datasets too large for GET /admin/snapshot. Rows are read in keyset batches and flushed as they go, so memory use does not grow with the dataset. The response is gzip-compressed when the client sends Accept-Encoding: gzip. A complete export ends with an "end" record holding the row counts.
+// @Tags admin
+// @Produce x-ndjson
+// @Success 200 {object} ExportRecord
+// @Router /export [get]
+func (h *SnapshotHandler) Stream(c *gin.Context) {
+	createdAt := time.Now().UTC()
+	compress := strings.Contains(c.GetHeader("Accept-Encoding"), "gzip")
+
+	filename := fmt.Sprintf("tod-export-%s.ndjson", createdAt.Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Vary", "Accept-Encoding")
+	if compress {
+		c.Header("Content-Encoding", "gzip")
+	}
+	c.Status(http.StatusOK)
+
+	stream := newExportStream(c, compress)
+	summary := ExportSummary{}
+	err := h.streamRecords(stream, createdAt, &summary)
+	if err == nil {
+		err = stream.write("end", summary)
+	} else {
+		log.Error().Err(err).Interface("written", summary).Msg("Export stream failed")
+		// Tell the client the export is incomplete; best effort, as the
+		// connection may be what failed
+		_ = stream.write("error", gin.H{"message": "Export failed part way"})
+	}
+	if closeErr := stream.close(); closeErr != nil && err == nil {
+		log.Error().Err(closeErr).Msg("Failed to finish export stream")
+	}
+}
+
+// streamRecords writes the header and every row, flushing after each batch
+func (h *SnapshotHandler) streamRecords(stream *exportStream, createdAt time.Time, summary *ExportSummary) error {
+	err := stream.write("header", ExportHeader{
+		FormatVersion: repository.SnapshotFormatVersion,
+		SchemaVersion: database.SchemaVersion,
+		AppVersion:    version.Get().Version,
+		CreatedAt:     createdAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = h.repo.EachCategory(exportBatchSize, func(categories []repository.SnapshotCategory) error {
+		for _, category := range categories {
+			if err := stream.write("category", category); err != nil {
+				return err
+			}
+		}
+		summary.Categories += len(categories)
+		return stream.flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	err = h.repo.EachTask(exportBatchSize, func(tasks []repository.SnapshotTask) error {
+		for _, task := range tasks {
+			if err := stream.write("task", task); err != nil {
+				return err
+			}
+		}
+		summary.Tasks += len(tasks)
+		return stream.flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	flags, err := h.repo.FeatureFlags()
+	if err != nil {
+		return err
+	}
+	for _, flag := range flags {
+		if err := stream.write("feature_flag", flag); err != nil {
+			return err
+		}
+	}
+	summary.FeatureFlags = len(flags)
+	return nil
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// FeatureFlagHandler handles runtime feature flag changes
+type FeatureFlagHandler struct {
+	flags *featureflags.Store
+}
+
+// NewFeatureFlagHandler creates a new FeatureFlagHandler
+func NewFeatureFlagHandler(flags *featureflags.Store) *FeatureFlagHandler {
+	return &FeatureFlagHandler{flags: flags}
+}
+
+// SetFeatureFlagRequest represents the request body for flipping a flag
+type SetFeatureFlagRequest struct {
+	Enabled        *bool `json:"enabled" binding:"required"`
+	RolloutPercent *int  `json:"rollout_percent,omitempty" binding:"omitempty,min=0,max=100"`
+}
+
+// List godoc
+// @Summary List feature flags
+// @Description Get the effective state of every feature flag and where it comes from (default, env or runtime)
+// @Tags feature-flags
+// @Produce json
+// @Success 200 {object} map[string][]featureflags.State
+// @Router /feature-flags [get]
+func (h *FeatureFlagHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.flags.All()})
+}
+
+// Set godoc
+// @Summary Flip a feature flag
+// @Description Override a flag at runtime, optionally for a percentage of subjects. The override is stored and picked up by other instances within 30 seconds.
+// @Tags feature-flags
+// @Accept json
+// @Produce json
+// @Param name path string true "Flag name"
+// @Param request body SetFeatureFlagRequest true "Flag state"
+// @Success 200 {object} featureflags.State
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /feature-flags/{name} [put]
+func (h *FeatureFlagHandler) Set(c *gin.Context) {
+	var req SetFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	rollout := 100
+	if req.RolloutPercent != nil {
+		rollout = *req.RolloutPercent
+	}
+
+	name := c.Param("name")
+	state, err := h.flags.Set(name, *req.Enabled, rollout)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	log.Warn().
+		Str("flag", name).
+		Bool("enabled", state.Enabled).
+		Int("rollout_percent", state.RolloutPercent).
+		Str("ip", c.ClientIP()).
+		Msg("Feature flag changed")
+
+	c.JSON(http.StatusOK, state)
+}
+
+// Reset godoc
+// @Summary Reset a feature flag
+// @Description Remove the runtime override so the flag falls back to its environment or default state
+// @Tags feature-flags
+// @Produce json
+// @Param name path string true "Flag name"
+// @Success 200 {object} featureflags.State
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /feature-flags/{name} [delete]
+func (h *FeatureFlagHandler) Reset(c *gin.Context) {
+	name := c.Param("name")
+	state, err := h.flags.Reset(name)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	log.Warn().Str("flag", name).Str("ip", c.ClientIP()).Msg("Feature flag reset")
+
+	c.JSON(http.StatusOK, state)
+}
+
+// respondError maps a store error to a response
+func (h *FeatureFlagHandler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, featureflags.ErrUnknownFlag) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Unknown feature flag",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "database_error",
+		Message: "Failed to update feature flag",
+	})
+}
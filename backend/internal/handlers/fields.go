@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// fieldSelection holds the response shaping query parameters: fields= picks
+// the top-level JSON fields returned per item, text_languages= trims
+// multilingual fields (labels, hints) to the languages a client displays,
+// and plain=true strips emoji and formatting from texts for voice and
+// plain-text clients.
+type fieldSelection struct {
+	fields    []string
+	languages []string
+	plain     bool
+}
+
+// parseFieldSelection reads fields=, text_languages= and plain= for items
+// of type T, responding 400 for unknown field names. The id field is always
+// kept.
+func parseFieldSelection[T any](c *gin.Context) (*fieldSelection, bool) {
+	sel := &fieldSelection{languages: splitAndTrim(c.Query("text_languages"))}
+	if plain := parseBoolParam(c.Query("plain")); plain != nil {
+		sel.plain = *plain
+	}
+
+	fields := splitAndTrim(c.Query("fields"))
+	if len(fields) == 0 {
+		return sel, true
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(*new(T)))
+	sel.fields = []string{"id"}
+	for _, field := range fields {
+		if !known[field] {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_fields",
+				Message: fmt.Sprintf("Unknown field: %s", field),
+			})
+			return nil, false
+		}
+		if field != "id" {
+			sel.fields = append(sel.fields, field)
+		}
+	}
+	return sel, true
+}
+
+// sparse reports whether items must be trimmed to selected fields
+func (s *fieldSelection) sparse() bool {
+	return len(s.fields) > 0
+}
+
+// text trims multilingual text to the selected languages, if any, and
+// strips formatting when plain text was asked for
+func (s *fieldSelection) text(m models.MultilingualText) models.MultilingualText {
+	if len(s.languages) > 0 {
+		m = m.Only(s.languages...)
+	}
+	if s.plain {
+		m = m.PlainText()
+	}
+	return m
+}
+
+// str strips formatting from a single text when plain text was asked for
+func (s *fieldSelection) str(text string) string {
+	if s.plain {
+		return models.PlainText(text)
+	}
+	return text
+}
+
+// sparseItems trims each item to the selected top-level JSON fields
+func sparseItems[T any](s *fieldSelection, items []T) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		out[i] = make(map[string]json.RawMessage, len(s.fields))
+		for _, field := range s.fields {
+			// Fields tagged omitempty stay absent when empty
+			if value, ok := all[field]; ok {
+				out[i][field] = value
+			}
+		}
+	}
+	return out, nil
+}
+
+// jsonFieldNames lists the JSON names of a struct's exported fields
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		names[name] = true
+	}
+	return names
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/metrics"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// freshnessCacheTTL is how long metrics scrapes reuse a freshness report
+const freshnessCacheTTL = time.Minute
+
+// FreshnessHandler reports how recently each category+language received
+// new content, against the freshness SLA
+type FreshnessHandler struct {
+	taskRepo     *repository.TaskRepository
+	categoryRepo *repository.CategoryRepository
+	cfg          *config.GenerationConfig
+
+	mu     sync.Mutex
+	cached *FreshnessReport
+}
+
+// NewFreshnessHandler creates a new FreshnessHandler
+func NewFreshnessHandler(taskRepo *repository.TaskRepository, categoryRepo *repository.CategoryRepository, cfg *config.GenerationConfig) *FreshnessHandler {
+	return &FreshnessHandler{taskRepo: taskRepo, categoryRepo: categoryRepo, cfg: cfg}
+}
+
+// LanguageFreshness is the freshness of one category+language. NewestTaskAt
+// and AgeSeconds are null when it holds no active task.
+type LanguageFreshness struct {
+	Language     string     `json:"language"`
+	NewestTaskAt *time.Time `json:"newest_task_at"`
+	AgeSeconds   *int64     `json:"age_seconds"`
+	Fresh        bool       `json:"fresh"`
+}
+
+// CategoryFreshness is the freshness of each language of a category
+type CategoryFreshness struct {
+	CategoryID   string                  `json:"category_id"`
+	Label        models.MultilingualText `json:"label"`
+	FreshPercent float64                 `json:"fresh_percent"`
+	Languages    []LanguageFreshness     `json:"languages"`
+}
+
+// FreshnessReport covers every supported language of every active category
+type FreshnessReport struct {
+	SLAHours     int                 `json:"sla_hours"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+	Fresh        int                 `json:"fresh"`
+	Total        int                 `json:"total"`
+	FreshPercent float64             `json:"fresh_percent"`
+	Categories   []CategoryFreshness `json:"categories"`
+}
+
+// Get godoc
+// @Summary Get content freshness
+// @Description Report the age of the newest active task per active category and supported language, and the share of combinations within the freshness SLA (FRESHNESS_SLA_HOURS). Combinations without active tasks count as stale.
+// @Tags tasks
+// @Produce json
+// @Success 200 {object} FreshnessReport
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/freshness [get]
+func (h *FreshnessHandler) Get(c *gin.Context) {
+	report, err := h.Report(time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to compute content freshness",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Report computes the freshness of every active category and supported
+// language as of now
+func (h *FreshnessHandler) Report(now time.Time) (*FreshnessReport, error) {
+	isActive := true
+	categories, err := h.categoryRepo.FindAll(&repository.CategoryFilter{IsActive: &isActive})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := h.taskRepo.FindNewestActive()
+	if err != nil {
+		return nil, err
+	}
+
+	newest := make(map[[2]string]time.Time, len(rows))
+	for _, row := range rows {
+		newest[[2]string{row.CategoryID, row.Language}] = row.CreatedAt
+	}
+
+	sla := time.Duration(h.cfg.FreshnessSLAHours) * time.Hour
+	report := &FreshnessReport{
+		SLAHours:    h.cfg.FreshnessSLAHours,
+		GeneratedAt: now,
+		Categories:  make([]CategoryFreshness, 0, len(categories)),
+	}
+	for _, category := range categories {
+		entry := CategoryFreshness{
+			CategoryID: category.ID,
+			Label:      category.Label,
+			Languages:  make([]LanguageFreshness, 0, len(models.SupportedLanguages)),
+		}
+		fresh := 0
+		for _, lang := range models.SupportedLanguages {
+			language := LanguageFreshness{Language: lang}
+			if createdAt, ok := newest[[2]string{category.ID, lang}]; ok {
+				age := now.Sub(createdAt)
+				ageSeconds := int64(age / time.Second)
+				language.NewestTaskAt = &createdAt
+				language.AgeSeconds = &ageSeconds
+				language.Fresh = age <= sla
+			}
+			if language.Fresh {
+				fresh++
+			}
+			entry.Languages = append(entry.Languages, language)
+		}
+		entry.FreshPercent = percent(fresh, len(entry.Languages))
+
+		report.Fresh += fresh
+		report.Total += len(entry.Languages)
+		report.Categories = append(report.Categories, entry)
+	}
+	report.FreshPercent = percent(report.Fresh, report.Total)
+	return report, nil
+}
+
+// cachedReport returns a report at most freshnessCacheTTL old, so frequent
+// scrapes do not each scan the tasks table
+func (h *FreshnessHandler) cachedReport() (*FreshnessReport, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now().UTC()
+	if h.cached != nil && now.Sub(h.cached.GeneratedAt) < freshnessCacheTTL {
+		return h.cached, nil
+	}
+	report, err := h.Report(now)
+	if err != nil {
+		return nil, err
+	}
+	h.cached = report
+	return report, nil
+}
+
+// AgeSamples returns the age of the newest active task per category and
+// language for the metrics endpoint, +Inf where there is none
+func (h *FreshnessHandler) AgeSamples() []metrics.LabeledValue {
+	report, err := h.cachedReport()
+	if err != nil {
+		return nil
+	}
+
+	var samples []metrics.LabeledValue
+	for _, category := range report.Categories {
+		for _, language := range category.Languages {
+			age := math.Inf(1)
+			if language.AgeSeconds != nil {
+				age = float64(*language.AgeSeconds)
+			}
+			samples = append(samples, metrics.LabeledValue{
+				Labels: metrics.Labels{"category_id": category.CategoryID, "language": language.Language},
+				Value:  age,
+			})
+		}
+	}
+	return samples
+}
+
+// FreshRatio returns the share of category+language combinations within
+// the SLA for the metrics endpoint, NaN when it cannot be computed
+func (h *FreshnessHandler) FreshRatio() float64 {
+	report, err := h.cachedReport()
+	if err != nil {
+		return math.NaN()
+	}
+	if report.Total == 0 {
+		return 1
+	}
+	return float64(report.Fresh) / float64(report.Total)
+}
+
+// percent returns part of total as a percentage rounded to one decimal,
+// 100 when total is zero
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
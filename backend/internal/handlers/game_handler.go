@@ -0,0 +1,568 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// HostTokenHeader carries the host token of a game session
+const HostTokenHeader = "X-Host-Token"
+
+// GameHandler handles game session requests
+type GameHandler struct {
+	games      *game.Service
+	categories *repository.CategoryRepository
+	cfg        *config.ModerationConfig
+}
+
+// NewGameHandler creates a new GameHandler
+func NewGameHandler(games *game.Service, categories *repository.CategoryRepository, cfg *config.ModerationConfig) *GameHandler {
+	return &GameHandler{games: games, categories: categories, cfg: cfg}
+}
+
+// CreateGameSessionRequest starts a game session
+type CreateGameSessionRequest struct {
+	Players         []string            `json:"players" binding:"required"`
+	CategoryIDs     []string            `json:"category_ids"`
+	Languages       []string            `json:"languages"`
+	AgeGroups       []string            `json:"age_groups"`
+	RequiresConsent *bool               `json:"requires_consent"`
+	Consent         *GameConsentRequest `json:"consent"`
+	// TurnSeconds times turns, see game.GameSession
+	TurnSeconds      int  `json:"turn_seconds"`
+	ForfeitOnTimeout bool `json:"forfeit_on_timeout"`
+}
+
+// GameConsentRequest is the consent given when a game session starts
+type GameConsentRequest struct {
+	ClientID      string `json:"client_id" binding:"required,max=64"`
+	PolicyVersion string `json:"policy_version" binding:"required,max=20"`
+	AgeConfirmed  bool   `json:"age_confirmed"`
+}
+
+// GameSessionResponse is a game session and whose turn it is
+type GameSessionResponse struct {
+	game.GameSession
+	CurrentPlayer string `json:"current_player"`
+	// Consent is the consent recorded when the session was created
+	Consent *models.ConsentRecord `json:"consent,omitempty"`
+}
+
+// KickPlayerRequest removes a player from a game session
+type KickPlayerRequest struct {
+	Player string `json:"player" binding:"required"`
+}
+
+// LockRoomRequest locks or unlocks a game session's room
+type LockRoomRequest struct {
+	Locked bool `json:"locked"`
+}
+
+// SetCategoriesRequest changes the categories a game session draws from
+type SetCategoriesRequest struct {
+	CategoryIDs []string `json:"category_ids"`
+}
+
+// ExcludeRequest hides tasks and categories from a game session's draws
+type ExcludeRequest struct {
+	TaskIDs     []string `json:"task_ids"`
+	CategoryIDs []string `json:"category_ids"`
+}
+
+// AddCustomTaskRequest adds a one-off prompt to a game session
+type AddCustomTaskRequest struct {
+	Text     string `json:"text" binding:"required"`
+	Type     string `json:"type" binding:"required,oneof=truth dare"`
+	Language string `json:"language"`
+}
+
+// GameTurnResponse is a task drawn for a player
+type GameTurnResponse struct {
+	Player     string              `json:"player"`
+	Round      int                 `json:"round"`
+	NextPlayer string              `json:"next_player"`
+	Task       models.TaskResponse `json:"task"`
+	// Deadline is when the turn expires in timed games, TimeLeftMS how
+	// long that is from the response, for clients whose clock is off
+	Deadline   *time.Time `json:"deadline,omitempty"`
+	TimeLeftMS int64      `json:"time_left_ms,omitempty"`
+}
+
+// Create godoc
+// @Summary Start a game session
+// @Description Start a game for players taking turns in the given order. Draws come from the given categories, languages and age groups (all when empty) and, like GET /tasks/random, from tasks that do or do not require consent when requires_consent is set. Categories or tasks that require consent are only drawn with consent for the current policy, recorded against the session; without it requires_consent defaults to false, and choosing a category that requires consent, or requires_consent true, fails. The session remembers the tasks it served, so GET /sessions/{id}/next needs no exclude list. Other devices join the game with the returned code at /ws/rooms/{code}. With turn_seconds (5-600) set, a drawn task's turn expires that long after the draw unless it is skipped or completed, and with forfeit_on_timeout the player then gets a dare. The response carries the host token for host controls, returned only here. Sessions expire GAME_SESSION_TTL_HOURS after their last draw.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param request body CreateGameSessionRequest true "Players and task settings"
+// @Success 201 {object} GameSessionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /sessions [post]
+func (h *GameHandler) Create(c *gin.Context) {
+	var req CreateGameSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	session := &game.GameSession{
+		CategoryIDs:      req.CategoryIDs,
+		Languages:        req.Languages,
+		AgeGroups:        req.AgeGroups,
+		RequiresConsent:  req.RequiresConsent,
+		TurnSeconds:      req.TurnSeconds,
+		ForfeitOnTimeout: req.ForfeitOnTimeout,
+	}
+	for _, player := range req.Players {
+		session.Players = append(session.Players, strings.TrimSpace(player))
+	}
+	if errs := session.Validate(); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+	// Consent is needed to choose categories that require it, or tasks that
+	// do through requires_consent
+	restricted, ok := h.checkCategories(c, session.CategoryIDs)
+	if !ok {
+		return
+	}
+	restricted = restricted || (session.RequiresConsent != nil && *session.RequiresConsent)
+
+	var consent *models.ConsentRecord
+	switch {
+	case req.Consent != nil:
+		// Any draw but one limited to tasks without consent may need it
+		mayRequire := session.RequiresConsent == nil || *session.RequiresConsent
+		if !checkConsent(c, h.cfg, req.Consent.PolicyVersion, req.Consent.AgeConfirmed, mayRequire) {
+			return
+		}
+		consent = &models.ConsentRecord{
+			ClientID:      req.Consent.ClientID,
+			PolicyVersion: req.Consent.PolicyVersion,
+			AgeConfirmed:  req.Consent.AgeConfirmed,
+			CategoryIDs:   session.CategoryIDs,
+		}
+	case restricted:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "consent_required",
+			Message: "Consent must be given to play categories or tasks that require it",
+		})
+		return
+	case session.RequiresConsent == nil:
+		// Without consent the game keeps to tasks that need none
+		noConsent := false
+		session.RequiresConsent = &noConsent
+	}
+
+	if err := h.games.CreateWithConsent(session, consent); err != nil {
+		log.Error().Err(err).Msg("Failed to create game session")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create game session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer(), Consent: consent})
+}
+
+// Get godoc
+// @Summary Get a game session
+// @Description Get a game session's settings and whose turn it is, by its ID or room code
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Success 200 {object} GameSessionResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sessions/{id} [get]
+func (h *GameHandler) Get(c *gin.Context) {
+	session, err := h.games.Lookup(c.Param("id"))
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer()})
+}
+
+// Next godoc
+// @Summary Draw the next task of a game session
+// @Description Draw a task for the player whose turn it is and pass the turn to the next player. Tasks the session served recently are skipped until every matching task has been drawn. In timed games the response carries the turn's deadline and the time left. Two draws for the same turn at once get 409 for the later one.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param type query string false "Task type (truth, dare); either when empty"
+// @Success 200 {object} GameTurnResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /sessions/{id}/next [get]
+func (h *GameHandler) Next(c *gin.Context) {
+	taskType := c.Query("type")
+	if taskType != "" && taskType != models.TaskTypeTruth && taskType != models.TaskTypeDare {
+		respondFieldErrors(c, []models.FieldError{{Field: "type", Message: "must be truth or dare"}})
+		return
+	}
+
+	task, player, session, err := h.games.Next(c.Param("id"), taskType)
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, GameTurnResponse{
+		Player:     player,
+		Round:      session.Round,
+		NextPlayer: session.CurrentPlayer(),
+		Task:       mapperFor(c).task(task),
+		Deadline:   session.TurnDeadline,
+		TimeLeftMS: game.TimeLeft(session.TurnDeadline).Milliseconds(),
+	})
+}
+
+// Kick godoc
+// @Summary Kick a player out of a game session
+// @Description Host only: remove a player from the turn order. The turn stays with the player it was with, or passes on when the kicked player had it. Devices in the room get a kicked event.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param X-Host-Token header string true "Host token"
+// @Param request body KickPlayerRequest true "Player"
+// @Success 200 {object} GameSessionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /sessions/{id}/kick [post]
+func (h *GameHandler) Kick(c *gin.Context) {
+	var req KickPlayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	session := h.hostSession(c)
+	if session == nil {
+		return
+	}
+
+	if err := h.games.Kick(session, strings.TrimSpace(req.Player)); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer()})
+}
+
+// Lock godoc
+// @Summary Lock a game session's room
+// @Description Host only: with locked true the room takes no more devices, including ones reconnecting; devices in it stay. Devices in the room get a locked or unlocked event.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param X-Host-Token header string true "Host token"
+// @Param request body LockRoomRequest true "Whether the room is locked"
+// @Success 200 {object} GameSessionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /sessions/{id}/lock [post]
+func (h *GameHandler) Lock(c *gin.Context) {
+	var req LockRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	session := h.hostSession(c)
+	if session == nil {
+		return
+	}
+
+	if err := h.games.Lock(session, req.Locked); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer()})
+}
+
+// SetCategories godoc
+// @Summary Change the categories of a game session
+// @Description Host only: draw from other categories from the next draw on, all when empty. Categories that require consent can only be chosen in games that may draw tasks requiring it, see POST /sessions. Devices in the room get a categories_changed event.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param X-Host-Token header string true "Host token"
+// @Param request body SetCategoriesRequest true "Categories"
+// @Success 200 {object} GameSessionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /sessions/{id}/categories [put]
+func (h *GameHandler) SetCategories(c *gin.Context) {
+	var req SetCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	session := h.hostSession(c)
+	if session == nil {
+		return
+	}
+	restricted, ok := h.checkCategories(c, req.CategoryIDs)
+	if !ok {
+		return
+	}
+	if restricted && session.RequiresConsent != nil && !*session.RequiresConsent {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "consent_required",
+			Message: "Categories that require consent can only be played in games started with consent",
+		})
+		return
+	}
+
+	if err := h.games.SetCategories(session, req.CategoryIDs); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer()})
+}
+
+// Exclude godoc
+// @Summary Exclude tasks or categories from a game session
+// @Description Host only: hide tasks and whole categories from the session's draws from the next draw on, such as inside jokes or sensitive topics, on top of those hidden before. Up to 500 tasks and 500 categories can be excluded. Devices in the room get an exclusions_changed event, without what was excluded.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param X-Host-Token header string true "Host token"
+// @Param request body ExcludeRequest true "Tasks and categories"
+// @Success 200 {object} GameSessionResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /sessions/{id}/exclusions [post]
+func (h *GameHandler) Exclude(c *gin.Context) {
+	var req ExcludeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if len(req.TaskIDs) == 0 && len(req.CategoryIDs) == 0 {
+		respondFieldErrors(c, []models.FieldError{{Field: "task_ids", Message: "task_ids or category_ids must not be empty"}})
+		return
+	}
+	session := h.hostSession(c)
+	if session == nil {
+		return
+	}
+	if _, ok := h.checkCategories(c, req.CategoryIDs); !ok {
+		return
+	}
+
+	if err := h.games.Exclude(session, req.TaskIDs, req.CategoryIDs); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer()})
+}
+
+// AddCustomTask godoc
+// @Summary Add a custom task to a game session
+// @Description Host only: add a one-off prompt, drawn once before the session's other tasks of its type. It stays with the session and is never stored as a task. Text with profanity, or with BLOCKED_WORDS, is refused. Up to 50 custom tasks can wait to be drawn. Devices in the room get a custom_task_added event, without the text.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param X-Host-Token header string true "Host token"
+// @Param request body AddCustomTaskRequest true "Custom task"
+// @Success 201 {object} game.CustomTask
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /sessions/{id}/custom-tasks [post]
+func (h *GameHandler) AddCustomTask(c *gin.Context) {
+	var req AddCustomTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	session := h.hostSession(c)
+	if session == nil {
+		return
+	}
+	custom := &game.CustomTask{Type: req.Type, Text: models.SanitizeText(req.Text), Language: req.Language}
+	errs := models.ValidateText("text", custom.Text, models.MaxTaskTextLength)
+	if len(errs) == 0 && models.ContainsProfanity(custom.Text, h.cfg.BlockedWords) {
+		errs = append(errs, models.FieldError{Field: "text", Message: "must not contain profanity or blocked words"})
+	}
+	if custom.Language != "" && !models.IsValidLanguage(custom.Language) {
+		errs = append(errs, models.FieldError{Field: "language", Message: "unsupported language code"})
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	if err := h.games.AddCustomTask(session, custom); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, custom)
+}
+
+// End godoc
+// @Summary End a game session
+// @Description Host only: end the game. It can no longer be drawn from or changed, but can be read until it expires. Devices in the room get an ended event.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Param X-Host-Token header string true "Host token"
+// @Success 200 {object} GameSessionResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /sessions/{id}/end [post]
+func (h *GameHandler) End(c *gin.Context) {
+	session := h.hostSession(c)
+	if session == nil {
+		return
+	}
+
+	if err := h.games.End(session); err != nil {
+		respondGameError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, GameSessionResponse{GameSession: *session, CurrentPlayer: session.CurrentPlayer()})
+}
+
+// hostSession finds the game session in the path, by ID or room code, and
+// checks the request carries its host token. It responds and returns nil
+// otherwise.
+func (h *GameHandler) hostSession(c *gin.Context) *game.GameSession {
+	session, err := h.games.Lookup(c.Param("id"))
+	if err != nil {
+		respondGameError(c, err)
+		return nil
+	}
+	if !session.IsHost(c.GetHeader(HostTokenHeader)) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "forbidden",
+			Message: "Only the host can change the game session",
+		})
+		return nil
+	}
+	return session
+}
+
+// checkCategories checks the categories exist and reports whether any
+// requires consent. It responds and returns false when they do not exist.
+func (h *GameHandler) checkCategories(c *gin.Context, ids []string) (restricted, ok bool) {
+	if len(ids) == 0 {
+		return false, true
+	}
+	categories, err := h.categories.FindByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check categories",
+		})
+		return false, false
+	}
+	if len(categories) != len(ids) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_category",
+			Message: "One or more categories do not exist",
+		})
+		return false, false
+	}
+	for _, category := range categories {
+		restricted = restricted || category.RequiresConsent
+	}
+	return restricted, true
+}
+
+// respondGameError maps game session errors to responses
+func respondGameError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, game.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Game session not found or expired",
+		})
+	case errors.Is(err, game.ErrNoTask):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "No matching task found",
+		})
+	case errors.Is(err, game.ErrConflict):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Another draw for this turn finished first",
+		})
+	case errors.Is(err, game.ErrEnded):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "ended",
+			Message: "The game has ended",
+		})
+	case errors.Is(err, game.ErrUnknownPlayer):
+		respondFieldErrors(c, []models.FieldError{{Field: "player", Message: "is not in the game"}})
+	case errors.Is(err, game.ErrLastPlayer):
+		respondFieldErrors(c, []models.FieldError{{Field: "player", Message: "is the last player"}})
+	case errors.Is(err, game.ErrUnknownTask):
+		respondFieldErrors(c, []models.FieldError{{Field: "task_ids", Message: "one or more tasks do not exist"}})
+	case errors.Is(err, game.ErrTooManyExclusions):
+		respondFieldErrors(c, []models.FieldError{{Field: "task_ids", Message: "at most 500 tasks and 500 categories can be excluded"}})
+	case errors.Is(err, game.ErrTooManyCustomTasks):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "too_many_custom_tasks",
+			Message: "Up to 50 custom tasks can wait to be drawn",
+		})
+	case errors.Is(err, maintenance.ErrReadOnly):
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "read_only",
+			Message: "Game sessions are paused while the service is in read-only mode",
+		})
+	default:
+		log.Error().Err(err).Msg("Failed to handle game session request")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update the game session",
+		})
+	}
+}
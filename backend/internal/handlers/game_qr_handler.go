@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/skip2/go-qrcode"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// QR code rendering
+const (
+	// qrPNGSize is the width and height of PNG codes in pixels
+	qrPNGSize = 512
+	// qrCacheMaxEntries bounds the codes kept in memory
+	qrCacheMaxEntries = 500
+)
+
+// qrContentTypes are the QR code formats and their content types
+var qrContentTypes = map[string]string{
+	"svg": "image/svg+xml",
+	"png": "image/png",
+}
+
+// GameQRHandler renders QR codes joining game sessions, for TVs and host
+// screens to show
+type GameQRHandler struct {
+	games     *game.Service
+	joinURL   string
+	publicURL string
+
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+// NewGameQRHandler creates a new GameQRHandler. joinURL is the link codes
+// encode, with {code} replaced by the room code; empty links to
+// GET /api/v1/sessions/{code} on publicURL or the request's host.
+func NewGameQRHandler(games *game.Service, joinURL, publicURL string) *GameQRHandler {
+	return &GameQRHandler{games: games, joinURL: joinURL, publicURL: publicURL, cache: make(map[string][]byte)}
+}
+
+// QR godoc
+// @Summary QR code joining a game session
+// @Description A QR code of the link joining the session, by default GET /api/v1/sessions/{code}, or GAME_JOIN_URL with {code} replaced. Codes are rendered once per link and format and kept in memory.
+// @Tags sessions
+// @Produce image/svg+xml
+// @Produce image/png
+// @Param id path string true "Room code or session ID"
+// @Param format query string false "svg (default) or png"
+// @Success 200 {file} binary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sessions/{id}/qr [get]
+func (h *GameQRHandler) QR(c *gin.Context) {
+	format := c.DefaultQuery("format", "svg")
+	contentType, ok := qrContentTypes[format]
+	if !ok {
+		respondFieldErrors(c, []models.FieldError{{Field: "format", Message: "must be svg or png"}})
+		return
+	}
+	session, err := h.games.Lookup(c.Param("id"))
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	link := h.link(c, session.Code)
+	image, err := h.render(link, format)
+	if err != nil {
+		log.Error().Err(err).Str("link", link).Msg("Failed to render session QR code")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "render_error",
+			Message: "Failed to render the QR code",
+		})
+		return
+	}
+	// A code's link only changes with the configuration
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, contentType, image)
+}
+
+// link returns the link joining the session with a room code
+func (h *GameQRHandler) link(c *gin.Context, code string) string {
+	if h.joinURL != "" {
+		return strings.ReplaceAll(h.joinURL, "{code}", code)
+	}
+	return baseURL(c, h.publicURL) + "/api/v1/sessions/" + code
+}
+
+// render returns the QR code of a link in a format, from the cache when
+// it was rendered before
+func (h *GameQRHandler) render(link, format string) ([]byte, error) {
+	key := format + " " + link
+	h.mu.Lock()
+	image, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok {
+		return image, nil
+	}
+
+	code, err := qrcode.New(link, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	if format == "png" {
+		image, err = code.PNG(qrPNGSize)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		image = qrSVG(code.Bitmap())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.cache) >= qrCacheMaxEntries {
+		for k := range h.cache {
+			delete(h.cache, k)
+			break
+		}
+	}
+	h.cache[key] = image
+	return image, nil
+}
+
+// qrSVG draws the dark modules of a QR code bitmap, quiet zone included,
+// as one SVG path, one unit per module
+func qrSVG(bitmap [][]bool) []byte {
+	var b strings.Builder
+	size := strconv.Itoa(len(bitmap))
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 ` + size + " " + size + `" shape-rendering="crispEdges">`)
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				b.WriteString("M" + strconv.Itoa(x) + " " + strconv.Itoa(y) + "h1v1h-1z")
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return []byte(b.String())
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// topDaresLimit is the number of dares a summary highlights
+const topDaresLimit = 3
+
+// GameSummaryHandler recaps game sessions
+type GameSummaryHandler struct {
+	games     *game.Service
+	telemetry *repository.TelemetryRepository
+}
+
+// NewGameSummaryHandler creates a new GameSummaryHandler
+func NewGameSummaryHandler(games *game.Service, telemetry *repository.TelemetryRepository) *GameSummaryHandler {
+	return &GameSummaryHandler{games: games, telemetry: telemetry}
+}
+
+// GameSummaryResponse recaps a game session
+type GameSummaryResponse struct {
+	game.Summary
+	Turns []GameSummaryTurn `json:"turns"`
+	// TopDares are the game's dares players liked most, across all games
+	TopDares []GameSummaryDare `json:"top_dares"`
+}
+
+// GameSummaryTurn is a task drawn in a game and its outcome
+type GameSummaryTurn struct {
+	Player  string              `json:"player"`
+	Task    models.TaskResponse `json:"task"`
+	Forfeit bool                `json:"forfeit,omitempty"`
+	Outcome string              `json:"outcome,omitempty"`
+}
+
+// GameSummaryDare is a dare drawn in a game and its likes
+type GameSummaryDare struct {
+	Task  models.TaskResponse `json:"task"`
+	Likes int64               `json:"likes"`
+}
+
+// Summary godoc
+// @Summary Recap a game session
+// @Description A shareable recap of a game session so far, by ID or room code: every task drawn, for whom and its outcome (completed, skipped or expired; none while open or when left unresolved), counts per game and per player, and up to three of its dares with the most likes in the play telemetry. Available until the session expires and is deleted with its events.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID or room code"
+// @Success 200 {object} GameSummaryResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /sessions/{id}/summary [get]
+func (h *GameSummaryHandler) Summary(c *gin.Context) {
+	session, err := h.games.Lookup(c.Param("id"))
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+	summary, err := h.games.Summary(session)
+	if err != nil {
+		respondGameError(c, err)
+		return
+	}
+
+	mapper := mapperFor(c)
+	response := GameSummaryResponse{
+		Summary:  *summary,
+		Turns:    make([]GameSummaryTurn, 0, len(summary.Turns)),
+		TopDares: []GameSummaryDare{},
+	}
+	dares := map[string]*models.Task{}
+	var dareIDs []string
+	for _, turn := range summary.Turns {
+		response.Turns = append(response.Turns, GameSummaryTurn{
+			Player:  turn.Player,
+			Task:    mapper.task(turn.Task),
+			Forfeit: turn.Forfeit,
+			Outcome: turn.Outcome,
+		})
+		if turn.Task.Type == models.TaskTypeDare && dares[turn.Task.ID] == nil {
+			dares[turn.Task.ID] = turn.Task
+			dareIDs = append(dareIDs, turn.Task.ID)
+		}
+	}
+
+	likes, err := h.telemetry.CountByTask(models.TelemetryTaskLiked, dareIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count game session likes")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to count likes",
+		})
+		return
+	}
+	for _, id := range dareIDs {
+		if likes[id] > 0 {
+			response.TopDares = append(response.TopDares, GameSummaryDare{Task: mapper.task(dares[id]), Likes: likes[id]})
+		}
+	}
+	// Most liked first, in the order drawn on ties
+	sort.SliceStable(response.TopDares, func(i, j int) bool {
+		return response.TopDares[i].Likes > response.TopDares[j].Likes
+	})
+	if len(response.TopDares) > topDaresLimit {
+		response.TopDares = response.TopDares[:topDaresLimit]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -8,19 +8,22 @@ import (
 	"github.com/truthordare/backend/internal/ai"
 	"github.com/truthordare/backend/internal/models"
 	"github.com/truthordare/backend/internal/prompts"
+	"github.com/truthordare/backend/internal/repository"
 )
 
 // GenerateCategoryLabelsHandler handles AI-based category label generation
 type GenerateCategoryLabelsHandler struct {
 	aiClient     *ai.Client
 	promptLoader *prompts.PromptLoader
+	languageRepo *repository.LanguageRepository
 }
 
 // NewGenerateCategoryLabelsHandler creates a new handler instance
-func NewGenerateCategoryLabelsHandler() *GenerateCategoryLabelsHandler {
+func NewGenerateCategoryLabelsHandler(languageRepo *repository.LanguageRepository) *GenerateCategoryLabelsHandler {
 	return &GenerateCategoryLabelsHandler{
 		aiClient:     ai.GetClient(),
 		promptLoader: prompts.GetLoader(),
+		languageRepo: languageRepo,
 	}
 }
 
@@ -29,7 +32,7 @@ type GenerateCategoryLabelsRequest struct {
 	// CategoryName is the English name of the category to translate
 	CategoryName string `json:"category_name" binding:"required"`
 	// Languages is an optional list of language codes to translate to
-	// If empty, all supported languages will be used
+	// If empty, all supported languages that are not frozen will be used
 	Languages []string `json:"languages,omitempty"`
 }
 
@@ -37,6 +40,7 @@ type GenerateCategoryLabelsRequest struct {
 type GenerateCategoryLabelsResponse struct {
 	Success bool                    `json:"success"`
 	Labels  models.MultilingualText `json:"labels"`
+	Cache   string                  `json:"cache"` // "hit" when served from the AI response cache
 }
 
 // SupportedLanguages returns the list of supported language codes
@@ -44,14 +48,16 @@ var SupportedLanguages = []string{"en", "zh", "es", "hi", "ar", "fr", "pt", "bn"
 
 // GenerateCategoryLabels godoc
 // @Summary Generate category labels using AI
-// @Description Generate multilingual labels for a category name using AI translation
+// @Description Generate multilingual labels for a category name using AI translation. Without languages, every language that is not frozen is used; naming a frozen language is a conflict.
 // @Tags generate
 // @Accept json
 // @Produce json
 // @Param request body GenerateCategoryLabelsRequest true "Category name and optional languages"
 // @Success 200 {object} GenerateCategoryLabelsResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /generate/category-labels [post]
 func (h *GenerateCategoryLabelsHandler) GenerateCategoryLabels(c *gin.Context) {
 	var req GenerateCategoryLabelsRequest
@@ -73,14 +79,8 @@ func (h *GenerateCategoryLabelsHandler) GenerateCategoryLabels(c *gin.Context) {
 		return
 	}
 
-	// Use default languages if not specified
-	languages := req.Languages
-	if len(languages) == 0 {
-		languages = SupportedLanguages
-	}
-
 	// Validate languages
-	for _, lang := range languages {
+	for _, lang := range req.Languages {
 		if !isValidLanguage(lang) {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Error:   "validation_error",
@@ -90,6 +90,33 @@ func (h *GenerateCategoryLabelsHandler) GenerateCategoryLabels(c *gin.Context) {
 		}
 	}
 
+	// Use the languages that are not frozen if not specified
+	languages := req.Languages
+	if len(languages) == 0 {
+		frozen, err := h.languageRepo.FrozenLanguages()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to load frozen languages",
+			})
+			return
+		}
+		for _, lang := range SupportedLanguages {
+			if !frozen[lang] {
+				languages = append(languages, lang)
+			}
+		}
+		if len(languages) == 0 {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "language_frozen",
+				Message: "Every language is frozen for AI generation and machine translation",
+			})
+			return
+		}
+	} else if !checkNotFrozen(c, h.languageRepo, languages...) {
+		return
+	}
+
 	// Check if AI is configured
 	if !h.aiClient.IsConfigured() {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -110,11 +137,11 @@ func (h *GenerateCategoryLabelsHandler) GenerateCategoryLabels(c *gin.Context) {
 	}
 
 	// Load and prepare the user prompt
-	userPrompt, err := h.promptLoader.LoadAndReplace(
-		"category_labels",
+	placeholders := []prompts.Placeholder{
 		prompts.P("CATEGORY_NAME", req.CategoryName),
 		prompts.P("LANGUAGES", strings.Join(languages, ", ")),
-	)
+	}
+	userPrompt, err := h.promptLoader.LoadAndReplace("category_labels", placeholders...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "internal_error",
@@ -130,15 +157,15 @@ func (h *GenerateCategoryLabelsHandler) GenerateCategoryLabels(c *gin.Context) {
 	}
 
 	var labels models.MultilingualText
-	err = h.aiClient.CompleteJSON(messages, &labels,
+	cacheStatus, err := h.aiClient.CompleteJSONCached(prompts.Key("category_labels", placeholders...), messages, &labels,
+		ai.WithContext(c.Request.Context()),
+		ai.WithTimeout(aiRequestTimeout),
+		ai.WithPrompt("category_labels", placeholders...),
 		ai.WithTemperature(0.3), // Lower temperature for more consistent translations
 		ai.WithMaxTokens(2500),  // Increased for multilingual responses
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "ai_error",
-			Message: "Failed to generate labels: " + err.Error(),
-		})
+		respondAIError(c, err, "Failed to generate labels")
 		return
 	}
 
@@ -150,6 +177,7 @@ func (h *GenerateCategoryLabelsHandler) GenerateCategoryLabels(c *gin.Context) {
 	c.JSON(http.StatusOK, GenerateCategoryLabelsResponse{
 		Success: true,
 		Labels:  labels,
+		Cache:   cacheStatus,
 	})
 }
 
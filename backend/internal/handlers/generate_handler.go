@@ -1,34 +1,58 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/middleware"
 	"github.com/truthordare/backend/internal/models"
 	"github.com/truthordare/backend/internal/prompts"
 	"github.com/truthordare/backend/internal/repository"
 )
 
+// aiRequestTimeout caps a single AI call made while serving an HTTP request
+const aiRequestTimeout = 30 * time.Second
+
 // GenerateHandler handles AI content generation requests
 type GenerateHandler struct {
 	aiClient     *ai.Client
 	promptLoader *prompts.PromptLoader
 	taskRepo     *repository.TaskRepository
 	categoryRepo *repository.CategoryRepository
+	runRepo      *repository.GenerationRunRepository
+	glossaryRepo *repository.GlossaryRepository
+	languageRepo *repository.LanguageRepository
+	cfg          *config.GenerationConfig
 }
 
 // NewGenerateHandler creates a new GenerateHandler
-func NewGenerateHandler(taskRepo *repository.TaskRepository, categoryRepo *repository.CategoryRepository) *GenerateHandler {
+func NewGenerateHandler(
+	taskRepo *repository.TaskRepository,
+	categoryRepo *repository.CategoryRepository,
+	runRepo *repository.GenerationRunRepository,
+	glossaryRepo *repository.GlossaryRepository,
+	languageRepo *repository.LanguageRepository,
+	cfg *config.GenerationConfig,
+) *GenerateHandler {
 	return &GenerateHandler{
 		aiClient:     ai.GetClient(),
 		promptLoader: prompts.GetLoader(),
 		taskRepo:     taskRepo,
 		categoryRepo: categoryRepo,
+		runRepo:      runRepo,
+		glossaryRepo: glossaryRepo,
+		languageRepo: languageRepo,
+		cfg:          cfg,
 	}
 }
 
@@ -45,37 +69,64 @@ type GenerateTasksRequest struct {
 	AgeGroup   *string `json:"age_group"`   // Optional - null means all age groups
 	Language   *string `json:"language"`    // Optional - null means all languages
 	Count      int     `json:"count"`       // Tasks per combination
+	WithHints  bool    `json:"with_hints"`  // Also generate a hint for every created task
+
+	// Optional per-run AI overrides - null means the server default
+	Model       *string  `json:"model"`       // Must be in the allowed model list
+	Temperature *float64 `json:"temperature"` // 0 to 2
+	MaxTokens   *int     `json:"max_tokens"`  // 1 to 8000
+
+	// Optional few-shot settings - null means the server default
+	Examples        *int    `json:"examples"`         // Existing truths and dares shown to the model; 0 disables
+	ExampleStrategy *string `json:"example_strategy"` // "random" or "recent"
 }
 
-// GenerateTasksResponse is the response for task generation
+// GenerateTasksResponse is the response for task generation. Report is
+// the stored report card of the run, also served by
+// GET /generate/jobs/:id/report.
 type GenerateTasksResponse struct {
-	Success           bool   `json:"success"`
-	Message           string `json:"message"`
-	TotalTruthsCount  int    `json:"total_truths_count"`
-	TotalDaresCount   int    `json:"total_dares_count"`
-	TasksCreated      int    `json:"tasks_created"`
-	CombinationsCount int    `json:"combinations_count"`
+	Success           bool                  `json:"success"`
+	Message           string                `json:"message"`
+	TotalTruthsCount  int                   `json:"total_truths_count"`
+	TotalDaresCount   int                   `json:"total_dares_count"`
+	TasksCreated      int                   `json:"tasks_created"`
+	CombinationsCount int                   `json:"combinations_count"`
+	RunID             string                `json:"run_id"`
+	Report            *models.GenerationRun `json:"report"`
+}
+
+// GenerationReportResponse is the report card of a generation run with
+// the review outcome so far of the tasks it created
+type GenerationReportResponse struct {
+	models.GenerationRun
+	Review repository.GenerationReviewCounts `json:"review"`
 }
 
 // generationParams holds parameters for a single generation
 type generationParams struct {
 	CategoryID   string
 	CategoryName string
-	AgeGroup     string
-	Language     string
-	ExplicitMode bool
+	// CategoryAgeGroup selects the text rules; the tasks are stored in the
+	// category whatever audience they were generated for
+	CategoryAgeGroup string
+	AgeGroup         string
+	Language         string
+	ExplicitMode     bool
 }
 
 // Generate godoc
 // @Summary Generate tasks using AI
-// @Description Generate truth and dare tasks using AI. If category_id, age_group, or language is null, generates for all combinations.
+// @Description Generate truth and dare tasks using AI. If category_id, age_group, or language is null, generates for all combinations. Frozen languages are skipped, and requesting one is a conflict. Runs and requested tasks per admin key and day are capped by GENERATE_DAILY_RUN_QUOTA and GENERATE_DAILY_COUNT_QUOTA.
 // @Tags generate
 // @Accept json
 // @Produce json
 // @Param request body GenerateTasksRequest true "Generation parameters (null values mean 'all')"
 // @Success 200 {object} GenerateTasksResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /generate [post]
 func (h *GenerateHandler) Generate(c *gin.Context) {
 	var req GenerateTasksRequest
@@ -95,6 +146,14 @@ func (h *GenerateHandler) Generate(c *gin.Context) {
 		req.Count = 50 // Cap at 50
 	}
 
+	if err := h.validateOverrides(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Check if AI is configured
 	if !h.aiClient.IsConfigured() {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -104,8 +163,24 @@ func (h *GenerateHandler) Generate(c *gin.Context) {
 		return
 	}
 
+	frozen, err := h.languageRepo.FrozenLanguages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to load frozen languages",
+		})
+		return
+	}
+	if req.Language != nil && frozen[*req.Language] {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "language_frozen",
+			Message: "Language is frozen for AI generation and machine translation: " + *req.Language,
+		})
+		return
+	}
+
 	// Build list of generation combinations
-	combinations, err := h.buildCombinations(req)
+	combinations, err := h.buildCombinations(req, frozen)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "validation_error",
@@ -122,38 +197,208 @@ func (h *GenerateHandler) Generate(c *gin.Context) {
 		return
 	}
 
-	// Generate tasks for each combination
-	totalTruths := 0
-	totalDares := 0
-	tasksCreated := 0
+	requested := req.Count * len(combinations)
+	if !h.checkQuota(c, requested) {
+		return
+	}
 
+	glossary, err := h.glossaryRepo.FindAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to load glossary",
+		})
+		return
+	}
+
+	run := &models.GenerationRun{
+		Trigger:        models.GenerationTriggerManual,
+		Status:         models.GenerationRunRunning,
+		StartedAt:      time.Now().UTC(),
+		RequestedBy:    middleware.AdminKeyID(c),
+		RequestedCount: requested,
+	}
+	if err := h.runRepo.Create(run); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record generation run",
+		})
+		return
+	}
+
+	// Generate tasks for each combination
+	var runErr error
 	for _, params := range combinations {
-		truths, dares, created, err := h.generateForParams(params, req.Count)
+		combination, err := h.generateForParams(c.Request.Context(), params, req, glossary, run)
+		if errors.Is(err, ai.ErrCircuitOpen) {
+			// Remaining combinations would be rejected as well
+			run.AddCombination(combination)
+			if run.TasksCreated == 0 {
+				h.finishRun(run, err)
+				respondAIError(c, err, "Failed to generate tasks")
+				return
+			}
+			log.Warn().Err(err).Msg("Stopping generation early; AI provider unavailable")
+			runErr = err
+			break
+		}
 		if err != nil {
 			log.Error().Err(err).
 				Str("category", params.CategoryName).
 				Str("age_group", params.AgeGroup).
 				Str("language", params.Language).
 				Msg("Failed to generate tasks for combination")
-			continue
 		}
-		totalTruths += truths
-		totalDares += dares
-		tasksCreated += created
+		run.AddCombination(combination)
 	}
+	// A run stopped early by the breaker still created tasks; report it
+	// completed with the error noted
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	h.finishRun(run, nil)
 
 	c.JSON(http.StatusOK, GenerateTasksResponse{
 		Success:           true,
 		Message:           "Tasks generated and saved successfully",
-		TotalTruthsCount:  totalTruths,
-		TotalDaresCount:   totalDares,
-		TasksCreated:      tasksCreated,
+		TotalTruthsCount:  run.TruthsGenerated,
+		TotalDaresCount:   run.DaresGenerated,
+		TasksCreated:      run.TasksCreated,
 		CombinationsCount: len(combinations),
+		RunID:             run.ID,
+		Report:            run,
 	})
 }
 
-// buildCombinations creates all parameter combinations based on the request
-func (h *GenerateHandler) buildCombinations(req GenerateTasksRequest) ([]generationParams, error) {
+// finishRun closes a run and stores its report. Storage failures are
+// logged; the tasks are already saved.
+func (h *GenerateHandler) finishRun(run *models.GenerationRun, err error) {
+	run.Finish(err)
+	if err := h.runRepo.Save(run); err != nil {
+		log.Error().Err(err).Str("run_id", run.ID).Msg("Failed to save generation run report")
+	}
+}
+
+// ListJobs godoc
+// @Summary List generation runs
+// @Description List the most recent generation runs, manual and scheduled, newest first
+// @Tags generate
+// @Produce json
+// @Param limit query int false "Number of runs (max 100)" default(20)
+// @Success 200 {object} []models.GenerationRun
+// @Failure 500 {object} models.ErrorResponse
+// @Router /generate/jobs [get]
+func (h *GenerateHandler) ListJobs(c *gin.Context) {
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	runs, err := h.runRepo.FindRecent(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch generation runs",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// Report godoc
+// @Summary Get a generation run report
+// @Description Get the report card of a generation run: counts per combination, duplicates skipped, texts rejected by validation, token spend and duration, plus how many of its tasks have since been approved, rejected or are still pending review
+// @Tags generate
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} GenerationReportResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /generate/jobs/{id}/report [get]
+func (h *GenerateHandler) Report(c *gin.Context) {
+	run, err := h.runRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Generation run not found",
+		})
+		return
+	}
+
+	review, err := h.runRepo.ReviewCounts(run.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch review counts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GenerationReportResponse{GenerationRun: *run, Review: review})
+}
+
+// validateOverrides checks the optional AI overrides against the allowed ranges
+func (h *GenerateHandler) validateOverrides(req GenerateTasksRequest) error {
+	if req.Model != nil && !h.aiClient.IsModelAllowed(*req.Model) {
+		return fmt.Errorf("model %q is not allowed; allowed models: %s",
+			*req.Model, strings.Join(h.aiClient.AllowedModels(), ", "))
+	}
+	if req.Temperature != nil && (*req.Temperature < ai.MinTemperature || *req.Temperature > ai.MaxTemperature) {
+		return fmt.Errorf("temperature must be between %g and %g", ai.MinTemperature, ai.MaxTemperature)
+	}
+	if req.MaxTokens != nil && (*req.MaxTokens < 1 || *req.MaxTokens > ai.MaxMaxTokens) {
+		return fmt.Errorf("max_tokens must be between 1 and %d", ai.MaxMaxTokens)
+	}
+	if req.Examples != nil && (*req.Examples < 0 || *req.Examples > maxExamples) {
+		return fmt.Errorf("examples must be between 0 and %d", maxExamples)
+	}
+	if req.ExampleStrategy != nil && !repository.IsValidExampleStrategy(*req.ExampleStrategy) {
+		return fmt.Errorf("invalid example_strategy: %s", *req.ExampleStrategy)
+	}
+	return nil
+}
+
+// maxExamples caps few-shot examples per task type to keep prompts small
+const maxExamples = 20
+
+// exampleSettings returns the few-shot example count and strategy for a run
+func (h *GenerateHandler) exampleSettings(req GenerateTasksRequest) (int, string) {
+	count, strategy := h.cfg.ExampleCount, h.cfg.ExampleStrategy
+	if req.Examples != nil {
+		count = *req.Examples
+	}
+	if req.ExampleStrategy != nil {
+		strategy = *req.ExampleStrategy
+	}
+	return count, strategy
+}
+
+// completionOptions returns the AI options for a run, applying request overrides
+func completionOptions(ctx context.Context, req GenerateTasksRequest) []ai.CompletionOption {
+	opts := []ai.CompletionOption{
+		ai.WithContext(ctx),
+		ai.WithTimeout(aiRequestTimeout),
+		ai.WithTemperature(0.8),
+		ai.WithMaxTokens(4000), // Increased for larger batches
+	}
+	if req.Model != nil {
+		opts = append(opts, ai.WithModel(*req.Model))
+	}
+	if req.Temperature != nil {
+		opts = append(opts, ai.WithTemperature(*req.Temperature))
+	}
+	if req.MaxTokens != nil {
+		opts = append(opts, ai.WithMaxTokens(*req.MaxTokens))
+	}
+	return opts
+}
+
+// buildCombinations creates all parameter combinations based on the request,
+// skipping frozen languages
+func (h *GenerateHandler) buildCombinations(req GenerateTasksRequest, frozen map[string]bool) ([]generationParams, error) {
 	var combinations []generationParams
 
 	// Get categories
@@ -193,7 +438,11 @@ func (h *GenerateHandler) buildCombinations(req GenerateTasksRequest) ([]generat
 		}
 		languages = append(languages, *req.Language)
 	} else {
-		languages = models.SupportedLanguages
+		for _, lang := range models.SupportedLanguages {
+			if !frozen[lang] {
+				languages = append(languages, lang)
+			}
+		}
 	}
 
 	// Build combinations - filter by age group compatibility
@@ -212,11 +461,12 @@ func (h *GenerateHandler) buildCombinations(req GenerateTasksRequest) ([]generat
 
 			for _, lang := range languages {
 				combinations = append(combinations, generationParams{
-					CategoryID:   cat.ID,
-					CategoryName: cat.Label["en"],
-					AgeGroup:     ageGroup,
-					Language:     lang,
-					ExplicitMode: cat.RequiresConsent && ageGroup == models.AgeGroupAdults,
+					CategoryID:       cat.ID,
+					CategoryName:     cat.Label["en"],
+					CategoryAgeGroup: cat.AgeGroup,
+					AgeGroup:         ageGroup,
+					Language:         lang,
+					ExplicitMode:     cat.RequiresConsent && ageGroup == models.AgeGroupAdults,
 				})
 			}
 		}
@@ -225,12 +475,33 @@ func (h *GenerateHandler) buildCombinations(req GenerateTasksRequest) ([]generat
 	return combinations, nil
 }
 
-// generateForParams generates tasks for a single parameter set
-func (h *GenerateHandler) generateForParams(params generationParams, count int) (int, int, int, error) {
+// generateForParams generates tasks for a single parameter set and
+// reports the outcome. On error the report carries what was spent so far.
+// Texts in another language are rejected or flagged per the language
+// check, and tasks violating the glossary are created with a reviewer note.
+func (h *GenerateHandler) generateForParams(ctx context.Context, params generationParams, req GenerateTasksRequest, glossary models.Glossary, run *models.GenerationRun) (models.GenerationCombination, error) {
+	started := time.Now()
+	combination := models.GenerationCombination{
+		CategoryID:   params.CategoryID,
+		CategoryName: params.CategoryName,
+		AgeGroup:     params.AgeGroup,
+		Language:     params.Language,
+	}
+	var usage ai.Usage
+	fail := func(err error) (models.GenerationCombination, error) {
+		combination.Error = err.Error()
+		combination.TotalTokens = usage.TotalTokens
+		combination.DurationMs = time.Since(started).Milliseconds()
+		run.PromptTokens += usage.PromptTokens
+		run.CompletionTokens += usage.CompletionTokens
+		run.TotalTokens += usage.TotalTokens
+		return combination, err
+	}
+
 	// Load system prompt
 	systemPrompt, err := h.promptLoader.Load("generate_tasks_system")
 	if err != nil {
-		return 0, 0, 0, err
+		return fail(err)
 	}
 
 	// Load and prepare the user prompt
@@ -239,16 +510,25 @@ func (h *GenerateHandler) generateForParams(params generationParams, count int)
 		explicitStr = "true"
 	}
 
-	userPrompt, err := h.promptLoader.LoadAndReplace(
-		"generate_tasks",
+	// Existing tasks as few-shot examples for consistent tone
+	exampleCount, exampleStrategy := h.exampleSettings(req)
+	truthExamples, dareExamples, err := h.taskRepo.FindExampleTexts(params.CategoryID, params.Language, exampleCount, exampleStrategy)
+	if err != nil {
+		return fail(err)
+	}
+
+	placeholders := []prompts.Placeholder{
 		prompts.P("AGE_GROUP", params.AgeGroup),
 		prompts.P("CATEGORY", params.CategoryName),
 		prompts.P("LANGUAGE", params.Language),
-		prompts.P("COUNT", strconv.Itoa(count)),
+		prompts.P("COUNT", strconv.Itoa(req.Count)),
 		prompts.P("EXPLICIT_MODE", explicitStr),
-	)
+		prompts.P("EXAMPLES", prompts.FormatExamples(truthExamples, dareExamples)),
+		prompts.P("GLOSSARY", glossary.Format(params.Language)),
+	}
+	userPrompt, err := h.promptLoader.LoadAndReplace("generate_tasks", placeholders...)
 	if err != nil {
-		return 0, 0, 0, err
+		return fail(err)
 	}
 
 	// Call AI to generate content
@@ -258,45 +538,40 @@ func (h *GenerateHandler) generateForParams(params generationParams, count int)
 	}
 
 	var content GeneratedContent
-	err = h.aiClient.CompleteJSON(messages, &content,
-		ai.WithTemperature(0.8),
-		ai.WithMaxTokens(4000), // Increased for larger batches
-	)
+	opts := append(completionOptions(ctx, req), ai.WithPrompt("generate_tasks", placeholders...), ai.WithUsage(&usage))
+	err = h.aiClient.CompleteJSON(messages, &content, opts...)
 	if err != nil {
-		return 0, 0, 0, err
+		return fail(err)
 	}
+	combination.Truths = len(content.Truths)
+	combination.Dares = len(content.Dares)
 
-	// Save generated tasks to database
-	tasksCreated := 0
-
-	// Save truths
+	// Save generated tasks to database, truths first
+	tasks := make([]models.Task, 0, len(content.Truths)+len(content.Dares))
 	for _, truth := range content.Truths {
-		task := &models.Task{
-			CategoryID: params.CategoryID,
-			Type:       models.TaskTypeTruth,
-			Text:       truth,
-			Language:   params.Language,
-		}
-		task.ID = uuid.New().String()
-
-		if err := h.taskRepo.Create(task); err == nil {
-			tasksCreated++
-		}
+		tasks = append(tasks, h.newGeneratedTask(params, models.TaskTypeTruth, truth, run.ID))
 	}
-
-	// Save dares
 	for _, dare := range content.Dares {
-		task := &models.Task{
-			CategoryID: params.CategoryID,
-			Type:       models.TaskTypeDare,
-			Text:       dare,
-			Language:   params.Language,
+		tasks = append(tasks, h.newGeneratedTask(params, models.TaskTypeDare, dare, run.ID))
+	}
+	tasks, combination.LanguageMismatches = models.CheckLanguages(tasks, h.cfg.LanguageCheck)
+	flagged := make(map[string]bool)
+	for i := range tasks {
+		if glossary.Flag(&tasks[i], "", "") {
+			flagged[tasks[i].ID] = true
 		}
-		task.ID = uuid.New().String()
+	}
+	created, duplicates, rejected, err := h.taskRepo.CreateGenerated(tasks, h.cfg.TextRules.For(params.CategoryAgeGroup))
+	combination.Created = len(created)
+	combination.DuplicatesSkipped = duplicates
+	combination.Rejected = rejected
+	combination.GlossaryFlagged = countFlagged(created, flagged)
+	if err != nil {
+		return fail(err)
+	}
 
-		if err := h.taskRepo.Create(task); err == nil {
-			tasksCreated++
-		}
+	if req.WithHints {
+		h.addHints(ctx, params, created, &usage)
 	}
 
 	log.Info().
@@ -305,8 +580,83 @@ func (h *GenerateHandler) generateForParams(params generationParams, count int)
 		Str("language", params.Language).
 		Int("truths", len(content.Truths)).
 		Int("dares", len(content.Dares)).
-		Int("created", tasksCreated).
+		Int("created", len(created)).
+		Int("duplicates", duplicates).
+		Int("rejected", rejected).
 		Msg("Generated tasks for combination")
 
-	return len(content.Truths), len(content.Dares), tasksCreated, nil
+	combination.TotalTokens = usage.TotalTokens
+	combination.DurationMs = time.Since(started).Milliseconds()
+	run.PromptTokens += usage.PromptTokens
+	run.CompletionTokens += usage.CompletionTokens
+	run.TotalTokens += usage.TotalTokens
+	return combination, nil
+}
+
+// countFlagged counts the tasks whose IDs are flagged
+func countFlagged(tasks []models.Task, flagged map[string]bool) int {
+	count := 0
+	for _, task := range tasks {
+		if flagged[task.ID] {
+			count++
+		}
+	}
+	return count
+}
+
+// newGeneratedTask builds a staged, pending task from generated text
+func (h *GenerateHandler) newGeneratedTask(params generationParams, taskType, text, runID string) models.Task {
+	task := models.Task{
+		CategoryID:      params.CategoryID,
+		Type:            taskType,
+		Text:            text,
+		Language:        params.Language,
+		RolloutPercent:  h.cfg.InitialRollout(),
+		ReviewState:     models.ReviewStatePending,
+		GenerationRunID: runID,
+	}
+	task.ID = uuid.New().String()
+	task.MarkMachine(params.Language)
+	return task
+}
+
+// addHints generates hints for freshly created tasks and saves them.
+// Failures are logged; the tasks themselves are already stored.
+func (h *GenerateHandler) addHints(ctx context.Context, params generationParams, tasks []models.Task, usage *ai.Usage) {
+	hints, _, err := generateHints(ctx, h.aiClient, h.promptLoader, tasks, []string{params.Language}, ai.WithUsage(usage))
+	if err != nil {
+		log.Error().Err(err).
+			Str("category", params.CategoryName).
+			Str("language", params.Language).
+			Msg("Failed to generate hints for combination")
+		return
+	}
+
+	for i := range tasks {
+		hint, ok := hints[tasks[i].ID]
+		if !ok {
+			continue
+		}
+		tasks[i].Hint = hint
+		tasks[i].MarkMachine(params.Language)
+		if err := h.taskRepo.Update(&tasks[i]); err != nil {
+			log.Error().Err(err).Str("task_id", tasks[i].ID).Msg("Failed to save task hint")
+		}
+	}
+}
+
+// respondAIError writes the error response for a failed AI call.
+// An open circuit breaker is reported as 503 so clients know to retry later.
+func respondAIError(c *gin.Context, err error, message string) {
+	if errors.Is(err, ai.ErrCircuitOpen) {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "ai_unavailable",
+			Message: message + ": " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "ai_error",
+		Message: message + ": " + err.Error(),
+	})
 }
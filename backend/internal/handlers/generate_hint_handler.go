@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/prompts"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// GenerateHintHandler handles AI-based hint generation for existing tasks
+type GenerateHintHandler struct {
+	aiClient     *ai.Client
+	promptLoader *prompts.PromptLoader
+	taskRepo     *repository.TaskRepository
+	languageRepo *repository.LanguageRepository
+}
+
+// NewGenerateHintHandler creates a new handler instance
+func NewGenerateHintHandler(taskRepo *repository.TaskRepository, languageRepo *repository.LanguageRepository) *GenerateHintHandler {
+	return &GenerateHintHandler{
+		aiClient:     ai.GetClient(),
+		promptLoader: prompts.GetLoader(),
+		taskRepo:     taskRepo,
+		languageRepo: languageRepo,
+	}
+}
+
+// GenerateHintRequest represents the request body
+type GenerateHintRequest struct {
+	// Languages is an optional list of language codes to write the hint in
+	// If empty, the task's own language is used
+	Languages []string `json:"languages,omitempty"`
+}
+
+// GenerateHint godoc
+// @Summary Generate a hint for a task using AI
+// @Description Write a short multilingual hint for a task and save it on the task. Frozen languages are a conflict.
+// @Tags generate
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body GenerateHintRequest false "Optional hint languages"
+// @Success 200 {object} models.TaskResponse
+// @Header 200 {string} X-Cache "AI cache status (hit or miss)"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /tasks/{id}/generate-hint [post]
+func (h *GenerateHintHandler) GenerateHint(c *gin.Context) {
+	id := c.Param("id")
+
+	task, err := h.taskRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	// The body is optional; an empty body means "task language only"
+	var req GenerateHintRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	languages := req.Languages
+	if len(languages) == 0 {
+		languages = []string{task.Language}
+	}
+
+	for _, lang := range languages {
+		if !models.IsValidLanguage(lang) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "Invalid language code: " + lang,
+			})
+			return
+		}
+	}
+
+	if !checkNotFrozen(c, h.languageRepo, languages...) {
+		return
+	}
+
+	// Check if AI is configured
+	if !h.aiClient.IsConfigured() {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "configuration_error",
+			Message: "AI service is not configured. Please set GROQ_API_KEY.",
+		})
+		return
+	}
+
+	hints, cacheStatus, err := generateHints(c.Request.Context(), h.aiClient, h.promptLoader, []models.Task{*task}, languages)
+	if err != nil {
+		respondAIError(c, err, "Failed to generate hint")
+		return
+	}
+
+	hint, ok := hints[task.ID]
+	if !ok {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "ai_error",
+			Message: "AI response did not include a hint for this task",
+		})
+		return
+	}
+
+	task.Hint = hint
+	task.MarkMachine(languages...)
+	if err := h.taskRepo.Update(task); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to save hint",
+		})
+		return
+	}
+
+	c.Header("X-Cache", cacheStatus)
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
+
+// hintPromptTask is the task shape sent to the model in the hints prompt
+type hintPromptTask struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// generatedHints represents the AI response structure for hint generation
+type generatedHints struct {
+	Hints []struct {
+		ID   string                  `json:"id"`
+		Hint models.MultilingualText `json:"hint"`
+	} `json:"hints"`
+}
+
+// generateHints asks the model for a hint per task in a single request and
+// returns the hints keyed by task ID along with the AI cache status.
+// Tasks the model skipped are absent. extra options are applied after the
+// defaults.
+func generateHints(ctx context.Context, aiClient *ai.Client, loader *prompts.PromptLoader, tasks []models.Task, languages []string, extra ...ai.CompletionOption) (map[string]models.MultilingualText, string, error) {
+	if len(tasks) == 0 {
+		return map[string]models.MultilingualText{}, ai.CacheMiss, nil
+	}
+
+	systemPrompt, err := loader.Load("generate_hints_system")
+	if err != nil {
+		return nil, ai.CacheMiss, err
+	}
+
+	promptTasks := make([]hintPromptTask, len(tasks))
+	for i, task := range tasks {
+		promptTasks[i] = hintPromptTask{ID: task.ID, Type: task.Type, Text: task.Text}
+	}
+	tasksJSON, err := json.Marshal(promptTasks)
+	if err != nil {
+		return nil, ai.CacheMiss, fmt.Errorf("failed to encode tasks for prompt: %w", err)
+	}
+
+	placeholders := []prompts.Placeholder{
+		prompts.P("LANGUAGES", strings.Join(languages, ", ")),
+		prompts.P("TASKS", string(tasksJSON)),
+	}
+	userPrompt, err := loader.LoadAndReplace("generate_hints", placeholders...)
+	if err != nil {
+		return nil, ai.CacheMiss, err
+	}
+
+	messages := []ai.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	}
+
+	var content generatedHints
+	opts := append([]ai.CompletionOption{
+		ai.WithContext(ctx),
+		ai.WithTimeout(aiRequestTimeout),
+		ai.WithPrompt("generate_hints", placeholders...),
+		ai.WithTemperature(0.5),
+		ai.WithMaxTokens(4000),
+	}, extra...)
+	cacheStatus, err := aiClient.CompleteJSONCached(prompts.Key("generate_hints", placeholders...), messages, &content, opts...)
+	if err != nil {
+		return nil, cacheStatus, err
+	}
+
+	hints := make(map[string]models.MultilingualText, len(content.Hints))
+	for _, item := range content.Hints {
+		if len(item.Hint) == 0 {
+			continue
+		}
+		hints[item.ID] = item.Hint
+	}
+
+	if len(hints) < len(tasks) {
+		log.Warn().
+			Int("requested", len(tasks)).
+			Int("received", len(hints)).
+			Msg("AI returned hints for fewer tasks than requested")
+	}
+
+	return hints, cacheStatus, nil
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// GenerateQuotaResponse is the generate endpoint usage of the calling admin
+// key today. Limits of 0 are disabled.
+type GenerateQuotaResponse struct {
+	Runs           int64     `json:"runs"`
+	RunLimit       int       `json:"run_limit"`
+	Requested      int64     `json:"requested"`
+	RequestedLimit int       `json:"requested_limit"`
+	ResetsAt       time.Time `json:"resets_at"`
+}
+
+// quotaDay returns the start of the current UTC day and of the next
+func quotaDay(now time.Time) (start, next time.Time) {
+	start = now.UTC().Truncate(24 * time.Hour)
+	return start, start.Add(24 * time.Hour)
+}
+
+// usage loads the quota usage of the calling admin key
+func (h *GenerateHandler) usage(c *gin.Context) (GenerateQuotaResponse, error) {
+	start, next := quotaDay(time.Now())
+	runs, requested, err := h.runRepo.ManualUsageSince(middleware.AdminKeyID(c), start)
+	return GenerateQuotaResponse{
+		Runs:           runs,
+		RunLimit:       h.cfg.DailyRunQuota,
+		Requested:      requested,
+		RequestedLimit: h.cfg.DailyCountQuota,
+		ResetsAt:       next,
+	}, err
+}
+
+// checkQuota reports whether the calling admin key may start a run asking
+// for requested tasks, responding when it may not
+func (h *GenerateHandler) checkQuota(c *gin.Context, requested int) bool {
+	if h.cfg.DailyRunQuota <= 0 && h.cfg.DailyCountQuota <= 0 {
+		return true
+	}
+
+	usage, err := h.usage(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check generation quota",
+		})
+		return false
+	}
+
+	var message string
+	switch {
+	case usage.RunLimit > 0 && usage.Runs >= int64(usage.RunLimit):
+		message = fmt.Sprintf("Daily generation quota reached: %d of %d runs started today", usage.Runs, usage.RunLimit)
+	case usage.RequestedLimit > 0 && usage.Requested+int64(requested) > int64(usage.RequestedLimit):
+		message = fmt.Sprintf("Daily generation quota exceeded: %d tasks requested today, this run asks for %d more, the limit is %d",
+			usage.Requested, requested, usage.RequestedLimit)
+	default:
+		return true
+	}
+
+	log.Warn().
+		Str("admin_key", middleware.AdminKeyID(c)).
+		Int64("runs", usage.Runs).
+		Int64("requested", usage.Requested).
+		Int("asked", requested).
+		Msg("Generation quota exceeded")
+
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(usage.ResetsAt).Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Error:   "quota_exceeded",
+		Message: message,
+	})
+	return false
+}
+
+// Quota godoc
+// @Summary Get the generation quota
+// @Description Report how many generation runs the calling admin key started today (UTC) and how many tasks they requested, against GENERATE_DAILY_RUN_QUOTA and GENERATE_DAILY_COUNT_QUOTA
+// @Tags generate
+// @Produce json
+// @Success 200 {object} GenerateQuotaResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /generate/quota [get]
+func (h *GenerateHandler) Quota(c *gin.Context) {
+	usage, err := h.usage(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch generation quota",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// GlossaryHandler manages the glossary AI prompts and output are held to
+type GlossaryHandler struct {
+	repo *repository.GlossaryRepository
+}
+
+// NewGlossaryHandler creates a new GlossaryHandler
+func NewGlossaryHandler(repo *repository.GlossaryRepository) *GlossaryHandler {
+	return &GlossaryHandler{repo: repo}
+}
+
+// GlossaryTermRequest represents the request body for creating or updating a glossary term
+type GlossaryTermRequest struct {
+	Term         string                  `json:"term" binding:"required"`
+	Translations models.MultilingualText `json:"translations"`
+	Note         string                  `json:"note"`
+}
+
+// List godoc
+// @Summary List glossary terms
+// @Description Get every glossary term with its approved translations, alphabetically
+// @Tags glossary
+// @Produce json
+// @Success 200 {object} map[string][]models.GlossaryTerm
+// @Failure 500 {object} models.ErrorResponse
+// @Router /glossary [get]
+func (h *GlossaryHandler) List(c *gin.Context) {
+	terms, err := h.repo.FindAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch glossary",
+		})
+		return
+	}
+	if terms == nil {
+		terms = models.Glossary{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": terms})
+}
+
+// Create godoc
+// @Summary Create glossary term
+// @Description Add a term with its approved translation per language. Languages without a translation keep the term as is. Terms are unique, ignoring case.
+// @Tags glossary
+// @Accept json
+// @Produce json
+// @Param request body GlossaryTermRequest true "Glossary term"
+// @Success 201 {object} models.GlossaryTerm
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /glossary [post]
+func (h *GlossaryHandler) Create(c *gin.Context) {
+	term := &models.GlossaryTerm{}
+	if !h.bind(c, term) {
+		return
+	}
+
+	if err := h.repo.Create(term); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create glossary term",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, term)
+}
+
+// Update godoc
+// @Summary Update glossary term
+// @Description Replace a glossary term, its translations and note
+// @Tags glossary
+// @Accept json
+// @Produce json
+// @Param id path string true "Glossary term ID"
+// @Param request body GlossaryTermRequest true "Glossary term"
+// @Success 200 {object} models.GlossaryTerm
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /glossary/{id} [put]
+func (h *GlossaryHandler) Update(c *gin.Context) {
+	term, err := h.repo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Glossary term not found",
+		})
+		return
+	}
+	if !h.bind(c, term) {
+		return
+	}
+
+	if err := h.repo.Update(term); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update glossary term",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, term)
+}
+
+// Delete godoc
+// @Summary Delete glossary term
+// @Description Remove a term from the glossary
+// @Tags glossary
+// @Produce json
+// @Param id path string true "Glossary term ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /glossary/{id} [delete]
+func (h *GlossaryHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.repo.FindByID(id); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Glossary term not found",
+		})
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to delete glossary term",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Glossary term deleted successfully",
+	})
+}
+
+// bind reads the request into term and validates it, responding with the
+// error when it fails
+func (h *GlossaryHandler) bind(c *gin.Context, term *models.GlossaryTerm) bool {
+	var req GlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	term.Term = strings.TrimSpace(req.Term)
+	term.Translations = req.Translations
+	term.Note = req.Note
+	if errs := term.Validate(); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return false
+	}
+
+	exists, err := h.repo.ExistsTerm(term.Term, term.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check glossary term",
+		})
+		return false
+	}
+	if exists {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Glossary term already exists: " + term.Term,
+		})
+		return false
+	}
+	return true
+}
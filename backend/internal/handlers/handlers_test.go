@@ -1,28 +1,60 @@
 package handlers_test
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"image/png"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/game"
 	"github.com/truthordare/backend/internal/handlers"
+	"github.com/truthordare/backend/internal/integrations"
+	"github.com/truthordare/backend/internal/middleware"
 	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
 	"github.com/truthordare/backend/internal/repository"
+	"github.com/truthordare/backend/internal/scheduler"
+	"github.com/truthordare/backend/internal/signedurl"
+	"github.com/truthordare/backend/internal/storage"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// TestMain runs the handler tests against the mock AI provider, so the
+// generate endpoints work without an API key or network access.
+func TestMain(m *testing.M) {
+	os.Setenv("AI_PROVIDER", "mock")
+	os.Exit(m.Run())
+}
+
 // setupTestDB creates an in-memory SQLite database for testing
-func setupTestDB(t *testing.T) *gorm.DB {
+func setupTestDB(t testing.TB) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err, "failed to open test database")
 
-	err = db.AutoMigrate(&models.Category{}, &models.Task{})
+	err = db.AutoMigrate(&models.Category{}, &models.Task{}, &models.TaskReport{}, &models.TelemetryRollup{}, &models.PrivacyAudit{}, &models.ConsentRecord{}, &models.GenerationRun{}, &models.GlossaryTerm{}, &models.CategoryRank{}, &models.LanguageFreeze{})
 	require.NoError(t, err, "failed to migrate test database")
 
 	return db
@@ -35,7 +67,7 @@ func setupTestRouter() *gin.Engine {
 }
 
 // seedTestCategory creates a test category in the database
-func seedTestCategory(t *testing.T, db *gorm.DB) *models.Category {
+func seedTestCategory(t testing.TB, db *gorm.DB) *models.Category {
 	category := &models.Category{
 		Label: models.MultilingualText{
 			"en": "Test Category",
@@ -53,7 +85,7 @@ func seedTestCategory(t *testing.T, db *gorm.DB) *models.Category {
 }
 
 // seedTestTask creates a test task in the database
-func seedTestTask(t *testing.T, db *gorm.DB, categoryID string, taskType string) *models.Task {
+func seedTestTask(t testing.TB, db *gorm.DB, categoryID string, taskType string) *models.Task {
 	task := &models.Task{
 		Text:       "Test task text",
 		Language:   "en",
@@ -117,6 +149,69 @@ func TestCategoryHandler_List(t *testing.T) {
 		assert.Equal(t, 1, len(response.Data))
 		assert.Equal(t, "🧪", response.Data[0].Emoji)
 	})
+
+	t.Run("sparse fields and label languages", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/categories?age_groups=kids&fields=label,emoji&text_languages=hi", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []map[string]json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Len(t, response.Data[0], 3)
+		assert.Contains(t, response.Data[0], "id")
+
+		var label models.MultilingualText
+		require.NoError(t, json.Unmarshal(response.Data[0]["label"], &label))
+		assert.Equal(t, models.MultilingualText{"hi": "परीक्षण श्रेणी"}, label)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/categories?fields=label,secret", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_fields")
+	})
+
+	t.Run("smart order", func(t *testing.T) {
+		require.NoError(t, db.Create(&models.CategoryRank{Language: "en", CategoryID: category2.ID, Plays: 10}).Error)
+
+		order := func(query string) (int, []string) {
+			req, _ := http.NewRequest("GET", "/categories"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var response struct {
+				Data []models.CategoryResponse `json:"data"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			labels := make([]string, len(response.Data))
+			for i, category := range response.Data {
+				labels[i] = category.Label["en"]
+			}
+			return w.Code, labels
+		}
+
+		code, labels := order("?order=smart&language=en")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"Teen Category", "Test Category"}, labels)
+
+		_, labels = order("?order=smart&language=hi")
+		assert.Equal(t, []string{"Test Category", "Teen Category"}, labels, "languages without plays keep sort_order")
+		_, labels = order("")
+		assert.Equal(t, []string{"Test Category", "Teen Category"}, labels, "sort_order stays the default")
+
+		code, _ = order("?order=popular")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = order("?order=smart&language=xx")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
 }
 
 func TestCategoryHandler_GetByID(t *testing.T) {
@@ -200,6 +295,88 @@ func TestCategoryHandler_Create(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("emoji is validated and defaulted by age group", func(t *testing.T) {
+		post := func(body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("POST", "/categories", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := post(`{"age_group": "kids", "label": {"en": "Bad"}, "emoji": "hi"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var errResp models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, []models.FieldError{{Field: "emoji", Message: "must be a single emoji"}}, errResp.Fields)
+
+		w = post(`{"age_group": "kids", "label": {"en": "Heart"}, "emoji": "❤︎"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var response models.CategoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "❤️", response.Emoji)
+
+		w = post(`{"age_group": "kids", "label": {"en": "No emoji"}}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.DefaultEmojiForAgeGroup(models.AgeGroupKids), response.Emoji)
+	})
+
+	t.Run("create category with a taken label", func(t *testing.T) {
+		seedTestCategory(t, db)
+		body := `{"age_group": "kids", "label": {"en": "TEST category"}}`
+
+		req, _ := http.NewRequest("POST", "/categories", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("create category with invalid label", func(t *testing.T) {
+		body := `{"age_group": "kids", "label": {"en": "Fine", "xx": "Unknown", "hi": "  "}}`
+
+		req, _ := http.NewRequest("POST", "/categories", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []models.FieldError{
+			{Field: "label.hi", Message: "must not be empty"},
+			{Field: "label.xx", Message: "unsupported language code"},
+		}, response.Fields)
+	})
+
+	t.Run("create category with instructions", func(t *testing.T) {
+		body := `{"age_group": "kids", "label": {"en": "Read Aloud"}, "instructions": {"en": "One player reads aloud", "es": "Un jugador lee en voz alta"}}`
+
+		req, _ := http.NewRequest("POST", "/categories", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var response models.CategoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "One player reads aloud", response.Instructions["en"])
+
+		saved, err := categoryRepo.FindByID(response.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Un jugador lee en voz alta", saved.Instructions["es"])
+
+		body = `{"age_group": "kids", "label": {"en": "Bad Instructions"}, "instructions": {"xx": "Unknown"}}`
+		req, _ = http.NewRequest("POST", "/categories", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
 
 func TestCategoryHandler_Update(t *testing.T) {
@@ -248,6 +425,31 @@ func TestCategoryHandler_Update(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
+
+	t.Run("label used by another category", func(t *testing.T) {
+		other := &models.Category{Label: models.MultilingualText{"en": "Funny", "hi": "मज़ेदार"}, AgeGroup: models.AgeGroupTeen}
+		require.NoError(t, db.Create(other).Error)
+
+		put := func(id, body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("PUT", "/categories/"+id, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := put(category.ID, `{"age_group": "kids", "label": {"en": "  funny ", "hi": "नया"}}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "label_conflict", response.Error)
+		assert.Equal(t, []models.FieldError{{Field: "label.en", Message: "already used by category " + other.ID}}, response.Fields)
+
+		// A category keeps its own label
+		w = put(other.ID, `{"age_group": "teen", "label": {"en": "FUNNY"}}`)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
 }
 
 func TestCategoryHandler_Count(t *testing.T) {
@@ -311,7 +513,7 @@ func TestTaskHandler_List(t *testing.T) {
 
 	categoryRepo := repository.NewCategoryRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
-	handler := handlers.NewTaskHandler(taskRepo, categoryRepo)
+	handler := handlers.NewTaskHandler(taskRepo, categoryRepo, nil, nil)
 
 	router.GET("/tasks", handler.List)
 
@@ -361,6 +563,168 @@ func TestTaskHandler_List(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 2, len(response.Data))
 	})
+
+	t.Run("filter by age", func(t *testing.T) {
+		// The seeded category is for kids (0-12)
+		counts := map[string]int{
+			"age_group=kids":                 2,
+			"age_group=adults":               0,
+			"age_groups=teen,kids":           2,
+			"max_age=10":                     2,
+			"min_age=15":                     0,
+			"age_group=adults&min_age=12":    2,
+			"age_group=kids&age_groups=teen": 0,
+		}
+		for query, want := range counts {
+			req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, query)
+			var response struct {
+				Total int64 `json:"total"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.EqualValues(t, want, response.Total, query)
+		}
+
+		for _, query := range []string{"age_group=toddlers", "min_age=-1", "min_age=18&max_age=12"} {
+			req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("sparse fields", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/tasks?fields=type,text&limit=1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data  []map[string]json.RawMessage `json:"data"`
+			Total int64                        `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(2), response.Total)
+		require.Len(t, response.Data, 1)
+		assert.Len(t, response.Data[0], 3)
+		for _, field := range []string{"id", "type", "text"} {
+			assert.Contains(t, response.Data[0], field)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/tasks?fields=nope", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("inactive tasks are for admins only", func(t *testing.T) {
+		t.Setenv("ADMIN_OTP_KEY", "test-otp-key")
+		inactive := seedTestTask(t, db, category.ID, models.TaskTypeDare)
+		require.NoError(t, db.Model(inactive).Update("is_active", false).Error)
+
+		total := func(query string, admin bool) (int, int64) {
+			req, _ := http.NewRequest("GET", "/tasks"+query, nil)
+			if admin {
+				req.Header.Set(middleware.AuthHeader, "test-otp-key")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var response struct {
+				Total int64 `json:"total"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			return w.Code, response.Total
+		}
+
+		code, count := total("", false)
+		assert.Equal(t, http.StatusOK, code)
+		assert.EqualValues(t, 2, count, "public callers only see active tasks")
+
+		code, _ = total("?active=false", false)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		_, count = total("", true)
+		assert.EqualValues(t, 3, count, "admins see every state")
+		_, count = total("?active=false", true)
+		assert.EqualValues(t, 1, count)
+		code, _ = total("?active=maybe", true)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("plain text", func(t *testing.T) {
+		task := &models.Task{
+			CategoryID: category.ID,
+			Type:       models.TaskTypeDare,
+			Text:       "🎤 **Sing** your _favourite_ song! 🇫🇷",
+			Language:   "fr",
+			Hint:       models.MultilingualText{"fr": "- Chante fort 👨\u200D👩\u200D👧"},
+			IsActive:   true,
+		}
+		require.NoError(t, db.Create(task).Error)
+
+		get := func(query string) models.TaskResponse {
+			req, _ := http.NewRequest("GET", "/tasks?language=fr"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Data []models.TaskResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.Len(t, response.Data, 1)
+			return response.Data[0]
+		}
+
+		plain := get("&plain=true")
+		assert.Equal(t, "Sing your favourite song!", plain.Text)
+		assert.Equal(t, models.MultilingualText{"fr": "Chante fort"}, plain.Hint)
+
+		assert.Equal(t, task.Text, get("").Text, "formatting is kept by default")
+	})
+}
+
+func FuzzTaskHandler_ListQuery(f *testing.F) {
+	db := setupTestDB(f)
+	router := setupTestRouter()
+
+	category := seedTestCategory(f, db)
+	seedTestTask(f, db, category.ID, models.TaskTypeTruth)
+	seedTestTask(f, db, category.ID, models.TaskTypeDare)
+
+	handler := handlers.NewTaskHandler(repository.NewTaskRepository(db), repository.NewCategoryRepository(db), nil, nil)
+	router.GET("/tasks", handler.List)
+
+	f.Add("10", "0", "2024-01-01T00:00:00Z", "en,hi", "created_at")
+	f.Add("-5", "-1", "not-a-date", ",,", "DROP TABLE tasks")
+	f.Add("99999999999999999999", "3", "2024-13-45T99:99:99Z", " en ", "type")
+	f.Add("", "7", "", "", "")
+
+	f.Fuzz(func(t *testing.T, limit, offset, fromDate, languages, sortBy string) {
+		query := url.Values{}
+		query.Set("limit", limit)
+		query.Set("offset", offset)
+		query.Set("from_date", fromDate)
+		query.Set("languages", languages)
+		query.Set("sort_by", sortBy)
+
+		req, _ := http.NewRequest("GET", "/tasks?"+query.Encode(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Malformed parameters are ignored, never surfaced as server errors
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d for %s: %s", w.Code, query.Encode(), w.Body.String())
+		}
+	})
 }
 
 func TestTaskHandler_Create(t *testing.T) {
@@ -371,7 +735,9 @@ func TestTaskHandler_Create(t *testing.T) {
 
 	categoryRepo := repository.NewCategoryRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
-	handler := handlers.NewTaskHandler(taskRepo, categoryRepo)
+	handler := handlers.NewTaskHandler(taskRepo, categoryRepo, nil, models.AgeGroupTextRules{
+		models.AgeGroupKids: {MaxLength: 100, MaxSentences: 2},
+	})
 
 	router.POST("/tasks", handler.Create)
 
@@ -434,6 +800,69 @@ func TestTaskHandler_Create(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("create task with invalid text and language", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"text":        strings.Repeat("a", models.MaxTaskTextLength+1),
+			"language":    "xx",
+			"type":        "truth",
+			"category_id": category.ID,
+		}
+		body, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Fields, 2)
+		assert.Equal(t, "text", response.Fields[0].Field)
+		assert.Equal(t, "language", response.Fields[1].Field)
+	})
+
+	t.Run("create task breaking the category's text rules", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"text":        "Tell us about your pet. What is its name? Why did you choose it?",
+			"language":    "en",
+			"type":        "truth",
+			"category_id": category.ID,
+		}
+		body, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []models.FieldError{{Field: "text", Message: "must be at most 2 sentences"}}, response.Fields)
+	})
+
+	t.Run("batch reports the failing task", func(t *testing.T) {
+		router.POST("/tasks/batch", handler.CreateBatch)
+		body := `{"tasks": [
+			{"text": "Fine", "language": "en", "type": "dare", "category_id": "` + category.ID + `"},
+			{"text": "   ", "language": "en", "type": "dare", "category_id": "` + category.ID + `"}
+		]}`
+
+		req, _ := http.NewRequest("POST", "/tasks/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []models.FieldError{{Field: "tasks[1].text", Message: "must not be empty"}}, response.Fields)
+	})
 }
 
 func TestTaskHandler_GetRandom(t *testing.T) {
@@ -443,11 +872,12 @@ func TestTaskHandler_GetRandom(t *testing.T) {
 	category := seedTestCategory(t, db)
 	seedTestTask(t, db, category.ID, models.TaskTypeTruth)
 	seedTestTask(t, db, category.ID, models.TaskTypeTruth)
-	seedTestTask(t, db, category.ID, models.TaskTypeDare)
+	dare := seedTestTask(t, db, category.ID, models.TaskTypeDare)
 
 	categoryRepo := repository.NewCategoryRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
-	handler := handlers.NewTaskHandler(taskRepo, categoryRepo)
+	served := repository.NewServeRecorder(db)
+	handler := handlers.NewTaskHandler(taskRepo, categoryRepo, served, nil)
 
 	router.GET("/tasks/random", handler.GetRandom)
 
@@ -476,6 +906,24 @@ func TestTaskHandler_GetRandom(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "truth", response.Type)
 	})
+
+	t.Run("served tasks are counted", func(t *testing.T) {
+		// Earlier subtests may already have drawn the dare
+		require.NoError(t, served.Flush())
+		before, err := taskRepo.FindByID(dare.ID)
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("GET", "/tasks/random?type=dare", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, served.Flush())
+
+		after, err := taskRepo.FindByID(dare.ID)
+		require.NoError(t, err)
+		assert.Equal(t, before.TimesServed+1, after.TimesServed)
+		assert.NotNil(t, after.LastServedAt)
+	})
 }
 
 func TestTaskHandler_Count(t *testing.T) {
@@ -498,7 +946,7 @@ func TestTaskHandler_Count(t *testing.T) {
 
 	categoryRepo := repository.NewCategoryRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
-	handler := handlers.NewTaskHandler(taskRepo, categoryRepo)
+	handler := handlers.NewTaskHandler(taskRepo, categoryRepo, nil, nil)
 
 	router.GET("/tasks/count", handler.Count)
 
@@ -528,3 +976,2844 @@ func TestTaskHandler_Count(t *testing.T) {
 		assert.Equal(t, int64(3), response["count"])
 	})
 }
+
+func TestGenerateHintHandler_GenerateHint(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+
+	taskRepo := repository.NewTaskRepository(db)
+	handler := handlers.NewGenerateHintHandler(taskRepo, repository.NewLanguageRepository(db))
+
+	router.POST("/tasks/:id/generate-hint", handler.GenerateHint)
+
+	t.Run("unknown task", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/tasks/does-not-exist/generate-hint", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("generates and saves hint", func(t *testing.T) {
+		body, _ := json.Marshal(handlers.GenerateHintRequest{Languages: []string{"en", "hi"}})
+		req, _ := http.NewRequest("POST", "/tasks/"+task.ID+"/generate-hint", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.TaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.Hint["en"])
+		assert.NotEmpty(t, response.Hint["hi"])
+
+		saved, err := taskRepo.FindByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, response.Hint, saved.Hint)
+	})
+
+	t.Run("invalid language", func(t *testing.T) {
+		body, _ := json.Marshal(handlers.GenerateHintRequest{Languages: []string{"xx"}})
+		req, _ := http.NewRequest("POST", "/tasks/"+task.ID+"/generate-hint", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCloneHandler_Clone(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	target := &models.Category{Label: models.MultilingualText{"en": "Target"}, AgeGroup: models.AgeGroupTeen}
+	require.NoError(t, db.Create(target).Error)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeDare)
+
+	taskRepo := repository.NewTaskRepository(db)
+	handler := handlers.NewCloneHandler(taskRepo, repository.NewCategoryRepository(db), repository.NewGlossaryRepository(db), repository.NewLanguageRepository(db), &config.GenerationConfig{RolloutPercent: 25})
+	router.POST("/tasks/:id/clone", handler.Clone)
+
+	clone := func(id string, req handlers.CloneTaskRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r, _ := http.NewRequest("POST", "/tasks/"+id+"/clone", bytes.NewBuffer(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("clone to category copies the text", func(t *testing.T) {
+		w := clone(task.ID, handlers.CloneTaskRequest{CategoryID: target.ID})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response handlers.CloneTaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.NotEqual(t, task.ID, response.Data[0].ID)
+		assert.Equal(t, target.ID, response.Data[0].CategoryID)
+		assert.Equal(t, task.Text, response.Data[0].Text)
+		assert.Equal(t, "en", response.Data[0].Language)
+		assert.Equal(t, models.FullRollout, response.Data[0].RolloutPercent)
+		assert.Empty(t, response.Data[0].ReviewState)
+	})
+
+	t.Run("clone to languages translates missing texts", func(t *testing.T) {
+		w := clone(task.ID, handlers.CloneTaskRequest{
+			Languages: []string{"hi", "es", "hi"},
+			Texts:     models.MultilingualText{"es": "Texto de prueba"},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response handlers.CloneTaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 2)
+
+		hi, es := response.Data[0], response.Data[1]
+		assert.Equal(t, "hi", hi.Language)
+		assert.Equal(t, "[mock hi] "+task.Text, hi.Text)
+		assert.Equal(t, models.ReviewStatePending, hi.ReviewState)
+		assert.Equal(t, 25, hi.RolloutPercent)
+		assert.Equal(t, category.ID, hi.CategoryID)
+		assert.Equal(t, []string{"hi"}, hi.MachineLanguages)
+
+		assert.Equal(t, "es", es.Language)
+		assert.Equal(t, "Texto de prueba", es.Text)
+		assert.Empty(t, es.ReviewState)
+		assert.Empty(t, es.MachineLanguages)
+	})
+
+	t.Run("translations breaking the glossary are flagged", func(t *testing.T) {
+		term := &models.GlossaryTerm{Term: "task", Translations: models.MultilingualText{"fr": "défi"}}
+		require.NoError(t, db.Create(term).Error)
+		defer db.Unscoped().Delete(term)
+
+		w := clone(task.ID, handlers.CloneTaskRequest{Languages: []string{"fr"}})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response handlers.CloneTaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, `Glossary: "task" should be "défi"`, response.Data[0].ReviewerNotes)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for name, req := range map[string]handlers.CloneTaskRequest{
+			"no target":        {},
+			"same target":      {Languages: []string{"en"}},
+			"unknown category": {CategoryID: "missing"},
+			"bad language":     {Languages: []string{"xx"}},
+			"blank text":       {Languages: []string{"fr"}, Texts: models.MultilingualText{"fr": " "}},
+		} {
+			w := clone(task.ID, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+
+		assert.Equal(t, http.StatusNotFound, clone("missing", handlers.CloneTaskRequest{CategoryID: target.ID}).Code)
+	})
+}
+
+func TestGenerateHandler_Overrides(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+	handler := handlers.NewGenerateHandler(taskRepo, categoryRepo, repository.NewGenerationRunRepository(db), repository.NewGlossaryRepository(db), repository.NewLanguageRepository(db), &config.GenerationConfig{ExampleCount: 5, ExampleStrategy: repository.ExampleStrategyRandom})
+
+	router.POST("/generate", handler.Generate)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "model not allowed", body: `{"model": "not-a-real-model"}`},
+		{name: "temperature too high", body: `{"temperature": 2.5}`},
+		{name: "negative temperature", body: `{"temperature": -0.1}`},
+		{name: "max tokens too large", body: `{"max_tokens": 100000}`},
+		{name: "zero max tokens", body: `{"max_tokens": 0}`},
+		{name: "too many examples", body: `{"examples": 100}`},
+		{name: "unknown example strategy", body: `{"example_strategy": "best"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/generate", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "validation_error", response.Error)
+		})
+	}
+}
+
+func TestGenerateHandler_Generate(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+	handler := handlers.NewGenerateHandler(taskRepo, categoryRepo, repository.NewGenerationRunRepository(db), repository.NewGlossaryRepository(db), repository.NewLanguageRepository(db), &config.GenerationConfig{ExampleCount: 5, ExampleStrategy: repository.ExampleStrategyRandom})
+
+	router.POST("/generate", handler.Generate)
+	router.GET("/generate/jobs", handler.ListJobs)
+	router.GET("/generate/jobs/:id/report", handler.Report)
+
+	generate := func() handlers.GenerateTasksResponse {
+		body := `{"category_id": "` + category.ID + `", "age_group": "kids", "language": "en", "count": 3, "with_hints": true}`
+		req, _ := http.NewRequest("POST", "/generate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response handlers.GenerateTasksResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := generate()
+	assert.Equal(t, 1, response.CombinationsCount)
+	assert.Equal(t, 6, response.TasksCreated)
+	require.NotEmpty(t, response.RunID)
+	require.NotNil(t, response.Report)
+	assert.Equal(t, models.GenerationRunCompleted, response.Report.Status)
+	require.Len(t, response.Report.Combinations, 1)
+	assert.Equal(t, 6, response.Report.Combinations[0].Created)
+	assert.Positive(t, response.Report.TotalTokens)
+
+	tasks, total, err := taskRepo.FindAll(&repository.TaskFilter{CategoryID: category.ID})
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, total)
+	for _, task := range tasks {
+		assert.NotEmpty(t, task.Hint["en"], "task %s has no hint", task.ID)
+		assert.Equal(t, models.ReviewStatePending, task.ReviewState, "generated tasks are queued for review")
+		assert.Equal(t, response.RunID, task.GenerationRunID)
+	}
+
+	t.Run("repeated texts are skipped as duplicates", func(t *testing.T) {
+		again := generate()
+		assert.Equal(t, 0, again.TasksCreated)
+		assert.Equal(t, 6, again.Report.DuplicatesSkipped)
+		assert.NotEqual(t, response.RunID, again.RunID)
+	})
+
+	t.Run("report includes review outcome", func(t *testing.T) {
+		require.NoError(t, taskRepo.Review(tasks[0].ID, models.ReviewStateRejected, "", false))
+
+		req, _ := http.NewRequest("GET", "/generate/jobs/"+response.RunID+"/report", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var report handlers.GenerationReportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, models.GenerationTriggerManual, report.Trigger)
+		assert.Equal(t, 6, report.TasksCreated)
+		assert.Equal(t, repository.GenerationReviewCounts{Pending: 5, Rejected: 1, Active: 5}, report.Review)
+
+		req, _ = http.NewRequest("GET", "/generate/jobs/missing/report", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("lists runs newest first", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/generate/jobs", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var runs []models.GenerationRun
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &runs))
+		require.Len(t, runs, 2)
+		assert.Equal(t, 6, runs[1].TasksCreated)
+	})
+}
+
+func TestGenerateHandler_Quota(t *testing.T) {
+	// Two admins with keys of their own, each with a budget of their own
+	t.Setenv("ADMIN_OTP_KEY", "")
+	t.Setenv("ADMIN_OTP_KEYS", "key-a,key-b")
+	db := setupTestDB(t)
+	router := setupTestRouter()
+	router.Use(middleware.AuthMiddleware())
+
+	category := seedTestCategory(t, db)
+	handler := handlers.NewGenerateHandler(repository.NewTaskRepository(db), repository.NewCategoryRepository(db), repository.NewGenerationRunRepository(db), repository.NewGlossaryRepository(db), repository.NewLanguageRepository(db), &config.GenerationConfig{DailyRunQuota: 2, DailyCountQuota: 5})
+	router.POST("/generate", handler.Generate)
+	router.GET("/generate/quota", handler.Quota)
+
+	generate := func(key string, count int) *httptest.ResponseRecorder {
+		body := `{"category_id": "` + category.ID + `", "age_group": "kids", "language": "en", "count": ` + strconv.Itoa(count) + `}`
+		req, _ := http.NewRequest("POST", "/generate", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.AuthHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requested count is capped", func(t *testing.T) {
+		w := generate("key-a", 6)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "quota_exceeded")
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		require.Equal(t, http.StatusOK, generate("key-a", 3).Code)
+		assert.Equal(t, http.StatusTooManyRequests, generate("key-a", 3).Code)
+	})
+
+	t.Run("runs are capped", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, generate("key-a", 1).Code)
+		w := generate("key-a", 1)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "2 of 2 runs")
+	})
+
+	t.Run("quotas are per key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, generate("key-b", 1).Code)
+
+		req, _ := http.NewRequest("GET", "/generate/quota", nil)
+		req.Header.Set(middleware.AuthHeader, "key-a")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var quota handlers.GenerateQuotaResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quota))
+		assert.EqualValues(t, 2, quota.Runs)
+		assert.EqualValues(t, 4, quota.Requested)
+		assert.Equal(t, 2, quota.RunLimit)
+		assert.Equal(t, 5, quota.RequestedLimit)
+		assert.True(t, quota.ResetsAt.After(time.Now()))
+
+		req, _ = http.NewRequest("GET", "/generate/quota", nil)
+		req.Header.Set(middleware.AuthHeader, "key-b")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quota))
+		assert.EqualValues(t, 1, quota.Runs)
+		assert.EqualValues(t, 1, quota.Requested)
+
+		assert.Equal(t, http.StatusUnauthorized, generate("key-c", 1).Code)
+	})
+}
+
+func TestGenerateCategoryLabelsHandler_GenerateCategoryLabels(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+	handler := handlers.NewGenerateCategoryLabelsHandler(repository.NewLanguageRepository(db))
+	// The AI client and its response cache are shared by the whole process,
+	// so a label cached by an earlier run would make the first call a hit
+	ai.GetClient().ClearCache()
+
+	router.POST("/generate/category-labels", handler.GenerateCategoryLabels)
+
+	generate := func() handlers.GenerateCategoryLabelsResponse {
+		body := `{"category_name": "Road Trip", "languages": ["en", "fr"]}`
+		req, _ := http.NewRequest("POST", "/generate/category-labels", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response handlers.GenerateCategoryLabelsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	first := generate()
+	assert.Equal(t, "Road Trip", first.Labels["en"])
+	assert.NotEmpty(t, first.Labels["fr"])
+	assert.Equal(t, "miss", first.Cache)
+
+	second := generate()
+	assert.Equal(t, first.Labels, second.Labels)
+	assert.Equal(t, "hit", second.Cache)
+}
+
+func TestCategoryImageHandler_GenerateImage(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	dir := t.TempDir()
+	handler := handlers.NewCategoryImageHandler(categoryRepo, storage.NewLocalStore(dir, "/media"))
+
+	router.POST("/categories/:id/generate-image", handler.GenerateImage)
+
+	t.Run("stores image and saves url", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/categories/"+category.ID+"/generate-image", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.Category
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "/media/categories/"+category.ID+".png", response.ImageURL)
+		assert.FileExists(t, filepath.Join(dir, "categories", category.ID+".png"))
+
+		saved, err := categoryRepo.FindByID(category.ID)
+		require.NoError(t, err)
+		assert.Equal(t, response.ImageURL, saved.ImageURL)
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/categories/does-not-exist/generate-image", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// chanNotifier records notifications for assertions
+type chanNotifier chan notify.Event
+
+func (n chanNotifier) Notify(ctx context.Context, event notify.Event) error {
+	n <- event
+	return nil
+}
+
+func TestReportHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	taskRepo := repository.NewTaskRepository(db)
+	notifier := make(chanNotifier, 1)
+	handler := handlers.NewReportHandler(taskRepo, repository.NewReportRepository(db),
+		&config.ModerationConfig{ReportThreshold: 2, ReportWindowHours: 24}, notifier)
+
+	router.POST("/tasks/:id/report", handler.Report)
+	router.GET("/tasks/reported", handler.ListReported)
+	router.GET("/tasks/:id/reports", handler.ListReports)
+	router.POST("/tasks/:id/reinstate", handler.Reinstate)
+
+	report := func(taskID string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/tasks/"+taskID+"/report", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("invalid reason", func(t *testing.T) {
+		w := report(task.ID, `{"reason":"boring"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		w := report("does-not-exist", `{"reason":"offensive"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("below threshold keeps task active", func(t *testing.T) {
+		w := report(task.ID, `{"reason":"offensive","client_id":"client-a"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response handlers.ReportTaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(1), response.Reports)
+		assert.False(t, response.Deactivated)
+	})
+
+	t.Run("duplicate client report", func(t *testing.T) {
+		w := report(task.ID, `{"reason":"other","client_id":"client-a"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("crossing threshold deactivates and notifies", func(t *testing.T) {
+		w := report(task.ID, `{"reason":"inappropriate","client_id":"client-b"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response handlers.ReportTaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Deactivated)
+
+		event := <-notifier
+		assert.Equal(t, notify.EventTaskDeactivated, event.Type)
+		assert.Equal(t, task.ID, event.Fields["task_id"])
+
+		saved, err := taskRepo.FindByID(task.ID)
+		require.NoError(t, err)
+		assert.False(t, saved.IsActive)
+		assert.Equal(t, models.ReviewStatePending, saved.ReviewState)
+	})
+
+	t.Run("review queue lists the task", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/tasks/reported", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.PaginatedResponse[handlers.ReportedTaskResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, task.ID, response.Data[0].ID)
+		assert.Equal(t, int64(2), response.Data[0].OpenReports)
+	})
+
+	t.Run("reinstate reactivates and resolves reports", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/tasks/"+task.ID+"/reinstate", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		saved, err := taskRepo.FindByID(task.ID)
+		require.NoError(t, err)
+		assert.True(t, saved.IsActive)
+		assert.Equal(t, models.ReviewStateApproved, saved.ReviewState)
+
+		req, _ = http.NewRequest("GET", "/tasks/"+task.ID+"/reports", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []models.TaskReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response.Data)
+	})
+
+	t.Run("client can report again after reinstatement", func(t *testing.T) {
+		w := report(task.ID, `{"reason":"offensive","client_id":"client-a"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response handlers.ReportTaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(1), response.Reports)
+	})
+}
+
+func TestReviewHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	taskRepo := repository.NewTaskRepository(db)
+	pending := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	other := seedTestTask(t, db, category.ID, models.TaskTypeDare)
+	seedTestTask(t, db, category.ID, models.TaskTypeDare) // never queued for review
+	require.NoError(t, taskRepo.SetModeration(pending.ID, true, models.ReviewStatePending))
+	require.NoError(t, taskRepo.SetModeration(other.ID, true, models.ReviewStatePending))
+
+	handler := handlers.NewReviewHandler(taskRepo, repository.NewReportRepository(db))
+	router.GET("/tasks/inbox", handler.Inbox)
+	router.POST("/tasks/:id/claim", handler.Claim)
+	router.POST("/tasks/:id/release", handler.Release)
+	router.POST("/tasks/:id/review", handler.Review)
+	router.GET("/tasks/translations", handler.Translations)
+	router.POST("/tasks/:id/verify-language", handler.VerifyLanguage)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	inbox := func(query string) models.PaginatedResponse[models.TaskResponse] {
+		req, _ := http.NewRequest("GET", "/tasks/inbox"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.PaginatedResponse[models.TaskResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("inbox lists pending tasks", func(t *testing.T) {
+		assert.Equal(t, int64(2), inbox("").Total)
+	})
+
+	t.Run("claim assigns the task", func(t *testing.T) {
+		w := post("/tasks/"+pending.ID+"/claim", `{"reviewer":"alice"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.TaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "alice", response.AssignedTo)
+
+		mine := inbox("?assigned_to=alice")
+		require.Len(t, mine.Data, 1)
+		assert.Equal(t, pending.ID, mine.Data[0].ID)
+
+		unassigned := inbox("?unassigned=true")
+		require.Len(t, unassigned.Data, 1)
+		assert.Equal(t, other.ID, unassigned.Data[0].ID)
+	})
+
+	t.Run("claim by another reviewer conflicts", func(t *testing.T) {
+		w := post("/tasks/"+pending.ID+"/claim", `{"reviewer":"bob"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = post("/tasks/"+pending.ID+"/release", `{"reviewer":"bob"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = post("/tasks/"+pending.ID+"/review", `{"reviewer":"bob","state":"approved"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("release returns the task to the pool", func(t *testing.T) {
+		w := post("/tasks/"+pending.ID+"/release", `{"reviewer":"alice"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, int64(2), inbox("?unassigned=true").Total)
+	})
+
+	t.Run("review rejects and clears assignment", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post("/tasks/"+other.ID+"/claim", `{"reviewer":"bob"}`).Code)
+
+		w := post("/tasks/"+other.ID+"/review", `{"reviewer":"bob","state":"rejected","notes":"Too mean"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		saved, err := taskRepo.FindByID(other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReviewStateRejected, saved.ReviewState)
+		assert.Equal(t, "Too mean", saved.ReviewerNotes)
+		assert.False(t, saved.IsActive)
+		assert.Empty(t, saved.AssignedTo)
+
+		assert.Equal(t, int64(1), inbox("?review_state=rejected").Total)
+	})
+
+	t.Run("review validates state", func(t *testing.T) {
+		w := post("/tasks/"+pending.ID+"/review", `{"reviewer":"alice","state":"maybe"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("machine generated languages are verified one by one", func(t *testing.T) {
+		translated := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+		require.NoError(t, taskRepo.SetMachineLanguages(translated.ID, models.StringArray{"en", "hi"}))
+
+		translations := func(language string) models.PaginatedResponse[models.TaskResponse] {
+			req, _ := http.NewRequest("GET", "/tasks/translations?language="+language, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var response models.PaginatedResponse[models.TaskResponse]
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response
+		}
+		hi := translations("hi")
+		require.Len(t, hi.Data, 1)
+		assert.Equal(t, translated.ID, hi.Data[0].ID)
+		assert.Equal(t, int64(0), translations("es").Total)
+
+		w := post("/tasks/"+translated.ID+"/verify-language", `{"reviewer":"alice","language":"hi"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response models.TaskResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{"en"}, response.MachineLanguages)
+
+		assert.Equal(t, int64(0), translations("hi").Total)
+		assert.Equal(t, int64(1), translations("en").Total)
+
+		w = post("/tasks/"+translated.ID+"/verify-language", `{"reviewer":"alice","language":"hi"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = post("/tasks/"+translated.ID+"/verify-language", `{"reviewer":"alice","language":"xx"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = post("/tasks/missing/verify-language", `{"reviewer":"alice","language":"en"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		req, _ := http.NewRequest("GET", "/tasks/translations", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestTelemetryHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	handler := handlers.NewTelemetryHandler(repository.NewTelemetryRepository(db))
+	router.POST("/telemetry", handler.Collect)
+	router.GET("/telemetry/rollups", handler.Rollups)
+
+	collect := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/telemetry", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	now := time.Now().UTC()
+	today := now.Format("2006-01-02")
+	ts := now.Format(time.RFC3339)
+	taskID := uuid.New().String()
+
+	t.Run("events are aggregated into daily rollups", func(t *testing.T) {
+		body := `{"events": [
+			{"event": "task_served", "occurred_at": "` + ts + `", "task_id": "` + taskID + `", "task_type": "truth", "language": "en"},
+			{"event": "task_served", "occurred_at": "` + ts + `", "task_id": "` + taskID + `", "task_type": "truth", "language": "en"},
+			{"event": "task_skipped", "occurred_at": "` + ts + `", "task_id": "` + taskID + `"}
+		]}`
+		w := collect(body)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+		// A second batch adds to the same counter
+		w = collect(`{"events": [{"event": "task_served", "occurred_at": "` + ts + `", "task_id": "` + taskID + `", "task_type": "truth", "language": "en"}]}`)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+		req, _ := http.NewRequest("GET", "/telemetry/rollups?from="+today+"&task_id="+taskID, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response handlers.TelemetryRollupsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 2)
+		assert.Equal(t, int64(3), response.Totals[models.TelemetryTaskServed])
+		assert.Equal(t, int64(1), response.Totals[models.TelemetryTaskSkipped])
+	})
+
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		w := collect(`{"events": [{"event": "game_started", "occurred_at": "` + ts + `", "device_id": "abc"}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid events are rejected", func(t *testing.T) {
+		for name, event := range map[string]string{
+			"unknown event": `{"event": "clicked", "occurred_at": "` + ts + `"}`,
+			"missing time":  `{"event": "game_started"}`,
+			"too old":       `{"event": "game_started", "occurred_at": "` + now.AddDate(0, 0, -30).Format(time.RFC3339) + `"}`,
+			"bad task id":   `{"event": "task_served", "occurred_at": "` + ts + `", "task_id": "not-a-uuid"}`,
+			"bad language":  `{"event": "game_started", "occurred_at": "` + ts + `", "language": "xx"}`,
+		} {
+			w := collect(`{"events": [` + event + `]}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+
+	t.Run("empty batch is rejected", func(t *testing.T) {
+		w := collect(`{"events": []}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestPrivacyHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	reportRepo := repository.NewReportRepository(db)
+	require.NoError(t, reportRepo.Create(&models.TaskReport{TaskID: task.ID, ClientID: "client-a", Reason: models.ReportReasonOffensive}))
+	require.NoError(t, reportRepo.Create(&models.TaskReport{TaskID: task.ID, ClientID: "client-a", Reason: models.ReportReasonOther}))
+	require.NoError(t, reportRepo.Create(&models.TaskReport{TaskID: task.ID, ClientID: "client-b", Reason: models.ReportReasonOther}))
+
+	handler := handlers.NewPrivacyHandler(repository.NewPrivacyRepository(db))
+	router.GET("/privacy/clients/:id/export", handler.Export)
+	router.DELETE("/privacy/clients/:id", handler.Delete)
+	router.GET("/privacy/audit", handler.Audit)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("export returns the client's rows", func(t *testing.T) {
+		w := do("GET", "/privacy/clients/client-a/export")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var export repository.ClientExport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+		assert.Len(t, export.Reports, 2)
+	})
+
+	t.Run("delete purges only the client's rows", func(t *testing.T) {
+		w := do("DELETE", "/privacy/clients/client-a")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response handlers.PrivacyDeleteResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, int64(2), response.Deleted["task_reports"])
+
+		var remaining int64
+		db.Unscoped().Model(&models.TaskReport{}).Count(&remaining)
+		assert.Equal(t, int64(1), remaining)
+	})
+
+	t.Run("audit trail stores only a hash", func(t *testing.T) {
+		w := do("GET", "/privacy/audit?client_id=client-a")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "client-a")
+
+		var response models.PaginatedResponse[models.PrivacyAudit]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, models.PrivacyActionDelete, response.Data[0].Action)
+		assert.Equal(t, int64(2), response.Data[0].Rows["task_reports"])
+		assert.Equal(t, models.PrivacyActionExport, response.Data[1].Action)
+	})
+
+	t.Run("client id too long", func(t *testing.T) {
+		w := do("DELETE", "/privacy/clients/"+strings.Repeat("x", 65))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestConsentHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	kids := seedTestCategory(t, db)
+	adults := &models.Category{
+		Label:           models.MultilingualText{"en": "Spicy"},
+		AgeGroup:        models.AgeGroupAdults,
+		RequiresConsent: true,
+		IsActive:        true,
+	}
+	require.NoError(t, db.Create(adults).Error)
+
+	require.NoError(t, db.AutoMigrate(&game.GameSession{}, &game.Event{}))
+	games := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 24, SessionHistory: 100}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana"}}
+	require.NoError(t, games.Create(session))
+
+	cfg := &config.ModerationConfig{ConsentPolicyVersion: "2"}
+	handler := handlers.NewConsentHandler(repository.NewConsentRepository(db), repository.NewCategoryRepository(db), games, cfg)
+	router.GET("/consent/policy", handler.Policy)
+	router.POST("/consent", handler.Record)
+	router.GET("/consent/records", handler.List)
+
+	post := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/consent", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("policy returns the current version", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/consent/policy", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"policy_version":"2"`)
+	})
+
+	t.Run("outdated policy version", func(t *testing.T) {
+		w := post(map[string]interface{}{
+			"client_id": "client-a", "policy_version": "1", "age_confirmed": true,
+			"category_ids": []string{adults.ID},
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "policy_outdated")
+	})
+
+	t.Run("consent categories require age confirmation", func(t *testing.T) {
+		w := post(map[string]interface{}{
+			"client_id": "client-a", "policy_version": "2",
+			"category_ids": []string{kids.ID, adults.ID},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "age_confirmation_required")
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		w := post(map[string]interface{}{
+			"client_id": "client-a", "policy_version": "2", "age_confirmed": true,
+			"category_ids": []string{uuid.NewString()},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_category")
+	})
+
+	t.Run("unknown game session", func(t *testing.T) {
+		w := post(map[string]interface{}{
+			"client_id": "client-a", "session_id": "room-1", "policy_version": "2",
+			"age_confirmed": true, "category_ids": []string{adults.ID},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_session")
+	})
+
+	t.Run("records consent and lists it for audits", func(t *testing.T) {
+		w := post(map[string]interface{}{
+			"client_id": "client-a", "session_id": session.ID, "policy_version": "2",
+			"age_confirmed": true, "category_ids": []string{adults.ID},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		req, _ := http.NewRequest("GET", "/consent/records?client_id=client-a", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response models.PaginatedResponse[models.ConsentRecord]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, session.ID, response.Data[0].SessionID)
+		assert.Equal(t, "2", response.Data[0].PolicyVersion)
+		assert.True(t, response.Data[0].AgeConfirmed)
+		assert.Equal(t, []string{adults.ID}, response.Data[0].CategoryIDs)
+	})
+}
+
+func TestClientConfigHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	handler := handlers.NewClientConfigHandler(repository.NewSnapshotRepository(db), nil, "2.1.0")
+	router.GET("/client-config", handler.Get)
+
+	get := func() handlers.ClientConfigResponse {
+		req, _ := http.NewRequest("GET", "/client-config", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response handlers.ClientConfigResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	first := get()
+	assert.Len(t, first.Languages, len(models.SupportedLanguages))
+	assert.Equal(t, models.AgeGroupKids, first.AgeGroups[0].Value)
+	assert.Equal(t, "2.1.0", first.MinAppVersion)
+	assert.Contains(t, first.FeatureFlags, "graphql")
+	assert.NotEmpty(t, first.ContentRevision)
+	assert.Equal(t, first.ContentRevision, get().ContentRevision)
+
+	category := seedTestCategory(t, db)
+	added := get().ContentRevision
+	assert.NotEqual(t, first.ContentRevision, added)
+
+	require.NoError(t, db.Delete(category).Error)
+	assert.NotEqual(t, added, get().ContentRevision)
+}
+
+func TestLanguageHandler_Freeze(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+
+	taskRepo := repository.NewTaskRepository(db)
+	languageRepo := repository.NewLanguageRepository(db)
+	handler := handlers.NewLanguageHandler(languageRepo)
+	router.GET("/languages/freezes", handler.ListFreezes)
+	router.POST("/languages/freeze", handler.Freeze)
+	router.POST("/languages/unfreeze", handler.Unfreeze)
+	cloneHandler := handlers.NewCloneHandler(taskRepo, repository.NewCategoryRepository(db), repository.NewGlossaryRepository(db), languageRepo, &config.GenerationConfig{})
+	router.POST("/tasks/:id/clone", cloneHandler.Clone)
+	hintHandler := handlers.NewGenerateHintHandler(taskRepo, languageRepo)
+	router.POST("/tasks/:id/generate-hint", hintHandler.GenerateHint)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("freeze and list", func(t *testing.T) {
+		w := post("/languages/freeze", `{"code":"hi","reason":"Manual review"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		// Freezing again replaces the reason
+		w = post("/languages/freeze", `{"code":"hi","reason":"Still reviewing"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		req, _ := http.NewRequest("GET", "/languages/freezes", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []models.LanguageFreeze `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "hi", response.Data[0].Language)
+		assert.Equal(t, "Still reviewing", response.Data[0].Reason)
+	})
+
+	t.Run("machine translation into a frozen language conflicts", func(t *testing.T) {
+		w := post("/tasks/"+task.ID+"/clone", `{"languages":["hi"]}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = post("/tasks/"+task.ID+"/generate-hint", `{"languages":["en","hi"]}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		// Hand-written texts are still accepted
+		w = post("/tasks/"+task.ID+"/clone", `{"languages":["hi"],"texts":{"hi":"परीक्षण"}}`)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("unfreeze", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post("/languages/unfreeze", `{"code":"hi"}`).Code)
+		assert.Equal(t, http.StatusNotFound, post("/languages/unfreeze", `{"code":"hi"}`).Code)
+
+		w := post("/tasks/"+task.ID+"/generate-hint", `{"languages":["hi"]}`)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("invalid language", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post("/languages/freeze", `{"code":"xx"}`).Code)
+	})
+}
+
+func TestLanguageHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	other := &models.Category{
+		Label:    models.MultilingualText{"en": "Other", "zh": "其他", "zh-CN": "其它"},
+		Emoji:    "🎲",
+		AgeGroup: models.AgeGroupTeen,
+	}
+	require.NoError(t, db.Create(other).Error)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	require.NoError(t, db.Model(task).UpdateColumn("hint", models.MultilingualText{"en": "Hint", "zh": "提示"}).Error)
+
+	handler := handlers.NewLanguageHandler(repository.NewLanguageRepository(db))
+	router.POST("/languages/prune", handler.Prune)
+	router.POST("/languages/rename", handler.Rename)
+
+	post := func(path, body string) (*httptest.ResponseRecorder, handlers.LanguageChangeResponse) {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp handlers.LanguageChangeResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w, resp
+	}
+
+	rows := func(resp handlers.LanguageChangeResponse, table string) (int64, int64) {
+		for _, change := range resp.Changes {
+			if change.Table == table {
+				return change.Rows, change.Conflicts
+			}
+		}
+		return 0, 0
+	}
+
+	labelOf := func(id string) models.MultilingualText {
+		var cat models.Category
+		require.NoError(t, db.First(&cat, "id = ?", id).Error)
+		return cat.Label
+	}
+
+	t.Run("dry run counts without writing", func(t *testing.T) {
+		w, resp := post("/languages/rename", `{"from": "zh", "to": "zh-CN", "dry_run": true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.True(t, resp.DryRun)
+
+		catRows, conflicts := rows(resp, "categories")
+		assert.Equal(t, int64(1), catRows)
+		assert.Equal(t, int64(1), conflicts)
+		taskRows, _ := rows(resp, "tasks")
+		assert.Equal(t, int64(1), taskRows)
+
+		assert.Equal(t, "其他", labelOf(other.ID)["zh"])
+	})
+
+	t.Run("rename moves texts and keeps existing targets", func(t *testing.T) {
+		w, _ := post("/languages/rename", `{"from": "zh", "to": "zh-CN"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, models.MultilingualText{"en": "Other", "zh-CN": "其它"}, labelOf(other.ID))
+
+		var updated models.Task
+		require.NoError(t, db.First(&updated, "id = ?", task.ID).Error)
+		assert.Equal(t, models.MultilingualText{"en": "Hint", "zh-CN": "提示"}, updated.Hint)
+	})
+
+	t.Run("prune removes a language", func(t *testing.T) {
+		w, resp := post("/languages/prune", `{"code": "hi"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		catRows, _ := rows(resp, "categories")
+		assert.Equal(t, int64(1), catRows)
+		assert.Equal(t, models.MultilingualText{"en": "Test Category"}, labelOf(category.ID))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for name, tc := range map[string]struct{ path, body string }{
+			"english":      {"/languages/prune", `{"code": "en"}`},
+			"bad code":     {"/languages/prune", `{"code": "%\"x"}`},
+			"same codes":   {"/languages/rename", `{"from": "fr", "to": "fr"}`},
+			"missing to":   {"/languages/rename", `{"from": "fr"}`},
+			"bad new code": {"/languages/rename", `{"from": "fr", "to": "FR_fr"}`},
+		} {
+			w, _ := post(tc.path, tc.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+}
+
+func TestSnapshotHandler(t *testing.T) {
+	source := setupTestDB(t)
+	require.NoError(t, source.AutoMigrate(&models.FeatureFlag{}))
+	target := setupTestDB(t)
+	require.NoError(t, target.AutoMigrate(&models.FeatureFlag{}))
+
+	sourceCategory := seedTestCategory(t, source)
+	newCategory := &models.Category{
+		Label:    models.MultilingualText{"en": "Staging Only"},
+		Emoji:    "🎲",
+		AgeGroup: models.AgeGroupTeen,
+	}
+	require.NoError(t, source.Create(newCategory).Error)
+	require.NoError(t, source.Model(newCategory).Update("is_active", false).Error)
+	seedTestTask(t, source, sourceCategory.ID, models.TaskTypeTruth)
+	stagedTask := &models.Task{Text: "A dare from staging", Language: "en", Type: models.TaskTypeDare, CategoryID: newCategory.ID}
+	require.NoError(t, source.Create(stagedTask).Error)
+	require.NoError(t, source.Create(&models.FeatureFlag{Name: featureflags.GraphQL, Enabled: false, RolloutPercent: 100}).Error)
+
+	// The target already holds the test category under another ID and
+	// casing, with the same task
+	targetCategory := &models.Category{
+		Label:    models.MultilingualText{"en": "test  category"},
+		Emoji:    "📝",
+		AgeGroup: models.AgeGroupAdults,
+	}
+	require.NoError(t, target.Create(targetCategory).Error)
+	targetTask := seedTestTask(t, target, targetCategory.ID, models.TaskTypeTruth)
+
+	router := setupTestRouter()
+	exporter := handlers.NewSnapshotHandler(repository.NewSnapshotRepository(source), featureflags.New(source))
+	importer := handlers.NewSnapshotHandler(repository.NewSnapshotRepository(target), featureflags.New(target))
+	router.GET("/source/snapshot", exporter.Export)
+	router.POST("/target/snapshot", importer.Import)
+
+	req, _ := http.NewRequest("GET", "/source/snapshot", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	archive := w.Body.String()
+
+	var snapshot repository.Snapshot
+	require.NoError(t, json.Unmarshal([]byte(archive), &snapshot))
+	assert.Equal(t, repository.SnapshotFormatVersion, snapshot.FormatVersion)
+	assert.Len(t, snapshot.Categories, 2)
+	assert.Len(t, snapshot.Tasks, 2)
+	assert.Len(t, snapshot.FeatureFlags, 1)
+
+	post := func(query, body string) (*httptest.ResponseRecorder, handlers.SnapshotImportResponse) {
+		req, _ := http.NewRequest("POST", "/target/snapshot"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp handlers.SnapshotImportResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w, resp
+	}
+
+	countCategories := func() int64 {
+		var count int64
+		require.NoError(t, target.Model(&models.Category{}).Count(&count).Error)
+		return count
+	}
+
+	t.Run("dry run reports without writing", func(t *testing.T) {
+		w, resp := post("?dry_run=true", archive)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, repository.SnapshotCounts{Created: 1, Updated: 1}, resp.Categories)
+		assert.Equal(t, repository.SnapshotCounts{Created: 1, Updated: 1}, resp.Tasks)
+		assert.Equal(t, []string{featureflags.GraphQL}, resp.FeatureFlags.Applied)
+
+		assert.Equal(t, int64(1), countCategories())
+	})
+
+	t.Run("import merges and remaps IDs", func(t *testing.T) {
+		w, resp := post("", archive)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.False(t, resp.DryRun)
+		assert.Equal(t, targetCategory.ID, resp.CategoryIDs[sourceCategory.ID])
+		assert.NotEqual(t, newCategory.ID, resp.CategoryIDs[newCategory.ID])
+
+		var updated models.Category
+		require.NoError(t, target.First(&updated, "id = ?", targetCategory.ID).Error)
+		assert.Equal(t, "Test Category", updated.Label["en"])
+		assert.Equal(t, models.AgeGroupKids, updated.AgeGroup)
+
+		var created models.Category
+		require.NoError(t, target.First(&created, "id = ?", resp.CategoryIDs[newCategory.ID]).Error)
+		assert.False(t, created.IsActive)
+
+		var task models.Task
+		require.NoError(t, target.First(&task, "id = ?", resp.TaskIDs[stagedTask.ID]).Error)
+		assert.Equal(t, created.ID, task.CategoryID)
+		assert.Equal(t, "A dare from staging", task.Text)
+
+		var taskCount int64
+		require.NoError(t, target.Model(&models.Task{}).Where("category_id = ?", targetCategory.ID).Count(&taskCount).Error)
+		assert.Equal(t, int64(1), taskCount, "matching task %s should be updated, not duplicated", targetTask.ID)
+
+		var flag models.FeatureFlag
+		require.NoError(t, target.First(&flag, "name = ?", featureflags.GraphQL).Error)
+		assert.False(t, flag.Enabled)
+	})
+
+	t.Run("importing again changes nothing new", func(t *testing.T) {
+		w, resp := post("", archive)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, resp.Categories.Created)
+		assert.Equal(t, 0, resp.Tasks.Created)
+		assert.Equal(t, int64(2), countCategories())
+	})
+
+	t.Run("rejects invalid archives", func(t *testing.T) {
+		cases := map[string]string{
+			"unknown format":   `{"format_version": 99}`,
+			"newer schema":     `{"format_version": 1, "schema_version": 999}`,
+			"unknown category": `{"format_version": 1, "tasks": [{"id": "t1", "category_id": "missing", "type": "truth", "text": "Hi", "language": "en"}]}`,
+			"invalid label":    `{"format_version": 1, "categories": [{"id": "c1", "emoji": "🎲", "age_group": "kids", "label": {}}]}`,
+		}
+		for name, body := range cases {
+			w, _ := post("", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+		assert.Equal(t, int64(2), countCategories())
+	})
+}
+
+func TestSnapshotHandler_Diff(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.FeatureFlag{}))
+
+	category := seedTestCategory(t, db)
+	unchanged := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	changed := &models.Task{Text: "A dare to change", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	require.NoError(t, db.Create(changed).Error)
+	removed := &models.Task{Text: "Only here", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	require.NoError(t, db.Create(removed).Error)
+	localCategory := &models.Category{Label: models.MultilingualText{"en": "Local Only"}, Emoji: "🏠", AgeGroup: models.AgeGroupTeen}
+	require.NoError(t, db.Create(localCategory).Error)
+
+	router := setupTestRouter()
+	handler := handlers.NewSnapshotHandler(repository.NewSnapshotRepository(db), featureflags.New(db))
+	router.POST("/admin/snapshot/diff", handler.Diff)
+
+	snapshot := repository.Snapshot{
+		FormatVersion: repository.SnapshotFormatVersion,
+		Categories: []repository.SnapshotCategory{
+			{ID: "c1", Emoji: "🧪", AgeGroup: models.AgeGroupTeen, Label: models.MultilingualText{"en": "test category", "hi": "परीक्षण श्रेणी"}, IsActive: true, SortOrder: 1},
+			{ID: "c2", Emoji: "🎲", AgeGroup: models.AgeGroupKids, Label: models.MultilingualText{"en": "New Category"}, IsActive: true},
+		},
+		Tasks: []repository.SnapshotTask{
+			{ID: "t1", CategoryID: "c1", Type: unchanged.Type, Text: unchanged.Text, Language: "en", IsActive: true, RolloutPercent: 100},
+			{ID: "t2", CategoryID: "c1", Type: changed.Type, Text: changed.Text, Language: "en", IsActive: false, RolloutPercent: 100},
+			{ID: "t3", CategoryID: "c2", Type: models.TaskTypeTruth, Text: "A new truth", Language: "en", IsActive: true, RolloutPercent: 100},
+		},
+	}
+
+	post := func(body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/admin/snapshot/diff", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("reports added, removed and changed rows", func(t *testing.T) {
+		body, _ := json.Marshal(snapshot)
+		w := post(body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var diff repository.SnapshotDiff
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+
+		require.Len(t, diff.Categories.Added, 1)
+		assert.Equal(t, "c2", diff.Categories.Added[0].SourceID)
+		require.Len(t, diff.Categories.Removed, 1)
+		assert.Equal(t, localCategory.ID, diff.Categories.Removed[0].ID)
+		require.Len(t, diff.Categories.Changed, 1)
+		assert.Equal(t, category.ID, diff.Categories.Changed[0].ID)
+		var fields []string
+		for _, change := range diff.Categories.Changed[0].Changes {
+			fields = append(fields, change.Field)
+		}
+		assert.Equal(t, []string{"label", "age_group"}, fields)
+
+		require.Len(t, diff.Tasks.Added, 1)
+		assert.Equal(t, "t3", diff.Tasks.Added[0].SourceID)
+		assert.Equal(t, "c2", diff.Tasks.Added[0].SourceCategoryID)
+		assert.Empty(t, diff.Tasks.Added[0].CategoryID)
+		require.Len(t, diff.Tasks.Removed, 1)
+		assert.Equal(t, removed.ID, diff.Tasks.Removed[0].ID)
+		require.Len(t, diff.Tasks.Changed, 1)
+		assert.Equal(t, changed.ID, diff.Tasks.Changed[0].ID)
+		assert.Equal(t, []repository.SnapshotFieldChange{{Field: "is_active", From: true, To: false}}, diff.Tasks.Changed[0].Changes)
+		assert.Equal(t, 1, diff.Tasks.Unchanged)
+
+		var count int64
+		require.NoError(t, db.Model(&models.Category{}).Count(&count).Error)
+		assert.Equal(t, int64(2), count, "diff must not write")
+	})
+
+	t.Run("rejects inconsistent archives", func(t *testing.T) {
+		w := post([]byte(`{"format_version": 1, "tasks": [{"id": "t1", "category_id": "missing", "type": "truth", "text": "Hi", "language": "en"}]}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSnapshotHandler_Stream(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.FeatureFlag{}))
+
+	category := seedTestCategory(t, db)
+	// More tasks than one batch, so the keyset paging is crossed
+	tasks := make([]models.Task, 2100)
+	for i := range tasks {
+		tasks[i] = models.Task{Text: "Task " + strconv.Itoa(i), Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	}
+	require.NoError(t, db.CreateInBatches(tasks, 500).Error)
+	require.NoError(t, db.Create(&models.FeatureFlag{Name: featureflags.GraphQL, Enabled: true, RolloutPercent: 100}).Error)
+
+	router := setupTestRouter()
+	handler := handlers.NewSnapshotHandler(repository.NewSnapshotRepository(db), featureflags.New(db))
+	router.GET("/export", handler.Stream)
+
+	read := func(body io.Reader) map[string]int {
+		counts := make(map[string]int)
+		seen := make(map[string]bool)
+		scanner := bufio.NewScanner(body)
+		lastType := ""
+		for scanner.Scan() {
+			var record struct {
+				Type string          `json:"type"`
+				Data json.RawMessage `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			if counts["header"] == 0 {
+				require.Equal(t, "header", record.Type, "the export opens with its header")
+			}
+			if record.Type == "task" {
+				var task repository.SnapshotTask
+				require.NoError(t, json.Unmarshal(record.Data, &task))
+				assert.False(t, seen[task.ID], "task %s exported twice", task.ID)
+				seen[task.ID] = true
+			}
+			counts[record.Type]++
+			lastType = record.Type
+		}
+		require.NoError(t, scanner.Err())
+		assert.Equal(t, "end", lastType, "a complete export ends with its summary")
+		return counts
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.True(t, w.Flushed)
+
+		counts := read(w.Body)
+		assert.Equal(t, map[string]int{"header": 1, "category": 1, "task": 2100, "feature_flag": 1, "end": 1}, counts)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/export", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 2100, read(gz)["task"])
+	})
+}
+
+func TestImportHandler_Resume(t *testing.T) {
+	// Export 2100 tasks from one instance
+	source := setupTestDB(t)
+	require.NoError(t, source.AutoMigrate(&models.FeatureFlag{}))
+	category := seedTestCategory(t, source)
+	tasks := make([]models.Task, 2100)
+	for i := range tasks {
+		tasks[i] = models.Task{Text: "Task " + strconv.Itoa(i), Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	}
+	require.NoError(t, source.CreateInBatches(tasks, 500).Error)
+	require.NoError(t, source.Create(&models.FeatureFlag{Name: featureflags.GraphQL, Enabled: true, RolloutPercent: 100}).Error)
+
+	exportRouter := setupTestRouter()
+	exportRouter.GET("/export", handlers.NewSnapshotHandler(repository.NewSnapshotRepository(source), featureflags.New(source)).Stream)
+	req, _ := http.NewRequest("GET", "/export", nil)
+	w := httptest.NewRecorder()
+	exportRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	file := w.Body.Bytes()
+
+	// Import it into another, where task creation fails part way
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.FeatureFlag{}, &models.ImportJob{}))
+	created, failAfter := 0, 1500
+	require.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:fail_tasks", func(tx *gorm.DB) {
+		if tx.Statement.Table != "tasks" {
+			return
+		}
+		if failAfter > 0 && created >= failAfter {
+			tx.AddError(assert.AnError)
+			return
+		}
+		created++
+	}))
+
+	router := setupTestRouter()
+	handler := handlers.NewImportHandler(repository.NewImportJobRepository(db), repository.NewSnapshotRepository(db), featureflags.New(db))
+	router.POST("/imports", handler.Create)
+	router.GET("/imports/:id", handler.Get)
+	router.POST("/imports/:id/resume", handler.Resume)
+
+	post := func(path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	countTasks := func() int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.Task{}).Count(&count).Error)
+		return count
+	}
+
+	w = post("/imports", file)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var job models.ImportJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(t, models.ImportFailed, job.Status)
+	assert.Contains(t, job.Error, "batch 1")
+	assert.Equal(t, 2104, job.Lines)
+	assert.Equal(t, 1000, job.Offset, "only the first batch committed")
+	require.Len(t, job.Batches, 2)
+	assert.Equal(t, models.ImportCompleted, job.Batches[0].Status)
+	assert.Equal(t, models.ImportFailed, job.Batches[1].Status)
+	assert.Equal(t, 1, job.CategoriesCreated)
+	assert.Equal(t, 998, job.TasksCreated, "the header and category take two lines of the first batch")
+	assert.Equal(t, int64(998), countTasks(), "the failed batch rolled back")
+
+	t.Run("the same file is not imported twice", func(t *testing.T) {
+		w := post("/imports", file)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "/imports/"+job.ID+"/resume")
+	})
+
+	t.Run("a different file cannot resume the job", func(t *testing.T) {
+		other := bytes.Replace(file, []byte("Task 1\""), []byte("Task X\""), 1)
+		w := post("/imports/"+job.ID+"/resume", other)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("a truncated file is rejected", func(t *testing.T) {
+		truncated := file[:bytes.LastIndex(bytes.TrimRight(file, "\n"), []byte("\n"))+1]
+		w := post("/imports", truncated)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "cut short")
+	})
+
+	// Resume once the fault is gone: no task is created twice
+	failAfter = 0
+	w = post("/imports/"+job.ID+"/resume", file)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	job = models.ImportJob{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(t, models.ImportCompleted, job.Status)
+	assert.Empty(t, job.Error)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, job.Lines, job.Offset)
+	assert.Equal(t, 2100, job.TasksCreated)
+	assert.Equal(t, 0, job.TasksUpdated)
+	assert.Equal(t, 1, job.FlagsApplied)
+	assert.Equal(t, int64(2100), countTasks())
+
+	var categories int64
+	require.NoError(t, db.Model(&models.Category{}).Count(&categories).Error)
+	assert.Equal(t, int64(1), categories, "resumed tasks keep the category of the first batch")
+
+	w = post("/imports/"+job.ID+"/resume", file)
+	assert.Equal(t, http.StatusConflict, w.Code, "a completed job cannot be resumed")
+}
+
+func TestEmbedHandler_DailyTask(t *testing.T) {
+	db := setupTestDB(t)
+	category := seedTestCategory(t, db)
+	for i := 0; i < 10; i++ {
+		seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	}
+	// Never picked: inactive, needing consent or still rolling out
+	hidden := []models.Task{
+		{Text: "Inactive", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID, IsActive: false},
+		{Text: "Needs consent", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID, IsActive: true, RequiresConsent: true},
+		{Text: "Rolling out", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID, IsActive: true, RolloutPercent: 10},
+	}
+	require.NoError(t, db.Create(&hidden).Error)
+	require.NoError(t, db.Model(&models.Task{}).Where("text = ?", "Inactive").Update("is_active", false).Error)
+
+	router := setupTestRouter()
+	router.GET("/embed/daily-task", handlers.NewEmbedHandler(repository.NewTaskRepository(db), repository.NewCategoryRepository(db), signedurl.New("test-secret"), "").DailyTask)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/embed/daily-task"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("?language=en")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var first handlers.DailyTaskResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.Equal(t, time.Now().UTC().Format("2006-01-02"), first.Date)
+	assert.NotContains(t, []string{"Inactive", "Needs consent", "Rolling out"}, first.Task.Text)
+
+	var again handlers.DailyTaskResponse
+	require.NoError(t, json.Unmarshal(get("?language=en").Body.Bytes(), &again))
+	assert.Equal(t, first.Task.ID, again.Task.ID, "the same task all day")
+
+	assert.Equal(t, http.StatusNotFound, get("?language=en&type=dare").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?language=xx").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?type=joke").Code)
+}
+
+func TestEmbedHandler_Task(t *testing.T) {
+	t.Setenv("ADMIN_OTP_KEY", "test-otp-key")
+	db := setupTestDB(t)
+	category := seedTestCategory(t, db)
+	task := seedTestTask(t, db, category.ID, models.TaskTypeDare)
+	require.NoError(t, db.Model(task).Update("hint", models.MultilingualText{"en": "Be brave", "hi": "हिम्मत रखो"}).Error)
+	hidden := &models.Task{Text: "Needs consent", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID, RequiresConsent: true}
+	require.NoError(t, db.Create(hidden).Error)
+
+	signer := signedurl.New("test-secret")
+	router := setupTestRouter()
+	router.Use(middleware.EnvelopeMiddleware("/api/v2"), middleware.SignedURLMiddleware(signer, handlers.OEmbedSizeParams...))
+	embed := handlers.NewEmbedHandler(repository.NewTaskRepository(db), repository.NewCategoryRepository(db), signer, "https://api.example.com")
+	router.GET("/api/v2/embed/task/:id", embed.Task)
+
+	get := func(target string, admin bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", target, nil)
+		if admin {
+			req.Header.Set(middleware.AuthHeader, "test-otp-key")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	cardPath := "/api/v2/embed/task/" + task.ID
+
+	t.Run("html card", func(t *testing.T) {
+		w := get(signer.Sign(cardPath, url.Values{"lang": {"hi"}}, expires), false)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Security-Policy"), "frame-ancestors *")
+		body := w.Body.String()
+		assert.Contains(t, body, `<html lang="hi" dir="ltr">`)
+		assert.Contains(t, body, "हिम्मत रखो")
+		assert.Contains(t, body, "परीक्षण श्रेणी")
+		assert.Contains(t, body, task.ShortCode)
+		assert.Contains(t, body, `property="og:description" content="`+task.Text+`"`)
+
+		// The oEmbed link is signed with the card's expiry
+		start := strings.Index(body, `type="application/json+oembed" href="`)
+		require.NotEqual(t, -1, start)
+		href := body[start+len(`type="application/json+oembed" href="`):]
+		oembed, err := url.Parse(strings.ReplaceAll(href[:strings.Index(href, `"`)], "&amp;", "&"))
+		require.NoError(t, err)
+		assert.Equal(t, "api.example.com", oembed.Host)
+		assert.Equal(t, "json", oembed.Query().Get("format"))
+		assert.Equal(t, "hi", oembed.Query().Get("lang"))
+		linkExpiry, err := signer.Verify(oembed.Path, oembed.Query(), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, expires.Unix(), linkExpiry.Unix())
+	})
+
+	t.Run("oembed json is not enveloped", func(t *testing.T) {
+		w := get(signer.Sign(cardPath, url.Values{"format": {"json"}}, expires)+"&maxwidth=300", false)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp handlers.OEmbedResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "1.0", resp.Version)
+		assert.Equal(t, "rich", resp.Type)
+		assert.Equal(t, "Dare · Test Category", resp.Title)
+		assert.Equal(t, 300, resp.Width)
+		assert.Equal(t, 240, resp.Height)
+		assert.Contains(t, resp.HTML, `<iframe src="https://api.example.com/api/v2/embed/task/`+task.ID+"?")
+		assert.NotContains(t, resp.HTML, "format=json")
+		assert.InDelta(t, time.Hour.Seconds(), resp.CacheAge, 5)
+	})
+
+	t.Run("hidden tasks", func(t *testing.T) {
+		hiddenPath := "/api/v2/embed/task/" + hidden.ID
+		assert.Equal(t, http.StatusNotFound, get(signer.Sign(hiddenPath, nil, expires), false).Code)
+		assert.Equal(t, http.StatusOK, get(hiddenPath, true).Code, "admins may preview")
+		assert.Equal(t, http.StatusNotFound, get(signer.Sign("/api/v2/embed/task/missing", nil, expires), false).Code)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []url.Values{{"lang": {"xx"}}, {"format": {"xml"}}, {"maxwidth": {"-1"}}} {
+			assert.Equal(t, http.StatusBadRequest, get(cardPath+"?"+query.Encode(), true).Code, query.Encode())
+		}
+	})
+}
+
+func TestSchedulerHandler_Calendar(t *testing.T) {
+	sched := scheduler.New(&config.Config{Scheduler: config.SchedulerConfig{Enabled: true}}, nil)
+	require.NoError(t, sched.AddJob(&scheduler.Job{
+		Name: "nightly", Description: "Nightly job", CronExpr: "0 2 * * *", Enabled: true,
+		Fn: func(ctx context.Context) error { return nil },
+	}))
+	router := setupTestRouter()
+	router.GET("/scheduler/calendar.ics", handlers.NewSchedulerHandler(sched).Calendar)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/scheduler/calendar.ics"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("?days=3")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"), body)
+	assert.Equal(t, 3, strings.Count(body, "SUMMARY:nightly\r\n"), "one run a day")
+
+	assert.Equal(t, 14, strings.Count(get("").Body.String(), "SUMMARY:nightly"), "two weeks by default")
+	for _, query := range []string{"?days=0", "?days=91", "?days=week"} {
+		assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+	}
+}
+
+func TestSignedURLHandler(t *testing.T) {
+	signer := signedurl.New("test-secret")
+	router := setupTestRouter()
+	router.POST("/signed-urls", handlers.NewSignedURLHandler(signer, []string{"/api/v1/embed/", "/api/v2/embed/", "/api/v2/scheduler/calendar.ics"}, 48*time.Hour).Create)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/signed-urls", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"path": "/api/v2/embed/daily-task?language=es", "ttl_seconds": 3600}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp handlers.SignedURLResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), resp.ExpiresAt, 2*time.Second)
+
+	signed, err := url.Parse(resp.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v2/embed/daily-task", signed.Path)
+	assert.Equal(t, "es", signed.Query().Get("language"))
+	_, err = signer.Verify(signed.Path, signed.Query(), time.Now())
+	assert.NoError(t, err)
+
+	w = post(`{"path": "/api/v1/embed/daily-task"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), resp.ExpiresAt, 2*time.Second, "one day by default")
+
+	assert.Equal(t, http.StatusCreated, post(`{"path": "/api/v2/scheduler/calendar.ics?days=30"}`).Code, "single paths are signable")
+
+	for _, body := range []string{
+		`{"path": "/api/v2/tasks/random"}`,
+		`{"path": "/api/v2/embed/../tasks/random"}`,
+		`{"path": "/api/v2/embed/"}`,
+		`{"path": "/api/v2/scheduler/calendar.ics/x"}`,
+		`{"path": "https://evil.example/api/v2/embed/daily-task"}`,
+		`{"path": "/api/v2/embed/daily-task", "ttl_seconds": 172801}`,
+		`{"path": "/api/v2/embed/daily-task", "ttl_seconds": -5}`,
+		`{}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, post(body).Code, body)
+	}
+}
+
+func TestChatHandler_SlackCommand(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChatWorkspace{}, &models.ChatDraw{}))
+	kids := seedTestCategory(t, db)
+	adults := &models.Category{Label: models.MultilingualText{"en": "Adults"}, AgeGroup: models.AgeGroupAdults, IsActive: true}
+	require.NoError(t, db.Create(adults).Error)
+	kidsTasks := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		task := &models.Task{Text: "Kids dare " + strconv.Itoa(i), Language: "en", Type: models.TaskTypeDare, CategoryID: kids.ID}
+		require.NoError(t, db.Create(task).Error)
+		kidsTasks[task.Text] = true
+	}
+	require.NoError(t, db.Create(&models.Task{Text: "Adults dare", Language: "en", Type: models.TaskTypeDare, CategoryID: adults.ID}).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Kids truth", Language: "es", Type: models.TaskTypeTruth, CategoryID: kids.ID}).Error)
+
+	chats := repository.NewChatRepository(db)
+	require.NoError(t, chats.CreateWorkspace(&models.ChatWorkspace{
+		Platform: models.ChatPlatformSlack, WorkspaceID: "T1", AgeGroup: models.AgeGroupKids, Language: "en", IsActive: true,
+	}))
+
+	cfg := &config.ChatConfig{SlackSigningSecret: "slack-secret", ChannelHistory: 50}
+	router := setupTestRouter()
+	router.POST("/integrations/slack/command", handlers.NewChatHandler(chats, repository.NewTaskRepository(db), repository.NewCategoryRepository(db), nil, cfg).SlackCommand)
+
+	command := func(form url.Values, secret string) *httptest.ResponseRecorder {
+		body := form.Encode()
+		req, _ := http.NewRequest("POST", "/integrations/slack/command", strings.NewReader(body))
+		req.Header = integrations.SignSlack(secret, []byte(body), time.Now())
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	draw := func(team, channel, text string) handlers.SlackCommandResponse {
+		w := command(url.Values{"team_id": {team}, "channel_id": {channel}, "text": {text}}, "slack-secret")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp handlers.SlackCommandResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	drawnText := func(resp handlers.SlackCommandResponse) string {
+		_, text, _ := strings.Cut(resp.Text, "\n")
+		return text
+	}
+
+	t.Run("draws are locked to the age group and not repeated in a channel", func(t *testing.T) {
+		seen := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			resp := draw("T1", "C1", "dare")
+			assert.Equal(t, "in_channel", resp.ResponseType)
+			assert.True(t, strings.HasPrefix(resp.Text, "*Dare* · T-"), resp.Text)
+			text := drawnText(resp)
+			assert.True(t, kidsTasks[text], "kids tasks only, got %q", text)
+			assert.False(t, seen[text], "repeated %q", text)
+			seen[text] = true
+		}
+
+		resp := draw("T1", "C1", "dare")
+		assert.True(t, kidsTasks[drawnText(resp)], "history starts over once every task was drawn")
+
+		var draws int64
+		require.NoError(t, db.Model(&models.ChatDraw{}).Where("channel_id = ?", "C1").Count(&draws).Error)
+		assert.Equal(t, int64(4), draws)
+	})
+
+	t.Run("language and type", func(t *testing.T) {
+		resp := draw("T1", "C2", "truth es")
+		assert.Equal(t, "*Verdad* · ", resp.Text[:len("*Verdad* · ")])
+		assert.Equal(t, "Kids truth", drawnText(resp))
+	})
+
+	t.Run("player errors are ephemeral", func(t *testing.T) {
+		for _, tc := range []struct{ team, text string }{
+			{"T2", "dare"},    // unknown workspace
+			{"T1", "joke"},    // unreadable arguments
+			{"T1", "dare fr"}, // nothing matches
+		} {
+			resp := draw(tc.team, "C1", tc.text)
+			assert.Equal(t, "ephemeral", resp.ResponseType, tc.text)
+		}
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		w := command(url.Values{"team_id": {"T1"}, "text": {"dare"}}, "wrong-secret")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req, _ := http.NewRequest("POST", "/integrations/slack/command", strings.NewReader("team_id=T1"))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestChatHandler_DiscordInteraction(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChatWorkspace{}, &models.ChatDraw{}))
+	category := seedTestCategory(t, db)
+	seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+
+	chats := repository.NewChatRepository(db)
+	require.NoError(t, chats.CreateWorkspace(&models.ChatWorkspace{
+		Platform: models.ChatPlatformDiscord, WorkspaceID: "G1", AgeGroup: models.AgeGroupKids, Language: "en", IsActive: true,
+	}))
+
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	cfg := &config.ChatConfig{DiscordPublicKey: hex.EncodeToString(public), ChannelHistory: 50}
+	router := setupTestRouter()
+	router.POST("/integrations/discord/interactions", handlers.NewChatHandler(chats, repository.NewTaskRepository(db), repository.NewCategoryRepository(db), nil, cfg).DiscordInteraction)
+
+	interact := func(body string) (*httptest.ResponseRecorder, handlers.DiscordInteractionResponse) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req, _ := http.NewRequest("POST", "/integrations/discord/interactions", strings.NewReader(body))
+		req.Header.Set(integrations.DiscordTimestampHeader, timestamp)
+		req.Header.Set(integrations.DiscordSignatureHeader, hex.EncodeToString(ed25519.Sign(private, []byte(timestamp+body))))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp handlers.DiscordInteractionResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, resp := interact(`{"type": 1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, resp.Type, "pings are answered with a pong")
+
+	w, resp = interact(`{"type": 2, "guild_id": "G1", "channel_id": "C1", "data": {"name": "truth"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 4, resp.Type)
+	require.NotNil(t, resp.Data)
+	assert.Equal(t, "**Truth** · ", resp.Data.Content[:len("**Truth** · ")])
+	assert.Zero(t, resp.Data.Flags)
+
+	_, resp = interact(`{"type": 2, "guild_id": "G1", "channel_id": "C1", "data": {"name": "tod", "options": [{"name": "type", "value": "dare"}]}}`)
+	require.NotNil(t, resp.Data)
+	assert.Equal(t, 64, resp.Data.Flags, "no dare matches, so only the player sees the error")
+
+	_, resp = interact(`{"type": 2, "channel_id": "D1", "data": {"name": "truth"}}`)
+	require.NotNil(t, resp.Data)
+	assert.Equal(t, 64, resp.Data.Flags, "direct messages have no workspace")
+
+	req, _ := http.NewRequest("POST", "/integrations/discord/interactions", strings.NewReader(`{"type": 1}`))
+	req.Header.Set(integrations.DiscordTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(integrations.DiscordSignatureHeader, strings.Repeat("00", ed25519.SignatureSize))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestChatHandler_TelegramWebhook(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChatWorkspace{}, &models.ChatDraw{}))
+	kids := seedTestCategory(t, db)
+	require.NoError(t, db.Create(&models.Task{Text: "Kids dare", Language: "en", Type: models.TaskTypeDare, CategoryID: kids.ID}).Error)
+	teen := &models.Category{Label: models.MultilingualText{"en": "Teen"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	require.NoError(t, db.Create(teen).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Teen dare", Language: "en", Type: models.TaskTypeDare, CategoryID: teen.ID}).Error)
+	require.NoError(t, db.Create(&models.Task{Text: "Reto teen", Language: "es", Type: models.TaskTypeDare, CategoryID: teen.ID}).Error)
+
+	chats := repository.NewChatRepository(db)
+	cfg := &config.ChatConfig{TelegramWebhookSecret: "telegram-secret", TelegramAgeGroup: models.AgeGroupTeen, ChannelHistory: 50}
+	router := setupTestRouter()
+	router.POST("/integrations/telegram/webhook", handlers.NewChatHandler(chats, repository.NewTaskRepository(db), repository.NewCategoryRepository(db), nil, cfg).TelegramWebhook)
+
+	update := func(secret, chatType, text string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"update_id": 1, "message": {"text": %q, "from": {"language_code": "en-GB"}, "chat": {"id": -100, "type": %q, "title": "Party"}}}`, text, chatType)
+		req, _ := http.NewRequest("POST", "/integrations/telegram/webhook", strings.NewReader(body))
+		req.Header.Set(integrations.TelegramSecretHeader, secret)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	send := func(text string) string {
+		w := update("telegram-secret", "supergroup", text)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var reply handlers.TelegramReply
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reply))
+		assert.Equal(t, "sendMessage", reply.Method)
+		assert.Equal(t, int64(-100), reply.ChatID)
+		return reply.Text
+	}
+	drawn := func(text string) string {
+		label, task, _ := strings.Cut(text, "\n")
+		kind, _, _ := strings.Cut(label, " · ")
+		return kind + ": " + task
+	}
+
+	t.Run("groups register in safe mode", func(t *testing.T) {
+		assert.Equal(t, "Dare: Kids dare", drawn(send("/dare@TruthOrDareBot")))
+		workspace, err := chats.FindWorkspace(models.ChatPlatformTelegram, "-100")
+		require.NoError(t, err)
+		assert.Equal(t, "Party", workspace.Name)
+		assert.Equal(t, models.AgeGroupTeen, workspace.AgeGroup)
+		assert.Equal(t, "en", workspace.Language)
+		assert.True(t, workspace.SafeMode)
+	})
+
+	t.Run("settings commands", func(t *testing.T) {
+		assert.Equal(t, "Safe mode is off. Drawing from all categories.", send("/safe off"))
+		assert.Contains(t, send("/categories"), "1. 📝 Teen")
+		assert.Equal(t, "Drawing from 1 categories.", send("/categories 1"))
+		assert.Contains(t, send("/categories 7"), "Unknown category")
+		assert.Equal(t, "Language set to es.", send("/language ES"))
+		assert.Contains(t, send("/language xx"), "Unsupported language")
+
+		workspace, err := chats.FindWorkspace(models.ChatPlatformTelegram, "-100")
+		require.NoError(t, err)
+		assert.False(t, workspace.SafeMode)
+		assert.Equal(t, models.StringArray{teen.ID}, workspace.CategoryIDs)
+		assert.Equal(t, "es", workspace.Language)
+		assert.Contains(t, send("/settings"), "Age group: teen")
+
+		assert.Equal(t, "Reto: Reto teen", drawn(send("/tod")))
+		assert.Equal(t, "Dare: Teen dare", drawn(send("/dare en")))
+	})
+
+	t.Run("other messages", func(t *testing.T) {
+		for _, text := range []string{"hello", "/roll"} {
+			w := update("telegram-secret", "supergroup", text)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `{}`, w.Body.String(), text)
+		}
+		assert.Contains(t, send("/help"), "/categories")
+
+		w := update("telegram-secret", "private", "/dare")
+		assert.Contains(t, w.Body.String(), "Add me to a group")
+	})
+
+	t.Run("secret token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, update("wrong-secret", "group", "/dare").Code)
+		assert.Equal(t, http.StatusUnauthorized, update("", "group", "/dare").Code)
+	})
+}
+
+func TestGameHandler(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&game.GameSession{}, &game.Event{}))
+	category := seedTestCategory(t, db)
+	seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+
+	games := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 24, SessionHistory: 100}, nil)
+	h := handlers.NewGameHandler(games, repository.NewCategoryRepository(db), &config.ModerationConfig{ConsentPolicyVersion: "2", BlockedWords: []string{"dave"}})
+	router := setupTestRouter()
+	router.POST("/sessions", h.Create)
+	router.GET("/sessions/:id", h.Get)
+	router.GET("/sessions/:id/next", h.Next)
+	router.POST("/sessions/:id/kick", h.Kick)
+	router.POST("/sessions/:id/lock", h.Lock)
+	router.PUT("/sessions/:id/categories", h.SetCategories)
+	router.POST("/sessions/:id/custom-tasks", h.AddCustomTask)
+	router.POST("/sessions/:id/exclusions", h.Exclude)
+	router.POST("/sessions/:id/end", h.End)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// host sends a request with a host token
+	host := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(handlers.HostTokenHeader, token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/sessions", `{"players": [" Ana ", "Ben"], "category_ids": ["`+category.ID+`"], "languages": ["en"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var session handlers.GameSessionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+	assert.Equal(t, models.StringArray{"Ana", "Ben"}, session.Players)
+	assert.Equal(t, "Ana", session.CurrentPlayer)
+	assert.NotContains(t, w.Body.String(), "served_task_ids")
+	assert.NotEmpty(t, session.HostToken)
+	hostToken := session.HostToken
+
+	for _, want := range []string{"Ana", "Ben"} {
+		w = send("GET", "/sessions/"+session.ID+"/next", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var turn handlers.GameTurnResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &turn))
+		assert.Equal(t, want, turn.Player)
+		assert.Equal(t, "Test task text", turn.Task.Text, "the only task comes round again")
+	}
+
+	w = send("GET", "/sessions/"+strings.ToLower(session.Code), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "host_token")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+	assert.Equal(t, 2, session.Round)
+	assert.Equal(t, "Ana", session.CurrentPlayer)
+
+	assert.Equal(t, http.StatusNotFound, send("GET", "/sessions/"+session.ID+"/next?type=dare", "").Code)
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/sessions/"+session.ID+"/next?type=joke", "").Code)
+	assert.Equal(t, http.StatusNotFound, send("GET", "/sessions/missing/next", "").Code)
+	for _, body := range []string{
+		`{}`,
+		`{"players": []}`,
+		`{"players": ["Ana", "Ana"]}`,
+		`{"players": ["Ana"], "languages": ["xx"]}`,
+		`{"players": ["Ana"], "category_ids": ["missing"]}`,
+		`{"players": ["Ana"], "turn_seconds": 3600}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, send("POST", "/sessions", body).Code, body)
+	}
+
+	t.Run("timed turns", func(t *testing.T) {
+		w := send("POST", "/sessions", `{"players": ["Ana"], "turn_seconds": 30}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.GameSessionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, 30, created.TurnSeconds)
+
+		w = send("GET", "/sessions/"+created.Code+"/next", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var turn handlers.GameTurnResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &turn))
+		require.NotNil(t, turn.Deadline)
+		assert.InDelta(t, 30000, turn.TimeLeftMS, 5000)
+	})
+
+	t.Run("exclusions", func(t *testing.T) {
+		w := send("POST", "/sessions", `{"players": ["Ana"]}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.GameSessionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		path := "/sessions/" + created.Code + "/exclusions"
+
+		body := `{"category_ids": ["` + category.ID + `"]}`
+		assert.Equal(t, http.StatusForbidden, send("POST", path, body).Code)
+		for _, bad := range []string{`{}`, `{"task_ids": ["missing"]}`, `{"category_ids": ["missing"]}`} {
+			assert.Equal(t, http.StatusBadRequest, host(created.HostToken, "POST", path, bad).Code, bad)
+		}
+		w = host(created.HostToken, "POST", path, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, models.StringArray{category.ID}, created.ExcludedCategoryIDs)
+		assert.Equal(t, http.StatusNotFound, send("GET", "/sessions/"+created.Code+"/next", "").Code, "the only category is excluded")
+	})
+
+	t.Run("custom tasks", func(t *testing.T) {
+		w := send("POST", "/sessions", `{"players": ["Ana"]}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.GameSessionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		path := "/sessions/" + created.Code + "/custom-tasks"
+
+		body := `{"text": "Do your best impression of the host", "type": "dare"}`
+		assert.Equal(t, http.StatusForbidden, send("POST", path, body).Code)
+		for _, bad := range []string{
+			`{"text": "Say shit", "type": "dare"}`,
+			`{"text": "Impersonate Dave", "type": "dare"}`,
+			`{"text": "Dance", "type": "joke"}`,
+			`{"text": "Dance", "type": "dare", "language": "xx"}`,
+			`{"text": " ", "type": "dare"}`,
+		} {
+			assert.Equal(t, http.StatusBadRequest, host(created.HostToken, "POST", path, bad).Code, bad)
+		}
+		w = host(created.HostToken, "POST", path, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var custom game.CustomTask
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &custom))
+
+		w = send("GET", "/sessions/"+created.Code+"/next", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var turn handlers.GameTurnResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &turn))
+		assert.Equal(t, custom.ID, turn.Task.ID)
+		assert.Equal(t, "Do your best impression of the host", turn.Task.Text)
+	})
+
+	t.Run("host controls", func(t *testing.T) {
+		w := send("POST", "/sessions", `{"players": ["Ana", "Ben", "Cy"]}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.GameSessionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		path := "/sessions/" + created.Code
+
+		assert.Equal(t, http.StatusForbidden, send("POST", path+"/kick", `{"player": "Ben"}`).Code)
+		assert.Equal(t, http.StatusForbidden, host(hostToken, "POST", path+"/kick", `{"player": "Ben"}`).Code, "another session's token")
+		assert.Equal(t, http.StatusBadRequest, host(created.HostToken, "POST", path+"/kick", `{"player": "Zed"}`).Code)
+
+		w = host(created.HostToken, "POST", path+"/kick", `{"player": "Ana"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var kicked handlers.GameSessionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &kicked))
+		assert.Equal(t, models.StringArray{"Ben", "Cy"}, kicked.Players)
+		assert.Equal(t, "Ben", kicked.CurrentPlayer)
+
+		w = host(created.HostToken, "POST", path+"/lock", `{"locked": true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"locked":true`)
+
+		w = host(created.HostToken, "PUT", path+"/categories", `{"category_ids": ["`+category.ID+`"]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), category.ID)
+		assert.Equal(t, http.StatusBadRequest, host(created.HostToken, "PUT", path+"/categories", `{"category_ids": ["missing"]}`).Code)
+
+		require.Equal(t, http.StatusOK, host(created.HostToken, "POST", path+"/end", "").Code)
+		w = send("GET", path+"/next", "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"ended"`)
+		assert.Equal(t, http.StatusConflict, host(created.HostToken, "POST", path+"/end", "").Code)
+	})
+
+	t.Run("consent", func(t *testing.T) {
+		adults := &models.Category{Label: models.MultilingualText{"en": "Spicy"}, AgeGroup: models.AgeGroupAdults, RequiresConsent: true, IsActive: true}
+		require.NoError(t, db.Create(adults).Error)
+		consent := func(policy string, ageConfirmed bool) string {
+			return fmt.Sprintf(`"consent": {"client_id": "client-a", "policy_version": %q, "age_confirmed": %t}`, policy, ageConfirmed)
+		}
+
+		// Without consent a game keeps to tasks that need none
+		w := send("POST", "/sessions", `{"players": ["Ana"]}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"requires_consent":false`)
+		assert.NotContains(t, w.Body.String(), `"consent":`)
+
+		for body, code := range map[string]string{
+			`{"players": ["Ana"], "category_ids": ["` + adults.ID + `"]}`:                              "consent_required",
+			`{"players": ["Ana"], "requires_consent": true}`:                                           "consent_required",
+			`{"players": ["Ana"], "category_ids": ["` + adults.ID + `"], ` + consent("1", true) + `}`:  "policy_outdated",
+			`{"players": ["Ana"], "category_ids": ["` + adults.ID + `"], ` + consent("2", false) + `}`: "age_confirmation_required",
+		} {
+			w := send("POST", "/sessions", body)
+			assert.Contains(t, w.Body.String(), code, body)
+			assert.NotEqual(t, http.StatusCreated, w.Code, body)
+		}
+
+		w = send("POST", "/sessions", `{"players": ["Ana"], "category_ids": ["`+adults.ID+`"], `+consent("2", true)+`}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created handlers.GameSessionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Nil(t, created.RequiresConsent)
+		require.NotNil(t, created.Consent)
+
+		var records []models.ConsentRecord
+		require.NoError(t, db.Find(&records).Error)
+		require.Len(t, records, 1, "refused sessions record no consent")
+		assert.Equal(t, created.ID, records[0].SessionID)
+		assert.Equal(t, "client-a", records[0].ClientID)
+		assert.Equal(t, []string{adults.ID}, records[0].CategoryIDs)
+
+		// Games started without consent cannot switch to categories that
+		// require it
+		w = send("POST", "/sessions", `{"players": ["Ana"]}`)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		w = host(created.HostToken, "PUT", "/sessions/"+created.ID+"/categories", `{"category_ids": ["`+adults.ID+`"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "consent_required")
+	})
+}
+
+func TestGameQRHandler(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&game.GameSession{}, &game.Event{}))
+	games := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 24, SessionHistory: 100}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana"}}
+	require.NoError(t, games.Create(session))
+
+	router := setupTestRouter()
+	router.GET("/sessions/:id/qr", handlers.NewGameQRHandler(games, "", "https://tod.example.com").QR)
+	router.GET("/custom/:id/qr", handlers.NewGameQRHandler(games, "tod://join/{code}", "").QR)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/sessions/" + strings.ToLower(session.Code) + "/qr")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "<svg"))
+	assert.Equal(t, w.Body.String(), get("/sessions/"+session.ID+"/qr").Body.String(), "the same code by ID")
+	assert.NotEqual(t, w.Body.String(), get("/custom/"+session.Code+"/qr").Body.String(), "GAME_JOIN_URL changes the link")
+
+	w = get("/sessions/" + session.Code + "/qr?format=png")
+	require.Equal(t, http.StatusOK, w.Code)
+	img, err := png.Decode(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 512, img.Bounds().Dx())
+
+	assert.Equal(t, http.StatusBadRequest, get("/sessions/"+session.Code+"/qr?format=gif").Code)
+	assert.Equal(t, http.StatusNotFound, get("/sessions/NOPE42/qr").Code)
+}
+
+func TestGameSummaryHandler(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&game.GameSession{}, &game.Event{}))
+	category := seedTestCategory(t, db)
+	dare := seedTestTask(t, db, category.ID, models.TaskTypeDare)
+	telemetry := repository.NewTelemetryRepository(db)
+	require.NoError(t, telemetry.AddCounts([]models.TelemetryRollup{
+		{Day: time.Now().UTC().Format("2006-01-02"), Event: models.TelemetryTaskLiked, TaskID: dare.ID, Count: 4, UpdatedAt: time.Now()},
+	}))
+
+	games := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 24, SessionHistory: 100}, nil)
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}}
+	require.NoError(t, games.Create(session))
+	_, _, _, err := games.Next(session.ID, "")
+	require.NoError(t, err)
+	_, err = games.Resolve(session.ID, game.EventSkipped)
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	router.GET("/sessions/:id/summary", handlers.NewGameSummaryHandler(games, telemetry).Summary)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/sessions/" + session.Code + "/summary")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var summary handlers.GameSummaryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 1, summary.Draws)
+	assert.Equal(t, 1, summary.Skipped)
+	require.Len(t, summary.Turns, 1)
+	assert.Equal(t, "Ana", summary.Turns[0].Player)
+	assert.Equal(t, game.OutcomeSkipped, summary.Turns[0].Outcome)
+	assert.Equal(t, dare.ID, summary.Turns[0].Task.ID)
+	require.Len(t, summary.TopDares, 1)
+	assert.Equal(t, int64(4), summary.TopDares[0].Likes)
+
+	assert.Equal(t, http.StatusNotFound, get("/sessions/NOPE42/summary").Code)
+}
+
+func TestChatHandler_Workspaces(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChatWorkspace{}, &models.ChatDraw{}))
+	h := handlers.NewChatHandler(repository.NewChatRepository(db), repository.NewTaskRepository(db), repository.NewCategoryRepository(db), nil, &config.ChatConfig{})
+	router := setupTestRouter()
+	router.GET("/integrations/workspaces", h.ListWorkspaces)
+	router.POST("/integrations/workspaces", h.CreateWorkspace)
+	router.PUT("/integrations/workspaces/:id", h.UpdateWorkspace)
+	router.DELETE("/integrations/workspaces/:id", h.DeleteWorkspace)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/integrations/workspaces", `{"platform": "slack", "workspace_id": "T1", "name": "Office", "age_group": "teen"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var workspace models.ChatWorkspace
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &workspace))
+	assert.Equal(t, "en", workspace.Language)
+	assert.True(t, workspace.IsActive)
+	assert.False(t, workspace.SafeMode)
+
+	assert.Equal(t, http.StatusConflict, send("POST", "/integrations/workspaces", `{"platform": "slack", "workspace_id": "T1", "age_group": "kids"}`).Code)
+	assert.Equal(t, http.StatusCreated, send("POST", "/integrations/workspaces", `{"platform": "discord", "workspace_id": "T1", "age_group": "kids"}`).Code, "IDs are scoped to a platform")
+	for _, body := range []string{
+		`{"platform": "teams", "workspace_id": "T3", "age_group": "kids"}`,
+		`{"platform": "slack", "workspace_id": "T3", "age_group": "toddlers"}`,
+		`{"platform": "slack", "workspace_id": "T3", "age_group": "kids", "language": "xx"}`,
+		`{"platform": "slack", "age_group": "kids"}`,
+		`{"platform": "slack", "workspace_id": "T3", "age_group": "kids", "category_ids": ["missing"]}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, send("POST", "/integrations/workspaces", body).Code, body)
+	}
+
+	w = send("PUT", "/integrations/workspaces/"+workspace.ID, `{"platform": "slack", "workspace_id": "T1", "age_group": "adults", "language": "es", "is_active": false}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &workspace))
+	assert.Equal(t, models.AgeGroupAdults, workspace.AgeGroup)
+	assert.False(t, workspace.IsActive)
+
+	assert.Equal(t, http.StatusOK, send("DELETE", "/integrations/workspaces/"+workspace.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, send("DELETE", "/integrations/workspaces/"+workspace.ID, "").Code)
+
+	var list struct {
+		Data []models.ChatWorkspace `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(send("GET", "/integrations/workspaces", "").Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, models.ChatPlatformDiscord, list.Data[0].Platform)
+}
+
+func TestFreshnessHandler(t *testing.T) {
+	db := setupTestDB(t)
+	category := seedTestCategory(t, db)
+
+	now := time.Now().UTC()
+	fresh := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	require.NoError(t, db.Model(fresh).Update("created_at", now.Add(-2*time.Hour)).Error)
+	older := &models.Task{Text: "An older dare", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	require.NoError(t, db.Create(older).Error)
+	require.NoError(t, db.Model(older).Update("created_at", now.Add(-48*time.Hour)).Error)
+	stale := &models.Task{Text: "पुराना सवाल", Language: "hi", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	require.NoError(t, db.Create(stale).Error)
+	require.NoError(t, db.Model(stale).Update("created_at", now.Add(-30*24*time.Hour)).Error)
+	// Inactive tasks do not count as fresh content
+	inactive := &models.Task{Text: "Nuevo pero inactivo", Language: "es", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	require.NoError(t, db.Create(inactive).Error)
+	require.NoError(t, db.Model(inactive).Update("is_active", false).Error)
+
+	handler := handlers.NewFreshnessHandler(
+		repository.NewTaskRepository(db),
+		repository.NewCategoryRepository(db),
+		&config.GenerationConfig{FreshnessSLAHours: 24},
+	)
+	router := setupTestRouter()
+	router.GET("/tasks/freshness", handler.Get)
+
+	req, _ := http.NewRequest("GET", "/tasks/freshness", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var report handlers.FreshnessReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 24, report.SLAHours)
+	assert.Equal(t, len(models.SupportedLanguages), report.Total)
+	assert.Equal(t, 1, report.Fresh)
+	assert.Equal(t, 10.0, report.FreshPercent)
+
+	require.Len(t, report.Categories, 1)
+	languages := make(map[string]handlers.LanguageFreshness)
+	for _, language := range report.Categories[0].Languages {
+		languages[language.Language] = language
+	}
+	assert.True(t, languages["en"].Fresh)
+	require.NotNil(t, languages["en"].AgeSeconds)
+	assert.InDelta(t, 2*3600, *languages["en"].AgeSeconds, 60)
+	assert.False(t, languages["hi"].Fresh)
+	assert.NotNil(t, languages["hi"].NewestTaskAt)
+	assert.False(t, languages["es"].Fresh)
+	assert.Nil(t, languages["es"].AgeSeconds)
+
+	assert.InDelta(t, 0.1, handler.FreshRatio(), 0.001)
+	samples := handler.AgeSamples()
+	assert.Len(t, samples, len(models.SupportedLanguages))
+}
+
+func TestTrendingHandler(t *testing.T) {
+	db := setupTestDB(t)
+	category := seedTestCategory(t, db)
+
+	popular := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	loved := &models.Task{Text: "A loved dare", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	require.NoError(t, db.Create(loved).Error)
+	retired := &models.Task{Text: "A retired dare", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	require.NoError(t, db.Create(retired).Error)
+	require.NoError(t, db.Model(retired).Update("is_active", false).Error)
+
+	now := time.Now().UTC()
+	today := now.Format("2006-01-02")
+	longAgo := now.AddDate(0, 0, -30).Format("2006-01-02")
+	repo := repository.NewTelemetryRepository(db)
+	rollup := func(day, event, taskID string, count int64) models.TelemetryRollup {
+		return models.TelemetryRollup{Day: day, Event: event, TaskID: taskID, Count: count, UpdatedAt: now}
+	}
+	require.NoError(t, repo.AddCounts([]models.TelemetryRollup{
+		rollup(today, models.TelemetryTaskServed, popular.ID, 50),
+		rollup(today, models.TelemetryTaskLiked, popular.ID, 5),
+		rollup(today, models.TelemetryTaskServed, loved.ID, 20),
+		rollup(today, models.TelemetryTaskCompleted, loved.ID, 18),
+		rollup(today, models.TelemetryTaskLiked, loved.ID, 15),
+		rollup(today, models.TelemetryTaskServed, retired.ID, 500),
+		rollup(longAgo, models.TelemetryTaskServed, loved.ID, 1000),
+	}))
+
+	router := setupTestRouter()
+	router.GET("/tasks/trending", handlers.NewTrendingHandler(repo, repository.NewTaskRepository(db)).Trending)
+
+	get := func(query string) (*httptest.ResponseRecorder, handlers.TrendingResponse) {
+		req, _ := http.NewRequest("GET", "/tasks/trending"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp handlers.TrendingResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w, resp
+	}
+
+	t.Run("ranks active tasks by serves in the window", func(t *testing.T) {
+		w, resp := get("?window=7d")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, resp.Data, 2)
+		assert.Equal(t, popular.ID, resp.Data[0].ID)
+		assert.Equal(t, int64(50), resp.Data[0].Served)
+		assert.Equal(t, loved.ID, resp.Data[1].ID)
+		assert.Equal(t, int64(20), resp.Data[1].Served)
+		assert.InDelta(t, 0.75, resp.Data[1].LikeRate, 0.001)
+		assert.InDelta(t, 0.9, resp.Data[1].CompletionRate, 0.001)
+	})
+
+	t.Run("ranks by like rate", func(t *testing.T) {
+		w, resp := get("?sort=like_rate&limit=1")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, loved.ID, resp.Data[0].ID)
+	})
+
+	t.Run("longer windows include older days", func(t *testing.T) {
+		w, resp := get("?window=60d&type=dare")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, int64(1020), resp.Data[0].Served)
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"?window=week", "?window=365d", "?window=-1h", "?sort=random"} {
+			w, _ := get(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+func TestSearchHandler_Search(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	other := seedTestTask(t, db, category.ID, models.TaskTypeTruth)
+	dare := &models.Task{CategoryID: category.ID, Type: models.TaskTypeDare, Text: "Sing a CATEGORY song", Language: "en",
+		Hint: models.MultilingualText{"hi": "परीक्षण गीत"}}
+	require.NoError(t, db.Create(dare).Error)
+	underscore := &models.Task{CategoryID: category.ID, Type: models.TaskTypeTruth, Text: "snake_case or camelCase?", Language: "en"}
+	require.NoError(t, db.Create(underscore).Error)
+
+	handler := handlers.NewSearchHandler(repository.NewCategoryRepository(db), repository.NewTaskRepository(db))
+	router.GET("/admin/search", handler.Search)
+
+	search := func(query string) (int, handlers.SearchResponse) {
+		req, _ := http.NewRequest("GET", "/admin/search?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response handlers.SearchResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	t.Run("groups categories and tasks", func(t *testing.T) {
+		code, response := search("q=category")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, response.Categories, 1)
+		assert.Equal(t, []string{"en"}, response.Categories[0].MatchedLanguages)
+		require.Len(t, response.Tasks, 1)
+		assert.Equal(t, dare.ID, response.Tasks[0].ID)
+		assert.Equal(t, "text", response.Tasks[0].MatchedField)
+		assert.Equal(t, "dare", response.Tasks[0].Type)
+		require.NotNil(t, response.Tasks[0].Category)
+	})
+
+	t.Run("matches hints and reports their language", func(t *testing.T) {
+		_, response := search("q=" + url.QueryEscape("परीक्षण"))
+		require.Len(t, response.Categories, 1)
+		assert.Equal(t, []string{"hi"}, response.Categories[0].MatchedLanguages)
+		require.Len(t, response.Tasks, 1)
+		assert.Equal(t, "hint", response.Tasks[0].MatchedField)
+		assert.Equal(t, "hi", response.Tasks[0].MatchedLanguage)
+	})
+
+	t.Run("matches IDs and treats wildcards literally", func(t *testing.T) {
+		_, response := search("q=" + other.ID)
+		require.Len(t, response.Tasks, 1)
+		assert.Equal(t, "id", response.Tasks[0].MatchedField)
+
+		_, response = search("q=e_c")
+		require.Len(t, response.Tasks, 1)
+		assert.Equal(t, underscore.ID, response.Tasks[0].ID)
+
+		_, response = search("q=en")
+		assert.Empty(t, response.Categories, "language keys are not matched")
+	})
+
+	t.Run("rejects short queries", func(t *testing.T) {
+		code, _ := search("q=a")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestTaskHandler_Neighbors(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	category := seedTestCategory(t, db)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	served := base.Add(time.Hour)
+	var tasks []*models.Task
+	for i, taskType := range []string{models.TaskTypeTruth, models.TaskTypeDare, models.TaskTypeTruth, models.TaskTypeDare} {
+		task := &models.Task{CategoryID: category.ID, Type: taskType, Text: "task " + strconv.Itoa(i), Language: "en"}
+		task.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if i == 2 {
+			task.LastServedAt = &served
+		}
+		require.NoError(t, db.Create(task).Error)
+		tasks = append(tasks, task)
+	}
+
+	handler := handlers.NewTaskHandler(repository.NewTaskRepository(db), repository.NewCategoryRepository(db), nil, nil)
+	router.GET("/tasks", handler.List)
+	router.GET("/tasks/:id/neighbors", handler.Neighbors)
+
+	neighbors := func(id, query string) (int, handlers.TaskNeighborsResponse) {
+		req, _ := http.NewRequest("GET", "/tasks/"+id+"/neighbors?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response handlers.TaskNeighborsResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	id := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	// Walking next from the first task must visit the listing in order
+	for _, query := range []string{"", "sort_by=created_at&sort_order=asc", "sort_by=type", "sort_by=last_served_at&sort_order=asc", "type=dare"} {
+		req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var listing struct {
+			Data []models.TaskResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+
+		current := listing.Data[0].ID
+		_, first := neighbors(current, query)
+		assert.Nil(t, first.PreviousID, query)
+		for i := 1; i < len(listing.Data); i++ {
+			code, response := neighbors(current, query)
+			require.Equal(t, http.StatusOK, code, query)
+			require.Equal(t, listing.Data[i].ID, id(response.NextID), "%s: step %d", query, i)
+			_, back := neighbors(listing.Data[i].ID, query)
+			assert.Equal(t, current, id(back.PreviousID), "%s: step %d back", query, i)
+			current = listing.Data[i].ID
+		}
+		_, last := neighbors(current, query)
+		assert.Nil(t, last.NextID, query)
+	}
+
+	code, _ := neighbors("missing", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestGlossaryHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	handler := handlers.NewGlossaryHandler(repository.NewGlossaryRepository(db))
+	router.GET("/glossary", handler.List)
+	router.POST("/glossary", handler.Create)
+	router.PUT("/glossary/:id", handler.Update)
+	router.DELETE("/glossary/:id", handler.Delete)
+
+	send := func(method, path string, req interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send("POST", "/glossary", handlers.GlossaryTermRequest{
+		Term:         " Truth or Dare ",
+		Translations: models.MultilingualText{"es": "Verdad o Reto"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.GlossaryTerm
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Truth or Dare", created.Term)
+
+	t.Run("duplicate term conflicts", func(t *testing.T) {
+		w := send("POST", "/glossary", handlers.GlossaryTermRequest{Term: "truth or dare"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("invalid translations are rejected", func(t *testing.T) {
+		w := send("POST", "/glossary", handlers.GlossaryTermRequest{Term: "Spin", Translations: models.MultilingualText{"xx": "Spin"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("update keeps its own term", func(t *testing.T) {
+		w := send("PUT", "/glossary/"+created.ID, handlers.GlossaryTermRequest{
+			Term:         "Truth or Dare",
+			Translations: models.MultilingualText{"es": "Verdad o Reto", "fr": "Action ou Vérité"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = send("GET", "/glossary", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []models.GlossaryTerm `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "Action ou Vérité", response.Data[0].Translations["fr"])
+	})
+
+	t.Run("delete frees the term", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("DELETE", "/glossary/"+created.ID, nil).Code)
+		assert.Equal(t, http.StatusNotFound, send("DELETE", "/glossary/"+created.ID, nil).Code)
+		assert.Equal(t, http.StatusCreated, send("POST", "/glossary", handlers.GlossaryTermRequest{Term: "Truth or Dare"}).Code)
+	})
+}
+
+func TestGenerateHandler_LanguageCheck(t *testing.T) {
+	// The mock provider answers in English whatever language is requested
+	for _, tt := range []struct {
+		mode    string
+		created int
+	}{
+		{models.LanguageCheckReject, 0},
+		{models.LanguageCheckFlag, 4},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			db := setupTestDB(t)
+			router := setupTestRouter()
+
+			category := seedTestCategory(t, db)
+			taskRepo := repository.NewTaskRepository(db)
+			handler := handlers.NewGenerateHandler(taskRepo, repository.NewCategoryRepository(db), repository.NewGenerationRunRepository(db), repository.NewGlossaryRepository(db), repository.NewLanguageRepository(db), &config.GenerationConfig{LanguageCheck: tt.mode})
+			router.POST("/generate", handler.Generate)
+
+			body := `{"category_id": "` + category.ID + `", "age_group": "kids", "language": "bn", "count": 2}`
+			req, _ := http.NewRequest("POST", "/generate", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var response handlers.GenerateTasksResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.created, response.TasksCreated)
+			assert.Equal(t, 4, response.Report.LanguageMismatches)
+			require.Len(t, response.Report.Combinations, 1)
+			assert.Equal(t, map[string]int{"en": 4}, response.Report.Combinations[0].LanguageMismatches)
+
+			tasks, _, err := taskRepo.FindAll(&repository.TaskFilter{Language: "bn"})
+			require.NoError(t, err)
+			require.Len(t, tasks, tt.created)
+			for _, task := range tasks {
+				assert.Equal(t, "Language: detected en, expected bn", task.ReviewerNotes)
+			}
+		})
+	}
+}
+
+func TestAttributionHandler(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	taskRepo := repository.NewTaskRepository(db)
+	taskHandler := handlers.NewTaskHandler(taskRepo, repository.NewCategoryRepository(db), nil, nil)
+	router.POST("/tasks", taskHandler.Create)
+	router.GET("/attributions", handlers.NewAttributionHandler(taskRepo).List)
+
+	pack := seedTestCategory(t, db)
+	require.NoError(t, db.Model(pack).Updates(map[string]interface{}{
+		"license":     "CC BY 4.0",
+		"attribution": "Party Pack by Jane Doe",
+	}).Error)
+	own := seedTestCategory(t, db)
+
+	create := func(req handlers.CreateTaskRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("attributed content needs a license", func(t *testing.T) {
+		w := create(handlers.CreateTaskRequest{Text: "Sing a song", Type: "dare", CategoryID: own.ID, Language: "en", Attribution: "Someone"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"license"`)
+	})
+
+	for _, req := range []handlers.CreateTaskRequest{
+		{Text: "Tell a secret", Type: "truth", CategoryID: pack.ID, Language: "en"},
+		{Text: "Do a dance", Type: "dare", CategoryID: pack.ID, Language: "en"},
+		{Text: "Tell a joke", Type: "dare", CategoryID: own.ID, Language: "en", License: "CC0 1.0"},
+		{Text: "Name a fruit", Type: "truth", CategoryID: own.ID, Language: "en"},
+	} {
+		w := create(req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		if req.License != "" {
+			var task models.TaskResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+			assert.Equal(t, req.License, task.License)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "/attributions", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []repository.Attribution `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []repository.Attribution{
+		{License: "CC BY 4.0", Attribution: "Party Pack by Jane Doe", Categories: 1, Tasks: 2},
+		{License: "CC0 1.0", Categories: 1, Tasks: 1},
+	}, response.Data)
+}
+
+func TestTaskImportExport(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+
+	taskRepo := repository.NewTaskRepository(db)
+	taskHandler := handlers.NewTaskHandler(taskRepo, repository.NewCategoryRepository(db), nil, nil)
+	router.GET("/tasks/export", taskHandler.Export)
+	router.POST("/tasks/import", taskHandler.Import)
+
+	category := seedTestCategory(t, db)
+	require.NoError(t, db.Create(&models.Task{Text: "Tell a secret", Type: "truth", CategoryID: category.ID, Language: "en", IsActive: true}).Error)
+
+	importFile := func(query, contentType, body string) (*httptest.ResponseRecorder, handlers.TaskImportResult) {
+		r, _ := http.NewRequest("POST", "/tasks/import"+query, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		var result handlers.TaskImportResult
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		}
+		return w, result
+	}
+	countTasks := func() int64 {
+		var n int64
+		require.NoError(t, db.Model(&models.Task{}).Count(&n).Error)
+		return n
+	}
+
+	csvFile := "text,type,language,category\n" +
+		"Do a dance,dare,en,Test Category\n" +
+		"  tell a SECRET ,truth,en,test category\n" +
+		"Do a dance,dare,en,Test Category\n" +
+		"Sing,dare,xx,Test Category\n" +
+		"Jump,dare,en,Unknown\n" +
+		"\"Say \"\"hi\"\"\",dare,en,Test Category\n"
+
+	t.Run("dry run validates without writing", func(t *testing.T) {
+		w, result := importFile("?dry_run=true", "text/csv", csvFile)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.True(t, result.DryRun)
+		assert.Equal(t, "csv", result.Format)
+		assert.Equal(t, 6, result.Rows)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, 2, result.Duplicates)
+		assert.Equal(t, []int{2, 3}, result.DuplicateRows)
+		assert.Equal(t, 2, result.Invalid)
+		assert.Equal(t, []handlers.TaskImportIssue{
+			{Row: 4, Field: "language", Message: "unsupported language code"},
+			{Row: 5, Field: "category_id", Message: "no category with this ID or English label"},
+		}, result.Errors)
+		assert.EqualValues(t, 1, countTasks())
+	})
+
+	t.Run("import creates the valid rows", func(t *testing.T) {
+		w, result := importFile("", "text/csv", csvFile)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 2, result.Created)
+		assert.EqualValues(t, 3, countTasks())
+
+		w, result = importFile("", "text/csv", csvFile)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, result.Created, "a second import finds only duplicates")
+		assert.Equal(t, 4, result.Duplicates)
+	})
+
+	t.Run("JSON rows with wrong types are invalid", func(t *testing.T) {
+		body := `[{"text": 5, "type": "dare", "language": "en", "category_id": "` + category.ID + `"},
+			{"text": "Hop", "type": "dare", "language": "en", "category_id": "` + category.ID + `", "is_active": false}]`
+		w, result := importFile("", "application/json", body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 2, result.Rows)
+		assert.Equal(t, 1, result.Created)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, handlers.TaskImportIssue{Row: 1, Field: "text", Message: "must be a string"}, result.Errors[0])
+
+		var hop models.Task
+		require.NoError(t, db.Where("text = ?", "Hop").First(&hop).Error)
+		assert.False(t, hop.IsActive)
+	})
+
+	t.Run("malformed file stops the import", func(t *testing.T) {
+		body := `[{"text": "Skip", "type": "dare", "language": "en", "category_id": "` + category.ID + `"}, {"text": `
+		w, _ := importFile("", "application/json", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Row 2 is malformed")
+		assert.Contains(t, w.Body.String(), "1 tasks from earlier rows were created")
+
+		w, _ = importFile("", "text/csv", "text,type\nHi,dare\n")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "header has no language column")
+	})
+
+	t.Run("export round trips", func(t *testing.T) {
+		for _, format := range []string{"json", "csv"} {
+			r, _ := http.NewRequest("GET", "/tasks/export?format="+format+"&language=en", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "."+format)
+
+			// Without the admin header only active tasks are listed
+			var active int64
+			require.NoError(t, db.Model(&models.Task{}).Where("is_active = ?", true).Count(&active).Error)
+			_, result := importFile("?dry_run=true&format="+format, "", w.Body.String())
+			assert.EqualValues(t, active, result.Rows, format)
+			assert.Equal(t, result.Rows, result.Duplicates, format)
+			assert.Empty(t, result.Errors, format)
+		}
+
+		r, _ := http.NewRequest("GET", "/tasks/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		var rows []handlers.TaskTransferRow
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		require.NotEmpty(t, rows)
+		assert.Equal(t, "Test Category", rows[0].Category)
+	})
+
+	t.Run("unknown format is rejected", func(t *testing.T) {
+		r, _ := http.NewRequest("GET", "/tasks/export?format=xml", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
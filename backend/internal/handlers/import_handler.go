@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Import tuning. A batch is committed in one transaction. A running job
+// that saved no progress for importStaleAfter is taken to be abandoned by
+// a server that died, and may be resumed.
+const (
+	importBatchSize  = 1000
+	importStaleAfter = 10 * time.Minute
+	// importMaxLine bounds one record of the file
+	importMaxLine = 1 << 20
+)
+
+// ImportHandler imports NDJSON exports as resumable jobs
+type ImportHandler struct {
+	jobs      *repository.ImportJobRepository
+	snapshots *repository.SnapshotRepository
+	flags     *featureflags.Store
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(jobs *repository.ImportJobRepository, snapshots *repository.SnapshotRepository, flags *featureflags.Store) *ImportHandler {
+	return &ImportHandler{jobs: jobs, snapshots: snapshots, flags: flags}
+}
+
+// importRecord is one line of an import file, its data left raw until its
+// type is known
+type importRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// importFile is an uploaded file spooled to disk
+type importFile struct {
+	path string
+	hash string
+	size int64
+}
+
+// Create godoc
+// @Summary Import an NDJSON export
+// @Description Import a file from GET /export as a job. The whole file is checked first; then it is merged like a snapshot archive, 1000 lines per transaction, with the progress saved after each. A failed or interrupted job is resumed with POST /imports/{id}/resume and the same file; posting a file that was already imported, or is being imported, is a conflict.
+// @Tags admin
+// @Accept x-ndjson
+// @Produce json
+// @Success 200 {object} models.ImportJob
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /imports [post]
+func (h *ImportHandler) Create(c *gin.Context) {
+	file, ok := spoolImport(c)
+	if !ok {
+		return
+	}
+	defer os.Remove(file.path)
+
+	lines, err := checkImportFile(file.path)
+	if err != nil {
+		respondImportFileError(c, err)
+		return
+	}
+
+	existing, err := h.jobs.FindByHash(file.hash)
+	if err == nil {
+		message := "This file was already imported by job " + existing.ID
+		if existing.Status != models.ImportCompleted {
+			message = "This file is being imported by job " + existing.ID + "; resume it with POST /imports/" + existing.ID + "/resume"
+		}
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: message,
+		})
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to look up import jobs",
+		})
+		return
+	}
+
+	job := &models.ImportJob{
+		FileHash:    file.hash,
+		FileSize:    file.size,
+		Lines:       lines,
+		Status:      models.ImportRunning,
+		Batches:     []models.ImportBatch{},
+		CategoryIDs: map[string]string{},
+	}
+	if err := h.jobs.Create(job); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record import job",
+		})
+		return
+	}
+
+	h.run(c.Request.Context(), job, file.path)
+	c.JSON(http.StatusOK, job)
+}
+
+// Resume godoc
+// @Summary Resume an import job
+// @Description Continue a failed or interrupted import after its last committed batch. The body must be the same file the job started with, checked by its SHA-256 hash.
+// @Tags admin
+// @Accept x-ndjson
+// @Produce json
+// @Param id path string true "Import job ID"
+// @Success 200 {object} models.ImportJob
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /imports/{id}/resume [post]
+func (h *ImportHandler) Resume(c *gin.Context) {
+	job, err := h.jobs.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Import job not found",
+		})
+		return
+	}
+	if job.Status == models.ImportCompleted {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Import job is already completed",
+		})
+		return
+	}
+
+	file, ok := spoolImport(c)
+	if !ok {
+		return
+	}
+	defer os.Remove(file.path)
+
+	if file.hash != job.FileHash {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "The file differs from the one the import job started with",
+		})
+		return
+	}
+
+	claimed, err := h.jobs.Claim(job.ID, importStaleAfter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to resume import job",
+		})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Import job is still running",
+		})
+		return
+	}
+	job.Status = models.ImportRunning
+	job.Error = ""
+
+	log.Info().Str("job_id", job.ID).Int("offset", job.Offset).Msg("Resuming import")
+	h.run(c.Request.Context(), job, file.path)
+	c.JSON(http.StatusOK, job)
+}
+
+// Get godoc
+// @Summary Get an import job
+// @Description Progress of an import job: lines committed, row counts and the outcome of each batch
+// @Tags admin
+// @Produce json
+// @Param id path string true "Import job ID"
+// @Success 200 {object} models.ImportJob
+// @Failure 404 {object} models.ErrorResponse
+// @Router /imports/{id} [get]
+func (h *ImportHandler) Get(c *gin.Context) {
+	job, err := h.jobs.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Import job not found",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// List godoc
+// @Summary List import jobs
+// @Description List the most recent import jobs, newest first
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Number of jobs (max 100)" default(20)
+// @Success 200 {object} []models.ImportJob
+// @Failure 500 {object} models.ErrorResponse
+// @Router /imports [get]
+func (h *ImportHandler) List(c *gin.Context) {
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	jobs, err := h.jobs.FindRecent(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch import jobs",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// spoolImport copies the request body to a temporary file, hashing it on
+// the way, so large files are never held in memory
+func spoolImport(c *gin.Context) (*importFile, bool) {
+	tmp, err := os.CreateTemp("", "tod-import-*.ndjson")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to buffer import file",
+		})
+		return nil, false
+	}
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), c.Request.Body)
+	if err != nil {
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Failed to read import file",
+		})
+		return nil, false
+	}
+	if size == 0 {
+		os.Remove(tmp.Name())
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Import file is empty",
+		})
+		return nil, false
+	}
+	return &importFile{path: tmp.Name(), hash: hex.EncodeToString(hash.Sum(nil)), size: size}, true
+}
+
+// importFileError is a problem found in an import file; unsupported marks
+// files from another format or a newer schema
+type importFileError struct {
+	line        int
+	message     string
+	unsupported bool
+}
+
+func (e *importFileError) Error() string {
+	if e.line == 0 {
+		return e.message
+	}
+	return fmt.Sprintf("line %d: %s", e.line, e.message)
+}
+
+// respondImportFileError answers with the problem checkImportFile found
+func respondImportFileError(c *gin.Context, err error) {
+	var fileErr *importFileError
+	if !errors.As(err, &fileErr) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to read import file",
+		})
+		return
+	}
+	code := "invalid_snapshot"
+	if fileErr.unsupported {
+		code = "unsupported_snapshot"
+	}
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   code,
+		Message: fileErr.Error(),
+	})
+}
+
+// eachImportLine calls fn with every non-blank line of the file and its
+// 1-based number
+func eachImportLine(path string, fn func(line int, record importRecord) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), importMaxLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record importRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return &importFileError{line: line, message: "not a JSON record"}
+		}
+		if err := fn(line, record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return &importFileError{line: line + 1, message: "record too long"}
+		}
+		return err
+	}
+	return nil
+}
+
+// checkImportFile reads the whole file before anything is imported: it
+// must open with a supported header, hold only valid records and end with
+// the end record, or it was cut short. It returns the number of lines.
+func checkImportFile(path string) (int, error) {
+	lines := 0
+	last := ""
+	err := eachImportLine(path, func(line int, record importRecord) error {
+		lines = line
+		switch {
+		case last == "" && record.Type != "header":
+			return &importFileError{line: line, message: "the file must start with a header record"}
+		case last != "" && record.Type == "header":
+			return &importFileError{line: line, message: "a second header record"}
+		case last == "end":
+			return &importFileError{line: line, message: "records after the end record"}
+		}
+		last = record.Type
+
+		switch record.Type {
+		case "header":
+			var header ExportHeader
+			if err := json.Unmarshal(record.Data, &header); err != nil {
+				return &importFileError{line: line, message: "invalid header"}
+			}
+			if header.FormatVersion != repository.SnapshotFormatVersion {
+				return &importFileError{line: line, unsupported: true, message: fmt.Sprintf("format version %d is not supported (expected %d)", header.FormatVersion, repository.SnapshotFormatVersion)}
+			}
+			if header.SchemaVersion > database.SchemaVersion {
+				return &importFileError{line: line, unsupported: true, message: fmt.Sprintf("exported at schema version %d, newer than this build (%d)", header.SchemaVersion, database.SchemaVersion)}
+			}
+		case "category", "task":
+			snapshot, err := decodeImportRows(record)
+			if err != nil {
+				return &importFileError{line: line, message: "invalid " + record.Type}
+			}
+			if errs := validateSnapshot(snapshot); len(errs) > 0 {
+				field := errs[0].Field[strings.Index(errs[0].Field, ".")+1:]
+				return &importFileError{line: line, message: field + " " + errs[0].Message}
+			}
+		case "feature_flag", "end":
+		default:
+			return &importFileError{line: line, message: "unknown record type " + record.Type}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if last != "end" {
+		return 0, &importFileError{message: "the file does not end with an end record; it was cut short"}
+	}
+	return lines, nil
+}
+
+// decodeImportRows decodes a category or task record as a one-row snapshot;
+// validateSnapshot normalizes it
+func decodeImportRows(record importRecord) (*repository.Snapshot, error) {
+	snapshot := &repository.Snapshot{}
+	if record.Type == "category" {
+		var category repository.SnapshotCategory
+		if err := json.Unmarshal(record.Data, &category); err != nil {
+			return nil, err
+		}
+		snapshot.Categories = []repository.SnapshotCategory{category}
+		return snapshot, nil
+	}
+	var task repository.SnapshotTask
+	if err := json.Unmarshal(record.Data, &task); err != nil {
+		return nil, err
+	}
+	snapshot.Tasks = []repository.SnapshotTask{task}
+	return snapshot, nil
+}
+
+// importBatch collects the records of one batch
+type importBatch struct {
+	models.ImportBatch
+	snapshot repository.Snapshot
+	started  time.Time
+}
+
+// run imports the file from the line after job.Offset, one batch per
+// transaction, saving the job after each. It stops at the first failing
+// batch, or when ctx is done, leaving the job failed and resumable.
+func (h *ImportHandler) run(ctx context.Context, job *models.ImportJob, path string) {
+	if job.CategoryIDs == nil {
+		job.CategoryIDs = map[string]string{}
+	}
+
+	var batch *importBatch
+	commit := func() error {
+		if batch == nil {
+			return nil
+		}
+		defer func() { batch = nil }()
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("import interrupted: %w", err)
+		}
+		return h.commitBatch(job, batch)
+	}
+
+	err := eachImportLine(path, func(line int, record importRecord) error {
+		if line <= job.Offset {
+			return nil
+		}
+		if batch == nil {
+			batch = &importBatch{started: time.Now()}
+			batch.Index = len(job.Batches)
+			batch.FirstLine = line
+		}
+		batch.LastLine = line
+
+		switch record.Type {
+		case "category", "task":
+			rows, err := decodeImportRows(record)
+			if err != nil {
+				return err
+			}
+			// Checked before the job started; called for the emoji
+			// normalization
+			validateSnapshot(rows)
+			batch.snapshot.Categories = append(batch.snapshot.Categories, rows.Categories...)
+			batch.snapshot.Tasks = append(batch.snapshot.Tasks, rows.Tasks...)
+		case "feature_flag":
+			var flag models.FeatureFlag
+			if err := json.Unmarshal(record.Data, &flag); err != nil {
+				return err
+			}
+			batch.snapshot.FeatureFlags = append(batch.snapshot.FeatureFlags, flag)
+		}
+
+		if line-batch.FirstLine+1 >= importBatchSize {
+			return commit()
+		}
+		return nil
+	})
+	if err == nil {
+		err = commit()
+	}
+
+	if err != nil {
+		job.Status = models.ImportFailed
+		job.Error = err.Error()
+		log.Error().Err(err).Str("job_id", job.ID).Int("offset", job.Offset).Msg("Import failed")
+	} else {
+		now := time.Now().UTC()
+		job.Status = models.ImportCompleted
+		job.FinishedAt = &now
+		log.Warn().
+			Str("job_id", job.ID).
+			Int("categories_created", job.CategoriesCreated).
+			Int("tasks_created", job.TasksCreated).
+			Int("tasks_updated", job.TasksUpdated).
+			Msg("Import completed")
+	}
+	if err := h.jobs.Save(job); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Failed to save import job")
+	}
+}
+
+// commitBatch imports one batch and records its outcome on the job. The
+// job is saved with the new offset right after the batch commits.
+func (h *ImportHandler) commitBatch(job *models.ImportJob, batch *importBatch) error {
+	result, err := h.snapshots.ImportBatch(batch.snapshot.Categories, batch.snapshot.Tasks, job.CategoryIDs)
+	if err == nil {
+		err = h.applyFlags(job, batch.snapshot.FeatureFlags)
+	}
+	batch.DurationMs = time.Since(batch.started).Milliseconds()
+	if err != nil {
+		batch.Status = models.ImportFailed
+		batch.Error = err.Error()
+		job.Batches = append(job.Batches, batch.ImportBatch)
+		return fmt.Errorf("batch %d (lines %d-%d): %w", batch.Index, batch.FirstLine, batch.LastLine, err)
+	}
+
+	batch.Status = models.ImportCompleted
+	batch.Created = result.Categories.Created + result.Tasks.Created
+	batch.Updated = result.Categories.Updated + result.Tasks.Updated
+	job.Batches = append(job.Batches, batch.ImportBatch)
+	job.Offset = batch.LastLine
+	job.CategoriesCreated += result.Categories.Created
+	job.CategoriesUpdated += result.Categories.Updated
+	job.TasksCreated += result.Tasks.Created
+	job.TasksUpdated += result.Tasks.Updated
+	for source, id := range result.CategoryIDs {
+		job.CategoryIDs[source] = id
+	}
+	return h.jobs.Save(job)
+}
+
+// applyFlags sets the feature flag overrides of a batch, skipping flags
+// this build does not know
+func (h *ImportHandler) applyFlags(job *models.ImportJob, flags []models.FeatureFlag) error {
+	for _, flag := range flags {
+		_, err := h.flags.Set(flag.Name, flag.Enabled, flag.RolloutPercent)
+		if errors.Is(err, featureflags.ErrUnknownFlag) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("feature flag %s: %w", flag.Name, err)
+		}
+		job.FlagsApplied++
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// languageCodePattern accepts codes such as en, zh or zh-CN
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// LanguageHandler handles bulk language administration
+type LanguageHandler struct {
+	repo *repository.LanguageRepository
+}
+
+// NewLanguageHandler creates a new LanguageHandler
+func NewLanguageHandler(repo *repository.LanguageRepository) *LanguageHandler {
+	return &LanguageHandler{repo: repo}
+}
+
+// PruneLanguageRequest represents the request body for removing a language
+type PruneLanguageRequest struct {
+	Code   string `json:"code" binding:"required"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// RenameLanguageRequest represents the request body for renaming a language code
+type RenameLanguageRequest struct {
+	From   string `json:"from" binding:"required"`
+	To     string `json:"to" binding:"required"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// FreezeLanguageRequest represents the request body for freezing a language
+type FreezeLanguageRequest struct {
+	Code   string `json:"code" binding:"required"`
+	Reason string `json:"reason,omitempty" binding:"max=200"`
+}
+
+// UnfreezeLanguageRequest represents the request body for lifting a freeze
+type UnfreezeLanguageRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// LanguageChangeResponse reports the rows changed, or that would change on a dry run
+type LanguageChangeResponse struct {
+	DryRun  bool                              `json:"dry_run"`
+	Changes []repository.LanguageColumnChange `json:"changes"`
+}
+
+// Prune godoc
+// @Summary Remove a language from all translations
+// @Description Remove a language key from every multilingual column (category labels, task hints), e.g. when a locale is deprecated. English cannot be removed since it is the fallback. Use dry_run to count affected rows first.
+// @Tags languages
+// @Accept json
+// @Produce json
+// @Param request body PruneLanguageRequest true "Language to remove"
+// @Success 200 {object} LanguageChangeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /languages/prune [post]
+func (h *LanguageHandler) Prune(c *gin.Context) {
+	var req PruneLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if !validLanguageCode(c, req.Code) {
+		return
+	}
+	if req.Code == "en" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "English is the fallback language and cannot be removed",
+		})
+		return
+	}
+
+	changes, err := h.repo.Prune(req.Code, req.DryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to remove language",
+		})
+		return
+	}
+
+	if !req.DryRun {
+		log.Warn().Str("code", req.Code).Interface("changes", changes).Str("ip", c.ClientIP()).Msg("Language removed from translations")
+	}
+
+	c.JSON(http.StatusOK, LanguageChangeResponse{DryRun: req.DryRun, Changes: changes})
+}
+
+// Rename godoc
+// @Summary Rename a language code in all translations
+// @Description Move texts from one language code to another (e.g. zh to zh-CN) in every multilingual column. Rows already holding the new code keep their text and are reported as conflicts. Use dry_run to count affected rows first.
+// @Tags languages
+// @Accept json
+// @Produce json
+// @Param request body RenameLanguageRequest true "Codes to rename"
+// @Success 200 {object} LanguageChangeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /languages/rename [post]
+func (h *LanguageHandler) Rename(c *gin.Context) {
+	var req RenameLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if !validLanguageCode(c, req.From) || !validLanguageCode(c, req.To) {
+		return
+	}
+	if req.From == req.To {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "from and to must differ",
+		})
+		return
+	}
+
+	changes, err := h.repo.Rename(req.From, req.To, req.DryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to rename language",
+		})
+		return
+	}
+
+	if !req.DryRun {
+		log.Warn().Str("from", req.From).Str("to", req.To).Interface("changes", changes).Str("ip", c.ClientIP()).Msg("Language renamed in translations")
+	}
+
+	c.JSON(http.StatusOK, LanguageChangeResponse{DryRun: req.DryRun, Changes: changes})
+}
+
+// ListFreezes godoc
+// @Summary List frozen languages
+// @Description Get the languages frozen for AI generation and machine translation
+// @Tags languages
+// @Produce json
+// @Success 200 {object} map[string][]models.LanguageFreeze
+// @Failure 500 {object} models.ErrorResponse
+// @Router /languages/freezes [get]
+func (h *LanguageHandler) ListFreezes(c *gin.Context) {
+	freezes, err := h.repo.FindFreezes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch frozen languages",
+		})
+		return
+	}
+	if freezes == nil {
+		freezes = []models.LanguageFreeze{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": freezes})
+}
+
+// Freeze godoc
+// @Summary Freeze a language
+// @Description Stop AI generation, machine translation and hint generation into a language, e.g. while its content is reviewed by hand. Existing content is still served and hand-written content can still be added. Freezing a frozen language replaces the reason.
+// @Tags languages
+// @Accept json
+// @Produce json
+// @Param request body FreezeLanguageRequest true "Language to freeze"
+// @Success 200 {object} models.LanguageFreeze
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /languages/freeze [post]
+func (h *LanguageHandler) Freeze(c *gin.Context) {
+	var req FreezeLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !models.IsValidLanguage(req.Code) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_language",
+			Message: "Invalid language code: " + req.Code,
+		})
+		return
+	}
+
+	freeze := &models.LanguageFreeze{Language: req.Code, Reason: req.Reason}
+	if err := h.repo.Freeze(freeze); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to freeze language",
+		})
+		return
+	}
+
+	log.Warn().Str("code", req.Code).Str("reason", req.Reason).Str("ip", c.ClientIP()).Msg("Language frozen")
+
+	c.JSON(http.StatusOK, freeze)
+}
+
+// Unfreeze godoc
+// @Summary Unfreeze a language
+// @Description Allow AI generation and machine translation into a frozen language again
+// @Tags languages
+// @Accept json
+// @Produce json
+// @Param request body UnfreezeLanguageRequest true "Language to unfreeze"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /languages/unfreeze [post]
+func (h *LanguageHandler) Unfreeze(c *gin.Context) {
+	var req UnfreezeLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	unfrozen, err := h.repo.Unfreeze(req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to unfreeze language",
+		})
+		return
+	}
+	if !unfrozen {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Language is not frozen: " + req.Code,
+		})
+		return
+	}
+
+	log.Warn().Str("code", req.Code).Str("ip", c.ClientIP()).Msg("Language unfrozen")
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Language unfrozen successfully",
+	})
+}
+
+// checkNotFrozen responds with a conflict when any of languages is frozen
+// for AI generation and machine translation
+func checkNotFrozen(c *gin.Context, repo *repository.LanguageRepository, languages ...string) bool {
+	frozen, err := repo.FrozenLanguages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to load frozen languages",
+		})
+		return false
+	}
+	for _, lang := range languages {
+		if frozen[lang] {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "language_frozen",
+				Message: "Language is frozen for AI generation and machine translation: " + lang,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// validLanguageCode rejects malformed language codes
+func validLanguageCode(c *gin.Context, code string) bool {
+	if !languageCodePattern.MatchString(code) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_language",
+			Message: "Invalid language code: " + code,
+		})
+		return false
+	}
+	return true
+}
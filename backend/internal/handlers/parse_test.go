@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func FuzzSplitAndTrim(f *testing.F) {
+	f.Add("en,hi,ur")
+	f.Add(" en , hi ,, ")
+	f.Add(",,,")
+	f.Add("")
+	f.Add("\t\n, ")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		parts := splitAndTrim(input)
+		for _, part := range parts {
+			if part == "" {
+				t.Fatalf("empty element in %q", parts)
+			}
+			if strings.Contains(part, ",") {
+				t.Fatalf("element %q contains a separator", part)
+			}
+			if part != strings.TrimSpace(part) {
+				t.Fatalf("element %q is not trimmed", part)
+			}
+		}
+		if len(parts) > strings.Count(input, ",")+1 {
+			t.Fatalf("got %d elements from %d separators", len(parts), strings.Count(input, ","))
+		}
+	})
+}
+
+func FuzzParseTimeParam(f *testing.F) {
+	f.Add("2024-01-01T00:00:00Z")
+	f.Add("2024-01-01T00:00:00+05:30")
+	f.Add("2024-01-01")
+	f.Add("9999-12-31T23:59:59.999999999Z")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		parsed := parseTimeParam(input)
+		if parsed == nil {
+			return
+		}
+		// Anything accepted must be a valid RFC3339 timestamp
+		if _, err := time.Parse(time.RFC3339, parsed.Format(time.RFC3339Nano)); err != nil {
+			t.Fatalf("accepted %q but cannot re-parse: %v", input, err)
+		}
+	})
+}
+
+func FuzzParseNonNegativeInt(f *testing.F) {
+	f.Add("10")
+	f.Add("-1")
+	f.Add("0")
+	f.Add("99999999999999999999")
+	f.Add("1e3")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		if val := parseNonNegativeInt(input); val < 0 {
+			t.Fatalf("parseNonNegativeInt(%q) = %d", input, val)
+		}
+	})
+}
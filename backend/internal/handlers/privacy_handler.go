@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// maxClientIDLength matches the client_id columns
+const maxClientIDLength = 64
+
+// PrivacyHandler handles data export and deletion requests for clients
+type PrivacyHandler struct {
+	repo *repository.PrivacyRepository
+}
+
+// NewPrivacyHandler creates a new PrivacyHandler
+func NewPrivacyHandler(repo *repository.PrivacyRepository) *PrivacyHandler {
+	return &PrivacyHandler{repo: repo}
+}
+
+// PrivacyDeleteResponse reports the rows removed per table
+type PrivacyDeleteResponse struct {
+	ClientID string           `json:"client_id"`
+	Deleted  map[string]int64 `json:"deleted"`
+}
+
+// clientIDParam validates the client ID path parameter
+func clientIDParam(c *gin.Context) (string, bool) {
+	clientID := c.Param("id")
+	if clientID == "" || len(clientID) > maxClientIDLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Client ID must be between 1 and 64 characters",
+		})
+		return "", false
+	}
+	return clientID, true
+}
+
+// Export godoc
+// @Summary Export client data
+// @Description Export every stored row tied to a client identifier (task reports and consent records). Telemetry is aggregated without identifiers and holds no client rows. The request is recorded in the privacy audit trail.
+// @Tags privacy
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} repository.ClientExport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /privacy/clients/{id}/export [get]
+func (h *PrivacyHandler) Export(c *gin.Context) {
+	clientID, ok := clientIDParam(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.repo.Export(clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to export client data",
+		})
+		return
+	}
+
+	if err := h.repo.RecordAudit(models.PrivacyActionExport, clientID, export.Counts()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record audit entry",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="client-export.json"`)
+	c.JSON(http.StatusOK, export)
+}
+
+// Delete godoc
+// @Summary Delete client data
+// @Description Permanently delete every stored row tied to a client identifier, including soft-deleted rows. The deletion is recorded in the privacy audit trail with a hash of the identifier.
+// @Tags privacy
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} PrivacyDeleteResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /privacy/clients/{id} [delete]
+func (h *PrivacyHandler) Delete(c *gin.Context) {
+	clientID, ok := clientIDParam(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.repo.Delete(clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to delete client data",
+		})
+		return
+	}
+
+	if err := h.repo.RecordAudit(models.PrivacyActionDelete, clientID, deleted); err != nil {
+		// The data is gone; a missing audit entry must still be investigated
+		log.Error().Err(err).Str("client_id_hash", repository.HashClientID(clientID)).Msg("Failed to record privacy deletion audit entry")
+	}
+
+	log.Info().Str("client_id_hash", repository.HashClientID(clientID)).Interface("deleted", deleted).Msg("Client data deleted")
+
+	c.JSON(http.StatusOK, PrivacyDeleteResponse{
+		ClientID: clientID,
+		Deleted:  deleted,
+	})
+}
+
+// Audit godoc
+// @Summary List privacy audit entries
+// @Description Get the privacy audit trail, newest first. Filter by client_id to check requests for one client.
+// @Tags privacy
+// @Produce json
+// @Param client_id query string false "Client ID"
+// @Param limit query int false "Limit results (default 50)"
+// @Param offset query int false "Offset for pagination"
+// @Success 200 {object} models.PaginatedResponse[models.PrivacyAudit]
+// @Failure 500 {object} models.ErrorResponse
+// @Router /privacy/audit [get]
+func (h *PrivacyHandler) Audit(c *gin.Context) {
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = 50
+	}
+	offset := parseNonNegativeInt(c.Query("offset"))
+
+	audits, total, err := h.repo.FindAudits(c.Query("client_id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch audit entries",
+		})
+		return
+	}
+
+	totalPages := 1
+	if total > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+	c.JSON(http.StatusOK, models.PaginatedResponse[models.PrivacyAudit]{
+		Data:       audits,
+		Total:      total,
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	})
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// notifyTimeout bounds delivery of a single admin notification
+const notifyTimeout = 10 * time.Second
+
+// ReportHandler handles player reports and the moderation review queue
+type ReportHandler struct {
+	taskRepo   *repository.TaskRepository
+	reportRepo *repository.ReportRepository
+	cfg        *config.ModerationConfig
+	notifier   notify.Notifier
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(
+	taskRepo *repository.TaskRepository,
+	reportRepo *repository.ReportRepository,
+	cfg *config.ModerationConfig,
+	notifier notify.Notifier,
+) *ReportHandler {
+	return &ReportHandler{
+		taskRepo:   taskRepo,
+		reportRepo: reportRepo,
+		cfg:        cfg,
+		notifier:   notifier,
+	}
+}
+
+// ReportTaskRequest represents the request body for reporting a task
+type ReportTaskRequest struct {
+	Reason   string `json:"reason" binding:"required"`
+	Comment  string `json:"comment,omitempty" binding:"max=500"`
+	ClientID string `json:"client_id,omitempty" binding:"max=64"`
+}
+
+// ReportTaskResponse is returned after a report is recorded
+type ReportTaskResponse struct {
+	TaskID      string `json:"task_id"`
+	Reports     int64  `json:"reports"`
+	Deactivated bool   `json:"deactivated"`
+}
+
+// ReportedTaskResponse is a task in the review queue with its open reports
+type ReportedTaskResponse struct {
+	models.TaskResponse
+	OpenReports int64 `json:"open_reports"`
+}
+
+// Report godoc
+// @Summary Report a task
+// @Description Report a task as offensive, inappropriate or otherwise broken. When a task collects REPORT_THRESHOLD unresolved reports within REPORT_WINDOW_HOURS it is deactivated, queued for review and admins are notified. A client_id may report a task once per window.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body ReportTaskRequest true "Report"
+// @Success 201 {object} ReportTaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/report [post]
+func (h *ReportHandler) Report(c *gin.Context) {
+	id := c.Param("id")
+
+	var req ReportTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if !models.IsValidReportReason(req.Reason) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid reason. Must be one of: offensive, inappropriate, wrong_language, duplicate, other",
+		})
+		return
+	}
+
+	task, err := h.taskRepo.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	since := time.Now().Add(-time.Duration(h.cfg.ReportWindowHours) * time.Hour)
+
+	if req.ClientID != "" {
+		duplicate, err := h.reportRepo.HasOpenFromClient(task.ID, req.ClientID, since)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to record report",
+			})
+			return
+		}
+		if duplicate {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "already_reported",
+				Message: "This task has already been reported from this client",
+			})
+			return
+		}
+	}
+
+	report := &models.TaskReport{
+		TaskID:   task.ID,
+		ClientID: req.ClientID,
+		Reason:   req.Reason,
+		Comment:  req.Comment,
+	}
+	if err := h.reportRepo.Create(report); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record report",
+		})
+		return
+	}
+
+	count, err := h.reportRepo.CountOpenSince(task.ID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to count reports",
+		})
+		return
+	}
+
+	resp := ReportTaskResponse{TaskID: task.ID, Reports: count}
+
+	// Deactivate only on the report that crosses the threshold, so admins are
+	// notified once and a reinstated task needs a fresh set of reports
+	threshold := int64(h.cfg.ReportThreshold)
+	if threshold > 0 && count >= threshold && task.IsActive {
+		if err := h.taskRepo.SetModeration(task.ID, false, models.ReviewStatePending); err != nil {
+			log.Error().Err(err).Str("task_id", task.ID).Msg("Failed to deactivate reported task")
+		} else {
+			resp.Deactivated = true
+			h.notifyDeactivated(task, count)
+		}
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// notifyDeactivated tells admins a task was pulled from rotation. Delivery
+// happens in the background so a slow webhook never delays the reporter.
+func (h *ReportHandler) notifyDeactivated(task *models.Task, reports int64) {
+	event := notify.Event{
+		Type:    notify.EventTaskDeactivated,
+		Message: "Task deactivated after player reports and queued for review",
+		Fields: map[string]string{
+			"task_id":     task.ID,
+			"category_id": task.CategoryID,
+			"language":    task.Language,
+			"reports":     strconv.FormatInt(reports, 10),
+		},
+		Time: time.Now().UTC(),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := h.notifier.Notify(ctx, event); err != nil {
+			log.Error().Err(err).Str("task_id", task.ID).Msg("Failed to notify admins")
+		}
+	}()
+}
+
+// ListReported godoc
+// @Summary List tasks pending review
+// @Description Get tasks deactivated by player reports that are waiting for review, with their open report counts
+// @Tags moderation
+// @Produce json
+// @Param limit query int false "Limit results (default 50)"
+// @Param offset query int false "Offset for pagination"
+// @Success 200 {object} models.PaginatedResponse[ReportedTaskResponse]
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/reported [get]
+func (h *ReportHandler) ListReported(c *gin.Context) {
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = 50
+	}
+	offset := parseNonNegativeInt(c.Query("offset"))
+
+	// Pending generated tasks stay active; reported ones were deactivated
+	inactive := false
+	tasks, total, err := h.taskRepo.FindAll(&repository.TaskFilter{
+		IsActive:    &inactive,
+		ReviewState: models.ReviewStatePending,
+		SortBy:      "updated_at",
+		SortOrder:   "asc",
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch reported tasks",
+		})
+		return
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	counts, err := h.reportRepo.CountOpenByTask(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to count reports",
+		})
+		return
+	}
+
+	data := make([]ReportedTaskResponse, len(tasks))
+	for i, task := range tasks {
+		data[i] = ReportedTaskResponse{
+			TaskResponse: mapperFor(c).task(&task),
+			OpenReports:  counts[task.ID],
+		}
+	}
+
+	totalPages := 1
+	if total > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+	c.JSON(http.StatusOK, models.PaginatedResponse[ReportedTaskResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	})
+}
+
+// ListReports godoc
+// @Summary List open reports for a task
+// @Description Get the unresolved player reports for a task, newest first
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} map[string][]models.TaskReport
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/reports [get]
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	task, err := h.taskRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	reports, err := h.reportRepo.FindOpenByTask(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch reports",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": reports})
+}
+
+// Reinstate godoc
+// @Summary Reinstate a reported task
+// @Description Reactivate a task, mark it as approved and resolve its open reports
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} models.TaskResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/reinstate [post]
+func (h *ReportHandler) Reinstate(c *gin.Context) {
+	task, err := h.taskRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	if err := h.reportRepo.ResolveForTask(task.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to resolve reports",
+		})
+		return
+	}
+
+	if err := h.taskRepo.SetModeration(task.ID, true, models.ReviewStateApproved); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to reinstate task",
+		})
+		return
+	}
+
+	task.IsActive = true
+	task.ReviewState = models.ReviewStateApproved
+
+	log.Info().Str("task_id", task.ID).Msg("Reported task reinstated")
+
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
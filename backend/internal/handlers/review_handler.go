@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// ReviewHandler handles the moderators' review inbox: listing pending
+// tasks, claiming and releasing them, and recording review decisions.
+type ReviewHandler struct {
+	taskRepo   *repository.TaskRepository
+	reportRepo *repository.ReportRepository
+}
+
+// NewReviewHandler creates a new ReviewHandler
+func NewReviewHandler(taskRepo *repository.TaskRepository, reportRepo *repository.ReportRepository) *ReviewHandler {
+	return &ReviewHandler{
+		taskRepo:   taskRepo,
+		reportRepo: reportRepo,
+	}
+}
+
+// ClaimRequest identifies the reviewer claiming or releasing a task
+type ClaimRequest struct {
+	Reviewer string `json:"reviewer" binding:"required,max=64"`
+	// Force releases a task held by another reviewer
+	Force bool `json:"force,omitempty"`
+}
+
+// ReviewRequest records a review decision
+type ReviewRequest struct {
+	Reviewer string `json:"reviewer" binding:"required,max=64"`
+	State    string `json:"state" binding:"required,oneof=approved rejected"`
+	Notes    string `json:"notes,omitempty" binding:"max=2000"`
+}
+
+// VerifyLanguageRequest records a human check of a machine generated language
+type VerifyLanguageRequest struct {
+	Reviewer string `json:"reviewer" binding:"required,max=64"`
+	Language string `json:"language" binding:"required"`
+}
+
+// Inbox godoc
+// @Summary List the review inbox
+// @Description Get tasks awaiting review, oldest first. Filter by reviewer to see your own queue, or by unassigned to pick up new work.
+// @Tags moderation
+// @Produce json
+// @Param review_state query string false "Review state (pending, approved, rejected); default pending"
+// @Param assigned_to query string false "Only tasks claimed by this reviewer"
+// @Param unassigned query bool false "Only tasks no reviewer has claimed"
+// @Param category_id query string false "Category ID filter"
+// @Param language query string false "Language code"
+// @Param type query string false "Task type (truth, dare)"
+// @Param limit query int false "Limit results (default 50)"
+// @Param offset query int false "Offset for pagination"
+// @Success 200 {object} models.PaginatedResponse[models.TaskResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/inbox [get]
+func (h *ReviewHandler) Inbox(c *gin.Context) {
+	filter := &repository.TaskFilter{
+		ReviewState: models.ReviewStatePending,
+		CategoryID:  c.Query("category_id"),
+		Language:    c.Query("language"),
+		Type:        c.Query("type"),
+		AssignedTo:  strings.TrimSpace(c.Query("assigned_to")),
+		SortBy:      "created_at",
+		SortOrder:   "asc",
+	}
+
+	if state := c.Query("review_state"); state != "" {
+		if !models.IsValidReviewState(state) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "Invalid review_state. Must be one of: pending, approved, rejected",
+			})
+			return
+		}
+		filter.ReviewState = state
+	}
+	filter.Unassigned = c.Query("unassigned") == "true"
+
+	filter.Limit = parseNonNegativeInt(c.Query("limit"))
+	if filter.Limit == 0 {
+		filter.Limit = 50
+	}
+	filter.Offset = parseNonNegativeInt(c.Query("offset"))
+
+	tasks, total, err := h.taskRepo.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch review inbox",
+		})
+		return
+	}
+
+	data := make([]models.TaskResponse, len(tasks))
+	for i, task := range tasks {
+		data[i] = mapperFor(c).task(&task)
+	}
+
+	totalPages := 1
+	if total > 0 {
+		totalPages = int((total + int64(filter.Limit) - 1) / int64(filter.Limit))
+	}
+	c.JSON(http.StatusOK, models.PaginatedResponse[models.TaskResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Offset/filter.Limit + 1,
+		PageSize:   filter.Limit,
+		TotalPages: totalPages,
+	})
+}
+
+// Translations godoc
+// @Summary List machine generated translations
+// @Description Get tasks whose text or hint in a language was machine generated and not yet verified by a human, oldest first
+// @Tags moderation
+// @Produce json
+// @Param language query string true "Language code"
+// @Param category_id query string false "Category ID filter"
+// @Param type query string false "Task type (truth, dare)"
+// @Param limit query int false "Limit results (default 50)"
+// @Param offset query int false "Offset for pagination"
+// @Success 200 {object} models.PaginatedResponse[models.TaskResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/translations [get]
+func (h *ReviewHandler) Translations(c *gin.Context) {
+	language := c.Query("language")
+	if !models.IsValidLanguage(language) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid or missing language code: " + language,
+		})
+		return
+	}
+
+	filter := &repository.TaskFilter{
+		MachineLanguage: language,
+		CategoryID:      c.Query("category_id"),
+		Type:            c.Query("type"),
+		SortBy:          "created_at",
+		SortOrder:       "asc",
+	}
+	filter.Limit = parseNonNegativeInt(c.Query("limit"))
+	if filter.Limit == 0 {
+		filter.Limit = 50
+	}
+	filter.Offset = parseNonNegativeInt(c.Query("offset"))
+
+	tasks, total, err := h.taskRepo.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch translations",
+		})
+		return
+	}
+
+	data := make([]models.TaskResponse, len(tasks))
+	for i, task := range tasks {
+		data[i] = mapperFor(c).task(&task)
+	}
+
+	totalPages := 1
+	if total > 0 {
+		totalPages = int((total + int64(filter.Limit) - 1) / int64(filter.Limit))
+	}
+	c.JSON(http.StatusOK, models.PaginatedResponse[models.TaskResponse]{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Offset/filter.Limit + 1,
+		PageSize:   filter.Limit,
+		TotalPages: totalPages,
+	})
+}
+
+// VerifyLanguage godoc
+// @Summary Verify a machine generated language
+// @Description Mark a task's machine generated text or hint in a language as verified by a human, removing it from the translations listing. The task's own review state is unchanged.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body VerifyLanguageRequest true "Reviewer and language"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/verify-language [post]
+func (h *ReviewHandler) VerifyLanguage(c *gin.Context) {
+	var req VerifyLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !models.IsValidLanguage(req.Language) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid language code: " + req.Language,
+		})
+		return
+	}
+
+	task, err := h.taskRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	if !task.MarkVerified(req.Language) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Task has no unverified machine generated text in " + req.Language,
+		})
+		return
+	}
+
+	if err := h.taskRepo.SetMachineLanguages(task.ID, task.MachineLanguages); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to save verification",
+		})
+		return
+	}
+
+	log.Info().
+		Str("task_id", task.ID).
+		Str("reviewer", req.Reviewer).
+		Str("language", req.Language).
+		Msg("Task language verified")
+
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
+
+// Claim godoc
+// @Summary Claim a task for review
+// @Description Assign a pending task to a reviewer so other moderators skip it. Claiming a task you already hold is a no-op.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body ClaimRequest true "Reviewer"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/claim [post]
+func (h *ReviewHandler) Claim(c *gin.Context) {
+	var req ClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.updateAssignment(c, func(id string) error {
+		return h.taskRepo.Claim(id, req.Reviewer)
+	}, "Task is not pending review or is claimed by another reviewer")
+}
+
+// Release godoc
+// @Summary Release a claimed task
+// @Description Return a claimed task to the unassigned pool. Only the assigned reviewer may release it unless force is set.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body ClaimRequest true "Reviewer"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/release [post]
+func (h *ReviewHandler) Release(c *gin.Context) {
+	var req ClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.updateAssignment(c, func(id string) error {
+		return h.taskRepo.Release(id, req.Reviewer, req.Force)
+	}, "Task is claimed by another reviewer")
+}
+
+// updateAssignment applies a claim or release to the task in the path and
+// responds with the updated task
+func (h *ReviewHandler) updateAssignment(c *gin.Context, apply func(id string) error, conflictMsg string) {
+	task, err := h.taskRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	if err := apply(task.ID); err != nil {
+		if errors.Is(err, repository.ErrNotClaimable) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: conflictMsg,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update assignment",
+		})
+		return
+	}
+
+	if task, err = h.taskRepo.FindByID(task.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
+
+// Review godoc
+// @Summary Record a review decision
+// @Description Approve or reject a task. Approval activates the task and resolves its open reports; rejection deactivates it. The task's assignment is cleared. A task claimed by another reviewer cannot be reviewed.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param request body ReviewRequest true "Review decision"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/review [post]
+func (h *ReviewHandler) Review(c *gin.Context) {
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	if task.AssignedTo != "" && task.AssignedTo != req.Reviewer {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Task is claimed by " + task.AssignedTo,
+		})
+		return
+	}
+
+	approved := req.State == models.ReviewStateApproved
+	if approved {
+		if err := h.reportRepo.ResolveForTask(task.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to resolve reports",
+			})
+			return
+		}
+	}
+
+	if err := h.taskRepo.Review(task.ID, req.State, req.Notes, approved); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to save review",
+		})
+		return
+	}
+
+	log.Info().
+		Str("task_id", task.ID).
+		Str("reviewer", req.Reviewer).
+		Str("state", req.State).
+		Msg("Task reviewed")
+
+	task.ReviewState = req.State
+	task.ReviewerNotes = req.Notes
+	task.IsActive = approved
+	task.AssignedTo = ""
+	task.AssignedAt = nil
+
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
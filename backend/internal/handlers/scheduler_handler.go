@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/ical"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/middleware"
 	"github.com/truthordare/backend/internal/models"
 	"github.com/truthordare/backend/internal/scheduler"
 )
@@ -49,7 +56,9 @@ type RunJobRequest struct {
 // @Param request body RunJobRequest true "Job name to run"
 // @Success 200 {object} RunJobResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /scheduler/run [post]
 func (h *SchedulerHandler) RunJob(c *gin.Context) {
 	var req RunJobRequest
@@ -62,6 +71,20 @@ func (h *SchedulerHandler) RunJob(c *gin.Context) {
 	}
 
 	err := h.scheduler.RunJobNow(req.JobName)
+	if errors.Is(err, maintenance.ErrReadOnly) {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "read_only",
+			Message: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, scheduler.ErrJobRunning) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "Job " + req.JobName + " is already running",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "job_error",
@@ -77,6 +100,49 @@ func (h *SchedulerHandler) RunJob(c *gin.Context) {
 	})
 }
 
+// Calendar feed look-ahead, in days
+const (
+	defaultCalendarDays = 14
+	maxCalendarDays     = 90
+)
+
+// Calendar godoc
+// @Summary Calendar feed of job runs and maintenance windows
+// @Description iCalendar feed of upcoming scheduled job runs, computed from their cron expressions, and maintenance windows, for operators to subscribe to. Runs that will be skipped, in a maintenance window or while read-only mode is on, are listed as cancelled. Calendar apps cannot send the admin key, so subscribe with a URL signed by POST /signed-urls.
+// @Tags scheduler
+// @Produce plain
+// @Param days query int false "Days ahead to list (1-90, default 14)"
+// @Success 200 {string} string "text/calendar feed"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /scheduler/calendar.ics [get]
+func (h *SchedulerHandler) Calendar(c *gin.Context) {
+	days := defaultCalendarDays
+	if value := c.Query("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxCalendarDays {
+			respondFieldErrors(c, []models.FieldError{{Field: "days", Message: "must be between 1 and " + strconv.Itoa(maxCalendarDays)}})
+			return
+		}
+		days = n
+	}
+
+	now := time.Now()
+	cal := ical.Calendar{
+		Name:    "Truth or Dare scheduler",
+		Refresh: time.Hour,
+		Events:  h.scheduler.Calendar(now, now.AddDate(0, 0, days)),
+	}
+	middleware.SkipEnvelope(c)
+	c.Header("Content-Type", ical.ContentType)
+	c.Header("Content-Disposition", `inline; filename="scheduler.ics"`)
+	c.Status(http.StatusOK)
+	if err := cal.Write(c.Writer, now); err != nil {
+		log.Error().Err(err).Msg("Failed to write scheduler calendar")
+	}
+}
+
 // SchedulerJobsResponse is the response for the GetJobs endpoint.
 type SchedulerJobsResponse struct {
 	Jobs []scheduler.JobInfo `json:"jobs"`
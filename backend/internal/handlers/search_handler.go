@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// Search bounds
+const (
+	minSearchQueryLength = 2
+	defaultSearchLimit   = 20
+	maxSearchLimit       = 100
+)
+
+// SearchHandler searches categories and tasks for the admin panel
+type SearchHandler struct {
+	categoryRepo *repository.CategoryRepository
+	taskRepo     *repository.TaskRepository
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(categoryRepo *repository.CategoryRepository, taskRepo *repository.TaskRepository) *SearchHandler {
+	return &SearchHandler{categoryRepo: categoryRepo, taskRepo: taskRepo}
+}
+
+// CategorySearchResult is a matched category and the label languages that
+// matched. It is empty when the category matched by ID.
+type CategorySearchResult struct {
+	models.CategoryResponse
+	MatchedLanguages []string `json:"matched_languages"`
+}
+
+// TaskSearchResult is a matched task. MatchedField is id, text or hint and
+// MatchedLanguage the language of the matched text or hint.
+type TaskSearchResult struct {
+	models.TaskResponse
+	MatchedField    string `json:"matched_field"`
+	MatchedLanguage string `json:"matched_language"`
+}
+
+// SearchResponse groups the matches of a search by kind
+type SearchResponse struct {
+	Query      string                 `json:"query"`
+	Categories []CategorySearchResult `json:"categories"`
+	Tasks      []TaskSearchResult     `json:"tasks"`
+}
+
+// Search godoc
+// @Summary Search categories and tasks
+// @Description Find categories whose label and tasks whose text or hint contain the query in any language, ignoring case, or whose ID equals it. Categories come in display order and tasks newest first, each group capped at limit.
+// @Tags admin
+// @Produce json
+// @Param q query string true "Search text (at least 2 characters)"
+// @Param limit query int false "Matches per group (max 100)" default(20)
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(q) < minSearchQueryLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "q must be at least 2 characters",
+		})
+		return
+	}
+
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	categories, err := h.categoryRepo.Search(q, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to search categories",
+		})
+		return
+	}
+	tasks, err := h.taskRepo.Search(q, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to search tasks",
+		})
+		return
+	}
+
+	response := SearchResponse{
+		Query:      q,
+		Categories: make([]CategorySearchResult, 0, len(categories)),
+		Tasks:      make([]TaskSearchResult, 0, len(tasks)),
+	}
+	for _, match := range categories {
+		languages := match.Languages
+		if languages == nil {
+			languages = []string{}
+		}
+		response.Categories = append(response.Categories, CategorySearchResult{
+			CategoryResponse: mapperFor(c).category(&match.Category),
+			MatchedLanguages: languages,
+		})
+	}
+	for _, match := range tasks {
+		response.Tasks = append(response.Tasks, TaskSearchResult{
+			TaskResponse:    mapperFor(c).task(&match.Task),
+			MatchedField:    match.Field,
+			MatchedLanguage: match.Language,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
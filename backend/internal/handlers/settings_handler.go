@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// SettingsHandler handles runtime instance settings
+type SettingsHandler struct {
+	mode *maintenance.Mode
+}
+
+// NewSettingsHandler creates a new SettingsHandler
+func NewSettingsHandler(mode *maintenance.Mode) *SettingsHandler {
+	return &SettingsHandler{mode: mode}
+}
+
+// SetReadOnlyRequest represents the request body for toggling read-only mode
+type SetReadOnlyRequest struct {
+	ReadOnly *bool  `json:"read_only" binding:"required"`
+	Reason   string `json:"reason,omitempty" binding:"max=200"`
+}
+
+// GetReadOnly godoc
+// @Summary Get read-only mode
+// @Description Report whether the instance is in read-only mode, and why
+// @Tags settings
+// @Produce json
+// @Success 200 {object} maintenance.Status
+// @Router /settings/read-only [get]
+func (h *SettingsHandler) GetReadOnly(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.Status())
+}
+
+// SetReadOnly godoc
+// @Summary Toggle read-only mode
+// @Description Switch read-only mode on or off. While on, every mutating endpoint except this one returns 503 and scheduler jobs that write are skipped. Use it during migrations, restores or incident response. The mode is not persisted; READ_ONLY sets it at startup.
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param request body SetReadOnlyRequest true "Mode"
+// @Success 200 {object} maintenance.Status
+// @Failure 400 {object} models.ErrorResponse
+// @Router /settings/read-only [put]
+func (h *SettingsHandler) SetReadOnly(c *gin.Context) {
+	var req SetReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.mode.Set(*req.ReadOnly, req.Reason)
+
+	log.Warn().
+		Bool("read_only", *req.ReadOnly).
+		Str("reason", req.Reason).
+		Str("ip", c.ClientIP()).
+		Msg("Read-only mode changed")
+
+	c.JSON(http.StatusOK, h.mode.Status())
+}
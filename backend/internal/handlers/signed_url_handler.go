@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/signedurl"
+)
+
+// defaultSignedURLTTL is the lifetime of signed URLs when none is asked for
+const defaultSignedURLTTL = 24 * time.Hour
+
+// SignedURLHandler issues signed URLs for the embeddable routes
+type SignedURLHandler struct {
+	signer   *signedurl.Signer
+	prefixes []string
+	maxTTL   time.Duration
+}
+
+// NewSignedURLHandler creates a new SignedURLHandler signing paths under
+// prefixes for up to maxTTL. Prefixes not ending in a slash are single
+// paths.
+func NewSignedURLHandler(signer *signedurl.Signer, prefixes []string, maxTTL time.Duration) *SignedURLHandler {
+	return &SignedURLHandler{signer: signer, prefixes: prefixes, maxTTL: maxTTL}
+}
+
+// CreateSignedURLRequest asks for a signed URL. Path is the API path with
+// its query, e.g. /api/v2/embed/daily-task?language=es.
+type CreateSignedURLRequest struct {
+	Path       string `json:"path" binding:"required"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// SignedURLResponse is a signed URL, relative to the API host
+type SignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Create godoc
+// @Summary Create a signed URL
+// @Description Sign a URL of an embeddable read-only route (under /embed/, or the scheduler calendar feed) so a third-party page or calendar app can fetch it without the admin key until it expires. The signature covers the path and every query parameter, so the link cannot be altered. ttl_seconds defaults to one day and is capped by SIGNED_URL_MAX_TTL_HOURS.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateSignedURLRequest true "Path and lifetime"
+// @Success 201 {object} SignedURLResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /signed-urls [post]
+func (h *SignedURLHandler) Create(c *gin.Context) {
+	var req CreateSignedURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	target, err := url.Parse(req.Path)
+	if err != nil || target.Scheme != "" || target.Host != "" || !strings.HasPrefix(target.Path, "/") {
+		respondFieldErrors(c, []models.FieldError{{Field: "path", Message: "must be an API path such as /api/v2/embed/daily-task"}})
+		return
+	}
+	// Cleaned, so dot segments cannot climb out of an embeddable prefix
+	signedPath := path.Clean(target.Path)
+	if !h.signable(signedPath) {
+		respondFieldErrors(c, []models.FieldError{{Field: "path", Message: "must be one of or under " + strings.Join(h.prefixes, ", ")}})
+		return
+	}
+
+	ttl := defaultSignedURLTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > h.maxTTL {
+		respondFieldErrors(c, []models.FieldError{{Field: "ttl_seconds", Message: fmt.Sprintf("must be between 1 and %d", int(h.maxTTL.Seconds()))}})
+		return
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	signed := h.signer.Sign(signedPath, target.Query(), expires)
+
+	log.Info().
+		Str("admin_key", middleware.AdminKeyID(c)).
+		Str("path", signedPath).
+		Time("expires_at", expires).
+		Msg("Signed URL issued")
+
+	c.JSON(http.StatusCreated, SignedURLResponse{URL: signed, ExpiresAt: expires.UTC()})
+}
+
+// signable reports whether a path is under an embeddable prefix, or is
+// one of the single signable paths
+func (h *SignedURLHandler) signable(p string) bool {
+	for _, prefix := range h.prefixes {
+		if !strings.HasSuffix(prefix, "/") {
+			if p == prefix {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(p, prefix) && len(p) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"github.com/truthordare/backend/internal/version"
+)
+
+// SnapshotHandler handles exporting and importing instance content
+type SnapshotHandler struct {
+	repo  *repository.SnapshotRepository
+	flags *featureflags.Store
+}
+
+// NewSnapshotHandler creates a new SnapshotHandler
+func NewSnapshotHandler(repo *repository.SnapshotRepository, flags *featureflags.Store) *SnapshotHandler {
+	return &SnapshotHandler{repo: repo, flags: flags}
+}
+
+// SnapshotFlagResult lists the feature flag overrides an import applied,
+// and those skipped because this build does not know them
+type SnapshotFlagResult struct {
+	Applied []string `json:"applied"`
+	Skipped []string `json:"skipped"`
+}
+
+// SnapshotImportResponse reports an import
+type SnapshotImportResponse struct {
+	DryRun bool `json:"dry_run"`
+	repository.SnapshotImportResult
+	FeatureFlags SnapshotFlagResult `json:"feature_flags"`
+}
+
+// Export godoc
+// @Summary Export instance content
+// @Description Export every category, task and runtime feature flag override as one versioned archive, to be imported into another instance (e.g. staging to production). Serve counts, reports, telemetry and consent records are not included.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} repository.Snapshot
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/snapshot [get]
+func (h *SnapshotHandler) Export(c *gin.Context) {
+	snapshot, err := h.repo.Export()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to export snapshot",
+		})
+		return
+	}
+
+	snapshot.SchemaVersion = database.SchemaVersion
+	snapshot.AppVersion = version.Get().Version
+
+	filename := fmt.Sprintf("tod-snapshot-%s.json", snapshot.CreatedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Import godoc
+// @Summary Import instance content
+// @Description Merge an archive from GET /admin/snapshot into this instance. Categories are matched by English label and tasks by category, language, type and text; matches are updated, the rest created with new IDs, and rows missing from the archive are kept. The response maps archive IDs to IDs on this instance. Use dry_run to preview the changes.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Report the changes without applying them"
+// @Param snapshot body repository.Snapshot true "Archive"
+// @Success 200 {object} SnapshotImportResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/snapshot [post]
+func (h *SnapshotHandler) Import(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	snapshot, ok := bindSnapshot(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.repo.Import(snapshot, dryRun)
+	if err != nil {
+		if respondLabelConflict(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrSnapshotInvalid) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_snapshot",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to import snapshot",
+		})
+		return
+	}
+
+	flags := SnapshotFlagResult{Applied: []string{}, Skipped: []string{}}
+	for _, flag := range snapshot.FeatureFlags {
+		if dryRun {
+			if knownFlag(flag.Name) {
+				flags.Applied = append(flags.Applied, flag.Name)
+			} else {
+				flags.Skipped = append(flags.Skipped, flag.Name)
+			}
+			continue
+		}
+
+		_, err := h.flags.Set(flag.Name, flag.Enabled, flag.RolloutPercent)
+		if errors.Is(err, featureflags.ErrUnknownFlag) {
+			flags.Skipped = append(flags.Skipped, flag.Name)
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Snapshot content was imported but feature flag " + flag.Name + " could not be set",
+			})
+			return
+		}
+		flags.Applied = append(flags.Applied, flag.Name)
+	}
+
+	if !dryRun {
+		log.Warn().
+			Str("app_version", snapshot.AppVersion).
+			Interface("categories", result.Categories).
+			Interface("tasks", result.Tasks).
+			Strs("feature_flags", flags.Applied).
+			Str("ip", c.ClientIP()).
+			Msg("Snapshot imported")
+	}
+
+	c.JSON(http.StatusOK, SnapshotImportResponse{
+		DryRun:               dryRun,
+		SnapshotImportResult: *result,
+		FeatureFlags:         flags,
+	})
+}
+
+// Diff godoc
+// @Summary Compare an archive with this instance
+// @Description Compare an archive from GET /admin/snapshot with the content of this instance, matching rows as the import does. Added rows would be created and changed rows updated, with the old and new value of each field. Removed rows exist only on this instance; the import keeps them. Nothing is written.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param snapshot body repository.Snapshot true "Archive"
+// @Success 200 {object} repository.SnapshotDiff
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/snapshot/diff [post]
+func (h *SnapshotHandler) Diff(c *gin.Context) {
+	snapshot, ok := bindSnapshot(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.repo.Diff(snapshot)
+	if err != nil {
+		if errors.Is(err, repository.ErrSnapshotInvalid) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_snapshot",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to compare snapshot",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// bindSnapshot reads and validates the archive in the request body,
+// writing the error response if it cannot be used
+func bindSnapshot(c *gin.Context) (*repository.Snapshot, bool) {
+	var snapshot repository.Snapshot
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+
+	if snapshot.FormatVersion != repository.SnapshotFormatVersion {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "unsupported_snapshot",
+			Message: fmt.Sprintf("Snapshot format version %d is not supported (expected %d)", snapshot.FormatVersion, repository.SnapshotFormatVersion),
+		})
+		return nil, false
+	}
+	if snapshot.SchemaVersion > database.SchemaVersion {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "unsupported_snapshot",
+			Message: fmt.Sprintf("Snapshot was taken at schema version %d, newer than this build (%d)", snapshot.SchemaVersion, database.SchemaVersion),
+		})
+		return nil, false
+	}
+
+	if errs := validateSnapshot(&snapshot); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return nil, false
+	}
+	return &snapshot, true
+}
+
+// validateSnapshot applies the create validation to every category and
+// task of a snapshot, normalizing category emoji in place
+func validateSnapshot(snapshot *repository.Snapshot) []models.FieldError {
+	var errs []models.FieldError
+	for i := range snapshot.Categories {
+		category := &snapshot.Categories[i]
+		prefix := fmt.Sprintf("categories[%d].", i)
+
+		var categoryErrs []models.FieldError
+		category.Emoji, categoryErrs = validateEmoji(category.Emoji, validateLabel(category.Label, true))
+		if !models.IsValidAgeGroup(category.AgeGroup) {
+			categoryErrs = append(categoryErrs, models.FieldError{Field: "age_group", Message: "must be kids, teen or adults"})
+		}
+		categoryErrs = append(categoryErrs, models.ValidateLicense("", category.License, category.Attribution)...)
+		categoryErrs = append(categoryErrs, category.Instructions.Validate("instructions", models.MaxInstructionsLength)...)
+		for _, e := range categoryErrs {
+			e.Field = prefix + e.Field
+			errs = append(errs, e)
+		}
+	}
+
+	for i, task := range snapshot.Tasks {
+		prefix := fmt.Sprintf("tasks[%d].", i)
+		errs = append(errs, validateTaskRequest(prefix, CreateTaskRequest{
+			Text:        task.Text,
+			Language:    task.Language,
+			License:     task.License,
+			Attribution: task.Attribution,
+		})...)
+		errs = append(errs, task.Hint.Validate(prefix+"hint", models.MaxHintLength)...)
+		if task.Type != "truth" && task.Type != "dare" {
+			errs = append(errs, models.FieldError{Field: prefix + "type", Message: "must be truth or dare"})
+		}
+	}
+	return errs
+}
+
+// knownFlag reports whether this build declares a feature flag
+func knownFlag(name string) bool {
+	for _, def := range featureflags.Definitions {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
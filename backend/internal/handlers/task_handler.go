@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/middleware"
 	"github.com/truthordare/backend/internal/models"
 	"github.com/truthordare/backend/internal/repository"
 )
@@ -16,13 +20,19 @@ import (
 type TaskHandler struct {
 	repo         *repository.TaskRepository
 	categoryRepo *repository.CategoryRepository
+	served       *repository.ServeRecorder
+	textRules    models.AgeGroupTextRules
 }
 
 // NewTaskHandler creates a new TaskHandler.
-func NewTaskHandler(repo *repository.TaskRepository, categoryRepo *repository.CategoryRepository) *TaskHandler {
+// Tasks drawn at random are counted through served, which may be nil.
+// Task text is held to the text rules of its category's age group.
+func NewTaskHandler(repo *repository.TaskRepository, categoryRepo *repository.CategoryRepository, served *repository.ServeRecorder, textRules models.AgeGroupTextRules) *TaskHandler {
 	return &TaskHandler{
 		repo:         repo,
 		categoryRepo: categoryRepo,
+		served:       served,
+		textRules:    textRules,
 	}
 }
 
@@ -39,6 +49,12 @@ func NewTaskHandler(repo *repository.TaskRepository, categoryRepo *repository.Ca
 // @Param language query string false "Single language code (en, hi, ur, etc.)"
 // @Param languages query string false "Language codes (comma-separated: en,hi,ur)"
 // @Param exclude query string false "Comma-separated task IDs to exclude"
+// @Param requires_consent query bool false "Only tasks that do (true) or do not (false) require consent, through their own flag or their category's"
+// @Param active query string false "true, false or all. Public callers only see active tasks; admins see all states by default"
+// @Param age_group query string false "Only tasks of categories for this age group (kids 0-12, teen 13-17, adults 18-99)"
+// @Param age_groups query string false "Only tasks of categories for these age groups (comma-separated)"
+// @Param min_age query int false "Only tasks of categories whose age group reaches this age (overrides age_group's lower bound)"
+// @Param max_age query int false "Only tasks of categories whose age group starts at or below this age (overrides age_group's upper bound)"
 // @Param from_date query string false "Filter tasks created after this date (RFC3339 format)"
 // @Param to_date query string false "Filter tasks created before this date (RFC3339 format)"
 // @Param sort_by query string false "Sort field (created_at, updated_at, language, type)"
@@ -46,10 +62,114 @@ func NewTaskHandler(repo *repository.TaskRepository, categoryRepo *repository.Ca
 // @Param limit query int false "Limit results"
 // @Param offset query int false "Offset for pagination"
 // @Param random query bool false "Randomize results"
+// @Param never_served query bool false "Only tasks never drawn for a game"
+// @Param served_before query string false "Only tasks not served since this date (RFC3339 format); includes never served"
+// @Param max_times_served query int false "Only tasks served at most this many times"
+// @Param fields query string false "Comma-separated response fields to return (id is always included)"
+// @Param text_languages query string false "Comma-separated languages kept in hints and category labels"
+// @Param plain query bool false "Strip emoji and markdown formatting from texts, hints and category labels (for voice and plain-text clients)"
 // @Success 200 {object} models.PaginatedResponse[models.TaskResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /tasks [get]
 func (h *TaskHandler) List(c *gin.Context) {
+	sel, ok := parseFieldSelection[models.TaskResponse](c)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseListFilter(c)
+	if !ok {
+		return
+	}
+
+	filter.Limit = parseNonNegativeInt(c.Query("limit"))
+	filter.Offset = parseNonNegativeInt(c.Query("offset"))
+
+	if random := c.Query("random"); random != "" {
+		if val, err := strconv.ParseBool(random); err == nil {
+			filter.Random = val
+		}
+	}
+	if filter.Random {
+		filter.RolloutRoll = rolloutRoll()
+	}
+
+	tasks, total, err := h.repo.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch tasks",
+		})
+		return
+	}
+
+	// Convert to response format
+	taskResponses := make([]models.TaskResponse, len(tasks))
+	for i, task := range tasks {
+		taskResponses[i] = mapperFor(c).task(&task)
+		taskResponses[i].Text = sel.str(taskResponses[i].Text)
+		taskResponses[i].Hint = sel.text(taskResponses[i].Hint)
+		if taskResponses[i].Category != nil {
+			taskResponses[i].Category.Label = sel.text(taskResponses[i].Category.Label)
+		}
+	}
+
+	// A random draw is a game fetching tasks to play
+	if filter.Random {
+		ids := make([]string, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		h.served.Record(ids...)
+	}
+
+	// Calculate pagination info
+	page := 1
+	pageSize := len(tasks)
+	if filter.Limit > 0 {
+		pageSize = filter.Limit
+		page = (filter.Offset / filter.Limit) + 1
+	}
+	totalPages := 1
+	if pageSize > 0 && total > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	if sel.sparse() {
+		data, err := sparseItems(sel, taskResponses)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to shape response",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, models.PaginatedResponse[map[string]json.RawMessage]{
+			Data:       data,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: totalPages,
+		})
+		return
+	}
+
+	response := models.PaginatedResponse[models.TaskResponse]{
+		Data:       taskResponses,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseListFilter reads the filter and sort parameters shared by List and
+// Neighbors. It responds and returns false when a parameter is invalid.
+func parseListFilter(c *gin.Context) (*repository.TaskFilter, bool) {
 	filter := &repository.TaskFilter{}
 
 	// Single category ID
@@ -86,18 +206,22 @@ func (h *TaskHandler) List(c *gin.Context) {
 		filter.ExcludeIDs = splitAndTrim(exclude)
 	}
 
-	// Date range filters
-	if fromDate := c.Query("from_date"); fromDate != "" {
-		if t, err := time.Parse(time.RFC3339, fromDate); err == nil {
-			filter.FromDate = &t
-		}
+	filter.RequiresConsent = parseBoolParam(c.Query("requires_consent"))
+	if !parseActiveFilter(c, filter) {
+		return nil, false
 	}
-	if toDate := c.Query("to_date"); toDate != "" {
-		if t, err := time.Parse(time.RFC3339, toDate); err == nil {
-			filter.ToDate = &t
-		}
+	if err := parseAgeFilter(c, filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return nil, false
 	}
 
+	// Date range filters
+	filter.FromDate = parseTimeParam(c.Query("from_date"))
+	filter.ToDate = parseTimeParam(c.Query("to_date"))
+
 	// Sort parameters
 	if sortBy := c.Query("sort_by"); sortBy != "" {
 		filter.SortBy = sortBy
@@ -106,73 +230,147 @@ func (h *TaskHandler) List(c *gin.Context) {
 		filter.SortOrder = strings.ToLower(sortOrder)
 	}
 
-	if limit := c.Query("limit"); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil {
-			filter.Limit = val
+	// Serve statistics filters
+	filter.NeverServed = c.Query("never_served") == "true"
+	filter.ServedBefore = parseTimeParam(c.Query("served_before"))
+	if maxServed := c.Query("max_times_served"); maxServed != "" {
+		if val, err := strconv.Atoi(maxServed); err == nil && val >= 0 {
+			filter.MaxTimesServed = &val
 		}
 	}
 
-	if offset := c.Query("offset"); offset != "" {
-		if val, err := strconv.Atoi(offset); err == nil {
-			filter.Offset = val
+	return filter, true
+}
+
+// rolloutRoll draws the staged-rollout roll for one random request. A task
+// is eligible when its rollout percentage exceeds the roll, so a task at
+// 25% appears in roughly a quarter of random draws.
+func rolloutRoll() *int {
+	roll := rand.Intn(models.FullRollout)
+	return &roll
+}
+
+// splitAndTrim splits a comma-separated string and trims whitespace.
+func splitAndTrim(s string) []string {
+	parts := strings.Split(s, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if trimmed != "" {
+			result = append(result, trimmed)
 		}
 	}
+	return result
+}
 
-	if random := c.Query("random"); random != "" {
-		if val, err := strconv.ParseBool(random); err == nil {
-			filter.Random = val
+// parseTimeParam parses an RFC3339 query value.
+// Returns nil when the value is empty or malformed.
+func parseTimeParam(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// parseActiveFilter reads the active parameter: true, false or all. Callers
+// without a valid admin key only see active tasks; admins see every state
+// unless they ask for one. It responds and returns false when the
+// parameter is invalid or not allowed.
+func parseActiveFilter(c *gin.Context, filter *repository.TaskFilter) bool {
+	value := strings.ToLower(c.Query("active"))
+	if !middleware.IsAdmin(c) {
+		if value != "" && value != "true" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "forbidden",
+				Message: "Only admins can list inactive tasks",
+			})
+			return false
 		}
+		active := true
+		filter.IsActive = &active
+		return true
 	}
 
-	tasks, total, err := h.repo.FindAll(filter)
+	if value == "" || value == "all" {
+		return true
+	}
+	active, err := strconv.ParseBool(value)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to fetch tasks",
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "active must be true, false or all",
 		})
-		return
+		return false
 	}
+	filter.IsActive = &active
+	return true
+}
 
-	// Convert to response format
-	taskResponses := make([]models.TaskResponse, len(tasks))
-	for i, task := range tasks {
-		taskResponses[i] = task.ToResponse()
+// parseAgeFilter reads the age_group, age_groups, min_age and max_age
+// parameters into filter. age_group stands for its age range, e.g. teen
+// for 13-17; min_age and max_age override either end of it.
+func parseAgeFilter(c *gin.Context, filter *repository.TaskFilter) error {
+	if group := c.Query("age_group"); group != "" {
+		if !models.IsValidAgeGroup(group) {
+			return fmt.Errorf("invalid age_group: %s", group)
+		}
+		minAge, maxAge := models.GetMinAgeForGroup(group), models.GetMaxAgeForGroup(group)
+		filter.MinAge, filter.MaxAge = &minAge, &maxAge
+	}
+	if groups := c.Query("age_groups"); groups != "" {
+		filter.AgeGroups = splitAndTrim(groups)
+		for _, group := range filter.AgeGroups {
+			if !models.IsValidAgeGroup(group) {
+				return fmt.Errorf("invalid age_groups entry: %s", group)
+			}
+		}
 	}
 
-	// Calculate pagination info
-	page := 1
-	pageSize := len(tasks)
-	if filter.Limit > 0 {
-		pageSize = filter.Limit
-		page = (filter.Offset / filter.Limit) + 1
+	for param, target := range map[string]**int{"min_age": &filter.MinAge, "max_age": &filter.MaxAge} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		age, err := strconv.Atoi(value)
+		if err != nil || age < 0 {
+			return fmt.Errorf("%s must be a non-negative integer", param)
+		}
+		*target = &age
 	}
-	totalPages := 1
-	if pageSize > 0 && total > 0 {
-		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	if filter.MinAge != nil && filter.MaxAge != nil && *filter.MinAge > *filter.MaxAge {
+		return fmt.Errorf("min_age must not exceed max_age")
 	}
+	return nil
+}
 
-	response := models.PaginatedResponse[models.TaskResponse]{
-		Data:       taskResponses,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
+// parseBoolParam parses an optional boolean query value.
+// Returns nil when the value is empty or malformed.
+func parseBoolParam(value string) *bool {
+	if value == "" {
+		return nil
 	}
-
-	c.JSON(http.StatusOK, response)
+	val, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil
+	}
+	return &val
 }
 
-// splitAndTrim splits a comma-separated string and trims whitespace.
-func splitAndTrim(s string) []string {
-	parts := strings.Split(s, ",")
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
+// parseNonNegativeInt parses an integer query value such as limit or offset.
+// Returns 0 when the value is empty, malformed or negative.
+func parseNonNegativeInt(value string) int {
+	if value == "" {
+		return 0
 	}
-	return result
+	val, err := strconv.Atoi(value)
+	if err != nil || val < 0 {
+		return 0
+	}
+	return val
 }
 
 // CheckAvailability godoc
@@ -183,7 +381,15 @@ func splitAndTrim(s string) []string {
 // @Produce json
 // @Param category_ids query string false "Category IDs (comma-separated)"
 // @Param languages query string false "Language codes (comma-separated)"
+// @Param requires_consent query bool false "Only count tasks that do (true) or do not (false) require consent"
+// @Param active query string false "true, false or all. Public callers only see active tasks; admins see all states by default"
+// @Param age_group query string false "Only tasks of categories for this age group (kids 0-12, teen 13-17, adults 18-99)"
+// @Param age_groups query string false "Only tasks of categories for these age groups (comma-separated)"
+// @Param min_age query int false "Only tasks of categories whose age group reaches this age (overrides age_group's lower bound)"
+// @Param max_age query int false "Only tasks of categories whose age group starts at or below this age (overrides age_group's upper bound)"
 // @Success 200 {object} TaskAvailabilityResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /tasks/availability [get]
 func (h *TaskHandler) CheckAvailability(c *gin.Context) {
@@ -197,6 +403,18 @@ func (h *TaskHandler) CheckAvailability(c *gin.Context) {
 		filter.Languages = splitAndTrim(languages)
 	}
 
+	filter.RequiresConsent = parseBoolParam(c.Query("requires_consent"))
+	if !parseActiveFilter(c, filter) {
+		return
+	}
+	if err := parseAgeFilter(c, filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	truthCount, dareCount, err := h.repo.CountByFilters(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -246,7 +464,39 @@ func (h *TaskHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, task.ToResponse())
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
+
+// GetByCode godoc
+// @Summary Get task by short code
+// @Description Resolve a short code such as T-7F3K, as read aloud or quoted in a bug report. Case, the T- prefix and dashes are optional, and O, I and L are read as 0, 1 and 1. Public callers only find active tasks
+// @Tags tasks
+// @Produce json
+// @Param code path string true "Task short code"
+// @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /tasks/code/{code} [get]
+func (h *TaskHandler) GetByCode(c *gin.Context) {
+	code, ok := models.NormalizeShortCode(c.Param("code"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid task short code",
+		})
+		return
+	}
+
+	task, err := h.repo.FindByShortCode(code)
+	if err != nil || (!task.IsActive && !middleware.IsAdmin(c)) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
 }
 
 // GetRandom godoc
@@ -261,7 +511,15 @@ func (h *TaskHandler) Get(c *gin.Context) {
 // @Param language query string false "Language code (en, hi, ur, etc.)"
 // @Param languages query string false "Language codes (comma-separated)"
 // @Param exclude query string false "Comma-separated task IDs to exclude"
+// @Param requires_consent query bool false "Only tasks that do (true) or do not (false) require consent, through their own flag or their category's"
+// @Param active query string false "true, false or all. Public callers only see active tasks; admins see all states by default"
+// @Param age_group query string false "Only tasks of categories for this age group (kids 0-12, teen 13-17, adults 18-99)"
+// @Param age_groups query string false "Only tasks of categories for these age groups (comma-separated)"
+// @Param min_age query int false "Only tasks of categories whose age group reaches this age (overrides age_group's lower bound)"
+// @Param max_age query int false "Only tasks of categories whose age group starts at or below this age (overrides age_group's upper bound)"
 // @Success 200 {object} models.TaskResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /tasks/random [get]
@@ -292,6 +550,19 @@ func (h *TaskHandler) GetRandom(c *gin.Context) {
 		filter.ExcludeIDs = strings.Split(exclude, ",")
 	}
 
+	filter.RequiresConsent = parseBoolParam(c.Query("requires_consent"))
+	if !parseActiveFilter(c, filter) {
+		return
+	}
+	if err := parseAgeFilter(c, filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	filter.RolloutRoll = rolloutRoll()
+
 	task, err := h.repo.FindRandom(filter)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -301,7 +572,68 @@ func (h *TaskHandler) GetRandom(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, task.ToResponse())
+	h.served.Record(task.ID)
+
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
+}
+
+// TaskNeighborsResponse holds the IDs of the tasks around a task in a
+// listing; null at either end
+type TaskNeighborsResponse struct {
+	ID         string  `json:"id"`
+	PreviousID *string `json:"previous_id"`
+	NextID     *string `json:"next_id"`
+}
+
+// Neighbors godoc
+// @Summary Get neighboring tasks
+// @Description Get the IDs of the tasks before and after a task in the listing GET /tasks returns for the same filter and sort parameters, for next/previous navigation while editing or reviewing. The task itself need not match the filter.
+// @Tags tasks
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param sort_by query string false "Sort field (created_at, updated_at, language, type, times_served, last_served_at)"
+// @Param sort_order query string false "Sort order (asc, desc)"
+// @Param category_id query string false "Single category ID filter"
+// @Param type query string false "Single task type (truth, dare)"
+// @Param language query string false "Single language code"
+// @Param active query string false "true, false or all" default(all)
+// @Success 200 {object} TaskNeighborsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/{id}/neighbors [get]
+func (h *TaskHandler) Neighbors(c *gin.Context) {
+	filter, ok := parseListFilter(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := h.repo.FindByID(id); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Task not found",
+		})
+		return
+	}
+
+	previous, next, err := h.repo.FindNeighbors(id, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch neighboring tasks",
+		})
+		return
+	}
+
+	response := TaskNeighborsResponse{ID: id}
+	if previous != "" {
+		response.PreviousID = &previous
+	}
+	if next != "" {
+		response.NextID = &next
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateTaskRequest is the request body for creating a task.
@@ -310,11 +642,18 @@ type CreateTaskRequest struct {
 	Type       string `json:"type" binding:"required,oneof=truth dare"`
 	CategoryID string `json:"category_id" binding:"required"`
 	Language   string `json:"language" binding:"required,len=2"`
+	// RequiresConsent flags an explicit task in a category that does not
+	// require consent as a whole
+	RequiresConsent bool `json:"requires_consent"`
+	// License and Attribution mark third-party content; attributed tasks
+	// must name a license. Tasks without one fall under the category's.
+	License     string `json:"license"`
+	Attribution string `json:"attribution"`
 }
 
 // Create godoc
 // @Summary Create task
-// @Description Create a new task
+// @Description Create a new task. The text must meet the length and readability limits of the category's age group.
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -333,8 +672,14 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if errs := validateTaskRequest("", req); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
 	// Validate that the category exists
-	if _, err := h.categoryRepo.FindByID(req.CategoryID); err != nil {
+	category, err := h.categoryRepo.FindByID(req.CategoryID)
+	if err != nil {
 		log.Warn().Str("category_id", req.CategoryID).Msg("Task creation attempted with non-existent category")
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "validation_error",
@@ -342,12 +687,19 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+	if errs := h.textRules.For(category.AgeGroup).Validate("text", req.Text, req.Language); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
 
 	task := &models.Task{
-		Text:       req.Text,
-		Type:       req.Type,
-		CategoryID: req.CategoryID,
-		Language:   req.Language,
+		Text:            req.Text,
+		Type:            req.Type,
+		CategoryID:      req.CategoryID,
+		Language:        req.Language,
+		RequiresConsent: req.RequiresConsent,
+		License:         strings.TrimSpace(req.License),
+		Attribution:     strings.TrimSpace(req.Attribution),
 	}
 
 	if err := h.repo.Create(task); err != nil {
@@ -359,7 +711,7 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, task.ToResponse())
+	c.JSON(http.StatusCreated, mapperFor(c).task(task))
 }
 
 // CreateBatchRequest is the request for creating multiple tasks.
@@ -369,7 +721,7 @@ type CreateBatchRequest struct {
 
 // CreateBatch godoc
 // @Summary Create multiple tasks
-// @Description Create multiple tasks at once
+// @Description Create multiple tasks at once. Texts must meet the length and readability limits of their category's age group.
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -388,13 +740,47 @@ func (h *TaskHandler) CreateBatch(c *gin.Context) {
 		return
 	}
 
+	categoryIDs := make([]string, 0, len(req.Tasks))
+	for _, t := range req.Tasks {
+		categoryIDs = append(categoryIDs, t.CategoryID)
+	}
+	categories, err := h.categoryRepo.FindByIDs(categoryIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch categories",
+		})
+		return
+	}
+	ageGroups := make(map[string]string, len(categories))
+	for _, category := range categories {
+		ageGroups[category.ID] = category.AgeGroup
+	}
+
+	var errs []models.FieldError
+	for i, t := range req.Tasks {
+		prefix := fmt.Sprintf("tasks[%d].", i)
+		taskErrs := validateTaskRequest(prefix, t)
+		if ageGroup, ok := ageGroups[t.CategoryID]; ok && len(taskErrs) == 0 {
+			taskErrs = h.textRules.For(ageGroup).Validate(prefix+"text", t.Text, t.Language)
+		}
+		errs = append(errs, taskErrs...)
+	}
+	if len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
 	tasks := make([]models.Task, len(req.Tasks))
 	for i, t := range req.Tasks {
 		tasks[i] = models.Task{
-			Text:       t.Text,
-			Type:       t.Type,
-			CategoryID: t.CategoryID,
-			Language:   t.Language,
+			Text:            t.Text,
+			Type:            t.Type,
+			CategoryID:      t.CategoryID,
+			Language:        t.Language,
+			RequiresConsent: t.RequiresConsent,
+			License:         strings.TrimSpace(t.License),
+			Attribution:     strings.TrimSpace(t.Attribution),
 		}
 	}
 
@@ -446,10 +832,24 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if errs := validateTaskRequest("", req); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+	if category, err := h.categoryRepo.FindByID(req.CategoryID); err == nil {
+		if errs := h.textRules.For(category.AgeGroup).Validate("text", req.Text, req.Language); len(errs) > 0 {
+			respondFieldErrors(c, errs)
+			return
+		}
+	}
+
 	task.Text = req.Text
 	task.Type = req.Type
 	task.CategoryID = req.CategoryID
 	task.Language = req.Language
+	task.RequiresConsent = req.RequiresConsent
+	task.License = strings.TrimSpace(req.License)
+	task.Attribution = strings.TrimSpace(req.Attribution)
 
 	if err := h.repo.Update(task); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -459,7 +859,7 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, task.ToResponse())
+	c.JSON(http.StatusOK, mapperFor(c).task(task))
 }
 
 // Delete godoc
@@ -552,7 +952,10 @@ func (h *TaskHandler) Stats(c *gin.Context) {
 // @Param languages query string false "Language codes (comma-separated)"
 // @Param from_date query string false "Filter tasks created after this date (RFC3339 format)"
 // @Param to_date query string false "Filter tasks created before this date (RFC3339 format)"
+// @Param requires_consent query bool false "Only count tasks that do (true) or do not (false) require consent"
+// @Param active query string false "true, false or all. Public callers only see active tasks; admins see all states by default"
 // @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /tasks/count [get]
 func (h *TaskHandler) Count(c *gin.Context) {
@@ -582,17 +985,12 @@ func (h *TaskHandler) Count(c *gin.Context) {
 		filter.Languages = splitAndTrim(languages)
 	}
 
-	if fromDate := c.Query("from_date"); fromDate != "" {
-		if t, err := time.Parse(time.RFC3339, fromDate); err == nil {
-			filter.FromDate = &t
-		}
-	}
-
-	if toDate := c.Query("to_date"); toDate != "" {
-		if t, err := time.Parse(time.RFC3339, toDate); err == nil {
-			filter.ToDate = &t
-		}
+	filter.RequiresConsent = parseBoolParam(c.Query("requires_consent"))
+	if !parseActiveFilter(c, filter) {
+		return
 	}
+	filter.FromDate = parseTimeParam(c.Query("from_date"))
+	filter.ToDate = parseTimeParam(c.Query("to_date"))
 
 	count, err := h.repo.Count(filter)
 	if err != nil {
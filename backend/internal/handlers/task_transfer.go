@@ -0,0 +1,605 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// Task import tuning. Valid rows are created taskImportBatchSize at a time;
+// at most taskImportMaxIssues errors and duplicates are listed.
+const (
+	taskImportBatchSize = 500
+	taskImportMaxIssues = 1000
+)
+
+// Task transfer formats
+const (
+	TransferFormatJSON = "json"
+	TransferFormatCSV  = "csv"
+)
+
+// taskTransferColumns are the CSV columns, in export order
+var taskTransferColumns = []string{
+	"id", "category_id", "category", "type", "language", "text",
+	"is_active", "requires_consent", "license", "attribution",
+}
+
+// TaskTransferRow is a task as GET /tasks/export writes it and POST
+// /tasks/import reads it. Category is the category's English label, which
+// the import falls back to when the category ID is unknown, as it is on
+// another instance. ID is informational and ignored on import.
+type TaskTransferRow struct {
+	ID              string `json:"id,omitempty"`
+	CategoryID      string `json:"category_id"`
+	Category        string `json:"category"`
+	Type            string `json:"type"`
+	Language        string `json:"language"`
+	Text            string `json:"text"`
+	IsActive        *bool  `json:"is_active,omitempty"` // Defaults to true on import
+	RequiresConsent bool   `json:"requires_consent"`
+	License         string `json:"license,omitempty"`
+	Attribution     string `json:"attribution,omitempty"`
+}
+
+// TaskImportIssue is a problem with one row of an import file. Rows are
+// numbered from 1, not counting the CSV header.
+type TaskImportIssue struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// TaskImportResult reports what an import did, or would do on a dry run
+type TaskImportResult struct {
+	DryRun bool   `json:"dry_run"`
+	Format string `json:"format"`
+	Rows   int    `json:"rows"`
+	// Created counts the tasks created, or that would be on a dry run
+	Created int `json:"created"`
+	// Duplicates counts rows skipped for matching a stored task or an
+	// earlier row in text, category and language
+	Duplicates int `json:"duplicates"`
+	// Invalid counts rows skipped for failing validation
+	Invalid       int               `json:"invalid"`
+	Errors        []TaskImportIssue `json:"errors"`
+	DuplicateRows []int             `json:"duplicate_rows"`
+	// Truncated is set when there were more errors or duplicates than listed
+	Truncated bool `json:"truncated"`
+}
+
+// Export godoc
+// @Summary Export tasks
+// @Description Stream the tasks matching the list filters as a JSON array or CSV, for POST /tasks/import on another instance. Rows are read in keyset batches and flushed as they go; the response is gzip-compressed when the client sends Accept-Encoding: gzip.
+// @Tags tasks
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "json (default) or csv"
+// @Success 200 {array} TaskTransferRow
+// @Failure 400 {object} models.ErrorResponse
+// @Router /tasks/export [get]
+func (h *TaskHandler) Export(c *gin.Context) {
+	format, ok := parseTransferFormat(c, TransferFormatJSON)
+	if !ok {
+		return
+	}
+	filter, ok := parseListFilter(c)
+	if !ok {
+		return
+	}
+
+	compress := strings.Contains(c.GetHeader("Accept-Encoding"), "gzip")
+	filename := fmt.Sprintf("tod-tasks-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	contentType := "application/json"
+	if format == TransferFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Vary", "Accept-Encoding")
+	if compress {
+		c.Header("Content-Encoding", "gzip")
+	}
+	c.Status(http.StatusOK)
+
+	stream := newExportStream(c, compress)
+	writer := newTaskRowWriter(format, stream.buf)
+	written := 0
+	err := writer.begin()
+	if err == nil {
+		err = h.repo.EachTask(filter, exportBatchSize, func(tasks []models.Task) error {
+			for _, task := range tasks {
+				if err := writer.write(toTransferRow(task)); err != nil {
+					return err
+				}
+			}
+			written += len(tasks)
+			return stream.flush()
+		})
+	}
+	if err == nil {
+		err = writer.end()
+	}
+	if err != nil {
+		// The status is sent; a JSON export is left unterminated, so it
+		// does not parse
+		log.Error().Err(err).Int("written", written).Msg("Task export failed")
+	}
+	if closeErr := stream.close(); closeErr != nil && err == nil {
+		log.Error().Err(closeErr).Msg("Failed to finish task export")
+	}
+}
+
+// Import godoc
+// @Summary Import tasks
+// @Description Create tasks from a JSON array or CSV file in the format of GET /tasks/export, read as a stream. The format is taken from the format parameter, else the Content-Type. Each row is validated like POST /tasks; categories are found by ID, else by English label. Rows matching a stored task or an earlier row in text, category and language (ignoring case and spacing) are skipped as duplicates, as are invalid rows, and valid rows are created 500 at a time. With dry_run=true nothing is written. A malformed file stops the import at the malformed row.
+// @Tags tasks
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param format query string false "json or csv; defaults to the Content-Type"
+// @Param dry_run query bool false "Validate and count without creating tasks"
+// @Success 200 {object} TaskImportResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/import [post]
+func (h *TaskHandler) Import(c *gin.Context) {
+	defaultFormat := TransferFormatJSON
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		defaultFormat = TransferFormatCSV
+	}
+	format, ok := parseTransferFormat(c, defaultFormat)
+	if !ok {
+		return
+	}
+
+	index, err := h.repo.NewImportIndex()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch categories",
+		})
+		return
+	}
+
+	imp := &taskImport{
+		h:     h,
+		index: index,
+		result: TaskImportResult{
+			DryRun:        c.Query("dry_run") == "true",
+			Format:        format,
+			Errors:        []TaskImportIssue{},
+			DuplicateRows: []int{},
+		},
+	}
+	reader := newTaskRowReader(format, c.Request.Body)
+	err = imp.run(reader)
+
+	var malformed *malformedRowError
+	switch {
+	case errors.As(err, &malformed):
+		message := fmt.Sprintf("Row %d is malformed: %s", malformed.row, malformed.err)
+		if !imp.result.DryRun {
+			message += fmt.Sprintf("; %d tasks from earlier rows were created", imp.result.Created)
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: message,
+		})
+	case err != nil:
+		log.Error().Err(err).Int("created", imp.result.Created).Msg("Task import failed")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: fmt.Sprintf("Import failed after creating %d tasks", imp.result.Created),
+		})
+	default:
+		c.JSON(http.StatusOK, imp.result)
+	}
+}
+
+// parseTransferFormat reads the format parameter, sending a 400 when it
+// is not a supported format
+func parseTransferFormat(c *gin.Context, fallback string) (string, bool) {
+	format := strings.ToLower(c.DefaultQuery("format", fallback))
+	if format != TransferFormatJSON && format != TransferFormatCSV {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "format must be json or csv",
+		})
+		return "", false
+	}
+	return format, true
+}
+
+// toTransferRow converts a task, with its category preloaded, for export
+func toTransferRow(task models.Task) TaskTransferRow {
+	active := task.IsActive
+	row := TaskTransferRow{
+		ID:              task.ID,
+		CategoryID:      task.CategoryID,
+		Type:            task.Type,
+		Language:        task.Language,
+		Text:            task.Text,
+		IsActive:        &active,
+		RequiresConsent: task.RequiresConsent,
+		License:         task.License,
+		Attribution:     task.Attribution,
+	}
+	if task.Category != nil {
+		row.Category = task.Category.Label["en"]
+	}
+	return row
+}
+
+// taskImport validates rows and creates the valid ones in batches
+type taskImport struct {
+	h      *TaskHandler
+	index  *repository.TaskImportIndex
+	batch  []models.Task
+	result TaskImportResult
+}
+
+// run reads every row. It stops at a malformed row or a database error,
+// after creating the batch of valid rows before it.
+func (imp *taskImport) run(reader taskRowReader) error {
+	for {
+		row, rowErrs, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if flushErr := imp.flush(); flushErr != nil {
+				return flushErr
+			}
+			return err
+		}
+
+		imp.result.Rows++
+		if err := imp.add(imp.result.Rows, row, rowErrs); err != nil {
+			return err
+		}
+		if len(imp.batch) >= taskImportBatchSize {
+			if err := imp.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return imp.flush()
+}
+
+// add validates a row and queues its task unless it is a duplicate
+func (imp *taskImport) add(n int, row TaskTransferRow, errs []models.FieldError) error {
+	category, found := imp.index.Category(row.CategoryID, row.Category)
+	if len(errs) == 0 {
+		errs = validateTaskRequest("", CreateTaskRequest{
+			Text:        row.Text,
+			Language:    row.Language,
+			License:     row.License,
+			Attribution: row.Attribution,
+		})
+		if row.Type != "truth" && row.Type != "dare" {
+			errs = append(errs, models.FieldError{Field: "type", Message: "must be truth or dare"})
+		}
+		if !found {
+			errs = append(errs, models.FieldError{Field: "category_id", Message: "no category with this ID or English label"})
+		} else if len(errs) == 0 {
+			errs = imp.h.textRules.For(category.AgeGroup).Validate("text", row.Text, row.Language)
+		}
+	}
+	if len(errs) > 0 {
+		imp.result.Invalid++
+		for _, e := range errs {
+			if imp.room() {
+				imp.result.Errors = append(imp.result.Errors, TaskImportIssue{Row: n, Field: e.Field, Message: e.Message})
+			}
+		}
+		return nil
+	}
+
+	task := models.Task{
+		Text:            row.Text,
+		Type:            row.Type,
+		CategoryID:      category.ID,
+		Language:        row.Language,
+		IsActive:        row.IsActive == nil || *row.IsActive,
+		RequiresConsent: row.RequiresConsent,
+		License:         strings.TrimSpace(row.License),
+		Attribution:     strings.TrimSpace(row.Attribution),
+	}
+	duplicate, err := imp.index.Duplicate(task)
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		imp.result.Duplicates++
+		if imp.room() {
+			imp.result.DuplicateRows = append(imp.result.DuplicateRows, n)
+		}
+		return nil
+	}
+	if err := imp.index.Add(task); err != nil {
+		return err
+	}
+	imp.result.Created++
+	if !imp.result.DryRun {
+		imp.batch = append(imp.batch, task)
+	}
+	return nil
+}
+
+// room reports whether another error or duplicate may be listed, marking
+// the result truncated when not
+func (imp *taskImport) room() bool {
+	if len(imp.result.Errors)+len(imp.result.DuplicateRows) >= taskImportMaxIssues {
+		imp.result.Truncated = true
+		return false
+	}
+	return true
+}
+
+// flush creates the queued tasks
+func (imp *taskImport) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+	if err := imp.h.repo.CreateImported(imp.batch); err != nil {
+		// None of the batch was created
+		imp.result.Created -= len(imp.batch)
+		return err
+	}
+	imp.batch = imp.batch[:0]
+	return nil
+}
+
+// malformedRowError stops an import at a row that cannot be read
+type malformedRowError struct {
+	row int
+	err error
+}
+
+func (e *malformedRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.row, e.err)
+}
+
+func (e *malformedRowError) Unwrap() error {
+	return e.err
+}
+
+// taskRowReader reads the rows of an import file one at a time
+type taskRowReader interface {
+	// next returns the next row with the problems found reading it, io.EOF
+	// after the last row, or a *malformedRowError
+	next() (TaskTransferRow, []models.FieldError, error)
+}
+
+func newTaskRowReader(format string, r io.Reader) taskRowReader {
+	if format == TransferFormatCSV {
+		return &csvRowReader{r: csv.NewReader(r)}
+	}
+	return &jsonRowReader{dec: json.NewDecoder(r)}
+}
+
+// jsonRowReader reads a JSON array of rows, one element at a time
+type jsonRowReader struct {
+	dec     *json.Decoder
+	row     int
+	started bool
+}
+
+func (r *jsonRowReader) next() (TaskTransferRow, []models.FieldError, error) {
+	var row TaskTransferRow
+	if !r.started {
+		r.started = true
+		if tok, err := r.dec.Token(); err != nil || tok != json.Delim('[') {
+			return row, nil, &malformedRowError{row: 1, err: errors.New("file must be a JSON array of tasks")}
+		}
+	}
+	if !r.dec.More() {
+		if _, err := r.dec.Token(); err != nil {
+			return row, nil, &malformedRowError{row: r.row + 1, err: err}
+		}
+		return row, nil, io.EOF
+	}
+
+	r.row++
+	err := r.dec.Decode(&row)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		// The decoder moved past the element; only this row is invalid
+		return row, []models.FieldError{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}}, nil
+	}
+	if err != nil {
+		return row, nil, &malformedRowError{row: r.row, err: err}
+	}
+	return row, nil, nil
+}
+
+// csvRowReader reads CSV rows, whose columns are named by a header line.
+// Unknown columns are ignored.
+type csvRowReader struct {
+	r       *csv.Reader
+	row     int
+	columns map[string]int
+	width   int
+}
+
+func (r *csvRowReader) next() (TaskTransferRow, []models.FieldError, error) {
+	var row TaskTransferRow
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return row, nil, err
+		}
+	}
+
+	record, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return row, nil, io.EOF
+	}
+	r.row++
+	if err != nil {
+		return row, nil, &malformedRowError{row: r.row, err: err}
+	}
+	if len(record) != r.width {
+		return row, []models.FieldError{{Message: fmt.Sprintf("has %d fields, the header has %d", len(record), r.width)}}, nil
+	}
+
+	get := func(column string) string {
+		if i, ok := r.columns[column]; ok {
+			return record[i]
+		}
+		return ""
+	}
+	row = TaskTransferRow{
+		ID:          get("id"),
+		CategoryID:  strings.TrimSpace(get("category_id")),
+		Category:    get("category"),
+		Type:        strings.TrimSpace(get("type")),
+		Language:    strings.TrimSpace(get("language")),
+		Text:        get("text"),
+		License:     get("license"),
+		Attribution: get("attribution"),
+	}
+
+	var errs []models.FieldError
+	if v := strings.TrimSpace(get("is_active")); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, models.FieldError{Field: "is_active", Message: "must be true or false"})
+		}
+		row.IsActive = &active
+	}
+	if v := strings.TrimSpace(get("requires_consent")); v != "" {
+		consent, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, models.FieldError{Field: "requires_consent", Message: "must be true or false"})
+		}
+		row.RequiresConsent = consent
+	}
+	return row, errs, nil
+}
+
+// readHeader reads the column names, which must include text, type,
+// language, and category_id or category
+func (r *csvRowReader) readHeader() error {
+	r.r.FieldsPerRecord = -1
+	r.r.ReuseRecord = true
+	header, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return &malformedRowError{row: 0, err: errors.New("file is empty")}
+	}
+	if err != nil {
+		return &malformedRowError{row: 0, err: err}
+	}
+
+	r.columns = make(map[string]int, len(header))
+	r.width = len(header)
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets save UTF-8 with a byte order mark
+			name = strings.TrimPrefix(name, "\uFEFF")
+		}
+		r.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, column := range []string{"text", "type", "language"} {
+		if _, ok := r.columns[column]; !ok {
+			return &malformedRowError{row: 0, err: fmt.Errorf("header has no %s column", column)}
+		}
+	}
+	_, hasID := r.columns["category_id"]
+	_, hasLabel := r.columns["category"]
+	if !hasID && !hasLabel {
+		return &malformedRowError{row: 0, err: errors.New("header has no category_id or category column")}
+	}
+	return nil
+}
+
+// taskRowWriter writes the rows of an export
+type taskRowWriter interface {
+	begin() error
+	write(TaskTransferRow) error
+	end() error
+}
+
+func newTaskRowWriter(format string, w io.Writer) taskRowWriter {
+	if format == TransferFormatCSV {
+		return &csvRowWriter{w: csv.NewWriter(w)}
+	}
+	return &jsonRowWriter{w: w}
+}
+
+// jsonRowWriter writes a JSON array, one element per line
+type jsonRowWriter struct {
+	w       io.Writer
+	buf     bytes.Buffer
+	enc     *json.Encoder
+	written bool
+}
+
+func (j *jsonRowWriter) begin() error {
+	j.enc = json.NewEncoder(&j.buf)
+	j.enc.SetEscapeHTML(false)
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonRowWriter) write(row TaskTransferRow) error {
+	sep := "\n"
+	if j.written {
+		sep = ",\n"
+	}
+	j.written = true
+	j.buf.Reset()
+	if err := j.enc.Encode(row); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err := j.w.Write(bytes.TrimSuffix(j.buf.Bytes(), []byte("\n")))
+	return err
+}
+
+func (j *jsonRowWriter) end() error {
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// csvRowWriter writes a header line and a line per row
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvRowWriter) begin() error {
+	return w.w.Write(taskTransferColumns)
+}
+
+func (w *csvRowWriter) write(row TaskTransferRow) error {
+	active := ""
+	if row.IsActive != nil {
+		active = strconv.FormatBool(*row.IsActive)
+	}
+	w.w.Write([]string{
+		row.ID, row.CategoryID, row.Category, row.Type, row.Language, row.Text,
+		active, strconv.FormatBool(row.RequiresConsent), row.License, row.Attribution,
+	})
+	// Hand the row on to the stream, which flushes after each batch
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvRowWriter) end() error {
+	w.w.Flush()
+	return w.w.Error()
+}
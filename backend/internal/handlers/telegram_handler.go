@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/integrations"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// telegramHelp lists the Telegram bot commands
+const telegramHelp = `Truth or Dare commands:
+/truth, /dare or /tod - draw a task, optionally in a language such as /dare es
+/language <code> - set the group's language
+/categories - list categories; /categories 1 3 picks some, /categories all resets
+/safe on|off - only tasks for kids while on
+/settings - show the group's settings`
+
+// telegramUpdate is the part of a Telegram update the bot reads
+type telegramUpdate struct {
+	Message *struct {
+		Text string `json:"text"`
+		From *struct {
+			LanguageCode string `json:"language_code"`
+		} `json:"from"`
+		Chat struct {
+			ID    int64  `json:"id"`
+			Type  string `json:"type"`
+			Title string `json:"title"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// TelegramReply answers a webhook update by calling sendMessage
+type TelegramReply struct {
+	Method string `json:"method"`
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// TelegramWebhook godoc
+// @Summary Handle a Telegram bot update
+// @Description Webhook for a Telegram bot, registered with setWebhook and secret_token TELEGRAM_WEBHOOK_SECRET. Answers commands in group chats with a sendMessage call in the response body: /truth, /dare and /tod draw a task, /language, /categories and /safe configure the group, /settings shows it. Groups register themselves on first use with safe mode on. Draws never need consent and skip the group's recent draws. Other updates are acknowledged with an empty object.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Success 200 {object} TelegramReply
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /integrations/telegram/webhook [post]
+func (h *ChatHandler) TelegramWebhook(c *gin.Context) {
+	middleware.SkipEnvelope(c)
+	if h.cfg.TelegramWebhookSecret == "" {
+		respondChatDisabled(c, "Telegram")
+		return
+	}
+	if err := integrations.VerifyTelegram(h.cfg.TelegramWebhookSecret, c.Request.Header); err != nil {
+		respondChatUnauthorized(c, err)
+		return
+	}
+	body, ok := readChatBody(c)
+	if !ok {
+		return
+	}
+
+	var update telegramUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid update payload",
+		})
+		return
+	}
+
+	message := update.Message
+	if message == nil || !strings.HasPrefix(message.Text, "/") {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	fields := strings.Fields(message.Text)
+	// Commands may be addressed to a bot, as in /dare@TruthOrDareBot
+	command, _, _ := strings.Cut(strings.ToLower(strings.TrimPrefix(fields[0], "/")), "@")
+	args := fields[1:]
+
+	reply := func(text string) {
+		c.JSON(http.StatusOK, TelegramReply{Method: "sendMessage", ChatID: message.Chat.ID, Text: text})
+	}
+	if command == "start" || command == "help" {
+		reply(telegramHelp)
+		return
+	}
+	switch command {
+	case "truth", "dare", "tod", "language", "categories", "safe", "settings":
+	default:
+		// Possibly meant for another bot in the group
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	if message.Chat.Type != "group" && message.Chat.Type != "supergroup" {
+		reply("Add me to a group chat to play Truth or Dare.")
+		return
+	}
+
+	language := ""
+	if message.From != nil {
+		language, _, _ = strings.Cut(message.From.LanguageCode, "-")
+	}
+	workspace, err := h.telegramGroup(strconv.FormatInt(message.Chat.ID, 10), message.Chat.Title, language)
+	if err != nil {
+		reply(err.Error())
+		return
+	}
+
+	var text string
+	switch command {
+	case "truth", "dare", "tod":
+		if command != "tod" {
+			args = append(args, command)
+		}
+		req, err := parseChatArgs(args)
+		if err == nil {
+			text, err = h.draw(workspace, workspace.WorkspaceID, req, "")
+		}
+		if err != nil {
+			text = err.Error()
+		}
+	case "language":
+		text = h.setTelegramLanguage(workspace, args)
+	case "categories":
+		text = h.setTelegramCategories(workspace, args)
+	case "safe":
+		text = h.setTelegramSafeMode(workspace, args)
+	case "settings":
+		text = h.telegramSettings(workspace)
+	}
+	reply(text)
+}
+
+// telegramGroup returns the workspace of a Telegram group, registering it
+// with safe mode on when it first uses the bot. Errors are messages for
+// the player.
+func (h *ChatHandler) telegramGroup(chatID, title, language string) (*models.ChatWorkspace, error) {
+	workspace, err := h.chats.FindWorkspace(models.ChatPlatformTelegram, chatID)
+	if err != nil {
+		if len(title) > 100 {
+			// Drop a rune cut in half along with the rest
+			title = strings.ToValidUTF8(title[:100], "")
+		}
+		if !models.IsValidLanguage(language) {
+			language = "en"
+		}
+		ageGroup := h.cfg.TelegramAgeGroup
+		if !models.IsValidAgeGroup(ageGroup) {
+			ageGroup = models.AgeGroupTeen
+		}
+		workspace = &models.ChatWorkspace{
+			Platform:    models.ChatPlatformTelegram,
+			WorkspaceID: chatID,
+			Name:        title,
+			AgeGroup:    ageGroup,
+			Language:    language,
+			SafeMode:    true,
+			IsActive:    true,
+		}
+		if err := h.chats.CreateWorkspace(workspace); err != nil {
+			// Another update may have registered the group first
+			if workspace, err = h.chats.FindWorkspace(models.ChatPlatformTelegram, chatID); err != nil {
+				return nil, fmt.Errorf("Could not set up this group, please try again.")
+			}
+		}
+	}
+	if !workspace.IsActive {
+		return nil, fmt.Errorf("This group is not enabled for Truth or Dare.")
+	}
+	return workspace, nil
+}
+
+// setTelegramLanguage sets or shows a group's language
+func (h *ChatHandler) setTelegramLanguage(workspace *models.ChatWorkspace, args []string) string {
+	if len(args) == 0 {
+		return fmt.Sprintf("Language: %s. Set it with /language <code>, one of %s.", workspace.Language, strings.Join(models.SupportedLanguages, ", "))
+	}
+	language := strings.ToLower(args[0])
+	if !models.IsValidLanguage(language) {
+		return fmt.Sprintf("Unsupported language %q. Use one of %s.", args[0], strings.Join(models.SupportedLanguages, ", "))
+	}
+	workspace.Language = language
+	return h.saveTelegramGroup(workspace, "Language set to "+language+".")
+}
+
+// setTelegramCategories lists the categories a group can draw from, or
+// limits its draws to those picked by number or ID
+func (h *ChatHandler) setTelegramCategories(workspace *models.ChatWorkspace, args []string) string {
+	active, consent := true, false
+	categories, err := h.categories.FindAll(&repository.CategoryFilter{
+		AgeGroups:       workspace.DrawAgeGroups(),
+		IsActive:        &active,
+		RequiresConsent: &consent,
+	})
+	if err != nil {
+		return "Could not load categories, please try again."
+	}
+
+	if len(args) == 0 {
+		selected := make(map[string]bool, len(workspace.CategoryIDs))
+		for _, id := range workspace.CategoryIDs {
+			selected[id] = true
+		}
+		var b strings.Builder
+		b.WriteString("Categories")
+		if len(selected) == 0 {
+			b.WriteString(" (all in play)")
+		}
+		b.WriteString(":")
+		for i, category := range categories {
+			mark := ""
+			if selected[category.ID] {
+				mark = " ✓"
+			}
+			fmt.Fprintf(&b, "\n%d. %s %s%s", i+1, category.Emoji, category.Label.Get(workspace.Language), mark)
+		}
+		b.WriteString("\nPick with /categories 1 3, or /categories all.")
+		return b.String()
+	}
+
+	if len(args) == 1 && strings.EqualFold(args[0], "all") {
+		workspace.CategoryIDs = nil
+		return h.saveTelegramGroup(workspace, "Drawing from all categories.")
+	}
+	var ids models.StringArray
+	for _, arg := range strings.FieldsFunc(strings.Join(args, " "), func(r rune) bool { return r == ' ' || r == ',' }) {
+		id := ""
+		if n, err := strconv.Atoi(arg); err == nil && n >= 1 && n <= len(categories) {
+			id = categories[n-1].ID
+		}
+		for _, category := range categories {
+			if category.ID == arg {
+				id = category.ID
+			}
+		}
+		if id == "" {
+			return fmt.Sprintf("Unknown category %q. List them with /categories.", arg)
+		}
+		ids = append(ids, id)
+	}
+	workspace.CategoryIDs = ids
+	return h.saveTelegramGroup(workspace, fmt.Sprintf("Drawing from %d categories.", len(ids)))
+}
+
+// setTelegramSafeMode turns a group's safe mode on or off, or shows it
+func (h *ChatHandler) setTelegramSafeMode(workspace *models.ChatWorkspace, args []string) string {
+	if len(args) == 0 {
+		return "Safe mode is " + onOff(workspace.SafeMode) + ". Use /safe on or /safe off."
+	}
+	switch strings.ToLower(args[0]) {
+	case "on":
+		workspace.SafeMode = true
+	case "off":
+		workspace.SafeMode = false
+	default:
+		return "Use /safe on or /safe off."
+	}
+	// Categories picked for one age group may not exist in the other
+	workspace.CategoryIDs = nil
+	return h.saveTelegramGroup(workspace, "Safe mode is "+onOff(workspace.SafeMode)+". Drawing from all categories.")
+}
+
+// telegramSettings describes a group's settings
+func (h *ChatHandler) telegramSettings(workspace *models.ChatWorkspace) string {
+	categories := "all"
+	if n := len(workspace.CategoryIDs); n > 0 {
+		categories = strconv.Itoa(n) + " picked"
+	}
+	return fmt.Sprintf("Language: %s\nCategories: %s\nSafe mode: %s\nAge group: %s",
+		workspace.Language, categories, onOff(workspace.SafeMode), strings.Join(workspace.DrawAgeGroups(), ", "))
+}
+
+// saveTelegramGroup stores a group's settings and returns done, or an
+// error message
+func (h *ChatHandler) saveTelegramGroup(workspace *models.ChatWorkspace, done string) string {
+	if err := h.chats.UpdateWorkspace(workspace); err != nil {
+		log.Error().Err(err).Str("chat_id", workspace.WorkspaceID).Msg("Failed to save Telegram group settings")
+		return "Could not save the settings, please try again."
+	}
+	return done
+}
+
+// onOff names a boolean setting
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
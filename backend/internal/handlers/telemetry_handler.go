@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// Telemetry ingestion limits
+const (
+	maxTelemetryEvents  = 100
+	maxTelemetryBody    = 64 << 10
+	telemetryMaxAgeDays = 7
+	telemetryDayLayout  = "2006-01-02"
+)
+
+// TelemetryHandler ingests anonymous play events and serves daily rollups
+type TelemetryHandler struct {
+	repo *repository.TelemetryRepository
+	now  func() time.Time
+}
+
+// NewTelemetryHandler creates a new TelemetryHandler
+func NewTelemetryHandler(repo *repository.TelemetryRepository) *TelemetryHandler {
+	return &TelemetryHandler{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// TelemetryEvent is a single anonymous play event. The schema is closed:
+// unknown fields are rejected so clients cannot send identifiers or free text.
+type TelemetryEvent struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	CategoryID string    `json:"category_id,omitempty"`
+	TaskID     string    `json:"task_id,omitempty"`
+	TaskType   string    `json:"task_type,omitempty"`
+	Language   string    `json:"language,omitempty"`
+	AgeGroup   string    `json:"age_group,omitempty"`
+}
+
+// TelemetryRequest is a batch of play events
+type TelemetryRequest struct {
+	Events []TelemetryEvent `json:"events"`
+}
+
+// TelemetryResponse reports how many events were recorded
+type TelemetryResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// TelemetryRollupsResponse is the admin view of daily rollups
+type TelemetryRollupsResponse struct {
+	Data   []models.TelemetryRollup `json:"data"`
+	Totals map[string]int64         `json:"totals"`
+}
+
+// Collect godoc
+// @Summary Submit anonymous telemetry
+// @Description Record a batch of up to 100 anonymous play events from a client that opted in. Events are validated against a closed schema (unknown fields are rejected) and folded into daily counters; nothing identifying the client is stored.
+// @Tags telemetry
+// @Accept json
+// @Produce json
+// @Param request body TelemetryRequest true "Play events"
+// @Success 202 {object} TelemetryResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /telemetry [post]
+func (h *TelemetryHandler) Collect(c *gin.Context) {
+	if c.Request.ContentLength > maxTelemetryBody {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error:   "payload_too_large",
+			Message: fmt.Sprintf("Telemetry batches are limited to %d bytes", maxTelemetryBody),
+		})
+		return
+	}
+
+	var req TelemetryRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxTelemetryBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid telemetry payload: " + err.Error(),
+		})
+		return
+	}
+
+	if len(req.Events) == 0 || len(req.Events) > maxTelemetryEvents {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: fmt.Sprintf("events must contain between 1 and %d entries", maxTelemetryEvents),
+		})
+		return
+	}
+
+	now := h.now().UTC()
+	oldest := now.AddDate(0, 0, -telemetryMaxAgeDays)
+
+	// Aggregate the batch first so each counter is written once
+	counts := make(map[models.TelemetryRollup]int64)
+	for i, event := range req.Events {
+		if err := validateTelemetryEvent(event, oldest, now); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: fmt.Sprintf("events[%d]: %s", i, err),
+			})
+			return
+		}
+
+		key := models.TelemetryRollup{
+			Day:        event.OccurredAt.UTC().Format(telemetryDayLayout),
+			Event:      event.Event,
+			CategoryID: event.CategoryID,
+			TaskID:     event.TaskID,
+			TaskType:   event.TaskType,
+			Language:   event.Language,
+			AgeGroup:   event.AgeGroup,
+		}
+		counts[key]++
+	}
+
+	rollups := make([]models.TelemetryRollup, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		key.UpdatedAt = now
+		rollups = append(rollups, key)
+	}
+
+	if err := h.repo.AddCounts(rollups); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record telemetry",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, TelemetryResponse{Accepted: len(req.Events)})
+}
+
+// validateTelemetryEvent checks an event against the telemetry schema
+func validateTelemetryEvent(event TelemetryEvent, oldest, now time.Time) error {
+	if !models.IsValidTelemetryEvent(event.Event) {
+		return fmt.Errorf("unknown event %q", event.Event)
+	}
+	if event.OccurredAt.IsZero() {
+		return fmt.Errorf("occurred_at is required")
+	}
+	// Allow a little clock skew on the client
+	if event.OccurredAt.Before(oldest) || event.OccurredAt.After(now.Add(time.Hour)) {
+		return fmt.Errorf("occurred_at must be within the last %d days", telemetryMaxAgeDays)
+	}
+	if event.CategoryID != "" {
+		if _, err := uuid.Parse(event.CategoryID); err != nil {
+			return fmt.Errorf("category_id must be a UUID")
+		}
+	}
+	if event.TaskID != "" {
+		if _, err := uuid.Parse(event.TaskID); err != nil {
+			return fmt.Errorf("task_id must be a UUID")
+		}
+	}
+	if event.TaskType != "" && !models.IsValidTaskType(event.TaskType) {
+		return fmt.Errorf("invalid task_type %q", event.TaskType)
+	}
+	if event.Language != "" && !models.IsValidLanguage(event.Language) {
+		return fmt.Errorf("invalid language %q", event.Language)
+	}
+	if event.AgeGroup != "" && !models.IsValidAgeGroup(event.AgeGroup) {
+		return fmt.Errorf("invalid age_group %q", event.AgeGroup)
+	}
+	return nil
+}
+
+// Rollups godoc
+// @Summary Get telemetry rollups
+// @Description Get daily event counters with optional filters, plus totals per event across the result
+// @Tags telemetry
+// @Produce json
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD)"
+// @Param event query string false "Event name"
+// @Param category_id query string false "Category ID"
+// @Param task_id query string false "Task ID"
+// @Param language query string false "Language code"
+// @Success 200 {object} TelemetryRollupsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /telemetry/rollups [get]
+func (h *TelemetryHandler) Rollups(c *gin.Context) {
+	filter := &repository.TelemetryFilter{
+		FromDay:    c.Query("from"),
+		ToDay:      c.Query("to"),
+		Event:      c.Query("event"),
+		CategoryID: c.Query("category_id"),
+		TaskID:     c.Query("task_id"),
+		Language:   c.Query("language"),
+	}
+
+	for _, day := range []string{filter.FromDay, filter.ToDay} {
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse(telemetryDayLayout, day); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "from and to must be dates in YYYY-MM-DD format",
+			})
+			return
+		}
+	}
+
+	rollups, err := h.repo.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch telemetry",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, TelemetryRollupsResponse{
+		Data:   rollups,
+		Totals: repository.TotalsByEvent(rollups),
+	})
+}
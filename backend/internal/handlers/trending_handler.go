@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// Trending defaults and bounds
+const (
+	defaultTrendingWindow   = "7d"
+	maxTrendingWindow       = 90 * 24 * time.Hour
+	defaultTrendingLimit    = 20
+	maxTrendingLimit        = 100
+	defaultTrendingMinServe = 10
+)
+
+// TrendingHandler ranks tasks by recent play telemetry
+type TrendingHandler struct {
+	telemetryRepo *repository.TelemetryRepository
+	taskRepo      *repository.TaskRepository
+	now           func() time.Time
+}
+
+// NewTrendingHandler creates a new TrendingHandler
+func NewTrendingHandler(telemetryRepo *repository.TelemetryRepository, taskRepo *repository.TaskRepository) *TrendingHandler {
+	return &TrendingHandler{
+		telemetryRepo: telemetryRepo,
+		taskRepo:      taskRepo,
+		now:           time.Now,
+	}
+}
+
+// TrendingTask is a task with its play counts over the window. Rates are
+// per serve and 0 for tasks without serves.
+type TrendingTask struct {
+	models.TaskResponse
+	Served         int64   `json:"served"`
+	Completed      int64   `json:"completed"`
+	Liked          int64   `json:"liked"`
+	CompletionRate float64 `json:"completion_rate"`
+	LikeRate       float64 `json:"like_rate"`
+}
+
+// TrendingResponse lists trending tasks, best first
+type TrendingResponse struct {
+	Window  string         `json:"window"`
+	FromDay string         `json:"from_day"`
+	Sort    string         `json:"sort"`
+	Data    []TrendingTask `json:"data"`
+}
+
+// Trending godoc
+// @Summary Get trending tasks
+// @Description Get the active tasks served most (or liked best) over a recent window, from the anonymous telemetry rollups. Counts are daily, so the window starts at the beginning of its first day. Sorting by like_rate skips tasks served fewer than min_served times.
+// @Tags tasks
+// @Produce json
+// @Param window query string false "Look-back window, e.g. 7d or 24h (max 90d)" default(7d)
+// @Param sort query string false "served or like_rate" default(served)
+// @Param min_served query int false "Serves a task needs to be ranked by like_rate" default(10)
+// @Param category_id query string false "Category ID filter"
+// @Param language query string false "Language code filter"
+// @Param type query string false "Task type filter (truth, dare)"
+// @Param limit query int false "Number of tasks (max 100)" default(20)
+// @Success 200 {object} TrendingResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /tasks/trending [get]
+func (h *TrendingHandler) Trending(c *gin.Context) {
+	window := c.DefaultQuery("window", defaultTrendingWindow)
+	duration, err := parseWindow(window)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sort := c.DefaultQuery("sort", "served")
+	if sort != "served" && sort != "like_rate" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "sort must be served or like_rate",
+		})
+		return
+	}
+
+	limit := parseNonNegativeInt(c.Query("limit"))
+	if limit == 0 {
+		limit = defaultTrendingLimit
+	}
+	if limit > maxTrendingLimit {
+		limit = maxTrendingLimit
+	}
+
+	minServed := int64(defaultTrendingMinServe)
+	if value := c.Query("min_served"); value != "" {
+		minServed = int64(parseNonNegativeInt(value))
+	}
+
+	fromDay := h.now().UTC().Add(-duration).Format(telemetryDayLayout)
+	counts, err := h.telemetryRepo.FindTrending(&repository.TrendingFilter{
+		FromDay:    fromDay,
+		CategoryID: c.Query("category_id"),
+		Language:   c.Query("language"),
+		Type:       c.Query("type"),
+		ByLikeRate: sort == "like_rate",
+		MinServed:  minServed,
+		Limit:      limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch trending tasks",
+		})
+		return
+	}
+
+	ids := make([]string, len(counts))
+	for i, count := range counts {
+		ids[i] = count.TaskID
+	}
+	tasks, err := h.taskRepo.FindByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch trending tasks",
+		})
+		return
+	}
+	byID := make(map[string]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	data := make([]TrendingTask, 0, len(counts))
+	for _, count := range counts {
+		task, ok := byID[count.TaskID]
+		if !ok {
+			continue
+		}
+		entry := TrendingTask{
+			TaskResponse: mapperFor(c).task(task),
+			Served:       count.Served,
+			Completed:    count.Completed,
+			Liked:        count.Liked,
+		}
+		if count.Served > 0 {
+			entry.CompletionRate = float64(count.Completed) / float64(count.Served)
+			entry.LikeRate = float64(count.Liked) / float64(count.Served)
+		}
+		data = append(data, entry)
+	}
+
+	c.JSON(http.StatusOK, TrendingResponse{
+		Window:  window,
+		FromDay: fromDay,
+		Sort:    sort,
+		Data:    data,
+	})
+}
+
+// parseWindow parses a look-back window: a number of days such as "7d",
+// or a Go duration such as "36h"
+func parseWindow(value string) (time.Duration, error) {
+	var duration time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("window must be a number of days (7d) or hours (24h)")
+		}
+		duration = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if duration, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("window must be a number of days (7d) or hours (24h)")
+		}
+	}
+
+	if duration <= 0 || duration > maxTrendingWindow {
+		return 0, fmt.Errorf("window must be positive and at most %d days", int(maxTrendingWindow.Hours()/24))
+	}
+	return duration, nil
+}
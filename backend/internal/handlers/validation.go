@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// respondFieldErrors sends a 400 listing the invalid fields
+func respondFieldErrors(c *gin.Context, errs []models.FieldError) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   "validation_error",
+		Message: "Invalid fields: " + errs[0].Field + " " + errs[0].Message,
+		Fields:  errs,
+	})
+}
+
+// validateTaskRequest checks a task's text, language and licensing. prefix
+// names the task in a batch, e.g. tasks[2].
+func validateTaskRequest(prefix string, req CreateTaskRequest) []models.FieldError {
+	errs := models.ValidateText(prefix+"text", req.Text, models.MaxTaskTextLength)
+	if !models.IsValidLanguage(req.Language) {
+		errs = append(errs, models.FieldError{Field: prefix + "language", Message: "unsupported language code"})
+	}
+	return append(errs, models.ValidateLicense(prefix, req.License, req.Attribution)...)
+}
+
+// validateEmoji normalizes a category emoji, adding a field error when it
+// is not a single emoji
+func validateEmoji(emoji string, errs []models.FieldError) (string, []models.FieldError) {
+	normalized, ok := models.NormalizeEmoji(emoji)
+	if !ok {
+		return emoji, append(errs, models.FieldError{Field: "emoji", Message: "must be a single emoji"})
+	}
+	return normalized, errs
+}
+
+// validateLabel checks a category label. A required label needs at least
+// one language.
+func validateLabel(label models.MultilingualText, required bool) []models.FieldError {
+	if required && len(label) == 0 {
+		return []models.FieldError{{Field: "label", Message: "must contain at least one language"}}
+	}
+	return label.Validate("label", models.MaxLabelLength)
+}
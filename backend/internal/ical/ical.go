@@ -0,0 +1,136 @@
+// Package ical writes iCalendar feeds (RFC 5545) that calendar apps can
+// subscribe to.
+package ical
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of an iCalendar feed
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest content line RFC 5545 allows, without the
+// line break
+const maxLineOctets = 75
+
+// Event is a calendar event. Times are written in UTC.
+type Event struct {
+	// UID identifies the event across refreshes of the feed.
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	// Cancelled marks events that are listed but will not happen.
+	Cancelled bool
+}
+
+// Calendar is a feed of events.
+type Calendar struct {
+	// Name is shown by calendar apps for the subscription.
+	Name string
+	// Refresh is how often subscribers should fetch the feed again; zero
+	// leaves it to them.
+	Refresh time.Duration
+	Events  []Event
+}
+
+// Write writes the calendar, stamped with the time it was generated.
+func (cal Calendar) Write(w io.Writer, stamp time.Time) error {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Truth or Dare//Backend//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+	if cal.Refresh > 0 {
+		refresh := duration(cal.Refresh)
+		writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:"+refresh)
+		line("X-PUBLISHED-TTL", refresh)
+	}
+	for _, event := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(event.UID))
+		line("DTSTAMP", timestamp(stamp))
+		line("DTSTART", timestamp(event.Start))
+		line("DTEND", timestamp(event.End))
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Cancelled {
+			line("STATUS", "CANCELLED")
+		}
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeLine writes a content line, folding it into continuation lines of
+// at most maxLineOctets without splitting a UTF-8 sequence
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// escape escapes a TEXT value
+var escape = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+).Replace
+
+// timestamp formats a time as a UTC DATE-TIME
+func timestamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// duration formats a positive duration as a DURATION value in whole
+// seconds, such as PT1H30M
+func duration(d time.Duration) string {
+	seconds := int64(d.Round(time.Second) / time.Second)
+	var b strings.Builder
+	b.WriteString("PT")
+	for _, unit := range []struct {
+		seconds int64
+		suffix  string
+	}{{3600, "H"}, {60, "M"}, {1, "S"}} {
+		if n := seconds / unit.seconds; n > 0 {
+			b.WriteString(strconv.FormatInt(n, 10) + unit.suffix)
+			seconds %= unit.seconds
+		}
+	}
+	if b.Len() == 2 {
+		b.WriteString("0S")
+	}
+	return b.String()
+}
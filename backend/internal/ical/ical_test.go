@@ -0,0 +1,73 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Write(t *testing.T) {
+	start := time.Date(2026, 10, 18, 3, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	cal := Calendar{
+		Name:    "Jobs",
+		Refresh: 90 * time.Minute,
+		Events: []Event{{
+			UID:         "job-1@test",
+			Summary:     "cleanup; daily, at 03:00",
+			Description: "Line one\nline two with a long tail of text " + strings.Repeat("é", 40),
+			Start:       start,
+			End:         start.Add(time.Minute),
+			Cancelled:   true,
+		}},
+	}
+
+	var b strings.Builder
+	if err := cal.Write(&b, start); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out := b.String()
+	if !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Errorf("Expected CRLF line endings, got %q", out)
+	}
+
+	for _, want := range []string{
+		"X-WR-CALNAME:Jobs\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H30M\r\n",
+		"DTSTART:20261018T010000Z\r\n",
+		"DTEND:20261018T010100Z\r\n",
+		`SUMMARY:cleanup\; daily\, at 03:00` + "\r\n",
+		"STATUS:CANCELLED\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in\n%s", want, out)
+		}
+	}
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("Expected lines of at most %d octets, got %d: %q", maxLineOctets, len(line), line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	if want := "\nDESCRIPTION:" + `Line one\nline two` + " with a long tail of text " + strings.Repeat("é", 40) + "\n"; !strings.Contains(unfolded.String(), want) {
+		t.Errorf("Expected folded lines to unfold to %q, got %q", want, unfolded.String())
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		time.Hour:                    "PT1H",
+		90 * time.Second:             "PT1M30S",
+		25*time.Hour + 5*time.Second: "PT25H5S",
+		0:                            "PT0S",
+	} {
+		if got := duration(d); got != want {
+			t.Errorf("Expected %s for %v, got %s", want, d, got)
+		}
+	}
+}
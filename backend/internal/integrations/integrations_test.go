@@ -0,0 +1,68 @@
+package integrations_test
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/integrations"
+)
+
+func TestVerifySlack(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	body := []byte("team_id=T1&channel_id=C1&text=dare")
+	header := integrations.SignSlack("slack-secret", body, now)
+
+	assert.NoError(t, integrations.VerifySlack("slack-secret", header, body, now.Add(time.Minute)))
+	assert.ErrorIs(t, integrations.VerifySlack("other-secret", header, body, now), integrations.ErrInvalid)
+	assert.ErrorIs(t, integrations.VerifySlack("slack-secret", header, []byte("team_id=T1&channel_id=C1&text=truth"), now), integrations.ErrInvalid)
+	assert.ErrorIs(t, integrations.VerifySlack("slack-secret", header, body, now.Add(10*time.Minute)), integrations.ErrStale)
+	assert.ErrorIs(t, integrations.VerifySlack("slack-secret", http.Header{}, body, now), integrations.ErrUnsigned)
+
+	replayed := header.Clone()
+	replayed.Set(integrations.SlackTimestampHeader, strconv.FormatInt(now.Add(time.Minute).Unix(), 10))
+	assert.ErrorIs(t, integrations.VerifySlack("slack-secret", replayed, body, now), integrations.ErrInvalid, "the timestamp is signed")
+}
+
+func TestVerifyDiscord(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	key, err := integrations.ParseDiscordKey(hex.EncodeToString(public))
+	require.NoError(t, err)
+
+	now := time.Unix(1_800_000_000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"type":1}`)
+	header := http.Header{}
+	header.Set(integrations.DiscordTimestampHeader, timestamp)
+	header.Set(integrations.DiscordSignatureHeader, hex.EncodeToString(ed25519.Sign(private, append([]byte(timestamp), body...))))
+
+	assert.NoError(t, integrations.VerifyDiscord(key, header, body, now))
+	assert.ErrorIs(t, integrations.VerifyDiscord(key, header, []byte(`{"type":2}`), now), integrations.ErrInvalid)
+	assert.ErrorIs(t, integrations.VerifyDiscord(key, header, body, now.Add(time.Hour)), integrations.ErrStale)
+	assert.ErrorIs(t, integrations.VerifyDiscord(key, http.Header{}, body, now), integrations.ErrUnsigned)
+
+	t.Run("parse key", func(t *testing.T) {
+		key, err := integrations.ParseDiscordKey("")
+		assert.NoError(t, err)
+		assert.Nil(t, key)
+		_, err = integrations.ParseDiscordKey("abcd")
+		assert.Error(t, err)
+		_, err = integrations.ParseDiscordKey("not hex")
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyTelegram(t *testing.T) {
+	header := http.Header{}
+	assert.ErrorIs(t, integrations.VerifyTelegram("telegram-secret", header), integrations.ErrUnsigned)
+	header.Set(integrations.TelegramSecretHeader, "guess")
+	assert.ErrorIs(t, integrations.VerifyTelegram("telegram-secret", header), integrations.ErrInvalid)
+	header.Set(integrations.TelegramSecretHeader, "telegram-secret")
+	assert.NoError(t, integrations.VerifyTelegram("telegram-secret", header))
+}
@@ -0,0 +1,172 @@
+// Package loadtest provides a local load-test harness for the public API.
+//
+// It seeds a SQLite database with a large number of tasks, serves the full
+// router through an httptest server and measures request latencies against
+// per-endpoint targets. It is meant to catch performance regressions in
+// repository changes before they reach production.
+//
+// The latency suite is skipped by default because seeding takes a while:
+//
+//	LOADTEST=1 go test ./internal/loadtest -run TestLatencyTargets -v
+//	LOADTEST=1 go test ./internal/loadtest -run '^$' -bench .
+//
+// LOADTEST_TASKS overrides the number of seeded tasks (default 100000).
+// Admin targets authenticate with the key the server accepts, from
+// ADMIN_OTP_KEY or ADMIN_OTP_KEYS.
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// DefaultTaskCount is the number of tasks seeded when LOADTEST_TASKS is unset.
+const DefaultTaskCount = 100000
+
+// Target describes an endpoint and its latency budget.
+type Target struct {
+	Name  string
+	Path  string
+	Admin bool          // Send the admin auth header
+	P95   time.Duration // 95th percentile latency budget
+}
+
+// Targets are the hot public paths and their latency budgets.
+var Targets = []Target{
+	{Name: "list", Path: "/api/v1/tasks?languages=en&types=truth&limit=20", P95: 50 * time.Millisecond},
+	{Name: "random", Path: "/api/v1/tasks/random?language=en&type=dare", Admin: true, P95: 50 * time.Millisecond},
+	{Name: "availability", Path: "/api/v1/tasks/availability?languages=en,hi", P95: 50 * time.Millisecond},
+}
+
+// Result holds latency statistics for one target.
+type Result struct {
+	Target   Target
+	Requests int
+	Errors   int
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+// WithinBudget reports whether the measured p95 meets the target.
+func (r Result) WithinBudget() bool {
+	return r.P95 <= r.Target.P95
+}
+
+// String formats the result as a single summary line.
+func (r Result) String() string {
+	return fmt.Sprintf("%-12s n=%d err=%d p50=%v p95=%v (budget %v) p99=%v max=%v",
+		r.Target.Name, r.Requests, r.Errors, r.P50, r.P95, r.Target.P95, r.P99, r.Max)
+}
+
+// Seed inserts categories and n tasks spread evenly across every supported
+// language, both task types and several categories.
+func Seed(db *gorm.DB, n int) error {
+	categories := make([]models.Category, 0, 6)
+	for i, group := range []string{models.AgeGroupKids, models.AgeGroupTeen, models.AgeGroupAdults} {
+		for j := 0; j < 2; j++ {
+			categories = append(categories, models.Category{
+				Label:     models.MultilingualText{"en": fmt.Sprintf("Load %s %d", group, j)},
+				Emoji:     "📈",
+				AgeGroup:  group,
+				IsActive:  true,
+				SortOrder: i*2 + j,
+			})
+		}
+	}
+	if err := db.Create(&categories).Error; err != nil {
+		return err
+	}
+
+	const batch = 1000
+	tasks := make([]models.Task, 0, batch)
+	for i := 0; i < n; i++ {
+		// Alternate type per language cycle so every language has both types
+		taskType := models.TaskTypeTruth
+		if (i/len(models.SupportedLanguages))%2 == 1 {
+			taskType = models.TaskTypeDare
+		}
+		tasks = append(tasks, models.Task{
+			Text:       fmt.Sprintf("Load test task %d", i),
+			Type:       taskType,
+			Language:   models.SupportedLanguages[i%len(models.SupportedLanguages)],
+			CategoryID: categories[i%len(categories)].ID,
+		})
+		if len(tasks) == batch || i == n-1 {
+			if err := db.CreateInBatches(tasks, 250).Error; err != nil {
+				return err
+			}
+			tasks = tasks[:0]
+		}
+	}
+
+	return nil
+}
+
+// Do performs a single request against the target.
+func Do(client *http.Client, baseURL string, target Target) error {
+	req, err := http.NewRequest(http.MethodGet, baseURL+target.Path, nil)
+	if err != nil {
+		return err
+	}
+	if target.Admin {
+		key, ok := middleware.AdminKey()
+		if !ok {
+			return fmt.Errorf("%s: no admin key set (ADMIN_OTP_KEY)", target.Name)
+		}
+		req.Header.Set(middleware.AuthHeader, key)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d: %s", target.Name, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// Run issues the given number of sequential requests and reports latencies.
+func Run(client *http.Client, baseURL string, target Target, requests int) Result {
+	latencies := make([]time.Duration, 0, requests)
+	result := Result{Target: target, Requests: requests}
+
+	for i := 0; i < requests; i++ {
+		start := time.Now()
+		if err := Do(client, baseURL, target); err != nil {
+			result.Errors++
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.P50 = percentile(latencies, 0.50)
+	result.P95 = percentile(latencies, 0.95)
+	result.P99 = percentile(latencies, 0.99)
+	if len(latencies) > 0 {
+		result.Max = latencies[len(latencies)-1]
+	}
+
+	return result
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
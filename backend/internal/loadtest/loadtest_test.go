@@ -0,0 +1,135 @@
+package loadtest_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/loadtest"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/server"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	setupOnce sync.Once
+	baseURL   string
+	setupErr  error
+)
+
+// startServer seeds the database and starts the API once per test binary.
+func startServer(tb testing.TB) string {
+	tb.Helper()
+
+	if os.Getenv("LOADTEST") == "" {
+		tb.Skip("set LOADTEST=1 to run the load-test suite")
+	}
+
+	setupOnce.Do(func() {
+		taskCount := loadtest.DefaultTaskCount
+		if v, err := strconv.Atoi(os.Getenv("LOADTEST_TASKS")); err == nil && v > 0 {
+			taskCount = v
+		}
+
+		dir, err := os.MkdirTemp("", "tod-loadtest")
+		if err != nil {
+			setupErr = err
+			return
+		}
+
+		db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "loadtest.db")), &gorm.Config{
+			Logger:                 logger.Default.LogMode(logger.Silent),
+			PrepareStmt:            true,
+			SkipDefaultTransaction: true,
+		})
+		if err != nil {
+			setupErr = err
+			return
+		}
+		if setupErr = database.Migrate(db); setupErr != nil {
+			return
+		}
+
+		start := time.Now()
+		if setupErr = loadtest.Seed(db, taskCount); setupErr != nil {
+			return
+		}
+		tb.Logf("seeded %d tasks in %v", taskCount, time.Since(start))
+
+		gin.SetMode(gin.TestMode)
+		gin.DefaultWriter = io.Discard
+
+		srv := server.New(&config.Config{
+			Env:       "loadtest",
+			APIPrefix: "/api",
+		}, db)
+		baseURL = httptest.NewServer(srv.Handler()).URL
+	})
+
+	if setupErr != nil {
+		tb.Fatalf("failed to set up load test: %v", setupErr)
+	}
+	return baseURL
+}
+
+func TestLatencyTargets(t *testing.T) {
+	url := startServer(t)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, target := range loadtest.Targets {
+		t.Run(target.Name, func(t *testing.T) {
+			// Warm up caches and prepared statements
+			loadtest.Run(client, url, target, 10)
+
+			result := loadtest.Run(client, url, target, 200)
+			t.Log(result)
+
+			if result.Errors > 0 {
+				t.Errorf("%d of %d requests failed", result.Errors, result.Requests)
+			}
+			if !result.WithinBudget() {
+				t.Errorf("p95 latency %v exceeds budget %v", result.P95, target.P95)
+			}
+		})
+	}
+}
+
+func BenchmarkEndpoints(b *testing.B) {
+	url := startServer(b)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, target := range loadtest.Targets {
+		b.Run(target.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := loadtest.Do(client, url, target); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDo_AdminKey(t *testing.T) {
+	t.Setenv("ADMIN_OTP_KEY", "deployment-key")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(middleware.AuthHeader) != "deployment-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	if err := loadtest.Do(srv.Client(), srv.URL, loadtest.Target{Name: "admin", Path: "/", Admin: true}); err != nil {
+		t.Fatalf("Expected the configured admin key to be sent, got %v", err)
+	}
+}
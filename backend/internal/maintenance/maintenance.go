@@ -0,0 +1,143 @@
+// Package maintenance holds instance-wide operating modes, such as the
+// read-only mode used during migrations, restores and incident response,
+// and the scheduled maintenance windows that take public endpoints offline.
+package maintenance
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrReadOnly is returned when a write is refused because read-only mode is on.
+var ErrReadOnly = errors.New("read-only mode is enabled")
+
+// Mode tracks whether the instance accepts writes. It is safe for
+// concurrent use. A nil *Mode is valid and never read-only.
+type Mode struct {
+	mu       sync.RWMutex
+	readOnly bool
+	reason   string
+	since    time.Time
+	windows  []Window
+}
+
+// Status describes the current mode.
+type Status struct {
+	ReadOnly           bool       `json:"read_only"`
+	Reason             string     `json:"reason,omitempty"`
+	Since              *time.Time `json:"since,omitempty"`
+	MaintenanceUntil   *time.Time `json:"maintenance_until,omitempty"`
+	MaintenanceWindows []string   `json:"maintenance_windows,omitempty"`
+}
+
+// New creates a Mode, read-only from the start when readOnly is set.
+func New(readOnly bool) *Mode {
+	m := &Mode{}
+	if readOnly {
+		m.Set(true, "enabled at startup")
+	}
+	return m
+}
+
+// ReadOnly reports whether writes are currently refused.
+func (m *Mode) ReadOnly() bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.readOnly
+}
+
+// Set switches read-only mode on or off. The reason is kept while the mode
+// is on and cleared when it is switched off.
+func (m *Mode) Set(readOnly bool, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if readOnly == m.readOnly {
+		if readOnly {
+			m.reason = reason
+		}
+		return
+	}
+
+	m.readOnly = readOnly
+	if readOnly {
+		m.reason = reason
+		m.since = time.Now().UTC()
+	} else {
+		m.reason = ""
+		m.since = time.Time{}
+	}
+}
+
+// Status returns a snapshot of the current mode.
+func (m *Mode) Status() Status {
+	if m == nil {
+		return Status{}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := Status{ReadOnly: m.readOnly, Reason: m.reason}
+	if m.readOnly {
+		since := m.since
+		status.Since = &since
+	}
+	for _, window := range m.windows {
+		status.MaintenanceWindows = append(status.MaintenanceWindows, window.Spec)
+	}
+	if ok, until := m.inWindow(time.Now()); ok {
+		status.MaintenanceUntil = &until
+	}
+	return status
+}
+
+// SetWindows replaces the scheduled maintenance windows.
+func (m *Mode) SetWindows(windows []Window) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = windows
+}
+
+// Windows returns the scheduled maintenance windows.
+func (m *Mode) Windows() []Window {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.windows
+}
+
+// InMaintenance reports whether now falls inside a maintenance window and,
+// if so, when the last overlapping window closes.
+func (m *Mode) InMaintenance(now time.Time) (bool, time.Time) {
+	if m == nil {
+		return false, time.Time{}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.inWindow(now)
+}
+
+// inWindow checks the windows; the caller holds the lock
+func (m *Mode) inWindow(now time.Time) (bool, time.Time) {
+	var open bool
+	var until time.Time
+	for _, window := range m.windows {
+		if ok, end := window.Contains(now); ok {
+			open = true
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+	return open, until
+}
@@ -0,0 +1,63 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// windowParser parses window starts with the same five-field syntax as the
+// scheduler's job specs.
+var windowParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// Window is a recurring maintenance window: it opens at every activation
+// of a cron expression and lasts for Duration.
+type Window struct {
+	Spec     string
+	Duration time.Duration
+	schedule cron.Schedule
+}
+
+// ParseWindows parses a semicolon-separated list of cron expressions, each
+// opening a window of the given duration. An empty list yields no windows.
+func ParseWindows(specs string, duration time.Duration) ([]Window, error) {
+	var windows []Window
+	for _, spec := range strings.Split(specs, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("maintenance window %q: duration must be positive", spec)
+		}
+		schedule, err := windowParser.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %q: %w", spec, err)
+		}
+		windows = append(windows, Window{Spec: spec, Duration: duration, schedule: schedule})
+	}
+	return windows, nil
+}
+
+// Contains reports whether t falls inside the window and, if so, when the
+// window closes.
+func (w Window) Contains(t time.Time) (bool, time.Time) {
+	// The window is open when it started within the last Duration, that is
+	// when its next start after t-Duration is not after t
+	start := w.schedule.Next(t.Add(-w.Duration))
+	if start.IsZero() || start.After(t) {
+		return false, time.Time{}
+	}
+	// Starts may repeat within one window; the latest one decides the close
+	for next := w.schedule.Next(start); !next.IsZero() && !next.After(t); next = w.schedule.Next(next) {
+		start = next
+	}
+	return true, start.Add(w.Duration)
+}
+
+// Next returns the first time the window opens after t.
+func (w Window) Next(t time.Time) time.Time {
+	return w.schedule.Next(t)
+}
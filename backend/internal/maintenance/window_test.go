@@ -0,0 +1,85 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows, err := ParseWindows("0 3 * * 0; 30 12 1 * *", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(windows))
+	}
+
+	if windows, err := ParseWindows("", time.Minute); err != nil || len(windows) != 0 {
+		t.Errorf("Expected no windows for an empty spec, got %d (%v)", len(windows), err)
+	}
+	if _, err := ParseWindows("not a cron", time.Minute); err == nil {
+		t.Error("Expected an error for an invalid spec")
+	}
+	if _, err := ParseWindows("0 3 * * 0", 0); err == nil {
+		t.Error("Expected an error for a zero duration")
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	// Sundays 03:00-03:30
+	windows, err := ParseWindows("0 3 * * 0", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	window := windows[0]
+
+	sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		{"before", sunday.Add(2*time.Hour + 59*time.Minute), false},
+		{"at start", sunday.Add(3 * time.Hour), true},
+		{"inside", sunday.Add(3*time.Hour + 29*time.Minute), true},
+		{"at end", sunday.Add(3*time.Hour + 30*time.Minute), false},
+		{"other day", sunday.AddDate(0, 0, 1).Add(3*time.Hour + 10*time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, until := window.Contains(tt.at)
+			if open != tt.open {
+				t.Fatalf("Expected open=%v, got %v", tt.open, open)
+			}
+			if open && !until.Equal(sunday.Add(3*time.Hour+30*time.Minute)) {
+				t.Errorf("Expected window to close at 03:30, got %v", until)
+			}
+		})
+	}
+}
+
+func TestMode_InMaintenance(t *testing.T) {
+	var nilMode *Mode
+	if open, _ := nilMode.InMaintenance(time.Now()); open {
+		t.Error("Expected a nil mode never to be in maintenance")
+	}
+
+	// Two overlapping windows: the later close wins
+	short, _ := ParseWindows("* * * * *", time.Minute)
+	long, _ := ParseWindows("* * * * *", time.Hour)
+	mode := New(false)
+	mode.SetWindows(append(short, long...))
+
+	now := time.Now()
+	open, until := mode.InMaintenance(now)
+	if !open {
+		t.Fatal("Expected maintenance window to be open")
+	}
+	if until.Sub(now) <= time.Minute {
+		t.Errorf("Expected the longest window to decide the close, got %v", until.Sub(now))
+	}
+	if status := mode.Status(); status.MaintenanceUntil == nil || len(status.MaintenanceWindows) != 2 {
+		t.Errorf("Expected status to report the open windows, got %+v", status)
+	}
+}
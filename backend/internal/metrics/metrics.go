@@ -0,0 +1,290 @@
+// Package metrics provides a small in-process metrics registry rendered in
+// the Prometheus text exposition format.
+//
+// Metrics are registered once, usually in a package-level var, and updated
+// from anywhere in the application:
+//
+//	var generated = metrics.NewCounter("tod_tasks_generated_total", "Tasks created by AI generation")
+//	generated.Inc()
+//
+// Values that already live elsewhere (breaker state, queue lengths) are
+// exposed with NewGaugeFunc, or NewGaugeVecFunc when labelled, so they are
+// read at scrape time.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Labels are metric dimensions, e.g. {"job": "cleanup"}
+type Labels map[string]string
+
+// Registry holds a set of metrics
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+// metric is implemented by every metric type
+type metric interface {
+	name() string
+	help() string
+	kind() string
+	samples() []sample
+}
+
+type sample struct {
+	labels Labels
+	value  float64
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+// register adds m, or returns the metric already registered under its name
+func (r *Registry) register(m metric) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[m.name()]; ok {
+		return existing
+	}
+	r.metrics[m.name()] = m
+	return m
+}
+
+// WritePrometheus writes all metrics in the Prometheus text format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.mu.RLock()
+		m := r.metrics[name]
+		r.mu.RUnlock()
+
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, m.help(), name, m.kind()); err != nil {
+			return err
+		}
+		for _, s := range m.samples() {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(s.labels), formatValue(s.value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// vec stores one value per distinct label set
+type vec struct {
+	metricName string
+	metricHelp string
+
+	mu     sync.Mutex
+	values map[string]*sample
+}
+
+func newVec(name, help string) vec {
+	return vec{metricName: name, metricHelp: help, values: make(map[string]*sample)}
+}
+
+func (v *vec) name() string { return v.metricName }
+func (v *vec) help() string { return v.metricHelp }
+
+func (v *vec) add(labels Labels, delta float64, set bool) {
+	key := formatLabels(labels)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.values[key]
+	if !ok {
+		s = &sample{labels: copyLabels(labels)}
+		v.values[key] = s
+	}
+	if set {
+		s.value = delta
+	} else {
+		s.value += delta
+	}
+}
+
+func (v *vec) get(labels Labels) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if s, ok := v.values[formatLabels(labels)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *vec) samples() []sample {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]sample, len(keys))
+	for i, key := range keys {
+		out[i] = *v.values[key]
+	}
+	return out
+}
+
+// Counter is a monotonically increasing value
+type Counter struct{ vec }
+
+// NewCounter registers a counter in the default registry
+func NewCounter(name, help string) *Counter {
+	return Default.register(&Counter{newVec(name, help)}).(*Counter)
+}
+
+func (c *Counter) kind() string { return "counter" }
+
+// Inc adds one to the unlabelled counter
+func (c *Counter) Inc() { c.add(nil, 1, false) }
+
+// Add adds delta to the unlabelled counter
+func (c *Counter) Add(delta float64) { c.add(nil, delta, false) }
+
+// IncWith adds one to the counter with the given labels
+func (c *Counter) IncWith(labels Labels) { c.add(labels, 1, false) }
+
+// AddWith adds delta to the counter with the given labels
+func (c *Counter) AddWith(labels Labels, delta float64) { c.add(labels, delta, false) }
+
+// Value returns the current value for the given labels
+func (c *Counter) Value(labels Labels) float64 { return c.get(labels) }
+
+// Gauge is a value that can go up and down
+type Gauge struct{ vec }
+
+// NewGauge registers a gauge in the default registry
+func NewGauge(name, help string) *Gauge {
+	return Default.register(&Gauge{newVec(name, help)}).(*Gauge)
+}
+
+func (g *Gauge) kind() string { return "gauge" }
+
+// Set sets the unlabelled gauge
+func (g *Gauge) Set(value float64) { g.add(nil, value, true) }
+
+// SetWith sets the gauge with the given labels
+func (g *Gauge) SetWith(labels Labels, value float64) { g.add(labels, value, true) }
+
+// Value returns the current value for the given labels
+func (g *Gauge) Value(labels Labels) float64 { return g.get(labels) }
+
+// GaugeFunc is a gauge whose value is computed at scrape time
+type GaugeFunc struct {
+	metricName string
+	metricHelp string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers a computed gauge in the default registry
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return Default.register(&GaugeFunc{metricName: name, metricHelp: help, fn: fn}).(*GaugeFunc)
+}
+
+func (g *GaugeFunc) name() string      { return g.metricName }
+func (g *GaugeFunc) help() string      { return g.metricHelp }
+func (g *GaugeFunc) kind() string      { return "gauge" }
+func (g *GaugeFunc) samples() []sample { return []sample{{value: g.fn()}} }
+
+// LabeledValue is one sample of a GaugeVecFunc
+type LabeledValue struct {
+	Labels Labels
+	Value  float64
+}
+
+// GaugeVecFunc is a labelled gauge whose values are computed at scrape time
+type GaugeVecFunc struct {
+	metricName string
+	metricHelp string
+	fn         func() []LabeledValue
+}
+
+// NewGaugeVecFunc registers a computed labelled gauge in the default registry
+func NewGaugeVecFunc(name, help string, fn func() []LabeledValue) *GaugeVecFunc {
+	return Default.register(&GaugeVecFunc{metricName: name, metricHelp: help, fn: fn}).(*GaugeVecFunc)
+}
+
+func (g *GaugeVecFunc) name() string { return g.metricName }
+func (g *GaugeVecFunc) help() string { return g.metricHelp }
+func (g *GaugeVecFunc) kind() string { return "gauge" }
+
+func (g *GaugeVecFunc) samples() []sample {
+	values := g.fn()
+	out := make([]sample, len(values))
+	for i, v := range values {
+		out[i] = sample{labels: copyLabels(v.Labels), value: v.Value}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return formatLabels(out[i].labels) < formatLabels(out[j].labels)
+	})
+	return out
+}
+
+func copyLabels(labels Labels) Labels {
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(Labels, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+// formatLabels renders labels as {k="v",...} in key order
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + strconv.Quote(labels[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
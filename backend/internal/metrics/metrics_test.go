@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	registry := NewRegistry()
+
+	counter := registry.register(&Counter{newVec("test_requests_total", "Requests")}).(*Counter)
+	counter.IncWith(Labels{"result": "success"})
+	counter.IncWith(Labels{"result": "success"})
+	counter.AddWith(Labels{"result": "failure"}, 3)
+
+	gauge := registry.register(&Gauge{newVec("test_queue_length", "Queue length")}).(*Gauge)
+	gauge.Set(7)
+
+	registry.register(&GaugeFunc{metricName: "test_state", metricHelp: "State", fn: func() float64 { return 2 }})
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.WritePrometheus(&buf))
+
+	assert.Equal(t, `# HELP test_queue_length Queue length
+# TYPE test_queue_length gauge
+test_queue_length 7
+# HELP test_requests_total Requests
+# TYPE test_requests_total counter
+test_requests_total{result="failure"} 3
+test_requests_total{result="success"} 2
+# HELP test_state State
+# TYPE test_state gauge
+test_state 2
+`, buf.String())
+}
+
+func TestGaugeVecFunc(t *testing.T) {
+	registry := NewRegistry()
+	registry.register(&GaugeVecFunc{metricName: "test_age_seconds", metricHelp: "Age", fn: func() []LabeledValue {
+		return []LabeledValue{
+			{Labels: Labels{"language": "hi"}, Value: math.Inf(1)},
+			{Labels: Labels{"language": "en"}, Value: 60},
+		}
+	}})
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.WritePrometheus(&buf))
+
+	assert.Equal(t, `# HELP test_age_seconds Age
+# TYPE test_age_seconds gauge
+test_age_seconds{language="en"} 60
+test_age_seconds{language="hi"} +Inf
+`, buf.String())
+}
+
+func TestRegistry_RegisterTwice(t *testing.T) {
+	registry := NewRegistry()
+
+	first := registry.register(&Counter{newVec("dup_total", "Dup")}).(*Counter)
+	second := registry.register(&Counter{newVec("dup_total", "Dup")}).(*Counter)
+	first.Inc()
+
+	assert.Same(t, first, second)
+	assert.Equal(t, float64(1), second.Value(nil))
+}
+
+func TestFormatLabels(t *testing.T) {
+	assert.Equal(t, "", formatLabels(nil))
+	assert.Equal(t, `{a="1",b="x\"y"}`, formatLabels(Labels{"b": `x"y`, "a": "1"}))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionKey is the context key holding the API version a request was
+// routed through, e.g. "v1"
+const APIVersionKey = "api_version"
+
+// Deprecation describes a deprecated API version and its successor
+type Deprecation struct {
+	// Prefix is the deprecated version's path prefix, e.g. "/api/v1"
+	Prefix string
+	// Successor is the replacing version's path prefix, e.g. "/api/v2"
+	Successor string
+	// Sunset is when the version may be removed; zero when not yet planned
+	Sunset time.Time
+}
+
+// APIVersionMiddleware tags requests with the API version they were routed
+// through. Requests to a deprecated version get a Deprecation header, a
+// Link to the same path on the successor version and, when planned, a
+// Sunset header (RFC 8594). A nil deprecation marks a current version.
+func APIVersionMiddleware(version string, deprecation *Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(APIVersionKey, version)
+
+		if deprecation != nil {
+			c.Header("Deprecation", "true")
+			if !deprecation.Sunset.IsZero() {
+				c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			}
+			successor := deprecation.Successor + strings.TrimPrefix(c.Request.URL.Path, deprecation.Prefix)
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+
+		c.Next()
+	}
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -15,28 +18,85 @@ const (
 	AuthHeader = "X-Admin-OTP"
 )
 
+// adminKeys returns the accepted admin OTP keys: ADMIN_OTP_KEY and the
+// comma-separated ADMIN_OTP_KEYS, so each admin can hold a key of their
+// own. ok is false in production when neither is set.
+func adminKeys() (keys []string, ok bool) {
+	if key := os.Getenv("ADMIN_OTP_KEY"); key != "" {
+		keys = append(keys, key)
+	}
+	for _, key := range strings.Split(os.Getenv("ADMIN_OTP_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		// In production, require the env var to be set
+		if os.Getenv("GIN_MODE") == "release" {
+			return nil, false
+		}
+		// Only use default in development
+		keys = []string{"TOD_ADMIN_2026_SECURE_KEY"}
+	}
+	return keys, true
+}
+
+// AdminKey returns an admin OTP key this server accepts, for tools calling
+// its restricted routes. ok is false in production when none is set.
+func AdminKey() (key string, ok bool) {
+	keys, ok := adminKeys()
+	if !ok {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// validAdminKey reports whether key is one of the accepted keys. Every key
+// is compared in constant time, so timing does not tell which one is close.
+func validAdminKey(key string, keys []string) bool {
+	valid := 0
+	for _, expected := range keys {
+		valid |= subtle.ConstantTimeCompare([]byte(key), []byte(expected))
+	}
+	return valid == 1
+}
+
+// IsAdmin reports whether the request carries a valid admin OTP key. Public
+// routes use it to show admins more; it never rejects the request.
+func IsAdmin(c *gin.Context) bool {
+	otpKey := c.GetHeader(AuthHeader)
+	keys, ok := adminKeys()
+	return ok && otpKey != "" && validAdminKey(otpKey, keys)
+}
+
+// AdminKeyID returns a fingerprint of the admin key the request carries,
+// to attribute admin actions and apply per-key quotas without storing the
+// key. Requests without a key get an empty ID.
+func AdminKeyID(c *gin.Context) string {
+	key := c.GetHeader(AuthHeader)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
 // AuthMiddleware validates the admin OTP key from header.
 // Uses timing-safe comparison to prevent timing attacks.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		otpKey := c.GetHeader(AuthHeader)
 
-		expectedKey := os.Getenv("ADMIN_OTP_KEY")
-		if expectedKey == "" {
-			// In production, require the env var to be set
-			if os.Getenv("GIN_MODE") == "release" {
-				log.Error().Msg("ADMIN_OTP_KEY not set in production mode")
-				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-					Error:   "configuration_error",
-					Message: "Server configuration error",
-				})
-				c.Abort()
-				return
-			}
-			// Only use default in development
-			expectedKey = "TOD_ADMIN_2026_SECURE_KEY"
+		keys, ok := adminKeys()
+		if !ok {
+			log.Error().Msg("ADMIN_OTP_KEY or ADMIN_OTP_KEYS not set in production mode")
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "configuration_error",
+				Message: "Server configuration error",
+			})
+			c.Abort()
+			return
 		}
-
 		if otpKey == "" {
 			log.Warn().
 				Str("ip", c.ClientIP()).
@@ -51,7 +111,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Use timing-safe comparison to prevent timing attacks
-		if subtle.ConstantTimeCompare([]byte(otpKey), []byte(expectedKey)) != 1 {
+		if !validAdminKey(otpKey, keys) {
 			log.Warn().
 				Str("ip", c.ClientIP()).
 				Str("path", c.Request.URL.Path).
@@ -1,13 +1,18 @@
 package middleware_test
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/metrics"
 	"github.com/truthordare/backend/internal/middleware"
 )
 
@@ -17,6 +22,23 @@ func setupTestRouter() *gin.Engine {
 	return router
 }
 
+// metricValue reads one series, e.g. `name{label="x"}`, from the default
+// registry, 0 when it is absent. Metrics live for the whole process, so
+// tests compare values from before and after rather than absolute counts.
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, metrics.Default.WritePrometheus(&buf))
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return v
+		}
+	}
+	return 0
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	originalKey := os.Getenv("ADMIN_OTP_KEY")
 	os.Setenv("ADMIN_OTP_KEY", "test-otp-key")
@@ -58,6 +80,32 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 }
 
+func TestAuthMiddleware_SeveralKeys(t *testing.T) {
+	t.Setenv("ADMIN_OTP_KEY", "")
+	t.Setenv("ADMIN_OTP_KEYS", "key-ana, key-ben")
+
+	router := setupTestRouter()
+	router.Use(middleware.AuthMiddleware())
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, middleware.AdminKeyID(c))
+	})
+
+	get := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set(middleware.AuthHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	ana, ben := get("key-ana"), get("key-ben")
+	require.Equal(t, http.StatusOK, ana.Code)
+	require.Equal(t, http.StatusOK, ben.Code)
+	assert.NotEqual(t, ana.Body.String(), ben.Body.String(), "each key is told apart")
+	assert.Equal(t, http.StatusUnauthorized, get("key-cy").Code)
+	assert.Equal(t, http.StatusUnauthorized, get("TOD_ADMIN_2026_SECURE_KEY").Code, "no default key once keys are set")
+}
+
 func TestAuthMiddleware_DefaultKey(t *testing.T) {
 	originalKey := os.Getenv("ADMIN_OTP_KEY")
 	originalMode := os.Getenv("GIN_MODE")
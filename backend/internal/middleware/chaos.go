@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/chaos"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// ChaosMiddleware injects the faults rolled by injector into requests whose
+// path starts with one of routes (every request when routes is empty):
+// latency, a 503 error, or a connection dropped without a response.
+// Development only; register it after TimeoutMiddleware, so injected
+// latency counts against the request budget.
+func ChaosMiddleware(injector *chaos.Injector, routes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !matchesRoute(c.Request.URL.Path, routes) {
+			c.Next()
+			return
+		}
+
+		fault := injector.Pick()
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+		}
+
+		switch {
+		case fault.Drop:
+			log.Debug().Str("path", c.Request.URL.Path).Msg("Chaos: dropping connection")
+			if conn, _, err := c.Writer.Hijack(); err == nil {
+				conn.Close()
+				c.Abort()
+				return
+			}
+			// Connection cannot be hijacked (e.g. HTTP/2): fail instead
+			fallthrough
+		case fault.Error:
+			log.Debug().Str("path", c.Request.URL.Path).Msg("Chaos: injecting error")
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "injected_fault",
+				Message: "Fault injected for resilience testing",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// matchesRoute reports whether path starts with one of routes; any path
+// matches an empty list
+func matchesRoute(path string, routes []string) bool {
+	if len(routes) == 0 {
+		return true
+	}
+	for _, route := range routes {
+		if strings.HasPrefix(path, route) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/chaos"
+	"github.com/truthordare/backend/internal/middleware"
+)
+
+func newChaosServer(t *testing.T, faults chaos.Faults) *httptest.Server {
+	t.Helper()
+	router := setupTestRouter()
+	router.Use(middleware.ChaosMiddleware(chaos.New(faults), []string{"/api/tasks"}))
+	router.GET("/api/tasks", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/api/languages", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestChaosMiddleware(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		srv := newChaosServer(t, chaos.Faults{ErrorRate: 1})
+
+		resp, err := http.Get(srv.URL + "/api/tasks")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Contains(t, string(body), "injected_fault")
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		srv := newChaosServer(t, chaos.Faults{DropRate: 1})
+
+		resp, err := http.Get(srv.URL + "/api/tasks")
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err, "dropped connection must not answer")
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		srv := newChaosServer(t, chaos.Faults{Latency: 50 * time.Millisecond, LatencyRate: 1})
+
+		start := time.Now()
+		resp, err := http.Get(srv.URL + "/api/tasks")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("other routes untouched", func(t *testing.T) {
+		srv := newChaosServer(t, chaos.Faults{ErrorRate: 1, DropRate: 1})
+
+		resp, err := http.Get(srv.URL + "/api/languages")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/metrics"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// ClientVersionHeader carries the app version of mobile clients
+const ClientVersionHeader = "X-Client-Version"
+
+// Version labels of requests without a usable version header
+const (
+	clientVersionNone    = "none"
+	clientVersionInvalid = "invalid"
+)
+
+var clientRequests = metrics.NewCounter("tod_client_requests_total",
+	"Public API requests by client version and outcome (accepted or upgrade_required)")
+
+// ClientVersionMiddleware counts requests per X-Client-Version and rejects
+// versions below minVersion with 426 and an UpgradeRequiredResponse.
+// Requests without the header or with an unparseable version pass, so
+// browsers and scripts keep working. An empty minVersion only counts.
+func ClientVersionMiddleware(minVersion string) gin.HandlerFunc {
+	minimum, enforce := parseVersion(minVersion)
+
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader(ClientVersionHeader))
+		version, ok := parseVersion(header)
+
+		label := clientVersionNone
+		switch {
+		case ok:
+			label = version.String()
+		case header != "":
+			label = clientVersionInvalid
+		}
+
+		if ok && enforce && version.less(minimum) {
+			clientRequests.IncWith(metrics.Labels{"version": label, "outcome": "upgrade_required"})
+			c.JSON(http.StatusUpgradeRequired, models.UpgradeRequiredResponse{
+				ErrorResponse: models.ErrorResponse{
+					Error:   "upgrade_required",
+					Message: "Client version " + label + " is no longer supported, please update to " + minimum.String() + " or later",
+				},
+				ClientVersion: label,
+				MinVersion:    minimum.String(),
+			})
+			c.Abort()
+			return
+		}
+
+		clientRequests.IncWith(metrics.Labels{"version": label, "outcome": "accepted"})
+		c.Next()
+	}
+}
+
+// clientVersion is a major.minor.patch app version
+type clientVersion [3]int
+
+// parseVersion parses versions like "2", "2.1" and "v2.1.3". Missing parts
+// are zero, and pre-release or build suffixes ("2.1.3-beta", "2.1.3+45")
+// are ignored.
+func parseVersion(s string) (clientVersion, bool) {
+	var v clientVersion
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return v, false
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > len(v) {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// less reports whether v is older than other
+func (v clientVersion) less(other clientVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+// String formats the version as major.minor.patch
+func (v clientVersion) String() string {
+	return strconv.Itoa(v[0]) + "." + strconv.Itoa(v[1]) + "." + strconv.Itoa(v[2])
+}
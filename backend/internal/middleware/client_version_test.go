@@ -0,0 +1,81 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestClientVersionMiddleware(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middleware.ClientVersionMiddleware("2.1"))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	series := []string{
+		`tod_client_requests_total{outcome="accepted",version="2.1.0"}`,
+		`tod_client_requests_total{outcome="accepted",version="none"}`,
+		`tod_client_requests_total{outcome="accepted",version="invalid"}`,
+		`tod_client_requests_total{outcome="upgrade_required",version="2.0.9"}`,
+	}
+	before := make(map[string]float64, len(series))
+	for _, s := range series {
+		before[s] = metricValue(t, s)
+	}
+
+	do := func(version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/tasks", nil)
+		if version != "" {
+			req.Header.Set(middleware.ClientVersionHeader, version)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("current and newer versions allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("2.1.0").Code)
+		assert.Equal(t, http.StatusOK, do("v2.10").Code)
+		assert.Equal(t, http.StatusOK, do("3.0.0-beta+12").Code)
+	})
+
+	t.Run("missing or unparseable version allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("").Code)
+		assert.Equal(t, http.StatusOK, do("nightly").Code)
+	})
+
+	t.Run("older version rejected", func(t *testing.T) {
+		w := do("2.0.9")
+		assert.Equal(t, http.StatusUpgradeRequired, w.Code)
+
+		var resp models.UpgradeRequiredResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "upgrade_required", resp.Error)
+		assert.Equal(t, "2.0.9", resp.ClientVersion)
+		assert.Equal(t, "2.1.0", resp.MinVersion)
+	})
+
+	t.Run("requests counted per version", func(t *testing.T) {
+		for _, s := range series {
+			assert.Equal(t, before[s]+1, metricValue(t, s), s)
+		}
+	})
+}
+
+func TestClientVersionMiddleware_NoMinimum(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middleware.ClientVersionMiddleware(""))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest("GET", "/tasks", nil)
+	req.Header.Set(middleware.ClientVersionHeader, "0.0.1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
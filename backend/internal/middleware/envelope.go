@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/response"
+)
+
+// EnvelopeMiddleware wraps the JSON responses of requests under prefix
+// (e.g. "/api/v2/") in the response envelope. Attachments, such as
+// snapshot exports, and other content types pass unchanged. Register it
+// before Recovery and TimeoutMiddleware, so their errors are wrapped too.
+func EnvelopeMiddleware(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		ew := &envelopeWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = ew
+		c.Set(envelopeKey, ew)
+		c.Next()
+		c.Writer = ew.ResponseWriter
+		ew.finish()
+	}
+}
+
+// envelopeKey holds the envelopeWriter of a request in the gin context
+const envelopeKey = "envelope_writer"
+
+// SkipEnvelope sends the JSON response of the request unwrapped, for
+// formats defined elsewhere such as oEmbed. Call it before writing.
+func SkipEnvelope(c *gin.Context) {
+	if ew, ok := c.Get(envelopeKey); ok {
+		ew.(*envelopeWriter).skip = true
+	}
+}
+
+// envelopeWriter buffers JSON responses, so finish can reshape them.
+// Anything else, such as attachments and streamed exports, is recognised
+// by its headers on the first write and passes through unbuffered.
+type envelopeWriter struct {
+	gin.ResponseWriter
+
+	status int
+	body   bytes.Buffer
+	wrote  bool
+	// decided is set on the first write, and passthrough with it when the
+	// response is not to be wrapped
+	decided     bool
+	passthrough bool
+	// skip is set by SkipEnvelope
+	skip bool
+}
+
+func (w *envelopeWriter) WriteHeader(code int) {
+	if code > 0 && !w.wrote {
+		w.status = code
+	}
+}
+
+func (w *envelopeWriter) WriteHeaderNow() {
+	w.wrote = true
+	if w.bypass() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	w.wrote = true
+	if w.bypass() {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	w.wrote = true
+	if w.bypass() {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+func (w *envelopeWriter) Status() int {
+	return w.status
+}
+
+func (w *envelopeWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	if !w.wrote {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *envelopeWriter) Written() bool {
+	return w.wrote
+}
+
+// Flush sends passthrough responses on; wrapped ones are sent whole by
+// finish
+func (w *envelopeWriter) Flush() {
+	if w.passthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// bypass decides on the first write whether the response passes through:
+// a body that is not JSON, is an attachment or skips the envelope. It then
+// sends the status.
+func (w *envelopeWriter) bypass() bool {
+	if !w.decided {
+		w.decided = true
+		header := w.ResponseWriter.Header()
+		w.passthrough = w.skip || !isJSON(header.Get("Content-Type")) || header.Get("Content-Disposition") != ""
+		if w.passthrough {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+	}
+	return w.passthrough
+}
+
+// finish wraps a buffered JSON body and sends the response
+func (w *envelopeWriter) finish() {
+	if w.passthrough {
+		return
+	}
+	body := w.body.Bytes()
+	header := w.ResponseWriter.Header()
+	if len(body) > 0 && isJSON(header.Get("Content-Type")) && header.Get("Content-Disposition") == "" {
+		if wrapped, err := response.Wrap(w.status, body); err == nil {
+			body = wrapped
+			header.Del("Content-Length")
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if !w.wrote {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(body)
+}
+
+// isJSON reports whether a Content-Type is JSON
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
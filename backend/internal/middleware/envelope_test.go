@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestEnvelopeMiddleware(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middleware.EnvelopeMiddleware("/v2/"))
+	router.Use(middleware.Recovery(nil))
+	router.Use(middleware.TimeoutMiddleware(50*time.Millisecond, nil))
+
+	for _, prefix := range []string{"/v1", "/v2"} {
+		group := router.Group(prefix)
+		group.GET("/list", func(c *gin.Context) {
+			c.JSON(http.StatusOK, models.PaginatedResponse[string]{Data: []string{"a"}, Total: 1})
+		})
+		group.GET("/missing", func(c *gin.Context) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "not_found", Message: "Task not found"})
+		})
+		group.GET("/export", func(c *gin.Context) {
+			c.Header("Content-Disposition", `attachment; filename="export.json"`)
+			c.JSON(http.StatusOK, gin.H{"data": "raw"})
+		})
+		group.GET("/stream", func(c *gin.Context) {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+			c.Writer.WriteString(`{"n":1}` + "\n")
+			c.Writer.Flush()
+			c.Writer.WriteString(`{"n":2}` + "\n")
+		})
+		group.GET("/oembed", func(c *gin.Context) {
+			middleware.SkipEnvelope(c)
+			c.JSON(http.StatusOK, gin.H{"version": "1.0"})
+		})
+		group.GET("/panic", func(c *gin.Context) { panic("boom") })
+		group.GET("/slow", func(c *gin.Context) {
+			// The context ends only once the 504 is written, so this
+			// late answer is discarded
+			<-c.Request.Context().Done()
+			c.JSON(http.StatusOK, gin.H{"late": true})
+		})
+		group.DELETE("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	}
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("v1 unchanged", func(t *testing.T) {
+		w := do("GET", "/v1/list")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":["a"],"total":1,"page":0,"page_size":0,"total_pages":0}`, w.Body.String())
+	})
+
+	t.Run("success wrapped with meta", func(t *testing.T) {
+		w := do("GET", "/v2/list")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":["a"],"error":null,"meta":{"total":1,"page":0,"page_size":0,"total_pages":0}}`, w.Body.String())
+	})
+
+	t.Run("error wrapped", func(t *testing.T) {
+		w := do("GET", "/v2/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"data":null,"error":{"code":"not_found","message":"Task not found"},"meta":{}}`, w.Body.String())
+	})
+
+	t.Run("attachment unchanged", func(t *testing.T) {
+		w := do("GET", "/v2/export")
+		assert.JSONEq(t, `{"data":"raw"}`, w.Body.String())
+	})
+
+	t.Run("stream passes through unbuffered", func(t *testing.T) {
+		w := do("GET", "/v2/stream")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "{\"n\":1}\n{\"n\":2}\n", w.Body.String())
+	})
+
+	t.Run("skipped envelope unchanged", func(t *testing.T) {
+		w := do("GET", "/v2/oembed")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"version":"1.0"}`, w.Body.String())
+	})
+
+	t.Run("panic wrapped", func(t *testing.T) {
+		w := do("GET", "/v2/panic")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"internal_error"`)
+	})
+
+	t.Run("timeout wrapped", func(t *testing.T) {
+		w := do("GET", "/v2/slow")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"timeout"`)
+		assert.NotContains(t, w.Body.String(), "late")
+	})
+
+	t.Run("status without body kept", func(t *testing.T) {
+		w := do("DELETE", "/v2/empty")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/errtrack"
+)
+
+// ErrorTracking reports responses with a 5xx status, along with any errors
+// handlers attached to the context. Register it before Recovery so it also
+// sees requests that panicked, which Recovery reports itself.
+func ErrorTracking() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError || c.GetBool(panicCapturedKey) {
+			return
+		}
+
+		// 503s are deliberate (read-only mode, maintenance windows)
+		if status == http.StatusServiceUnavailable {
+			return
+		}
+
+		event := errtrack.Event{
+			Message: fmt.Sprintf("HTTP %d from %s", status, requestName(c)),
+			Tags:    map[string]string{"source": "http", "status": fmt.Sprint(status)},
+			Request: requestInfo(c, status),
+		}
+		if len(c.Errors) > 0 {
+			event.Error = strings.Join(c.Errors.Errors(), "; ")
+		}
+		errtrack.Capture(event)
+	}
+}
+
+// requestName names a request by its route, so events group per endpoint
+func requestName(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return c.Request.Method + " " + route
+}
+
+// requestInfo describes the request for an event. Query strings are left
+// out since they may carry client identifiers.
+func requestInfo(c *gin.Context, status int) *errtrack.Request {
+	return &errtrack.Request{
+		Method: c.Request.Method,
+		URL:    c.Request.URL.Path,
+		Route:  c.FullPath(),
+		Status: status,
+		IP:     c.ClientIP(),
+	}
+}
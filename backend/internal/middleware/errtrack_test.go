@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/errtrack"
+	"github.com/truthordare/backend/internal/middleware"
+)
+
+// recordingReporter keeps reported events in memory
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []errtrack.Event
+}
+
+func (r *recordingReporter) Report(ctx context.Context, event errtrack.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingReporter) take() []errtrack.Event {
+	errtrack.Flush(5 * time.Second)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func TestErrorTracking(t *testing.T) {
+	reporter := &recordingReporter{}
+	errtrack.SetDefault(errtrack.NewTracker(reporter, "test", "dev"))
+	defer errtrack.SetDefault(nil)
+
+	router := setupTestRouter()
+	router.Use(middleware.ErrorTracking())
+	router.Use(middleware.Recovery(nil))
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	router.GET("/fail/:id", func(c *gin.Context) {
+		c.Error(errors.New("database is locked"))
+		c.Status(http.StatusInternalServerError)
+	})
+	router.GET("/unavailable", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(path string) int {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("panic is recovered and reported once", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, do("/panic"))
+
+		events := reporter.take()
+		require.Len(t, events, 1)
+		assert.Equal(t, errtrack.LevelFatal, events[0].Level)
+		assert.Equal(t, "boom", events[0].Error)
+		assert.NotEmpty(t, events[0].Stack)
+	})
+
+	t.Run("5xx is reported with request context", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, do("/fail/42?client_id=secret"))
+
+		events := reporter.take()
+		require.Len(t, events, 1)
+		assert.Equal(t, "HTTP 500 from GET /fail/:id", events[0].Message)
+		assert.Equal(t, "database is locked", events[0].Error)
+		assert.Equal(t, "/fail/42", events[0].Request.URL)
+	})
+
+	t.Run("success and 503 are not reported", func(t *testing.T) {
+		do("/ok")
+		do("/unavailable")
+		assert.Empty(t, reporter.take())
+	})
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// ReadOnlyMiddleware rejects mutating requests with 503 while read-only mode
+// is on. Reads pass through. Exempt paths, such as the endpoint switching
+// the mode off again, are always allowed.
+func ReadOnlyMiddleware(mode *maintenance.Mode, exempt ...string) gin.HandlerFunc {
+	exemptPaths := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		exemptPaths[path] = true
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if !mode.ReadOnly() || exemptPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		message := "The service is in read-only mode"
+		if reason := mode.Status().Reason; reason != "" {
+			message += ": " + reason
+		}
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "read_only",
+			Message: message,
+		})
+		c.Abort()
+	}
+}
+
+// MaintenanceWindowMiddleware rejects requests with 503 and a Retry-After
+// header while a scheduled maintenance window is open. Apply it to public
+// routes only, so admins can keep working during the window.
+func MaintenanceWindowMiddleware(mode *maintenance.Mode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		open, until := mode.InMaintenance(now)
+		if !open {
+			c.Next()
+			return
+		}
+
+		retryAfter := int(math.Ceil(until.Sub(now).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "maintenance",
+			Message: "The service is down for scheduled maintenance until " + until.UTC().Format(time.RFC3339),
+		})
+		c.Abort()
+	}
+}
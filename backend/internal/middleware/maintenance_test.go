@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/middleware"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	mode := maintenance.New(false)
+
+	router := setupTestRouter()
+	router.Use(middleware.ReadOnlyMiddleware(mode, "/settings/read-only"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/tasks", ok)
+	router.POST("/tasks", ok)
+	router.DELETE("/tasks", ok)
+	router.PUT("/settings/read-only", ok)
+
+	do := func(method, path string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("writes allowed when off", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("POST", "/tasks"))
+	})
+
+	mode.Set(true, "restoring backup")
+
+	t.Run("reads allowed when on", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("GET", "/tasks"))
+	})
+
+	t.Run("writes rejected when on", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/tasks", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "read_only")
+		assert.Contains(t, w.Body.String(), "restoring backup")
+		assert.Equal(t, http.StatusServiceUnavailable, do("POST", "/tasks"))
+	})
+
+	t.Run("exempt path allowed when on", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("PUT", "/settings/read-only"))
+	})
+
+	mode.Set(false, "")
+
+	t.Run("writes allowed again when switched off", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("POST", "/tasks"))
+		assert.False(t, mode.Status().ReadOnly)
+		assert.Nil(t, mode.Status().Since)
+	})
+}
+
+func TestMaintenanceWindowMiddleware(t *testing.T) {
+	mode := maintenance.New(false)
+
+	router := setupTestRouter()
+	router.Use(middleware.MaintenanceWindowMiddleware(mode))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/tasks", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("no window", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do().Code)
+	})
+
+	t.Run("open window", func(t *testing.T) {
+		windows, err := maintenance.ParseWindows("* * * * *", 2*time.Minute)
+		require.NoError(t, err)
+		mode.SetWindows(windows)
+
+		w := do()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "maintenance")
+
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.Greater(t, retryAfter, 0)
+		assert.LessOrEqual(t, retryAfter, 120)
+	})
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/errtrack"
+	"github.com/truthordare/backend/internal/metrics"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
+)
+
+// panicCapturedKey marks requests whose panic was already reported
+const panicCapturedKey = "errtrack_panic_captured"
+
+const (
+	// panicAlertInterval limits admin alerts to one per route in this window,
+	// so a panicking hot endpoint does not flood the webhook
+	panicAlertInterval = 5 * time.Minute
+	panicAlertTimeout  = 10 * time.Second
+)
+
+var panicsTotal = metrics.NewCounter("tod_http_panics_total",
+	"Handler panics recovered, by route")
+
+// Recovery recovers from handler panics, logs and reports them with their
+// stack trace, counts them per route, alerts admins through notifier and
+// responds with a 500 ErrorResponse. It replaces gin.Recovery. A nil
+// notifier disables alerts.
+func Recovery(notifier notify.Notifier) gin.HandlerFunc {
+	alerts := &panicAlerts{notifier: notifier, last: make(map[string]time.Time)}
+
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		stack := string(debug.Stack())
+		name := requestName(c)
+
+		log.Error().
+			Interface("panic", recovered).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Str("stack", stack).
+			Msg("Recovered from panic")
+
+		panicsTotal.IncWith(metrics.Labels{"route": name})
+
+		errtrack.Capture(errtrack.Event{
+			Level:   errtrack.LevelFatal,
+			Message: "Panic in " + name,
+			Error:   fmt.Sprint(recovered),
+			Stack:   stack,
+			Tags:    map[string]string{"source": "panic"},
+			Request: requestInfo(c, http.StatusInternalServerError),
+		})
+		c.Set(panicCapturedKey, true)
+
+		alerts.send(name, fmt.Sprint(recovered), time.Now())
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Internal server error",
+		})
+	})
+}
+
+// panicAlerts sends panic notifications, at most one per route per interval
+type panicAlerts struct {
+	notifier notify.Notifier
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func (a *panicAlerts) send(route, panicValue string, now time.Time) {
+	if a.notifier == nil {
+		return
+	}
+
+	a.mu.Lock()
+	if last, ok := a.last[route]; ok && now.Sub(last) < panicAlertInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.last[route] = now
+	a.mu.Unlock()
+
+	event := notify.Event{
+		Type:    notify.EventPanic,
+		Message: "Recovered from panic in " + route,
+		Fields: map[string]string{
+			"route": route,
+			"panic": panicValue,
+		},
+		Time: now.UTC(),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), panicAlertTimeout)
+		defer cancel()
+		if err := a.notifier.Notify(ctx, event); err != nil {
+			log.Error().Err(err).Str("route", route).Msg("Failed to send panic alert")
+		}
+	}()
+}
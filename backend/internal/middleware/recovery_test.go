@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
+)
+
+// recordingNotifier keeps sent events in memory
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, event notify.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return nil
+}
+
+func (n *recordingNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.events)
+}
+
+func TestRecovery(t *testing.T) {
+	notifier := &recordingNotifier{}
+
+	router := setupTestRouter()
+	router.Use(middleware.Recovery(notifier))
+	router.GET("/recovery/panic", func(c *gin.Context) { panic("boom") })
+
+	do := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/recovery/panic", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	const panics = `tod_http_panics_total{route="GET /recovery/panic"}`
+	before := metricValue(t, panics)
+
+	w := do()
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var resp models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "internal_error", resp.Error)
+	assert.NotContains(t, w.Body.String(), "boom")
+
+	// A second panic on the same route is counted but not alerted again
+	do()
+
+	assert.Equal(t, before+2, metricValue(t, panics))
+
+	require.Eventually(t, func() bool { return notifier.count() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 1, notifier.count())
+
+	notifier.mu.Lock()
+	event := notifier.events[0]
+	notifier.mu.Unlock()
+	assert.Equal(t, notify.EventPanic, event.Type)
+	assert.Equal(t, "GET /recovery/panic", event.Fields["route"])
+	assert.Equal(t, "boom", event.Fields["panic"])
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/metrics"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/signedurl"
+)
+
+// signedCacheSeconds caps how long shared caches keep a signed response
+const signedCacheSeconds = 300
+
+// SignedURLExpiresKey holds the expiry of the signed URL a request was
+// admitted with; admin requests have none
+const SignedURLExpiresKey = "signed_url_expires"
+
+var signedRequests = metrics.NewCounter("tod_signed_url_requests_total",
+	"Requests to signed URL routes by outcome (admin, signed, expired, invalid or unsigned)")
+
+// SignedURLMiddleware admits requests carrying the admin key or a valid,
+// unexpired URL signature, for read-only routes embedded by third parties.
+// Signed responses may be read by any origin and cached until shortly
+// before the link expires. unsigned names query parameters consumers may
+// add to a signed URL, such as oEmbed's maxwidth, which the signature
+// does not cover.
+func SignedURLMiddleware(signer *signedurl.Signer, unsigned ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAdmin(c) {
+			signedRequests.IncWith(metrics.Labels{"outcome": "admin"})
+			c.Next()
+			return
+		}
+
+		query := c.Request.URL.Query()
+		for _, param := range unsigned {
+			query.Del(param)
+		}
+		expires, err := signer.Verify(c.Request.URL.Path, query, time.Now())
+		switch {
+		case err == nil:
+		case errors.Is(err, signedurl.ErrExpired):
+			signedRequests.IncWith(metrics.Labels{"outcome": "expired"})
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "signature_expired",
+				Message: "This link expired at " + expires.UTC().Format(time.RFC3339),
+			})
+			c.Abort()
+			return
+		case errors.Is(err, signedurl.ErrUnsigned):
+			signedRequests.IncWith(metrics.Labels{"outcome": "unsigned"})
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "unauthorized",
+				Message: "A signed URL or the admin key is required",
+			})
+			c.Abort()
+			return
+		default:
+			signedRequests.IncWith(metrics.Labels{"outcome": "invalid"})
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "invalid_signature",
+				Message: "The URL signature is invalid",
+			})
+			c.Abort()
+			return
+		}
+
+		signedRequests.IncWith(metrics.Labels{"outcome": "signed"})
+		c.Set(SignedURLExpiresKey, expires)
+		maxAge := min(int(time.Until(expires).Seconds()), signedCacheSeconds)
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+		// Embedding pages are on any origin and send no credentials
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Del("Access-Control-Allow-Credentials")
+		c.Next()
+	}
+}
@@ -0,0 +1,86 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/signedurl"
+)
+
+func TestSignedURLMiddleware(t *testing.T) {
+	t.Setenv("ADMIN_OTP_KEY", "test-otp-key")
+	signer := signedurl.New("test-secret")
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	})
+	router.Use(middleware.SignedURLMiddleware(signer, "maxwidth"))
+	router.GET("/embed/daily-task", func(c *gin.Context) {
+		if _, signed := c.Get(middleware.SignedURLExpiresKey); signed {
+			c.Header("X-Signed", "true")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	do := func(target string, admin bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", target, nil)
+		if admin {
+			req.Header.Set(middleware.AuthHeader, "test-otp-key")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		w := do(signer.Sign("/embed/daily-task", url.Values{"language": {"es"}}, time.Now().Add(time.Hour)), false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "true", w.Header().Get("X-Signed"))
+	})
+
+	t.Run("unsigned parameters may be added", func(t *testing.T) {
+		signed := signer.Sign("/embed/daily-task", url.Values{"language": {"es"}}, time.Now().Add(time.Hour))
+		assert.Equal(t, http.StatusOK, do(signed+"&maxwidth=300", false).Code)
+	})
+
+	t.Run("cached no longer than the link lives", func(t *testing.T) {
+		w := do(signer.Sign("/embed/daily-task", nil, time.Now().Add(time.Minute)), false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, []string{"public, max-age=59", "public, max-age=60"}, w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("admin key", func(t *testing.T) {
+		w := do("/embed/daily-task", true)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Cache-Control"), "admin responses are not shared")
+		assert.Empty(t, w.Header().Get("X-Signed"))
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		w := do("/embed/daily-task?language=es", false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("altered", func(t *testing.T) {
+		signed := signer.Sign("/embed/daily-task", url.Values{"language": {"es"}}, time.Now().Add(time.Hour))
+		w := do(signed+"&category_id=x", false)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_signature")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		w := do(signer.Sign("/embed/daily-task", nil, time.Now().Add(-time.Second)), false)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "signature_expired")
+	})
+}
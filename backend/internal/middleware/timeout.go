@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// TimeoutMiddleware bounds each request by a budget. The route's budget
+// comes from budgets, keyed by route pattern (e.g. "/api/v1/generate"),
+// falling back to defaultBudget. A budget of 0 disables the timeout.
+//
+// When the budget runs out before the handler has written anything, the
+// client gets a 504 right away and whatever the handler writes afterwards
+// is discarded. Only then is the request context cancelled, so AI calls
+// made with it stop, and a handler reacting to the cancellation cannot
+// answer before the 504. A response already being written is never cut
+// off.
+func TimeoutMiddleware(defaultBudget time.Duration, budgets map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget := defaultBudget
+		if routeBudget, ok := budgets[c.FullPath()]; ok {
+			budget = routeBudget
+		}
+		if budget <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		tw := &timeoutWriter{ResponseWriter: c.Writer, header: make(http.Header)}
+		c.Writer = tw
+
+		timer := time.AfterFunc(budget, func() {
+			tw.timeout(budget)
+			cancel()
+		})
+		c.Next()
+		timer.Stop()
+
+		tw.finish()
+		c.Writer = tw.ResponseWriter
+	}
+}
+
+// timeoutWriter lets a timer answer with 504 while the handler is still
+// running. The handler writes its headers into a private map, so the timer
+// and the handler never touch the same header map.
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu       sync.Mutex
+	header   http.Header
+	wrote    bool
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.commitHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.commitHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.commitHeader()
+	w.ResponseWriter.Flush()
+}
+
+// commitHeader copies the handler's headers out before the first byte is
+// written. The caller holds the lock.
+func (w *timeoutWriter) commitHeader() {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	dst := w.ResponseWriter.Header()
+	for k, v := range w.header {
+		dst[k] = v
+	}
+}
+
+// timeout answers with 504 unless the handler already started responding
+func (w *timeoutWriter) timeout(budget time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wrote {
+		return
+	}
+	w.timedOut = true
+
+	body, _ := json.Marshal(models.ErrorResponse{
+		Error:   "timeout",
+		Message: "The request did not complete within " + budget.String(),
+	})
+	header := w.ResponseWriter.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	w.ResponseWriter.Write(body)
+	w.ResponseWriter.Flush()
+}
+
+// finish commits the handler's headers for responses without a body
+func (w *timeoutWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.commitHeader()
+	}
+}
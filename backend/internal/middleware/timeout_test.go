@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/middleware"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middleware.TimeoutMiddleware(50*time.Millisecond, map[string]time.Duration{
+		"/long": time.Second,
+	}))
+
+	slow := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+		c.JSON(http.StatusOK, gin.H{"late": true})
+	}
+	router.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Custom", "kept")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	router.GET("/slow", slow)
+	router.GET("/long", slow)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("fast handler is untouched", func(t *testing.T) {
+		w := do("/fast")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "kept", w.Header().Get("X-Custom"))
+		assert.JSONEq(t, `{"ok": true}`, w.Body.String())
+	})
+
+	t.Run("slow handler gets 504", func(t *testing.T) {
+		w := do("/slow")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"timeout"`)
+		assert.NotContains(t, w.Body.String(), "late")
+	})
+
+	t.Run("route budget overrides the default", func(t *testing.T) {
+		w := do("/long")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "late")
+	})
+}
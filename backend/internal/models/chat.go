@@ -0,0 +1,77 @@
+package models
+
+// Chat platforms whose bots can draw tasks
+const (
+	ChatPlatformSlack    = "slack"
+	ChatPlatformDiscord  = "discord"
+	ChatPlatformTelegram = "telegram"
+)
+
+// ChatWorkspace is a Slack workspace, Discord server or Telegram group
+// allowed to draw tasks through a bot. Its draws are locked to categories
+// of AgeGroup, whatever players ask for, and never include tasks that
+// require consent, since consent cannot be recorded for everyone in a
+// channel. Slack and Discord workspaces are added by admins; Telegram
+// groups register themselves when they first use the bot.
+type ChatWorkspace struct {
+	BaseModel
+	Platform    string `gorm:"type:varchar(10);not null;uniqueIndex:idx_chat_workspace" json:"platform"`
+	WorkspaceID string `gorm:"type:varchar(64);not null;uniqueIndex:idx_chat_workspace" json:"workspace_id"` // Slack team ID, Discord guild ID or Telegram chat ID
+	Name        string `gorm:"type:varchar(100)" json:"name,omitempty"`
+	AgeGroup    string `gorm:"type:varchar(20);not null" json:"age_group"`
+	Language    string `gorm:"type:varchar(2);not null" json:"language"` // Default language of draws
+	// CategoryIDs limits draws to these categories; empty allows all.
+	CategoryIDs StringArray `gorm:"type:json" json:"category_ids,omitempty"`
+	// SafeMode limits draws to categories for kids, below AgeGroup.
+	SafeMode bool `gorm:"not null;default:false" json:"safe_mode"`
+	IsActive bool `gorm:"not null" json:"is_active"`
+}
+
+// TableName returns the table name for ChatWorkspace.
+func (ChatWorkspace) TableName() string {
+	return "chat_workspaces"
+}
+
+// DrawAgeGroups returns the age groups the workspace draws from.
+func (w ChatWorkspace) DrawAgeGroups() []string {
+	if w.SafeMode {
+		return []string{AgeGroupKids}
+	}
+	return []string{w.AgeGroup}
+}
+
+// Validate checks the platform, age group and language.
+func (w ChatWorkspace) Validate() []FieldError {
+	var errs []FieldError
+	if w.Platform != ChatPlatformSlack && w.Platform != ChatPlatformDiscord && w.Platform != ChatPlatformTelegram {
+		errs = append(errs, FieldError{Field: "platform", Message: "must be slack, discord or telegram"})
+	}
+	if w.WorkspaceID == "" || len(w.WorkspaceID) > 64 {
+		errs = append(errs, FieldError{Field: "workspace_id", Message: "must be 1-64 characters"})
+	}
+	if len(w.Name) > 100 {
+		errs = append(errs, FieldError{Field: "name", Message: "must be at most 100 characters"})
+	}
+	if !IsValidAgeGroup(w.AgeGroup) {
+		errs = append(errs, FieldError{Field: "age_group", Message: "must be kids, teen or adults"})
+	}
+	if !IsValidLanguage(w.Language) {
+		errs = append(errs, FieldError{Field: "language", Message: "unsupported language"})
+	}
+	return errs
+}
+
+// ChatDraw is a task drawn for a chat channel. A channel's recent draws are
+// excluded from its next ones, so players do not see repeats.
+type ChatDraw struct {
+	BaseModel
+	Platform    string `gorm:"type:varchar(10);not null;index:idx_chat_draw_channel"`
+	WorkspaceID string `gorm:"type:varchar(64);not null;index:idx_chat_draw_channel"`
+	ChannelID   string `gorm:"type:varchar(64);not null;index:idx_chat_draw_channel"`
+	TaskID      string `gorm:"type:varchar(36);not null"`
+}
+
+// TableName returns the table name for ChatDraw.
+func (ChatDraw) TableName() string {
+	return "chat_draws"
+}
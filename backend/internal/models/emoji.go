@@ -0,0 +1,195 @@
+package models
+
+import "strings"
+
+// MaxEmojiBytes matches the categories.emoji column
+const MaxEmojiBytes = 50
+
+const (
+	zeroWidthJoiner   = '\u200D'
+	textPresentation  = '\uFE0E'
+	emojiPresentation = '\uFE0F'
+	combiningKeycap   = '\u20E3'
+	tagCancel         = '\U000E007F'
+)
+
+// DefaultEmojiForAgeGroup returns the emoji given to categories created
+// without one.
+func DefaultEmojiForAgeGroup(group string) string {
+	switch group {
+	case AgeGroupKids:
+		return "🧸"
+	case AgeGroupTeen:
+		return "🎉"
+	case AgeGroupAdults:
+		return "🍷"
+	default:
+		return "📝"
+	}
+}
+
+// NormalizeEmoji checks that s is a single emoji grapheme: a pictograph
+// with optional skin tone or tags, a flag, a keycap, or a ZWJ sequence of
+// those. It returns the emoji in emoji presentation: symbols that render as
+// text by default get U+FE0F, redundant selectors are dropped and text
+// selectors (U+FE0E) become U+FE0F. Surrounding whitespace is ignored.
+func NormalizeEmoji(s string) (string, bool) {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	i := 0
+	for {
+		n, ok := writeEmojiElement(&b, runes[i:])
+		if !ok {
+			return "", false
+		}
+		i += n
+		if i == len(runes) {
+			break
+		}
+		// Elements only continue through a zero width joiner
+		if runes[i] != zeroWidthJoiner || i+1 == len(runes) {
+			return "", false
+		}
+		b.WriteRune(zeroWidthJoiner)
+		i++
+	}
+
+	out := b.String()
+	if len(out) > MaxEmojiBytes {
+		return "", false
+	}
+	return out, true
+}
+
+// writeEmojiElement writes the normalized emoji starting runes and returns
+// the number of runes consumed
+func writeEmojiElement(b *strings.Builder, runes []rune) (int, bool) {
+	r := runes[0]
+
+	switch {
+	case isRegionalIndicator(r):
+		// Flags are a pair of regional indicators
+		if len(runes) < 2 || !isRegionalIndicator(runes[1]) {
+			return 0, false
+		}
+		b.WriteRune(r)
+		b.WriteRune(runes[1])
+		return 2, true
+
+	case r == '#' || r == '*' || (r >= '0' && r <= '9'):
+		// Keycaps: base, optional variation selector, combining keycap
+		i := 1
+		if i < len(runes) && isVariationSelector(runes[i]) {
+			i++
+		}
+		if i >= len(runes) || runes[i] != combiningKeycap {
+			return 0, false
+		}
+		b.WriteRune(r)
+		b.WriteRune(emojiPresentation)
+		b.WriteRune(combiningKeycap)
+		return i + 1, true
+
+	case isPictographic(r):
+		b.WriteRune(r)
+		i := 1
+
+		hasSelector := false
+		for i < len(runes) && isVariationSelector(runes[i]) {
+			hasSelector = true
+			i++
+		}
+
+		if i < len(runes) && isSkinTone(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+		} else if needsPresentationSelector(r, hasSelector) {
+			b.WriteRune(emojiPresentation)
+		}
+
+		// Subdivision flags: black flag followed by tag characters
+		if i < len(runes) && isTag(runes[i]) {
+			for i < len(runes) && isTag(runes[i]) && runes[i] != tagCancel {
+				b.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) || runes[i] != tagCancel {
+				return 0, false
+			}
+			b.WriteRune(tagCancel)
+			i++
+		}
+		return i, true
+	}
+
+	return 0, false
+}
+
+// needsPresentationSelector reports whether r needs U+FE0F to render as an
+// emoji. BMP symbols default to text unless listed in emojiPresentationBMP;
+// outside the BMP a selector the client sent is kept, since most pictographs
+// there default to emoji and the rest need it.
+func needsPresentationSelector(r rune, hasSelector bool) bool {
+	if r >= 0x10000 {
+		return hasSelector
+	}
+	for _, rng := range emojiPresentationBMP {
+		if r >= rng[0] && r <= rng[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// emojiPresentationBMP lists the BMP ranges with Emoji_Presentation=Yes
+var emojiPresentationBMP = [][2]rune{
+	{0x231A, 0x231B}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0}, {0x23F3, 0x23F3},
+	{0x25FD, 0x25FE}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267F, 0x267F},
+	{0x2693, 0x2693}, {0x26A1, 0x26A1}, {0x26AA, 0x26AB}, {0x26BD, 0x26BE},
+	{0x26C4, 0x26C5}, {0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA},
+	{0x26F2, 0x26F3}, {0x26F5, 0x26F5}, {0x26FA, 0x26FA}, {0x26FD, 0x26FD},
+	{0x2705, 0x2705}, {0x270A, 0x270B}, {0x2728, 0x2728}, {0x274C, 0x274C},
+	{0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27B0, 0x27B0}, {0x27BF, 0x27BF}, {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+}
+
+func isVariationSelector(r rune) bool {
+	return r == emojiPresentation || r == textPresentation
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isTag(r rune) bool {
+	return r >= 0xE0020 && r <= 0xE007F
+}
+
+// isPictographic approximates the Unicode Extended_Pictographic property
+// with the blocks emoji are drawn from
+func isPictographic(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return !isRegionalIndicator(r) && !isSkinTone(r)
+	case r >= 0x2600 && r <= 0x27BF, // Miscellaneous symbols, dingbats
+		r >= 0x2300 && r <= 0x23FF, // Miscellaneous technical
+		r >= 0x2B00 && r <= 0x2BFF, // Arrows and shapes
+		r >= 0x2190 && r <= 0x21FF, // Arrows
+		r >= 0x25A0 && r <= 0x25FF, // Geometric shapes
+		r >= 0x2934 && r <= 0x2935,
+		r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049,
+		r == 0x2122, r == 0x2139, r == 0x24C2,
+		r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestNormalizeEmoji(t *testing.T) {
+	valid := map[string]string{
+		"single":                 "🔥",
+		"emoji presentation":     "✨",
+		"text default gets FE0F": "❤",
+		"text selector replaced": "❤︎",
+		"selector kept":          "🏔️",
+		"skin tone":              "👍🏽",
+		"flag":                   "🇮🇳",
+		"keycap":                 "1⃣",
+		"family":                 "👨‍👩‍👧",
+		"rainbow flag":           "🏳️‍🌈",
+		"subdivision flag":       "🏴\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F",
+		"surrounding spaces":     "  😂 ",
+	}
+	want := map[string]string{
+		"single":                 "🔥",
+		"emoji presentation":     "✨",
+		"text default gets FE0F": "❤️",
+		"text selector replaced": "❤️",
+		"selector kept":          "🏔️",
+		"skin tone":              "👍🏽",
+		"flag":                   "🇮🇳",
+		"keycap":                 "1️⃣",
+		"family":                 "👨‍👩‍👧",
+		"rainbow flag":           "🏳️‍🌈",
+		"subdivision flag":       "🏴\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F",
+		"surrounding spaces":     "😂",
+	}
+	for name, input := range valid {
+		got, ok := models.NormalizeEmoji(input)
+		assert.True(t, ok, name)
+		assert.Equal(t, want[name], got, name)
+	}
+
+	for name, input := range map[string]string{
+		"empty":            "",
+		"text":             "abc",
+		"two emoji":        "🔥🔥",
+		"emoji and text":   "🔥 hot",
+		"lone indicator":   "🇮",
+		"trailing joiner":  "👨‍",
+		"bare digit":       "1",
+		"lone skin tone":   "🏽",
+		"unterminated tag": "🏴\U000E0067\U000E0062",
+	} {
+		_, ok := models.NormalizeEmoji(input)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestDefaultEmojiForAgeGroup(t *testing.T) {
+	for _, group := range []string{models.AgeGroupKids, models.AgeGroupTeen, models.AgeGroupAdults, ""} {
+		emoji := models.DefaultEmojiForAgeGroup(group)
+		normalized, ok := models.NormalizeEmoji(emoji)
+		assert.True(t, ok, group)
+		assert.Equal(t, emoji, normalized, group)
+	}
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxGlossaryTermLength bounds glossary terms and their translations.
+const MaxGlossaryTermLength = 100
+
+// GlossaryTerm is a brand or safety term with its approved translation per
+// language. Languages without a translation keep the term unchanged, which
+// suits brand names.
+type GlossaryTerm struct {
+	BaseModel
+	Term         string           `gorm:"type:varchar(100);not null;uniqueIndex" json:"term"`
+	Translations MultilingualText `gorm:"type:json" json:"translations"`
+	Note         string           `gorm:"type:varchar(500)" json:"note,omitempty"`
+}
+
+// TableName returns the table name for GlossaryTerm.
+func (GlossaryTerm) TableName() string {
+	return "glossary_terms"
+}
+
+// For returns the approved form of the term in a language.
+func (t GlossaryTerm) For(language string) string {
+	if text, ok := t.Translations[language]; ok && text != "" {
+		return text
+	}
+	return t.Term
+}
+
+// Validate checks the term and its translations.
+func (t GlossaryTerm) Validate() []FieldError {
+	errs := ValidateText("term", t.Term, MaxGlossaryTermLength)
+	errs = append(errs, t.Translations.Validate("translations", MaxGlossaryTermLength)...)
+	if utf8.RuneCountInString(t.Note) > 500 {
+		errs = append(errs, FieldError{Field: "note", Message: "must be at most 500 characters"})
+	}
+	return errs
+}
+
+// Glossary is the set of terms prompts and AI output are held to.
+type Glossary []GlossaryTerm
+
+// Format renders the approved forms in the given languages as a bulleted
+// list for the {{GLOSSARY}} prompt placeholder.
+func (g Glossary) Format(languages ...string) string {
+	if len(g) == 0 {
+		return "None."
+	}
+
+	var b strings.Builder
+	for i, term := range g {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("- " + term.Term + " →")
+		for j, lang := range languages {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " %s: %s", lang, term.For(lang))
+		}
+	}
+	return b.String()
+}
+
+// GlossaryViolation is a glossary term a text uses without its approved
+// form in the text's language.
+type GlossaryViolation struct {
+	Term     string `json:"term"`
+	Expected string `json:"expected"`
+	Language string `json:"language"`
+}
+
+// Check returns the terms text in language violates. A term is violated
+// when the text lacks its approved form while it uses another form of the
+// term, or while sourceText (in sourceLanguage) uses it, as when text is a
+// translation of sourceText. sourceText is empty for generated text.
+func (g Glossary) Check(text, language, sourceText, sourceLanguage string) []GlossaryViolation {
+	var violations []GlossaryViolation
+	for _, term := range g {
+		expected := term.For(language)
+		if containsTerm(text, expected) {
+			continue
+		}
+
+		used := sourceText != "" && containsTerm(sourceText, term.For(sourceLanguage))
+		if !used {
+			forms := append([]string{term.Term}, term.Translations.values()...)
+			for _, form := range forms {
+				if containsTerm(text, form) {
+					used = true
+					break
+				}
+			}
+		}
+		if used {
+			violations = append(violations, GlossaryViolation{Term: term.Term, Expected: expected, Language: language})
+		}
+	}
+	return violations
+}
+
+// GlossaryNote renders violations as a reviewer note.
+func GlossaryNote(violations []GlossaryViolation) string {
+	if len(violations) == 0 {
+		return ""
+	}
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		parts[i] = fmt.Sprintf("%q should be %q", v.Term, v.Expected)
+	}
+	return "Glossary: " + strings.Join(parts, "; ")
+}
+
+// Flag notes the glossary violations of a task's text for reviewers and
+// reports whether there were any.
+func (g Glossary) Flag(task *Task, sourceText, sourceLanguage string) bool {
+	note := GlossaryNote(g.Check(task.Text, task.Language, sourceText, sourceLanguage))
+	if note == "" {
+		return false
+	}
+	task.AddReviewerNote(note)
+	return true
+}
+
+// values returns the texts in language order.
+func (m MultilingualText) values() []string {
+	langs := make([]string, 0, len(m))
+	for lang := range m {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	values := make([]string, 0, len(m))
+	for _, lang := range langs {
+		values = append(values, m[lang])
+	}
+	return values
+}
+
+// containsTerm reports whether text contains term as a whole word,
+// ignoring case. Terms in scripts written without spaces match anywhere.
+func containsTerm(text, term string) bool {
+	if strings.TrimSpace(term) == "" {
+		return false
+	}
+	haystack, needle := []rune(strings.ToLower(text)), []rune(strings.ToLower(term))
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) != string(needle) {
+			continue
+		}
+		if i > 0 && isWordRune(haystack[i-1]) && isWordRune(needle[0]) {
+			continue
+		}
+		end := i + len(needle)
+		if end < len(haystack) && isWordRune(haystack[end]) && isWordRune(needle[len(needle)-1]) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// isWordRune reports whether r continues a space-separated word.
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)) && !unicode.Is(unicode.Han, r)
+}
@@ -0,0 +1,53 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestGlossary_Check(t *testing.T) {
+	glossary := models.Glossary{
+		{Term: "Truth or Dare", Translations: models.MultilingualText{"es": "Verdad o Reto", "zh": "真心话大冒险"}},
+		{Term: "Spin", Translations: models.MultilingualText{"es": "Girar"}},
+	}
+
+	tests := []struct {
+		name           string
+		text           string
+		language       string
+		sourceText     string
+		sourceLanguage string
+		want           []string
+	}{
+		{"approved form", "Juega a Verdad o Reto", "es", "", "", nil},
+		{"term left untranslated", "Juega a truth or dare", "es", "", "", []string{"Truth or Dare"}},
+		{"translation drops source term", "Juega a Verdad y Desafío", "es", "Play Truth or Dare", "en", []string{"Truth or Dare"}},
+		{"term absent", "Canta una canción", "es", "Sing a song", "en", nil},
+		{"whole words only", "Spinning around", "es", "", "", nil},
+		{"untranslated language keeps term", "Spin the bottle", "fr", "", "", nil},
+		{"chinese without spaces", "我们玩真心话大冒险吧", "zh", "Play Truth or Dare", "en", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var terms []string
+			for _, v := range glossary.Check(tt.text, tt.language, tt.sourceText, tt.sourceLanguage) {
+				terms = append(terms, v.Term)
+			}
+			assert.Equal(t, tt.want, terms)
+		})
+	}
+}
+
+func TestGlossary_Flag(t *testing.T) {
+	glossary := models.Glossary{{Term: "Dare", Translations: models.MultilingualText{"es": "Reto"}}}
+
+	task := &models.Task{Text: "Haz un dare", Language: "es"}
+	assert.True(t, glossary.Flag(task, "", ""))
+	assert.Equal(t, `Glossary: "Dare" should be "Reto"`, task.ReviewerNotes)
+
+	clean := &models.Task{Text: "Haz un reto", Language: "es"}
+	assert.False(t, glossary.Flag(clean, "", ""))
+	assert.Empty(t, clean.ReviewerNotes)
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Language check modes for generated text.
+const (
+	// LanguageCheckReject drops generated texts in the wrong language.
+	LanguageCheckReject = "reject"
+	// LanguageCheckFlag keeps them with a reviewer note.
+	LanguageCheckFlag = "flag"
+	// LanguageCheckOff skips detection. An empty mode is off as well.
+	LanguageCheckOff = "off"
+)
+
+// Scripts of the supported languages. Languages sharing a script are told
+// apart by their letters (Arabic, Urdu) or common words (Latin).
+var languageScripts = map[string]*unicode.RangeTable{
+	"en": unicode.Latin,
+	"es": unicode.Latin,
+	"fr": unicode.Latin,
+	"pt": unicode.Latin,
+	"zh": unicode.Han,
+	"hi": unicode.Devanagari,
+	"bn": unicode.Bengali,
+	"ar": unicode.Arabic,
+	"ur": unicode.Arabic,
+	"ru": unicode.Cyrillic,
+}
+
+// scriptLanguages maps a script to the language detected for it before
+// telling apart languages sharing it.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Latin, "en"},
+	{unicode.Han, "zh"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Arabic, "ar"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// urduLetters are Arabic-script letters used by Urdu but not Arabic.
+const urduLetters = "ٹڈڑںھہےیکگپچژ"
+
+// latinWords are common short words of the Latin-script languages.
+var latinWords = map[string][]string{
+	"en": {"the", "you", "your", "and", "is", "are", "what", "who", "do", "have", "with", "for", "of", "to", "in", "most", "ever", "would", "that", "this", "how", "was", "my", "if"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "un", "una", "tu", "tus", "es", "con", "por", "para", "qué", "cuál", "has", "lo", "más", "te", "se", "del", "al", "como", "si"},
+	"fr": {"le", "la", "les", "de", "des", "et", "un", "une", "tu", "ton", "ta", "tes", "est", "avec", "pour", "que", "qui", "quel", "quelle", "as", "ce", "dans", "du", "au", "plus", "vous", "votre", "si"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "em", "um", "uma", "você", "seu", "sua", "com", "por", "para", "é", "qual", "mais", "do", "da", "no", "na", "já", "te", "se"},
+}
+
+// latinMarks are letters that point at one Latin-script language.
+var latinMarks = map[rune]string{
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ã': "pt", 'õ': "pt",
+	'è': "fr", 'ù': "fr", 'œ': "fr", 'ë': "fr", 'ï': "fr", 'î': "fr", 'û': "fr",
+}
+
+// minDetectLetters is the fewest letters a text needs to be detected.
+const minDetectLetters = 3
+
+// DetectLanguage guesses the supported language text is written in from
+// its dominant script. Languages sharing a script are told apart by
+// script-specific letters and common words; confident is false when that
+// is a guess, and language is empty when the text has too few letters.
+func DetectLanguage(text string) (language string, confident bool) {
+	counts := make(map[*unicode.RangeTable]int, len(scriptLanguages))
+	letters := 0
+	for _, r := range text {
+		// Vowel signs of Indic scripts are marks
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				counts[s.script]++
+				break
+			}
+		}
+	}
+	if letters < minDetectLetters {
+		return "", false
+	}
+
+	var script *unicode.RangeTable
+	for _, s := range scriptLanguages {
+		if script == nil || counts[s.script] > counts[script] {
+			script, language = s.script, s.language
+		}
+	}
+	// Mixed text, e.g. a brand name in another script, goes by the majority
+	if counts[script]*2 <= letters {
+		return "", false
+	}
+
+	switch script {
+	case unicode.Arabic:
+		if strings.ContainsAny(text, urduLetters) {
+			return "ur", true
+		}
+		return "ar", true
+	case unicode.Latin:
+		return detectLatin(text)
+	}
+	return language, true
+}
+
+// detectLatin tells apart the Latin-script languages by common words and
+// marked letters. It is confident when the best language scores at least
+// two and twice the runner-up; otherwise it guesses English.
+func detectLatin(text string) (string, bool) {
+	scores := make(map[string]int, len(latinWords))
+	lower := strings.ToLower(text)
+	for _, r := range lower {
+		if lang, ok := latinMarks[r]; ok {
+			scores[lang] += 2
+		}
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for lang, common := range latinWords {
+			for _, w := range common {
+				if word == w {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+
+	best, runnerUp := "en", 0
+	for _, lang := range []string{"en", "es", "fr", "pt"} {
+		if scores[lang] > scores[best] {
+			best = lang
+		}
+	}
+	for lang, score := range scores {
+		if lang != best && score > runnerUp {
+			runnerUp = score
+		}
+	}
+	return best, scores[best] >= 2 && scores[best] >= 2*runnerUp
+}
+
+// DetectMismatch reports whether text appears to be in another language
+// than expected, and the language detected. Texts in another script always
+// mismatch; texts in the same script only when detection is confident.
+// Texts that cannot be detected match.
+func DetectMismatch(text, expected string) (detected string, mismatch bool) {
+	detected, confident := DetectLanguage(text)
+	if detected == "" || detected == expected {
+		return detected, false
+	}
+	if languageScripts[detected] != languageScripts[expected] {
+		return detected, true
+	}
+	return detected, confident
+}
+
+// CheckLanguages detects the language of generated tasks in the given
+// mode. Mismatched tasks are dropped when rejecting and get a reviewer
+// note when flagging. It returns the tasks to keep and the number of
+// mismatches per detected language.
+func CheckLanguages(tasks []Task, mode string) ([]Task, map[string]int) {
+	if mode != LanguageCheckReject && mode != LanguageCheckFlag {
+		return tasks, nil
+	}
+
+	var mismatches map[string]int
+	kept := tasks[:0:0]
+	for _, task := range tasks {
+		detected, mismatch := DetectMismatch(task.Text, task.Language)
+		if !mismatch {
+			kept = append(kept, task)
+			continue
+		}
+		if mismatches == nil {
+			mismatches = make(map[string]int)
+		}
+		mismatches[detected]++
+		if mode == LanguageCheckFlag {
+			task.AddReviewerNote(fmt.Sprintf("Language: detected %s, expected %s", detected, task.Language))
+			kept = append(kept, task)
+		}
+	}
+	return kept, mismatches
+}
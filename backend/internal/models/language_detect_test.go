@@ -0,0 +1,83 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantLanguage  string
+		wantConfident bool
+	}{
+		{"english", "What is your most embarrassing memory?", "en", true},
+		{"spanish", "¿Cuál es tu recuerdo más vergonzoso?", "es", true},
+		{"french", "Quel est ton souvenir le plus gênant ?", "fr", true},
+		{"portuguese", "Qual é a sua lembrança mais constrangedora?", "pt", true},
+		{"chinese", "你最尴尬的回忆是什么？", "zh", true},
+		{"hindi", "आपकी सबसे शर्मनाक याद क्या है?", "hi", true},
+		{"bengali", "তোমার সবচেয়ে লজ্জার স্মৃতি কী?", "bn", true},
+		{"arabic", "ما هي أكثر ذكرياتك إحراجاً؟", "ar", true},
+		{"urdu", "آپ کی سب سے شرمناک یاد کیا ہے؟", "ur", true},
+		{"russian", "Какое твоё самое неловкое воспоминание?", "ru", true},
+		{"latin without common words", "Dance salsa", "en", false},
+		{"too short", "OK", "", false},
+		{"majority script wins", "চলো Truth or Dare খেলি", "en", false},
+		{"no majority script", "Dare খেলো", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, confident := models.DetectLanguage(tt.text)
+			assert.Equal(t, tt.wantLanguage, language)
+			assert.Equal(t, tt.wantConfident, confident)
+		})
+	}
+}
+
+func TestDetectMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+		want     bool
+	}{
+		{"english answer for bengali", "What is your biggest secret?", "bn", true},
+		{"english guess for spanish", "Baila salsa", "es", false},
+		{"confident english for french", "What is your biggest secret?", "fr", true},
+		{"arabic for urdu", "ما هي أكثر ذكرياتك إحراجاً؟", "ur", true},
+		{"matching language", "你最大的秘密是什么？", "zh", false},
+		{"undetectable", "?!", "ru", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mismatch := models.DetectMismatch(tt.text, tt.expected)
+			assert.Equal(t, tt.want, mismatch)
+		})
+	}
+}
+
+func TestCheckLanguages(t *testing.T) {
+	tasks := []models.Task{
+		{Text: "তোমার সবচেয়ে বড় গোপন কথা কী?", Language: "bn"},
+		{Text: "What is your biggest secret?", Language: "bn"},
+	}
+
+	kept, mismatches := models.CheckLanguages(tasks, models.LanguageCheckReject)
+	assert.Len(t, kept, 1)
+	assert.Equal(t, map[string]int{"en": 1}, mismatches)
+
+	kept, mismatches = models.CheckLanguages(tasks, models.LanguageCheckFlag)
+	assert.Len(t, kept, 2)
+	assert.Equal(t, map[string]int{"en": 1}, mismatches)
+	assert.Empty(t, kept[0].ReviewerNotes)
+	assert.Equal(t, "Language: detected en, expected bn", kept[1].ReviewerNotes)
+	assert.Empty(t, tasks[1].ReviewerNotes, "input tasks are left untouched")
+
+	kept, mismatches = models.CheckLanguages(tasks, "")
+	assert.Len(t, kept, 2)
+	assert.Nil(t, mismatches)
+}
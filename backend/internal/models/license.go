@@ -0,0 +1,41 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Length limits for licensing metadata, matching the columns.
+const (
+	MaxLicenseLength     = 100
+	MaxAttributionLength = 500
+)
+
+// ValidateLicense checks the licensing metadata of a category or task.
+// Third-party content is content with an attribution, and it must name the
+// license it is redistributed under. prefix names the item in a batch,
+// e.g. tasks[2].
+func ValidateLicense(prefix, license, attribution string) []FieldError {
+	var errs []FieldError
+	if strings.TrimSpace(attribution) != "" && strings.TrimSpace(license) == "" {
+		errs = append(errs, FieldError{Field: prefix + "license", Message: "is required for attributed content"})
+	}
+	if utf8.RuneCountInString(license) > MaxLicenseLength {
+		errs = append(errs, FieldError{Field: prefix + "license", Message: fmt.Sprintf("must be at most %d characters", MaxLicenseLength)})
+	}
+	if utf8.RuneCountInString(attribution) > MaxAttributionLength {
+		errs = append(errs, FieldError{Field: prefix + "attribution", Message: fmt.Sprintf("must be at most %d characters", MaxAttributionLength)})
+	}
+	return errs
+}
+
+// Licensing returns the license and attribution that apply to a task: its
+// own when it names a license, otherwise its category's. category may be
+// nil when it is not loaded.
+func (t *Task) Licensing(category *Category) (license, attribution string) {
+	if t.License != "" || category == nil {
+		return t.License, t.Attribution
+	}
+	return category.License, category.Attribution
+}
@@ -0,0 +1,30 @@
+package models
+
+import "slices"
+
+// MarkMachine records languages whose text or hint was machine generated
+// as awaiting human verification.
+func (t *Task) MarkMachine(languages ...string) {
+	for _, lang := range languages {
+		if !slices.Contains(t.MachineLanguages, lang) {
+			t.MachineLanguages = append(t.MachineLanguages, lang)
+		}
+	}
+}
+
+// MarkVerified records that a human verified the text and hint in a
+// language. It reports whether the language was awaiting verification.
+func (t *Task) MarkVerified(language string) bool {
+	i := slices.Index(t.MachineLanguages, language)
+	if i < 0 {
+		return false
+	}
+	t.MachineLanguages = slices.Delete(t.MachineLanguages, i, i+1)
+	return true
+}
+
+// IsMachineGenerated reports whether the text or hint in a language awaits
+// human verification.
+func (t *Task) IsMachineGenerated(language string) bool {
+	return slices.Contains(t.MachineLanguages, language)
+}
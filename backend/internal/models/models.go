@@ -21,17 +21,46 @@ func (m MultilingualText) Value() (driver.Value, error) {
 
 // Scan implements the sql.Scanner interface for database retrieval.
 func (m *MultilingualText) Scan(value interface{}) error {
-	if value == nil {
+	data, ok := jsonColumnBytes(value)
+	if !ok {
+		return errors.New("failed to unmarshal MultilingualText")
+	}
+	if data == nil {
 		*m = make(MultilingualText)
 		return nil
 	}
 
-	bytes, ok := value.([]byte)
-	if !ok {
-		return errors.New("failed to unmarshal MultilingualText")
+	// Decode into a fresh map so a reused destination never keeps stale keys
+	text := make(MultilingualText)
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	if text == nil {
+		// A JSON null decodes to a nil map; treat it like a SQL NULL
+		text = make(MultilingualText)
 	}
 
-	return json.Unmarshal(bytes, m)
+	*m = text
+	return nil
+}
+
+// jsonColumnBytes extracts the raw JSON from a scanned column value.
+// Drivers differ in how they return JSON columns: SQLite yields []byte,
+// Postgres drivers may yield string, and some wrappers pass json.RawMessage.
+// A nil slice with ok=true means the column was NULL.
+func jsonColumnBytes(value interface{}) (data []byte, ok bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case []byte:
+		return v, true
+	case json.RawMessage:
+		return v, true
+	case string:
+		return []byte(v), true
+	default:
+		return nil, false
+	}
 }
 
 // Get returns the text for a language with fallback to English.
@@ -48,6 +77,32 @@ func (m MultilingualText) Get(lang string) string {
 	return ""
 }
 
+// Only returns the texts for the given languages. When none of them is
+// present it keeps the Get fallback, so the result is never empty for a
+// non-empty input.
+func (m MultilingualText) Only(langs ...string) MultilingualText {
+	if m == nil {
+		return nil
+	}
+	out := make(MultilingualText, len(langs))
+	for _, lang := range langs {
+		if text, ok := m[lang]; ok {
+			out[lang] = text
+		}
+	}
+	if len(out) == 0 && len(m) > 0 {
+		if text, ok := m["en"]; ok {
+			out["en"] = text
+		} else {
+			for lang, text := range m {
+				out[lang] = text
+				break
+			}
+		}
+	}
+	return out
+}
+
 // BaseModel contains common fields for all models.
 type BaseModel struct {
 	ID        string         `gorm:"type:varchar(36);primaryKey" json:"id"`
@@ -65,16 +120,24 @@ func (b *BaseModel) BeforeCreate(tx *gorm.DB) error {
 }
 
 // Category represents a question/task category.
-// Schema: { id, emoji, agegroup, label: { en, es, hi, ur, ... } }
+// Schema: { id, emoji, agegroup, label: { en, es, hi, ur, ... }, instructions: { en, ... }, image_url }
 type Category struct {
 	BaseModel
 	Emoji           string           `gorm:"type:varchar(50);default:'📝'" json:"emoji"`
+	ImageURL        string           `gorm:"type:varchar(500)" json:"image_url,omitempty"`
 	AgeGroup        string           `gorm:"type:varchar(20);not null;index;default:'adults'" json:"age_group"`
 	Label           MultilingualText `gorm:"type:json;not null" json:"label"`
 	RequiresConsent bool             `gorm:"default:false;index" json:"requires_consent"`
 	IsActive        bool             `gorm:"default:true;index" json:"is_active"`
 	SortOrder       int              `gorm:"default:0;index" json:"sort_order"`
-	Tasks           []Task           `gorm:"foreignKey:CategoryID" json:"-"`
+	// Instructions tell players how the category is played, e.g. "one
+	// player reads aloud", per language.
+	Instructions MultilingualText `gorm:"type:json" json:"instructions,omitempty"`
+	// License and Attribution describe third-party content. They apply to
+	// the category's tasks that name no license of their own.
+	License     string `gorm:"type:varchar(100);not null;default:''" json:"license,omitempty"`
+	Attribution string `gorm:"type:varchar(500);not null;default:''" json:"attribution,omitempty"`
+	Tasks       []Task `gorm:"foreignKey:CategoryID" json:"-"`
 }
 
 // TableName returns the table name for Category.
@@ -92,28 +155,76 @@ func (s StringArray) Value() (driver.Value, error) {
 
 // Scan implements the sql.Scanner interface.
 func (s *StringArray) Scan(value interface{}) error {
-	if value == nil {
+	data, ok := jsonColumnBytes(value)
+	if !ok {
+		return errors.New("failed to unmarshal StringArray")
+	}
+	if data == nil {
 		*s = []string{}
 		return nil
 	}
 
-	bytes, ok := value.([]byte)
-	if !ok {
-		return errors.New("failed to unmarshal StringArray")
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+	if arr == nil {
+		arr = []string{}
 	}
 
-	return json.Unmarshal(bytes, s)
+	*s = arr
+	return nil
 }
 
 // Task represents a truth or dare task/question.
-// Schema: { id, category_id, type (truth/dare), text, language }
+// Schema: { id, category_id, type (truth/dare), text, language, hint: { en, ... }, is_active, review_state }
 type Task struct {
 	BaseModel
-	CategoryID string    `gorm:"type:varchar(36);not null;index:idx_task_category" json:"category_id"`
-	Category   *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	Type       string    `gorm:"type:varchar(10);not null;index:idx_task_type" json:"type"` // "truth" or "dare"
-	Text       string    `gorm:"type:text;not null" json:"text"`
-	Language   string    `gorm:"type:varchar(2);not null;index:idx_task_language" json:"language"` // 2-char code: en, hi, ur, etc.
+	// ShortCode is a human-friendly ID, e.g. "T-7F3K", assigned on creation
+	ShortCode  string           `gorm:"type:varchar(12);uniqueIndex" json:"short_code"`
+	CategoryID string           `gorm:"type:varchar(36);not null;index:idx_task_category" json:"category_id"`
+	Category   *Category        `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Type       string           `gorm:"type:varchar(10);not null;index:idx_task_type" json:"type"` // "truth" or "dare"
+	Text       string           `gorm:"type:text;not null" json:"text"`
+	Language   string           `gorm:"type:varchar(2);not null;index:idx_task_language" json:"language"` // 2-char code: en, hi, ur, etc.
+	Hint       MultilingualText `gorm:"type:json" json:"hint,omitempty"`
+	IsActive   bool             `gorm:"default:true;index" json:"is_active"`
+	// RequiresConsent flags a single task as explicit. A task requires
+	// consent when it or its category is flagged.
+	RequiresConsent bool       `gorm:"default:false;index" json:"requires_consent"`
+	ReviewState     string     `gorm:"type:varchar(20);index" json:"review_state,omitempty"` // "", "pending", "approved", "rejected"
+	AssignedTo      string     `gorm:"type:varchar(64);index" json:"assigned_to,omitempty"`  // Reviewer who claimed the task
+	AssignedAt      *time.Time `json:"assigned_at,omitempty"`
+	ReviewerNotes   string     `gorm:"type:text" json:"reviewer_notes,omitempty"`
+	// RolloutPercent is the share of random draws the task is eligible for.
+	// New AI-generated tasks start below 100 and are promoted once they
+	// have been in rotation without reports.
+	RolloutPercent int `gorm:"default:100;index" json:"rollout_percent"`
+	// TimesServed and LastServedAt track how often the task was drawn for a
+	// game. They are written in batches and may lag by a flush interval.
+	TimesServed  int        `gorm:"default:0;not null;index" json:"times_served"`
+	LastServedAt *time.Time `gorm:"index" json:"last_served_at,omitempty"`
+	// GenerationRunID is the AI generation run that created the task, if any.
+	GenerationRunID string `gorm:"type:varchar(36);index" json:"generation_run_id,omitempty"`
+	// MachineLanguages lists the languages whose text or hint was machine
+	// generated and not yet verified by a human.
+	MachineLanguages StringArray `gorm:"type:json" json:"machine_languages,omitempty"`
+	// License and Attribution describe third-party content; a task without
+	// a license of its own falls under its category's.
+	License     string `gorm:"type:varchar(100);not null;default:''" json:"license,omitempty"`
+	Attribution string `gorm:"type:varchar(500);not null;default:''" json:"attribution,omitempty"`
+}
+
+// FullRollout is the RolloutPercent of tasks served to every random draw.
+const FullRollout = 100
+
+// AddReviewerNote appends a note to the task's reviewer notes.
+func (t *Task) AddReviewerNote(note string) {
+	if t.ReviewerNotes == "" {
+		t.ReviewerNotes = note
+		return
+	}
+	t.ReviewerNotes += "; " + note
 }
 
 // TableName returns the table name for Task.
@@ -121,6 +232,323 @@ func (Task) TableName() string {
 	return "tasks"
 }
 
+// TaskReport is a player report against a task. Enough unresolved reports
+// within the configured window deactivate the task for review.
+type TaskReport struct {
+	BaseModel
+	TaskID     string     `gorm:"type:varchar(36);not null;index:idx_report_task" json:"task_id"`
+	ClientID   string     `gorm:"type:varchar(64);index" json:"client_id,omitempty"`
+	Reason     string     `gorm:"type:varchar(20);not null" json:"reason"`
+	Comment    string     `gorm:"type:varchar(500)" json:"comment,omitempty"`
+	ResolvedAt *time.Time `gorm:"index" json:"resolved_at,omitempty"`
+}
+
+// TableName returns the table name for TaskReport.
+func (TaskReport) TableName() string {
+	return "task_reports"
+}
+
+// TelemetryRollup counts anonymous play events per day and dimension.
+// Events are aggregated on ingestion; no raw events or client identifiers
+// are stored. Empty dimensions mean the client did not send them.
+type TelemetryRollup struct {
+	Day        string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_telemetry_rollup;index" json:"day"` // YYYY-MM-DD (UTC)
+	Event      string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_telemetry_rollup" json:"event"`
+	CategoryID string    `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_telemetry_rollup" json:"category_id,omitempty"`
+	TaskID     string    `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_telemetry_rollup" json:"task_id,omitempty"`
+	TaskType   string    `gorm:"type:varchar(10);not null;default:'';uniqueIndex:idx_telemetry_rollup" json:"task_type,omitempty"`
+	Language   string    `gorm:"type:varchar(2);not null;default:'';uniqueIndex:idx_telemetry_rollup" json:"language,omitempty"`
+	AgeGroup   string    `gorm:"type:varchar(20);not null;default:'';uniqueIndex:idx_telemetry_rollup" json:"age_group,omitempty"`
+	Count      int64     `gorm:"not null;default:0" json:"count"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for TelemetryRollup.
+func (TelemetryRollup) TableName() string {
+	return "telemetry_rollups"
+}
+
+// CategoryRank holds how often a category was played recently, per player
+// language and age group, for the smart category order. It is recomputed
+// nightly from telemetry. An empty Language or AgeGroup sums over all of
+// them.
+type CategoryRank struct {
+	Language   string    `gorm:"type:varchar(2);primaryKey" json:"language"`
+	AgeGroup   string    `gorm:"type:varchar(20);primaryKey" json:"age_group"`
+	CategoryID string    `gorm:"type:varchar(36);primaryKey" json:"category_id"`
+	Plays      int64     `gorm:"not null;default:0" json:"plays"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// TableName returns the table name for CategoryRank.
+func (CategoryRank) TableName() string {
+	return "category_ranks"
+}
+
+// LanguageFreeze stops AI generation and machine translation into a
+// language, e.g. while its content is reviewed by hand. Existing content in
+// the language is still served.
+type LanguageFreeze struct {
+	Language  string    `gorm:"type:varchar(2);primaryKey" json:"language"`
+	Reason    string    `gorm:"type:varchar(200)" json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for LanguageFreeze.
+func (LanguageFreeze) TableName() string {
+	return "language_freezes"
+}
+
+// ConsentRecord is the consent a client gave before starting a game with
+// categories that require consent. It is kept so audits can show which
+// policy version was accepted, when, and whether the players confirmed
+// their age.
+type ConsentRecord struct {
+	BaseModel
+	ClientID      string   `gorm:"type:varchar(64);not null;index" json:"client_id"`
+	SessionID     string   `gorm:"type:varchar(64);index" json:"session_id,omitempty"`
+	PolicyVersion string   `gorm:"type:varchar(20);not null;index" json:"policy_version"`
+	AgeConfirmed  bool     `gorm:"not null" json:"age_confirmed"`
+	CategoryIDs   []string `gorm:"serializer:json" json:"category_ids"`
+}
+
+// TableName returns the table name for ConsentRecord.
+func (ConsentRecord) TableName() string {
+	return "consent_records"
+}
+
+// GenerationRun is the report card of one AI generation run, started from
+// the generate endpoint or the auto-generate job. Totals sum the
+// combinations; token counts include retried AI calls.
+type GenerationRun struct {
+	BaseModel
+	Trigger            string                  `gorm:"type:varchar(20);not null;index" json:"trigger"`       // "manual" or "scheduled"
+	RequestedBy        string                  `gorm:"type:varchar(16);index" json:"requested_by,omitempty"` // Admin key fingerprint of manual runs
+	RequestedCount     int                     `json:"requested_count,omitempty"`                            // Tasks asked for, count times combinations
+	Status             string                  `gorm:"type:varchar(20);not null;index" json:"status"`        // "running", "completed" or "failed"
+	StartedAt          time.Time               `gorm:"not null;index" json:"started_at"`
+	FinishedAt         *time.Time              `json:"finished_at,omitempty"`
+	DurationMs         int64                   `json:"duration_ms"`
+	Combinations       []GenerationCombination `gorm:"serializer:json" json:"combinations"`
+	TruthsGenerated    int                     `json:"truths_generated"`
+	DaresGenerated     int                     `json:"dares_generated"`
+	TasksCreated       int                     `json:"tasks_created"`
+	DuplicatesSkipped  int                     `json:"duplicates_skipped"`
+	Rejected           int                     `json:"rejected"`
+	LanguageMismatches int                     `json:"language_mismatches"`
+	FailedCount        int                     `json:"failed_count"`
+	GlossaryFlagged    int                     `json:"glossary_flagged"`
+	PromptTokens       int                     `json:"prompt_tokens"`
+	CompletionTokens   int                     `json:"completion_tokens"`
+	TotalTokens        int                     `json:"total_tokens"`
+	Error              string                  `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName returns the table name for GenerationRun.
+func (GenerationRun) TableName() string {
+	return "generation_runs"
+}
+
+// GenerationCombination is the outcome of one category+age group+language
+// in a generation run. Generated texts are either created, skipped as
+// duplicates of stored or sibling texts, or rejected by validation.
+// GlossaryFlagged counts created tasks whose text violates the glossary.
+type GenerationCombination struct {
+	CategoryID        string `json:"category_id"`
+	CategoryName      string `json:"category_name"`
+	AgeGroup          string `json:"age_group"`
+	Language          string `json:"language"`
+	Truths            int    `json:"truths"`
+	Dares             int    `json:"dares"`
+	Created           int    `json:"created"`
+	DuplicatesSkipped int    `json:"duplicates_skipped"`
+	Rejected          int    `json:"rejected"`
+	// LanguageMismatches counts generated texts detected in another
+	// language, by detected language. They are rejected or flagged for
+	// review depending on GENERATE_LANGUAGE_CHECK.
+	LanguageMismatches map[string]int `json:"language_mismatches,omitempty"`
+	GlossaryFlagged    int            `json:"glossary_flagged"`
+	SkippedAtCap       bool           `json:"skipped_at_cap,omitempty"`
+	TotalTokens        int            `json:"total_tokens"`
+	DurationMs         int64          `json:"duration_ms"`
+	Error              string         `json:"error,omitempty"`
+}
+
+// AddCombination records a combination and adds it to the run totals.
+func (r *GenerationRun) AddCombination(c GenerationCombination) {
+	r.Combinations = append(r.Combinations, c)
+	r.TruthsGenerated += c.Truths
+	r.DaresGenerated += c.Dares
+	r.TasksCreated += c.Created
+	r.DuplicatesSkipped += c.DuplicatesSkipped
+	r.Rejected += c.Rejected
+	r.GlossaryFlagged += c.GlossaryFlagged
+	for _, n := range c.LanguageMismatches {
+		r.LanguageMismatches += n
+	}
+	if c.Error != "" {
+		r.FailedCount++
+	}
+}
+
+// Finish marks the run completed, or failed when err is not nil.
+func (r *GenerationRun) Finish(err error) {
+	now := time.Now().UTC()
+	r.FinishedAt = &now
+	r.DurationMs = now.Sub(r.StartedAt).Milliseconds()
+	r.Status = GenerationRunCompleted
+	if err != nil {
+		r.Status = GenerationRunFailed
+		r.Error = err.Error()
+	}
+}
+
+// GenerationRun triggers and statuses.
+const (
+	GenerationTriggerManual    = "manual"
+	GenerationTriggerScheduled = "scheduled"
+
+	GenerationRunRunning   = "running"
+	GenerationRunCompleted = "completed"
+	GenerationRunFailed    = "failed"
+)
+
+// ImportJob tracks the import of an NDJSON export file. Lines are
+// committed in batches, each in its own transaction, and Offset is the
+// last line committed, so a failed or interrupted import resumes after it
+// when the same file (by hash) is posted again.
+type ImportJob struct {
+	BaseModel
+	FileHash          string            `gorm:"type:varchar(64);not null;index" json:"file_hash"` // SHA-256 of the file
+	FileSize          int64             `json:"file_size"`
+	Lines             int               `json:"lines"`
+	Offset            int               `json:"offset"`
+	Status            string            `gorm:"type:varchar(20);not null;index" json:"status"` // "running", "completed" or "failed"
+	CategoriesCreated int               `json:"categories_created"`
+	CategoriesUpdated int               `json:"categories_updated"`
+	TasksCreated      int               `json:"tasks_created"`
+	TasksUpdated      int               `json:"tasks_updated"`
+	FlagsApplied      int               `json:"flags_applied"`
+	Batches           []ImportBatch     `gorm:"serializer:json" json:"batches"`
+	CategoryIDs       map[string]string `gorm:"serializer:json" json:"-"` // File category IDs to IDs on this instance
+	FinishedAt        *time.Time        `json:"finished_at,omitempty"`
+	Error             string            `gorm:"type:text" json:"error,omitempty"`
+}
+
+// TableName returns the table name for ImportJob.
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+// ImportBatch is the outcome of one batch of an import job, covering file
+// lines FirstLine to LastLine.
+type ImportBatch struct {
+	Index      int    `json:"index"`
+	FirstLine  int    `json:"first_line"`
+	LastLine   int    `json:"last_line"`
+	Status     string `json:"status"` // "completed" or "failed"
+	Created    int    `json:"created"`
+	Updated    int    `json:"updated"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ImportJob statuses.
+const (
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// FeatureFlag is a runtime override of a feature flag, set through the
+// admin API. It takes precedence over the FEATURE_* environment defaults.
+type FeatureFlag struct {
+	Name           string    `gorm:"type:varchar(50);primaryKey" json:"name"`
+	Enabled        bool      `gorm:"not null" json:"enabled"`
+	RolloutPercent int       `gorm:"not null;default:100" json:"rollout_percent"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for FeatureFlag.
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
+
+// PrivacyAudit records a data export or deletion for a client identifier.
+// Only a hash of the identifier is kept, so the trail itself holds no
+// client data after a deletion.
+type PrivacyAudit struct {
+	ID           uint             `gorm:"primaryKey" json:"id"`
+	Action       string           `gorm:"type:varchar(10);not null;index" json:"action"` // "export" or "delete"
+	ClientIDHash string           `gorm:"type:varchar(64);not null;index" json:"client_id_hash"`
+	Rows         map[string]int64 `gorm:"serializer:json" json:"rows"`
+	CreatedAt    time.Time        `gorm:"index" json:"created_at"`
+}
+
+// TableName returns the table name for PrivacyAudit.
+func (PrivacyAudit) TableName() string {
+	return "privacy_audits"
+}
+
+// PrivacyAudit action constants.
+const (
+	PrivacyActionExport = "export"
+	PrivacyActionDelete = "delete"
+)
+
+// Telemetry event constants.
+const (
+	TelemetryGameStarted   = "game_started"
+	TelemetryGameEnded     = "game_ended"
+	TelemetryTaskServed    = "task_served"
+	TelemetryTaskCompleted = "task_completed"
+	TelemetryTaskSkipped   = "task_skipped"
+	TelemetryTaskLiked     = "task_liked"
+)
+
+// IsValidTelemetryEvent checks if a telemetry event name is valid.
+func IsValidTelemetryEvent(event string) bool {
+	switch event {
+	case TelemetryGameStarted, TelemetryGameEnded, TelemetryTaskServed,
+		TelemetryTaskCompleted, TelemetryTaskSkipped, TelemetryTaskLiked:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReviewState constants. An empty state means the task was never queued.
+const (
+	ReviewStatePending  = "pending"
+	ReviewStateApproved = "approved"
+	ReviewStateRejected = "rejected"
+)
+
+// IsValidReviewState checks if a review state is valid.
+func IsValidReviewState(state string) bool {
+	return state == ReviewStatePending || state == ReviewStateApproved || state == ReviewStateRejected
+}
+
+// ReportReason constants.
+const (
+	ReportReasonOffensive     = "offensive"
+	ReportReasonInappropriate = "inappropriate"
+	ReportReasonWrongLanguage = "wrong_language"
+	ReportReasonDuplicate     = "duplicate"
+	ReportReasonOther         = "other"
+)
+
+// IsValidReportReason checks if a report reason is valid.
+func IsValidReportReason(reason string) bool {
+	switch reason {
+	case ReportReasonOffensive, ReportReasonInappropriate, ReportReasonWrongLanguage,
+		ReportReasonDuplicate, ReportReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
 // TaskType constants.
 const (
 	TaskTypeTruth = "truth"
@@ -175,11 +603,61 @@ func IsValidLanguage(code string) bool {
 	return false
 }
 
+// AgeGroupsInRange returns the age groups whose ages overlap the range
+// minAge to maxAge, youngest first.
+func AgeGroupsInRange(minAge, maxAge int) []string {
+	var groups []string
+	for _, group := range []string{AgeGroupKids, AgeGroupTeen, AgeGroupAdults} {
+		if GetMinAgeForGroup(group) <= maxAge && GetMaxAgeForGroup(group) >= minAge {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
 // IsValidAgeGroup checks if an age group is valid.
 func IsValidAgeGroup(group string) bool {
 	return group == AgeGroupKids || group == AgeGroupTeen || group == AgeGroupAdults
 }
 
+// LanguageInfo describes a supported language for clients.
+type LanguageInfo struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	NativeName string `json:"native_name"`
+	Icon       string `json:"icon"`
+}
+
+// Languages describes the supported languages, in SupportedLanguages order.
+var Languages = []LanguageInfo{
+	{Code: "en", Name: "English", NativeName: "English", Icon: "🇬🇧"},
+	{Code: "zh", Name: "Chinese", NativeName: "中文", Icon: "🇨🇳"},
+	{Code: "es", Name: "Spanish", NativeName: "Español", Icon: "🇪🇸"},
+	{Code: "hi", Name: "Hindi", NativeName: "हिन्दी", Icon: "🇮🇳"},
+	{Code: "ar", Name: "Arabic", NativeName: "العربية", Icon: "🇸🇦"},
+	{Code: "fr", Name: "French", NativeName: "Français", Icon: "🇫🇷"},
+	{Code: "pt", Name: "Portuguese", NativeName: "Português", Icon: "🇵🇹"},
+	{Code: "bn", Name: "Bengali", NativeName: "বাংলা", Icon: "🇧🇩"},
+	{Code: "ru", Name: "Russian", NativeName: "Русский", Icon: "🇷🇺"},
+	{Code: "ur", Name: "Urdu", NativeName: "اردو", Icon: "🇵🇰"},
+}
+
+// AgeGroupInfo describes an age group for clients.
+type AgeGroupInfo struct {
+	Value       string `json:"value"`
+	Label       string `json:"label"`
+	MinAge      int    `json:"min_age"`
+	MaxAge      int    `json:"max_age"`
+	Description string `json:"description"`
+}
+
+// AgeGroups describes the age groups, youngest first.
+var AgeGroups = []AgeGroupInfo{
+	{Value: AgeGroupKids, Label: "Kids", MinAge: 0, MaxAge: 12, Description: "Content suitable for children aged 0-12"},
+	{Value: AgeGroupTeen, Label: "Teen", MinAge: 13, MaxAge: 17, Description: "Content suitable for teenagers aged 13-17"},
+	{Value: AgeGroupAdults, Label: "Adults", MinAge: 18, MaxAge: 99, Description: "Content for adults 18 and above"},
+}
+
 // IsValidTaskType checks if a task type is valid.
 func IsValidTaskType(taskType string) bool {
 	return taskType == TaskTypeTruth || taskType == TaskTypeDare
@@ -193,9 +671,12 @@ type CategoryResponse struct {
 	Emoji           string           `json:"emoji"`
 	AgeGroup        string           `json:"age_group"`
 	Label           MultilingualText `json:"label"`
+	Instructions    MultilingualText `json:"instructions,omitempty"`
 	RequiresConsent bool             `json:"requires_consent"`
 	IsActive        bool             `json:"is_active"`
 	SortOrder       int              `json:"sort_order"`
+	License         string           `json:"license,omitempty"`
+	Attribution     string           `json:"attribution,omitempty"`
 	CreatedAt       string           `json:"created_at"`
 	UpdatedAt       string           `json:"updated_at"`
 }
@@ -207,9 +688,12 @@ func (c *Category) ToResponse() CategoryResponse {
 		Emoji:           c.Emoji,
 		AgeGroup:        c.AgeGroup,
 		Label:           c.Label,
+		Instructions:    c.Instructions,
 		RequiresConsent: c.RequiresConsent,
 		IsActive:        c.IsActive,
 		SortOrder:       c.SortOrder,
+		License:         c.License,
+		Attribution:     c.Attribution,
 		CreatedAt:       c.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:       c.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
@@ -217,26 +701,56 @@ func (c *Category) ToResponse() CategoryResponse {
 
 // TaskResponse is the API response format for a task.
 type TaskResponse struct {
-	ID         string            `json:"id"`
-	CategoryID string            `json:"category_id"`
-	Category   *CategoryResponse `json:"category,omitempty"`
-	Type       string            `json:"type"`
-	Text       string            `json:"text"`
-	Language   string            `json:"language"`
-	CreatedAt  string            `json:"created_at"`
-	UpdatedAt  string            `json:"updated_at"`
+	ID              string            `json:"id"`
+	ShortCode       string            `json:"short_code,omitempty"`
+	CategoryID      string            `json:"category_id"`
+	Category        *CategoryResponse `json:"category,omitempty"`
+	Type            string            `json:"type"`
+	Text            string            `json:"text"`
+	Language        string            `json:"language"`
+	Hint            MultilingualText  `json:"hint,omitempty"`
+	IsActive        bool              `json:"is_active"`
+	RequiresConsent bool              `json:"requires_consent"`
+	ReviewState     string            `json:"review_state,omitempty"`
+	RolloutPercent  int               `json:"rollout_percent"`
+	AssignedTo      string            `json:"assigned_to,omitempty"`
+	ReviewerNotes   string            `json:"reviewer_notes,omitempty"`
+	// MachineLanguages lists the languages awaiting human verification
+	MachineLanguages []string `json:"machine_languages,omitempty"`
+	TimesServed      int      `json:"times_served"`
+	LastServedAt     *string  `json:"last_served_at,omitempty"`
+	License          string   `json:"license,omitempty"`
+	Attribution      string   `json:"attribution,omitempty"`
+	CreatedAt        string   `json:"created_at"`
+	UpdatedAt        string   `json:"updated_at"`
 }
 
 // ToResponse converts a Task to TaskResponse.
 func (t *Task) ToResponse() TaskResponse {
 	resp := TaskResponse{
-		ID:         t.ID,
-		CategoryID: t.CategoryID,
-		Type:       t.Type,
-		Text:       t.Text,
-		Language:   t.Language,
-		CreatedAt:  t.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:  t.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:               t.ID,
+		ShortCode:        t.ShortCode,
+		CategoryID:       t.CategoryID,
+		Type:             t.Type,
+		Text:             t.Text,
+		Language:         t.Language,
+		Hint:             t.Hint,
+		IsActive:         t.IsActive,
+		RequiresConsent:  t.RequiresConsent,
+		ReviewState:      t.ReviewState,
+		RolloutPercent:   t.RolloutPercent,
+		AssignedTo:       t.AssignedTo,
+		ReviewerNotes:    t.ReviewerNotes,
+		MachineLanguages: t.MachineLanguages,
+		TimesServed:      t.TimesServed,
+		License:          t.License,
+		Attribution:      t.Attribution,
+		CreatedAt:        t.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        t.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if t.LastServedAt != nil {
+		lastServed := t.LastServedAt.Format("2006-01-02T15:04:05Z")
+		resp.LastServedAt = &lastServed
 	}
 	if t.Category != nil {
 		catResp := t.Category.ToResponse()
@@ -245,9 +759,25 @@ func (t *Task) ToResponse() TaskResponse {
 	return resp
 }
 
-// ErrorResponse is the standard error response format.
+// ErrorResponse is the standard error response format. Fields lists the
+// invalid request fields of a validation error.
 type ErrorResponse struct {
-	Error   string `json:"error"`
+	Error   string       `json:"error"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// UpgradeRequiredResponse is the 426 error for clients older than the
+// minimum supported version.
+type UpgradeRequiredResponse struct {
+	ErrorResponse
+	ClientVersion string `json:"client_version"`
+	MinVersion    string `json:"min_version"`
+}
+
+// FieldError describes one invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
 
@@ -263,6 +793,13 @@ type HealthResponse struct {
 	Version string `json:"version"`
 }
 
+// ReadinessResponse is the readiness check response format.
+// Checks maps each dependency to its status ("ok" or an error description).
+type ReadinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]interface{} `json:"checks"`
+}
+
 // PaginatedResponse is a generic paginated response.
 type PaginatedResponse[T any] struct {
 	Data       []T   `json:"data"`
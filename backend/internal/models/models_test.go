@@ -74,6 +74,163 @@ func TestMultilingualText_Scan(t *testing.T) {
 		assert.NotNil(t, text)
 		assert.Empty(t, text)
 	})
+
+	t.Run("scan from string", func(t *testing.T) {
+		var text models.MultilingualText
+		err := text.Scan(`{"en":"Hello"}`)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", text["en"])
+	})
+
+	t.Run("scan JSON null", func(t *testing.T) {
+		var text models.MultilingualText
+		err := text.Scan([]byte("null"))
+		require.NoError(t, err)
+		assert.NotNil(t, text)
+		assert.Empty(t, text)
+	})
+
+	t.Run("rescan replaces previous keys", func(t *testing.T) {
+		text := models.MultilingualText{"hi": "पुराना"}
+		err := text.Scan([]byte(`{"en":"New"}`))
+		require.NoError(t, err)
+		assert.Equal(t, models.MultilingualText{"en": "New"}, text)
+	})
+
+	t.Run("scan unsupported type", func(t *testing.T) {
+		var text models.MultilingualText
+		assert.Error(t, text.Scan(42))
+	})
+}
+
+// driverValues returns the same JSON document in each representation a
+// database driver may hand to Scan.
+func driverValues(doc string) map[string]interface{} {
+	return map[string]interface{}{
+		"bytes":       []byte(doc),
+		"string":      doc,
+		"raw message": json.RawMessage(doc),
+	}
+}
+
+func TestMultilingualText_ScanDriverTypes(t *testing.T) {
+	for name, value := range driverValues(`{"en":"Hello","ur":"ہیلو"}`) {
+		t.Run(name, func(t *testing.T) {
+			var text models.MultilingualText
+			require.NoError(t, text.Scan(value))
+			assert.Equal(t, models.MultilingualText{"en": "Hello", "ur": "ہیلو"}, text)
+		})
+	}
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		for name, value := range driverValues(`{"en":`) {
+			var text models.MultilingualText
+			assert.Error(t, text.Scan(value), name)
+		}
+	})
+}
+
+func TestMultilingualText_Only(t *testing.T) {
+	text := models.MultilingualText{"en": "Hello", "hi": "नमस्ते", "ur": "سلام"}
+
+	assert.Equal(t, models.MultilingualText{"hi": "नमस्ते"}, text.Only("hi"))
+	assert.Equal(t, models.MultilingualText{"hi": "नमस्ते", "ur": "سلام"}, text.Only("hi", "ur", "fr"))
+	assert.Equal(t, models.MultilingualText{"en": "Hello"}, text.Only("fr"), "falls back to English")
+	assert.Equal(t, models.MultilingualText{"hi": "नमस्ते"}, models.MultilingualText{"hi": "नमस्ते"}.Only("fr"), "falls back to any language")
+	assert.Nil(t, models.MultilingualText(nil).Only("en"))
+}
+
+func TestMultilingualText_Validate(t *testing.T) {
+	assert.Empty(t, models.MultilingualText{"en": "Hello", "hi": "नमस्ते"}.Validate("label", 10))
+
+	errs := models.MultilingualText{
+		"en": "",
+		"hi": "नमस्ते नमस्ते",
+		"xx": "Unknown",
+	}.Validate("label", 10)
+	assert.Equal(t, []models.FieldError{
+		{Field: "label.en", Message: "must not be empty"},
+		{Field: "label.hi", Message: "must be at most 10 characters"},
+		{Field: "label.xx", Message: "unsupported language code"},
+	}, errs)
+}
+
+func TestStringArray_Scan(t *testing.T) {
+	for name, value := range driverValues(`["en","hi"]`) {
+		t.Run(name, func(t *testing.T) {
+			var arr models.StringArray
+			require.NoError(t, arr.Scan(value))
+			assert.Equal(t, models.StringArray{"en", "hi"}, arr)
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		var arr models.StringArray
+		require.NoError(t, arr.Scan(nil))
+		assert.NotNil(t, arr)
+		assert.Empty(t, arr)
+	})
+
+	t.Run("JSON null", func(t *testing.T) {
+		var arr models.StringArray
+		require.NoError(t, arr.Scan("null"))
+		assert.NotNil(t, arr)
+		assert.Empty(t, arr)
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		for name, value := range driverValues(`["en",`) {
+			var arr models.StringArray
+			assert.Error(t, arr.Scan(value), name)
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		var arr models.StringArray
+		assert.Error(t, arr.Scan(3.14))
+	})
+
+	t.Run("value round trip", func(t *testing.T) {
+		value, err := models.StringArray{"a", "b"}.Value()
+		require.NoError(t, err)
+		var arr models.StringArray
+		require.NoError(t, arr.Scan(value))
+		assert.Equal(t, models.StringArray{"a", "b"}, arr)
+	})
+}
+
+func FuzzMultilingualText_Scan(f *testing.F) {
+	f.Add([]byte(`{"en":"Hello","hi":"नमस्ते"}`))
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{"en":`))
+	f.Add([]byte(`["en"]`))
+	f.Add([]byte{0xff, 0xfe})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var fromBytes, fromString models.MultilingualText
+		errBytes := fromBytes.Scan(data)
+		errString := fromString.Scan(string(data))
+
+		// []byte and string drivers must behave identically
+		if (errBytes == nil) != (errString == nil) {
+			t.Fatalf("bytes err=%v, string err=%v", errBytes, errString)
+		}
+		if errBytes != nil {
+			return
+		}
+		if fromBytes == nil {
+			t.Fatal("successful scan must leave a non-nil map")
+		}
+		assert.Equal(t, fromBytes, fromString)
+
+		// A successful scan must survive a Value/Scan round trip
+		value, err := fromBytes.Value()
+		require.NoError(t, err)
+		var roundTrip models.MultilingualText
+		require.NoError(t, roundTrip.Scan(value))
+		assert.Equal(t, fromBytes, roundTrip)
+	})
 }
 
 func TestIsValidAgeGroup(t *testing.T) {
@@ -215,3 +372,10 @@ func TestConstants(t *testing.T) {
 	assert.Equal(t, "teen", models.AgeGroupTeen)
 	assert.Equal(t, "adults", models.AgeGroupAdults)
 }
+
+func TestAgeGroupsInRange(t *testing.T) {
+	assert.Equal(t, []string{models.AgeGroupKids}, models.AgeGroupsInRange(0, 12))
+	assert.Equal(t, []string{models.AgeGroupKids, models.AgeGroupTeen}, models.AgeGroupsInRange(10, 15))
+	assert.Equal(t, []string{models.AgeGroupAdults}, models.AgeGroupsInRange(30, 30))
+	assert.Empty(t, models.AgeGroupsInRange(100, 120))
+}
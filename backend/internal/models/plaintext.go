@@ -0,0 +1,63 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Markdown-ish artifacts AI output and hand-written tasks carry over.
+var (
+	markdownLink     = regexp.MustCompile(`\[([^\]\n]+)\]\([^)\n]*\)`)
+	markdownEmphasis = regexp.MustCompile(`(\*\*|__|~~)([^\n]+?)(\*\*|__|~~)`)
+	markdownCode     = regexp.MustCompile("`([^`\n]+)`")
+	markdownItalic   = regexp.MustCompile(`(^|[^\p{L}\p{N}])[*_]([^*_\n]+)[*_]($|[^\p{L}\p{N}])`)
+	markdownPrefix   = regexp.MustCompile(`(?m)^[ \t]*(#{1,6}|>|[-*+•])[ \t]+`)
+)
+
+// PlainText strips emoji and markdown-ish formatting from text for voice
+// assistants and plain-text displays: links keep their text, emphasis and
+// code markers, heading, quote and bullet prefixes are dropped, and
+// whitespace collapses as in SanitizeText. Zero-width joiners are dropped
+// only within emoji sequences.
+func PlainText(s string) string {
+	s = markdownLink.ReplaceAllString(s, "$1")
+	s = markdownPrefix.ReplaceAllString(s, "")
+	s = markdownEmphasis.ReplaceAllString(s, "$2")
+	s = markdownCode.ReplaceAllString(s, "$1")
+	s = markdownItalic.ReplaceAllString(s, "$1$2$3")
+	s = strings.NewReplacer("*", "", "`", "").Replace(s)
+	return SanitizeText(stripEmoji(s))
+}
+
+// stripEmoji drops pictographs, flags, keycap marks and the modifiers,
+// selectors and joiners of emoji sequences.
+func stripEmoji(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	inEmoji := false
+	for i, r := range runes {
+		switch {
+		case isPictographic(r), isRegionalIndicator(r):
+			inEmoji = true
+		case isSkinTone(r), isTag(r), isVariationSelector(r), r == combiningKeycap:
+		case r == zeroWidthJoiner && (inEmoji || i+1 < len(runes) && isPictographic(runes[i+1])):
+		default:
+			inEmoji = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// PlainText returns a copy with PlainText applied to every text.
+func (m MultilingualText) PlainText() MultilingualText {
+	if m == nil {
+		return nil
+	}
+	out := make(MultilingualText, len(m))
+	for lang, text := range m {
+		out[lang] = PlainText(text)
+	}
+	return out
+}
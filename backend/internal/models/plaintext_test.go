@@ -0,0 +1,41 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "What is your secret?", "What is your secret?"},
+		{"emoji", "🎤 Sing a song! 😂🔥", "Sing a song!"},
+		{"emoji sequences", "Call 👨\u200D👩\u200D👧 your family 👍🏽 now 🇮🇳", "Call your family now"},
+		{"keycap", "Pick 1\uFE0F\u20E3 friend", "Pick 1 friend"},
+		{"text presentation symbols", "Draw a ❤\uFE0F or ☀\uFE0E", "Draw a or"},
+		{"bold and strike", "**Dance** for ~~ten~~ 20 seconds", "Dance for ten 20 seconds"},
+		{"italics", "Say it *slowly* and _loudly_", "Say it slowly and loudly"},
+		{"underscores in words", "Spell snake_case_name", "Spell snake_case_name"},
+		{"code", "Type `hello` on your phone", "Type hello on your phone"},
+		{"link", "Watch [this video](https://example.com/v) together", "Watch this video together"},
+		{"heading and bullets", "## Dare\n- Sing\n* Dance\n> Laugh", "Dare Sing Dance Laugh"},
+		{"keeps joiners in words", "क्\u200Dष करो", "क्\u200Dष करो"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.PlainText(tt.text))
+		})
+	}
+}
+
+func TestMultilingualText_PlainText(t *testing.T) {
+	hint := models.MultilingualText{"en": "**Be** honest 😇"}
+	assert.Equal(t, models.MultilingualText{"en": "Be honest"}, hint.PlainText())
+	assert.Equal(t, "**Be** honest 😇", hint["en"], "the original is left untouched")
+	assert.Nil(t, models.MultilingualText(nil).PlainText())
+}
@@ -0,0 +1,24 @@
+package models
+
+// profaneWords are refused in text players write themselves, such as the
+// custom tasks of a game session. Words match whole, ignoring case, so
+// "Scunthorpe" passes; operators add their own through BLOCKED_WORDS.
+var profaneWords = []string{
+	"asshole", "bastard", "bitch", "bollocks", "bullshit", "cock", "cunt",
+	"dickhead", "fuck", "fucked", "fucker", "fucking", "motherfucker",
+	"prick", "shit", "slut", "twat", "wanker", "whore",
+}
+
+// ContainsProfanity reports whether text contains a profane word or one
+// of blocked as a whole word, ignoring case.
+func ContainsProfanity(text string, blocked []string) bool {
+	text = SanitizeText(text)
+	for _, words := range [][]string{profaneWords, blocked} {
+		for _, word := range words {
+			if containsTerm(text, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
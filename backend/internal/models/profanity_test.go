@@ -0,0 +1,30 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestContainsProfanity(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		blocked []string
+		want    bool
+	}{
+		{"clean", "Sing your favourite song", nil, false},
+		{"profane", "Say shit three times", nil, true},
+		{"ignores case", "Say SHIT three times", nil, true},
+		{"whole words only", "Name a town like Scunthorpe", nil, false},
+		{"behind zero-width junk", "Say f\u200Buck", nil, true},
+		{"blocked by the operator", "Tell us about Dave", []string{"dave"}, true},
+		{"blocked phrase", "Talk about the office party", []string{"office party"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.ContainsProfanity(tt.text, tt.blocked))
+		})
+	}
+}
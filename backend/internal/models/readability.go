@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextRules limits the length and readability of task text. A zero limit
+// is not enforced; MaxTaskTextLength always applies.
+type TextRules struct {
+	MaxLength           int
+	MaxSentences        int
+	MaxWordsPerSentence int
+}
+
+// AgeGroupTextRules holds the TextRules of each age group.
+type AgeGroupTextRules map[string]TextRules
+
+// For returns the rules of an age group, treating an unknown or empty age
+// group as adults.
+func (r AgeGroupTextRules) For(ageGroup string) TextRules {
+	if !IsValidAgeGroup(ageGroup) {
+		ageGroup = AgeGroupAdults
+	}
+	return r[ageGroup]
+}
+
+// Validate checks text against the rules on top of ValidateText.
+func (r TextRules) Validate(field, text, language string) []FieldError {
+	maxLen := MaxTaskTextLength
+	if r.MaxLength > 0 && r.MaxLength < maxLen {
+		maxLen = r.MaxLength
+	}
+	if errs := ValidateText(field, text, maxLen); len(errs) > 0 {
+		return errs
+	}
+
+	score := MeasureReadability(text, language)
+	if r.MaxSentences > 0 && score.Sentences > r.MaxSentences {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at most %d sentences", r.MaxSentences)}}
+	}
+	if r.MaxWordsPerSentence > 0 && score.LongestSentence > r.MaxWordsPerSentence {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("sentences must be at most %d words", r.MaxWordsPerSentence)}}
+	}
+	return nil
+}
+
+// Readability is a rough measure of how easy a text is to read.
+type Readability struct {
+	Sentences       int `json:"sentences"`
+	Words           int `json:"words"`
+	LongestSentence int `json:"longest_sentence"`
+}
+
+// charsPerWord approximates words in scripts written without spaces, where
+// each character is counted instead.
+const charsPerWord = 2
+
+// MeasureReadability counts sentences and words in text. Sentences end at
+// terminal punctuation of any supported script (. ! ? 。 ؟ । ۔); a run of
+// terminators such as "?!" or "..." ends one sentence, and a point between
+// digits (3.5) ends none. Words are split on spaces, except in Chinese
+// where every two characters count as one word.
+func MeasureReadability(text, language string) Readability {
+	var score Readability
+	words, chars := 0, 0
+	endSentence := func() {
+		if language == "zh" {
+			words += (chars + charsPerWord - 1) / charsPerWord
+		}
+		if words > 0 {
+			score.Sentences++
+			score.Words += words
+			score.LongestSentence = max(score.LongestSentence, words)
+		}
+		words, chars = 0, 0
+	}
+
+	inWord := false
+	runes := []rune(text)
+	for i, r := range runes {
+		if isSentenceEnd(r) && !(r == '.' && i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])) {
+			inWord = false
+			endSentence()
+			continue
+		}
+		switch {
+		case unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			inWord = false
+		case language == "zh" && r >= utf8.RuneSelf:
+			chars++
+			inWord = false
+		default:
+			if !inWord {
+				words++
+			}
+			inWord = true
+		}
+	}
+	endSentence()
+	return score
+}
+
+// isSentenceEnd reports whether r ends a sentence.
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？', '؟', '।', '۔', '…':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestMeasureReadability(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		want     models.Readability
+	}{
+		{"single sentence", "Sing your favorite song", "en", models.Readability{Sentences: 1, Words: 4, LongestSentence: 4}},
+		{"terminator runs", "Really?! Show us... now.", "en", models.Readability{Sentences: 3, Words: 4, LongestSentence: 2}},
+		{"decimal point", "Jump 2.5 meters.", "en", models.Readability{Sentences: 1, Words: 4, LongestSentence: 4}},
+		{"hindi danda", "एक गाना गाओ। नाचो।", "hi", models.Readability{Sentences: 2, Words: 4, LongestSentence: 3}},
+		{"urdu full stop", "ایک گانا گاؤ۔ ناچو؟", "ur", models.Readability{Sentences: 2, Words: 4, LongestSentence: 3}},
+		{"chinese characters", "唱一首歌。跳舞！", "zh", models.Readability{Sentences: 2, Words: 3, LongestSentence: 2}},
+		{"blank", "  ", "en", models.Readability{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.MeasureReadability(tt.text, tt.language))
+		})
+	}
+}
+
+func TestTextRules_Validate(t *testing.T) {
+	rules := models.AgeGroupTextRules{
+		models.AgeGroupKids: {MaxLength: 40, MaxSentences: 1, MaxWordsPerSentence: 5},
+	}
+	kids := rules.For(models.AgeGroupKids)
+
+	assert.Empty(t, kids.Validate("text", "Hop like a frog!", "en"))
+	assert.Equal(t, "must be at most 40 characters", kids.Validate("text", "Tell everyone about the best day of your whole life", "en")[0].Message)
+	assert.Equal(t, "must be at most 1 sentences", kids.Validate("text", "Hop. Then sing.", "en")[0].Message)
+	assert.Equal(t, "sentences must be at most 5 words", kids.Validate("text", "Hop on one leg for ten seconds", "en")[0].Message)
+
+	// Age groups without rules only get the global limits
+	assert.Empty(t, rules.For(models.AgeGroupAdults).Validate("text", "Hop. Then sing. Then dance.", "en"))
+	assert.NotEmpty(t, rules.For("").Validate("text", " ", "en"))
+}
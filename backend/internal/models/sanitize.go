@@ -0,0 +1,121 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// Invisible characters stripped from stored text. Joiners (U+200C,
+// U+200D), bidi marks and variation selectors are kept: Indic and
+// Arabic-script words and emoji sequences depend on them.
+var junkRunes = map[rune]bool{
+	'\u00AD': true, // soft hyphen
+	'\u180E': true, // Mongolian vowel separator
+	'\u200B': true, // zero-width space
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // byte order mark
+	'\uFFFD': true, // replacement character left by broken decoding
+}
+
+// No-break spaces are kept on their own, as French puts them before
+// question marks; within a longer run of spaces they collapse.
+const (
+	noBreakSpace       = '\u00A0'
+	narrowNoBreakSpace = '\u202F'
+)
+
+// SanitizeText normalizes text before it is stored: invalid UTF-8 is
+// dropped, UTF-8 decoded as Windows-1252 (mojibake such as "Ã©") is
+// repaired, the text is composed to NFC, control and zero-width
+// characters are stripped, and runs of whitespace collapse to one space
+// with none around the text.
+func SanitizeText(s string) string {
+	s = repairMojibake(strings.ToValidUTF8(s, ""))
+	s = norm.NFC.String(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	var space []rune
+	flush := func() {
+		if len(space) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			if len(space) == 1 && (space[0] == noBreakSpace || space[0] == narrowNoBreakSpace) {
+				b.WriteRune(space[0])
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		space = space[:0]
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			space = append(space, r)
+		case unicode.IsControl(r), junkRunes[r]:
+		default:
+			flush()
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// repairMojibake undoes UTF-8 text decoded as Windows-1252. It applies only
+// when every rune is in Windows-1252 and its bytes form valid UTF-8 with at
+// least one multibyte character, which plain Latin text never does: a
+// lone "é" is not valid UTF-8.
+func repairMojibake(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	raw, err := charmap.Windows1252.NewEncoder().String(s)
+	if err != nil || !utf8.ValidString(raw) || isASCII(raw) {
+		return s
+	}
+	return raw
+}
+
+// isASCII reports whether s has only ASCII bytes.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitize applies SanitizeText to every text.
+func (m MultilingualText) sanitize() {
+	for lang, text := range m {
+		m[lang] = SanitizeText(text)
+	}
+}
+
+// BeforeSave sanitizes the category's labels and instructions.
+func (c *Category) BeforeSave(tx *gorm.DB) error {
+	c.Label.sanitize()
+	c.Instructions.sanitize()
+	return nil
+}
+
+// BeforeSave sanitizes the task's text and hints.
+func (t *Task) BeforeSave(tx *gorm.DB) error {
+	t.Text = SanitizeText(t.Text)
+	t.Hint.sanitize()
+	return nil
+}
+
+// BeforeSave sanitizes the glossary term and its translations.
+func (t *GlossaryTerm) BeforeSave(tx *gorm.DB) error {
+	t.Term = SanitizeText(t.Term)
+	t.Translations.sanitize()
+	return nil
+}
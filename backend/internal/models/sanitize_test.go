@@ -0,0 +1,40 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "What is your secret?", "What is your secret?"},
+		{"collapses whitespace", "  What\tis \n\n your   secret? ", "What is your secret?"},
+		{"composes to NFC", "Que\u0301 secreto", "Qué secreto"},
+		{"strips control characters", "Sing\x00 a\x07 song", "Sing a song"},
+		{"strips zero-width junk", "\uFEFFDance\u200B now\u2060!\u00AD", "Dance now!"},
+		{"drops invalid UTF-8", "Dance\xff now", "Dance now"},
+		{"repairs mojibake", "Â¿QuÃ© secreto tienes?", "¿Qué secreto tienes?"},
+		{"repairs mojibake with Windows-1252 bytes", "Itâ€™s your turn", "It’s your turn"},
+		{"keeps Latin-1 text", "Quel est ton secret ?", "Quel est ton secret ?"},
+		{"keeps a lone no-break space", "Quel est ton secret\u00A0?", "Quel est ton secret\u00A0?"},
+		{"collapses runs with no-break spaces", "Quel\u00A0 secret", "Quel secret"},
+		{"keeps joiners", "क्\u200Dष 👨\u200D👩\u200D👧 می\u200Cخواهم", "क्\u200Dष 👨\u200D👩\u200D👧 می\u200Cخواهم"},
+		{"only junk", "\u200B \uFEFF", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.SanitizeText(tt.text))
+		})
+	}
+}
+
+func TestValidateText_JunkIsBlank(t *testing.T) {
+	errs := models.ValidateText("text", "\u200B\u200B", models.MaxTaskTextLength)
+	assert.Equal(t, []models.FieldError{{Field: "text", Message: "must not be empty"}}, errs)
+}
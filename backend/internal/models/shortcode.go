@@ -0,0 +1,197 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Short codes identify a task in speech or a bug report, e.g. "T-7F3K".
+// They use Crockford's base32 alphabet, which has no I, L, O or U, so
+// codes read aloud or typed from memory are not misheard.
+const (
+	ShortCodePrefix   = "T-"
+	shortCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// Codes start at shortCodeMinLength characters and grow when a length
+	// keeps colliding, up to shortCodeMaxLength
+	shortCodeMinLength = 4
+	shortCodeMaxLength = 8
+	shortCodeAttempts  = 3
+)
+
+// shortCodePoolKey holds the codes reserved for the rows of one insert,
+// looked up together when the first row is created
+const shortCodePoolKey = "tod:short_code_pool"
+
+// shortCodeLookupChunk bounds the codes checked by one query, within
+// SQLite's limit on bound variables
+const shortCodeLookupChunk = 500
+
+// ErrShortCodeExhausted is returned when no free short code was found.
+var ErrShortCodeExhausted = errors.New("no free task short code found")
+
+// NewShortCode returns a random short code of length characters after the
+// prefix.
+func NewShortCode(length int) string {
+	var b strings.Builder
+	b.WriteString(ShortCodePrefix)
+	max := big.NewInt(int64(len(shortCodeAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b.WriteByte(shortCodeAlphabet[n.Int64()])
+	}
+	return b.String()
+}
+
+// NormalizeShortCode turns a code as typed or heard into its stored form:
+// case and the prefix are optional, spaces and dashes are ignored and
+// letters confused with digits (O, I, L) are read as those digits. It
+// reports false when the input cannot be a short code. Without the dash a
+// leading T is part of the code.
+func NormalizeShortCode(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.TrimPrefix(code, ShortCodePrefix)
+
+	var b strings.Builder
+	for _, r := range code {
+		switch r {
+		case ' ', '-':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		if !strings.ContainsRune(shortCodeAlphabet, r) {
+			return "", false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() < shortCodeMinLength || b.Len() > shortCodeMaxLength {
+		return "", false
+	}
+	return ShortCodePrefix + b.String(), true
+}
+
+// uniqueShortCodes picks n distinct short codes no task, deleted ones
+// included, holds. Candidates are checked against the database together,
+// so a batch costs one query unless codes collide.
+func uniqueShortCodes(tx *gorm.DB, n int) ([]string, error) {
+	// Unscoped returns a statement that chained calls would add to; the
+	// session makes each lookup start from it afresh
+	db := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Session(&gorm.Session{})
+	codes := make([]string, 0, n)
+	tried := make(map[string]bool, n)
+	for length := shortCodeMinLength; length <= shortCodeMaxLength; length++ {
+		for attempt := 0; attempt < shortCodeAttempts; attempt++ {
+			candidates := make([]string, 0, n-len(codes))
+			// Bounded, as a short length may not have room for every row
+			for i := 0; i < 2*n && len(candidates) < n-len(codes); i++ {
+				if code := NewShortCode(length); !tried[code] {
+					tried[code] = true
+					candidates = append(candidates, code)
+				}
+			}
+
+			taken := make(map[string]bool)
+			for start := 0; start < len(candidates); start += shortCodeLookupChunk {
+				chunk := candidates[start:min(start+shortCodeLookupChunk, len(candidates))]
+				var found []string
+				if err := db.Model(&Task{}).Where("short_code IN ?", chunk).Pluck("short_code", &found).Error; err != nil {
+					return nil, err
+				}
+				for _, code := range found {
+					taken[code] = true
+				}
+			}
+			for _, code := range candidates {
+				if !taken[code] {
+					codes = append(codes, code)
+				}
+			}
+			if len(codes) == n {
+				return codes, nil
+			}
+		}
+	}
+	return nil, ErrShortCodeExhausted
+}
+
+// missingShortCodes counts the rows of an insert without a short code
+func missingShortCodes(rows reflect.Value) int {
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		return 1
+	}
+	n := 0
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.Indirect(rows.Index(i))
+		if row.Kind() != reflect.Struct {
+			continue
+		}
+		if code := row.FieldByName("ShortCode"); code.IsValid() && code.String() == "" {
+			n++
+		}
+	}
+	return max(n, 1)
+}
+
+// BeforeCreate generates the ID and short code of a new task.
+func (t *Task) BeforeCreate(tx *gorm.DB) error {
+	if err := t.BaseModel.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if t.ShortCode != "" {
+		return nil
+	}
+	// A batch insert runs this hook for every row, on the same statement,
+	// before writing any; the first row reserves codes for them all
+	pool, ok := tx.Statement.Settings.Load(shortCodePoolKey)
+	if !ok || len(*pool.(*[]string)) == 0 {
+		codes, err := uniqueShortCodes(tx, missingShortCodes(tx.Statement.ReflectValue))
+		if err != nil {
+			return err
+		}
+		pool = &codes
+		tx.Statement.Settings.Store(shortCodePoolKey, pool)
+	}
+	codes := pool.(*[]string)
+	t.ShortCode = (*codes)[0]
+	*codes = (*codes)[1:]
+	return nil
+}
+
+// AssignShortCodes gives every task without a short code one, for tasks
+// created before short codes existed. It returns the number assigned.
+func AssignShortCodes(db *gorm.DB) (int, error) {
+	assigned := 0
+	for {
+		var ids []string
+		err := db.Unscoped().Model(&Task{}).
+			Where("short_code IS NULL OR short_code = ''").
+			Limit(500).Pluck("id", &ids).Error
+		if err != nil {
+			return assigned, err
+		}
+		if len(ids) == 0 {
+			return assigned, nil
+		}
+		codes, err := uniqueShortCodes(db, len(ids))
+		if err != nil {
+			return assigned, err
+		}
+		for i, id := range ids {
+			err = db.Unscoped().Model(&Task{}).Where("id = ?", id).UpdateColumn("short_code", codes[i]).Error
+			if err != nil {
+				return assigned, err
+			}
+			assigned++
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/truthordare/backend/internal/models"
+)
+
+func TestNormalizeShortCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"T-7F3K", "T-7F3K", true},
+		{"t-7f3k", "T-7F3K", true},
+		{"7F3K", "T-7F3K", true},
+		{" 7f-3k ", "T-7F3K", true},
+		{"T-7FOL", "T-7F01", true},
+		{"TABC", "T-TABC", true},
+		{"T-7F3", "", false},
+		{"T-7F3K7F3K7", "", false},
+		{"T-7U3K", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := models.NormalizeShortCode(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewShortCode(t *testing.T) {
+	code := models.NewShortCode(5)
+	normalized, ok := models.NormalizeShortCode(code)
+	assert.True(t, ok)
+	assert.Equal(t, code, normalized)
+	assert.Len(t, code, len(models.ShortCodePrefix)+5)
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Length limits for user-facing text, in characters.
+const (
+	MaxLabelLength        = 100
+	MaxTaskTextLength     = 500
+	MaxHintLength         = 300
+	MaxInstructionsLength = 500
+)
+
+// Validate checks that every key is a supported language code and every
+// text is non-blank and at most maxLen characters. field names the payload
+// field in the returned errors, e.g. label.hi.
+func (m MultilingualText) Validate(field string, maxLen int) []FieldError {
+	langs := make([]string, 0, len(m))
+	for lang := range m {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	var errs []FieldError
+	for _, lang := range langs {
+		name := field + "." + lang
+		if !IsValidLanguage(lang) {
+			errs = append(errs, FieldError{Field: name, Message: "unsupported language code"})
+			continue
+		}
+		errs = append(errs, ValidateText(name, m[lang], maxLen)...)
+	}
+	return errs
+}
+
+// ValidateText checks that text is non-blank and at most maxLen characters.
+// Text made only of characters SanitizeText strips counts as blank.
+func ValidateText(field, text string, maxLen int) []FieldError {
+	if SanitizeText(text) == "" {
+		return []FieldError{{Field: field, Message: "must not be empty"}}
+	}
+	if utf8.RuneCountInString(text) > maxLen {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at most %d characters", maxLen)}}
+	}
+	return nil
+}
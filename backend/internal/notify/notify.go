@@ -0,0 +1,136 @@
+// Package notify delivers operational events to admins.
+//
+// Events go to a webhook (Slack-compatible JSON with a "text" field plus the
+// structured event) when NOTIFY_WEBHOOK_URL is set, and to the log otherwise.
+// Events addressed to recipients, such as the weekly digest, list them so a
+// mail relay behind the webhook can deliver them.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event types
+const (
+	EventTaskDeactivated = "task_deactivated"
+	EventPanic           = "panic"
+	EventWeeklyDigest    = "weekly_digest"
+	EventDiskSpaceLow    = "disk_space_low"
+	EventDiskSpaceOK     = "disk_space_recovered"
+)
+
+// Event is a notification for admins
+type Event struct {
+	Type    string            `json:"type"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	// Body is an optional multi-line text report following the message
+	Body string `json:"body,omitempty"`
+	// Recipients are the addresses the event is meant for, if any
+	Recipients []string  `json:"recipients,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Notifier delivers events to admins
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// New returns a webhook notifier when webhookURL is set, otherwise a log notifier
+func New(webhookURL string) Notifier {
+	if webhookURL == "" {
+		return LogNotifier{}
+	}
+	return NewWebhookNotifier(webhookURL)
+}
+
+// LogNotifier writes events to the application log
+type LogNotifier struct{}
+
+// Notify logs the event at warn level so it stands out from request logs
+func (LogNotifier) Notify(ctx context.Context, event Event) error {
+	entry := log.Warn().Str("event", event.Type)
+	for k, v := range event.Fields {
+		entry = entry.Str(k, v)
+	}
+	if len(event.Recipients) > 0 {
+		entry = entry.Strs("recipients", event.Recipients)
+	}
+	if event.Body != "" {
+		entry = entry.Str("body", event.Body)
+	}
+	entry.Msg(event.Message)
+	return nil
+}
+
+// WebhookNotifier posts events as JSON to a URL
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the event. Any non-2xx response is an error.
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+		Event
+	}{Text: summary(event), Event: event})
+	if err != nil {
+		return fmt.Errorf("notify: failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify: webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// summary renders the event as a single line for chat webhooks, followed
+// by the body when there is one
+func summary(event Event) string {
+	keys := make([]string, 0, len(event.Fields))
+	for k := range event.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(event.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%s", k, event.Fields[k])
+	}
+	if event.Body != "" {
+		b.WriteString("\n")
+		b.WriteString(event.Body)
+	}
+	return b.String()
+}
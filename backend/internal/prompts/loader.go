@@ -11,6 +11,7 @@ package prompts
 import (
 	"embed"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -132,3 +133,48 @@ func (l *PromptLoader) ListAvailable() ([]string, error) {
 func P(key, value string) Placeholder {
 	return Placeholder{Key: key, Value: value}
 }
+
+// Key returns a stable identity for a rendered template, built from the
+// template name and its placeholder values in a fixed order. It is used to
+// key caches of AI responses to the same prompt.
+func Key(name string, placeholders ...Placeholder) string {
+	sorted := make([]Placeholder, len(placeholders))
+	copy(sorted, placeholders)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, p := range sorted {
+		b.WriteString("\x00")
+		b.WriteString(p.Key)
+		b.WriteString("=")
+		b.WriteString(p.Value)
+	}
+	return b.String()
+}
+
+// FormatExamples renders existing truths and dares as a bulleted list for the
+// {{EXAMPLES}} placeholder of the generation prompt.
+func FormatExamples(truths, dares []string) string {
+	if len(truths) == 0 && len(dares) == 0 {
+		return "None yet."
+	}
+
+	var b strings.Builder
+	for _, section := range []struct {
+		title string
+		items []string
+	}{{"Truths", truths}, {"Dares", dares}} {
+		if len(section.items) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(section.title + ":\n")
+		for _, item := range section.items {
+			b.WriteString("- " + item + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,188 @@
+package repository_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Run with: go test ./internal/repository -run '^$' -bench . -benchmem
+
+// benchmarkTaskCount is the number of tasks seeded for read benchmarks.
+const benchmarkTaskCount = 20000
+
+// benchmarkConfigs are the GORM settings compared by the benchmarks, so the
+// defaults in config.DatabaseConfig can be justified with numbers.
+var benchmarkConfigs = []struct {
+	name string
+	cfg  gorm.Config
+}{
+	{name: "default", cfg: gorm.Config{}},
+	{name: "prepare", cfg: gorm.Config{PrepareStmt: true}},
+	{name: "skip_tx", cfg: gorm.Config{SkipDefaultTransaction: true}},
+	{name: "prepare+skip_tx", cfg: gorm.Config{PrepareStmt: true, SkipDefaultTransaction: true}},
+}
+
+// setupBenchmarkDB opens a file-backed SQLite database with the given settings.
+// A file is used rather than :memory: so every pooled connection sees the same data.
+func setupBenchmarkDB(b *testing.B, cfg gorm.Config) *gorm.DB {
+	b.Helper()
+
+	cfg.Logger = logger.Default.LogMode(logger.Silent)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(b.TempDir(), "bench.db")), &cfg)
+	require.NoError(b, err)
+	require.NoError(b, db.AutoMigrate(&models.Category{}, &models.Task{}))
+
+	return db
+}
+
+// seedBenchmarkTasks inserts n tasks spread across languages and types.
+func seedBenchmarkTasks(b *testing.B, db *gorm.DB, n int) *models.Category {
+	b.Helper()
+
+	category := &models.Category{Label: models.MultilingualText{"en": "Bench"}, Emoji: "⏱️", AgeGroup: models.AgeGroupKids, IsActive: true}
+	require.NoError(b, db.Create(category).Error)
+
+	require.NoError(b, db.CreateInBatches(benchmarkTasks(category.ID, n), 500).Error)
+
+	return category
+}
+
+// benchmarkTasks builds n unsaved tasks for the given category.
+func benchmarkTasks(categoryID string, n int) []models.Task {
+	tasks := make([]models.Task, n)
+	for i := range tasks {
+		taskType := models.TaskTypeTruth
+		if i%2 == 1 {
+			taskType = models.TaskTypeDare
+		}
+		tasks[i] = models.Task{
+			Text:       fmt.Sprintf("Benchmark task %d", i),
+			Language:   models.SupportedLanguages[i%len(models.SupportedLanguages)],
+			Type:       taskType,
+			CategoryID: categoryID,
+		}
+	}
+	return tasks
+}
+
+func BenchmarkTaskRepository_FindRandom(b *testing.B) {
+	db := setupBenchmarkDB(b, gorm.Config{})
+	seedBenchmarkTasks(b, db, benchmarkTaskCount)
+
+	taskRepo := repository.NewTaskRepository(db)
+	filter := &repository.TaskFilter{Type: models.TaskTypeTruth, Language: "en"}
+
+	b.Run("random offset", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := taskRepo.FindRandom(filter); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// Baseline: the ORDER BY RANDOM() LIMIT 1 approach FindRandom replaced.
+	b.Run("order by random", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := taskRepo.FindAll(&repository.TaskFilter{
+				Type:     filter.Type,
+				Language: filter.Language,
+				Random:   true,
+				Limit:    1,
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTaskRepository_FindAll(b *testing.B) {
+	for _, bc := range benchmarkConfigs {
+		b.Run(bc.name, func(b *testing.B) {
+			db := setupBenchmarkDB(b, bc.cfg)
+			category := seedBenchmarkTasks(b, db, benchmarkTaskCount)
+			taskRepo := repository.NewTaskRepository(db)
+
+			filter := &repository.TaskFilter{
+				CategoryIDs: []string{category.ID},
+				Languages:   []string{"en", "hi"},
+				Limit:       20,
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := taskRepo.FindAll(filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTaskRepository_CountByFilters(b *testing.B) {
+	for _, bc := range benchmarkConfigs {
+		b.Run(bc.name, func(b *testing.B) {
+			db := setupBenchmarkDB(b, bc.cfg)
+			category := seedBenchmarkTasks(b, db, benchmarkTaskCount)
+			taskRepo := repository.NewTaskRepository(db)
+
+			filter := &repository.TaskFilter{
+				CategoryIDs: []string{category.ID},
+				Languages:   []string{"en"},
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := taskRepo.CountByFilters(filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTaskRepository_Create(b *testing.B) {
+	for _, bc := range benchmarkConfigs {
+		b.Run(bc.name, func(b *testing.B) {
+			db := setupBenchmarkDB(b, bc.cfg)
+			category := seedBenchmarkTasks(b, db, 0)
+			taskRepo := repository.NewTaskRepository(db)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				task := &models.Task{Text: "Create", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+				if err := taskRepo.Create(task); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTaskRepository_CreateBatch(b *testing.B) {
+	for _, batchSize := range []int{50, 100, 500} {
+		b.Run(fmt.Sprintf("batch_%d", batchSize), func(b *testing.B) {
+			db := setupBenchmarkDB(b, gorm.Config{CreateBatchSize: batchSize, SkipDefaultTransaction: true})
+			category := seedBenchmarkTasks(b, db, 0)
+			taskRepo := repository.NewTaskRepository(db)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tasks := benchmarkTasks(category.ID, 1000)
+				b.StartTimer()
+
+				if err := taskRepo.CreateBatch(tasks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CategoryRankRepository stores the play counts behind the smart category
+// order.
+type CategoryRankRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRankRepository creates a new CategoryRankRepository.
+func NewCategoryRankRepository(db *gorm.DB) *CategoryRankRepository {
+	return &CategoryRankRepository{db: db}
+}
+
+// Replace swaps every stored rank for ranks in one transaction, so readers
+// never see a half-computed order.
+func (r *CategoryRankRepository) Replace(ranks []models.CategoryRank) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.CategoryRank{}).Error; err != nil {
+			return err
+		}
+		if len(ranks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(ranks, defaultCreateBatchSize).Error
+	})
+}
+
+// FindAll returns the ranks for a language and age group, most played
+// first. Empty keys select the ranks summed over all of them.
+func (r *CategoryRankRepository) FindAll(language, ageGroup string) ([]models.CategoryRank, error) {
+	var ranks []models.CategoryRank
+	err := r.db.Where("language = ? AND age_group = ?", language, ageGroup).
+		Order("plays DESC, category_id ASC").
+		Find(&ranks).Error
+	return ranks, err
+}
@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/truthordare/backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -20,6 +24,13 @@ type CategoryFilter struct {
 	AgeGroups       []string // Filter by age groups (kids, teen, adults)
 	RequiresConsent *bool    // Filter by consent requirement
 	IsActive        *bool    // Filter by active status
+
+	// SmartOrder sorts the most played categories for SmartLanguage and
+	// SmartAgeGroup first (see models.CategoryRank), then by sort_order.
+	// Empty keys rank over all languages or age groups.
+	SmartOrder    bool
+	SmartLanguage string
+	SmartAgeGroup string
 }
 
 // FindAll retrieves all categories with optional filters.
@@ -29,19 +40,26 @@ func (r *CategoryRepository) FindAll(filter *CategoryFilter) ([]models.Category,
 
 	if filter != nil {
 		if len(filter.AgeGroups) > 0 {
-			query = query.Where("age_group IN ?", filter.AgeGroups)
+			query = query.Where("categories.age_group IN ?", filter.AgeGroups)
 		}
 
 		if filter.RequiresConsent != nil {
-			query = query.Where("requires_consent = ?", *filter.RequiresConsent)
+			query = query.Where("categories.requires_consent = ?", *filter.RequiresConsent)
 		}
 
 		if filter.IsActive != nil {
-			query = query.Where("is_active = ?", *filter.IsActive)
+			query = query.Where("categories.is_active = ?", *filter.IsActive)
+		}
+
+		if filter.SmartOrder {
+			query = query.
+				Joins("LEFT JOIN category_ranks ON category_ranks.category_id = categories.id AND category_ranks.language = ? AND category_ranks.age_group = ?",
+					filter.SmartLanguage, filter.SmartAgeGroup).
+				Order("COALESCE(category_ranks.plays, 0) DESC")
 		}
 	}
 
-	err := query.Order("sort_order ASC, created_at DESC").Find(&categories).Error
+	err := query.Order("categories.sort_order ASC, categories.created_at DESC").Find(&categories).Error
 	return categories, err
 }
 
@@ -55,9 +73,25 @@ func (r *CategoryRepository) FindByID(id string) (*models.Category, error) {
 	return &category, nil
 }
 
-// Create creates a new category.
+// FindByIDs retrieves the categories with the given IDs.
+func (r *CategoryRepository) FindByIDs(ids []string) ([]models.Category, error) {
+	var categories []models.Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&categories).Error
+	return categories, err
+}
+
+// Create creates a new category. It returns a *LabelConflictError when
+// another category already uses one of its labels.
 func (r *CategoryRepository) Create(category *models.Category) error {
-	return r.db.Create(category).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLabelConflicts(tx, category); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
 }
 
 // Update updates an existing category.
@@ -65,6 +99,133 @@ func (r *CategoryRepository) Update(category *models.Category) error {
 	return r.db.Save(category).Error
 }
 
+// UpdateUnique updates an existing category like Update, returning a
+// *LabelConflictError when another category already uses one of its labels.
+func (r *CategoryRepository) UpdateUnique(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLabelConflicts(tx, category); err != nil {
+			return err
+		}
+		return tx.Save(category).Error
+	})
+}
+
+// LabelConflict is a label already used by another category.
+type LabelConflict struct {
+	Language   string `json:"language"`
+	Label      string `json:"label"`
+	CategoryID string `json:"category_id"`
+}
+
+// LabelConflictError reports the labels of a category that other
+// categories already use.
+type LabelConflictError struct {
+	Conflicts []LabelConflict
+}
+
+func (e *LabelConflictError) Error() string {
+	c := e.Conflicts[0]
+	return fmt.Sprintf("category label %q (%s) is already used by category %s", c.Label, c.Language, c.CategoryID)
+}
+
+// checkLabelConflicts compares the category's labels with every other
+// category, per language and ignoring case and extra whitespace. The
+// categories table is small, so labels are compared in Go rather than
+// through JSON queries.
+func checkLabelConflicts(tx *gorm.DB, category *models.Category) error {
+	var others []models.Category
+	query := tx.Select("id", "label")
+	if category.ID != "" {
+		query = query.Where("id <> ?", category.ID)
+	}
+	if err := query.Find(&others).Error; err != nil {
+		return err
+	}
+
+	var conflicts []LabelConflict
+	for _, lang := range sortedLanguages(category.Label) {
+		key := labelKey(category.Label[lang])
+		for _, other := range others {
+			if text, ok := other.Label[lang]; ok && labelKey(text) == key {
+				conflicts = append(conflicts, LabelConflict{Language: lang, Label: text, CategoryID: other.ID})
+				break
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &LabelConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// Search returns up to limit categories whose ID equals q or whose label
+// contains it in any language, ignoring case, in display order.
+func (r *CategoryRepository) Search(q string, limit int) ([]CategoryMatch, error) {
+	var categories []models.Category
+	// The LIKE narrows the scan; labels are matched on the decoded map
+	err := r.db.Where("id = ? OR label LIKE ? ESCAPE '\\'", q, likePattern(q)).
+		Order("sort_order ASC, created_at ASC").
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []CategoryMatch
+	for _, category := range categories {
+		languages := matchingLanguages(category.Label, q)
+		if len(languages) == 0 && category.ID != q {
+			continue
+		}
+		matches = append(matches, CategoryMatch{Category: category, Languages: languages})
+		if len(matches) == limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// CategoryMatch is a category found by Search, with the languages of its
+// label that matched
+type CategoryMatch struct {
+	Category  models.Category
+	Languages []string
+}
+
+// likePattern builds a LIKE pattern matching values containing q, with the
+// wildcards in q escaped by a backslash
+func likePattern(q string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q)
+	return "%" + escaped + "%"
+}
+
+// matchingLanguages returns the languages of text containing q, ignoring case
+func matchingLanguages(text models.MultilingualText, q string) []string {
+	q = strings.ToLower(q)
+	var languages []string
+	for _, lang := range sortedLanguages(text) {
+		if strings.Contains(strings.ToLower(text[lang]), q) {
+			languages = append(languages, lang)
+		}
+	}
+	return languages
+}
+
+// labelKey is the form labels are compared in
+func labelKey(label string) string {
+	return strings.ToLower(models.SanitizeText(label))
+}
+
+// sortedLanguages returns the languages of a text in a stable order
+func sortedLanguages(text models.MultilingualText) []string {
+	langs := make([]string, 0, len(text))
+	for lang := range text {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
 // CountTasks returns the number of tasks in a category.
 func (r *CategoryRepository) CountTasks(categoryID string) (int64, error) {
 	var count int64
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ChatRepository handles chat workspace and channel draw database
+// operations.
+type ChatRepository struct {
+	db *gorm.DB
+}
+
+// NewChatRepository creates a new ChatRepository.
+func NewChatRepository(db *gorm.DB) *ChatRepository {
+	return &ChatRepository{db: db}
+}
+
+// FindWorkspaces retrieves every chat workspace, by platform and name.
+func (r *ChatRepository) FindWorkspaces() ([]models.ChatWorkspace, error) {
+	var workspaces []models.ChatWorkspace
+	err := r.db.Order("platform ASC, name ASC").Find(&workspaces).Error
+	return workspaces, err
+}
+
+// FindWorkspaceByID retrieves a chat workspace by ID.
+func (r *ChatRepository) FindWorkspaceByID(id string) (*models.ChatWorkspace, error) {
+	var workspace models.ChatWorkspace
+	if err := r.db.First(&workspace, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// FindWorkspace retrieves the chat workspace of a platform's team or
+// server ID.
+func (r *ChatRepository) FindWorkspace(platform, workspaceID string) (*models.ChatWorkspace, error) {
+	var workspace models.ChatWorkspace
+	err := r.db.First(&workspace, "platform = ? AND workspace_id = ?", platform, workspaceID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// ExistsWorkspace reports whether a workspace other than excludeID is
+// stored for the same platform and workspace ID.
+func (r *ChatRepository) ExistsWorkspace(platform, workspaceID, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.Unscoped().Model(&models.ChatWorkspace{}).
+		Where("platform = ? AND workspace_id = ?", platform, workspaceID)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// CreateWorkspace creates a new chat workspace.
+func (r *ChatRepository) CreateWorkspace(workspace *models.ChatWorkspace) error {
+	return r.db.Create(workspace).Error
+}
+
+// UpdateWorkspace updates an existing chat workspace.
+func (r *ChatRepository) UpdateWorkspace(workspace *models.ChatWorkspace) error {
+	return r.db.Save(workspace).Error
+}
+
+// DeleteWorkspace permanently deletes a chat workspace and the draw
+// history of its channels.
+func (r *ChatRepository) DeleteWorkspace(workspace *models.ChatWorkspace) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("platform = ? AND workspace_id = ?", workspace.Platform, workspace.WorkspaceID).
+			Delete(&models.ChatDraw{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.ChatWorkspace{}, "id = ?", workspace.ID).Error
+	})
+}
+
+// RecentDraws returns the IDs of the last limit tasks drawn for a channel,
+// newest first.
+func (r *ChatRepository) RecentDraws(platform, workspaceID, channelID string, limit int) ([]string, error) {
+	var ids []string
+	err := channelDraws(r.db, platform, workspaceID, channelID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("task_id", &ids).Error
+	return ids, err
+}
+
+// RecordDraw stores a draw and forgets the channel's draws beyond the last
+// keep, so the history of busy channels stays bounded.
+func (r *ChatRepository) RecordDraw(draw *models.ChatDraw, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(draw).Error; err != nil {
+			return err
+		}
+		kept := channelDraws(tx, draw.Platform, draw.WorkspaceID, draw.ChannelID).
+			Order("created_at DESC").
+			Limit(keep).
+			Select("id")
+		return channelDraws(tx, draw.Platform, draw.WorkspaceID, draw.ChannelID).
+			Unscoped().
+			Where("id NOT IN (?)", kept).
+			Delete(&models.ChatDraw{}).Error
+	})
+}
+
+// channelDraws scopes a query to the draws of one channel
+func channelDraws(db *gorm.DB, platform, workspaceID, channelID string) *gorm.DB {
+	return db.Model(&models.ChatDraw{}).
+		Where("platform = ? AND workspace_id = ? AND channel_id = ?", platform, workspaceID, channelID)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ConsentRepository handles consent record database operations.
+type ConsentRepository struct {
+	db *gorm.DB
+}
+
+// NewConsentRepository creates a new ConsentRepository.
+func NewConsentRepository(db *gorm.DB) *ConsentRepository {
+	return &ConsentRepository{db: db}
+}
+
+// ConsentFilter contains filter options for querying consent records.
+type ConsentFilter struct {
+	ClientID      string
+	SessionID     string
+	PolicyVersion string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Limit         int
+	Offset        int
+}
+
+// Create creates a new consent record.
+func (r *ConsentRepository) Create(record *models.ConsentRecord) error {
+	return r.db.Create(record).Error
+}
+
+// FindAll retrieves consent records, newest first, with the total count
+// matching the filter.
+func (r *ConsentRepository) FindAll(filter *ConsentFilter) ([]models.ConsentRecord, int64, error) {
+	var records []models.ConsentRecord
+	var total int64
+
+	query := r.db.Model(&models.ConsentRecord{})
+	if filter.ClientID != "" {
+		query = query.Where("client_id = ?", filter.ClientID)
+	}
+	if filter.SessionID != "" {
+		query = query.Where("session_id = ?", filter.SessionID)
+	}
+	if filter.PolicyVersion != "" {
+		query = query.Where("policy_version = ?", filter.PolicyVersion)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedBefore)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&records).Error
+	return records, total, err
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// GenerationRunRepository handles generation run report database operations.
+type GenerationRunRepository struct {
+	db *gorm.DB
+}
+
+// NewGenerationRunRepository creates a new GenerationRunRepository.
+func NewGenerationRunRepository(db *gorm.DB) *GenerationRunRepository {
+	return &GenerationRunRepository{db: db}
+}
+
+// Create creates a new generation run.
+func (r *GenerationRunRepository) Create(run *models.GenerationRun) error {
+	return r.db.Create(run).Error
+}
+
+// Save writes the current state of a run.
+func (r *GenerationRunRepository) Save(run *models.GenerationRun) error {
+	return r.db.Save(run).Error
+}
+
+// FindByID retrieves a generation run by ID.
+func (r *GenerationRunRepository) FindByID(id string) (*models.GenerationRun, error) {
+	var run models.GenerationRun
+	if err := r.db.First(&run, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FindRecent retrieves up to limit runs, newest first.
+func (r *GenerationRunRepository) FindRecent(limit int) ([]models.GenerationRun, error) {
+	var runs []models.GenerationRun
+	err := r.db.Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// ManualUsageSince counts the manual runs an admin key started since a
+// time, and the tasks they requested.
+func (r *GenerationRunRepository) ManualUsageSince(requestedBy string, since time.Time) (runs, requested int64, err error) {
+	var usage struct {
+		Runs      int64
+		Requested int64
+	}
+	err = r.db.Model(&models.GenerationRun{}).
+		Select("COUNT(*) AS runs, COALESCE(SUM(requested_count), 0) AS requested").
+		Where("trigger = ? AND requested_by = ? AND started_at >= ?", models.GenerationTriggerManual, requestedBy, since).
+		Scan(&usage).Error
+	return usage.Runs, usage.Requested, err
+}
+
+// GenerationReviewCounts is the moderation outcome so far of the tasks a
+// run created. Deleted tasks are not counted.
+type GenerationReviewCounts struct {
+	Pending  int64 `json:"pending"`
+	Approved int64 `json:"approved"`
+	Rejected int64 `json:"rejected"`
+	Active   int64 `json:"active"`
+}
+
+// ReviewCounts counts the tasks created by a run per review state.
+func (r *GenerationRunRepository) ReviewCounts(runID string) (GenerationReviewCounts, error) {
+	type Result struct {
+		ReviewState string
+		IsActive    bool
+		Count       int64
+	}
+
+	var results []Result
+	err := r.db.Model(&models.Task{}).
+		Select("review_state, is_active, count(*) as count").
+		Where("generation_run_id = ?", runID).
+		Group("review_state, is_active").
+		Find(&results).Error
+	if err != nil {
+		return GenerationReviewCounts{}, err
+	}
+
+	var counts GenerationReviewCounts
+	for _, res := range results {
+		switch res.ReviewState {
+		case models.ReviewStatePending:
+			counts.Pending += res.Count
+		case models.ReviewStateApproved:
+			counts.Approved += res.Count
+		case models.ReviewStateRejected:
+			counts.Rejected += res.Count
+		}
+		if res.IsActive {
+			counts.Active += res.Count
+		}
+	}
+	return counts, nil
+}
+
+// GenerationUsage sums the generation runs started in a period.
+type GenerationUsage struct {
+	Runs             int64 `json:"runs"`
+	FailedRuns       int64 `json:"failed_runs"`
+	TasksCreated     int64 `json:"tasks_created"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// UsageSince sums the runs started at or after since.
+func (r *GenerationRunRepository) UsageSince(since time.Time) (GenerationUsage, error) {
+	var usage GenerationUsage
+	err := r.db.Model(&models.GenerationRun{}).
+		Select("count(*) as runs, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) as failed_runs, "+
+			"COALESCE(SUM(tasks_created), 0) as tasks_created, "+
+			"COALESCE(SUM(prompt_tokens), 0) as prompt_tokens, "+
+			"COALESCE(SUM(completion_tokens), 0) as completion_tokens, "+
+			"COALESCE(SUM(total_tokens), 0) as total_tokens", models.GenerationRunFailed).
+		Where("started_at >= ?", since).
+		Scan(&usage).Error
+	return usage, err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// GlossaryRepository handles glossary term database operations.
+type GlossaryRepository struct {
+	db *gorm.DB
+}
+
+// NewGlossaryRepository creates a new GlossaryRepository.
+func NewGlossaryRepository(db *gorm.DB) *GlossaryRepository {
+	return &GlossaryRepository{db: db}
+}
+
+// FindAll retrieves every glossary term in alphabetical order.
+func (r *GlossaryRepository) FindAll() (models.Glossary, error) {
+	var terms models.Glossary
+	err := r.db.Order("term ASC").Find(&terms).Error
+	return terms, err
+}
+
+// FindByID retrieves a glossary term by ID.
+func (r *GlossaryRepository) FindByID(id string) (*models.GlossaryTerm, error) {
+	var term models.GlossaryTerm
+	if err := r.db.First(&term, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+// ExistsTerm reports whether a term other than excludeID is stored under
+// the same text, ignoring case.
+func (r *GlossaryRepository) ExistsTerm(term, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.GlossaryTerm{}).Where("LOWER(term) = LOWER(?)", term)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// Create creates a new glossary term.
+func (r *GlossaryRepository) Create(term *models.GlossaryTerm) error {
+	return r.db.Create(term).Error
+}
+
+// Update updates an existing glossary term.
+func (r *GlossaryRepository) Update(term *models.GlossaryTerm) error {
+	return r.db.Save(term).Error
+}
+
+// Delete permanently deletes a glossary term, freeing the term for reuse.
+func (r *GlossaryRepository) Delete(id string) error {
+	return r.db.Unscoped().Delete(&models.GlossaryTerm{}, "id = ?", id).Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ImportJobRepository handles import job database operations.
+type ImportJobRepository struct {
+	db *gorm.DB
+}
+
+// NewImportJobRepository creates a new ImportJobRepository.
+func NewImportJobRepository(db *gorm.DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+// Create creates a new import job.
+func (r *ImportJobRepository) Create(job *models.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// Save writes the current state of a job.
+func (r *ImportJobRepository) Save(job *models.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+// FindByID retrieves an import job by ID.
+func (r *ImportJobRepository) FindByID(id string) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := r.db.First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindByHash retrieves the latest import job of a file, by its hash.
+func (r *ImportJobRepository) FindByHash(hash string) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := r.db.Order("created_at DESC").First(&job, "file_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindRecent retrieves up to limit jobs, newest first.
+func (r *ImportJobRepository) FindRecent(limit int) ([]models.ImportJob, error) {
+	var jobs []models.ImportJob
+	err := r.db.Order("created_at DESC").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// Claim marks a job running so it can be resumed, and reports whether it
+// could. Failed jobs can be claimed, and so can running jobs that have not
+// saved progress for staleAfter, as their server most likely died.
+func (r *ImportJobRepository) Claim(id string, staleAfter time.Duration) (bool, error) {
+	result := r.db.Model(&models.ImportJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))",
+			id, models.ImportFailed, models.ImportRunning, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{"status": models.ImportRunning, "error": ""})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// languageBatchSize is the number of rows loaded and rewritten per query
+const languageBatchSize = 500
+
+// multilingualColumns lists every MultilingualText column. New columns
+// holding translations must be added here so language changes cover them.
+var multilingualColumns = []struct {
+	table  string
+	column string
+}{
+	{table: "categories", column: "label"},
+	{table: "categories", column: "instructions"},
+	{table: "tasks", column: "hint"},
+}
+
+// LanguageColumnChange reports the rows a language change touches in one column.
+type LanguageColumnChange struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Rows   int64  `json:"rows"`
+	// Conflicts counts rows of a rename that already had the new code; the
+	// existing text is kept and the old code dropped.
+	Conflicts int64 `json:"conflicts,omitempty"`
+}
+
+// LanguageRepository rewrites language keys across all MultilingualText columns.
+type LanguageRepository struct {
+	db *gorm.DB
+}
+
+// NewLanguageRepository creates a new LanguageRepository.
+func NewLanguageRepository(db *gorm.DB) *LanguageRepository {
+	return &LanguageRepository{db: db}
+}
+
+// Prune removes a language from every multilingual column. With dryRun set
+// it only counts the affected rows.
+func (r *LanguageRepository) Prune(code string, dryRun bool) ([]LanguageColumnChange, error) {
+	return r.rewrite(code, dryRun, func(text models.MultilingualText) bool {
+		delete(text, code)
+		return false
+	})
+}
+
+// Rename moves texts from one language code to another in every
+// multilingual column. With dryRun set it only counts the affected rows.
+func (r *LanguageRepository) Rename(from, to string, dryRun bool) ([]LanguageColumnChange, error) {
+	return r.rewrite(from, dryRun, func(text models.MultilingualText) bool {
+		value := text[from]
+		delete(text, from)
+		if _, exists := text[to]; exists {
+			return true
+		}
+		text[to] = value
+		return false
+	})
+}
+
+// multilingualRow is one row's ID and multilingual column
+type multilingualRow struct {
+	ID   string
+	Text models.MultilingualText
+}
+
+// rewrite applies change to every row holding code, in batches within one
+// transaction. change edits the text in place and reports a conflict.
+// Soft-deleted rows are rewritten too, so restoring them stays consistent.
+func (r *LanguageRepository) rewrite(code string, dryRun bool, change func(models.MultilingualText) bool) ([]LanguageColumnChange, error) {
+	changes := make([]LanguageColumnChange, 0, len(multilingualColumns))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, col := range multilingualColumns {
+			result := LanguageColumnChange{Table: col.table, Column: col.column}
+
+			lastID := ""
+			for {
+				// The LIKE narrows the scan; the key is checked on the decoded map
+				var rows []multilingualRow
+				err := tx.Table(col.table).
+					Select("id, "+col.column+" AS text").
+					Where("id > ? AND "+col.column+" LIKE ?", lastID, `%"`+code+`":%`).
+					Order("id ASC").
+					Limit(languageBatchSize).
+					Scan(&rows).Error
+				if err != nil {
+					return err
+				}
+
+				for _, row := range rows {
+					if _, ok := row.Text[code]; !ok {
+						continue
+					}
+					result.Rows++
+					if change(row.Text) {
+						result.Conflicts++
+					}
+					if dryRun {
+						continue
+					}
+					err := tx.Table(col.table).
+						Where("id = ?", row.ID).
+						UpdateColumn(col.column, row.Text).Error
+					if err != nil {
+						return err
+					}
+				}
+
+				if len(rows) < languageBatchSize {
+					break
+				}
+				lastID = rows[len(rows)-1].ID
+			}
+
+			changes = append(changes, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Freeze freezes a language, replacing the reason of an existing freeze.
+func (r *LanguageRepository) Freeze(freeze *models.LanguageFreeze) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "language"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason"}),
+	}).Create(freeze).Error
+}
+
+// Unfreeze lifts the freeze of a language. It reports whether the language
+// was frozen.
+func (r *LanguageRepository) Unfreeze(language string) (bool, error) {
+	result := r.db.Where("language = ?", language).Delete(&models.LanguageFreeze{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindFreezes returns the frozen languages in code order.
+func (r *LanguageRepository) FindFreezes() ([]models.LanguageFreeze, error) {
+	var freezes []models.LanguageFreeze
+	err := r.db.Order("language ASC").Find(&freezes).Error
+	return freezes, err
+}
+
+// FrozenLanguages returns the set of frozen language codes.
+func (r *LanguageRepository) FrozenLanguages() (map[string]bool, error) {
+	var languages []string
+	if err := r.db.Model(&models.LanguageFreeze{}).Pluck("language", &languages).Error; err != nil {
+		return nil, err
+	}
+	frozen := make(map[string]bool, len(languages))
+	for _, lang := range languages {
+		frozen[lang] = true
+	}
+	return frozen, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// PrivacyRepository exports and purges all rows tied to a client identifier
+// and keeps the audit trail of those requests.
+type PrivacyRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRepository creates a new PrivacyRepository.
+func NewPrivacyRepository(db *gorm.DB) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+// ClientExport holds every stored row tied to a client identifier.
+type ClientExport struct {
+	ClientID string                 `json:"client_id"`
+	Reports  []models.TaskReport    `json:"reports"`
+	Consents []models.ConsentRecord `json:"consents"`
+}
+
+// clientTables lists the tables holding a client_id column. New tables
+// storing client identifiers must be added here so deletion covers them.
+var clientTables = []struct {
+	name  string
+	model interface{}
+}{
+	{name: "task_reports", model: &models.TaskReport{}},
+	{name: "consent_records", model: &models.ConsentRecord{}},
+}
+
+// HashClientID returns the identifier form stored in the audit trail.
+func HashClientID(clientID string) string {
+	sum := sha256.Sum256([]byte(clientID))
+	return hex.EncodeToString(sum[:])
+}
+
+// Export returns all rows tied to the client, including soft-deleted ones.
+func (r *PrivacyRepository) Export(clientID string) (*ClientExport, error) {
+	export := &ClientExport{
+		ClientID: clientID,
+		Reports:  []models.TaskReport{},
+		Consents: []models.ConsentRecord{},
+	}
+
+	for _, dest := range []interface{}{&export.Reports, &export.Consents} {
+		err := r.db.Unscoped().
+			Where("client_id = ?", clientID).
+			Order("created_at ASC").
+			Find(dest).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+// Counts returns the number of rows per table in an export.
+func (e *ClientExport) Counts() map[string]int64 {
+	return map[string]int64{
+		"task_reports":    int64(len(e.Reports)),
+		"consent_records": int64(len(e.Consents)),
+	}
+}
+
+// Delete permanently removes all rows tied to the client and returns the
+// number of rows removed per table. Soft-deleted rows are removed too.
+func (r *PrivacyRepository) Delete(clientID string) (map[string]int64, error) {
+	deleted := make(map[string]int64, len(clientTables))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range clientTables {
+			result := tx.Unscoped().Where("client_id = ?", clientID).Delete(table.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted[table.name] = result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// RecordAudit stores an audit entry for an export or deletion.
+func (r *PrivacyRepository) RecordAudit(action, clientID string, rows map[string]int64) error {
+	return r.db.Create(&models.PrivacyAudit{
+		Action:       action,
+		ClientIDHash: HashClientID(clientID),
+		Rows:         rows,
+	}).Error
+}
+
+// FindAudits retrieves audit entries, newest first. When clientID is set,
+// only entries for that client are returned.
+func (r *PrivacyRepository) FindAudits(clientID string, limit, offset int) ([]models.PrivacyAudit, int64, error) {
+	var audits []models.PrivacyAudit
+	var total int64
+
+	query := r.db.Model(&models.PrivacyAudit{})
+	if clientID != "" {
+		query = query.Where("client_id_hash = ?", HashClientID(clientID))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Find(&audits).Error
+	return audits, total, err
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReportRepository handles task report database operations.
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository creates a new ReportRepository.
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Create creates a new report.
+func (r *ReportRepository) Create(report *models.TaskReport) error {
+	return r.db.Create(report).Error
+}
+
+// CountOpenSince returns the number of unresolved reports for a task
+// created at or after since.
+func (r *ReportRepository) CountOpenSince(taskID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TaskReport{}).
+		Where("task_id = ? AND resolved_at IS NULL AND created_at >= ?", taskID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// HasOpenFromClient reports whether the client already has an unresolved
+// report for the task created at or after since.
+func (r *ReportRepository) HasOpenFromClient(taskID, clientID string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TaskReport{}).
+		Where("task_id = ? AND client_id = ? AND resolved_at IS NULL AND created_at >= ?", taskID, clientID, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindOpenByTask retrieves the unresolved reports for a task, newest first.
+func (r *ReportRepository) FindOpenByTask(taskID string) ([]models.TaskReport, error) {
+	var reports []models.TaskReport
+	err := r.db.
+		Where("task_id = ? AND resolved_at IS NULL", taskID).
+		Order("created_at DESC").
+		Find(&reports).Error
+	return reports, err
+}
+
+// CountOpenByTask returns unresolved report counts for the given tasks.
+func (r *ReportRepository) CountOpenByTask(taskIDs []string) (map[string]int64, error) {
+	type Result struct {
+		TaskID string
+		Count  int64
+	}
+
+	counts := make(map[string]int64)
+	if len(taskIDs) == 0 {
+		return counts, nil
+	}
+
+	var results []Result
+	err := r.db.Model(&models.TaskReport{}).
+		Select("task_id, count(*) as count").
+		Where("task_id IN ? AND resolved_at IS NULL", taskIDs).
+		Group("task_id").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range results {
+		counts[res.TaskID] = res.Count
+	}
+	return counts, nil
+}
+
+// ResolveForTask marks all unresolved reports for a task as resolved.
+func (r *ReportRepository) ResolveForTask(taskID string) error {
+	return r.db.Model(&models.TaskReport{}).
+		Where("task_id = ? AND resolved_at IS NULL", taskID).
+		Update("resolved_at", time.Now()).Error
+}
+
+// CountOpen returns the number of unresolved reports and of the tasks
+// they are against.
+func (r *ReportRepository) CountOpen() (reports, tasks int64, err error) {
+	var result struct {
+		Reports int64
+		Tasks   int64
+	}
+	err = r.db.Model(&models.TaskReport{}).
+		Select("count(*) as reports, count(DISTINCT task_id) as tasks").
+		Where("resolved_at IS NULL").
+		Scan(&result).Error
+	return result.Reports, result.Tasks, err
+}
+
+// ReportedTask is the number of reports against a task.
+type ReportedTask struct {
+	TaskID string
+	Count  int64
+}
+
+// FindTopReported returns up to limit tasks with the most reports created
+// at or after since, resolved or not, most reported first.
+func (r *ReportRepository) FindTopReported(since time.Time, limit int) ([]ReportedTask, error) {
+	var results []ReportedTask
+	err := r.db.Model(&models.TaskReport{}).
+		Select("task_id, count(*) as count").
+		Where("created_at >= ?", since).
+		Group("task_id").
+		Order("count DESC, task_id ASC").
+		Limit(limit).
+		Find(&results).Error
+	return results, err
+}
@@ -1,6 +1,8 @@
 package repository_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -246,6 +248,81 @@ func TestTaskRepository_FindByID(t *testing.T) {
 	})
 }
 
+func TestTaskRepository_ShortCodes(t *testing.T) {
+	db := setupTestDB(t)
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	category := &models.Category{Label: models.MultilingualText{"en": "Test"}, Emoji: "📝", AgeGroup: models.AgeGroupKids, IsActive: true}
+	categoryRepo.Create(category)
+
+	taskRepo := repository.NewTaskRepository(db)
+	tasks := make([]models.Task, 20)
+	for i := range tasks {
+		tasks[i] = models.Task{Text: fmt.Sprintf("Task %d", i), Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	}
+	require.NoError(t, taskRepo.CreateBatch(tasks))
+
+	t.Run("created tasks get unique codes", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for _, task := range tasks {
+			assert.Regexp(t, `^T-[0-9A-HJKMNP-TV-Z]{4}$`, task.ShortCode)
+			assert.False(t, seen[task.ShortCode], "duplicate code %s", task.ShortCode)
+			seen[task.ShortCode] = true
+		}
+	})
+
+	t.Run("find by code", func(t *testing.T) {
+		found, err := taskRepo.FindByShortCode(tasks[3].ShortCode)
+		require.NoError(t, err)
+		assert.Equal(t, tasks[3].ID, found.ID)
+
+		_, err = taskRepo.FindByShortCode("T-ZZZZZZZZ")
+		assert.Error(t, err)
+	})
+
+	t.Run("existing tasks are backfilled", func(t *testing.T) {
+		db.Model(&models.Task{}).Where("id = ?", tasks[0].ID).UpdateColumn("short_code", nil)
+
+		assigned, err := models.AssignShortCodes(db)
+		require.NoError(t, err)
+		assert.Equal(t, 1, assigned)
+
+		found, err := taskRepo.FindByID(tasks[0].ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, found.ShortCode)
+	})
+
+	t.Run("rows of one insert get distinct codes", func(t *testing.T) {
+		// Enough rows per insert that random 4-character codes would
+		// most likely collide
+		batch := make([]models.Task, 5000)
+		for i := range batch {
+			batch[i] = models.Task{Text: fmt.Sprintf("Batch task %d", i), Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+		}
+		require.NoError(t, db.CreateInBatches(batch, 1000).Error)
+	})
+
+	t.Run("an insert looks its codes up in one query", func(t *testing.T) {
+		lookups := 0
+		require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count_code_lookups", func(tx *gorm.DB) {
+			if strings.Contains(tx.Statement.SQL.String(), "short_code IN") {
+				lookups++
+			}
+		}))
+		t.Cleanup(func() { db.Callback().Query().Remove("test:count_code_lookups") })
+
+		batch := make([]models.Task, 200)
+		for i := range batch {
+			batch[i] = models.Task{Text: fmt.Sprintf("Counted task %d", i), Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+		}
+		require.NoError(t, taskRepo.CreateBatch(batch))
+		// CreateBatch inserts 100 rows at a time: one lookup each, and
+		// another when a code happens to be taken
+		assert.GreaterOrEqual(t, lookups, 2)
+		assert.LessOrEqual(t, lookups, 6)
+	})
+}
+
 func TestTaskRepository_FindAll(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -332,6 +409,74 @@ func TestTaskRepository_FindRandom(t *testing.T) {
 		})
 		assert.Error(t, err)
 	})
+
+	t.Run("picks vary across calls", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			task, err := taskRepo.FindRandom(nil)
+			require.NoError(t, err)
+			seen[task.ID] = true
+		}
+		assert.Greater(t, len(seen), 1)
+	})
+
+	t.Run("excluded tasks are never picked", func(t *testing.T) {
+		all, _, err := taskRepo.FindAll(nil)
+		require.NoError(t, err)
+
+		exclude := []string{all[0].ID, all[1].ID, all[2].ID, all[3].ID}
+		for i := 0; i < 20; i++ {
+			task, err := taskRepo.FindRandom(&repository.TaskFilter{ExcludeIDs: exclude})
+			require.NoError(t, err)
+			assert.Equal(t, all[4].ID, task.ID)
+		}
+	})
+}
+
+func TestTaskRepository_FindExampleTexts(t *testing.T) {
+	db := setupTestDB(t)
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	category := &models.Category{Label: models.MultilingualText{"en": "Test"}, Emoji: "🎲", AgeGroup: models.AgeGroupKids, IsActive: true}
+	categoryRepo.Create(category)
+
+	taskRepo := repository.NewTaskRepository(db)
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 4; i++ {
+		for _, taskType := range []string{models.TaskTypeTruth, models.TaskTypeDare} {
+			task := &models.Task{
+				Text:       fmt.Sprintf("%s %d", taskType, i),
+				Language:   "en",
+				Type:       taskType,
+				CategoryID: category.ID,
+			}
+			task.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+			require.NoError(t, taskRepo.Create(task))
+		}
+	}
+	require.NoError(t, taskRepo.Create(&models.Task{Text: "other language", Language: "hi", Type: models.TaskTypeTruth, CategoryID: category.ID}))
+
+	t.Run("recent", func(t *testing.T) {
+		truths, dares, err := taskRepo.FindExampleTexts(category.ID, "en", 2, repository.ExampleStrategyRecent)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"truth 3", "truth 2"}, truths)
+		assert.Equal(t, []string{"dare 3", "dare 2"}, dares)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		truths, dares, err := taskRepo.FindExampleTexts(category.ID, "en", 3, repository.ExampleStrategyRandom)
+		require.NoError(t, err)
+		assert.Len(t, truths, 3)
+		assert.Len(t, dares, 3)
+		assert.NotContains(t, truths, "other language")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		truths, dares, err := taskRepo.FindExampleTexts(category.ID, "en", 0, repository.ExampleStrategyRandom)
+		require.NoError(t, err)
+		assert.Empty(t, truths)
+		assert.Empty(t, dares)
+	})
 }
 
 func TestTaskRepository_CountByFilters(t *testing.T) {
@@ -366,6 +511,24 @@ func TestTaskRepository_CountByFilters(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(3), truthCount)
 	assert.Equal(t, int64(2), dareCount)
+
+	t.Run("type filter is ignored", func(t *testing.T) {
+		truthCount, dareCount, err := taskRepo.CountByFilters(&repository.TaskFilter{
+			Type: models.TaskTypeTruth,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), truthCount)
+		assert.Equal(t, int64(2), dareCount)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		truthCount, dareCount, err := taskRepo.CountByFilters(&repository.TaskFilter{
+			Language: "hi",
+		})
+		require.NoError(t, err)
+		assert.Zero(t, truthCount)
+		assert.Zero(t, dareCount)
+	})
 }
 
 func TestTaskRepository_DateFilters(t *testing.T) {
@@ -416,6 +579,70 @@ func TestTaskRepository_DateFilters(t *testing.T) {
 	})
 }
 
+func TestTaskRepository_RequiresConsentFilter(t *testing.T) {
+	db := setupTestDB(t)
+	categoryRepo := repository.NewCategoryRepository(db)
+	repo := repository.NewTaskRepository(db)
+
+	general := &models.Category{Label: models.MultilingualText{"en": "General"}, AgeGroup: models.AgeGroupAdults, IsActive: true}
+	explicit := &models.Category{Label: models.MultilingualText{"en": "Explicit"}, AgeGroup: models.AgeGroupAdults, IsActive: true, RequiresConsent: true}
+	require.NoError(t, categoryRepo.Create(general))
+	require.NoError(t, categoryRepo.Create(explicit))
+
+	tasks := map[string]*models.Task{
+		"plain":       {CategoryID: general.ID, Type: models.TaskTypeTruth, Text: "plain", Language: "en"},
+		"flagged":     {CategoryID: general.ID, Type: models.TaskTypeTruth, Text: "flagged", Language: "en", RequiresConsent: true},
+		"in explicit": {CategoryID: explicit.ID, Type: models.TaskTypeTruth, Text: "in explicit", Language: "en"},
+	}
+	for _, task := range tasks {
+		require.NoError(t, repo.Create(task))
+	}
+
+	texts := func(requiresConsent bool) []string {
+		found, _, err := repo.FindAll(&repository.TaskFilter{RequiresConsent: &requiresConsent, SortBy: "created_at"})
+		require.NoError(t, err)
+		var result []string
+		for _, task := range found {
+			result = append(result, task.Text)
+		}
+		return result
+	}
+	assert.ElementsMatch(t, []string{"flagged", "in explicit"}, texts(true))
+	assert.ElementsMatch(t, []string{"plain"}, texts(false))
+
+	safe := false
+	for i := 0; i < 10; i++ {
+		task, err := repo.FindRandom(&repository.TaskFilter{RequiresConsent: &safe})
+		require.NoError(t, err)
+		assert.Equal(t, "plain", task.Text)
+	}
+}
+
+func TestTaskRepository_CreateGenerated(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewTaskRepository(db)
+
+	require.NoError(t, repo.Create(&models.Task{CategoryID: "cat", Type: models.TaskTypeTruth, Text: "Name a fruit", Language: "en"}))
+
+	newTask := func(text string) models.Task {
+		return models.Task{CategoryID: "cat", Type: models.TaskTypeDare, Text: text, Language: "en"}
+	}
+	tasks := []models.Task{
+		newTask("Hop like a frog"),
+		newTask("name a  FRUIT"),
+		newTask("Hop like a frog"),
+		newTask("Describe your morning. Then describe your evening. Then act both out."),
+		newTask(""),
+	}
+
+	created, duplicates, rejected, err := repo.CreateGenerated(tasks, models.TextRules{MaxSentences: 2})
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, "Hop like a frog", created[0].Text)
+	assert.Equal(t, 2, duplicates)
+	assert.Equal(t, 2, rejected)
+}
+
 func TestTaskRepository_Update(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -462,3 +689,202 @@ func TestTaskRepository_Delete(t *testing.T) {
 	_, err = taskRepo.FindByID(task.ID)
 	assert.Error(t, err)
 }
+
+func TestTaskRepository_SetModeration(t *testing.T) {
+	db := setupTestDB(t)
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	category := &models.Category{Label: models.MultilingualText{"en": "Test"}, Emoji: "🚩", AgeGroup: models.AgeGroupKids, IsActive: true}
+	categoryRepo.Create(category)
+
+	taskRepo := repository.NewTaskRepository(db)
+	active := &models.Task{Text: "Active", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	reported := &models.Task{Text: "Reported", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}
+	taskRepo.Create(active)
+	taskRepo.Create(reported)
+	assert.True(t, active.IsActive, "tasks are active by default")
+
+	require.NoError(t, taskRepo.SetModeration(reported.ID, false, models.ReviewStatePending))
+
+	isActive := true
+	tasks, total, err := taskRepo.FindAll(&repository.TaskFilter{IsActive: &isActive})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, active.ID, tasks[0].ID)
+
+	tasks, _, err = taskRepo.FindAll(&repository.TaskFilter{ReviewState: models.ReviewStatePending})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, reported.ID, tasks[0].ID)
+	assert.False(t, tasks[0].IsActive)
+
+	count, err := taskRepo.Count(&repository.TaskFilter{IsActive: &isActive})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestTaskRepository_RolloutRoll(t *testing.T) {
+	db := setupTestDB(t)
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	category := &models.Category{Label: models.MultilingualText{"en": "Test"}, Emoji: "🧪", AgeGroup: models.AgeGroupKids, IsActive: true}
+	categoryRepo.Create(category)
+
+	taskRepo := repository.NewTaskRepository(db)
+	full := &models.Task{Text: "Full", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	staged := &models.Task{Text: "Staged", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID, RolloutPercent: 25}
+	taskRepo.Create(full)
+	taskRepo.Create(staged)
+	assert.Equal(t, models.FullRollout, full.RolloutPercent, "tasks default to full rollout")
+
+	for _, tc := range []struct {
+		roll     int
+		expected int64
+	}{
+		{roll: 0, expected: 2},
+		{roll: 24, expected: 2},
+		{roll: 25, expected: 1},
+		{roll: 99, expected: 1},
+	} {
+		roll := tc.roll
+		_, total, err := taskRepo.FindAll(&repository.TaskFilter{RolloutRoll: &roll})
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, total, "roll %d", roll)
+	}
+}
+
+func TestServeRecorder(t *testing.T) {
+	db := setupTestDB(t)
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	category := &models.Category{Label: models.MultilingualText{"en": "Test"}, Emoji: "🎲", AgeGroup: models.AgeGroupKids, IsActive: true}
+	categoryRepo.Create(category)
+
+	taskRepo := repository.NewTaskRepository(db)
+	popular := &models.Task{Text: "Popular", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	unseen := &models.Task{Text: "Unseen", Language: "en", Type: models.TaskTypeTruth, CategoryID: category.ID}
+	taskRepo.Create(popular)
+	taskRepo.Create(unseen)
+
+	recorder := repository.NewServeRecorder(db)
+	recorder.Record(popular.ID)
+	recorder.Record(popular.ID, popular.ID)
+	assert.Equal(t, 1, recorder.Pending())
+
+	// Nothing is written until the recorder flushes
+	found, err := taskRepo.FindByID(popular.ID)
+	require.NoError(t, err)
+	assert.Zero(t, found.TimesServed)
+
+	require.NoError(t, recorder.Stop())
+	assert.Zero(t, recorder.Pending())
+
+	found, err = taskRepo.FindByID(popular.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, found.TimesServed)
+	require.NotNil(t, found.LastServedAt)
+
+	t.Run("never served filter", func(t *testing.T) {
+		tasks, _, err := taskRepo.FindAll(&repository.TaskFilter{NeverServed: true})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, unseen.ID, tasks[0].ID)
+	})
+
+	t.Run("served before filter includes never served", func(t *testing.T) {
+		before := found.LastServedAt.Add(-time.Minute)
+		_, total, err := taskRepo.FindAll(&repository.TaskFilter{ServedBefore: &before})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+
+		after := found.LastServedAt.Add(time.Minute)
+		_, total, err = taskRepo.FindAll(&repository.TaskFilter{ServedBefore: &after})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+	})
+
+	t.Run("max times served filter", func(t *testing.T) {
+		max := 2
+		tasks, _, err := taskRepo.FindAll(&repository.TaskFilter{MaxTimesServed: &max})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, unseen.ID, tasks[0].ID)
+	})
+
+	t.Run("nil recorder is a no-op", func(t *testing.T) {
+		var nilRecorder *repository.ServeRecorder
+		nilRecorder.Record(popular.ID)
+		assert.NoError(t, nilRecorder.Flush())
+	})
+
+	t.Run("held recorder keeps serves buffered", func(t *testing.T) {
+		held := true
+		recorder := repository.NewServeRecorder(db)
+		recorder.SetHold(func() bool { return held })
+		recorder.Record(unseen.ID)
+
+		require.NoError(t, recorder.Flush())
+		assert.Equal(t, 1, recorder.Pending())
+
+		held = false
+		require.NoError(t, recorder.Flush())
+		assert.Zero(t, recorder.Pending())
+
+		found, err := taskRepo.FindByID(unseen.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.TimesServed)
+	})
+}
+
+func TestTaskRepository_SanitizesText(t *testing.T) {
+	db := setupTestDB(t)
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	category := &models.Category{
+		Label:    models.MultilingualText{"en": " Party\u200B  Time ", "es": "FiestÃ¡"},
+		AgeGroup: models.AgeGroupKids,
+		IsActive: true,
+	}
+	require.NoError(t, categoryRepo.Create(category))
+
+	taskRepo := repository.NewTaskRepository(db)
+	task := &models.Task{
+		Text:       "\uFEFFWhat\tis  your\x00 secret?\n",
+		Language:   "es",
+		Type:       models.TaskTypeTruth,
+		CategoryID: category.ID,
+		Hint:       models.MultilingualText{"es": "Di la verdad\u200B "},
+	}
+	require.NoError(t, taskRepo.Create(task))
+
+	storedCategory, err := categoryRepo.FindByID(category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MultilingualText{"en": "Party Time", "es": "Fiestá"}, storedCategory.Label)
+
+	stored, err := taskRepo.FindByID(task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is your secret?", stored.Text)
+	assert.Equal(t, "Di la verdad", stored.Hint["es"])
+}
+
+func TestChatRepository_RecordDraw(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChatWorkspace{}, &models.ChatDraw{}))
+	repo := repository.NewChatRepository(db)
+
+	for i := 0; i < 5; i++ {
+		draw := &models.ChatDraw{Platform: models.ChatPlatformSlack, WorkspaceID: "T1", ChannelID: "C1", TaskID: fmt.Sprintf("task-%d", i)}
+		require.NoError(t, repo.RecordDraw(draw, 3))
+		time.Sleep(time.Millisecond)
+	}
+	other := &models.ChatDraw{Platform: models.ChatPlatformSlack, WorkspaceID: "T1", ChannelID: "C2", TaskID: "task-0"}
+	require.NoError(t, repo.RecordDraw(other, 3))
+
+	recent, err := repo.RecentDraws(models.ChatPlatformSlack, "T1", "C1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-4", "task-3", "task-2"}, recent, "only the last draws are kept, newest first")
+
+	recent, err = repo.RecentDraws(models.ChatPlatformSlack, "T1", "C2", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-0"}, recent, "channels keep their own history")
+}
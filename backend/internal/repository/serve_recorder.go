@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ServeRecorder counts how often tasks are served without writing on the
+// request path. Serves are buffered in memory and applied to times_served
+// and last_served_at in one transaction per flush. Serves still buffered
+// when the process dies are lost, which is acceptable for usage statistics.
+//
+// A nil *ServeRecorder is valid and records nothing.
+type ServeRecorder struct {
+	db   *gorm.DB
+	now  func() time.Time
+	hold func() bool
+
+	mu      sync.Mutex
+	pending map[string]*servedEntry
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// defaultServeFlushInterval is used when Start is given no interval
+const defaultServeFlushInterval = 10 * time.Second
+
+type servedEntry struct {
+	count int
+	last  time.Time
+}
+
+// NewServeRecorder creates a ServeRecorder writing to db.
+func NewServeRecorder(db *gorm.DB) *ServeRecorder {
+	return &ServeRecorder{
+		db:      db,
+		now:     time.Now,
+		pending: make(map[string]*servedEntry),
+	}
+}
+
+// Record buffers one serve of each task.
+func (r *ServeRecorder) Record(ids ...string) {
+	if r == nil || len(ids) == 0 {
+		return
+	}
+
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		entry, ok := r.pending[id]
+		if !ok {
+			entry = &servedEntry{}
+			r.pending[id] = entry
+		}
+		entry.count++
+		entry.last = now
+	}
+}
+
+// Pending returns the number of tasks with buffered serves.
+func (r *ServeRecorder) Pending() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
+
+// Flush writes all buffered serves. On failure the serves are put back so
+// the next flush retries them. While held, nothing is written.
+func (r *ServeRecorder) Flush() error {
+	if r == nil || (r.hold != nil && r.hold()) {
+		return nil
+	}
+
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = make(map[string]*servedEntry)
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for id, entry := range batch {
+			err := tx.Model(&models.Task{}).
+				Where("id = ?", id).
+				UpdateColumns(map[string]interface{}{
+					"times_served":   gorm.Expr("times_served + ?", entry.count),
+					"last_served_at": entry.last,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.requeue(batch)
+	}
+	return err
+}
+
+// requeue merges a failed batch back into the pending serves
+func (r *ServeRecorder) requeue(batch map[string]*servedEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, failed := range batch {
+		entry, ok := r.pending[id]
+		if !ok {
+			r.pending[id] = failed
+			continue
+		}
+		entry.count += failed.count
+		if failed.last.After(entry.last) {
+			entry.last = failed.last
+		}
+	}
+}
+
+// SetHold makes flushes keep serves buffered while hold returns true, for
+// example while the instance is in read-only mode.
+func (r *ServeRecorder) SetHold(hold func() bool) {
+	if r == nil {
+		return
+	}
+	r.hold = hold
+}
+
+// Start flushes buffered serves every interval until Stop is called.
+func (r *ServeRecorder) Start(interval time.Duration) {
+	if r == nil || r.stop != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultServeFlushInterval
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.Flush(); err != nil {
+					log.Error().Err(err).Msg("Failed to flush task serve counts")
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the flush loop and writes any remaining serves.
+func (r *ServeRecorder) Stop() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop = nil
+	}
+	return r.Flush()
+}
@@ -0,0 +1,752 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// SnapshotFormatVersion is the version of the snapshot archive layout.
+// Bump it when fields are renamed or removed; new fields are fine.
+const SnapshotFormatVersion = 1
+
+// Snapshot is the content of an instance: categories, tasks and runtime
+// feature flag overrides. IDs are those of the source instance and are
+// remapped on import.
+type Snapshot struct {
+	FormatVersion int                  `json:"format_version"`
+	SchemaVersion int                  `json:"schema_version"`
+	AppVersion    string               `json:"app_version"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Categories    []SnapshotCategory   `json:"categories"`
+	Tasks         []SnapshotTask       `json:"tasks"`
+	FeatureFlags  []models.FeatureFlag `json:"feature_flags"`
+}
+
+// SnapshotCategory is a category in a snapshot.
+type SnapshotCategory struct {
+	ID              string                  `json:"id"`
+	Emoji           string                  `json:"emoji"`
+	ImageURL        string                  `json:"image_url,omitempty"`
+	AgeGroup        string                  `json:"age_group"`
+	Label           models.MultilingualText `json:"label"`
+	Instructions    models.MultilingualText `json:"instructions,omitempty"`
+	RequiresConsent bool                    `json:"requires_consent"`
+	IsActive        bool                    `json:"is_active"`
+	SortOrder       int                     `json:"sort_order"`
+	License         string                  `json:"license,omitempty"`
+	Attribution     string                  `json:"attribution,omitempty"`
+}
+
+// SnapshotTask is a task in a snapshot. Serve counts, reports and review
+// assignments are specific to an instance and are left out.
+type SnapshotTask struct {
+	ID              string                  `json:"id"`
+	CategoryID      string                  `json:"category_id"`
+	Type            string                  `json:"type"`
+	Text            string                  `json:"text"`
+	Language        string                  `json:"language"`
+	Hint            models.MultilingualText `json:"hint,omitempty"`
+	IsActive        bool                    `json:"is_active"`
+	RequiresConsent bool                    `json:"requires_consent,omitempty"`
+	ReviewState     string                  `json:"review_state,omitempty"`
+	RolloutPercent  int                     `json:"rollout_percent"`
+	License         string                  `json:"license,omitempty"`
+	Attribution     string                  `json:"attribution,omitempty"`
+}
+
+// SnapshotCounts reports the rows an import creates and updates.
+type SnapshotCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// SnapshotImportResult reports an import, mapping source IDs to the IDs
+// on this instance.
+type SnapshotImportResult struct {
+	Categories  SnapshotCounts    `json:"categories"`
+	Tasks       SnapshotCounts    `json:"tasks"`
+	CategoryIDs map[string]string `json:"category_ids"`
+	TaskIDs     map[string]string `json:"task_ids"`
+}
+
+// ErrSnapshotInvalid is returned when a snapshot refers to a category it
+// does not contain or repeats an ID.
+var ErrSnapshotInvalid = errors.New("invalid snapshot")
+
+// errSnapshotDryRun rolls back the transaction of a dry run
+var errSnapshotDryRun = errors.New("snapshot dry run")
+
+// SnapshotRepository exports and imports instance content.
+type SnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewSnapshotRepository creates a new SnapshotRepository.
+func NewSnapshotRepository(db *gorm.DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// Export reads every category, task and feature flag override. Soft-deleted
+// rows are left out. The version fields are left to the caller.
+func (r *SnapshotRepository) Export() (*Snapshot, error) {
+	snapshot := &Snapshot{
+		FormatVersion: SnapshotFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Categories:    []SnapshotCategory{},
+		Tasks:         []SnapshotTask{},
+		FeatureFlags:  []models.FeatureFlag{},
+	}
+
+	var categories []models.Category
+	if err := r.db.Order("sort_order ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		snapshot.Categories = append(snapshot.Categories, toSnapshotCategory(category))
+	}
+
+	var tasks []models.Task
+	if err := r.db.Order("category_id ASC, id ASC").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		snapshot.Tasks = append(snapshot.Tasks, toSnapshotTask(task))
+	}
+
+	if err := r.db.Order("name ASC").Find(&snapshot.FeatureFlags).Error; err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// ImportBatch merges one batch of a streamed import in one transaction,
+// like Import. Tasks may refer to categories of earlier batches through
+// categoryIDs, which maps file IDs to IDs on this instance; the result
+// holds the mappings of this batch only. Task IDs are checked for repeats
+// within the batch.
+func (r *SnapshotRepository) ImportBatch(categories []SnapshotCategory, tasks []SnapshotTask, categoryIDs map[string]string) (*SnapshotImportResult, error) {
+	result := &SnapshotImportResult{
+		CategoryIDs: make(map[string]string, len(categories)),
+		TaskIDs:     make(map[string]string, len(tasks)),
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := importCategories(tx, categories, result); err != nil {
+			return err
+		}
+		// Tasks see the categories of earlier batches and of this one
+		known := &SnapshotImportResult{CategoryIDs: make(map[string]string, len(categoryIDs)+len(result.CategoryIDs)), TaskIDs: result.TaskIDs}
+		for source, id := range categoryIDs {
+			known.CategoryIDs[source] = id
+		}
+		for source, id := range result.CategoryIDs {
+			known.CategoryIDs[source] = id
+		}
+		if err := importTasks(tx, tasks, known); err != nil {
+			return err
+		}
+		result.Tasks = known.Tasks
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EachCategory walks every category in batches of up to batchSize,
+// ordered by ID. Batches are read by keyset, so memory stays flat however
+// many rows there are. fn errors stop the walk and are returned.
+func (r *SnapshotRepository) EachCategory(batchSize int, fn func([]SnapshotCategory) error) error {
+	return eachBatch(r.db, batchSize, func(categories []models.Category) error {
+		batch := make([]SnapshotCategory, len(categories))
+		for i, category := range categories {
+			batch[i] = toSnapshotCategory(category)
+		}
+		return fn(batch)
+	}, func(c models.Category) string { return c.ID })
+}
+
+// EachTask walks every task like EachCategory.
+func (r *SnapshotRepository) EachTask(batchSize int, fn func([]SnapshotTask) error) error {
+	return eachBatch(r.db, batchSize, func(tasks []models.Task) error {
+		batch := make([]SnapshotTask, len(tasks))
+		for i, task := range tasks {
+			batch[i] = toSnapshotTask(task)
+		}
+		return fn(batch)
+	}, func(t models.Task) string { return t.ID })
+}
+
+// FeatureFlags reads the runtime feature flag overrides.
+func (r *SnapshotRepository) FeatureFlags() ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	err := r.db.Order("name ASC").Find(&flags).Error
+	return flags, err
+}
+
+// eachBatch reads the rows of T in ID order, batchSize at a time, seeking
+// past the last ID of the previous batch rather than using offsets
+func eachBatch[T any](db *gorm.DB, batchSize int, fn func([]T) error, id func(T) string) error {
+	last := ""
+	for {
+		var rows []T
+		if err := db.Where("id > ?", last).Order("id ASC").Limit(batchSize).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := fn(rows); err != nil {
+			return err
+		}
+		if len(rows) < batchSize {
+			return nil
+		}
+		last = id(rows[len(rows)-1])
+	}
+}
+
+// toSnapshotCategory converts a category to its snapshot form
+func toSnapshotCategory(category models.Category) SnapshotCategory {
+	return SnapshotCategory{
+		ID:              category.ID,
+		Emoji:           category.Emoji,
+		ImageURL:        category.ImageURL,
+		AgeGroup:        category.AgeGroup,
+		Label:           category.Label,
+		Instructions:    category.Instructions,
+		RequiresConsent: category.RequiresConsent,
+		IsActive:        category.IsActive,
+		SortOrder:       category.SortOrder,
+		License:         category.License,
+		Attribution:     category.Attribution,
+	}
+}
+
+// toSnapshotTask converts a task to its snapshot form
+func toSnapshotTask(task models.Task) SnapshotTask {
+	return SnapshotTask{
+		ID:              task.ID,
+		CategoryID:      task.CategoryID,
+		Type:            task.Type,
+		Text:            task.Text,
+		Language:        task.Language,
+		Hint:            task.Hint,
+		IsActive:        task.IsActive,
+		RequiresConsent: task.RequiresConsent,
+		ReviewState:     task.ReviewState,
+		RolloutPercent:  task.RolloutPercent,
+		License:         task.License,
+		Attribution:     task.Attribution,
+	}
+}
+
+// Import merges the categories and tasks of a snapshot in one transaction.
+// Categories are matched by English label and tasks by category, language,
+// type and text; matches are updated and the rest created with new IDs.
+// Rows missing from the snapshot are kept. With dryRun set the changes are
+// rolled back and only reported. Feature flags are left to the caller.
+//
+// It returns ErrSnapshotInvalid (wrapped) for inconsistent snapshots and a
+// *LabelConflictError when an imported label clashes with another category.
+func (r *SnapshotRepository) Import(snapshot *Snapshot, dryRun bool) (*SnapshotImportResult, error) {
+	result := &SnapshotImportResult{
+		CategoryIDs: make(map[string]string, len(snapshot.Categories)),
+		TaskIDs:     make(map[string]string, len(snapshot.Tasks)),
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := importCategories(tx, snapshot.Categories, result); err != nil {
+			return err
+		}
+		if err := importTasks(tx, snapshot.Tasks, result); err != nil {
+			return err
+		}
+		if dryRun {
+			return errSnapshotDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSnapshotDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// categoriesByLabel indexes categories by their English label, the key
+// snapshot categories are matched on
+func categoriesByLabel(categories []models.Category) map[string]models.Category {
+	byLabel := make(map[string]models.Category, len(categories))
+	for _, category := range categories {
+		if label, ok := category.Label["en"]; ok {
+			byLabel[labelKey(label)] = category
+		}
+	}
+	return byLabel
+}
+
+// matchCategory finds the category a snapshot category maps to
+func matchCategory(byLabel map[string]models.Category, source SnapshotCategory) (models.Category, bool) {
+	label, ok := source.Label["en"]
+	if !ok {
+		return models.Category{}, false
+	}
+	category, ok := byLabel[labelKey(label)]
+	return category, ok
+}
+
+// checkSnapshotID rejects empty and repeated IDs in a snapshot
+func checkSnapshotID(kind, id string, seen map[string]string) error {
+	if id == "" {
+		return fmt.Errorf("%w: %s without id", ErrSnapshotInvalid, kind)
+	}
+	if _, ok := seen[id]; ok {
+		return fmt.Errorf("%w: %s %s appears twice", ErrSnapshotInvalid, kind, id)
+	}
+	return nil
+}
+
+// importCategories creates or updates the snapshot categories, recording
+// their IDs in result
+func importCategories(tx *gorm.DB, categories []SnapshotCategory, result *SnapshotImportResult) error {
+	var existing []models.Category
+	if err := tx.Find(&existing).Error; err != nil {
+		return err
+	}
+	byLabel := categoriesByLabel(existing)
+
+	for _, source := range categories {
+		if err := checkSnapshotID("category", source.ID, result.CategoryIDs); err != nil {
+			return err
+		}
+
+		category, matched := matchCategory(byLabel, source)
+		category.Emoji = source.Emoji
+		category.ImageURL = source.ImageURL
+		category.AgeGroup = source.AgeGroup
+		category.Label = source.Label
+		category.Instructions = source.Instructions
+		category.RequiresConsent = source.RequiresConsent
+		category.IsActive = source.IsActive
+		category.SortOrder = source.SortOrder
+		category.License = source.License
+		category.Attribution = source.Attribution
+
+		if err := checkLabelConflicts(tx, &category); err != nil {
+			return err
+		}
+
+		if matched {
+			if err := tx.Save(&category).Error; err != nil {
+				return err
+			}
+			result.Categories.Updated++
+		} else {
+			// Create skips zero values in favour of column defaults, so an
+			// inactive category must be written explicitly
+			if err := tx.Create(&category).Error; err != nil {
+				return err
+			}
+			if !source.IsActive {
+				if err := tx.Model(&category).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+			result.Categories.Created++
+		}
+		result.CategoryIDs[source.ID] = category.ID
+	}
+	return nil
+}
+
+// taskKey identifies a task across instances
+type taskKey struct {
+	categoryID, language, taskType, text string
+}
+
+// keyOfTask returns the key of a stored task
+func keyOfTask(task models.Task) taskKey {
+	return taskKey{task.CategoryID, task.Language, task.Type, task.Text}
+}
+
+// snapshotTaskCategory maps the category of a snapshot task to its ID on
+// this instance
+func snapshotTaskCategory(source SnapshotTask, categoryIDs map[string]string) (string, error) {
+	categoryID, ok := categoryIDs[source.CategoryID]
+	if !ok {
+		return "", fmt.Errorf("%w: task %s refers to unknown category %s", ErrSnapshotInvalid, source.ID, source.CategoryID)
+	}
+	return categoryID, nil
+}
+
+// importTextChunk is the number of texts matched per query on import
+const importTextChunk = 500
+
+// importTasks creates or updates the snapshot tasks, recording their IDs
+// in result. Categories must be imported first.
+func importTasks(tx *gorm.DB, tasks []SnapshotTask, result *SnapshotImportResult) error {
+	categoryIDs := make([]string, 0, len(result.CategoryIDs))
+	for _, id := range result.CategoryIDs {
+		categoryIDs = append(categoryIDs, id)
+	}
+
+	// Load only the stored tasks sharing a text with the imported ones, in
+	// chunks to stay within the bound variable limit
+	existing := make(map[taskKey]models.Task)
+	if len(categoryIDs) > 0 {
+		texts := make([]string, 0, len(tasks))
+		seen := make(map[string]bool, len(tasks))
+		for _, task := range tasks {
+			if !seen[task.Text] {
+				seen[task.Text] = true
+				texts = append(texts, task.Text)
+			}
+		}
+		for start := 0; start < len(texts); start += importTextChunk {
+			end := min(start+importTextChunk, len(texts))
+			var rows []models.Task
+			if err := tx.Where("category_id IN ? AND text IN ?", categoryIDs, texts[start:end]).Find(&rows).Error; err != nil {
+				return err
+			}
+			for _, task := range rows {
+				existing[keyOfTask(task)] = task
+			}
+		}
+	}
+
+	for _, source := range tasks {
+		if err := checkSnapshotID("task", source.ID, result.TaskIDs); err != nil {
+			return err
+		}
+		categoryID, err := snapshotTaskCategory(source, result.CategoryIDs)
+		if err != nil {
+			return err
+		}
+
+		key := taskKey{categoryID, source.Language, source.Type, source.Text}
+		task, matched := existing[key]
+		if !matched {
+			task = models.Task{CategoryID: categoryID, Type: source.Type, Text: source.Text, Language: source.Language}
+		}
+		task.Hint = source.Hint
+		task.IsActive = source.IsActive
+		task.RequiresConsent = source.RequiresConsent
+		task.ReviewState = source.ReviewState
+		task.RolloutPercent = source.RolloutPercent
+		task.License = source.License
+		task.Attribution = source.Attribution
+
+		if matched {
+			if err := tx.Save(&task).Error; err != nil {
+				return err
+			}
+			result.Tasks.Updated++
+		} else {
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+			// As with categories, zero values fall back to column defaults on create
+			if !source.IsActive || source.RolloutPercent == 0 {
+				err := tx.Model(&task).Updates(map[string]interface{}{
+					"is_active":       source.IsActive,
+					"rollout_percent": source.RolloutPercent,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			existing[key] = task
+			result.Tasks.Created++
+		}
+		result.TaskIDs[source.ID] = task.ID
+	}
+	return nil
+}
+
+// SnapshotFieldChange is a field an import would overwrite.
+type SnapshotFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// SnapshotCategoryChange is a category an import would create, update or
+// leave without a counterpart. SourceID is the ID in the snapshot and ID
+// the ID on this instance; either is empty when there is no such row.
+type SnapshotCategoryChange struct {
+	SourceID string                  `json:"source_id,omitempty"`
+	ID       string                  `json:"id,omitempty"`
+	Label    models.MultilingualText `json:"label"`
+	Changes  []SnapshotFieldChange   `json:"changes,omitempty"`
+}
+
+// SnapshotTaskChange is a task an import would create, update or leave
+// without a counterpart. IDs follow SnapshotCategoryChange; CategoryID is
+// empty for tasks of categories the import would create.
+type SnapshotTaskChange struct {
+	SourceID         string                `json:"source_id,omitempty"`
+	ID               string                `json:"id,omitempty"`
+	SourceCategoryID string                `json:"source_category_id,omitempty"`
+	CategoryID       string                `json:"category_id,omitempty"`
+	Type             string                `json:"type"`
+	Language         string                `json:"language"`
+	Text             string                `json:"text"`
+	Changes          []SnapshotFieldChange `json:"changes,omitempty"`
+}
+
+// SnapshotCategoryDiff groups the category differences of a snapshot.
+type SnapshotCategoryDiff struct {
+	Added     []SnapshotCategoryChange `json:"added"`
+	Removed   []SnapshotCategoryChange `json:"removed"`
+	Changed   []SnapshotCategoryChange `json:"changed"`
+	Unchanged int                      `json:"unchanged"`
+}
+
+// SnapshotTaskDiff groups the task differences of a snapshot.
+type SnapshotTaskDiff struct {
+	Added     []SnapshotTaskChange `json:"added"`
+	Removed   []SnapshotTaskChange `json:"removed"`
+	Changed   []SnapshotTaskChange `json:"changed"`
+	Unchanged int                  `json:"unchanged"`
+}
+
+// SnapshotDiff compares a snapshot with this instance. Added rows would be
+// created by an import and changed rows updated. Removed rows exist only
+// on this instance; an import keeps them.
+type SnapshotDiff struct {
+	Categories SnapshotCategoryDiff `json:"categories"`
+	Tasks      SnapshotTaskDiff     `json:"tasks"`
+}
+
+// Diff compares a snapshot with the stored categories and tasks, matching
+// rows as Import does. Nothing is written.
+//
+// It returns ErrSnapshotInvalid (wrapped) for inconsistent snapshots.
+func (r *SnapshotRepository) Diff(snapshot *Snapshot) (*SnapshotDiff, error) {
+	diff := &SnapshotDiff{
+		Categories: SnapshotCategoryDiff{
+			Added:   []SnapshotCategoryChange{},
+			Removed: []SnapshotCategoryChange{},
+			Changed: []SnapshotCategoryChange{},
+		},
+		Tasks: SnapshotTaskDiff{
+			Added:   []SnapshotTaskChange{},
+			Removed: []SnapshotTaskChange{},
+			Changed: []SnapshotTaskChange{},
+		},
+	}
+
+	var categories []models.Category
+	if err := r.db.Order("sort_order ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byLabel := categoriesByLabel(categories)
+
+	// categoryIDs maps snapshot category IDs to IDs on this instance, empty
+	// for categories an import would create
+	categoryIDs := make(map[string]string, len(snapshot.Categories))
+	matchedCategories := make(map[string]bool, len(categories))
+	for _, source := range snapshot.Categories {
+		if err := checkSnapshotID("category", source.ID, categoryIDs); err != nil {
+			return nil, err
+		}
+
+		category, matched := matchCategory(byLabel, source)
+		if !matched {
+			categoryIDs[source.ID] = ""
+			diff.Categories.Added = append(diff.Categories.Added, SnapshotCategoryChange{SourceID: source.ID, Label: source.Label})
+			continue
+		}
+		categoryIDs[source.ID] = category.ID
+		matchedCategories[category.ID] = true
+
+		changes := categoryChanges(category, source)
+		if len(changes) == 0 {
+			diff.Categories.Unchanged++
+			continue
+		}
+		diff.Categories.Changed = append(diff.Categories.Changed, SnapshotCategoryChange{
+			SourceID: source.ID,
+			ID:       category.ID,
+			Label:    category.Label,
+			Changes:  changes,
+		})
+	}
+	for _, category := range categories {
+		if !matchedCategories[category.ID] {
+			diff.Categories.Removed = append(diff.Categories.Removed, SnapshotCategoryChange{ID: category.ID, Label: category.Label})
+		}
+	}
+
+	var tasks []models.Task
+	if err := r.db.Order("category_id ASC, id ASC").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	existing := make(map[taskKey]models.Task, len(tasks))
+	for _, task := range tasks {
+		existing[keyOfTask(task)] = task
+	}
+
+	seen := make(map[string]string, len(snapshot.Tasks))
+	matchedTasks := make(map[string]bool, len(tasks))
+	for _, source := range snapshot.Tasks {
+		if err := checkSnapshotID("task", source.ID, seen); err != nil {
+			return nil, err
+		}
+		categoryID, err := snapshotTaskCategory(source, categoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		seen[source.ID] = categoryID
+
+		change := SnapshotTaskChange{
+			SourceID:         source.ID,
+			SourceCategoryID: source.CategoryID,
+			CategoryID:       categoryID,
+			Type:             source.Type,
+			Language:         source.Language,
+			Text:             source.Text,
+		}
+
+		task, matched := existing[taskKey{categoryID, source.Language, source.Type, source.Text}]
+		if categoryID == "" || !matched {
+			diff.Tasks.Added = append(diff.Tasks.Added, change)
+			continue
+		}
+		matchedTasks[task.ID] = true
+
+		change.ID = task.ID
+		change.Changes = taskChanges(task, source)
+		if len(change.Changes) == 0 {
+			diff.Tasks.Unchanged++
+			continue
+		}
+		diff.Tasks.Changed = append(diff.Tasks.Changed, change)
+	}
+	for _, task := range tasks {
+		if !matchedTasks[task.ID] {
+			diff.Tasks.Removed = append(diff.Tasks.Removed, SnapshotTaskChange{
+				ID:         task.ID,
+				CategoryID: task.CategoryID,
+				Type:       task.Type,
+				Language:   task.Language,
+				Text:       task.Text,
+			})
+		}
+	}
+
+	return diff, nil
+}
+
+// categoryChanges lists the fields an import of source would overwrite
+func categoryChanges(category models.Category, source SnapshotCategory) []SnapshotFieldChange {
+	var changes []SnapshotFieldChange
+	if !sameText(category.Label, source.Label) {
+		changes = append(changes, SnapshotFieldChange{Field: "label", From: category.Label, To: source.Label})
+	}
+	if !sameText(category.Instructions, source.Instructions) {
+		changes = append(changes, SnapshotFieldChange{Field: "instructions", From: category.Instructions, To: source.Instructions})
+	}
+	if category.Emoji != source.Emoji {
+		changes = append(changes, SnapshotFieldChange{Field: "emoji", From: category.Emoji, To: source.Emoji})
+	}
+	if category.ImageURL != source.ImageURL {
+		changes = append(changes, SnapshotFieldChange{Field: "image_url", From: category.ImageURL, To: source.ImageURL})
+	}
+	if category.AgeGroup != source.AgeGroup {
+		changes = append(changes, SnapshotFieldChange{Field: "age_group", From: category.AgeGroup, To: source.AgeGroup})
+	}
+	if category.RequiresConsent != source.RequiresConsent {
+		changes = append(changes, SnapshotFieldChange{Field: "requires_consent", From: category.RequiresConsent, To: source.RequiresConsent})
+	}
+	if category.IsActive != source.IsActive {
+		changes = append(changes, SnapshotFieldChange{Field: "is_active", From: category.IsActive, To: source.IsActive})
+	}
+	if category.SortOrder != source.SortOrder {
+		changes = append(changes, SnapshotFieldChange{Field: "sort_order", From: category.SortOrder, To: source.SortOrder})
+	}
+	changes = append(changes, licenseChanges(category.License, category.Attribution, source.License, source.Attribution)...)
+	return changes
+}
+
+// taskChanges lists the fields an import of source would overwrite. The
+// matched fields are equal by construction.
+func taskChanges(task models.Task, source SnapshotTask) []SnapshotFieldChange {
+	var changes []SnapshotFieldChange
+	if !sameText(task.Hint, source.Hint) {
+		changes = append(changes, SnapshotFieldChange{Field: "hint", From: task.Hint, To: source.Hint})
+	}
+	if task.IsActive != source.IsActive {
+		changes = append(changes, SnapshotFieldChange{Field: "is_active", From: task.IsActive, To: source.IsActive})
+	}
+	if task.RequiresConsent != source.RequiresConsent {
+		changes = append(changes, SnapshotFieldChange{Field: "requires_consent", From: task.RequiresConsent, To: source.RequiresConsent})
+	}
+	if task.ReviewState != source.ReviewState {
+		changes = append(changes, SnapshotFieldChange{Field: "review_state", From: task.ReviewState, To: source.ReviewState})
+	}
+	if task.RolloutPercent != source.RolloutPercent {
+		changes = append(changes, SnapshotFieldChange{Field: "rollout_percent", From: task.RolloutPercent, To: source.RolloutPercent})
+	}
+	changes = append(changes, licenseChanges(task.License, task.Attribution, source.License, source.Attribution)...)
+	return changes
+}
+
+// licenseChanges lists the licensing fields an import would overwrite
+func licenseChanges(license, attribution, sourceLicense, sourceAttribution string) []SnapshotFieldChange {
+	var changes []SnapshotFieldChange
+	if license != sourceLicense {
+		changes = append(changes, SnapshotFieldChange{Field: "license", From: license, To: sourceLicense})
+	}
+	if attribution != sourceAttribution {
+		changes = append(changes, SnapshotFieldChange{Field: "attribution", From: attribution, To: sourceAttribution})
+	}
+	return changes
+}
+
+// sameText reports whether two texts hold the same translations
+func sameText(a, b models.MultilingualText) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for lang, text := range a {
+		if other, ok := b[lang]; !ok || other != text {
+			return false
+		}
+	}
+	return true
+}
+
+// Revision identifies the current content. It changes whenever a category
+// or task is created, updated or deleted, so clients can tell whether the
+// content they cached is stale.
+func (r *SnapshotRepository) Revision() (string, error) {
+	h := fnv.New64a()
+	for _, model := range []interface{}{&models.Category{}, &models.Task{}} {
+		// Hard deletes lower the row count; soft deletes set deleted_at
+		var rows int64
+		if err := r.db.Unscoped().Model(model).Count(&rows).Error; err != nil {
+			return "", err
+		}
+		var updated, deleted models.BaseModel
+		err := r.db.Unscoped().Model(model).Select("updated_at").
+			Order("updated_at DESC").Limit(1).Scan(&updated).Error
+		if err != nil {
+			return "", err
+		}
+		err = r.db.Unscoped().Model(model).Select("deleted_at").
+			Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Limit(1).Scan(&deleted).Error
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%d %d %d;", rows, updated.UpdatedAt.UnixNano(), deleted.DeletedAt.Time.UnixNano())
+	}
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
@@ -1,6 +1,11 @@
 package repository
 
 import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/truthordare/backend/internal/models"
@@ -20,20 +25,53 @@ func NewTaskRepository(db *gorm.DB) *TaskRepository {
 // TaskFilter contains filter options for querying tasks.
 // Supports multiple values for categories, types, and languages.
 type TaskFilter struct {
-	CategoryID  string     // Filter by single category ID
-	CategoryIDs []string   // Filter by multiple category IDs
-	Type        string     // Filter by type (truth/dare)
-	Types       []string   // Filter by multiple types
-	Language    string     // Filter by single language code
-	Languages   []string   // Filter by multiple language codes
-	ExcludeIDs  []string   // Exclude specific task IDs (for rotation)
-	FromDate    *time.Time // Filter tasks created after this date
-	ToDate      *time.Time // Filter tasks created before this date
-	SortBy      string     // Sort field (created_at, updated_at, etc.)
-	SortOrder   string     // Sort order (asc, desc)
-	Limit       int        // Limit results
-	Offset      int        // Offset for pagination
-	Random      bool       // Randomize results
+	CategoryID         string     // Filter by single category ID
+	CategoryIDs        []string   // Filter by multiple category IDs
+	AgeGroups          []string   // Filter by the age groups of the categories
+	MinAge             *int       // Only categories whose age group admits players this old or older
+	MaxAge             *int       // Only categories whose age group admits players this young or younger
+	Type               string     // Filter by type (truth/dare)
+	Types              []string   // Filter by multiple types
+	Language           string     // Filter by single language code
+	Languages          []string   // Filter by multiple language codes
+	ExcludeIDs         []string   // Exclude specific task IDs (for rotation)
+	ExcludeCategoryIDs []string   // Exclude the tasks of these categories
+	IsActive           *bool      // Filter by active status
+	RequiresConsent    *bool      // Filter by consent requirement of the task or its category
+	ReviewState        string     // Filter by review state (pending, approved)
+	RolloutRoll        *int       // Staged rollout: only tasks whose rollout percentage exceeds this roll (0-99)
+	AssignedTo         string     // Filter by assigned reviewer
+	Unassigned         bool       // Only tasks no reviewer has claimed
+	MachineLanguage    string     // Only tasks whose text or hint in this language awaits human verification
+	NeverServed        bool       // Only tasks never drawn for a game
+	ServedBefore       *time.Time // Only tasks not served since this time (includes never served)
+	MaxTimesServed     *int       // Only tasks served at most this many times
+	FromDate           *time.Time // Filter tasks created after this date
+	ToDate             *time.Time // Filter tasks created before this date
+	SortBy             string     // Sort field (created_at, updated_at, etc.)
+	SortOrder          string     // Sort order (asc, desc)
+	Limit              int        // Limit results
+	Offset             int        // Offset for pagination
+	Random             bool       // Randomize results
+}
+
+// taskSortFields are the columns tasks can be sorted by
+var taskSortFields = map[string]bool{
+	"created_at":     true,
+	"updated_at":     true,
+	"language":       true,
+	"type":           true,
+	"times_served":   true,
+	"last_served_at": true,
+}
+
+// sortOrder returns the validated sort column and direction, newest first
+// by default.
+func (f *TaskFilter) sortOrder() (column string, desc bool) {
+	if f == nil || !taskSortFields[f.SortBy] {
+		return "created_at", true
+	}
+	return f.SortBy, f.SortOrder != "asc"
 }
 
 // FindAll retrieves tasks with optional filters.
@@ -41,73 +79,23 @@ func (r *TaskRepository) FindAll(filter *TaskFilter) ([]models.Task, int64, erro
 	var tasks []models.Task
 	var total int64
 
-	query := r.db.Model(&models.Task{})
-
-	if filter != nil {
-		// Category filters
-		if filter.CategoryID != "" {
-			query = query.Where("category_id = ?", filter.CategoryID)
-		}
-		if len(filter.CategoryIDs) > 0 {
-			query = query.Where("category_id IN ?", filter.CategoryIDs)
-		}
-
-		// Type filters
-		if filter.Type != "" {
-			query = query.Where("type = ?", filter.Type)
-		}
-		if len(filter.Types) > 0 {
-			query = query.Where("type IN ?", filter.Types)
-		}
-
-		// Language filters
-		if filter.Language != "" {
-			query = query.Where("language = ?", filter.Language)
-		}
-		if len(filter.Languages) > 0 {
-			query = query.Where("language IN ?", filter.Languages)
-		}
-
-		if len(filter.ExcludeIDs) > 0 {
-			query = query.Where("id NOT IN ?", filter.ExcludeIDs)
-		}
-
-		// Date range filters
-		if filter.FromDate != nil {
-			query = query.Where("created_at >= ?", *filter.FromDate)
-		}
-		if filter.ToDate != nil {
-			query = query.Where("created_at <= ?", *filter.ToDate)
-		}
-	}
+	query := r.filteredQuery(filter)
 
 	// Get total count before pagination
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Apply ordering
+	// Apply ordering; ties are broken by ID so pages and neighbors agree
 	if filter != nil && filter.Random {
 		query = query.Order("RANDOM()")
-	} else if filter != nil && filter.SortBy != "" {
-		// Validate sort field to prevent SQL injection
-		validSortFields := map[string]bool{
-			"created_at": true,
-			"updated_at": true,
-			"language":   true,
-			"type":       true,
-		}
-		if validSortFields[filter.SortBy] {
-			order := "DESC"
-			if filter.SortOrder == "asc" {
-				order = "ASC"
-			}
-			query = query.Order(filter.SortBy + " " + order)
-		} else {
-			query = query.Order("created_at DESC")
-		}
 	} else {
-		query = query.Order("created_at DESC")
+		column, desc := filter.sortOrder()
+		direction := " ASC"
+		if desc {
+			direction = " DESC"
+		}
+		query = query.Order(column + direction + ", id" + direction)
 	}
 
 	// Apply pagination
@@ -124,6 +112,174 @@ func (r *TaskRepository) FindAll(filter *TaskFilter) ([]models.Task, int64, erro
 	return tasks, total, err
 }
 
+// filteredQuery builds a task query with the filter's WHERE clauses applied.
+// Ordering and pagination are left to the caller.
+func (r *TaskRepository) filteredQuery(filter *TaskFilter) *gorm.DB {
+	query := r.db.Model(&models.Task{})
+
+	if filter == nil {
+		return query
+	}
+
+	// Category filters
+	if filter.CategoryID != "" {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ?", filter.CategoryIDs)
+	}
+	if len(filter.ExcludeCategoryIDs) > 0 {
+		query = query.Where("category_id NOT IN ?", filter.ExcludeCategoryIDs)
+	}
+	if groups, ok := filter.ageGroups(); ok {
+		query = query.Where("category_id IN (?)", r.db.Model(&models.Category{}).Select("id").Where("age_group IN ?", groups))
+	}
+
+	// Type filters
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+
+	// Language filters
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+	if len(filter.Languages) > 0 {
+		query = query.Where("language IN ?", filter.Languages)
+	}
+
+	if len(filter.ExcludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", filter.ExcludeIDs)
+	}
+
+	// Moderation filters
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.RequiresConsent != nil {
+		consentCategories := r.db.Model(&models.Category{}).Select("id").Where("requires_consent = ?", true)
+		if *filter.RequiresConsent {
+			query = query.Where("requires_consent = ? OR category_id IN (?)", true, consentCategories)
+		} else {
+			query = query.Where("requires_consent = ? AND category_id NOT IN (?)", false, consentCategories)
+		}
+	}
+	if filter.ReviewState != "" {
+		query = query.Where("review_state = ?", filter.ReviewState)
+	}
+	if filter.RolloutRoll != nil {
+		query = query.Where("rollout_percent > ?", *filter.RolloutRoll)
+	}
+	if filter.AssignedTo != "" {
+		query = query.Where("assigned_to = ?", filter.AssignedTo)
+	}
+	if filter.Unassigned {
+		query = query.Where("assigned_to = '' OR assigned_to IS NULL")
+	}
+	if filter.MachineLanguage != "" {
+		query = query.Where("machine_languages LIKE ?", `%"`+filter.MachineLanguage+`"%`)
+	}
+
+	// Serve statistics filters
+	if filter.NeverServed {
+		query = query.Where("times_served = 0")
+	}
+	if filter.ServedBefore != nil {
+		query = query.Where("last_served_at IS NULL OR last_served_at < ?", *filter.ServedBefore)
+	}
+	if filter.MaxTimesServed != nil {
+		query = query.Where("times_served <= ?", *filter.MaxTimesServed)
+	}
+
+	// Date range filters
+	if filter.FromDate != nil {
+		query = query.Where("created_at >= ?", *filter.FromDate)
+	}
+	if filter.ToDate != nil {
+		query = query.Where("created_at <= ?", *filter.ToDate)
+	}
+
+	return query
+}
+
+// ageGroups resolves the age group and age range filters to the category
+// age groups matching both. ok is false when neither is set.
+func (f *TaskFilter) ageGroups() (groups []string, ok bool) {
+	if len(f.AgeGroups) == 0 && f.MinAge == nil && f.MaxAge == nil {
+		return nil, false
+	}
+
+	minAge, maxAge := 0, models.GetMaxAgeForGroup(models.AgeGroupAdults)
+	if f.MinAge != nil {
+		minAge = *f.MinAge
+	}
+	if f.MaxAge != nil {
+		maxAge = *f.MaxAge
+	}
+	groups = models.AgeGroupsInRange(minAge, maxAge)
+	if len(f.AgeGroups) > 0 {
+		groups = slices.DeleteFunc(groups, func(group string) bool {
+			return !slices.Contains(f.AgeGroups, group)
+		})
+	}
+	return groups, true
+}
+
+// Example selection strategies for few-shot generation prompts
+const (
+	ExampleStrategyRandom = "random" // A random sample of existing tasks
+	ExampleStrategyRecent = "recent" // The newest tasks first
+)
+
+// IsValidExampleStrategy checks if an example selection strategy is supported.
+func IsValidExampleStrategy(strategy string) bool {
+	return strategy == ExampleStrategyRandom || strategy == ExampleStrategyRecent
+}
+
+// FindExampleTexts returns up to limit truth and limit dare texts for a
+// category+language, for use as few-shot examples in generation prompts.
+func (r *TaskRepository) FindExampleTexts(categoryID, language string, limit int, strategy string) (truths, dares []string, err error) {
+	if limit <= 0 {
+		return nil, nil, nil
+	}
+
+	texts := func(taskType string) ([]string, error) {
+		filter := &TaskFilter{
+			CategoryID: categoryID,
+			Language:   language,
+			Type:       taskType,
+			Limit:      limit,
+		}
+		if strategy == ExampleStrategyRecent {
+			filter.SortBy = "created_at"
+			filter.SortOrder = "desc"
+		} else {
+			filter.Random = true
+		}
+
+		tasks, _, err := r.FindAll(filter)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, len(tasks))
+		for i, task := range tasks {
+			out[i] = task.Text
+		}
+		return out, nil
+	}
+
+	if truths, err = texts(models.TaskTypeTruth); err != nil {
+		return nil, nil, err
+	}
+	if dares, err = texts(models.TaskTypeDare); err != nil {
+		return nil, nil, err
+	}
+	return truths, dares, nil
+}
+
 // FindByID retrieves a task by ID.
 func (r *TaskRepository) FindByID(id string) (*models.Task, error) {
 	var task models.Task
@@ -134,67 +290,162 @@ func (r *TaskRepository) FindByID(id string) (*models.Task, error) {
 	return &task, nil
 }
 
+// FindByShortCode retrieves a task by its short code, in stored form.
+func (r *TaskRepository) FindByShortCode(code string) (*models.Task, error) {
+	var task models.Task
+	err := r.db.First(&task, "short_code = ?", code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindByIDs retrieves the tasks with the given IDs, in no particular order.
+func (r *TaskRepository) FindByIDs(ids []string) ([]models.Task, error) {
+	var tasks []models.Task
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&tasks).Error
+	return tasks, err
+}
+
+// randomPickAttempts bounds how often FindRandom retries when the sampled
+// offset no longer exists because rows were deleted between count and fetch.
+const randomPickAttempts = 3
+
 // FindRandom retrieves a random task matching the filter.
+//
+// Rather than ORDER BY RANDOM(), which evaluates and ranks every matching
+// row, it counts the matching rows and fetches the row at a random offset.
+// Both queries can be served from the task indexes, so the cost no longer
+// grows with a full sort of the filtered set.
 func (r *TaskRepository) FindRandom(filter *TaskFilter) (*models.Task, error) {
-	if filter == nil {
-		filter = &TaskFilter{}
+	for attempt := 0; attempt < randomPickAttempts; attempt++ {
+		var total int64
+		if err := r.filteredQuery(filter).Count(&total).Error; err != nil {
+			return nil, err
+		}
+
+		if total == 0 {
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		var tasks []models.Task
+		err := r.filteredQuery(filter).
+			Offset(int(rand.Int63n(total))).
+			Limit(1).
+			Find(&tasks).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tasks) > 0 {
+			return &tasks[0], nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// FindDaily returns the task of the day among those matching filter: the
+// same task all day for the same seed, a different one the next. Tasks are
+// picked by position, so adding or removing tasks can change the pick.
+func (r *TaskRepository) FindDaily(filter *TaskFilter, day time.Time, seed string) (*models.Task, error) {
+	var total int64
+	if err := r.filteredQuery(filter).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, gorm.ErrRecordNotFound
 	}
-	filter.Limit = 1
-	filter.Random = true
 
-	tasks, _, err := r.FindAll(filter)
+	h := fnv.New64a()
+	h.Write([]byte(day.UTC().Format("2006-01-02") + "\n" + seed))
+	var task models.Task
+	err := r.filteredQuery(filter).
+		Order("tasks.id").
+		Offset(int(h.Sum64() % uint64(total))).
+		Limit(1).
+		Take(&task).Error
 	if err != nil {
 		return nil, err
 	}
+	return &task, nil
+}
 
-	if len(tasks) == 0 {
-		return nil, gorm.ErrRecordNotFound
+// FindNeighbors returns the IDs of the tasks before and after a task in
+// the order FindAll lists tasks matching filter, empty at either end. The
+// task itself need not match the filter.
+func (r *TaskRepository) FindNeighbors(id string, filter *TaskFilter) (previous, next string, err error) {
+	column, desc := filter.sortOrder()
+	// NULLs sort first in SQLite, as the empty string does among dates
+	key := "COALESCE(" + column + ", '')"
+
+	var value interface{}
+	row := r.db.Model(&models.Task{}).Select(key).Where("id = ?", id).Row()
+	if err := row.Scan(&value); err != nil {
+		return "", "", err
 	}
 
-	return &tasks[0], nil
+	neighbor := func(after bool) (string, error) {
+		// Walking forward in a descending order means smaller keys
+		op, direction := ">", " ASC"
+		if after == desc {
+			op, direction = "<", " DESC"
+		}
+		var ids []string
+		err := r.filteredQuery(filter).
+			Where(key+" "+op+" ? OR ("+key+" = ? AND id "+op+" ?)", value, value, id).
+			Order(key+direction+", id"+direction).
+			Limit(1).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return "", err
+		}
+		return ids[0], nil
+	}
+
+	if previous, err = neighbor(false); err != nil {
+		return "", "", err
+	}
+	if next, err = neighbor(true); err != nil {
+		return "", "", err
+	}
+	return previous, next, nil
 }
 
-// CountByFilters returns the count of tasks matching the filters.
-// Uses efficient COUNT queries instead of loading all records.
+// CountByFilters returns the count of tasks matching the filters, split by type.
+// Both counts come from a single GROUP BY query; type filters are ignored.
 func (r *TaskRepository) CountByFilters(filter *TaskFilter) (truthCount, dareCount int64, err error) {
-	// Build base query with filters (excluding type filter)
-	buildQuery := func(taskType string) *gorm.DB {
-		query := r.db.Model(&models.Task{}).Where("type = ?", taskType)
+	var typeless TaskFilter
+	if filter != nil {
+		typeless = *filter
+		typeless.Type = ""
+		typeless.Types = nil
+	}
 
-		if filter != nil {
-			if filter.CategoryID != "" {
-				query = query.Where("category_id = ?", filter.CategoryID)
-			}
-			if len(filter.CategoryIDs) > 0 {
-				query = query.Where("category_id IN ?", filter.CategoryIDs)
-			}
-			if filter.Language != "" {
-				query = query.Where("language = ?", filter.Language)
-			}
-			if len(filter.Languages) > 0 {
-				query = query.Where("language IN ?", filter.Languages)
-			}
-			if len(filter.ExcludeIDs) > 0 {
-				query = query.Where("id NOT IN ?", filter.ExcludeIDs)
-			}
-			if filter.FromDate != nil {
-				query = query.Where("created_at >= ?", *filter.FromDate)
-			}
-			if filter.ToDate != nil {
-				query = query.Where("created_at <= ?", *filter.ToDate)
-			}
-		}
-		return query
+	type Result struct {
+		Type  string
+		Count int64
 	}
 
-	// Count truths
-	if err = buildQuery(models.TaskTypeTruth).Count(&truthCount).Error; err != nil {
+	var results []Result
+	err = r.filteredQuery(&typeless).
+		Select("type, count(*) as count").
+		Group("type").
+		Find(&results).Error
+	if err != nil {
 		return 0, 0, err
 	}
 
-	// Count dares
-	if err = buildQuery(models.TaskTypeDare).Count(&dareCount).Error; err != nil {
-		return 0, 0, err
+	for _, res := range results {
+		switch res.Type {
+		case models.TaskTypeTruth:
+			truthCount = res.Count
+		case models.TaskTypeDare:
+			dareCount = res.Count
+		}
 	}
 
 	return truthCount, dareCount, nil
@@ -205,9 +456,42 @@ func (r *TaskRepository) Create(task *models.Task) error {
 	return r.db.Create(task).Error
 }
 
-// CreateBatch creates multiple tasks.
+// defaultCreateBatchSize is used when the connection has no CreateBatchSize configured.
+const defaultCreateBatchSize = 100
+
+// CreateBatch creates multiple tasks, honouring the connection's CreateBatchSize.
 func (r *TaskRepository) CreateBatch(tasks []models.Task) error {
-	return r.db.CreateInBatches(tasks, 100).Error
+	batchSize := r.db.CreateBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCreateBatchSize
+	}
+	return r.db.CreateInBatches(tasks, batchSize).Error
+}
+
+// CreateImported creates imported tasks in one transaction. Tasks imported
+// inactive are switched off once created, as GORM fills in the column's
+// default for a false IsActive, and writes it back into the tasks.
+func (r *TaskRepository) CreateImported(tasks []models.Task) error {
+	var inactive []int
+	for i, task := range tasks {
+		if !task.IsActive {
+			inactive = append(inactive, i)
+		}
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(tasks, defaultCreateBatchSize).Error; err != nil {
+			return err
+		}
+		if len(inactive) == 0 {
+			return nil
+		}
+		ids := make([]string, len(inactive))
+		for j, i := range inactive {
+			tasks[i].IsActive = false
+			ids[j] = tasks[i].ID
+		}
+		return tx.Model(&models.Task{}).Where("id IN ?", ids).Update("is_active", false).Error
+	})
 }
 
 // Update updates an existing task.
@@ -215,6 +499,311 @@ func (r *TaskRepository) Update(task *models.Task) error {
 	return r.db.Save(task).Error
 }
 
+// SetModeration sets a task's active flag and review state.
+// It updates only those columns, so concurrent edits to the text are kept.
+func (r *TaskRepository) SetModeration(id string, isActive bool, reviewState string) error {
+	return r.db.Model(&models.Task{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"is_active":    isActive,
+			"review_state": reviewState,
+		}).Error
+}
+
+// ErrNotClaimable is returned by Claim and Release when the task is not
+// pending review or is assigned to another reviewer.
+var ErrNotClaimable = errors.New("task is not pending review or is assigned to another reviewer")
+
+// Claim assigns a pending task to reviewer. Claiming a task the reviewer
+// already holds succeeds; a task held by someone else returns ErrNotClaimable.
+// The check and the write are one UPDATE, so two reviewers racing for the
+// same task cannot both win.
+func (r *TaskRepository) Claim(id, reviewer string) error {
+	result := r.db.Model(&models.Task{}).
+		Where("id = ? AND review_state = ?", id, models.ReviewStatePending).
+		Where("assigned_to = '' OR assigned_to IS NULL OR assigned_to = ?", reviewer).
+		Updates(map[string]interface{}{
+			"assigned_to": reviewer,
+			"assigned_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotClaimable
+	}
+	return nil
+}
+
+// Release clears a task's assignment. Unless force is set, only the
+// assigned reviewer may release it.
+func (r *TaskRepository) Release(id, reviewer string, force bool) error {
+	query := r.db.Model(&models.Task{}).Where("id = ?", id)
+	if !force {
+		query = query.Where("assigned_to = ?", reviewer)
+	}
+	result := query.Updates(map[string]interface{}{
+		"assigned_to": "",
+		"assigned_at": nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotClaimable
+	}
+	return nil
+}
+
+// Review records a review decision, sets the task's active flag and clears
+// its assignment.
+func (r *TaskRepository) Review(id, state, notes string, isActive bool) error {
+	return r.db.Model(&models.Task{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"review_state":   state,
+			"reviewer_notes": notes,
+			"is_active":      isActive,
+			"assigned_to":    "",
+			"assigned_at":    nil,
+		}).Error
+}
+
+// SetMachineLanguages sets the languages of a task awaiting human
+// verification. It updates only that column.
+func (r *TaskRepository) SetMachineLanguages(id string, languages models.StringArray) error {
+	return r.db.Model(&models.Task{}).
+		Where("id = ?", id).
+		Update("machine_languages", languages).Error
+}
+
+// CreateGenerated stores AI-generated tasks of one category+language.
+// Texts breaking the text rules are rejected, and texts matching a stored
+// task of the same category+language (ignoring case and spacing) or an
+// earlier text of the batch are skipped as duplicates.
+func (r *TaskRepository) CreateGenerated(tasks []models.Task, rules models.TextRules) (created []models.Task, duplicates, rejected int, err error) {
+	if len(tasks) == 0 {
+		return nil, 0, 0, nil
+	}
+
+	var stored []string
+	err = r.db.Model(&models.Task{}).
+		Where("category_id = ? AND language = ?", tasks[0].CategoryID, tasks[0].Language).
+		Pluck("text", &stored).Error
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	seen := make(map[string]bool, len(stored)+len(tasks))
+	for _, text := range stored {
+		seen[labelKey(text)] = true
+	}
+
+	for i := range tasks {
+		task := tasks[i]
+		if len(rules.Validate("text", task.Text, task.Language)) > 0 {
+			rejected++
+			continue
+		}
+		key := labelKey(task.Text)
+		if seen[key] {
+			duplicates++
+			continue
+		}
+		if err := r.db.Create(&task).Error; err != nil {
+			return created, duplicates, rejected, err
+		}
+		seen[key] = true
+		created = append(created, task)
+	}
+	return created, duplicates, rejected, nil
+}
+
+// TaskMatch is a task found by Search. Field is "id", "text" or "hint";
+// Language is the language of the matched text or hint.
+type TaskMatch struct {
+	Task     models.Task
+	Field    string
+	Language string
+}
+
+// Search returns up to limit tasks whose ID equals q or whose text or hint
+// contains it, ignoring case, newest first. Categories are preloaded.
+func (r *TaskRepository) Search(q string, limit int) ([]TaskMatch, error) {
+	pattern := likePattern(q)
+	var matches []TaskMatch
+	// Hints are JSON, so a pattern matching only a language key is dropped
+	// below; fetch in batches until limit real matches are found
+	const batchSize = 200
+	for offset := 0; len(matches) < limit; offset += batchSize {
+		var tasks []models.Task
+		err := r.db.Preload("Category").
+			Where("id = ? OR text LIKE ? ESCAPE '\\' OR hint LIKE ? ESCAPE '\\'", q, pattern, pattern).
+			Order("created_at DESC, id ASC").
+			Offset(offset).Limit(batchSize).
+			Find(&tasks).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range tasks {
+			match := TaskMatch{Task: task, Language: task.Language}
+			switch {
+			case task.ID == q:
+				match.Field = "id"
+			case strings.Contains(strings.ToLower(task.Text), strings.ToLower(q)):
+				match.Field = "text"
+			default:
+				languages := matchingLanguages(task.Hint, q)
+				if len(languages) == 0 {
+					continue
+				}
+				match.Field = "hint"
+				match.Language = languages[0]
+			}
+			matches = append(matches, match)
+			if len(matches) == limit {
+				break
+			}
+		}
+		if len(tasks) < batchSize {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// CategoryLanguageCount is the number of tasks in one category+language.
+type CategoryLanguageCount struct {
+	CategoryID string
+	Language   string
+	Count      int64
+}
+
+// FindOverCap returns the category+language combinations holding more than
+// limit tasks.
+func (r *TaskRepository) FindOverCap(limit int) ([]CategoryLanguageCount, error) {
+	var results []CategoryLanguageCount
+	err := r.db.Model(&models.Task{}).
+		Select("category_id, language, count(*) as count").
+		Group("category_id, language").
+		Having("count(*) > ?", limit).
+		Find(&results).Error
+	return results, err
+}
+
+// CategoryLanguageNewest is the creation time of the newest active task
+// in one category+language.
+type CategoryLanguageNewest struct {
+	CategoryID string
+	Language   string
+	CreatedAt  time.Time
+}
+
+// FindNewestActive returns the newest active task time of every
+// category+language holding an active task.
+func (r *TaskRepository) FindNewestActive() ([]CategoryLanguageNewest, error) {
+	// MAX(created_at) comes back untyped from SQLite, so select the rows
+	// holding it instead
+	var rows []CategoryLanguageNewest
+	err := r.db.Model(&models.Task{}).
+		Select("category_id, language, created_at").
+		Where("is_active = ?", true).
+		Where("created_at = (SELECT MAX(t.created_at) FROM tasks t WHERE t.category_id = tasks.category_id AND t.language = tasks.language AND t.is_active = ? AND t.deleted_at IS NULL)", true).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Ties on created_at yield one row each
+	seen := make(map[[2]string]bool, len(rows))
+	newest := rows[:0]
+	for _, row := range rows {
+		key := [2]string{row.CategoryID, row.Language}
+		if !seen[key] {
+			seen[key] = true
+			newest = append(newest, row)
+		}
+	}
+	return newest, nil
+}
+
+// FindEvictable returns up to limit IDs of inactive tasks in a
+// category+language, in eviction order: most open reports first, then oldest.
+func (r *TaskRepository) FindEvictable(categoryID, language string, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.Task{}).
+		Joins("LEFT JOIN (SELECT task_id, count(*) AS reports FROM task_reports WHERE resolved_at IS NULL AND deleted_at IS NULL GROUP BY task_id) r ON r.task_id = tasks.id").
+		Where("tasks.category_id = ? AND tasks.language = ? AND tasks.is_active = ?", categoryID, language, false).
+		Order("COALESCE(r.reports, 0) DESC, tasks.created_at ASC").
+		Limit(limit).
+		Pluck("tasks.id", &ids).Error
+	return ids, err
+}
+
+// DeleteMany soft-deletes the given tasks.
+func (r *TaskRepository) DeleteMany(ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.db.Delete(&models.Task{}, "id IN ?", ids)
+	return result.RowsAffected, result.Error
+}
+
+// FindStaged retrieves active tasks still in staged rollout that were
+// created before the given time, oldest first.
+func (r *TaskRepository) FindStaged(createdBefore time.Time, limit int) ([]models.Task, error) {
+	var tasks []models.Task
+	query := r.db.
+		Where("rollout_percent < ? AND is_active = ? AND created_at < ?", models.FullRollout, true, createdBefore).
+		Order("created_at ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&tasks).Error
+	return tasks, err
+}
+
+// SetRolloutPercent sets the rollout percentage of the given tasks.
+func (r *TaskRepository) SetRolloutPercent(ids []string, percent int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Task{}).
+		Where("id IN ?", ids).
+		Update("rollout_percent", percent).Error
+}
+
+// Task sources counted by CountCreatedBySource
+const (
+	TaskSourceManual    = "manual"
+	TaskSourceGenerated = "generated"
+)
+
+// CountCreatedBySource counts the tasks created at or after since by
+// source: AI-generated, or manual for hand-written and imported tasks.
+func (r *TaskRepository) CountCreatedBySource(since time.Time) (map[string]int64, error) {
+	type Result struct {
+		Source string
+		Count  int64
+	}
+
+	var results []Result
+	err := r.db.Model(&models.Task{}).
+		Select("CASE WHEN COALESCE(generation_run_id, '') = '' THEN ? ELSE ? END AS source, count(*) as count", TaskSourceManual, TaskSourceGenerated).
+		Where("created_at >= ?", since).
+		Group("source").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{TaskSourceManual: 0, TaskSourceGenerated: 0}
+	for _, res := range results {
+		counts[res.Source] = res.Count
+	}
+	return counts, nil
+}
+
 // Delete soft-deletes a task.
 func (r *TaskRepository) Delete(id string) error {
 	return r.db.Delete(&models.Task{}, "id = ?", id).Error
@@ -270,6 +859,36 @@ func (r *TaskRepository) CountByType() (map[string]int64, error) {
 	return counts, nil
 }
 
+// Attribution is a license and attribution some active content is
+// redistributed under, with the categories and tasks it covers.
+type Attribution struct {
+	License     string `json:"license"`
+	Attribution string `json:"attribution"`
+	Categories  int64  `json:"categories"`
+	Tasks       int64  `json:"tasks"`
+}
+
+// FindAttributions groups the active tasks of active categories by the
+// license and attribution that apply to them (see models.Task.Licensing),
+// most tasks first. Unlicensed tasks are left out.
+func (r *TaskRepository) FindAttributions() ([]Attribution, error) {
+	licensed := r.db.Table("tasks").
+		Select(`tasks.category_id,
+			CASE WHEN COALESCE(tasks.license, '') <> '' THEN tasks.license ELSE COALESCE(categories.license, '') END AS license,
+			CASE WHEN COALESCE(tasks.license, '') <> '' THEN COALESCE(tasks.attribution, '') ELSE COALESCE(categories.attribution, '') END AS attribution`).
+		Joins("JOIN categories ON categories.id = tasks.category_id AND categories.deleted_at IS NULL").
+		Where("tasks.deleted_at IS NULL AND tasks.is_active = ? AND categories.is_active = ?", true, true)
+
+	var attributions []Attribution
+	err := r.db.Table("(?) AS licensed", licensed).
+		Select("license, attribution, COUNT(DISTINCT category_id) AS categories, COUNT(*) AS tasks").
+		Where("license <> ''").
+		Group("license, attribution").
+		Order("COUNT(*) DESC, license ASC, attribution ASC").
+		Scan(&attributions).Error
+	return attributions, err
+}
+
 // Count returns the total count of tasks matching the filter.
 func (r *TaskRepository) Count(filter *TaskFilter) (int64, error) {
 	var count int64
@@ -300,6 +919,14 @@ func (r *TaskRepository) Count(filter *TaskFilter) (int64, error) {
 			query = query.Where("language IN ?", filter.Languages)
 		}
 
+		// Moderation filters
+		if filter.IsActive != nil {
+			query = query.Where("is_active = ?", *filter.IsActive)
+		}
+		if filter.ReviewState != "" {
+			query = query.Where("review_state = ?", filter.ReviewState)
+		}
+
 		// Date range filters
 		if filter.FromDate != nil {
 			query = query.Where("created_at >= ?", *filter.FromDate)
@@ -312,3 +939,98 @@ func (r *TaskRepository) Count(filter *TaskFilter) (int64, error) {
 	err := query.Count(&count).Error
 	return count, err
 }
+
+// EachTask walks the tasks matching filter in batches of up to batchSize,
+// ordered by ID, with their categories preloaded. Ordering and pagination
+// in the filter are ignored. Like SnapshotRepository.EachTask, batches are
+// read by keyset, so memory stays flat however many rows there are.
+func (r *TaskRepository) EachTask(filter *TaskFilter, batchSize int, fn func([]models.Task) error) error {
+	query := r.filteredQuery(filter).Preload("Category").Session(&gorm.Session{})
+	return eachBatch(query, batchSize, fn, func(t models.Task) string { return t.ID })
+}
+
+// TaskImportIndex resolves the categories of imported tasks and finds the
+// duplicates among them: tasks with the text of a stored task or an earlier
+// imported one in the same category and language, ignoring case and
+// spacing, as CreateGenerated compares them.
+type TaskImportIndex struct {
+	db      *gorm.DB
+	byID    map[string]models.Category
+	byLabel map[string]models.Category
+	// texts holds the text keys per category and language, loaded from the
+	// database the first time the pair is seen
+	texts map[string]map[string]bool
+}
+
+// NewImportIndex loads the categories for an import. The categories table
+// is small, so it is read whole.
+func (r *TaskRepository) NewImportIndex() (*TaskImportIndex, error) {
+	var categories []models.Category
+	if err := r.db.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	return &TaskImportIndex{
+		db:      r.db,
+		byID:    byID,
+		byLabel: categoriesByLabel(categories),
+		texts:   make(map[string]map[string]bool),
+	}, nil
+}
+
+// Category finds a task's category by ID or, as IDs differ between
+// instances, by English label like snapshot imports.
+func (x *TaskImportIndex) Category(id, label string) (models.Category, bool) {
+	if category, ok := x.byID[id]; ok {
+		return category, true
+	}
+	if label == "" {
+		return models.Category{}, false
+	}
+	category, ok := x.byLabel[labelKey(label)]
+	return category, ok
+}
+
+// Duplicate reports whether the task's text is stored or was added to the
+// index already in its category and language
+func (x *TaskImportIndex) Duplicate(task models.Task) (bool, error) {
+	texts, err := x.textsOf(task.CategoryID, task.Language)
+	if err != nil {
+		return false, err
+	}
+	return texts[labelKey(task.Text)], nil
+}
+
+// Add records the task's text, so later duplicates of it are found
+func (x *TaskImportIndex) Add(task models.Task) error {
+	texts, err := x.textsOf(task.CategoryID, task.Language)
+	if err != nil {
+		return err
+	}
+	texts[labelKey(task.Text)] = true
+	return nil
+}
+
+func (x *TaskImportIndex) textsOf(categoryID, language string) (map[string]bool, error) {
+	pair := categoryID + "/" + language
+	if texts, ok := x.texts[pair]; ok {
+		return texts, nil
+	}
+
+	var stored []string
+	err := x.db.Model(&models.Task{}).
+		Where("category_id = ? AND language = ?", categoryID, language).
+		Pluck("text", &stored).Error
+	if err != nil {
+		return nil, err
+	}
+	texts := make(map[string]bool, len(stored))
+	for _, text := range stored {
+		texts[labelKey(text)] = true
+	}
+	x.texts[pair] = texts
+	return texts, nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TelemetryRepository handles telemetry rollup database operations.
+type TelemetryRepository struct {
+	db *gorm.DB
+}
+
+// NewTelemetryRepository creates a new TelemetryRepository.
+func NewTelemetryRepository(db *gorm.DB) *TelemetryRepository {
+	return &TelemetryRepository{db: db}
+}
+
+// TelemetryFilter contains filter options for querying rollups.
+type TelemetryFilter struct {
+	FromDay    string // Inclusive, YYYY-MM-DD
+	ToDay      string // Inclusive, YYYY-MM-DD
+	Event      string
+	CategoryID string
+	TaskID     string
+	Language   string
+}
+
+// AddCounts adds each rollup's Count to the stored counter for its day and
+// dimensions, creating counters as needed. All rollups are applied in one
+// transaction.
+func (r *TelemetryRepository) AddCounts(rollups []models.TelemetryRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range rollups {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{
+					{Name: "day"}, {Name: "event"}, {Name: "category_id"}, {Name: "task_id"},
+					{Name: "task_type"}, {Name: "language"}, {Name: "age_group"},
+				},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"count":      gorm.Expr("telemetry_rollups.count + excluded.count"),
+					"updated_at": gorm.Expr("excluded.updated_at"),
+				}),
+			}).Create(&rollups[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindAll retrieves rollups matching the filter, ordered by day then event.
+func (r *TelemetryRepository) FindAll(filter *TelemetryFilter) ([]models.TelemetryRollup, error) {
+	var rollups []models.TelemetryRollup
+	query := r.db.Model(&models.TelemetryRollup{})
+
+	if filter != nil {
+		if filter.FromDay != "" {
+			query = query.Where("day >= ?", filter.FromDay)
+		}
+		if filter.ToDay != "" {
+			query = query.Where("day <= ?", filter.ToDay)
+		}
+		if filter.Event != "" {
+			query = query.Where("event = ?", filter.Event)
+		}
+		if filter.CategoryID != "" {
+			query = query.Where("category_id = ?", filter.CategoryID)
+		}
+		if filter.TaskID != "" {
+			query = query.Where("task_id = ?", filter.TaskID)
+		}
+		if filter.Language != "" {
+			query = query.Where("language = ?", filter.Language)
+		}
+	}
+
+	err := query.Order("day ASC, event ASC").Find(&rollups).Error
+	return rollups, err
+}
+
+// TaskEventCounts sums the play events of one task.
+type TaskEventCounts struct {
+	TaskID    string
+	Served    int64
+	Completed int64
+	Liked     int64
+}
+
+// CountByTask sums an event's counts per task, over all days, for the
+// given tasks. Tasks without the event are left out.
+func (r *TelemetryRepository) CountByTask(event string, taskIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(taskIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TaskID string
+		Total  int64
+	}
+	err := r.db.Model(&models.TelemetryRollup{}).
+		Select("task_id, SUM(count) AS total").
+		Where("event = ? AND task_id IN ?", event, taskIDs).
+		Group("task_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.TaskID] = row.Total
+	}
+	return counts, nil
+}
+
+// TrendingFilter selects and orders the tasks returned by FindTrending.
+type TrendingFilter struct {
+	FromDay    string // Inclusive, YYYY-MM-DD
+	CategoryID string
+	Language   string
+	Type       string
+	// ByLikeRate orders by liked/served instead of served, skipping tasks
+	// served fewer than MinServed times
+	ByLikeRate bool
+	MinServed  int64
+	Limit      int
+}
+
+// FindTrending sums served, completed and liked events per task since
+// FromDay and returns the top active tasks, most served (or best liked)
+// first.
+func (r *TelemetryRepository) FindTrending(filter *TrendingFilter) ([]TaskEventCounts, error) {
+	query := r.db.Model(&models.TelemetryRollup{}).
+		Select(`telemetry_rollups.task_id AS task_id,
+			SUM(CASE WHEN telemetry_rollups.event = ? THEN telemetry_rollups.count ELSE 0 END) AS served,
+			SUM(CASE WHEN telemetry_rollups.event = ? THEN telemetry_rollups.count ELSE 0 END) AS completed,
+			SUM(CASE WHEN telemetry_rollups.event = ? THEN telemetry_rollups.count ELSE 0 END) AS liked`,
+			models.TelemetryTaskServed, models.TelemetryTaskCompleted, models.TelemetryTaskLiked).
+		Joins("JOIN tasks ON tasks.id = telemetry_rollups.task_id AND tasks.deleted_at IS NULL").
+		Where("tasks.is_active = ?", true).
+		Where("telemetry_rollups.day >= ?", filter.FromDay).
+		Where("telemetry_rollups.event IN ?", []string{models.TelemetryTaskServed, models.TelemetryTaskCompleted, models.TelemetryTaskLiked})
+
+	if filter.CategoryID != "" {
+		query = query.Where("tasks.category_id = ?", filter.CategoryID)
+	}
+	if filter.Language != "" {
+		query = query.Where("tasks.language = ?", filter.Language)
+	}
+	if filter.Type != "" {
+		query = query.Where("tasks.type = ?", filter.Type)
+	}
+
+	query = query.Group("telemetry_rollups.task_id")
+	if filter.ByLikeRate {
+		query = query.
+			Having("SUM(CASE WHEN telemetry_rollups.event = ? THEN telemetry_rollups.count ELSE 0 END) >= ?", models.TelemetryTaskServed, max(filter.MinServed, 1)).
+			Order("liked * 1.0 / served DESC, served DESC, task_id ASC")
+	} else {
+		query = query.
+			Having("SUM(CASE WHEN telemetry_rollups.event = ? THEN telemetry_rollups.count ELSE 0 END) > 0", models.TelemetryTaskServed).
+			Order("served DESC, liked DESC, task_id ASC")
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var counts []TaskEventCounts
+	err := query.Scan(&counts).Error
+	return counts, err
+}
+
+// CategoryPlays counts the tasks served from a category to players of one
+// language and age group. Either is empty when clients did not send it.
+type CategoryPlays struct {
+	CategoryID string
+	Language   string
+	AgeGroup   string
+	Plays      int64
+}
+
+// SumCategoryPlays sums served tasks per category, language and age group
+// since fromDay (inclusive, YYYY-MM-DD). Events without a category count
+// for the category of their task; CategoryID is empty when neither is
+// known.
+func (r *TelemetryRepository) SumCategoryPlays(fromDay string) ([]CategoryPlays, error) {
+	var plays []CategoryPlays
+	err := r.db.Model(&models.TelemetryRollup{}).
+		Select(`COALESCE(NULLIF(telemetry_rollups.category_id, ''), tasks.category_id, '') AS category_id,
+			telemetry_rollups.language AS language,
+			telemetry_rollups.age_group AS age_group,
+			SUM(telemetry_rollups.count) AS plays`).
+		Joins("LEFT JOIN tasks ON tasks.id = telemetry_rollups.task_id").
+		Where("telemetry_rollups.event = ? AND telemetry_rollups.day >= ?", models.TelemetryTaskServed, fromDay).
+		Group("1, 2, 3").
+		Scan(&plays).Error
+	return plays, err
+}
+
+// TotalsByEvent sums rollups per event.
+func TotalsByEvent(rollups []models.TelemetryRollup) map[string]int64 {
+	totals := make(map[string]int64)
+	for _, rollup := range rollups {
+		totals[rollup.Event] += rollup.Count
+	}
+	return totals
+}
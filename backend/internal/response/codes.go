@@ -0,0 +1,58 @@
+package response
+
+import "net/http"
+
+// Code describes a machine-readable error code: the HTTP status it usually
+// comes with and when it is returned
+type Code struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// Codes is the catalogue of error codes the API returns, served on
+// /error-codes. Clients should branch on the code, not the message.
+var Codes = []Code{
+	{"validation_error", http.StatusBadRequest, "The request is malformed or a field is invalid; fields lists the invalid fields"},
+	{"invalid_fields", http.StatusBadRequest, "The fields query parameter names an unknown field"},
+	{"invalid_language", http.StatusBadRequest, "A language code is not supported"},
+	{"invalid_category", http.StatusBadRequest, "A referenced category does not exist"},
+	{"invalid_snapshot", http.StatusBadRequest, "A content snapshot archive cannot be read"},
+	{"unsupported_snapshot", http.StatusBadRequest, "A content snapshot has an unsupported format or a newer schema"},
+	{"age_confirmation_required", http.StatusBadRequest, "Consent requires the player to confirm their age"},
+	{"unauthorized", http.StatusUnauthorized, "The admin OTP is missing or invalid"},
+	{"forbidden", http.StatusForbidden, "The request needs admin authentication"},
+	{"invalid_signature", http.StatusForbidden, "The signature of a signed URL does not match its path and parameters"},
+	{"signature_expired", http.StatusForbidden, "A signed URL is past its expiry"},
+	{"not_found", http.StatusNotFound, "The resource does not exist"},
+	{"conflict", http.StatusConflict, "The resource conflicts with an existing one or is in the wrong state"},
+	{"label_conflict", http.StatusConflict, "Another category already has this label"},
+	{"already_reported", http.StatusConflict, "The task was already reported from this client"},
+	{"policy_outdated", http.StatusConflict, "Consent was given for an outdated policy version"},
+	{"language_frozen", http.StatusConflict, "The language is frozen for AI generation and machine translation"},
+	{"payload_too_large", http.StatusRequestEntityTooLarge, "The request body is too large"},
+	{"upgrade_required", http.StatusUpgradeRequired, "The client version is below the minimum supported; details has min_version"},
+	{"quota_exceeded", http.StatusTooManyRequests, "The daily generation quota of the admin key is used up; see Retry-After"},
+	{"internal_error", http.StatusInternalServerError, "An unexpected server error"},
+	{"database_error", http.StatusInternalServerError, "A database operation failed"},
+	{"configuration_error", http.StatusInternalServerError, "The server or AI provider is misconfigured"},
+	{"ai_error", http.StatusInternalServerError, "The AI provider returned an unusable response"},
+	{"storage_error", http.StatusInternalServerError, "Storing media failed"},
+	{"job_error", http.StatusInternalServerError, "A scheduler job failed"},
+	{"read_only", http.StatusServiceUnavailable, "The instance is in read-only mode and rejects writes"},
+	{"maintenance", http.StatusServiceUnavailable, "A scheduled maintenance window is open; see Retry-After"},
+	{"ai_unavailable", http.StatusServiceUnavailable, "The AI provider is unavailable or its circuit breaker is open"},
+	{"injected_fault", http.StatusServiceUnavailable, "A fault injected by chaos testing, in development only"},
+	{"timeout", http.StatusGatewayTimeout, "The request did not complete within its time budget"},
+}
+
+// codeForStatus returns the code for error bodies without one
+func codeForStatus(status int) string {
+	switch {
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status >= http.StatusInternalServerError:
+		return "internal_error"
+	}
+	return "validation_error"
+}
@@ -0,0 +1,97 @@
+// Package response defines the envelope every v2 API response is wrapped
+// in and the catalogue of machine-readable error codes.
+//
+// Handlers keep writing their v1 bodies; Wrap reshapes them for v2:
+//
+//	{"data": ..., "error": null, "meta": {"total": 42, ...}}
+//	{"data": null, "error": {"code": "not_found", "message": "..."}, "meta": {}}
+//
+// A body with a "data" field has it moved to data and its other fields,
+// such as pagination, to meta. Any other success body becomes data as is.
+// An error body's error, message and fields become the error object; its
+// other fields (e.g. min_version) go to error.details.
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Envelope is the shape of every v2 response
+type Envelope struct {
+	Data  json.RawMessage            `json:"data"`
+	Error *Error                     `json:"error"`
+	Meta  map[string]json.RawMessage `json:"meta"`
+}
+
+// Error is the error object of a failed v2 response. Code is one of the
+// codes in Codes.
+type Error struct {
+	Code    string                     `json:"code"`
+	Message string                     `json:"message"`
+	Fields  json.RawMessage            `json:"fields,omitempty"`
+	Details map[string]json.RawMessage `json:"details,omitempty"`
+}
+
+var (
+	null       = json.RawMessage("null")
+	errNotJSON = errors.New("response body is not JSON")
+)
+
+// Wrap reshapes the JSON body of a response with the given status into an
+// Envelope
+func Wrap(status int, body []byte) ([]byte, error) {
+	env := Envelope{Data: null, Meta: map[string]json.RawMessage{}}
+
+	var fields map[string]json.RawMessage
+	isObject := bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+	if isObject {
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, err
+		}
+	} else if !json.Valid(body) {
+		return nil, errNotJSON
+	}
+
+	if status >= http.StatusBadRequest {
+		env.Error = wrapError(status, fields)
+		return json.Marshal(env)
+	}
+
+	data, ok := fields["data"]
+	if !ok {
+		env.Data = json.RawMessage(body)
+		return json.Marshal(env)
+	}
+	env.Data = data
+	for key, value := range fields {
+		if key != "data" {
+			env.Meta[key] = value
+		}
+	}
+	return json.Marshal(env)
+}
+
+// wrapError builds the error object from the fields of an ErrorResponse
+// body. Bodies without an error code get a generic one for their status.
+func wrapError(status int, fields map[string]json.RawMessage) *Error {
+	e := &Error{Code: codeForStatus(status), Message: http.StatusText(status)}
+	for key, value := range fields {
+		switch key {
+		case "error":
+			json.Unmarshal(value, &e.Code)
+		case "message":
+			json.Unmarshal(value, &e.Message)
+		case "fields":
+			e.Fields = value
+		default:
+			if e.Details == nil {
+				e.Details = make(map[string]json.RawMessage)
+			}
+			e.Details[key] = value
+		}
+	}
+	return e
+}
@@ -0,0 +1,84 @@
+package response
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			name:   "data with pagination",
+			status: http.StatusOK,
+			body:   `{"data":[{"id":"a"}],"total":1,"page":1}`,
+			want:   `{"data":[{"id":"a"}],"error":null,"meta":{"page":1,"total":1}}`,
+		},
+		{
+			name:   "data only",
+			status: http.StatusOK,
+			body:   `{"data":[]}`,
+			want:   `{"data":[],"error":null,"meta":{}}`,
+		},
+		{
+			name:   "bare object",
+			status: http.StatusCreated,
+			body:   `{"id":"a","text":"Sing"}`,
+			want:   `{"data":{"id":"a","text":"Sing"},"error":null,"meta":{}}`,
+		},
+		{
+			name:   "array",
+			status: http.StatusOK,
+			body:   `[1,2]`,
+			want:   `{"data":[1,2],"error":null,"meta":{}}`,
+		},
+		{
+			name:   "error with fields",
+			status: http.StatusBadRequest,
+			body:   `{"error":"validation_error","message":"Invalid request","fields":[{"field":"text","message":"is required"}]}`,
+			want:   `{"data":null,"error":{"code":"validation_error","message":"Invalid request","fields":[{"field":"text","message":"is required"}]},"meta":{}}`,
+		},
+		{
+			name:   "error with details",
+			status: http.StatusUpgradeRequired,
+			body:   `{"error":"upgrade_required","message":"Update","client_version":"1.0.0","min_version":"2.0.0"}`,
+			want:   `{"data":null,"error":{"code":"upgrade_required","message":"Update","details":{"client_version":"1.0.0","min_version":"2.0.0"}},"meta":{}}`,
+		},
+		{
+			name:   "error without code",
+			status: http.StatusNotFound,
+			body:   `{}`,
+			want:   `{"data":null,"error":{"code":"not_found","message":"Not Found"},"meta":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Wrap(tt.status, []byte(tt.body))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := Wrap(http.StatusOK, []byte("not json"))
+		assert.Error(t, err)
+	})
+}
+
+func TestCodes(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, code := range Codes {
+		assert.False(t, seen[code.Code], "duplicate code %s", code.Code)
+		seen[code.Code] = true
+		assert.NotEmpty(t, code.Description, code.Code)
+		assert.GreaterOrEqual(t, code.Status, http.StatusBadRequest, code.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/truthordare/backend/internal/ical"
+)
+
+// maxCalendarRuns caps the runs listed per job and per maintenance window,
+// so jobs running every few minutes do not flood the calendar
+const maxCalendarRuns = 100
+
+// minCalendarRun is the length of runs of jobs that have not run yet, or
+// ran in under a minute, so calendar apps still show them
+const minCalendarRun = time.Minute
+
+// Calendar returns the job runs and maintenance windows starting between
+// from and to, ordered by start. Runs are computed from each job's cron
+// expression and last as long as its last run. Runs skipped because they
+// fall in a maintenance window, or because read-only mode is on, are
+// marked cancelled. Without the scheduler enabled there are no runs.
+func (s *Scheduler) Calendar(from, to time.Time) []ical.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mode := s.mode.Load()
+	var events []ical.Event
+	for i, window := range mode.Windows() {
+		start := window.Next(from.Add(-time.Second))
+		for n := 0; n < maxCalendarRuns && !start.IsZero() && start.Before(to); n++ {
+			events = append(events, ical.Event{
+				UID:         fmt.Sprintf("maintenance-%d-%d@scheduler", i, start.Unix()),
+				Summary:     "Maintenance window",
+				Description: "Public endpoints are offline (" + window.Spec + ")",
+				Start:       start,
+				End:         start.Add(window.Duration),
+			})
+			start = window.Next(start)
+		}
+	}
+
+	if s.cfg.Scheduler.Enabled {
+		for _, job := range s.jobs {
+			schedule := s.cron.Entry(job.entryID).Schedule
+			if schedule == nil {
+				continue
+			}
+			length := max(time.Duration(job.lastDuration.Load())*time.Millisecond, minCalendarRun)
+			start := schedule.Next(from.Add(-time.Second))
+			for n := 0; n < maxCalendarRuns && !start.IsZero() && start.Before(to); n++ {
+				event := ical.Event{
+					UID:         fmt.Sprintf("job-%s-%d@scheduler", job.Name, start.Unix()),
+					Summary:     job.Name,
+					Description: job.Description + " (" + job.CronExpr + ")",
+					Start:       start,
+					End:         start.Add(length),
+				}
+				if s.skipReadOnly(job) {
+					event.Cancelled = true
+					event.Description += ", skipped while read-only mode is on"
+				} else if open, _ := mode.InMaintenance(start); open && job.PauseInMaintenance {
+					event.Cancelled = true
+					event.Description += ", skipped in the maintenance window"
+				}
+				events = append(events, event)
+				start = schedule.Next(start)
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// CategoryRankJob recomputes the smart category order from the tasks
+// served per category over a recent window, per player language and age
+// group and summed over each.
+type CategoryRankJob struct {
+	cfg           *config.SchedulerConfig
+	telemetryRepo *repository.TelemetryRepository
+	rankRepo      *repository.CategoryRankRepository
+	now           func() time.Time
+}
+
+// NewCategoryRankJob creates a new category-rank job.
+func NewCategoryRankJob(
+	cfg *config.SchedulerConfig,
+	telemetryRepo *repository.TelemetryRepository,
+	rankRepo *repository.CategoryRankRepository,
+) *CategoryRankJob {
+	return &CategoryRankJob{
+		cfg:           cfg,
+		telemetryRepo: telemetryRepo,
+		rankRepo:      rankRepo,
+		now:           time.Now,
+	}
+}
+
+// ToJob converts CategoryRankJob to a schedulable Job.
+func (j *CategoryRankJob) ToJob() *Job {
+	return &Job{
+		Name:        "category-rank",
+		Description: "Rank categories by recent plays for the smart category order",
+		CronExpr:    j.cfg.CategoryRankCron,
+		Enabled:     j.cfg.CategoryRankEnabled,
+		Fn:          j.Execute,
+	}
+}
+
+// categoryRankKey identifies a rank row
+type categoryRankKey struct {
+	language, ageGroup, categoryID string
+}
+
+// Execute runs the category-rank job.
+func (j *CategoryRankJob) Execute(ctx context.Context) error {
+	logger := log.With().Str("job", "category-rank").Logger()
+
+	now := j.now().UTC()
+	fromDay := now.AddDate(0, 0, -j.cfg.CategoryRankWindowDays).Format(time.DateOnly)
+
+	plays, err := j.telemetryRepo.SumCategoryPlays(fromDay)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to sum category plays")
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Each count adds to its own key and to the keys summed over the
+	// language, the age group or both
+	sums := make(map[categoryRankKey]int64)
+	for _, p := range plays {
+		if p.CategoryID == "" {
+			continue
+		}
+		for _, language := range uniqueKeys(p.Language) {
+			for _, ageGroup := range uniqueKeys(p.AgeGroup) {
+				sums[categoryRankKey{language, ageGroup, p.CategoryID}] += p.Plays
+			}
+		}
+	}
+
+	ranks := make([]models.CategoryRank, 0, len(sums))
+	for key, count := range sums {
+		ranks = append(ranks, models.CategoryRank{
+			Language:   key.language,
+			AgeGroup:   key.ageGroup,
+			CategoryID: key.categoryID,
+			Plays:      count,
+			ComputedAt: now,
+		})
+	}
+
+	if err := j.rankRepo.Replace(ranks); err != nil {
+		logger.Error().Err(err).Msg("Failed to store category ranks")
+		return err
+	}
+
+	logger.Info().
+		Str("from_day", fromDay).
+		Int("ranks", len(ranks)).
+		Msg("Category-rank job completed")
+	return nil
+}
+
+// uniqueKeys returns the keys a dimension value counts for: itself and the
+// empty key summing over all values
+func uniqueKeys(value string) []string {
+	if value == "" {
+		return []string{""}
+	}
+	return []string{value, ""}
+}
@@ -7,20 +7,36 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/repository"
 	"gorm.io/gorm"
 )
 
+// sqliteAutoVacuumIncremental is the value PRAGMA auto_vacuum reports for
+// incremental mode.
+const sqliteAutoVacuumIncremental = 2
+
+// cleanupTables are the tables whose soft-deleted rows the cleanup job
+// purges and vacuums, dependents before the tables they reference.
+var cleanupTables = []string{"tasks", "categories", "glossary_terms", "chat_draws", "chat_workspaces"}
+
+// retainedTables soft delete rows but are never purged: consent records,
+// reports, generation runs and import jobs back audits, and game sessions
+// are deleted when they expire.
+var retainedTables = []string{"consent_records", "task_reports", "generation_runs", "import_jobs", "game_sessions"}
+
 // CleanupJob handles cleanup of deprecated/soft-deleted data.
 type CleanupJob struct {
-	db  *gorm.DB
-	cfg *config.SchedulerConfig
+	db       *gorm.DB
+	cfg      *config.SchedulerConfig
+	taskRepo *repository.TaskRepository
 }
 
 // NewCleanupJob creates a new cleanup job.
 func NewCleanupJob(db *gorm.DB, cfg *config.SchedulerConfig) *CleanupJob {
 	return &CleanupJob{
-		db:  db,
-		cfg: cfg,
+		db:       db,
+		cfg:      cfg,
+		taskRepo: repository.NewTaskRepository(db),
 	}
 }
 
@@ -28,7 +44,7 @@ func NewCleanupJob(db *gorm.DB, cfg *config.SchedulerConfig) *CleanupJob {
 func (c *CleanupJob) ToJob() *Job {
 	return &Job{
 		Name:        "cleanup",
-		Description: "Clean up soft-deleted data older than retention period and run VACUUM",
+		Description: "Clean up soft-deleted data older than retention period and reclaim disk space",
 		CronExpr:    c.cfg.CleanupCron,
 		Enabled:     c.cfg.CleanupEnabled,
 		Fn:          c.Execute,
@@ -50,26 +66,36 @@ func (c *CleanupJob) Execute(ctx context.Context) error {
 	// Track cleanup statistics
 	var stats CleanupStats
 
-	// Clean up tasks
-	taskResult, err := c.cleanupTable(ctx, "tasks", cutoffDate)
-	if err != nil {
-		logger.Error().Err(err).Msg("Failed to cleanup tasks table")
-		return err
+	// Retire inactive tasks from combinations over the cap first, so the
+	// purge below can eventually remove them for good
+	if c.cfg.CleanupEvictOverCap && c.cfg.TaskCapPerCategoryLanguage > 0 {
+		evicted, err := c.evictOverCap(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to evict tasks over cap")
+			return err
+		}
+		stats.TasksEvicted = evicted
+		logger.Info().
+			Int("cap", c.cfg.TaskCapPerCategoryLanguage).
+			Int64("tasks_evicted", evicted).
+			Msg("Inactive tasks over category cap retired")
 	}
-	stats.TasksDeleted = taskResult
 
-	// Clean up categories
-	categoryResult, err := c.cleanupTable(ctx, "categories", cutoffDate)
-	if err != nil {
-		logger.Error().Err(err).Msg("Failed to cleanup categories table")
-		return err
+	stats.RowsDeleted = make(map[string]int64, len(cleanupTables))
+	for _, table := range cleanupTables {
+		deleted, err := c.cleanupTable(ctx, table, cutoffDate)
+		if err != nil {
+			logger.Error().Err(err).Str("table", table).Msg("Failed to cleanup table")
+			return err
+		}
+		stats.RowsDeleted[table] = deleted
+		logger.Info().
+			Str("table", table).
+			Int64("rows_deleted", deleted).
+			Msg("Soft-deleted records permanently removed")
 	}
-	stats.CategoriesDeleted = categoryResult
-
-	logger.Info().
-		Int64("tasks_deleted", stats.TasksDeleted).
-		Int64("categories_deleted", stats.CategoriesDeleted).
-		Msg("Soft-deleted records permanently removed")
+	stats.TasksDeleted = stats.RowsDeleted["tasks"]
+	stats.CategoriesDeleted = stats.RowsDeleted["categories"]
 
 	// Run VACUUM to reclaim disk space
 	if err := c.runVacuum(ctx); err != nil {
@@ -81,6 +107,36 @@ func (c *CleanupJob) Execute(ctx context.Context) error {
 	return nil
 }
 
+// evictOverCap soft-deletes inactive tasks from every category+language
+// combination above the task cap, down to the cap where enough inactive
+// tasks exist. Active tasks are never evicted.
+func (c *CleanupJob) evictOverCap(ctx context.Context) (int64, error) {
+	limit := c.cfg.TaskCapPerCategoryLanguage
+
+	over, err := c.taskRepo.FindOverCap(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var evicted int64
+	for _, combo := range over {
+		if err := ctx.Err(); err != nil {
+			return evicted, err
+		}
+
+		ids, err := c.taskRepo.FindEvictable(combo.CategoryID, combo.Language, int(combo.Count)-limit)
+		if err != nil {
+			return evicted, err
+		}
+		n, err := c.taskRepo.DeleteMany(ids)
+		if err != nil {
+			return evicted, err
+		}
+		evicted += n
+	}
+	return evicted, nil
+}
+
 // cleanupTable permanently deletes soft-deleted records older than cutoff date.
 func (c *CleanupJob) cleanupTable(ctx context.Context, tableName string, cutoffDate time.Time) (int64, error) {
 	// Use raw SQL to permanently delete soft-deleted records
@@ -97,10 +153,25 @@ func (c *CleanupJob) cleanupTable(ctx context.Context, tableName string, cutoffD
 	return result.RowsAffected, nil
 }
 
-// runVacuum runs SQLite VACUUM command to reclaim disk space.
+// runVacuum reclaims disk space and refreshes planner statistics using the
+// strategy appropriate for the active database dialect.
 func (c *CleanupJob) runVacuum(ctx context.Context) error {
+	switch c.db.Dialector.Name() {
+	case "postgres":
+		return c.runPostgresVacuum(ctx)
+	default:
+		return c.runSQLiteVacuum(ctx)
+	}
+}
+
+// runSQLiteVacuum reclaims free pages using incremental vacuum.
+//
+// A plain VACUUM rewrites the whole file and holds an exclusive lock for the
+// duration, blocking all writers. With auto_vacuum=INCREMENTAL the freelist
+// can be trimmed in place instead. Databases created before incremental mode
+// was enabled are converted once; that conversion requires a full VACUUM.
+func (c *CleanupJob) runSQLiteVacuum(ctx context.Context) error {
 	logger := log.With().Str("job", "cleanup").Logger()
-	logger.Info().Msg("Running VACUUM to reclaim disk space")
 
 	// Get underlying SQL DB
 	sqlDB, err := c.db.DB()
@@ -108,18 +179,36 @@ func (c *CleanupJob) runVacuum(ctx context.Context) error {
 		return err
 	}
 
-	// Get database size before VACUUM (for SQLite)
+	// Get database size before vacuum
 	var sizeBefore int64
 	row := sqlDB.QueryRowContext(ctx, "SELECT page_count * page_size as size FROM pragma_page_count(), pragma_page_size()")
 	_ = row.Scan(&sizeBefore)
 
-	// Run VACUUM - must be executed outside of a transaction
-	_, err = sqlDB.ExecContext(ctx, "VACUUM")
-	if err != nil {
+	var autoVacuum int
+	if err := sqlDB.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
 		return err
 	}
 
-	// Get database size after VACUUM
+	if autoVacuum != sqliteAutoVacuumIncremental {
+		logger.Warn().
+			Int("auto_vacuum", autoVacuum).
+			Msg("Converting database to incremental auto_vacuum; this one-time VACUUM blocks writes")
+
+		if _, err := sqlDB.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+			return err
+		}
+		// VACUUM must be executed outside of a transaction
+		if _, err := sqlDB.ExecContext(ctx, "VACUUM"); err != nil {
+			return err
+		}
+	} else {
+		logger.Info().Msg("Running incremental vacuum to reclaim disk space")
+		if _, err := sqlDB.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+			return err
+		}
+	}
+
+	// Get database size after vacuum
 	var sizeAfter int64
 	row = sqlDB.QueryRowContext(ctx, "SELECT page_count * page_size as size FROM pragma_page_count(), pragma_page_size()")
 	_ = row.Scan(&sizeAfter)
@@ -134,10 +223,35 @@ func (c *CleanupJob) runVacuum(ctx context.Context) error {
 	return nil
 }
 
+// runPostgresVacuum runs VACUUM ANALYZE on each table touched by the cleanup.
+// Postgres vacuum does not take an exclusive lock, so it is safe to run while
+// the API is serving traffic.
+func (c *CleanupJob) runPostgresVacuum(ctx context.Context) error {
+	logger := log.With().Str("job", "cleanup").Logger()
+
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+
+	for _, table := range cleanupTables {
+		logger.Info().Str("table", table).Msg("Running VACUUM ANALYZE")
+		// VACUUM cannot run inside a transaction block
+		if _, err := sqlDB.ExecContext(ctx, "VACUUM ANALYZE "+table); err != nil {
+			return err
+		}
+	}
+
+	logger.Info().Msg("VACUUM ANALYZE completed")
+	return nil
+}
+
 // CleanupStats holds statistics from the cleanup job.
 type CleanupStats struct {
+	TasksEvicted      int64
 	TasksDeleted      int64
 	CategoriesDeleted int64
+	RowsDeleted       map[string]int64 // By table, tasks and categories included
 	SpaceSavedBytes   int64
 }
 
@@ -151,35 +265,29 @@ func (c *CleanupJob) GetCleanupPreview(ctx context.Context) (*CleanupPreview, er
 		RetentionMonths: retentionMonths,
 	}
 
-	// Count tasks to be deleted
-	var taskCount int64
-	err := c.db.WithContext(ctx).Raw(
-		"SELECT COUNT(*) FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?",
-		cutoffDate,
-	).Scan(&taskCount).Error
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
-	}
-	preview.TasksToDelete = taskCount
-
-	// Count categories to be deleted
-	var categoryCount int64
-	err = c.db.WithContext(ctx).Raw(
-		"SELECT COUNT(*) FROM categories WHERE deleted_at IS NOT NULL AND deleted_at < ?",
-		cutoffDate,
-	).Scan(&categoryCount).Error
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
+	preview.RowsToDelete = make(map[string]int64, len(cleanupTables))
+	for _, table := range cleanupTables {
+		var count int64
+		err := c.db.WithContext(ctx).Raw(
+			"SELECT COUNT(*) FROM "+table+" WHERE deleted_at IS NOT NULL AND deleted_at < ?",
+			cutoffDate,
+		).Scan(&count).Error
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		preview.RowsToDelete[table] = count
 	}
-	preview.CategoriesToDelete = categoryCount
+	preview.TasksToDelete = preview.RowsToDelete["tasks"]
+	preview.CategoriesToDelete = preview.RowsToDelete["categories"]
 
 	return preview, nil
 }
 
 // CleanupPreview shows what would be cleaned up without actually doing it.
 type CleanupPreview struct {
-	CutoffDate         time.Time `json:"cutoff_date"`
-	RetentionMonths    int       `json:"retention_months"`
-	TasksToDelete      int64     `json:"tasks_to_delete"`
-	CategoriesToDelete int64     `json:"categories_to_delete"`
+	CutoffDate         time.Time        `json:"cutoff_date"`
+	RetentionMonths    int              `json:"retention_months"`
+	TasksToDelete      int64            `json:"tasks_to_delete"`
+	CategoriesToDelete int64            `json:"categories_to_delete"`
+	RowsToDelete       map[string]int64 `json:"rows_to_delete"` // By table, tasks and categories included
 }
@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// digestPeriod is the look-back window of the weekly digest.
+const digestPeriod = 7 * 24 * time.Hour
+
+// digestTextLength bounds the task text quoted in the digest.
+const digestTextLength = 80
+
+// DigestJob compiles a weekly content digest and sends it to the
+// configured recipients through the admin notifier.
+type DigestJob struct {
+	cfg        *config.SchedulerConfig
+	taskRepo   *repository.TaskRepository
+	reportRepo *repository.ReportRepository
+	runRepo    *repository.GenerationRunRepository
+	notifier   notify.Notifier
+	now        func() time.Time
+}
+
+// NewDigestJob creates a new weekly digest job.
+func NewDigestJob(
+	cfg *config.SchedulerConfig,
+	taskRepo *repository.TaskRepository,
+	reportRepo *repository.ReportRepository,
+	runRepo *repository.GenerationRunRepository,
+	notifier notify.Notifier,
+) *DigestJob {
+	return &DigestJob{
+		cfg:        cfg,
+		taskRepo:   taskRepo,
+		reportRepo: reportRepo,
+		runRepo:    runRepo,
+		notifier:   notifier,
+		now:        time.Now,
+	}
+}
+
+// ToJob converts DigestJob to a schedulable Job.
+func (d *DigestJob) ToJob() *Job {
+	return &Job{
+		Name:        "weekly-digest",
+		Description: "Send the weekly content digest: new tasks, moderation backlog, top reported tasks and AI usage",
+		CronExpr:    d.cfg.DigestCron,
+		Enabled:     d.cfg.DigestEnabled,
+		Fn:          d.Execute,
+	}
+}
+
+// Digest summarizes content activity over a period.
+type Digest struct {
+	From time.Time
+	To   time.Time
+	// NewTasks counts the tasks created in the period by source
+	// (repository.TaskSourceGenerated or repository.TaskSourceManual).
+	NewTasks map[string]int64
+	// Moderation backlog as of the end of the period
+	PendingReview int64
+	OpenReports   int64
+	ReportedTasks int64
+	// TopReported are the tasks reported most in the period
+	TopReported []DigestReportedTask
+	// AIUsage sums the generation runs started in the period
+	AIUsage repository.GenerationUsage
+}
+
+// DigestReportedTask is a reported task in the digest. Text and Language
+// are empty when the task has since been deleted.
+type DigestReportedTask struct {
+	TaskID   string
+	Text     string
+	Language string
+	Reports  int64
+}
+
+// Execute builds the digest for the past week and sends it.
+func (d *DigestJob) Execute(ctx context.Context) error {
+	logger := log.With().Str("job", "weekly-digest").Logger()
+
+	digest, err := d.Build(d.now().UTC())
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to build digest")
+		return err
+	}
+
+	if err := d.notifier.Notify(ctx, digest.Event(d.cfg.DigestRecipients)); err != nil {
+		logger.Error().Err(err).Msg("Failed to send digest")
+		return err
+	}
+
+	logger.Info().
+		Int("recipients", len(d.cfg.DigestRecipients)).
+		Msg("Weekly digest sent")
+	return nil
+}
+
+// Build compiles the digest for the week ending at now.
+func (d *DigestJob) Build(now time.Time) (*Digest, error) {
+	digest := &Digest{From: now.Add(-digestPeriod), To: now}
+
+	var err error
+	if digest.NewTasks, err = d.taskRepo.CountCreatedBySource(digest.From); err != nil {
+		return nil, fmt.Errorf("failed to count new tasks: %w", err)
+	}
+	if digest.PendingReview, err = d.taskRepo.Count(&repository.TaskFilter{ReviewState: models.ReviewStatePending}); err != nil {
+		return nil, fmt.Errorf("failed to count pending reviews: %w", err)
+	}
+	if digest.OpenReports, digest.ReportedTasks, err = d.reportRepo.CountOpen(); err != nil {
+		return nil, fmt.Errorf("failed to count open reports: %w", err)
+	}
+	if digest.AIUsage, err = d.runRepo.UsageSince(digest.From); err != nil {
+		return nil, fmt.Errorf("failed to sum generation runs: %w", err)
+	}
+
+	if d.cfg.DigestTopReported > 0 {
+		reported, err := d.reportRepo.FindTopReported(digest.From, d.cfg.DigestTopReported)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find top reported tasks: %w", err)
+		}
+		ids := make([]string, len(reported))
+		for i, r := range reported {
+			ids[i] = r.TaskID
+		}
+		tasks, err := d.taskRepo.FindByIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load reported tasks: %w", err)
+		}
+		byID := make(map[string]*models.Task, len(tasks))
+		for i := range tasks {
+			byID[tasks[i].ID] = &tasks[i]
+		}
+		for _, r := range reported {
+			entry := DigestReportedTask{TaskID: r.TaskID, Reports: r.Count}
+			if task, ok := byID[r.TaskID]; ok {
+				entry.Text = task.Text
+				entry.Language = task.Language
+			}
+			digest.TopReported = append(digest.TopReported, entry)
+		}
+	}
+
+	return digest, nil
+}
+
+// Event renders the digest as a notification for the given recipients.
+func (d *Digest) Event(recipients []string) notify.Event {
+	generated := d.NewTasks[repository.TaskSourceGenerated]
+	manual := d.NewTasks[repository.TaskSourceManual]
+	from, to := d.From.Format(time.DateOnly), d.To.Format(time.DateOnly)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "New tasks: %d (%d generated, %d manual)\n", generated+manual, generated, manual)
+	fmt.Fprintf(&b, "Moderation backlog: %d tasks pending review, %d open reports on %d tasks\n",
+		d.PendingReview, d.OpenReports, d.ReportedTasks)
+	fmt.Fprintf(&b, "AI usage: %d runs (%d failed), %d tasks created, %d tokens (%d prompt, %d completion)\n",
+		d.AIUsage.Runs, d.AIUsage.FailedRuns, d.AIUsage.TasksCreated,
+		d.AIUsage.TotalTokens, d.AIUsage.PromptTokens, d.AIUsage.CompletionTokens)
+	if len(d.TopReported) == 0 {
+		b.WriteString("Top reported: none")
+	} else {
+		b.WriteString("Top reported:")
+		for _, task := range d.TopReported {
+			text := "(deleted)"
+			if task.Language != "" {
+				text = fmt.Sprintf("%q [%s]", truncate(task.Text, digestTextLength), task.Language)
+			}
+			fmt.Fprintf(&b, "\n  %d reports: %s %s", task.Reports, task.TaskID, text)
+		}
+	}
+
+	return notify.Event{
+		Type:    notify.EventWeeklyDigest,
+		Message: fmt.Sprintf("Weekly content digest %s to %s", from, to),
+		Fields: map[string]string{
+			"new_tasks":      strconv.FormatInt(generated+manual, 10),
+			"pending_review": strconv.FormatInt(d.PendingReview, 10),
+			"open_reports":   strconv.FormatInt(d.OpenReports, 10),
+			"ai_tokens":      strconv.FormatInt(d.AIUsage.TotalTokens, 10),
+		},
+		Body:       b.String(),
+		Recipients: recipients,
+		Time:       d.To,
+	}
+}
+
+// truncate shortens text to at most n runes, marking the cut with an ellipsis.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/diskspace"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/notify"
+)
+
+// diskGuardReason starts the read-only reason the disk guard sets, so it
+// only switches off read-only mode it switched on itself.
+const diskGuardReason = "disk guard"
+
+// DiskGuardJob watches the free space next to the SQLite database. A write
+// to a full disk can leave SQLite's journal half written, so below
+// DiskGuardMinFreeMB the job switches the instance to read-only mode and
+// alerts admins. Once twice that is free again it switches back.
+type DiskGuardJob struct {
+	cfg      *config.SchedulerConfig
+	path     string
+	mode     func() *maintenance.Mode
+	notifier notify.Notifier
+	usage    func(path string) (diskspace.Usage, error)
+	// low is set while free space is below the threshold
+	low bool
+}
+
+// NewDiskGuardJob creates a new disk guard job for the database at path,
+// switching the read-only mode that mode returns at the time of a run.
+func NewDiskGuardJob(cfg *config.SchedulerConfig, path string, mode func() *maintenance.Mode, notifier notify.Notifier) *DiskGuardJob {
+	return &DiskGuardJob{
+		cfg:      cfg,
+		path:     path,
+		mode:     mode,
+		notifier: notifier,
+		usage:    diskspace.Of,
+	}
+}
+
+// ToJob converts DiskGuardJob to a schedulable Job. In-memory databases
+// have no disk to guard.
+func (j *DiskGuardJob) ToJob() *Job {
+	return &Job{
+		Name:         "disk-guard",
+		Description:  "Switch to read-only mode when the database disk runs low on free space",
+		CronExpr:     j.cfg.DiskGuardCron,
+		Enabled:      j.cfg.DiskGuardEnabled && j.path != "",
+		ReadOnlySafe: true,
+		Fn:           j.Execute,
+	}
+}
+
+// Execute runs the disk guard job.
+func (j *DiskGuardJob) Execute(ctx context.Context) error {
+	logger := log.With().Str("job", "disk-guard").Logger()
+
+	usage, err := j.usage(j.path)
+	if errors.Is(err, diskspace.ErrUnsupported) {
+		logger.Debug().Msg("Disk space unavailable on this platform")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read disk space: %w", err)
+	}
+
+	minFree := uint64(j.cfg.DiskGuardMinFreeMB) << 20
+	mode := j.mode()
+	fields := map[string]string{
+		"path":        j.path,
+		"free_mb":     fmt.Sprint(usage.Free >> 20),
+		"min_free_mb": fmt.Sprint(j.cfg.DiskGuardMinFreeMB),
+	}
+
+	switch {
+	case usage.Free < minFree && !j.low:
+		j.low = true
+		message := fmt.Sprintf("Database disk has %d MiB free, below %d MiB", usage.Free>>20, j.cfg.DiskGuardMinFreeMB)
+		// Keep the reason of read-only mode an admin switched on
+		if mode != nil && !mode.ReadOnly() {
+			mode.Set(true, fmt.Sprintf("%s: %d MiB free on the database disk", diskGuardReason, usage.Free>>20))
+			message += "; switched to read-only mode"
+		}
+		logger.Error().Uint64("free_bytes", usage.Free).Msg(message)
+		return j.notifier.Notify(ctx, notify.Event{Type: notify.EventDiskSpaceLow, Message: message, Fields: fields})
+
+	case usage.Free >= 2*minFree && j.low:
+		j.low = false
+		message := fmt.Sprintf("Database disk has %d MiB free again", usage.Free>>20)
+		if mode != nil && strings.HasPrefix(mode.Status().Reason, diskGuardReason) {
+			mode.Set(false, "")
+			message += "; switched off read-only mode"
+		}
+		logger.Info().Uint64("free_bytes", usage.Free).Msg(message)
+		return j.notifier.Notify(ctx, notify.Event{Type: notify.EventDiskSpaceOK, Message: message, Fields: fields})
+	}
+
+	logger.Debug().Uint64("free_bytes", usage.Free).Msg("Disk space checked")
+	return nil
+}
@@ -19,8 +19,12 @@ import (
 type AutoGenerateJob struct {
 	db           *gorm.DB
 	cfg          *config.SchedulerConfig
+	genCfg       *config.GenerationConfig
 	categoryRepo *repository.CategoryRepository
 	taskRepo     *repository.TaskRepository
+	runRepo      *repository.GenerationRunRepository
+	glossaryRepo *repository.GlossaryRepository
+	languageRepo *repository.LanguageRepository
 	aiClient     *ai.Client
 	promptLoader *prompts.PromptLoader
 }
@@ -29,14 +33,19 @@ type AutoGenerateJob struct {
 func NewAutoGenerateJob(
 	db *gorm.DB,
 	cfg *config.SchedulerConfig,
+	genCfg *config.GenerationConfig,
 	categoryRepo *repository.CategoryRepository,
 	taskRepo *repository.TaskRepository,
 ) *AutoGenerateJob {
 	return &AutoGenerateJob{
 		db:           db,
 		cfg:          cfg,
+		genCfg:       genCfg,
 		categoryRepo: categoryRepo,
 		taskRepo:     taskRepo,
+		runRepo:      repository.NewGenerationRunRepository(db),
+		glossaryRepo: repository.NewGlossaryRepository(db),
+		languageRepo: repository.NewLanguageRepository(db),
 		aiClient:     ai.GetClient(),
 		promptLoader: prompts.GetLoader(),
 	}
@@ -49,7 +58,9 @@ func (a *AutoGenerateJob) ToJob() *Job {
 		Description: "Generate tasks for all category+language combinations",
 		CronExpr:    a.cfg.AutoGenerateCron,
 		Enabled:     a.cfg.AutoGenerateEnabled,
-		Fn:          a.Execute,
+		// Generation is heavy on the database and the AI provider
+		PauseInMaintenance: true,
+		Fn:                 a.Execute,
 	}
 }
 
@@ -85,9 +96,29 @@ func (a *AutoGenerateJob) Execute(ctx context.Context) error {
 		return nil
 	}
 
+	glossary, err := a.glossaryRepo.FindAll()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch glossary")
+		return err
+	}
+
+	frozen, err := a.languageRepo.FrozenLanguages()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch frozen languages")
+		return err
+	}
+	var languages []string
+	for _, language := range models.SupportedLanguages {
+		if frozen[language] {
+			logger.Info().Str("language", language).Msg("Skipping frozen language")
+			continue
+		}
+		languages = append(languages, language)
+	}
+
 	logger.Info().
 		Int("categories", len(categories)).
-		Int("languages", len(models.SupportedLanguages)).
+		Int("languages", len(languages)).
 		Msg("Starting task generation")
 
 	// Track statistics
@@ -95,6 +126,16 @@ func (a *AutoGenerateJob) Execute(ctx context.Context) error {
 		StartTime: time.Now(),
 	}
 
+	// The run report is best effort; generation goes ahead without it
+	run := &models.GenerationRun{
+		Trigger:   models.GenerationTriggerScheduled,
+		Status:    models.GenerationRunRunning,
+		StartedAt: stats.StartTime.UTC(),
+	}
+	if err := a.runRepo.Create(run); err != nil {
+		logger.Warn().Err(err).Msg("Failed to record generation run")
+	}
+
 	// Process each category
 	for _, category := range categories {
 		// Determine age group for the category
@@ -104,16 +145,31 @@ func (a *AutoGenerateJob) Execute(ctx context.Context) error {
 		}
 
 		// Process each language
-		for _, language := range models.SupportedLanguages {
+		for _, language := range languages {
 			select {
 			case <-ctx.Done():
 				logger.Warn().Msg("Auto-generate job cancelled")
+				a.finishRun(run, ctx.Err())
 				return ctx.Err()
 			default:
 			}
 
-			result := a.generateForCombination(ctx, &category, language, ageGroup)
+			room := a.room(category.ID, language)
+			if room == 0 {
+				stats.SkippedCount++
+				run.AddCombination(models.GenerationCombination{
+					CategoryID:   category.ID,
+					CategoryName: category.Label.Get("en"),
+					AgeGroup:     ageGroup,
+					Language:     language,
+					SkippedAtCap: true,
+				})
+				continue
+			}
+
+			result := a.generateForCombination(ctx, &category, language, ageGroup, glossary, room, run)
 			stats.TotalAttempts++
+			run.AddCombination(result.Combination)
 
 			if result.Success {
 				stats.SuccessCount++
@@ -126,31 +182,70 @@ func (a *AutoGenerateJob) Execute(ctx context.Context) error {
 					Error:      result.Error,
 				})
 			}
-
-			// Small delay between API calls to avoid rate limiting
-			time.Sleep(500 * time.Millisecond)
 		}
 	}
 
 	stats.EndTime = time.Now()
 	stats.Duration = stats.EndTime.Sub(stats.StartTime)
+	a.finishRun(run, nil)
 
 	logger.Info().
 		Int("total_attempts", stats.TotalAttempts).
 		Int("success_count", stats.SuccessCount).
 		Int("failure_count", stats.FailureCount).
+		Int("skipped_at_cap", stats.SkippedCount).
 		Int("tasks_created", stats.TasksCreated).
 		Dur("duration", stats.Duration).
+		Str("run_id", run.ID).
 		Msg("Auto-generate job completed")
 
 	return nil
 }
 
+// finishRun closes the run and stores its report, if it was recorded.
+func (a *AutoGenerateJob) finishRun(run *models.GenerationRun, err error) {
+	if run.ID == "" {
+		return
+	}
+	run.Finish(err)
+	if err := a.runRepo.Save(run); err != nil {
+		log.Warn().Err(err).Str("job", "auto-generate").Str("run_id", run.ID).Msg("Failed to save generation run report")
+	}
+}
+
+// room returns how many more tasks a category+language may hold under the
+// configured cap: 0 at the cap, -1 when there is no cap. Count errors are
+// logged and do not block generation.
+func (a *AutoGenerateJob) room(categoryID, language string) int {
+	limit := a.cfg.TaskCapPerCategoryLanguage
+	if limit <= 0 {
+		return -1
+	}
+
+	count, err := a.taskRepo.Count(&repository.TaskFilter{CategoryID: categoryID, Language: language})
+	if err != nil {
+		log.Warn().Err(err).Str("category_id", categoryID).Str("language", language).Msg("Failed to count tasks for cap check")
+		return -1
+	}
+	if count >= int64(limit) {
+		log.Info().
+			Str("job", "auto-generate").
+			Str("category_id", categoryID).
+			Str("language", language).
+			Int64("tasks", count).
+			Int("cap", limit).
+			Msg("Category at task cap, skipping generation")
+		return 0
+	}
+	return limit - int(count)
+}
+
 // GenerateResult represents the result of a single generation attempt.
 type GenerateResult struct {
 	Success      bool
 	TasksCreated int
 	Error        string
+	Combination  models.GenerationCombination
 }
 
 // generateForCombination generates tasks for a specific category+language combination with retry logic.
@@ -159,6 +254,9 @@ func (a *AutoGenerateJob) generateForCombination(
 	category *models.Category,
 	language string,
 	ageGroup string,
+	glossary models.Glossary,
+	room int,
+	run *models.GenerationRun,
 ) GenerateResult {
 	logger := log.With().
 		Str("job", "auto-generate").
@@ -169,34 +267,57 @@ func (a *AutoGenerateJob) generateForCombination(
 		Logger()
 
 	maxRetries := a.cfg.AutoGenerateRetryMax
-	retryDelay := time.Duration(a.cfg.AutoGenerateRetryDelaySeconds) * time.Second
 	count := a.cfg.AutoGenerateCount
+	// Ask for no more truths and dares than fit under the cap
+	if room > 0 && 2*count > room {
+		count = (room + 1) / 2
+	}
+
+	// Tokens spent count across retries
+	started := time.Now()
+	var usage ai.Usage
+	combination := models.GenerationCombination{
+		CategoryID:   category.ID,
+		CategoryName: category.Label.Get("en"),
+		AgeGroup:     ageGroup,
+		Language:     language,
+	}
+	finish := func(result GenerateResult) GenerateResult {
+		combination.Error = result.Error
+		combination.TotalTokens = usage.TotalTokens
+		combination.DurationMs = time.Since(started).Milliseconds()
+		run.PromptTokens += usage.PromptTokens
+		run.CompletionTokens += usage.CompletionTokens
+		run.TotalTokens += usage.TotalTokens
+		result.Combination = combination
+		return result
+	}
 
 	var lastError error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		select {
 		case <-ctx.Done():
-			return GenerateResult{Success: false, Error: "context cancelled"}
+			return finish(GenerateResult{Success: false, Error: "context cancelled"})
 		default:
 		}
 
+		// No pause between attempts: the AI client's shared rate limiter
+		// paces every call, retries included
 		if attempt > 1 {
 			logger.Info().
 				Int("attempt", attempt).
 				Int("max_retries", maxRetries).
-				Dur("delay", retryDelay).
-				Msg("Retrying after delay")
-			time.Sleep(retryDelay)
+				Msg("Retrying")
 		}
 
-		result, err := a.doGenerate(ctx, category, language, ageGroup, count)
+		result, err := a.doGenerate(ctx, category, language, ageGroup, glossary, count, room, run.ID, &usage, &combination)
 		if err == nil {
 			logger.Info().
 				Int("tasks_created", result.TasksCreated).
 				Int("attempt", attempt).
 				Msg("Generation successful")
-			return result
+			return finish(result)
 		}
 
 		lastError = err
@@ -223,19 +344,28 @@ func (a *AutoGenerateJob) generateForCombination(
 		Int("attempts", maxRetries).
 		Msg("All generation attempts failed")
 
-	return GenerateResult{
+	return finish(GenerateResult{
 		Success: false,
 		Error:   errorMsg,
-	}
+	})
 }
 
-// doGenerate performs the actual generation.
+// doGenerate performs the actual generation, adding token usage and
+// outcome counts to usage and combination. Texts in another language are
+// rejected or flagged per the language check, and tasks violating the
+// glossary are created with a reviewer note. At most room tasks are
+// created, unless room is negative.
 func (a *AutoGenerateJob) doGenerate(
 	ctx context.Context,
 	category *models.Category,
 	language string,
 	ageGroup string,
+	glossary models.Glossary,
 	count int,
+	room int,
+	runID string,
+	usage *ai.Usage,
+	combination *models.GenerationCombination,
 ) (GenerateResult, error) {
 	// Determine explicit mode based on category
 	explicitMode := category.RequiresConsent
@@ -250,15 +380,23 @@ func (a *AutoGenerateJob) doGenerate(
 		categoryName = category.Label.Get(language)
 	}
 
+	// Existing tasks as few-shot examples for consistent tone
+	truthExamples, dareExamples, err := a.taskRepo.FindExampleTexts(category.ID, language, a.genCfg.ExampleCount, a.genCfg.ExampleStrategy)
+	if err != nil {
+		return GenerateResult{}, err
+	}
+
 	// Load and prepare the prompt
-	prompt, err := a.promptLoader.LoadAndReplace(
-		"generate_tasks",
+	placeholders := []prompts.Placeholder{
 		prompts.P("AGE_GROUP", ageGroup),
 		prompts.P("CATEGORY", categoryName),
 		prompts.P("LANGUAGE", language),
 		prompts.P("COUNT", strconv.Itoa(count)),
 		prompts.P("EXPLICIT_MODE", explicitStr),
-	)
+		prompts.P("EXAMPLES", prompts.FormatExamples(truthExamples, dareExamples)),
+		prompts.P("GLOSSARY", glossary.Format(language)),
+	}
+	prompt, err := a.promptLoader.LoadAndReplace("generate_tasks", placeholders...)
 	if err != nil {
 		return GenerateResult{}, err
 	}
@@ -270,52 +408,73 @@ func (a *AutoGenerateJob) doGenerate(
 
 	var content GeneratedContent
 	err = a.aiClient.CompleteJSON(messages, &content,
+		ai.WithContext(ctx),
+		ai.WithTimeout(time.Duration(a.cfg.AutoGenerateTimeoutSeconds)*time.Second),
+		ai.WithPrompt("generate_tasks", placeholders...),
 		ai.WithTemperature(0.8),
 		ai.WithMaxTokens(2000),
+		ai.WithUsage(usage),
 	)
 	if err != nil {
 		return GenerateResult{}, err
 	}
 
-	// Save generated tasks to database
-	tasksCreated := 0
+	combination.Truths = len(content.Truths)
+	combination.Dares = len(content.Dares)
 
-	// Save truths
+	// Save generated tasks to database, truths first
+	tasks := make([]models.Task, 0, len(content.Truths)+len(content.Dares))
 	for _, truth := range content.Truths {
-		task := &models.Task{
-			CategoryID: category.ID,
-			Type:       models.TaskTypeTruth,
-			Text:       truth,
-			Language:   language,
-		}
-		task.ID = uuid.New().String()
-
-		if err := a.taskRepo.Create(task); err == nil {
-			tasksCreated++
-		}
+		tasks = append(tasks, a.newTask(category.ID, models.TaskTypeTruth, truth, language, runID))
 	}
-
-	// Save dares
 	for _, dare := range content.Dares {
-		task := &models.Task{
-			CategoryID: category.ID,
-			Type:       models.TaskTypeDare,
-			Text:       dare,
-			Language:   language,
+		tasks = append(tasks, a.newTask(category.ID, models.TaskTypeDare, dare, language, runID))
+	}
+	tasks, combination.LanguageMismatches = models.CheckLanguages(tasks, a.genCfg.LanguageCheck)
+	if room >= 0 && len(tasks) > room {
+		tasks = tasks[:room]
+	}
+	flagged := make(map[string]bool)
+	for i := range tasks {
+		if glossary.Flag(&tasks[i], "", "") {
+			flagged[tasks[i].ID] = true
 		}
-		task.ID = uuid.New().String()
-
-		if err := a.taskRepo.Create(task); err == nil {
-			tasksCreated++
+	}
+	created, duplicates, rejected, err := a.taskRepo.CreateGenerated(tasks, a.genCfg.TextRules.For(category.AgeGroup))
+	if err != nil {
+		return GenerateResult{}, err
+	}
+	combination.Created = len(created)
+	combination.DuplicatesSkipped = duplicates
+	combination.Rejected = rejected
+	for _, task := range created {
+		if flagged[task.ID] {
+			combination.GlossaryFlagged++
 		}
 	}
 
 	return GenerateResult{
 		Success:      true,
-		TasksCreated: tasksCreated,
+		TasksCreated: len(created),
 	}, nil
 }
 
+// newTask builds a generated task queued for review.
+func (a *AutoGenerateJob) newTask(categoryID, taskType, text, language, runID string) models.Task {
+	task := models.Task{
+		CategoryID:      categoryID,
+		Type:            taskType,
+		Text:            text,
+		Language:        language,
+		RolloutPercent:  a.genCfg.InitialRollout(),
+		ReviewState:     models.ReviewStatePending,
+		GenerationRunID: runID,
+	}
+	task.ID = uuid.New().String()
+	task.MarkMachine(language)
+	return task
+}
+
 // isRetryableError checks if an error is retryable (e.g., rate limit).
 func isRetryableError(err error) bool {
 	if err == nil {
@@ -369,6 +528,7 @@ type GenerateStats struct {
 	TotalAttempts int
 	SuccessCount  int
 	FailureCount  int
+	SkippedCount  int
 	TasksCreated  int
 	Errors        []GenerateError
 }
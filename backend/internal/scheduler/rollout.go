@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+)
+
+// rolloutPromoteBatch bounds the tasks examined per run.
+const rolloutPromoteBatch = 500
+
+// RolloutPromoteJob moves staged tasks into full rotation once they have
+// been served for the configured period without any open player reports.
+// Tasks with reports stay staged; enough reports deactivate them instead.
+type RolloutPromoteJob struct {
+	cfg        *config.SchedulerConfig
+	taskRepo   *repository.TaskRepository
+	reportRepo *repository.ReportRepository
+}
+
+// NewRolloutPromoteJob creates a new rollout-promote job.
+func NewRolloutPromoteJob(
+	cfg *config.SchedulerConfig,
+	taskRepo *repository.TaskRepository,
+	reportRepo *repository.ReportRepository,
+) *RolloutPromoteJob {
+	return &RolloutPromoteJob{
+		cfg:        cfg,
+		taskRepo:   taskRepo,
+		reportRepo: reportRepo,
+	}
+}
+
+// ToJob converts RolloutPromoteJob to a schedulable Job.
+func (r *RolloutPromoteJob) ToJob() *Job {
+	return &Job{
+		Name:        "rollout-promote",
+		Description: "Promote staged AI-generated tasks without reports to full rotation",
+		CronExpr:    r.cfg.RolloutPromoteCron,
+		Enabled:     r.cfg.RolloutPromoteEnabled,
+		Fn:          r.Execute,
+	}
+}
+
+// Execute runs the rollout-promote job.
+func (r *RolloutPromoteJob) Execute(ctx context.Context) error {
+	logger := log.With().Str("job", "rollout-promote").Logger()
+
+	cutoff := time.Now().Add(-time.Duration(r.cfg.RolloutPromoteAfterHours) * time.Hour)
+
+	tasks, err := r.taskRepo.FindStaged(cutoff, rolloutPromoteBatch)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch staged tasks")
+		return err
+	}
+	if len(tasks) == 0 {
+		logger.Debug().Msg("No staged tasks ready for promotion")
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	reports, err := r.reportRepo.CountOpenByTask(ids)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to count reports")
+		return err
+	}
+
+	var promote []string
+	for _, id := range ids {
+		if reports[id] == 0 {
+			promote = append(promote, id)
+		}
+	}
+
+	if err := r.taskRepo.SetRolloutPercent(promote, models.FullRollout); err != nil {
+		logger.Error().Err(err).Msg("Failed to promote tasks")
+		return err
+	}
+
+	logger.Info().
+		Int("staged", len(tasks)).
+		Int("promoted", len(promote)).
+		Int("held", len(tasks)-len(promote)).
+		Msg("Rollout-promote job completed")
+
+	return nil
+}
@@ -4,44 +4,112 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog/log"
 	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/errtrack"
+	"github.com/truthordare/backend/internal/maintenance"
 	"gorm.io/gorm"
 )
 
+// ErrJobRunning is returned when a job is triggered while a previous run
+// is still in progress.
+var ErrJobRunning = errors.New("job is already running")
+
 // Job represents a scheduled job with metadata.
 type Job struct {
 	Name        string
 	Description string
 	CronExpr    string
 	Enabled     bool
-	Fn          func(ctx context.Context) error
-	entryID     cron.EntryID
+	// ReadOnlySafe marks jobs that never write. Only these run while
+	// read-only mode is on.
+	ReadOnlySafe bool
+	// PauseInMaintenance skips scheduled runs that fall inside a
+	// maintenance window.
+	PauseInMaintenance bool
+	Fn                 func(ctx context.Context) error
+	entryID            cron.EntryID
+
+	// A job never runs twice at once: a scheduled run due while the
+	// previous one is still going is skipped.
+	running      atomic.Bool
+	runs         atomic.Int64
+	failures     atomic.Int64
+	skipped      atomic.Int64
+	lastDuration atomic.Int64
+}
+
+// start claims the job for a run; false if a run is in progress
+func (j *Job) start() bool {
+	if !j.running.CompareAndSwap(false, true) {
+		j.skipped.Add(1)
+		return false
+	}
+	return true
+}
+
+// finish records the outcome of a run and releases the job
+func (j *Job) finish(started time.Time, err error) {
+	j.runs.Add(1)
+	if err != nil {
+		j.failures.Add(1)
+	}
+	j.lastDuration.Store(time.Since(started).Milliseconds())
+	j.running.Store(false)
+}
+
+// Stats returns the job's run counters
+func (j *Job) Stats() JobStats {
+	return JobStats{
+		Running:        j.running.Load(),
+		Runs:           j.runs.Load(),
+		Failures:       j.failures.Load(),
+		Skipped:        j.skipped.Load(),
+		LastDurationMs: j.lastDuration.Load(),
+	}
+}
+
+// JobStats counts a job's runs, scheduled and manual, since startup.
+// Failures include panics; Skipped counts runs refused because the
+// previous one was still in progress.
+type JobStats struct {
+	Running        bool  `json:"running"`
+	Runs           int64 `json:"runs"`
+	Failures       int64 `json:"failures"`
+	Skipped        int64 `json:"skipped"`
+	LastDurationMs int64 `json:"last_duration_ms"`
 }
 
 // Scheduler manages background jobs.
 type Scheduler struct {
-	cron   *cron.Cron
-	jobs   []*Job
-	db     *gorm.DB
-	cfg    *config.Config
-	mu     sync.RWMutex
-	ctx    context.Context
-	cancel context.CancelFunc
+	cron    *cron.Cron
+	jobs    []*Job
+	db      *gorm.DB
+	cfg     *config.Config
+	mode    atomic.Pointer[maintenance.Mode]
+	running atomic.Bool
+	mu      sync.RWMutex
+	ctx     context.Context
+	cancel  context.CancelFunc
 }
 
 // New creates a new Scheduler instance.
 func New(cfg *config.Config, db *gorm.DB) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Create cron with seconds field (optional) and recover from panics
+	// Create cron with standard fields, plus descriptors such as @daily
+	// and @every 1h, and recover from panics
 	c := cron.New(
 		cron.WithParser(cron.NewParser(
-			cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow,
+			cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow|cron.Descriptor,
 		)),
 		cron.WithChain(
 			cron.Recover(cron.DefaultLogger),
@@ -76,13 +144,51 @@ func (s *Scheduler) AddJob(job *Job) error {
 			Time("start_time", startTime).
 			Logger()
 
+		if s.skipReadOnly(job) {
+			logger.Warn().Msg("Job skipped, read-only mode is enabled")
+			return
+		}
+
+		if job.PauseInMaintenance {
+			if open, _ := s.mode.Load().InMaintenance(startTime); open {
+				logger.Info().Msg("Job skipped, maintenance window is open")
+				return
+			}
+		}
+
+		if !job.start() {
+			logger.Warn().Msg("Job skipped, previous run still in progress")
+			return
+		}
+		var err error
+		defer func() { job.finish(startTime, err) }()
+
 		logger.Info().Msg("Job started")
 
-		if err := job.Fn(s.ctx); err != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("panic: %v", recovered)
+				logger.Error().Interface("panic", recovered).Msg("Job panicked")
+				errtrack.Capture(errtrack.Event{
+					Level:   errtrack.LevelFatal,
+					Message: "Scheduled job panicked: " + job.Name,
+					Error:   fmt.Sprint(recovered),
+					Stack:   string(debug.Stack()),
+					Tags:    map[string]string{"source": "scheduler", "job": job.Name},
+				})
+			}
+		}()
+
+		if err = job.Fn(s.ctx); err != nil {
 			logger.Error().
 				Err(err).
 				Dur("duration", time.Since(startTime)).
 				Msg("Job failed")
+			errtrack.Capture(errtrack.Event{
+				Message: "Scheduled job failed: " + job.Name,
+				Error:   err.Error(),
+				Tags:    map[string]string{"source": "scheduler", "job": job.Name},
+			})
 			return
 		}
 
@@ -118,11 +224,18 @@ func (s *Scheduler) Start() {
 
 	log.Info().Int("jobs", len(s.jobs)).Msg("Starting scheduler")
 	s.cron.Start()
+	s.running.Store(true)
+}
+
+// Running reports whether the scheduler was started and not stopped since.
+func (s *Scheduler) Running() bool {
+	return s.running.Load()
 }
 
 // Stop gracefully stops the scheduler.
 func (s *Scheduler) Stop() context.Context {
 	log.Info().Msg("Stopping scheduler")
+	s.running.Store(false)
 	s.cancel()
 	return s.cron.Stop()
 }
@@ -134,8 +247,18 @@ func (s *Scheduler) RunJobNow(name string) error {
 
 	for _, job := range s.jobs {
 		if job.Name == name {
+			if s.skipReadOnly(job) {
+				return maintenance.ErrReadOnly
+			}
+			if !job.start() {
+				return ErrJobRunning
+			}
 			log.Info().Str("job", name).Msg("Running job manually")
-			return job.Fn(s.ctx)
+			started := time.Now()
+			var err error
+			defer func() { job.finish(started, err) }()
+			err = job.Fn(s.ctx)
+			return err
 		}
 	}
 
@@ -158,6 +281,7 @@ func (s *Scheduler) GetJobs() []JobInfo {
 			Enabled:     job.Enabled,
 			NextRun:     entry.Next,
 			PrevRun:     entry.Prev,
+			Stats:       job.Stats(),
 		}
 		infos = append(infos, info)
 	}
@@ -173,6 +297,19 @@ type JobInfo struct {
 	Enabled     bool      `json:"enabled"`
 	NextRun     time.Time `json:"next_run"`
 	PrevRun     time.Time `json:"prev_run"`
+	Stats       JobStats  `json:"stats"`
+}
+
+// SetMode sets the maintenance mode consulted before each run. While it is
+// read-only, jobs not marked ReadOnlySafe are skipped; while a maintenance
+// window is open, scheduled runs of jobs marked PauseInMaintenance are.
+func (s *Scheduler) SetMode(mode *maintenance.Mode) {
+	s.mode.Store(mode)
+}
+
+// skipReadOnly reports whether a job must not run in the current mode
+func (s *Scheduler) skipReadOnly(job *Job) bool {
+	return !job.ReadOnlySafe && s.mode.Load().ReadOnly()
 }
 
 // GetDB returns the database connection for use by jobs.
@@ -2,10 +2,25 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/truthordare/backend/internal/ai"
 	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/diskspace"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
+	"github.com/truthordare/backend/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestScheduler_New(t *testing.T) {
@@ -151,6 +166,110 @@ func TestScheduler_RunJobNow(t *testing.T) {
 	}
 }
 
+func TestScheduler_RunJobNow_ReadOnly(t *testing.T) {
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			Enabled: true,
+		},
+	}
+
+	s := New(cfg, nil)
+	s.SetMode(maintenance.New(true))
+
+	writes, reads := 0, 0
+	jobs := []*Job{
+		{Name: "write-job", CronExpr: "0 0 1 1 *", Enabled: true, Fn: func(ctx context.Context) error {
+			writes++
+			return nil
+		}},
+		{Name: "read-job", CronExpr: "0 0 1 1 *", Enabled: true, ReadOnlySafe: true, Fn: func(ctx context.Context) error {
+			reads++
+			return nil
+		}},
+	}
+	for _, job := range jobs {
+		if err := s.AddJob(job); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := s.RunJobNow("write-job"); !errors.Is(err, maintenance.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+	if err := s.RunJobNow("read-job"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if writes != 0 {
+		t.Errorf("Expected write job to be skipped, ran %d times", writes)
+	}
+	if reads != 1 {
+		t.Errorf("Expected read-only safe job to run once, ran %d times", reads)
+	}
+}
+
+func TestScheduler_Calendar(t *testing.T) {
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			Enabled: true,
+		},
+	}
+	s := New(cfg, nil)
+	for _, job := range []*Job{
+		{Name: "hourly", Description: "Hourly job", CronExpr: "0 * * * *", Enabled: true, PauseInMaintenance: true},
+		{Name: "sunday", Description: "Sunday job", CronExpr: "15 3 * * 0", Enabled: true},
+	} {
+		job.Fn = func(ctx context.Context) error { return nil }
+		if err := s.AddJob(job); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	// Sundays 03:00-03:30
+	windows, err := maintenance.ParseWindows("0 3 * * 0", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mode := maintenance.New(false)
+	mode.SetWindows(windows)
+	s.SetMode(mode)
+
+	sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	events := s.Calendar(sunday.Add(2*time.Hour), sunday.Add(5*time.Hour))
+
+	var got []string
+	for _, event := range events {
+		got = append(got, fmt.Sprintf("%s %s %v", event.Start.Format("15:04"), event.Summary, event.Cancelled))
+	}
+	want := []string{
+		"02:00 hourly false",
+		"03:00 Maintenance window false",
+		"03:00 hourly true", // paused in the window
+		"03:15 sunday false",
+		"04:00 hourly false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	if end := events[1].End; !end.Equal(sunday.Add(3*time.Hour + 30*time.Minute)) {
+		t.Errorf("Expected the window to end at 03:30, got %v", end)
+	}
+	if length := events[0].End.Sub(events[0].Start); length != minCalendarRun {
+		t.Errorf("Expected runs that never ran to last %v, got %v", minCalendarRun, length)
+	}
+
+	mode.Set(true, "test")
+	for _, event := range s.Calendar(sunday.Add(2*time.Hour), sunday.Add(5*time.Hour)) {
+		if event.Summary != "Maintenance window" && !event.Cancelled {
+			t.Errorf("Expected %s at %v to be skipped while read-only", event.Summary, event.Start)
+		}
+	}
+
+	cfg.Scheduler.Enabled = false
+	if events := s.Calendar(sunday, sunday.AddDate(0, 0, 1)); len(events) != 1 {
+		t.Errorf("Expected only the maintenance window with the scheduler disabled, got %d events", len(events))
+	}
+}
+
 func TestScheduler_Stop(t *testing.T) {
 	cfg := &config.Config{
 		Scheduler: config.SchedulerConfig{
@@ -220,3 +339,568 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+func TestCleanupJob_ConvertsToIncrementalVacuum(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cleanup.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	job := NewCleanupJob(db, &config.SchedulerConfig{CleanupRetentionMonths: 2})
+
+	// First run converts the database, second run uses incremental vacuum
+	for i := 0; i < 2; i++ {
+		if err := job.Execute(context.Background()); err != nil {
+			t.Fatalf("Expected no error on run %d, got %v", i+1, err)
+		}
+	}
+
+	var autoVacuum int
+	if err := db.Raw("PRAGMA auto_vacuum").Scan(&autoVacuum).Error; err != nil {
+		t.Fatalf("Failed to read auto_vacuum: %v", err)
+	}
+	if autoVacuum != sqliteAutoVacuumIncremental {
+		t.Errorf("Expected auto_vacuum %d, got %d", sqliteAutoVacuumIncremental, autoVacuum)
+	}
+}
+
+func TestCleanupJob_PurgesSoftDeletedTables(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "purge.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Tables beyond tasks and categories soft delete too
+	old := &models.GlossaryTerm{Term: "Old"}
+	recent := &models.GlossaryTerm{Term: "Recent"}
+	for _, term := range []*models.GlossaryTerm{old, recent} {
+		if err := db.Create(term).Error; err != nil {
+			t.Fatalf("Failed to create glossary term: %v", err)
+		}
+		if err := db.Delete(term).Error; err != nil {
+			t.Fatalf("Failed to delete glossary term: %v", err)
+		}
+	}
+	db.Unscoped().Model(old).UpdateColumn("deleted_at", time.Now().AddDate(0, -3, 0))
+
+	// Consent records are kept for audits however old
+	consent := &models.ConsentRecord{ClientID: "client-a", PolicyVersion: "1", AgeConfirmed: true}
+	if err := db.Create(consent).Error; err != nil {
+		t.Fatalf("Failed to create consent record: %v", err)
+	}
+	db.Unscoped().Model(consent).UpdateColumn("deleted_at", time.Now().AddDate(-1, 0, 0))
+
+	job := NewCleanupJob(db, &config.SchedulerConfig{CleanupRetentionMonths: 2})
+	preview, err := job.GetCleanupPreview(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if preview.RowsToDelete["glossary_terms"] != 1 {
+		t.Errorf("Expected 1 glossary term to delete, got %v", preview.RowsToDelete)
+	}
+	if _, ok := preview.RowsToDelete["consent_records"]; ok {
+		t.Errorf("Expected consent records to be left out, got %v", preview.RowsToDelete)
+	}
+	body, err := json.Marshal(preview)
+	if err != nil {
+		t.Fatalf("Failed to encode preview: %v", err)
+	}
+	for _, key := range []string{`"tasks_to_delete":0`, `"categories_to_delete":0`} {
+		if !strings.Contains(string(body), key) {
+			t.Errorf("Expected preview to keep %s, got %s", key, body)
+		}
+	}
+
+	if err := job.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var ids []string
+	db.Unscoped().Model(&models.GlossaryTerm{}).Pluck("id", &ids)
+	if len(ids) != 1 || ids[0] != recent.ID {
+		t.Errorf("Expected only the recently deleted term to remain, got %v", ids)
+	}
+	var consents int64
+	db.Unscoped().Model(&models.ConsentRecord{}).Count(&consents)
+	if consents != 1 {
+		t.Errorf("Expected the consent record to be kept, got %d", consents)
+	}
+}
+
+func TestCleanupTables_CoverSoftDeleteTables(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tables.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	tables, err := database.SoftDeleteTables(db)
+	if err != nil {
+		t.Fatalf("Failed to list soft-delete tables: %v", err)
+	}
+
+	// A new soft-delete table must be purged or kept on purpose
+	listed := make(map[string]bool)
+	for _, table := range append(append([]string(nil), cleanupTables...), retainedTables...) {
+		listed[table] = true
+	}
+	for _, table := range tables {
+		if !listed[table] {
+			t.Errorf("Expected %s in cleanupTables or retainedTables", table)
+		}
+	}
+}
+
+func TestAutoGenerateJob_MockProvider(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "generate.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &models.GenerationRun{}, &models.GlossaryTerm{}, &models.LanguageFreeze{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	category := &models.Category{Label: models.MultilingualText{"en": "Mock"}, AgeGroup: models.AgeGroupKids, IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	// Frozen languages get no new tasks
+	if err := db.Create(&models.LanguageFreeze{Language: "ur"}).Error; err != nil {
+		t.Fatalf("Failed to freeze language: %v", err)
+	}
+	languages := len(models.SupportedLanguages) - 1
+
+	cfg := &config.SchedulerConfig{AutoGenerateCount: 2, AutoGenerateRetryMax: 1, AutoGenerateTimeoutSeconds: 5}
+	job := NewAutoGenerateJob(db, cfg, &config.GenerationConfig{ExampleCount: 2, RolloutPercent: 25}, repository.NewCategoryRepository(db), repository.NewTaskRepository(db))
+	job.aiClient = ai.NewClient(ai.ClientConfig{Provider: ai.ProviderMock})
+
+	if err := job.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var count int64
+	db.Model(&models.Task{}).Count(&count)
+	// 2 truths + 2 dares for every supported language but the frozen one
+	expected := int64(4 * languages)
+	if count != expected {
+		t.Errorf("Expected %d tasks, got %d", expected, count)
+	}
+	var staged int64
+	db.Model(&models.Task{}).Where("rollout_percent = ?", 25).Count(&staged)
+	if staged != expected {
+		t.Errorf("Expected all %d generated tasks to start at 25%% rollout, got %d", expected, staged)
+	}
+
+	var run models.GenerationRun
+	if err := db.First(&run).Error; err != nil {
+		t.Fatalf("Expected a generation run report: %v", err)
+	}
+	if run.Trigger != models.GenerationTriggerScheduled || run.Status != models.GenerationRunCompleted {
+		t.Errorf("Expected a completed scheduled run, got %s/%s", run.Trigger, run.Status)
+	}
+	if int64(run.TasksCreated) != expected || len(run.Combinations) != languages {
+		t.Errorf("Expected %d tasks over %d combinations, got %d over %d", expected, languages, run.TasksCreated, len(run.Combinations))
+	}
+	var frozen int64
+	db.Model(&models.Task{}).Where("language = ?", "ur").Count(&frozen)
+	if frozen != 0 {
+		t.Errorf("Expected no tasks in the frozen language, got %d", frozen)
+	}
+	var linked int64
+	db.Model(&models.Task{}).Where("generation_run_id = ?", run.ID).Count(&linked)
+	if linked != expected {
+		t.Errorf("Expected all generated tasks linked to run %s, got %d", run.ID, linked)
+	}
+}
+
+func TestRolloutPromoteJob(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rollout.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &models.TaskReport{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	old := time.Now().Add(-72 * time.Hour)
+	newTask := func(text string, createdAt time.Time) *models.Task {
+		task := &models.Task{CategoryID: "cat", Type: models.TaskTypeTruth, Text: text, Language: "en", RolloutPercent: 25}
+		task.CreatedAt = createdAt
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		return task
+	}
+	clean := newTask("clean", old)
+	reported := newTask("reported", old)
+	fresh := newTask("fresh", time.Now())
+
+	if err := db.Create(&models.TaskReport{TaskID: reported.ID, Reason: models.ReportReasonOffensive}).Error; err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	taskRepo := repository.NewTaskRepository(db)
+	job := NewRolloutPromoteJob(&config.SchedulerConfig{RolloutPromoteAfterHours: 48}, taskRepo, repository.NewReportRepository(db))
+	if err := job.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]int{
+		clean.ID:    models.FullRollout,
+		reported.ID: 25,
+		fresh.ID:    25,
+	}
+	for id, want := range expected {
+		task, err := taskRepo.FindByID(id)
+		if err != nil {
+			t.Fatalf("Failed to load task: %v", err)
+		}
+		if task.RolloutPercent != want {
+			t.Errorf("Task %q: expected rollout %d, got %d", task.Text, want, task.RolloutPercent)
+		}
+	}
+}
+
+func TestAutoGenerateJob_SkipsCombinationsAtCap(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cap.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &models.GlossaryTerm{}, &models.LanguageFreeze{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	category := &models.Category{Label: models.MultilingualText{"en": "Full"}, AgeGroup: models.AgeGroupKids, IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		task := &models.Task{CategoryID: category.ID, Type: models.TaskTypeTruth, Text: "existing", Language: "en"}
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	cfg := &config.SchedulerConfig{AutoGenerateCount: 1, AutoGenerateRetryMax: 1, AutoGenerateTimeoutSeconds: 5, TaskCapPerCategoryLanguage: 2}
+	job := NewAutoGenerateJob(db, cfg, &config.GenerationConfig{}, repository.NewCategoryRepository(db), repository.NewTaskRepository(db))
+	job.aiClient = ai.NewClient(ai.ClientConfig{Provider: ai.ProviderMock})
+
+	if err := job.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var english int64
+	db.Model(&models.Task{}).Where("language = ?", "en").Count(&english)
+	if english != 2 {
+		t.Errorf("Expected English to stay at the cap of 2 tasks, got %d", english)
+	}
+
+	var others int64
+	db.Model(&models.Task{}).Where("language <> ?", "en").Count(&others)
+	// 1 truth + 1 dare for every other language
+	if expected := int64(2 * (len(models.SupportedLanguages) - 1)); others != expected {
+		t.Errorf("Expected %d tasks in other languages, got %d", expected, others)
+	}
+}
+
+func TestCleanupJob_EvictsInactiveTasksOverCap(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "evict.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &models.TaskReport{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	newTask := func(text string, active bool, age time.Duration) *models.Task {
+		task := &models.Task{CategoryID: "cat", Type: models.TaskTypeDare, Text: text, Language: "en"}
+		task.CreatedAt = base.Add(-age)
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		if !active {
+			db.Model(task).Update("is_active", false)
+		}
+		return task
+	}
+	newTask("active old", true, 10*time.Minute)
+	newTask("active new", true, 0)
+	oldest := newTask("inactive oldest", false, 5*time.Minute)
+	reported := newTask("inactive reported", false, 0)
+	kept := newTask("inactive kept", false, time.Minute)
+
+	if err := db.Create(&models.TaskReport{TaskID: reported.ID, Reason: models.ReportReasonOffensive}).Error; err != nil {
+		t.Fatalf("Failed to create report: %v", err)
+	}
+
+	job := NewCleanupJob(db, &config.SchedulerConfig{CleanupRetentionMonths: 2, CleanupEvictOverCap: true, TaskCapPerCategoryLanguage: 3})
+	evicted, err := job.evictOverCap(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if evicted != 2 {
+		t.Fatalf("Expected 2 tasks evicted, got %d", evicted)
+	}
+
+	var remaining []models.Task
+	db.Find(&remaining)
+	if len(remaining) != 3 {
+		t.Fatalf("Expected 3 tasks to remain, got %d", len(remaining))
+	}
+	for _, task := range remaining {
+		if task.ID == oldest.ID || task.ID == reported.ID {
+			t.Errorf("Expected %q to be evicted", task.Text)
+		}
+	}
+	if err := db.First(&models.Task{}, "id = ?", kept.ID).Error; err != nil {
+		t.Errorf("Expected %q to be kept: %v", kept.Text, err)
+	}
+}
+
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, event notify.Event) error {
+	n.events = append(n.events, event)
+	return nil
+}
+
+func TestDigestJob(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "digest.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &models.TaskReport{}, &models.GenerationRun{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	now := time.Now().UTC()
+	lastMonth := now.AddDate(0, -1, 0)
+	newTask := func(text, runID, reviewState string, createdAt time.Time) *models.Task {
+		task := &models.Task{CategoryID: "cat", Type: models.TaskTypeTruth, Text: text, Language: "en", GenerationRunID: runID, ReviewState: reviewState}
+		task.CreatedAt = createdAt
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		return task
+	}
+	runs := []models.GenerationRun{
+		{Trigger: models.GenerationTriggerScheduled, Status: models.GenerationRunCompleted, StartedAt: now.Add(-time.Hour), TasksCreated: 2, PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150},
+		{Trigger: models.GenerationTriggerManual, Status: models.GenerationRunFailed, StartedAt: now.Add(-48 * time.Hour), PromptTokens: 10, TotalTokens: 10},
+		{Trigger: models.GenerationTriggerScheduled, Status: models.GenerationRunCompleted, StartedAt: lastMonth, TasksCreated: 9, TotalTokens: 900},
+	}
+	if err := db.Create(&runs).Error; err != nil {
+		t.Fatalf("Failed to create runs: %v", err)
+	}
+	newTask("generated one", runs[0].ID, models.ReviewStatePending, now)
+	newTask("generated two", runs[0].ID, models.ReviewStatePending, now)
+	manual := newTask("manual", "", "", now)
+	old := newTask("old", "", "", lastMonth)
+
+	reports := []models.TaskReport{
+		{TaskID: manual.ID, Reason: models.ReportReasonOffensive},
+		{TaskID: manual.ID, Reason: models.ReportReasonOffensive},
+		{TaskID: old.ID, Reason: models.ReportReasonOffensive},
+	}
+	if err := db.Create(&reports).Error; err != nil {
+		t.Fatalf("Failed to create reports: %v", err)
+	}
+
+	notifier := &recordingNotifier{}
+	cfg := &config.SchedulerConfig{DigestRecipients: []string{"admin@example.com"}, DigestTopReported: 1}
+	job := NewDigestJob(cfg, repository.NewTaskRepository(db), repository.NewReportRepository(db), repository.NewGenerationRunRepository(db), notifier)
+	job.now = func() time.Time { return now }
+
+	digest, err := job.Build(now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if digest.NewTasks[repository.TaskSourceGenerated] != 2 || digest.NewTasks[repository.TaskSourceManual] != 1 {
+		t.Errorf("Expected 2 generated and 1 manual new tasks, got %v", digest.NewTasks)
+	}
+	if digest.PendingReview != 2 {
+		t.Errorf("Expected 2 tasks pending review, got %d", digest.PendingReview)
+	}
+	if digest.OpenReports != 3 || digest.ReportedTasks != 2 {
+		t.Errorf("Expected 3 open reports on 2 tasks, got %d on %d", digest.OpenReports, digest.ReportedTasks)
+	}
+	if len(digest.TopReported) != 1 || digest.TopReported[0].TaskID != manual.ID || digest.TopReported[0].Reports != 2 {
+		t.Errorf("Expected the manual task with 2 reports on top, got %+v", digest.TopReported)
+	}
+	usage := digest.AIUsage
+	if usage.Runs != 2 || usage.FailedRuns != 1 || usage.TasksCreated != 2 || usage.TotalTokens != 160 {
+		t.Errorf("Expected usage of this week's 2 runs, got %+v", usage)
+	}
+
+	if err := job.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(notifier.events) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(notifier.events))
+	}
+	event := notifier.events[0]
+	if event.Type != notify.EventWeeklyDigest {
+		t.Errorf("Expected weekly digest event, got %q", event.Type)
+	}
+	if len(event.Recipients) != 1 || event.Recipients[0] != "admin@example.com" {
+		t.Errorf("Expected the configured recipient, got %v", event.Recipients)
+	}
+	if event.Fields["new_tasks"] != "3" || event.Fields["ai_tokens"] != "160" {
+		t.Errorf("Unexpected digest fields: %v", event.Fields)
+	}
+	if !strings.Contains(event.Body, "New tasks: 3 (2 generated, 1 manual)") {
+		t.Errorf("Expected new task counts in body, got %q", event.Body)
+	}
+}
+
+func TestCategoryRankJob(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rank.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &models.TelemetryRollup{}, &models.CategoryRank{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	categories := []models.Category{
+		{Label: models.MultilingualText{"en": "Classic"}, AgeGroup: models.AgeGroupKids, IsActive: true, SortOrder: 1},
+		{Label: models.MultilingualText{"en": "Silly"}, AgeGroup: models.AgeGroupKids, IsActive: true, SortOrder: 2},
+		{Label: models.MultilingualText{"en": "Music"}, AgeGroup: models.AgeGroupKids, IsActive: true, SortOrder: 3},
+	}
+	if err := db.Create(&categories).Error; err != nil {
+		t.Fatalf("Failed to create categories: %v", err)
+	}
+	classic, silly, music := categories[0].ID, categories[1].ID, categories[2].ID
+	task := &models.Task{CategoryID: music, Type: models.TaskTypeDare, Text: "Sing", Language: "hi"}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	now := time.Now().UTC()
+	today := now.Format(time.DateOnly)
+	rollups := []models.TelemetryRollup{
+		{Day: today, Event: models.TelemetryTaskServed, CategoryID: silly, Language: "en", AgeGroup: models.AgeGroupKids, Count: 5},
+		{Day: today, Event: models.TelemetryTaskServed, CategoryID: classic, Language: "en", AgeGroup: models.AgeGroupTeen, Count: 8},
+		// Served events without a category count for the task's
+		{Day: today, Event: models.TelemetryTaskServed, TaskID: task.ID, Language: "hi", Count: 3},
+		// Other events and plays outside the window are ignored
+		{Day: today, Event: models.TelemetryTaskLiked, CategoryID: music, Language: "en", AgeGroup: models.AgeGroupKids, Count: 50},
+		{Day: now.AddDate(0, 0, -60).Format(time.DateOnly), Event: models.TelemetryTaskServed, CategoryID: music, Language: "en", AgeGroup: models.AgeGroupKids, Count: 50},
+	}
+	if err := repository.NewTelemetryRepository(db).AddCounts(rollups); err != nil {
+		t.Fatalf("Failed to add rollups: %v", err)
+	}
+
+	rankRepo := repository.NewCategoryRankRepository(db)
+	// Ranks from an earlier run are replaced
+	if err := rankRepo.Replace([]models.CategoryRank{{CategoryID: music, Plays: 100}}); err != nil {
+		t.Fatalf("Failed to seed ranks: %v", err)
+	}
+
+	cfg := &config.SchedulerConfig{CategoryRankWindowDays: 30}
+	job := NewCategoryRankJob(cfg, repository.NewTelemetryRepository(db), rankRepo)
+	job.now = func() time.Time { return now }
+	if err := job.Execute(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	order := func(language, ageGroup string) []string {
+		found, err := repository.NewCategoryRepository(db).FindAll(&repository.CategoryFilter{
+			SmartOrder:    true,
+			SmartLanguage: language,
+			SmartAgeGroup: ageGroup,
+		})
+		if err != nil {
+			t.Fatalf("Failed to list categories: %v", err)
+		}
+		ids := make([]string, len(found))
+		for i, category := range found {
+			ids[i] = category.ID
+		}
+		return ids
+	}
+	tests := []struct {
+		name, language, ageGroup string
+		want                     []string
+	}{
+		{"all players", "", "", []string{classic, silly, music}},
+		{"english kids", "en", models.AgeGroupKids, []string{silly, classic, music}},
+		{"hindi", "hi", "", []string{music, classic, silly}},
+		{"no plays keeps sort order", "fr", "", []string{classic, silly, music}},
+	}
+	for _, tt := range tests {
+		if got := order(tt.language, tt.ageGroup); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected order %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDiskGuardJob(t *testing.T) {
+	mode := maintenance.New(false)
+	notifier := &recordingNotifier{}
+	cfg := &config.SchedulerConfig{DiskGuardEnabled: true, DiskGuardCron: "@every 1m", DiskGuardMinFreeMB: 100}
+	job := NewDiskGuardJob(cfg, "data/app.db", func() *maintenance.Mode { return mode }, notifier)
+	free := uint64(150 << 20)
+	job.usage = func(path string) (diskspace.Usage, error) {
+		return diskspace.Usage{Total: 1 << 30, Free: free}, nil
+	}
+
+	if !job.ToJob().ReadOnlySafe {
+		t.Error("Expected the disk guard to run in read-only mode")
+	}
+	if NewDiskGuardJob(cfg, "", job.mode, notifier).ToJob().Enabled {
+		t.Error("Expected no disk guard for an in-memory database")
+	}
+
+	run := func() {
+		t.Helper()
+		if err := job.Execute(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	run()
+	if mode.ReadOnly() || len(notifier.events) != 0 {
+		t.Fatalf("Expected nothing above the threshold, got read-only %v and %d events", mode.ReadOnly(), len(notifier.events))
+	}
+
+	free = 50 << 20
+	run()
+	run()
+	if !mode.ReadOnly() || !strings.HasPrefix(mode.Status().Reason, diskGuardReason) {
+		t.Errorf("Expected read-only mode below the threshold, got %+v", mode.Status())
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notify.EventDiskSpaceLow {
+		t.Fatalf("Expected one low disk alert, got %+v", notifier.events)
+	}
+
+	free = 150 << 20
+	run()
+	if !mode.ReadOnly() {
+		t.Error("Expected read-only mode until twice the threshold is free")
+	}
+
+	free = 300 << 20
+	run()
+	if mode.ReadOnly() {
+		t.Error("Expected read-only mode switched off once space recovered")
+	}
+	if len(notifier.events) != 2 || notifier.events[1].Type != notify.EventDiskSpaceOK {
+		t.Fatalf("Expected a recovery notice, got %+v", notifier.events)
+	}
+
+	// Read-only mode an admin switched on is left alone
+	mode.Set(true, "restore in progress")
+	free = 50 << 20
+	run()
+	free = 300 << 20
+	run()
+	if status := mode.Status(); !status.ReadOnly || status.Reason != "restore in progress" {
+		t.Errorf("Expected the admin's read-only mode to be kept, got %+v", status)
+	}
+}
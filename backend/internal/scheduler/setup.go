@@ -2,18 +2,27 @@ package scheduler
 
 import (
 	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/ai"
 	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/notify"
 	"github.com/truthordare/backend/internal/repository"
 	"gorm.io/gorm"
 )
 
 // Setup creates and configures the scheduler with all jobs.
 func Setup(cfg *config.Config, db *gorm.DB) *Scheduler {
+	return setup(cfg, db, ai.GetClient())
+}
+
+// setup registers all jobs, generating tasks through aiClient
+func setup(cfg *config.Config, db *gorm.DB, aiClient *ai.Client) *Scheduler {
 	scheduler := New(cfg, db)
 
 	// Create repositories for jobs that need them
 	categoryRepo := repository.NewCategoryRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	runRepo := repository.NewGenerationRunRepository(db)
 
 	// Register cleanup job
 	cleanupJob := NewCleanupJob(db, &cfg.Scheduler)
@@ -22,10 +31,35 @@ func Setup(cfg *config.Config, db *gorm.DB) *Scheduler {
 	}
 
 	// Register auto-generate job
-	autoGenerateJob := NewAutoGenerateJob(db, &cfg.Scheduler, categoryRepo, taskRepo)
+	autoGenerateJob := NewAutoGenerateJob(db, &cfg.Scheduler, &cfg.Generation, categoryRepo, taskRepo)
+	autoGenerateJob.aiClient = aiClient
 	if err := scheduler.AddJob(autoGenerateJob.ToJob()); err != nil {
 		log.Error().Err(err).Msg("Failed to register auto-generate job")
 	}
 
+	// Register rollout-promote job
+	rolloutPromoteJob := NewRolloutPromoteJob(&cfg.Scheduler, taskRepo, reportRepo)
+	if err := scheduler.AddJob(rolloutPromoteJob.ToJob()); err != nil {
+		log.Error().Err(err).Msg("Failed to register rollout-promote job")
+	}
+
+	// Register weekly digest job
+	digestJob := NewDigestJob(&cfg.Scheduler, taskRepo, reportRepo, runRepo, notify.New(cfg.Moderation.NotifyWebhookURL))
+	if err := scheduler.AddJob(digestJob.ToJob()); err != nil {
+		log.Error().Err(err).Msg("Failed to register weekly digest job")
+	}
+
+	// Register category-rank job
+	categoryRankJob := NewCategoryRankJob(&cfg.Scheduler, repository.NewTelemetryRepository(db), repository.NewCategoryRankRepository(db))
+	if err := scheduler.AddJob(categoryRankJob.ToJob()); err != nil {
+		log.Error().Err(err).Msg("Failed to register category-rank job")
+	}
+
+	// Register disk guard job
+	diskGuardJob := NewDiskGuardJob(&cfg.Scheduler, cfg.DBFile(), scheduler.mode.Load, notify.New(cfg.Moderation.NotifyWebhookURL))
+	if err := scheduler.AddJob(diskGuardJob.ToJob()); err != nil {
+		log.Error().Err(err).Msg("Failed to register disk guard job")
+	}
+
 	return scheduler
 }
@@ -0,0 +1,244 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Soak mode runs every job with its schedule compressed to once a second,
+// so a few seconds cover weeks of weekly runs. Run longer soaks with e.g.
+// go test ./internal/scheduler -run TestSoak -soak 2m
+var soakDuration = flag.Duration("soak", 3*time.Second, "how long TestSoak runs the compressed schedule")
+
+// soakCap is the task cap per category+language during the soak, low
+// enough for generation to reach it within a few runs
+const soakCap = 7
+
+// soakTracker wraps job functions to catch overlapping runs
+type soakTracker struct {
+	mu       sync.Mutex
+	active   map[string]int
+	overlaps []string
+}
+
+func (tr *soakTracker) wrap(job *Job) {
+	fn := job.Fn
+	job.Fn = func(ctx context.Context) error {
+		tr.mu.Lock()
+		tr.active[job.Name]++
+		if tr.active[job.Name] > 1 {
+			tr.overlaps = append(tr.overlaps, job.Name)
+		}
+		tr.mu.Unlock()
+
+		defer func() {
+			tr.mu.Lock()
+			tr.active[job.Name]--
+			tr.mu.Unlock()
+		}()
+		return fn(ctx)
+	}
+}
+
+// newSoakProvider is a completions endpoint answering generation prompts
+// with texts unique per call, so runs are not all dropped as duplicates
+func newSoakProvider(t *testing.T) *httptest.Server {
+	t.Helper()
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt64(&calls, 1)
+		content, _ := json.Marshal(GeneratedContent{
+			Truths: []string{fmt.Sprintf("What was your favourite moment of week %d?", call), fmt.Sprintf("Who did you last text in week %d?", call)},
+			Dares:  []string{fmt.Sprintf("Hum a song from week %d.", call), fmt.Sprintf("Wave at everyone %d times.", call)},
+		})
+		resp := ai.CompletionResponse{}
+		resp.Choices = append(resp.Choices, struct {
+			Index   int        `json:"index"`
+			Message ai.Message `json:"message"`
+		}{Message: ai.Message{Role: "assistant", Content: string(content)}})
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newSoakDB migrates a fresh database and seeds two categories, with every
+// language but English frozen to keep runs short
+func newSoakDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "soak.db")+"?_busy_timeout=5000"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	for _, name := range []string{"Party", "Couples"} {
+		category := &models.Category{Label: models.MultilingualText{"en": name}, AgeGroup: models.AgeGroupAdults, IsActive: true}
+		if err := db.Create(category).Error; err != nil {
+			t.Fatalf("Failed to seed category: %v", err)
+		}
+	}
+	for _, language := range models.SupportedLanguages {
+		if language == "en" {
+			continue
+		}
+		if err := db.Create(&models.LanguageFreeze{Language: language, Reason: "soak"}).Error; err != nil {
+			t.Fatalf("Failed to freeze language: %v", err)
+		}
+	}
+	return db
+}
+
+// TestSoak runs the full scheduler on a compressed schedule, with manual
+// runs triggered alongside, and checks invariants that only break over
+// many runs: no job runs twice at once, every run is accounted for and
+// leaves its records, and generation respects the task cap.
+func TestSoak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test skipped in short mode")
+	}
+
+	db := newSoakDB(t)
+	provider := newSoakProvider(t)
+	every := "@every 1s"
+	cfg := &config.Config{
+		Scheduler: config.SchedulerConfig{
+			Enabled:                    true,
+			CleanupEnabled:             true,
+			CleanupCron:                every,
+			CleanupRetentionMonths:     2,
+			CleanupEvictOverCap:        true,
+			TaskCapPerCategoryLanguage: soakCap,
+			AutoGenerateEnabled:        true,
+			AutoGenerateCron:           every,
+			AutoGenerateCount:          2,
+			AutoGenerateRetryMax:       1,
+			AutoGenerateTimeoutSeconds: 5,
+			RolloutPromoteEnabled:      true,
+			RolloutPromoteCron:         every,
+			RolloutPromoteAfterHours:   48,
+			DigestEnabled:              true,
+			DigestCron:                 every,
+			DigestTopReported:          5,
+			CategoryRankEnabled:        true,
+			CategoryRankCron:           every,
+			CategoryRankWindowDays:     30,
+		},
+		Generation: config.GenerationConfig{RolloutPercent: 25, LanguageCheck: models.LanguageCheckOff},
+	}
+	aiClient := ai.NewClient(ai.ClientConfig{APIKey: "soak", APIURL: provider.URL, Model: "soak"})
+
+	sched := setup(cfg, db, aiClient)
+	tracker := &soakTracker{active: make(map[string]int)}
+	for _, job := range sched.jobs {
+		tracker.wrap(job)
+	}
+	if len(sched.jobs) != 5 {
+		t.Fatalf("Expected all 5 jobs registered, got %d", len(sched.jobs))
+	}
+
+	// Admins triggering jobs by hand race the schedule
+	done := make(chan struct{})
+	var manual sync.WaitGroup
+	manual.Add(1)
+	go func() {
+		defer manual.Done()
+		ticker := time.NewTicker(300 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := sched.RunJobNow("auto-generate"); err != nil && err != ErrJobRunning {
+					t.Errorf("Manual run failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	sched.Start()
+	time.Sleep(*soakDuration)
+	close(done)
+	manual.Wait()
+	<-sched.Stop().Done()
+
+	t.Run("no overlapping runs", func(t *testing.T) {
+		if len(tracker.overlaps) > 0 {
+			t.Errorf("Jobs ran concurrently with themselves: %v", tracker.overlaps)
+		}
+	})
+
+	stats := make(map[string]JobStats)
+	for _, info := range sched.GetJobs() {
+		stats[info.Name] = info.Stats
+	}
+
+	t.Run("every job ran and stats are recorded", func(t *testing.T) {
+		for name, s := range stats {
+			if s.Runs == 0 {
+				t.Errorf("Job %s never ran", name)
+			}
+			if s.Running {
+				t.Errorf("Job %s still marked running after stop", name)
+			}
+		}
+		if stats["auto-generate"].Failures > 0 {
+			t.Errorf("Expected no auto-generate failures, got %d", stats["auto-generate"].Failures)
+		}
+
+		// Each auto-generate run leaves a run report; a run cut short by
+		// stopping the scheduler is reported as failed
+		var reports int64
+		db.Model(&models.GenerationRun{}).Count(&reports)
+		if reports != stats["auto-generate"].Runs {
+			t.Errorf("Expected a run report per auto-generate run (%d), got %d", stats["auto-generate"].Runs, reports)
+		}
+		var running int64
+		db.Model(&models.GenerationRun{}).Where("status = ?", models.GenerationRunRunning).Count(&running)
+		if running > 0 {
+			t.Errorf("Expected every run report closed, %d still running", running)
+		}
+	})
+
+	t.Run("caps respected", func(t *testing.T) {
+		var counts []struct {
+			CategoryID string
+			Language   string
+			Tasks      int
+		}
+		db.Model(&models.Task{}).
+			Select("category_id, language, COUNT(*) AS tasks").
+			Group("category_id, language").
+			Scan(&counts)
+		if len(counts) == 0 {
+			t.Fatal("Expected generated tasks")
+		}
+		for _, c := range counts {
+			if c.Tasks > soakCap {
+				t.Errorf("Category %s/%s holds %d tasks, over the cap of %d", c.CategoryID, c.Language, c.Tasks, soakCap)
+			}
+		}
+	})
+}
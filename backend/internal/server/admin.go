@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// engines returns the distinct routers, the admin one only when it has its
+// own listener
+func (s *Server) engines() []*gin.Engine {
+	if s.admin == s.router {
+		return []*gin.Engine{s.router}
+	}
+	return []*gin.Engine{s.router, s.admin}
+}
+
+// startAdmin binds ADMIN_ADDR and serves the admin routes in the background.
+// Binding happens before returning so a taken port fails startup.
+func (s *Server) startAdmin() error {
+	ln, err := net.Listen("tcp", s.cfg.AdminAddr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           s.admin,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("addr", ln.Addr().String()).Msg("Starting admin server")
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Str("addr", s.cfg.AdminAddr).Msg("Admin server failed")
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/database"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	cfg.APIPrefix = "/api"
+	srv := New(cfg, db)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func statusOf(handler http.Handler, path string) int {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w.Code
+}
+
+func TestAdminListener(t *testing.T) {
+	t.Run("shared by default", func(t *testing.T) {
+		srv := newTestServer(t, &config.Config{})
+
+		if srv.Handler() != srv.AdminHandler() {
+			t.Fatal("Expected admin routes on the main router")
+		}
+		if code := statusOf(srv.Handler(), "/api/v1/auth/verify"); code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for restricted route, got %d", code)
+		}
+	})
+
+	t.Run("separate admin router", func(t *testing.T) {
+		srv := newTestServer(t, &config.Config{AdminAddr: "127.0.0.1:0"})
+
+		tests := []struct {
+			handler http.Handler
+			path    string
+			want    int
+		}{
+			{srv.Handler(), "/api/v1/languages", http.StatusOK},
+			{srv.Handler(), "/api/v1/auth/verify", http.StatusNotFound},
+			{srv.Handler(), "/metrics", http.StatusNotFound},
+			{srv.Handler(), "/health", http.StatusOK},
+			{srv.AdminHandler(), "/api/v1/auth/verify", http.StatusUnauthorized},
+			{srv.AdminHandler(), "/api/v1/languages", http.StatusOK},
+			{srv.AdminHandler(), "/metrics", http.StatusOK},
+			{srv.AdminHandler(), "/health", http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			name := "public"
+			if tt.handler == srv.AdminHandler() {
+				name = "admin"
+			}
+			if code := statusOf(tt.handler, tt.path); code != tt.want {
+				t.Errorf("%s %s: expected %d, got %d", name, tt.path, tt.want, code)
+			}
+		}
+	})
+}
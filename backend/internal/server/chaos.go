@@ -0,0 +1,55 @@
+package server
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/chaos"
+	"github.com/truthordare/backend/internal/config"
+)
+
+// setupChaos creates the fault injector for HTTP routes and hands the AI
+// client its own, when chaos testing is configured. Outside development
+// the configuration is ignored, so a stray CHAOS_ENABLED never reaches
+// production traffic.
+func (s *Server) setupChaos() {
+	cfg := s.cfg.Chaos
+	if !cfg.Enabled {
+		return
+	}
+	if !s.cfg.IsDevelopment() {
+		log.Warn().Str("env", s.cfg.Env).Msg("CHAOS_ENABLED ignored outside development")
+		return
+	}
+
+	if faults := httpFaults(cfg); faults.Active() {
+		s.chaos = chaos.New(faults)
+	}
+	if faults := aiFaults(cfg); faults.Active() {
+		ai.GetClient().SetChaos(chaos.New(faults))
+	}
+	log.Warn().
+		Strs("routes", cfg.Routes).
+		Interface("http", httpFaults(cfg)).
+		Interface("ai", aiFaults(cfg)).
+		Msg("Chaos testing enabled: injecting faults")
+}
+
+func httpFaults(cfg config.ChaosConfig) chaos.Faults {
+	return chaos.Faults{
+		Latency:     time.Duration(cfg.LatencyMS) * time.Millisecond,
+		LatencyRate: cfg.LatencyRate,
+		ErrorRate:   cfg.ErrorRate,
+		DropRate:    cfg.DropRate,
+	}
+}
+
+func aiFaults(cfg config.ChaosConfig) chaos.Faults {
+	return chaos.Faults{
+		Latency:     time.Duration(cfg.AILatencyMS) * time.Millisecond,
+		LatencyRate: cfg.AILatencyRate,
+		ErrorRate:   cfg.AIErrorRate,
+		DropRate:    cfg.AIDropRate,
+	}
+}
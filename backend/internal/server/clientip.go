@@ -0,0 +1,27 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+)
+
+// configureClientIP sets which proxies gin trusts, so c.ClientIP() returns
+// the real client address behind a load balancer and the connection address
+// otherwise. Forwarding headers from untrusted peers are ignored, since
+// anyone can send them.
+func configureClientIP(router *gin.Engine, cfg *config.Config) {
+	if len(cfg.ClientIPHeaders) > 0 {
+		router.RemoteIPHeaders = cfg.ClientIPHeaders
+	}
+
+	// A nil list trusts no proxy; gin trusts every peer by default
+	proxies := cfg.TrustedProxies
+	if len(proxies) == 0 {
+		proxies = nil
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		log.Error().Err(err).Strs("trusted_proxies", proxies).Msg("Invalid trusted proxies, trusting none")
+		_ = router.SetTrustedProxies(nil)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+)
+
+func TestConfigureClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		proxies    []string
+		headers    []string
+		remoteAddr string
+		header     string
+		value      string
+		want       string
+	}{
+		{
+			name:       "no trusted proxies ignores forwarded header",
+			remoteAddr: "203.0.113.7:1234",
+			header:     "X-Forwarded-For",
+			value:      "198.51.100.1",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy forwards client IP",
+			proxies:    []string{"10.0.0.0/8"},
+			headers:    []string{"X-Forwarded-For", "X-Real-IP"},
+			remoteAddr: "10.1.2.3:1234",
+			header:     "X-Forwarded-For",
+			value:      "198.51.100.1, 10.1.2.2",
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "X-Real-IP from trusted proxy",
+			proxies:    []string{"10.1.2.3"},
+			headers:    []string{"X-Forwarded-For", "X-Real-IP"},
+			remoteAddr: "10.1.2.3:1234",
+			header:     "X-Real-IP",
+			value:      "198.51.100.9",
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "untrusted peer cannot spoof",
+			proxies:    []string{"10.0.0.0/8"},
+			headers:    []string{"X-Forwarded-For"},
+			remoteAddr: "203.0.113.7:1234",
+			header:     "X-Forwarded-For",
+			value:      "198.51.100.1",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "invalid proxy list trusts none",
+			proxies:    []string{"not-an-ip"},
+			remoteAddr: "10.1.2.3:1234",
+			header:     "X-Forwarded-For",
+			value:      "198.51.100.1",
+			want:       "10.1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			configureClientIP(router, &config.Config{TrustedProxies: tt.proxies, ClientIPHeaders: tt.headers})
+			router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Expected client IP %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/version"
+)
+
+// startedAt is when the process started, for uptime reporting
+var startedAt = time.Now()
+
+func init() {
+	expvar.Publish("build", expvar.Func(func() interface{} { return version.Get() }))
+	expvar.Publish("goroutines", expvar.Func(func() interface{} { return runtime.NumGoroutine() }))
+}
+
+// diagnosticsHandler serves pprof profiles and expvar variables
+func diagnosticsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// startDiagnostics serves pprof and expvar on a localhost-only port, so
+// profiles are reachable through SSH or kubectl port-forward but never
+// exposed with the public API.
+func (s *Server) startDiagnostics() {
+	if s.cfg.DiagnosticsPort <= 0 {
+		return
+	}
+
+	addr := fmt.Sprintf("127.0.0.1:%d", s.cfg.DiagnosticsPort)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           diagnosticsHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("addr", addr).Msg("Starting diagnostics server")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Str("addr", addr).Msg("Diagnostics server failed")
+		}
+	}()
+}
+
+// RuntimeSnapshot is a point-in-time view of the process
+type RuntimeSnapshot struct {
+	Version       version.Info `json:"version"`
+	UptimeSeconds int64        `json:"uptime_seconds"`
+	Goroutines    int          `json:"goroutines"`
+	NumCPU        int          `json:"num_cpu"`
+	GOMAXPROCS    int          `json:"gomaxprocs"`
+	Heap          HeapStats    `json:"heap"`
+	GC            GCStats      `json:"gc"`
+}
+
+// HeapStats are the memory figures relevant to tracking memory growth
+type HeapStats struct {
+	AllocBytes      uint64 `json:"alloc_bytes"`
+	InuseBytes      uint64 `json:"inuse_bytes"`
+	IdleBytes       uint64 `json:"idle_bytes"`
+	ReleasedBytes   uint64 `json:"released_bytes"`
+	Objects         uint64 `json:"objects"`
+	SysBytes        uint64 `json:"sys_bytes"`
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+}
+
+// GCStats summarise garbage collector activity
+type GCStats struct {
+	NumGC         uint32     `json:"num_gc"`
+	NextGCBytes   uint64     `json:"next_gc_bytes"`
+	LastGC        *time.Time `json:"last_gc,omitempty"`
+	LastPauseNs   uint64     `json:"last_pause_ns"`
+	PauseTotalNs  uint64     `json:"pause_total_ns"`
+	GCCPUFraction float64    `json:"gc_cpu_fraction"`
+	NumForcedGC   uint32     `json:"num_forced_gc"`
+}
+
+// runtimeSnapshot reports goroutine, heap and GC figures
+func (s *Server) runtimeSnapshot(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	snapshot := RuntimeSnapshot{
+		Version:       version.Get(),
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Heap: HeapStats{
+			AllocBytes:      mem.HeapAlloc,
+			InuseBytes:      mem.HeapInuse,
+			IdleBytes:       mem.HeapIdle,
+			ReleasedBytes:   mem.HeapReleased,
+			Objects:         mem.HeapObjects,
+			SysBytes:        mem.Sys,
+			TotalAllocBytes: mem.TotalAlloc,
+		},
+		GC: GCStats{
+			NumGC:         mem.NumGC,
+			NextGCBytes:   mem.NextGC,
+			PauseTotalNs:  mem.PauseTotalNs,
+			GCCPUFraction: mem.GCCPUFraction,
+			NumForcedGC:   mem.NumForcedGC,
+		},
+	}
+	if mem.NumGC > 0 {
+		lastGC := time.Unix(0, int64(mem.LastGC)).UTC()
+		snapshot.GC.LastGC = &lastGC
+		snapshot.GC.LastPauseNs = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
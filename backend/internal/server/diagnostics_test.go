@@ -0,0 +1,42 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDiagnosticsHandler(t *testing.T) {
+	handler := diagnosticsHandler()
+
+	for _, path := range []string{"/debug/pprof/", "/debug/vars"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected 200 from %s, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestRuntimeSnapshot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/runtime", (&Server{}).runtimeSnapshot)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/runtime", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var snapshot RuntimeSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.Goroutines == 0 || snapshot.Heap.SysBytes == 0 || snapshot.Version.GoVersion == "" {
+		t.Errorf("Expected a populated snapshot, got %+v", snapshot)
+	}
+}
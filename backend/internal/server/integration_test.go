@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+)
+
+// Run with -update to rewrite the golden files after an intended change
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+const testAdminKey = "TOD_ADMIN_2026_SECURE_KEY"
+
+// fixtures is the content loaded from testdata/fixtures.json
+type fixtures struct {
+	Categories []models.Category `json:"categories"`
+	Tasks      []models.Task     `json:"tasks"`
+}
+
+// newIntegrationServer starts a full server, routes and middleware
+// included, on a fresh SQLite database seeded with the fixtures
+func newIntegrationServer(t *testing.T) *Server {
+	t.Helper()
+	srv := newTestServer(t, &config.Config{})
+
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fixtures: %v", err)
+	}
+	var fx fixtures
+	if err := json.Unmarshal(data, &fx); err != nil {
+		t.Fatalf("Failed to parse fixtures: %v", err)
+	}
+	for i := range fx.Categories {
+		if err := srv.db.Create(&fx.Categories[i]).Error; err != nil {
+			t.Fatalf("Failed to load category fixture: %v", err)
+		}
+	}
+	for i := range fx.Tasks {
+		if err := srv.db.Create(&fx.Tasks[i]).Error; err != nil {
+			t.Fatalf("Failed to load task fixture: %v", err)
+		}
+	}
+	return srv
+}
+
+// integrationCase is one request against the full server. Its response
+// body is compared with testdata/golden/<name>.json; fields named in scrub
+// vary between runs (generated IDs, timestamps) and are masked first.
+type integrationCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	admin  bool
+	status int
+	scrub  []string
+}
+
+func TestIntegration(t *testing.T) {
+	srv := newIntegrationServer(t)
+
+	tests := []integrationCase{
+		{name: "languages", method: "GET", path: "/api/v1/languages", status: http.StatusOK},
+		{name: "categories_list", method: "GET", path: "/api/v1/categories", status: http.StatusOK},
+		{name: "categories_list_v2", method: "GET", path: "/api/v2/categories", status: http.StatusOK},
+		{name: "tasks_list", method: "GET", path: "/api/v1/tasks?sort_by=created_at&sort_order=asc", status: http.StatusOK},
+		{name: "tasks_list_v2", method: "GET", path: "/api/v2/tasks?sort_by=created_at&sort_order=asc", status: http.StatusOK},
+		{name: "tasks_inactive_forbidden", method: "GET", path: "/api/v1/tasks?active=false", status: http.StatusForbidden},
+		{name: "tasks_random_unauthorized", method: "GET", path: "/api/v1/tasks/random", status: http.StatusUnauthorized},
+		{name: "tasks_random", method: "GET", path: "/api/v1/tasks/random?category_id=11111111-0000-0000-0000-000000000002", admin: true, status: http.StatusOK},
+		{name: "tasks_random_none", method: "GET", path: "/api/v2/tasks/random?type=truth&category_id=11111111-0000-0000-0000-000000000002", admin: true, status: http.StatusNotFound},
+		{name: "tasks_get_unauthorized", method: "GET", path: "/api/v1/tasks/22222222-0000-0000-0000-000000000001", status: http.StatusUnauthorized},
+		{name: "tasks_get", method: "GET", path: "/api/v1/tasks/22222222-0000-0000-0000-000000000001", admin: true, status: http.StatusOK},
+		{name: "tasks_get_missing_v2", method: "GET", path: "/api/v2/tasks/22222222-0000-0000-0000-000000000009", admin: true, status: http.StatusNotFound},
+		{name: "tasks_code", method: "GET", path: "/api/v1/tasks/code/t-ooo1", status: http.StatusOK},
+		{name: "tasks_code_invalid_v2", method: "GET", path: "/api/v2/tasks/code/T-U!", status: http.StatusBadRequest},
+		{name: "tasks_count", method: "GET", path: "/api/v1/tasks/count", admin: true, status: http.StatusOK},
+		{name: "embed_daily_task_unsigned_v2", method: "GET", path: "/api/v2/embed/daily-task?language=en", status: http.StatusUnauthorized},
+		{
+			name:   "tasks_create",
+			method: "POST",
+			path:   "/api/v1/tasks",
+			body:   `{"category_id":"11111111-0000-0000-0000-000000000001","type":"truth","text":"Who was your first crush?","language":"en"}`,
+			admin:  true,
+			status: http.StatusCreated,
+			scrub:  []string{"id", "short_code", "created_at", "updated_at"},
+		},
+		{
+			name:   "tasks_create_invalid_v2",
+			method: "POST",
+			path:   "/api/v2/tasks",
+			body:   `{"category_id":"11111111-0000-0000-0000-000000000001","type":"truth"}`,
+			admin:  true,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.admin {
+				req.Header.Set(middleware.AuthHeader, testAdminKey)
+			}
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			checkGolden(t, tt.name, w.Body.Bytes(), tt.scrub)
+		})
+	}
+}
+
+// checkGolden compares a JSON body with its golden file, or rewrites the
+// file with -update
+func checkGolden(t *testing.T, name string, body []byte, scrub []string) {
+	t.Helper()
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("Response is not JSON: %v: %s", err, body)
+	}
+	scrubFields(v, scrub)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("Failed to encode response: %v", err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Response differs from %s (run with -update if intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// scrubFields masks the values of the named fields at any depth
+func scrubFields(v interface{}, fields []string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			for _, field := range fields {
+				if key == field {
+					v[key] = "<scrubbed>"
+				}
+			}
+			scrubFields(value, fields)
+		}
+	case []interface{}:
+		for _, value := range v {
+			scrubFields(value, fields)
+		}
+	}
+}
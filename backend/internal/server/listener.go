@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// systemdListenFDStart is the first file descriptor systemd passes to an
+// activated service (SD_LISTEN_FDS_START)
+const systemdListenFDStart = 3
+
+// listen opens the API listener: a socket inherited from systemd socket
+// activation, a Unix domain socket when LISTEN_SOCKET is set, or TCP on PORT.
+func (s *Server) listen() (net.Listener, error) {
+	ln, err := systemdListener()
+	if err != nil {
+		return nil, err
+	}
+	if ln != nil {
+		log.Info().Str("addr", ln.Addr().String()).Msg("Using systemd-activated listener")
+		return ln, nil
+	}
+
+	if s.cfg.ListenSocket != "" {
+		return listenUnix(s.cfg.ListenSocket, s.cfg.ListenSocketMode)
+	}
+
+	return net.Listen("tcp", fmt.Sprintf(":%s", s.cfg.Port))
+}
+
+// listenUnix listens on a Unix domain socket at path, replacing a socket
+// left behind by a previous run, and sets its permissions to mode.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen socket %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// systemdListener returns the listener passed by systemd socket activation,
+// or nil when the process was not socket-activated. Only the first socket is
+// used; the environment is cleared so child processes do not inherit it.
+func systemdListener() (net.Listener, error) {
+	fds, ok := systemdListenFDs(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getpid())
+	if !ok {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	if fds > 1 {
+		log.Warn().Int("fds", fds).Msg("systemd passed several sockets, using the first")
+	}
+
+	file := os.NewFile(uintptr(systemdListenFDStart), "systemd-listener")
+	if file == nil {
+		return nil, errors.New("systemd listener file descriptor is invalid")
+	}
+	defer file.Close()
+
+	ln, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use systemd listener: %w", err)
+	}
+	return ln, nil
+}
+
+// systemdListenFDs reports how many sockets systemd passed to process pid
+func systemdListenFDs(listenPID, listenFDs string, pid int) (int, bool) {
+	if listenPID == "" || listenFDs == "" {
+		return 0, false
+	}
+	if p, err := strconv.Atoi(listenPID); err != nil || p != pid {
+		return 0, false
+	}
+	fds, err := strconv.Atoi(listenFDs)
+	if err != nil || fds < 1 {
+		return 0, false
+	}
+	return fds, true
+}
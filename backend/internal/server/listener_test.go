@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tod.sock")
+
+	ln, err := listenUnix(path, 0600)
+	if err != nil {
+		t.Fatalf("listenUnix failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected socket file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to dial socket: %v", err)
+	}
+	conn.Close()
+
+	// Simulate a socket left behind by a crashed process
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	ln, err = listenUnix(path, 0600)
+	if err != nil {
+		t.Fatalf("Expected stale socket to be replaced: %v", err)
+	}
+	ln.Close()
+}
+
+func TestListenUnix_RefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := listenUnix(path, 0600); err == nil {
+		t.Fatal("Expected error for a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected regular file to be left alone: %v", err)
+	}
+}
+
+func TestSystemdListenFDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		listenPID string
+		listenFDs string
+		wantFDs   int
+		wantOK    bool
+	}{
+		{"not activated", "", "", 0, false},
+		{"activated", "42", "1", 1, true},
+		{"several sockets", "42", "2", 2, true},
+		{"other process", "7", "1", 0, false},
+		{"no sockets", "42", "0", 0, false},
+		{"malformed", "42", "x", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fds, ok := systemdListenFDs(tt.listenPID, tt.listenFDs, 42)
+			if fds != tt.wantFDs || ok != tt.wantOK {
+				t.Errorf("Expected (%d, %v), got (%d, %v)", tt.wantFDs, tt.wantOK, fds, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,567 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/handlers"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/metrics"
+	"github.com/truthordare/backend/internal/middleware"
+	"github.com/truthordare/backend/internal/models"
+	"golang.org/x/net/websocket"
+)
+
+// Room limits
+const (
+	// roomMaxConnections bounds the players' devices in one room
+	roomMaxConnections = 32
+	// roomMaxSpectators bounds the spectators in one room, counted apart
+	// from the players' devices
+	roomMaxSpectators = 16
+	// roomMaxMessageBytes bounds a message from a device
+	roomMaxMessageBytes = 4 << 10
+	// roomSendBuffer is the events queued for a device; a device falling
+	// further behind is disconnected
+	roomSendBuffer = 64
+	// roomReplayLimit bounds the missed events sent to a device resuming;
+	// one that missed more only gets the state of the game
+	roomReplayLimit = 32
+	// roomIdleTimeout disconnects devices that sent nothing, not even a
+	// ping, for this long
+	roomIdleTimeout = 2 * time.Minute
+	// roomWriteTimeout bounds sending one event to a device
+	roomWriteTimeout = 10 * time.Second
+)
+
+// Room messages sent by devices
+const (
+	RoomDraw     = "draw"
+	RoomSkip     = "skip"
+	RoomComplete = "complete"
+	RoomPing     = "ping"
+)
+
+// Roles devices join a room in, see Join
+const (
+	RoomRolePlayer    = "player"
+	RoomRoleSpectator = "spectator"
+)
+
+// Room events sent to devices
+const (
+	RoomEventState     = "state"
+	RoomEventPresence  = "presence"
+	RoomEventDrawn     = game.EventDrawn
+	RoomEventSkipped   = game.EventSkipped
+	RoomEventCompleted = game.EventCompleted
+	RoomEventExpired   = game.EventTurnExpired
+	RoomEventForfeit   = game.EventForfeit
+	RoomEventPong      = "pong"
+	RoomEventError     = "error"
+
+	// Host controls, see the session endpoints
+	RoomEventKicked            = game.EventKicked
+	RoomEventLocked            = game.EventLocked
+	RoomEventUnlocked          = game.EventUnlocked
+	RoomEventCategoriesChanged = game.EventCategoriesChanged
+	RoomEventEnded             = game.EventEnded
+	RoomEventCustomTaskAdded   = game.EventCustomTaskAdded
+	RoomEventExclusionsChanged = game.EventExclusionsChanged
+)
+
+var errRoomFull = errors.New("room is full")
+
+// RoomMessage is a message from a device in a room
+type RoomMessage struct {
+	Type string `json:"type"`
+	// TaskType limits a draw to truth or dare; either when empty
+	TaskType string `json:"task_type,omitempty"`
+}
+
+// RoomEvent is an event sent to the devices in a room
+type RoomEvent struct {
+	Type string `json:"type"`
+	// ID numbers the session's events, and on a state event is the last
+	// one's. Devices resuming pass the last ID they got as last_event_id.
+	ID int `json:"id,omitempty"`
+	// Player is who the task of a drawn, skipped or completed event is
+	// for, or who was kicked
+	Player string               `json:"player,omitempty"`
+	Task   *models.TaskResponse `json:"task,omitempty"`
+	// Round, CurrentPlayer and Players describe the game after the event
+	Round         int      `json:"round,omitempty"`
+	CurrentPlayer string   `json:"current_player,omitempty"`
+	Players       []string `json:"players,omitempty"`
+	// CategoryIDs are the categories drawn from after a categories_changed
+	// event
+	CategoryIDs []string `json:"category_ids,omitempty"`
+	// Deadline is when the turn of a drawn task expires in timed games,
+	// and TimeLeftMS how long that was from sending the event, for devices
+	// whose clock is off
+	Deadline   *time.Time `json:"deadline,omitempty"`
+	TimeLeftMS int64      `json:"time_left_ms,omitempty"`
+	// Locked and Ended describe the room on a state event
+	Locked bool `json:"locked,omitempty"`
+	Ended  bool `json:"ended,omitempty"`
+	// Connections counts the players' devices in the room and Spectators
+	// the spectators
+	Connections int    `json:"connections,omitempty"`
+	Spectators  int    `json:"spectators,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// RoomManager keeps the WebSocket rooms of game sessions. Devices join a
+// room by the session's code; the session's events, such as draws, skips
+// and completions from any of them or over HTTP, are broadcast to all.
+// Rooms live in memory on one instance, from the first device joining
+// until the last one leaves; the events they broadcast are stored with the
+// session.
+type RoomManager struct {
+	games *game.Service
+	cfg   *config.Config
+
+	mu     sync.Mutex
+	rooms  map[string]*room
+	closed bool
+}
+
+// NewRoomManager creates a new RoomManager broadcasting the events of games
+func NewRoomManager(games *game.Service, cfg *config.Config) *RoomManager {
+	m := &RoomManager{games: games, cfg: cfg, rooms: make(map[string]*room)}
+	games.Listen(m.publish)
+	return m
+}
+
+// room is the hub of one game session's devices
+type room struct {
+	sessionID string
+
+	mu    sync.Mutex
+	conns map[*roomConn]struct{}
+	// seq is the last event broadcast
+	seq int
+}
+
+// roomConn is a device in a room. Events are queued on send and written
+// by the connection's own goroutine, so a slow device never holds up a
+// broadcast.
+type roomConn struct {
+	ws      *websocket.Conn
+	mapTask func(*models.Task) models.TaskResponse
+	send    chan RoomEvent
+	// spectator devices get the events but cannot draw, skip or complete
+	spectator bool
+}
+
+// Join upgrades the request to a WebSocket joining the room of the game
+// session with the code in the path. A device resuming passes the last
+// event ID it got as last_event_id to be sent the events it missed; one
+// only showing the game, such as a TV, joins with role=spectator.
+func (m *RoomManager) Join(c *gin.Context) {
+	session, err := m.games.FindByCode(c.Param("code"))
+	if errors.Is(err, game.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Game session not found or expired",
+		})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find game session by code")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to find game session",
+		})
+		return
+	}
+	if session.Locked {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "locked",
+			Message: "The host locked the room",
+		})
+		return
+	}
+	role := c.DefaultQuery("role", RoomRolePlayer)
+	if role != RoomRolePlayer && role != RoomRoleSpectator {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid fields: role must be player or spectator",
+			Fields:  []models.FieldError{{Field: "role", Message: "must be player or spectator"}},
+		})
+		return
+	}
+	lastEventID := -1
+	if value := c.Query("last_event_id"); value != "" {
+		lastEventID, err = strconv.Atoi(value)
+		if err != nil || lastEventID < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "Invalid fields: last_event_id must be a non-negative integer",
+				Fields:  []models.FieldError{{Field: "last_event_id", Message: "must be a non-negative integer"}},
+			})
+			return
+		}
+	}
+	if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Expected a WebSocket upgrade",
+		})
+		return
+	}
+
+	middleware.SkipEnvelope(c)
+	mapTask := handlers.MapTask(c)
+	websocket.Server{
+		Handshake: m.checkOrigin,
+		Handler:   func(ws *websocket.Conn) { m.serve(ws, session, role == RoomRoleSpectator, lastEventID, mapTask) },
+	}.ServeHTTP(c.Writer, c.Request)
+}
+
+// Close disconnects every device. Call it on shutdown, as hijacked
+// connections outlive the HTTP server.
+func (m *RoomManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for _, r := range m.rooms {
+		r.mu.Lock()
+		for conn := range r.conns {
+			conn.ws.Close()
+		}
+		r.mu.Unlock()
+	}
+}
+
+// DeviceSamples returns the devices in open rooms by role, for the
+// tod_game_room_devices metric
+func (m *RoomManager) DeviceSamples() []metrics.LabeledValue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var players, spectators int
+	for _, r := range m.rooms {
+		r.mu.Lock()
+		p, s := r.count()
+		r.mu.Unlock()
+		players += p
+		spectators += s
+	}
+	return []metrics.LabeledValue{
+		{Labels: metrics.Labels{"role": RoomRolePlayer}, Value: float64(players)},
+		{Labels: metrics.Labels{"role": RoomRoleSpectator}, Value: float64(spectators)},
+	}
+}
+
+// checkOrigin accepts browsers on the CORS origins and native apps, which
+// send no Origin
+func (m *RoomManager) checkOrigin(cfg *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin != "" && !originAllowed(m.cfg, origin) {
+		return errors.New("origin not allowed")
+	}
+	return nil
+}
+
+// serve runs a device's connection until it leaves. lastEventID is the
+// last event a resuming device got, -1 for a new one.
+func (m *RoomManager) serve(ws *websocket.Conn, session *game.GameSession, spectator bool, lastEventID int, mapTask func(*models.Task) models.TaskResponse) {
+	ws.MaxPayloadBytes = roomMaxMessageBytes
+	conn := &roomConn{ws: ws, mapTask: mapTask, send: make(chan RoomEvent, roomSendBuffer), spectator: spectator}
+	r, err := m.join(session, conn)
+	if err != nil {
+		_ = websocket.JSON.Send(ws, RoomEvent{Type: RoomEventError, Error: "room_full", Message: "The room is full or closing"})
+		return
+	}
+	defer m.leave(r, conn)
+	go conn.writeLoop()
+
+	m.welcome(r, conn, lastEventID)
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(roomIdleTimeout))
+		var msg RoomMessage
+		err := websocket.JSON.Receive(ws, &msg)
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			conn.deliver(RoomEvent{Type: RoomEventError, Error: "validation_error", Message: "Messages must be JSON objects with a type"})
+			continue
+		}
+		if err != nil {
+			return
+		}
+		m.handle(r, conn, msg)
+	}
+}
+
+// join adds a device to the room of a session, opening the room
+func (m *RoomManager) join(session *game.GameSession, conn *roomConn) (*room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, errRoomFull
+	}
+
+	r, ok := m.rooms[session.ID]
+	if !ok {
+		r = &room{sessionID: session.ID, conns: make(map[*roomConn]struct{}), seq: session.LastEventID}
+		m.rooms[session.ID] = r
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	players, spectators := r.count()
+	if conn.spectator && spectators >= roomMaxSpectators || !conn.spectator && players >= roomMaxConnections {
+		return nil, errRoomFull
+	}
+	r.conns[conn] = struct{}{}
+	return r, nil
+}
+
+// leave removes a device from its room, closing the room after the last
+func (m *RoomManager) leave(r *room, conn *roomConn) {
+	r.mu.Lock()
+	delete(r.conns, conn)
+	close(conn.send)
+	conn.ws.Close()
+	if len(r.conns) > 0 {
+		r.broadcast(r.presence(), nil)
+	}
+	r.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.conns) == 0 && m.rooms[r.sessionID] == r {
+		delete(m.rooms, r.sessionID)
+	}
+}
+
+// welcome sends a device that joined the state of the game, with the task
+// drawn last if it is still open, and tells the others it joined. A device
+// resuming is first sent the events after lastEventID, unless it missed
+// too many.
+func (m *RoomManager) welcome(r *room, conn *roomConn, lastEventID int) {
+	// Events wait for the state, so the device gets every later one
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, err := m.games.Find(r.sessionID)
+	if err != nil {
+		conn.deliver(roomError(err))
+		return
+	}
+	drawn, err := m.games.OpenDraw(session)
+	if err != nil {
+		conn.deliver(roomError(err))
+		return
+	}
+	r.seq = max(r.seq, session.LastEventID)
+
+	if missed := session.LastEventID - lastEventID; lastEventID >= 0 && missed > 0 && missed <= roomReplayLimit {
+		events, err := m.games.Events(session.ID, lastEventID, missed)
+		if err != nil {
+			conn.deliver(roomError(err))
+			return
+		}
+		for _, event := range events {
+			conn.deliverTask(roomEvent(event), event.Task)
+		}
+	}
+
+	event := RoomEvent{
+		Type:          RoomEventState,
+		ID:            session.LastEventID,
+		Round:         session.Round,
+		CurrentPlayer: session.CurrentPlayer(),
+		Players:       session.Players,
+		Locked:        session.Locked,
+		Ended:         session.Ended(),
+	}
+	event.Connections, event.Spectators = r.count()
+	var task *models.Task
+	if drawn != nil {
+		event.Player, task = drawn.Player, drawn.Task
+		event.Deadline = session.TurnDeadline
+		event.TimeLeftMS = game.TimeLeft(session.TurnDeadline).Milliseconds()
+	}
+	conn.deliverTask(event, task)
+	presence := r.presence()
+	for other := range r.conns {
+		if other != conn {
+			other.deliver(presence)
+		}
+	}
+}
+
+// handle acts on a message from a device. The events of draws, skips and
+// completions reach the room through publish.
+func (m *RoomManager) handle(r *room, conn *roomConn, msg RoomMessage) {
+	if conn.spectator && msg.Type != RoomPing {
+		conn.deliver(RoomEvent{Type: RoomEventError, Error: "forbidden", Message: "Spectators can only ping"})
+		return
+	}
+
+	switch msg.Type {
+	case RoomPing:
+		conn.deliver(RoomEvent{Type: RoomEventPong})
+
+	case RoomDraw:
+		if msg.TaskType != "" && msg.TaskType != models.TaskTypeTruth && msg.TaskType != models.TaskTypeDare {
+			conn.deliver(RoomEvent{Type: RoomEventError, Error: "validation_error", Message: "task_type must be truth or dare"})
+			return
+		}
+		if _, _, _, err := m.games.Next(r.sessionID, msg.TaskType); err != nil {
+			conn.deliver(roomError(err))
+		}
+
+	case RoomSkip, RoomComplete:
+		eventType := game.EventCompleted
+		if msg.Type == RoomSkip {
+			eventType = game.EventSkipped
+		}
+		if _, err := m.games.Resolve(r.sessionID, eventType); err != nil {
+			conn.deliver(roomError(err))
+		}
+
+	default:
+		conn.deliver(RoomEvent{Type: RoomEventError, Error: "validation_error", Message: "type must be draw, skip, complete or ping"})
+	}
+}
+
+// publish broadcasts an event of a session to the devices in its room, if
+// it is open. Events recorded concurrently may arrive out of order; those
+// missing in between are loaded, so devices get every event in order.
+func (m *RoomManager) publish(event game.Event) {
+	m.mu.Lock()
+	r := m.rooms[event.SessionID]
+	m.mu.Unlock()
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.Seq <= r.seq {
+		return
+	}
+	events := []game.Event{event}
+	if missed := event.Seq - r.seq - 1; missed > 0 {
+		earlier, err := m.games.Events(r.sessionID, r.seq, min(missed, roomReplayLimit))
+		if err != nil {
+			log.Error().Err(err).Str("session_id", r.sessionID).Msg("Failed to load missed room events")
+		}
+		events = append(earlier, event)
+	}
+	for _, e := range events {
+		r.broadcast(roomEvent(e), e.Task)
+		r.seq = e.Seq
+	}
+}
+
+// roomEvent maps a session event to a room event, without its task
+func roomEvent(event game.Event) RoomEvent {
+	return RoomEvent{
+		Type:          event.Type,
+		ID:            event.Seq,
+		Player:        event.Player,
+		Round:         event.Round,
+		CurrentPlayer: event.CurrentPlayer,
+		Players:       event.Players,
+		CategoryIDs:   event.CategoryIDs,
+		Deadline:      event.Deadline,
+		TimeLeftMS:    game.TimeLeft(event.Deadline).Milliseconds(),
+	}
+}
+
+// count returns the players' devices and the spectators in the room. The
+// caller holds r.mu.
+func (r *room) count() (players, spectators int) {
+	for conn := range r.conns {
+		if conn.spectator {
+			spectators++
+		} else {
+			players++
+		}
+	}
+	return players, spectators
+}
+
+// presence returns the presence event of the room. The caller holds r.mu.
+func (r *room) presence() RoomEvent {
+	players, spectators := r.count()
+	return RoomEvent{Type: RoomEventPresence, Connections: players, Spectators: spectators}
+}
+
+// broadcast sends an event to every device in the room, with the task
+// mapped to each device's API version. The caller holds r.mu.
+func (r *room) broadcast(event RoomEvent, task *models.Task) {
+	for conn := range r.conns {
+		conn.deliverTask(event, task)
+	}
+}
+
+// deliverTask queues an event with the task, if any, mapped to the
+// device's API version
+func (c *roomConn) deliverTask(event RoomEvent, task *models.Task) {
+	if task != nil {
+		response := c.mapTask(task)
+		event.Task = &response
+	}
+	c.deliver(event)
+}
+
+// deliver queues an event for the device, disconnecting a device too slow
+// to keep up. The caller holds the room's lock or is the device's reader.
+func (c *roomConn) deliver(event RoomEvent) {
+	select {
+	case c.send <- event:
+	default:
+		c.ws.Close()
+	}
+}
+
+// writeLoop writes queued events until the device leaves
+func (c *roomConn) writeLoop() {
+	for event := range c.send {
+		_ = c.ws.SetWriteDeadline(time.Now().Add(roomWriteTimeout))
+		if err := websocket.JSON.Send(c.ws, event); err != nil {
+			c.ws.Close()
+			return
+		}
+	}
+}
+
+// roomError maps game session errors to an error event
+func roomError(err error) RoomEvent {
+	switch {
+	case errors.Is(err, game.ErrNotFound):
+		return RoomEvent{Type: RoomEventError, Error: "not_found", Message: "Game session not found or expired"}
+	case errors.Is(err, game.ErrNoTask):
+		return RoomEvent{Type: RoomEventError, Error: "not_found", Message: "No matching task found"}
+	case errors.Is(err, game.ErrNoOpenTask):
+		return RoomEvent{Type: RoomEventError, Error: "not_found", Message: "No task is waiting to be skipped or completed"}
+	case errors.Is(err, game.ErrConflict):
+		return RoomEvent{Type: RoomEventError, Error: "conflict", Message: "Another draw for this turn finished first"}
+	case errors.Is(err, game.ErrEnded):
+		return RoomEvent{Type: RoomEventError, Error: "ended", Message: "The game has ended"}
+	case errors.Is(err, game.ErrTurnExpired):
+		return RoomEvent{Type: RoomEventError, Error: "turn_expired", Message: "The turn expired before the task was skipped or completed"}
+	case errors.Is(err, maintenance.ErrReadOnly):
+		return RoomEvent{Type: RoomEventError, Error: "read_only", Message: "Game sessions are paused while the service is in read-only mode"}
+	default:
+		log.Error().Err(err).Msg("Failed to update room game session")
+		return RoomEvent{Type: RoomEventError, Error: "database_error", Message: "Failed to update the game session"}
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/game"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/repository"
+	"golang.org/x/net/websocket"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRoomManager(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rooms.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Task{}, &game.GameSession{}, &game.Event{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	category := &models.Category{Label: models.MultilingualText{"en": "Party"}, AgeGroup: models.AgeGroupTeen, IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	if err := db.Create(&models.Task{Text: "Sing a song", Language: "en", Type: models.TaskTypeDare, CategoryID: category.ID}).Error; err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	cfg := &config.Config{Env: "production", CORSOrigins: []string{"http://localhost"}}
+	games := game.NewService(db, repository.NewTaskRepository(db), nil, &config.GameConfig{SessionTTLHours: 1, SessionHistory: 10}, maintenance.New(false))
+	session := &game.GameSession{Players: models.StringArray{"Ana", "Ben"}}
+	if err := games.Create(session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	rooms := NewRoomManager(games, cfg)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws/rooms/:code", rooms.Join)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	defer rooms.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/rooms/"
+
+	dial := func(code, origin string) (*websocket.Conn, error) {
+		return websocket.Dial(url+code, "", origin)
+	}
+	// next returns the next event of a type, skipping others
+	next := func(ws *websocket.Conn, eventType string) RoomEvent {
+		t.Helper()
+		for {
+			_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+			var event RoomEvent
+			if err := websocket.JSON.Receive(ws, &event); err != nil {
+				t.Fatalf("Expected a %s event: %v", eventType, err)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		}
+	}
+
+	ana, err := dial(strings.ToLower(session.Code), "http://localhost")
+	if err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+	defer ana.Close()
+	if state := next(ana, RoomEventState); state.CurrentPlayer != "Ana" || len(state.Players) != 2 || state.Connections != 1 {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	ben, err := dial(session.Code, "http://localhost")
+	if err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+	defer ben.Close()
+	next(ben, RoomEventState)
+	if presence := next(ana, RoomEventPresence); presence.Connections != 2 {
+		t.Errorf("Expected 2 connections, got %d", presence.Connections)
+	}
+
+	if err := websocket.JSON.Send(ana, RoomMessage{Type: RoomDraw}); err != nil {
+		t.Fatalf("Failed to draw: %v", err)
+	}
+	for _, ws := range []*websocket.Conn{ana, ben} {
+		drawn := next(ws, RoomEventDrawn)
+		if drawn.Player != "Ana" || drawn.CurrentPlayer != "Ben" || drawn.Round != 1 || drawn.Task == nil || drawn.Task.Text != "Sing a song" {
+			t.Errorf("Unexpected drawn event: %+v", drawn)
+		}
+	}
+
+	// A device joining late sees the open task
+	cy, err := dial(session.Code, "http://localhost")
+	if err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+	if state := next(cy, RoomEventState); state.Task == nil || state.Player != "Ana" {
+		t.Errorf("Expected the open task in the state, got %+v", state)
+	}
+	cy.Close()
+	if presence := next(ana, RoomEventPresence); presence.Connections != 3 {
+		t.Errorf("Expected 3 connections, got %d", presence.Connections)
+	}
+	if presence := next(ana, RoomEventPresence); presence.Connections != 2 {
+		t.Errorf("Expected 2 connections after leaving, got %d", presence.Connections)
+	}
+
+	if err := websocket.JSON.Send(ben, RoomMessage{Type: RoomComplete}); err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+	if completed := next(ana, RoomEventCompleted); completed.Player != "Ana" || completed.Task == nil {
+		t.Errorf("Unexpected completed event: %+v", completed)
+	}
+	// A device resuming gets the events it missed, then the state
+	resumed, err := dial(session.Code+"?last_event_id=1", "http://localhost")
+	if err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if missed := next(resumed, RoomEventCompleted); missed.ID != 2 || missed.Task == nil {
+		t.Errorf("Expected the missed completion, got %+v", missed)
+	}
+	if state := next(resumed, RoomEventState); state.ID != 2 || state.Task != nil {
+		t.Errorf("Expected the state after the last event, got %+v", state)
+	}
+	resumed.Close()
+
+	if err := websocket.JSON.Send(ben, RoomMessage{Type: RoomSkip}); err != nil {
+		t.Fatalf("Failed to skip: %v", err)
+	}
+	if failed := next(ben, RoomEventError); failed.Error != "not_found" {
+		t.Errorf("Expected no task to skip, got %+v", failed)
+	}
+
+	if err := websocket.JSON.Send(ben, RoomMessage{Type: RoomPing}); err != nil {
+		t.Fatalf("Failed to ping: %v", err)
+	}
+	next(ben, RoomEventPong)
+
+	// Spectators get the events, are counted apart and cannot draw
+	tv, err := dial(session.Code+"?role=spectator", "http://localhost")
+	if err != nil {
+		t.Fatalf("Failed to join as a spectator: %v", err)
+	}
+	defer tv.Close()
+	if state := next(tv, RoomEventState); state.Connections != 2 || state.Spectators != 1 {
+		t.Errorf("Unexpected spectator state: %+v", state)
+	}
+	// Skip the presence events of the device that resumed
+	presence := next(ana, RoomEventPresence)
+	for presence.Connections != 2 || presence.Spectators == 0 {
+		presence = next(ana, RoomEventPresence)
+	}
+	if presence.Spectators != 1 {
+		t.Errorf("Expected a spectator, got %+v", presence)
+	}
+	if err := websocket.JSON.Send(tv, RoomMessage{Type: RoomDraw}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if failed := next(tv, RoomEventError); failed.Error != "forbidden" {
+		t.Errorf("Expected spectators to be refused draws, got %+v", failed)
+	}
+	if devices := rooms.DeviceSamples(); devices[0].Value != 2 || devices[1].Value != 1 {
+		t.Errorf("Unexpected device samples: %+v", devices)
+	}
+
+	if _, err := dial(session.Code, "https://evil.example.com"); err == nil {
+		t.Error("Expected other origins to be refused")
+	}
+
+	// Draws over HTTP are broadcast too
+	if _, _, _, err := games.Next(session.ID, ""); err != nil {
+		t.Fatalf("Failed to draw: %v", err)
+	}
+	if drawn := next(ben, RoomEventDrawn); drawn.Player != "Ben" || drawn.ID != 3 {
+		t.Errorf("Unexpected drawn event: %+v", drawn)
+	}
+	if drawn := next(tv, RoomEventDrawn); drawn.Task == nil {
+		t.Errorf("Expected spectators to get draws, got %+v", drawn)
+	}
+
+	resp, err := http.Get(srv.URL + "/ws/rooms/" + session.Code + "?role=host")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown role, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/ws/rooms/" + session.Code + "?last_event_id=-1")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative last_event_id, got %d", resp.StatusCode)
+	}
+
+	// Host controls are broadcast, and locked rooms take no more devices
+	found, err := games.Find(session.ID)
+	if err != nil {
+		t.Fatalf("Failed to find session: %v", err)
+	}
+	if err := games.Kick(found, "Ana"); err != nil {
+		t.Fatalf("Failed to kick: %v", err)
+	}
+	if kicked := next(ben, RoomEventKicked); kicked.Player != "Ana" || len(kicked.Players) != 1 || kicked.CurrentPlayer != "Ben" {
+		t.Errorf("Unexpected kicked event: %+v", kicked)
+	}
+	if err := games.Lock(found, true); err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+	next(ana, RoomEventLocked)
+	if _, err := dial(session.Code, "http://localhost"); err == nil {
+		t.Error("Expected a locked room to refuse devices")
+	}
+
+	resp, err = http.Get(srv.URL + "/ws/rooms/NOPE42")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown code, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/diskspace"
+	"github.com/truthordare/backend/internal/integrations"
+)
+
+// Self-check outcomes, from best to worst
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// Self-check thresholds
+const (
+	// aiPingTimeout bounds the request to the AI provider
+	aiPingTimeout = 5 * time.Second
+	// diskWarnPercent is the free space worth acting on before the disk
+	// guard's DISK_GUARD_MIN_FREE_MB is reached
+	diskWarnPercent = 10
+	// clockWarnSkew is the clock skew worth fixing before it reaches
+	// integrations.MaxSkew, where bot requests are refused
+	clockWarnSkew = 30 * time.Second
+)
+
+// SelfCheck is the outcome of one self-check, with what to do about it
+// when it did not pass
+type SelfCheck struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// DiagnosticsReport is the outcome of every self-check. Status is the
+// worst outcome.
+type DiagnosticsReport struct {
+	Status    string      `json:"status"`
+	CheckedAt time.Time   `json:"checked_at"`
+	Checks    []SelfCheck `json:"checks"`
+}
+
+// diagnostics runs the self-checks for incident triage. It always answers
+// 200; the report says what failed.
+func (s *Server) diagnostics(c *gin.Context) {
+	c.JSON(http.StatusOK, s.diagnose(c.Request.Context(), ai.GetClient()))
+}
+
+// diagnose runs every self-check in turn
+func (s *Server) diagnose(ctx context.Context, client *ai.Client) DiagnosticsReport {
+	// The AI provider's clock serves the clock skew check
+	var providerTime time.Time
+	checks := []struct {
+		name string
+		run  func() SelfCheck
+	}{
+		{"database_writable", s.checkDatabaseWritable},
+		{"migrations", s.checkMigrations},
+		{"disk_space", s.checkDiskSpace},
+		{"ai_provider", func() SelfCheck {
+			var check SelfCheck
+			providerTime, check = checkAIProvider(ctx, client)
+			return check
+		}},
+		{"clock_skew", func() SelfCheck { return checkClockSkew(providerTime, time.Now()) }},
+		{"scheduler", s.checkScheduler},
+	}
+
+	report := DiagnosticsReport{Status: CheckPass, CheckedAt: time.Now().UTC()}
+	for _, check := range checks {
+		started := time.Now()
+		result := check.run()
+		result.Name = check.name
+		result.DurationMs = time.Since(started).Milliseconds()
+		report.Checks = append(report.Checks, result)
+		if worse(result.Status, report.Status) {
+			report.Status = result.Status
+		}
+	}
+	return report
+}
+
+// checkDatabaseWritable takes the write lock in a transaction that is
+// rolled back, so nothing changes
+func (s *Server) checkDatabaseWritable() SelfCheck {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return SelfCheck{Status: CheckFail, Message: "Database unreachable: " + tx.Error.Error(),
+			Remediation: "Check DB_PATH and that the database file and its directory are readable by the server"}
+	}
+	defer tx.Rollback()
+	if err := tx.Exec("CREATE TABLE IF NOT EXISTS selfcheck_probe (id INTEGER)").Error; err != nil {
+		return SelfCheck{Status: CheckFail, Message: "Database is not writable: " + err.Error(),
+			Remediation: "Check the file permissions of the database and its directory, that the disk is not full or mounted read-only, and that no other process holds a write lock"}
+	}
+	if s.mode.ReadOnly() {
+		return SelfCheck{Status: CheckWarn, Message: "Database is writable, but read-only mode is on: " + s.mode.Status().Reason,
+			Remediation: "Switch read-only mode off with PUT /settings/read-only once the incident or migration is over"}
+	}
+	return SelfCheck{Status: CheckPass, Message: "Database is writable"}
+}
+
+// checkMigrations compares the recorded schema version with this build's
+func (s *Server) checkMigrations() SelfCheck {
+	current, err := database.CurrentSchemaVersion(s.db)
+	switch {
+	case err != nil:
+		return SelfCheck{Status: CheckFail, Message: "Failed to read the schema version: " + err.Error(),
+			Remediation: "Check the database is reachable, then run migrations"}
+	case current < database.SchemaVersion:
+		return SelfCheck{Status: CheckFail, Message: fmt.Sprintf("Database schema v%d is older than this build (v%d)", current, database.SchemaVersion),
+			Remediation: "Run migrations, or restart the server with migrations enabled"}
+	case current > database.SchemaVersion:
+		return SelfCheck{Status: CheckWarn, Message: fmt.Sprintf("Database schema v%d is newer than this build (v%d)", current, database.SchemaVersion),
+			Remediation: "Expected during a rolling deploy; otherwise deploy the newer build everywhere"}
+	}
+	return SelfCheck{Status: CheckPass, Message: fmt.Sprintf("Database schema v%d is current", current)}
+}
+
+// checkDiskSpace checks the free space next to the SQLite database
+func (s *Server) checkDiskSpace() SelfCheck {
+	if s.db.Dialector.Name() != "sqlite" {
+		return SelfCheck{Status: CheckPass, Message: "Not using SQLite; disk space is managed by the database server"}
+	}
+	path := s.cfg.DBFile()
+	if path == "" {
+		return SelfCheck{Status: CheckPass, Message: "In-memory database"}
+	}
+	usage, err := diskspace.Of(path)
+	if errors.Is(err, diskspace.ErrUnsupported) {
+		return SelfCheck{Status: CheckWarn, Message: err.Error(), Remediation: "Watch the disk holding the database by other means"}
+	}
+	if err != nil {
+		return SelfCheck{Status: CheckFail, Message: "Failed to read disk space: " + err.Error(),
+			Remediation: "Check that the directory of DB_PATH exists and is readable"}
+	}
+
+	message := fmt.Sprintf("%d MiB free of %d MiB (%.1f%%)", usage.Free>>20, usage.Total>>20, usage.FreePercent())
+	remediation := "Free disk space or grow the volume holding DB_PATH; the cleanup job reclaims space from deleted rows"
+	switch {
+	case usage.Free < uint64(s.cfg.Scheduler.DiskGuardMinFreeMB)<<20:
+		return SelfCheck{Status: CheckFail, Message: message, Remediation: remediation + ". The disk guard keeps the instance read-only until space is freed"}
+	case usage.FreePercent() < diskWarnPercent:
+		return SelfCheck{Status: CheckWarn, Message: message, Remediation: remediation}
+	}
+	return SelfCheck{Status: CheckPass, Message: message}
+}
+
+// checkAIProvider pings the AI provider, returning its clock
+func checkAIProvider(ctx context.Context, client *ai.Client) (time.Time, SelfCheck) {
+	if !client.IsConfigured() {
+		return time.Time{}, SelfCheck{Status: CheckWarn, Message: "No AI provider is configured; generation is disabled",
+			Remediation: "Set GROQ_API_KEY, or AI_PROVIDER=mock for development"}
+	}
+	if breaker := client.BreakerStatus(); breaker.State == ai.BreakerOpen.String() {
+		return time.Time{}, SelfCheck{Status: CheckFail, Message: fmt.Sprintf("Circuit breaker is open after %d consecutive failures", breaker.ConsecutiveFailures),
+			Remediation: "Check the provider's status page and the logs for the failing calls; the breaker retries after AI_BREAKER_COOLDOWN_SECONDS"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, aiPingTimeout)
+	defer cancel()
+	date, err := client.Ping(ctx)
+	var apiErr *ai.APIError
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+		return date, SelfCheck{Status: CheckFail, Message: fmt.Sprintf("AI provider rejected the API key (status %d)", apiErr.StatusCode),
+			Remediation: "Check GROQ_API_KEY is current and has not been revoked"}
+	case err != nil:
+		return date, SelfCheck{Status: CheckFail, Message: "AI provider unreachable: " + err.Error(),
+			Remediation: "Check GROQ_API_URL, outbound network access from the server and the provider's status page"}
+	case client.IsMock():
+		return date, SelfCheck{Status: CheckPass, Message: "Mock AI provider"}
+	}
+	return date, SelfCheck{Status: CheckPass, Message: "AI provider reachable with model " + client.Model()}
+}
+
+// checkClockSkew compares the local clock with the AI provider's
+func checkClockSkew(reference, now time.Time) SelfCheck {
+	if reference.IsZero() {
+		return SelfCheck{Status: CheckWarn, Message: "No reference clock; the AI provider did not answer with a date",
+			Remediation: "Check the system clock is synchronised with NTP"}
+	}
+	skew := now.Sub(reference).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+	message := fmt.Sprintf("Clock is %v off the AI provider's", skew)
+	remediation := "Synchronise the system clock with NTP; Slack and Discord requests are refused beyond " + integrations.MaxSkew.String()
+	switch {
+	case skew >= integrations.MaxSkew:
+		return SelfCheck{Status: CheckFail, Message: message, Remediation: remediation}
+	case skew >= clockWarnSkew:
+		return SelfCheck{Status: CheckWarn, Message: message, Remediation: remediation}
+	}
+	return SelfCheck{Status: CheckPass, Message: message}
+}
+
+// checkScheduler checks background jobs are running
+func (s *Server) checkScheduler() SelfCheck {
+	switch {
+	case s.scheduler == nil:
+		return SelfCheck{Status: CheckWarn, Message: "No scheduler in this process",
+			Remediation: "Expected when another instance runs the jobs; otherwise check startup logs"}
+	case !s.cfg.Scheduler.Enabled:
+		return SelfCheck{Status: CheckWarn, Message: "Scheduler is disabled; cleanup and generation jobs do not run",
+			Remediation: "Set SCHEDULER_ENABLED=true on one instance"}
+	case !s.scheduler.Running():
+		return SelfCheck{Status: CheckFail, Message: "Scheduler is enabled but not running",
+			Remediation: "Check startup logs for scheduler errors and restart the server"}
+	}
+	return SelfCheck{Status: CheckPass, Message: fmt.Sprintf("Scheduler is running %d jobs", len(s.scheduler.GetJobs()))}
+}
+
+// worse reports whether outcome a is worse than b
+func worse(a, b string) bool {
+	rank := map[string]int{CheckPass: 0, CheckWarn: 1, CheckFail: 2}
+	return rank[a] > rank[b]
+}
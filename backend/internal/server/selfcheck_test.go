@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/database"
+	"github.com/truthordare/backend/internal/maintenance"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDiagnose(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.Exec("CREATE TABLE schema_versions (id INTEGER PRIMARY KEY, version INTEGER, compatible_from INTEGER, applied_at DATETIME)")
+	if err := db.Exec("INSERT INTO schema_versions (id, version) VALUES (1, ?)", database.SchemaVersion).Error; err != nil {
+		t.Fatalf("Failed to record schema version: %v", err)
+	}
+
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer provider.Close()
+
+	s := &Server{cfg: &config.Config{DBPath: ":memory:"}, db: db, mode: maintenance.New(false)}
+	statuses := func(report DiagnosticsReport) map[string]string {
+		byName := map[string]string{}
+		for _, check := range report.Checks {
+			byName[check.Name] = check.Status
+			if check.Status != CheckPass && check.Remediation == "" {
+				t.Errorf("Expected a remediation hint for %s", check.Name)
+			}
+		}
+		return byName
+	}
+
+	client := ai.NewClient(ai.ClientConfig{APIKey: "good-key", APIURL: provider.URL + "/v1/chat/completions"})
+	report := s.diagnose(context.Background(), client)
+	got := statuses(report)
+	for _, name := range []string{"database_writable", "migrations", "disk_space", "ai_provider", "clock_skew"} {
+		if got[name] != CheckPass {
+			t.Errorf("Expected %s to pass, got %s", name, got[name])
+		}
+	}
+	if got["scheduler"] != CheckWarn || report.Status != CheckWarn {
+		t.Errorf("Expected a warning for the missing scheduler, got %s overall %s", got["scheduler"], report.Status)
+	}
+
+	s.mode.Set(true, "incident")
+	db.Exec("UPDATE schema_versions SET version = ? WHERE id = 1", database.SchemaVersion-1)
+	client = ai.NewClient(ai.ClientConfig{APIKey: "bad-key", APIURL: provider.URL + "/v1/chat/completions"})
+	report = s.diagnose(context.Background(), client)
+	got = statuses(report)
+	if got["database_writable"] != CheckWarn || got["migrations"] != CheckFail || got["ai_provider"] != CheckFail {
+		t.Errorf("Expected read-only, stale schema and rejected key to be reported, got %v", got)
+	}
+	if report.Status != CheckFail {
+		t.Errorf("Expected fail overall, got %s", report.Status)
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		reference time.Time
+		want      string
+	}{
+		{now.Add(-2 * time.Second), CheckPass},
+		{now.Add(time.Minute), CheckWarn},
+		{now.Add(-10 * time.Minute), CheckFail},
+		{time.Time{}, CheckWarn},
+	} {
+		if got := checkClockSkew(tc.reference, now); got.Status != tc.want {
+			t.Errorf("Expected %s for %v, got %s: %s", tc.want, now.Sub(tc.reference), got.Status, got.Message)
+		}
+	}
+}
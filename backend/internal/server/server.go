@@ -3,15 +3,28 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/truthordare/backend/internal/ai"
+	"github.com/truthordare/backend/internal/chaos"
 	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/featureflags"
+	"github.com/truthordare/backend/internal/game"
 	"github.com/truthordare/backend/internal/handlers"
+	"github.com/truthordare/backend/internal/maintenance"
+	"github.com/truthordare/backend/internal/metrics"
 	"github.com/truthordare/backend/internal/middleware"
 	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/notify"
 	"github.com/truthordare/backend/internal/repository"
+	"github.com/truthordare/backend/internal/response"
 	"github.com/truthordare/backend/internal/scheduler"
+	"github.com/truthordare/backend/internal/signedurl"
+	"github.com/truthordare/backend/internal/storage"
+	"github.com/truthordare/backend/internal/version"
 	"gorm.io/gorm"
 )
 
@@ -20,7 +33,15 @@ type Server struct {
 	cfg       *config.Config
 	db        *gorm.DB
 	router    *gin.Engine
+	admin     *gin.Engine
 	scheduler *scheduler.Scheduler
+	served    *repository.ServeRecorder
+	chaos     *chaos.Injector // Development fault injection, nil when off
+	mode      *maintenance.Mode
+	flags     *featureflags.Store
+	signer    *signedurl.Signer
+	rooms     *RoomManager
+	v1Sunset  time.Time
 }
 
 // New creates a new Server instance.
@@ -30,79 +51,407 @@ func New(cfg *config.Config, db *gorm.DB) *Server {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.New()
-
-	// Add middleware
-	router.Use(gin.Recovery())
-	router.Use(corsMiddleware(cfg))
-	router.Use(loggerMiddleware())
-
 	s := &Server{
 		cfg:    cfg,
 		db:     db,
-		router: router,
+		served: repository.NewServeRecorder(db),
+		mode:   maintenance.New(cfg.ReadOnly),
+		flags:  featureflags.New(db),
+		signer: signedurl.New(cfg.SignedURLSecret),
+	}
+	if cfg.SignedURLSecret == "" {
+		log.Warn().Msg("SIGNED_URL_SECRET not set, signed URLs stop working on restart")
+	}
+
+	sunset, err := parseSunset(cfg.APIV1Sunset)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid API_V1_SUNSET, no Sunset header sent")
+	}
+	s.v1Sunset = sunset
+	s.setupChaos()
+
+	// Admin routes share the main router unless they get their own listener
+	s.router = s.newEngine()
+	s.admin = s.router
+	if cfg.AdminAddr != "" {
+		s.admin = s.newEngine()
+	}
+
+	windows, err := maintenance.ParseWindows(cfg.Maintenance.Windows, time.Duration(cfg.Maintenance.WindowMinutes)*time.Minute)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid maintenance windows, none scheduled")
 	}
+	s.mode.SetWindows(windows)
+
+	s.served.SetHold(s.mode.ReadOnly)
+	s.served.Start(time.Duration(cfg.Database.ServeStatsFlushSeconds) * time.Second)
 
 	s.setupRoutes()
+	registerBuildInfo()
 
 	return s
 }
 
+// newEngine creates a router with the middleware every listener uses
+func (s *Server) newEngine() *gin.Engine {
+	router := gin.New()
+	configureClientIP(router, s.cfg)
+
+	// Add middleware
+	router.Use(middleware.ErrorTracking())
+	router.Use(middleware.EnvelopeMiddleware(apiPrefix(s.cfg, apiV2) + "/"))
+	router.Use(middleware.Recovery(notify.New(s.cfg.Moderation.NotifyWebhookURL)))
+	router.Use(corsMiddleware(s.cfg))
+	router.Use(loggerMiddleware())
+	router.Use(middleware.TimeoutMiddleware(
+		time.Duration(s.cfg.RequestTimeoutSeconds)*time.Second,
+		longRequestBudgets(s.cfg),
+	))
+	if s.chaos != nil {
+		router.Use(middleware.ChaosMiddleware(s.chaos, s.cfg.Chaos.Routes))
+	}
+
+	// Reject writes in read-only mode, except the toggle switching it off
+	// and resetting the slow query log, which lives in memory
+	readOnlyExempt := append(apiPaths(s.cfg, "/settings/read-only"), apiPaths(s.cfg, "/admin/slow-queries")...)
+	router.Use(middleware.ReadOnlyMiddleware(s.mode, readOnlyExempt...))
+
+	return router
+}
+
+// registerBuildInfo exposes the running build as a constant gauge, so
+// dashboards can join other series on version and commit
+func registerBuildInfo() {
+	info := version.Get()
+	metrics.NewGauge("tod_build_info", "Build information of the running server, always 1").
+		SetWith(metrics.Labels{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"go_version": info.GoVersion,
+		}, 1)
+}
+
+// registerFreshnessMetrics exposes content freshness, so alerts fire when
+// the generation pipeline stops producing. Values are computed at scrape
+// time from a report cached for a minute.
+func registerFreshnessMetrics(h *handlers.FreshnessHandler, slaHours int) {
+	metrics.NewGauge("tod_content_freshness_sla_seconds", "Age the newest active task of each category+language should stay under").
+		Set(float64(slaHours) * 3600)
+	metrics.NewGaugeVecFunc("tod_content_newest_task_age_seconds",
+		"Age of the newest active task per category and language (+Inf when there is none)",
+		h.AgeSamples)
+	metrics.NewGaugeFunc("tod_content_fresh_ratio",
+		"Share of active category+language combinations whose newest task is within the freshness SLA",
+		h.FreshRatio)
+}
+
 // SetScheduler sets the scheduler for the server (used for API endpoints).
 func (s *Server) SetScheduler(sched *scheduler.Scheduler) {
 	s.scheduler = sched
+	s.scheduler.SetMode(s.mode)
 	s.setupSchedulerRoutes()
 }
 
+// Handler returns the HTTP handler serving the public routes, and the admin
+// routes unless ADMIN_ADDR moves them to their own listener. For use with
+// httptest servers and custom listeners.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// AdminHandler returns the HTTP handler serving the restricted routes and
+// metrics. It is the same as Handler when ADMIN_ADDR is empty.
+func (s *Server) AdminHandler() http.Handler {
+	return s.admin
+}
+
+// longRequestBudgets lists the routes allowed the long request budget:
+// AI generation, batch writes, exports and snapshots, bulk language
+// changes and manual job runs
+func longRequestBudgets(cfg *config.Config) map[string]time.Duration {
+	budget := time.Duration(cfg.LongRequestTimeoutSeconds) * time.Second
+
+	budgets := make(map[string]time.Duration)
+	for _, route := range []string{
+		"/generate",
+		"/generate/category-labels",
+		"/categories/:id/generate-image",
+		"/tasks/:id/generate-hint",
+		"/tasks/:id/clone",
+		"/tasks/batch",
+		"/tasks/export",
+		"/tasks/import",
+		"/privacy/clients/:id",
+		"/languages/prune",
+		"/languages/rename",
+		"/admin/snapshot",
+		"/admin/snapshot/diff",
+		"/export",
+		"/imports",
+		"/imports/:id/resume",
+		"/privacy/clients/:id/export",
+		"/scheduler/run",
+	} {
+		for _, path := range apiPaths(cfg, route) {
+			budgets[path] = budget
+		}
+	}
+	// WebSockets stay open as long as the devices do
+	for _, path := range apiPaths(cfg, "/ws/rooms/:code") {
+		budgets[path] = 0
+	}
+	return budgets
+}
+
+// Close disconnects the devices in game rooms and flushes buffered task
+// serve counts. Call it on shutdown.
+func (s *Server) Close() error {
+	s.rooms.Close()
+	return s.served.Stop()
+}
+
 // Start starts the HTTP server.
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%s", s.cfg.Port)
-	return s.router.Run(addr)
+	s.startDiagnostics()
+
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	if s.admin != s.router {
+		if err := s.startAdmin(); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+
+	log.Info().Str("addr", ln.Addr().String()).Msg("Starting server")
+	return s.router.RunListener(ln)
 }
 
 func (s *Server) setupRoutes() {
-	// Health check
-	s.router.GET("/health", s.healthCheck)
+	// Health check, on every listener so each can be probed
+	for _, router := range s.engines() {
+		router.GET("/health", s.healthCheck)
+		router.GET("/health/ready", s.readinessCheck)
+		router.GET("/version", s.version)
+	}
+
+	// Prometheus metrics
+	s.admin.GET("/metrics", s.metrics)
 
-	// API v1 routes
-	v1 := s.router.Group(s.cfg.APIPrefix + "/" + s.cfg.APIVersion)
+	// Stored media (category images), when served by this instance
+	store := storage.NewLocalStore(s.cfg.Storage.Dir, s.cfg.Storage.BaseURL)
+	if strings.HasPrefix(s.cfg.Storage.BaseURL, "/") {
+		for _, router := range s.engines() {
+			router.Static(s.cfg.Storage.BaseURL, store.Dir())
+		}
+	}
+
+	// API routes, served once per API version
 	{
 		// Initialize repositories
 		categoryRepo := repository.NewCategoryRepository(s.db)
 		taskRepo := repository.NewTaskRepository(s.db)
+		reportRepo := repository.NewReportRepository(s.db)
+		telemetryRepo := repository.NewTelemetryRepository(s.db)
+		privacyRepo := repository.NewPrivacyRepository(s.db)
+		consentRepo := repository.NewConsentRepository(s.db)
+		languageRepo := repository.NewLanguageRepository(s.db)
+		snapshotRepo := repository.NewSnapshotRepository(s.db)
+		generationRunRepo := repository.NewGenerationRunRepository(s.db)
+		glossaryRepo := repository.NewGlossaryRepository(s.db)
+		importJobRepo := repository.NewImportJobRepository(s.db)
+		chatRepo := repository.NewChatRepository(s.db)
 
 		// Initialize handlers
 		categoryHandler := handlers.NewCategoryHandler(categoryRepo)
-		taskHandler := handlers.NewTaskHandler(taskRepo, categoryRepo)
-		generateHandler := handlers.NewGenerateHandler(taskRepo, categoryRepo)
-		generateCategoryLabelsHandler := handlers.NewGenerateCategoryLabelsHandler()
+		taskHandler := handlers.NewTaskHandler(taskRepo, categoryRepo, s.served, s.cfg.Generation.TextRules)
+		generateHandler := handlers.NewGenerateHandler(taskRepo, categoryRepo, generationRunRepo, glossaryRepo, languageRepo, &s.cfg.Generation)
+		generateCategoryLabelsHandler := handlers.NewGenerateCategoryLabelsHandler(languageRepo)
+		generateHintHandler := handlers.NewGenerateHintHandler(taskRepo, languageRepo)
+		cloneHandler := handlers.NewCloneHandler(taskRepo, categoryRepo, glossaryRepo, languageRepo, &s.cfg.Generation)
+		categoryImageHandler := handlers.NewCategoryImageHandler(categoryRepo, store)
+		reviewHandler := handlers.NewReviewHandler(taskRepo, reportRepo)
+		telemetryHandler := handlers.NewTelemetryHandler(telemetryRepo)
+		privacyHandler := handlers.NewPrivacyHandler(privacyRepo)
+		games := game.NewService(s.db, taskRepo, s.served, &s.cfg.Game, s.mode)
+		if err := games.ScheduleTimers(); err != nil {
+			log.Error().Err(err).Msg("Failed to schedule game turn timers")
+		}
+		consentHandler := handlers.NewConsentHandler(consentRepo, categoryRepo, games, &s.cfg.Moderation)
+		settingsHandler := handlers.NewSettingsHandler(s.mode)
+		featureFlagHandler := handlers.NewFeatureFlagHandler(s.flags)
+		languageHandler := handlers.NewLanguageHandler(languageRepo)
+		glossaryHandler := handlers.NewGlossaryHandler(glossaryRepo)
+		searchHandler := handlers.NewSearchHandler(categoryRepo, taskRepo)
+		snapshotHandler := handlers.NewSnapshotHandler(snapshotRepo, s.flags)
+		importHandler := handlers.NewImportHandler(importJobRepo, snapshotRepo, s.flags)
+		embedHandler := handlers.NewEmbedHandler(taskRepo, categoryRepo, s.signer, s.cfg.PublicURL)
+		signablePaths := append(apiPaths(s.cfg, "/embed/"), apiPaths(s.cfg, "/scheduler/calendar.ics")...)
+		signedURLHandler := handlers.NewSignedURLHandler(s.signer, signablePaths,
+			time.Duration(s.cfg.SignedURLMaxTTLHours)*time.Hour)
+		gameHandler := handlers.NewGameHandler(games, categoryRepo, &s.cfg.Moderation)
+		gameQRHandler := handlers.NewGameQRHandler(games, s.cfg.Game.JoinURL, s.cfg.PublicURL)
+		gameSummaryHandler := handlers.NewGameSummaryHandler(games, telemetryRepo)
+		s.rooms = NewRoomManager(games, s.cfg)
+		metrics.NewGaugeVecFunc("tod_game_room_devices",
+			"Devices in the game rooms open on this instance, by role (player or spectator)",
+			s.rooms.DeviceSamples)
+		chatHandler := handlers.NewChatHandler(chatRepo, taskRepo, categoryRepo, s.served, &s.cfg.Chat)
+		trendingHandler := handlers.NewTrendingHandler(telemetryRepo, taskRepo)
+		attributionHandler := handlers.NewAttributionHandler(taskRepo)
+		clientConfigHandler := handlers.NewClientConfigHandler(snapshotRepo, s.flags, s.cfg.MinAppVersion)
+		freshnessHandler := handlers.NewFreshnessHandler(taskRepo, categoryRepo, &s.cfg.Generation)
+		registerFreshnessMetrics(freshnessHandler, s.cfg.Generation.FreshnessSLAHours)
+		reportHandler := handlers.NewReportHandler(taskRepo, reportRepo, &s.cfg.Moderation, notify.New(s.cfg.Moderation.NotifyWebhookURL))
 
 		// ========== PUBLIC ROUTES (No Auth) ==========
+		// Served on every listener, since the admin panel reads them too.
+		// Closed during scheduled maintenance windows and to clients below
+		// the minimum version
+		for _, public := range s.versionGroups(s.engines()...) {
+			public.Use(middleware.MaintenanceWindowMiddleware(s.mode))
+			public.Use(middleware.ClientVersionMiddleware(s.cfg.MinClientVersion))
+
+			// Client runtime configuration, and the static data endpoints it supersedes
+			public.GET("/client-config", clientConfigHandler.Get)
+			public.GET("/languages", s.listLanguages)
+			public.GET("/age-groups", s.listAgeGroups)
+			public.GET("/error-codes", s.listErrorCodes)
+
+			// Category routes - Public
+			categories := public.Group("/categories")
+			{
+				categories.GET("", categoryHandler.List) // List all categories (with filters)
+			}
 
-		// Static data endpoints
-		v1.GET("/languages", s.listLanguages)
-		v1.GET("/age-groups", s.listAgeGroups)
+			// Task routes - Public
+			tasks := public.Group("/tasks")
+			{
+				tasks.GET("", taskHandler.List) // List tasks (with filters, sort, pagination)
+				tasks.GET("/availability", taskHandler.CheckAvailability)
+				tasks.GET("/trending", trendingHandler.Trending)
+				tasks.GET("/code/:code", taskHandler.GetByCode)
+				tasks.POST("/:id/report", reportHandler.Report)
+			}
 
-		// Category routes - Public
-		categories := v1.Group("/categories")
-		{
-			categories.GET("", categoryHandler.List) // List all categories (with filters)
+			// Game sessions drawing without exclude lists - Public
+			sessions := public.Group("/sessions")
+			{
+				sessions.POST("", gameHandler.Create)
+				sessions.GET("/:id", gameHandler.Get)
+				sessions.GET("/:id/next", gameHandler.Next)
+				sessions.GET("/:id/qr", gameQRHandler.QR)
+				sessions.GET("/:id/summary", gameSummaryHandler.Summary)
+				// Host controls, with the session's X-Host-Token
+				sessions.POST("/:id/kick", gameHandler.Kick)
+				sessions.POST("/:id/lock", gameHandler.Lock)
+				sessions.PUT("/:id/categories", gameHandler.SetCategories)
+				sessions.POST("/:id/custom-tasks", gameHandler.AddCustomTask)
+				sessions.POST("/:id/exclusions", gameHandler.Exclude)
+				sessions.POST("/:id/end", gameHandler.End)
+			}
+
+			// Game rooms sharing a session between devices - Public
+			public.GET("/ws/rooms/:code", s.rooms.Join)
+
+			// Licenses and credits of third-party content - Public
+			public.GET("/attributions", attributionHandler.List)
+
+			// Anonymous telemetry - Public
+			public.POST("/telemetry", telemetryHandler.Collect)
+
+			// Consent for categories that require it - Public
+			public.GET("/consent/policy", consentHandler.Policy)
+			public.POST("/consent", consentHandler.Record)
 		}
 
-		// Task routes - Public
-		tasks := v1.Group("/tasks")
-		{
-			tasks.GET("", taskHandler.List) // List tasks (with filters, sort, pagination)
-			tasks.GET("/availability", taskHandler.CheckAvailability)
+		// ========== SIGNED ROUTES (Signed URL or Auth) ==========
+		// Read-only content for widgets on third-party sites, reachable on
+		// every listener with a URL signed by POST /signed-urls
+		for _, public := range s.versionGroups(s.engines()...) {
+			embed := public.Group("/embed")
+			embed.Use(middleware.MaintenanceWindowMiddleware(s.mode))
+			embed.Use(middleware.SignedURLMiddleware(s.signer, handlers.OEmbedSizeParams...))
+			{
+				embed.GET("/daily-task", embedHandler.DailyTask)
+				embed.GET("/task/:id", embedHandler.Task)
+			}
+		}
+
+		// ========== BOT ROUTES (Platform signature) ==========
+		// Slack, Discord and Telegram bots drawing tasks for chat channels.
+		// Requests are verified against the platform's signature or secret
+		// token, not the admin key
+		for _, public := range s.versionGroups(s.engines()...) {
+			bots := public.Group("/integrations")
+			bots.Use(middleware.MaintenanceWindowMiddleware(s.mode))
+			{
+				bots.POST("/slack/command", chatHandler.SlackCommand)
+				bots.POST("/discord/interactions", chatHandler.DiscordInteraction)
+				bots.POST("/telegram/webhook", chatHandler.TelegramWebhook)
+			}
 		}
 
 		// ========== RESTRICTED ROUTES (Requires Auth) ==========
-		restricted := v1.Group("")
-		restricted.Use(middleware.AuthMiddleware())
-		{
+		for _, restricted := range s.versionGroups(s.admin) {
+			restricted.Use(middleware.AuthMiddleware())
+
 			// Auth verification
 			restricted.GET("/auth/verify", s.verifyAuth)
 
+			// Instance settings - Restricted
+			restricted.GET("/settings/read-only", settingsHandler.GetReadOnly)
+			restricted.PUT("/settings/read-only", settingsHandler.SetReadOnly)
+
+			// Runtime diagnostics - Restricted
+			restricted.GET("/admin/runtime", s.runtimeSnapshot)
+			restricted.GET("/admin/diagnostics", s.diagnostics)
+			restricted.GET("/admin/slow-queries", s.slowQueries)
+			restricted.DELETE("/admin/slow-queries", s.resetSlowQueries)
+
+			// Signed URLs for embeds - Restricted
+			restricted.POST("/signed-urls", signedURLHandler.Create)
+
+			// Chat workspaces allowed to use the bots - Restricted
+			restricted.GET("/integrations/workspaces", chatHandler.ListWorkspaces)
+			restricted.POST("/integrations/workspaces", chatHandler.CreateWorkspace)
+			restricted.PUT("/integrations/workspaces/:id", chatHandler.UpdateWorkspace)
+			restricted.DELETE("/integrations/workspaces/:id", chatHandler.DeleteWorkspace)
+
+			// Global search for the admin panel - Restricted
+			restricted.GET("/admin/search", searchHandler.Search)
+
+			// Content export/import for promotion between instances - Restricted
+			restricted.GET("/admin/snapshot", snapshotHandler.Export)
+			restricted.POST("/admin/snapshot", snapshotHandler.Import)
+			restricted.POST("/admin/snapshot/diff", snapshotHandler.Diff)
+			restricted.GET("/export", snapshotHandler.Stream)
+			restricted.GET("/imports", importHandler.List)
+			restricted.POST("/imports", importHandler.Create)
+			restricted.GET("/imports/:id", importHandler.Get)
+			restricted.POST("/imports/:id/resume", importHandler.Resume)
+
+			// Bulk language changes across translations - Restricted
+			restricted.POST("/languages/prune", languageHandler.Prune)
+			restricted.POST("/languages/rename", languageHandler.Rename)
+			restricted.GET("/languages/freezes", languageHandler.ListFreezes)
+			restricted.POST("/languages/freeze", languageHandler.Freeze)
+			restricted.POST("/languages/unfreeze", languageHandler.Unfreeze)
+
+			// Translation glossary - Restricted
+			restricted.GET("/glossary", glossaryHandler.List)
+			restricted.POST("/glossary", glossaryHandler.Create)
+			restricted.PUT("/glossary/:id", glossaryHandler.Update)
+			restricted.DELETE("/glossary/:id", glossaryHandler.Delete)
+
+			// Feature flags - Restricted
+			restricted.GET("/feature-flags", featureFlagHandler.List)
+			restricted.PUT("/feature-flags/:name", featureFlagHandler.Set)
+			restricted.DELETE("/feature-flags/:name", featureFlagHandler.Reset)
+
 			// Category management - Restricted
 			restrictedCategories := restricted.Group("/categories")
 			{
@@ -111,6 +460,7 @@ func (s *Server) setupRoutes() {
 				restrictedCategories.POST("", categoryHandler.Create)
 				restrictedCategories.POST("/reorder", categoryHandler.Reorder)
 				restrictedCategories.PUT("/:id", categoryHandler.Update)
+				restrictedCategories.POST("/:id/generate-image", categoryImageHandler.GenerateImage)
 			}
 
 			// Task management - Restricted
@@ -118,16 +468,48 @@ func (s *Server) setupRoutes() {
 			{
 				restrictedTasks.GET("/count", taskHandler.Count)
 				restrictedTasks.GET("/:id", taskHandler.Get)
+				restrictedTasks.GET("/:id/neighbors", taskHandler.Neighbors)
 				restrictedTasks.POST("", taskHandler.Create)
 				restrictedTasks.POST("/batch", taskHandler.CreateBatch)
+				restrictedTasks.GET("/export", taskHandler.Export)
+				restrictedTasks.POST("/import", taskHandler.Import)
 				restrictedTasks.PUT("/:id", taskHandler.Update)
 				restrictedTasks.DELETE("/:id", taskHandler.Delete)
 				restrictedTasks.GET("/stats", taskHandler.Stats)
+				restrictedTasks.GET("/freshness", freshnessHandler.Get)
 				restrictedTasks.GET("/random", taskHandler.GetRandom)
+				restrictedTasks.GET("/reported", reportHandler.ListReported)
+				restrictedTasks.GET("/:id/reports", reportHandler.ListReports)
+				restrictedTasks.POST("/:id/reinstate", reportHandler.Reinstate)
+				restrictedTasks.GET("/inbox", reviewHandler.Inbox)
+				restrictedTasks.POST("/:id/claim", reviewHandler.Claim)
+				restrictedTasks.POST("/:id/release", reviewHandler.Release)
+				restrictedTasks.POST("/:id/review", reviewHandler.Review)
+				restrictedTasks.GET("/translations", reviewHandler.Translations)
+				restrictedTasks.POST("/:id/verify-language", reviewHandler.VerifyLanguage)
+				restrictedTasks.POST("/:id/generate-hint", generateHintHandler.GenerateHint)
+				restrictedTasks.POST("/:id/clone", cloneHandler.Clone)
+			}
+
+			// Telemetry rollups - Restricted
+			restricted.GET("/telemetry/rollups", telemetryHandler.Rollups)
+
+			// Consent audit - Restricted
+			restricted.GET("/consent/records", consentHandler.List)
+
+			// Client data export/deletion - Restricted
+			privacy := restricted.Group("/privacy")
+			{
+				privacy.GET("/clients/:id/export", privacyHandler.Export)
+				privacy.DELETE("/clients/:id", privacyHandler.Delete)
+				privacy.GET("/audit", privacyHandler.Audit)
 			}
 
 			// AI Generation - Restricted
 			restricted.POST("/generate", generateHandler.Generate)
+			restricted.GET("/generate/jobs", generateHandler.ListJobs)
+			restricted.GET("/generate/quota", generateHandler.Quota)
+			restricted.GET("/generate/jobs/:id/report", generateHandler.Report)
 			restricted.POST("/generate/category-labels", generateCategoryLabelsHandler.GenerateCategoryLabels)
 		}
 	}
@@ -136,10 +518,65 @@ func (s *Server) setupRoutes() {
 func (s *Server) healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, models.HealthResponse{
 		Status:  "healthy",
-		Version: "1.0.0",
+		Version: version.Version,
 	})
 }
 
+// version reports the build the server was compiled from
+func (s *Server) version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
+
+// readinessCheck reports whether the instance can serve traffic.
+// The database is required; the AI provider is reported but never fails
+// readiness, since the game API works without it.
+func (s *Server) readinessCheck(c *gin.Context) {
+	status := http.StatusOK
+	resp := models.ReadinessResponse{
+		Status: "ready",
+		Checks: map[string]interface{}{},
+	}
+
+	if err := pingDB(s.db); err != nil {
+		status = http.StatusServiceUnavailable
+		resp.Status = "not_ready"
+		resp.Checks["database"] = err.Error()
+	} else {
+		resp.Checks["database"] = "ok"
+	}
+
+	// Neither fails readiness: reads and admin endpoints keep working
+	resp.Checks["read_only"] = s.mode.ReadOnly()
+	resp.Checks["maintenance_window"], _ = s.mode.InMaintenance(time.Now())
+
+	aiClient := ai.GetClient()
+	resp.Checks["ai"] = gin.H{
+		"configured": aiClient.IsConfigured(),
+		"breaker":    aiClient.BreakerStatus(),
+		"rate_limit": aiClient.RateLimitStatus(),
+	}
+
+	c.JSON(status, resp)
+}
+
+// pingDB checks the database connection
+func pingDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// metrics serves all registered metrics in the Prometheus text format
+func (s *Server) metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := metrics.Default.WritePrometheus(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
 // verifyAuth validates the authentication and returns success if valid
 func (s *Server) verifyAuth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -148,34 +585,26 @@ func (s *Server) verifyAuth(c *gin.Context) {
 	})
 }
 
-// listLanguages returns all supported languages (static)
+// listLanguages returns all supported languages (static). Superseded by
+// /client-config; kept for older clients.
 func (s *Server) listLanguages(c *gin.Context) {
-	languages := []map[string]string{
-		{"code": "en", "name": "English", "native_name": "English", "icon": "🇬🇧"},
-		{"code": "zh", "name": "Chinese", "native_name": "中文", "icon": "🇨🇳"},
-		{"code": "es", "name": "Spanish", "native_name": "Español", "icon": "🇪🇸"},
-		{"code": "hi", "name": "Hindi", "native_name": "हिन्दी", "icon": "🇮🇳"},
-		{"code": "ar", "name": "Arabic", "native_name": "العربية", "icon": "🇸🇦"},
-		{"code": "fr", "name": "French", "native_name": "Français", "icon": "🇫🇷"},
-		{"code": "pt", "name": "Portuguese", "native_name": "Português", "icon": "🇵🇹"},
-		{"code": "bn", "name": "Bengali", "native_name": "বাংলা", "icon": "🇧🇩"},
-		{"code": "ru", "name": "Russian", "native_name": "Русский", "icon": "🇷🇺"},
-		{"code": "ur", "name": "Urdu", "native_name": "اردو", "icon": "🇵🇰"},
-	}
 	c.JSON(http.StatusOK, gin.H{
-		"data": languages,
+		"data": models.Languages,
 	})
 }
 
-// listAgeGroups returns all age groups (static)
+// listAgeGroups returns all age groups (static). Superseded by
+// /client-config; kept for older clients.
 func (s *Server) listAgeGroups(c *gin.Context) {
-	ageGroups := []map[string]interface{}{
-		{"value": "kids", "label": "Kids", "min_age": 0, "max_age": 12, "description": "Content suitable for children aged 0-12"},
-		{"value": "teen", "label": "Teen", "min_age": 13, "max_age": 17, "description": "Content suitable for teenagers aged 13-17"},
-		{"value": "adults", "label": "Adults", "min_age": 18, "max_age": 99, "description": "Content for adults 18 and above"},
-	}
 	c.JSON(http.StatusOK, gin.H{
-		"data": ageGroups,
+		"data": models.AgeGroups,
+	})
+}
+
+// listErrorCodes returns the catalogue of machine-readable error codes
+func (s *Server) listErrorCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": response.Codes,
 	})
 }
 
@@ -184,22 +613,12 @@ func (s *Server) listAgeGroups(c *gin.Context) {
 func corsMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
-
-		// Check if origin is allowed
-		allowed := false
-		for _, o := range cfg.CORSOrigins {
-			if o == origin || o == "*" {
-				allowed = true
-				break
-			}
-		}
-
-		if allowed || cfg.IsDevelopment() {
+		if originAllowed(cfg, origin) {
 			c.Header("Access-Control-Allow-Origin", origin)
 		}
 
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Admin-OTP")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Admin-OTP, X-Client-Version, X-Host-Token")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Max-Age", "86400")
 
@@ -212,6 +631,17 @@ func corsMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// originAllowed reports whether browsers on origin may call the API. Any
+// origin may in development.
+func originAllowed(cfg *config.Config, origin string) bool {
+	for _, o := range cfg.CORSOrigins {
+		if o == origin || o == "*" {
+			return true
+		}
+	}
+	return cfg.IsDevelopment()
+}
+
 func loggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -224,9 +654,9 @@ func loggerMiddleware() gin.HandlerFunc {
 		method := c.Request.Method
 
 		gin.DefaultWriter.Write([]byte(
-			fmt.Sprintf("[GIN] %s | %d | %v | %s %s\n",
+			fmt.Sprintf("[GIN] %s | %d | %v | %s | %s %s\n",
 				time.Now().Format("2006/01/02 - 15:04:05"),
-				status, latency, method, path),
+				status, latency, c.ClientIP(), method, path),
 		))
 	}
 }
@@ -239,11 +669,16 @@ func (s *Server) setupSchedulerRoutes() {
 
 	schedulerHandler := handlers.NewSchedulerHandler(s.scheduler)
 
+	// Calendar feed (signed URL or auth), for calendar apps that cannot
+	// send the admin key
+	for _, admin := range s.versionGroups(s.admin) {
+		admin.GET("/scheduler/calendar.ics", middleware.SignedURLMiddleware(s.signer), schedulerHandler.Calendar)
+	}
+
 	// Scheduler routes (restricted)
-	v1 := s.router.Group(s.cfg.APIPrefix + "/" + s.cfg.APIVersion)
-	restricted := v1.Group("")
-	restricted.Use(middleware.AuthMiddleware())
-	{
+	for _, restricted := range s.versionGroups(s.admin) {
+		restricted.Use(middleware.AuthMiddleware())
+
 		schedulerGroup := restricted.Group("/scheduler")
 		{
 			schedulerGroup.GET("/jobs", schedulerHandler.GetJobs)
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/models"
+	"github.com/truthordare/backend/internal/slowquery"
+)
+
+// Slow query report limits
+const (
+	defaultSlowQueries = 20
+	maxSlowQueries     = 200
+)
+
+// slowQueries reports the slowest recorded query shapes with SQLite's
+// plans and the indexes that would spare them a full table scan
+func (s *Server) slowQueries(c *gin.Context) {
+	log := slowquery.From(s.db)
+	if log == nil {
+		respondSlowQueriesDisabled(c)
+		return
+	}
+
+	limit := defaultSlowQueries
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSlowQueries {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "limit must be between 1 and 200",
+			})
+			return
+		}
+		limit = n
+	}
+
+	c.JSON(http.StatusOK, log.Advise(s.db, limit))
+}
+
+// resetSlowQueries forgets the recorded queries, e.g. to measure again
+// after adding an index
+func (s *Server) resetSlowQueries(c *gin.Context) {
+	log := slowquery.From(s.db)
+	if log == nil {
+		respondSlowQueriesDisabled(c)
+		return
+	}
+	log.Reset()
+	c.Status(http.StatusNoContent)
+}
+
+func respondSlowQueriesDisabled(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "configuration_error",
+		Message: "Slow queries are not recorded; set SLOW_QUERY_THRESHOLD_MS",
+	})
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/truthordare/backend/internal/config"
+	"github.com/truthordare/backend/internal/middleware"
+)
+
+// API versions served side by side. Both share repositories and handlers;
+// they differ in the response mappers the handlers pick by version, and v2
+// wraps every JSON response in the envelope of the response package. v1 is
+// deprecated in favour of v2 and can be switched off.
+const (
+	apiV1 = "v1"
+	apiV2 = "v2"
+)
+
+// apiVersions returns the API versions served, oldest first
+func apiVersions(cfg *config.Config) []string {
+	if cfg.APIV1Disabled {
+		return []string{apiV2}
+	}
+	return []string{apiV1, apiV2}
+}
+
+// apiPrefix returns the path prefix of an API version, e.g. "/api/v1"
+func apiPrefix(cfg *config.Config, version string) string {
+	return cfg.APIPrefix + "/" + version
+}
+
+// apiPaths returns route under the prefix of every served API version
+func apiPaths(cfg *config.Config, route string) []string {
+	versions := apiVersions(cfg)
+	paths := make([]string, len(versions))
+	for i, version := range versions {
+		paths[i] = apiPrefix(cfg, version) + route
+	}
+	return paths
+}
+
+// parseSunset parses the planned v1 removal date; empty means none
+func parseSunset(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// versionGroups returns a route group per router and served API version,
+// tagged with its version. v1 groups carry the deprecation headers.
+func (s *Server) versionGroups(routers ...*gin.Engine) []*gin.RouterGroup {
+	var groups []*gin.RouterGroup
+	for _, router := range routers {
+		for _, version := range apiVersions(s.cfg) {
+			var deprecation *middleware.Deprecation
+			if version == apiV1 {
+				deprecation = &middleware.Deprecation{
+					Prefix:    apiPrefix(s.cfg, apiV1),
+					Successor: apiPrefix(s.cfg, apiV2),
+					Sunset:    s.v1Sunset,
+				}
+			}
+
+			group := router.Group(apiPrefix(s.cfg, version))
+			group.Use(middleware.APIVersionMiddleware(version, deprecation))
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
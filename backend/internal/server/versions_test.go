@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/truthordare/backend/internal/config"
+)
+
+func TestAPIVersions(t *testing.T) {
+	t.Run("v1 deprecated next to v2", func(t *testing.T) {
+		srv := newTestServer(t, &config.Config{APIV1Sunset: "2027-03-01"})
+
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/languages", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for v1, got %d", w.Code)
+		}
+		if got := w.Header().Get("Deprecation"); got != "true" {
+			t.Errorf("Expected Deprecation header, got %q", got)
+		}
+		if got := w.Header().Get("Sunset"); got != "Mon, 01 Mar 2027 00:00:00 GMT" {
+			t.Errorf("Unexpected Sunset header %q", got)
+		}
+		if got := w.Header().Get("Link"); got != `</api/v2/languages>; rel="successor-version"` {
+			t.Errorf("Unexpected Link header %q", got)
+		}
+
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/languages", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for v2, got %d", w.Code)
+		}
+		if got := w.Header().Get("Deprecation"); got != "" {
+			t.Errorf("Expected no Deprecation header on v2, got %q", got)
+		}
+		if body := w.Body.String(); !strings.HasPrefix(body, `{"data":[{"code":"en"`) || !strings.Contains(body, `"error":null`) {
+			t.Errorf("Expected v2 response in the envelope, got %s", body)
+		}
+		if code := statusOf(srv.Handler(), "/api/v2/auth/verify"); code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for restricted v2 route, got %d", code)
+		}
+	})
+
+	t.Run("v1 disabled", func(t *testing.T) {
+		srv := newTestServer(t, &config.Config{APIV1Disabled: true})
+
+		if code := statusOf(srv.Handler(), "/api/v1/languages"); code != http.StatusNotFound {
+			t.Errorf("Expected 404 for disabled v1, got %d", code)
+		}
+		if code := statusOf(srv.Handler(), "/api/v2/languages"); code != http.StatusOK {
+			t.Errorf("Expected 200 for v2, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package signedurl_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/signedurl"
+)
+
+func TestSigner(t *testing.T) {
+	signer := signedurl.New("test-secret")
+	now := time.Unix(1_800_000_000, 0)
+	expires := now.Add(time.Hour)
+
+	signed := signer.Sign("/api/v2/embed/daily-task", url.Values{"language": {"es"}, "signature": {"stale"}}, expires)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v2/embed/daily-task", u.Path)
+	assert.Equal(t, "es", u.Query().Get("language"))
+	assert.Equal(t, "1800003600", u.Query().Get(signedurl.ExpiresParam))
+	assert.NotEqual(t, "stale", u.Query().Get(signedurl.SignatureParam))
+
+	got, err := signer.Verify(u.Path, u.Query(), now)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(expires))
+
+	t.Run("altered parameters", func(t *testing.T) {
+		for name, alter := range map[string]func(url.Values){
+			"changed value": func(q url.Values) { q.Set("language", "fr") },
+			"added param":   func(q url.Values) { q.Set("category_id", "x") },
+			"removed param": func(q url.Values) { q.Del("language") },
+			"later expiry":  func(q url.Values) { q.Set(signedurl.ExpiresParam, "1900000000") },
+			"bad expiry":    func(q url.Values) { q.Set(signedurl.ExpiresParam, "soon") },
+			"bad signature": func(q url.Values) { q.Set(signedurl.SignatureParam, strings.Repeat("A", 43)) },
+		} {
+			q := u.Query()
+			alter(q)
+			_, err := signer.Verify(u.Path, q, now)
+			assert.ErrorIs(t, err, signedurl.ErrInvalid, name)
+		}
+	})
+
+	t.Run("altered path", func(t *testing.T) {
+		_, err := signer.Verify("/api/v2/embed/other", u.Query(), now)
+		assert.ErrorIs(t, err, signedurl.ErrInvalid)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		_, err := signedurl.New("rotated").Verify(u.Path, u.Query(), now)
+		assert.ErrorIs(t, err, signedurl.ErrInvalid)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := signer.Verify(u.Path, u.Query(), expires)
+		assert.ErrorIs(t, err, signedurl.ErrExpired)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		_, err := signer.Verify(u.Path, url.Values{"language": {"es"}}, now)
+		assert.ErrorIs(t, err, signedurl.ErrUnsigned)
+	})
+
+	t.Run("random secret without one configured", func(t *testing.T) {
+		a, b := signedurl.New(""), signedurl.New("")
+		link, _ := url.Parse(a.Sign("/p", nil, expires))
+		_, err := a.Verify(link.Path, link.Query(), now)
+		assert.NoError(t, err)
+		_, err = b.Verify(link.Path, link.Query(), now)
+		assert.ErrorIs(t, err, signedurl.ErrInvalid)
+	})
+}
@@ -0,0 +1,205 @@
+package slowquery
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// maxIndexColumns bounds the columns of a suggested index
+const maxIndexColumns = 4
+
+// Analysis is a recorded query shape with SQLite's plan for its sample
+type Analysis struct {
+	Query
+	AvgMs float64 `json:"avg_ms"`
+	// Plan is the detail of each step of EXPLAIN QUERY PLAN
+	Plan []string `json:"plan,omitempty"`
+	// FullScans are the tables the plan reads whole
+	FullScans []string `json:"full_scans,omitempty"`
+	// Filters are the columns the query filters the scanned tables on
+	Filters map[string][]string `json:"filters,omitempty"`
+	// PlanError says why there is no plan
+	PlanError string `json:"plan_error,omitempty"`
+}
+
+// Suggestion is an index that would spare slow queries a full scan of a
+// table filtered on the same columns
+type Suggestion struct {
+	Table     string   `json:"table"`
+	Columns   []string `json:"columns"`
+	Statement string   `json:"statement"`
+	// Queries counts the shapes that would use it, TotalMs their time
+	Queries int     `json:"queries"`
+	TotalMs float64 `json:"total_ms"`
+}
+
+// Report is the slow query log with index advice
+type Report struct {
+	ThresholdMs int64        `json:"threshold_ms"`
+	Queries     []Analysis   `json:"queries"`
+	Suggestions []Suggestion `json:"suggestions"`
+	// Dropped counts slow queries of shapes beyond the log's room
+	Dropped int64 `json:"dropped"`
+}
+
+// Advise explains the limit slowest shapes on db and suggests an index per
+// table and filter combination that is scanned in full. Plans are only
+// available on SQLite.
+func (l *Log) Advise(db *gorm.DB, limit int) Report {
+	queries, dropped := l.Queries()
+	if limit > 0 && len(queries) > limit {
+		queries = queries[:limit]
+	}
+	report := Report{
+		ThresholdMs: l.threshold.Milliseconds(),
+		Queries:     make([]Analysis, 0, len(queries)),
+		Suggestions: []Suggestion{},
+		Dropped:     dropped,
+	}
+
+	// The advisor's own queries stay out of the log
+	quiet := db.Session(&gorm.Session{Logger: logger.Discard})
+	suggestions := make(map[string]*Suggestion)
+	for _, q := range queries {
+		analysis := Analysis{Query: q, AvgMs: q.AvgMs()}
+		analysis.Plan, analysis.PlanError = explain(quiet, q)
+		analysis.FullScans = fullScans(analysis.Plan)
+		for _, table := range analysis.FullScans {
+			columns := filterColumns(q.SQL, table)
+			if len(columns) == 0 {
+				continue
+			}
+			if analysis.Filters == nil {
+				analysis.Filters = make(map[string][]string)
+			}
+			analysis.Filters[table] = columns
+
+			key := table + "(" + strings.Join(columns, ",") + ")"
+			s, ok := suggestions[key]
+			if !ok {
+				s = &Suggestion{
+					Table:   table,
+					Columns: columns,
+					Statement: fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);",
+						table, strings.Join(columns, "_"), table, strings.Join(columns, ", ")),
+				}
+				suggestions[key] = s
+			}
+			s.Queries++
+			s.TotalMs += q.TotalMs
+		}
+		report.Queries = append(report.Queries, analysis)
+	}
+
+	for _, s := range suggestions {
+		report.Suggestions = append(report.Suggestions, *s)
+	}
+	sort.Slice(report.Suggestions, func(i, j int) bool {
+		if report.Suggestions[i].TotalMs != report.Suggestions[j].TotalMs {
+			return report.Suggestions[i].TotalMs > report.Suggestions[j].TotalMs
+		}
+		return report.Suggestions[i].Statement < report.Suggestions[j].Statement
+	})
+	return report
+}
+
+// explain returns SQLite's plan for a query's sample
+func explain(db *gorm.DB, q Query) ([]string, string) {
+	if db.Dialector.Name() != "sqlite" {
+		return nil, "plans are only available on SQLite"
+	}
+	if q.truncated {
+		return nil, "sample too long to explain"
+	}
+	verb, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(q.Sample)), " ")
+	if verb != "SELECT" && verb != "UPDATE" && verb != "DELETE" && verb != "WITH" {
+		return nil, "only reads, updates and deletes are explained"
+	}
+
+	rows, err := db.Raw("EXPLAIN QUERY PLAN " + q.Sample).Rows()
+	if err != nil {
+		return nil, err.Error()
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, err.Error()
+		}
+		plan = append(plan, detail)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err.Error()
+	}
+	return plan, ""
+}
+
+// fullScans returns the tables a plan reads whole: SCAN steps that use no
+// index
+func fullScans(plan []string) []string {
+	var tables []string
+	for _, step := range plan {
+		rest, ok := strings.CutPrefix(step, "SCAN ")
+		if !ok || strings.Contains(rest, " USING ") {
+			continue
+		}
+		table, _, _ := strings.Cut(rest, " ")
+		if table == "CONSTANT" || table == "SUBQUERY" || strings.HasPrefix(table, "(") {
+			continue
+		}
+		tables = append(tables, strings.Trim(table, `"`))
+	}
+	return tables
+}
+
+var (
+	whereClause = regexp.MustCompile(`(?is)\bWHERE\b(.*?)(?:\bGROUP BY\b|\bORDER BY\b|\bLIMIT\b|\bHAVING\b|$)`)
+	comparison  = regexp.MustCompile(`(?i)(?:[\x60"]?(\w+)[\x60"]?\.)?[\x60"]?(\w+)[\x60"]?\s*(=|!=|<>|<=|>=|<|>|\bNOT IN\b|\bIN\b|\bLIKE\b|\bIS\b|\bBETWEEN\b)`)
+)
+
+// filterColumns returns the columns of table a query's WHERE clauses
+// compare, equality comparisons first as an index wants them, without
+// deleted_at, which soft-deleted models add to every query
+func filterColumns(sql, table string) []string {
+	var equality, ranges []string
+	seen := map[string]bool{"deleted_at": true}
+	for _, where := range whereClause.FindAllStringSubmatch(sql, -1) {
+		for _, m := range comparison.FindAllStringSubmatch(where[1], -1) {
+			qualifier, column, op := m[1], strings.ToLower(m[2]), strings.ToUpper(m[3])
+			if qualifier != "" && !strings.EqualFold(qualifier, table) {
+				continue
+			}
+			if seen[column] || isKeyword(column) {
+				continue
+			}
+			seen[column] = true
+			if op == "=" || op == "IN" || op == "IS" {
+				equality = append(equality, column)
+			} else {
+				ranges = append(ranges, column)
+			}
+		}
+	}
+	columns := append(equality, ranges...)
+	if len(columns) > maxIndexColumns {
+		columns = columns[:maxIndexColumns]
+	}
+	return columns
+}
+
+// isKeyword reports whether a matched word is SQL rather than a column
+func isKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT", "NULL", "WHERE", "SELECT", "EXISTS":
+		return true
+	}
+	return false
+}
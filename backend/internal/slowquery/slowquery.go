@@ -0,0 +1,182 @@
+// Package slowquery records the queries slower than a threshold, grouped
+// by shape with their values masked, and advises on indexes for the ones
+// SQLite answers with a full table scan. It wraps the GORM logger, so every
+// query of the connection is seen, whichever repository or job ran it.
+package slowquery
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Log limits
+const (
+	// maxShapes bounds the query shapes kept; slow queries of further
+	// shapes are counted as dropped
+	maxShapes = 200
+	// maxSampleBytes bounds the sample kept per shape
+	maxSampleBytes = 4 << 10
+)
+
+// Query aggregates the slow runs of one query shape
+type Query struct {
+	// SQL is the shape, with literals replaced by ?
+	SQL string `json:"sql"`
+	// Sample is the slowest run with its values
+	Sample    string    `json:"sample"`
+	Count     int64     `json:"count"`
+	TotalMs   float64   `json:"total_ms"`
+	MaxMs     float64   `json:"max_ms"`
+	MaxRows   int64     `json:"max_rows"`
+	LastSeen  time.Time `json:"last_seen"`
+	truncated bool
+}
+
+// AvgMs returns the mean duration of the shape's slow runs
+func (q Query) AvgMs() float64 {
+	if q.Count == 0 {
+		return 0
+	}
+	return q.TotalMs / float64(q.Count)
+}
+
+// store is the slow query log shared by a Log and the copies LogMode makes
+type store struct {
+	mu      sync.Mutex
+	queries map[string]*Query
+	dropped int64
+}
+
+// Log is a GORM logger recording slow queries before passing every call
+// on to the logger it wraps.
+type Log struct {
+	base      logger.Interface
+	threshold time.Duration
+	store     *store
+}
+
+// New wraps base, recording queries that take threshold or longer
+func New(base logger.Interface, threshold time.Duration) *Log {
+	return &Log{base: base, threshold: threshold, store: &store{queries: make(map[string]*Query)}}
+}
+
+// From returns the slow query log installed as db's logger, or nil
+func From(db *gorm.DB) *Log {
+	l, _ := db.Logger.(*Log)
+	return l
+}
+
+// LogMode sets the log level of the wrapped logger, keeping the record
+func (l *Log) LogMode(level logger.LogLevel) logger.Interface {
+	return &Log{base: l.base.LogMode(level), threshold: l.threshold, store: l.store}
+}
+
+// Info logs through the wrapped logger
+func (l *Log) Info(ctx context.Context, msg string, data ...interface{}) {
+	l.base.Info(ctx, msg, data...)
+}
+
+// Warn logs through the wrapped logger
+func (l *Log) Warn(ctx context.Context, msg string, data ...interface{}) {
+	l.base.Warn(ctx, msg, data...)
+}
+
+// Error logs through the wrapped logger
+func (l *Log) Error(ctx context.Context, msg string, data ...interface{}) {
+	l.base.Error(ctx, msg, data...)
+}
+
+// Trace records the query when it was slow and passes it on
+func (l *Log) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if elapsed := time.Since(begin); elapsed >= l.threshold {
+		sql, rows := fc()
+		l.record(sql, rows, elapsed, time.Now())
+	}
+	l.base.Trace(ctx, begin, fc, err)
+}
+
+// Threshold returns the duration from which queries are recorded
+func (l *Log) Threshold() time.Duration {
+	return l.threshold
+}
+
+// Queries returns the recorded shapes, slowest in total first, and how
+// many slow queries were dropped for lack of room
+func (l *Log) Queries() ([]Query, int64) {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	queries := make([]Query, 0, len(l.store.queries))
+	for _, q := range l.store.queries {
+		queries = append(queries, *q)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].TotalMs != queries[j].TotalMs {
+			return queries[i].TotalMs > queries[j].TotalMs
+		}
+		return queries[i].SQL < queries[j].SQL
+	})
+	return queries, l.store.dropped
+}
+
+// Reset forgets every recorded query, e.g. to measure after adding an index
+func (l *Log) Reset() {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	l.store.queries = make(map[string]*Query)
+	l.store.dropped = 0
+}
+
+// record adds a slow run to its shape
+func (l *Log) record(sql string, rows int64, elapsed time.Duration, now time.Time) {
+	shape := Normalize(sql)
+	ms := float64(elapsed.Microseconds()) / 1000
+
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	q, ok := l.store.queries[shape]
+	if !ok {
+		if len(l.store.queries) >= maxShapes {
+			l.store.dropped++
+			return
+		}
+		q = &Query{SQL: shape}
+		l.store.queries[shape] = q
+	}
+	q.Count++
+	q.TotalMs += ms
+	q.LastSeen = now
+	if ms >= q.MaxMs {
+		q.MaxMs = ms
+		q.MaxRows = rows
+		q.Sample, q.truncated = sql, len(sql) > maxSampleBytes
+		if q.truncated {
+			q.Sample = strings.ToValidUTF8(sql[:maxSampleBytes], "")
+		}
+	}
+}
+
+var (
+	// GORM quotes identifiers with backticks and values, when it fills
+	// them in for the log, with double quotes
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"`)
+	numberLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	valueList     = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	space         = regexp.MustCompile(`\s+`)
+)
+
+// Normalize returns the shape of a query: string and number literals
+// become ?, lists of them a single (?), and runs of space one space.
+func Normalize(sql string) string {
+	sql = stringLiteral.ReplaceAllString(sql, "?")
+	sql = numberLiteral.ReplaceAllString(sql, "?")
+	sql = valueList.ReplaceAllString(sql, "(?)")
+	return strings.TrimSpace(space.ReplaceAllString(sql, " "))
+}
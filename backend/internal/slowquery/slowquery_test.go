@@ -0,0 +1,121 @@
+package slowquery
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type item struct {
+	ID       uint `gorm:"primaryKey"`
+	Kind     string
+	Language string
+	Score    int
+}
+
+func TestNormalize(t *testing.T) {
+	got := Normalize("SELECT * FROM `tasks`  WHERE `language` = 'it''s' AND id IN (1, 2,3)\n LIMIT 10")
+	want := "SELECT * FROM `tasks` WHERE `language` = ? AND id IN (?) LIMIT ?"
+	if got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+}
+
+func TestLog(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "slow.db")), &gorm.Config{
+		// Every query counts as slow
+		Logger: New(logger.Discard, 0),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	log := From(db)
+	if log == nil {
+		t.Fatal("Expected the slow query log to be installed")
+	}
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	log.Reset()
+
+	var items []item
+	for _, language := range []string{"en", "es", "fr"} {
+		db.Where("kind = ? AND language = ?", "dare", language).Where("score > ?", 3).Find(&items)
+	}
+	db.First(&item{}, 1)
+	// Sessions such as Debug keep recording
+	db.Debug().Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)}).Find(&items, "language = ?", "de")
+
+	queries, dropped := log.Queries()
+	if dropped != 0 {
+		t.Errorf("Expected nothing dropped, got %d", dropped)
+	}
+	var scan *Query
+	for i, q := range queries {
+		if strings.Contains(q.SQL, "kind = ?") {
+			scan = &queries[i]
+		}
+	}
+	if scan == nil || scan.Count != 3 || len(queries) != 3 {
+		t.Fatalf("Expected 3 runs of the filtered query among 3 shapes, got %+v", queries)
+	}
+
+	report := log.Advise(db, 10)
+	var analysis *Analysis
+	for i := range report.Queries {
+		if report.Queries[i].SQL == scan.SQL {
+			analysis = &report.Queries[i]
+		}
+	}
+	if analysis == nil || len(analysis.FullScans) != 1 || analysis.FullScans[0] != "items" {
+		t.Fatalf("Expected a full scan of items, got %+v", analysis)
+	}
+	if got := strings.Join(analysis.Filters["items"], ","); got != "kind,language,score" {
+		t.Errorf("Expected equality filters before ranges, got %s", got)
+	}
+	if len(report.Suggestions) != 2 {
+		t.Fatalf("Expected 2 suggestions, got %+v", report.Suggestions)
+	}
+	want := "CREATE INDEX idx_items_kind_language_score ON items (kind, language, score);"
+	found := false
+	for _, s := range report.Suggestions {
+		found = found || s.Statement == want
+	}
+	if !found {
+		t.Errorf("Expected %q among %+v", want, report.Suggestions)
+	}
+
+	// Adding the index stops the full scan
+	if err := db.Exec(want).Error; err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	for _, q := range log.Advise(db, 10).Queries {
+		if q.SQL == scan.SQL && len(q.FullScans) > 0 {
+			t.Errorf("Expected the index to be used, got plan %v", q.Plan)
+		}
+	}
+	if queries, _ := log.Queries(); len(queries) != 4 {
+		t.Errorf("Expected the advisor's queries to stay out of the log, got %d shapes", len(queries))
+	}
+
+	log.Reset()
+	if queries, _ := log.Queries(); len(queries) != 0 {
+		t.Errorf("Expected an empty log after reset, got %d", len(queries))
+	}
+}
+
+func TestLog_Threshold(t *testing.T) {
+	log := New(logger.Discard, time.Second)
+	log.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	log.Trace(context.Background(), time.Now().Add(-2*time.Second), func() (string, int64) { return "SELECT 2", 1 }, nil)
+	queries, _ := log.Queries()
+	if len(queries) != 1 || queries[0].SQL != "SELECT ?" || queries[0].Sample != "SELECT 2" {
+		t.Errorf("Expected only the slow query, got %+v", queries)
+	}
+}
@@ -0,0 +1,70 @@
+// Package storage stores generated media files and returns their public URLs.
+//
+// The local backend writes files under a directory that the server exposes
+// as static files. Other backends (S3, GCS) can implement Store without
+// changes to callers.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Store saves objects and returns the URL clients use to fetch them
+type Store interface {
+	// Put writes data under key, replacing any existing object
+	Put(ctx context.Context, key string, data []byte, contentType string) (url string, err error)
+}
+
+// LocalStore keeps objects on the local filesystem
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates a store writing under dir and serving files from baseURL
+func NewLocalStore(dir, baseURL string) *LocalStore {
+	return &LocalStore{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Dir returns the directory files are written to
+func (s *LocalStore) Dir() string {
+	return s.dir
+}
+
+// Put writes data to dir/key, creating parent directories as needed
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != key {
+		return "", errors.New("storage: invalid key " + key)
+	}
+
+	target := filepath.Join(s.dir, filepath.FromSlash(clean))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", fmt.Errorf("storage: failed to create directory: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("storage: failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("storage: failed to move file into place: %w", err)
+	}
+
+	return s.baseURL + "/" + clean, nil
+}
@@ -0,0 +1,41 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/truthordare/backend/internal/storage"
+)
+
+func TestLocalStore_Put(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocalStore(dir, "https://cdn.example.com/media/")
+
+	url, err := store.Put(context.Background(), "categories/abc.png", []byte("png"), "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/media/categories/abc.png", url)
+
+	data, err := os.ReadFile(filepath.Join(dir, "categories", "abc.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	t.Run("overwrites existing object", func(t *testing.T) {
+		_, err := store.Put(context.Background(), "categories/abc.png", []byte("new"), "image/png")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "categories", "abc.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(data))
+	})
+
+	t.Run("rejects keys escaping the directory", func(t *testing.T) {
+		for _, key := range []string{"../evil.png", "/abs.png", "a/../../b.png", ""} {
+			_, err := store.Put(context.Background(), key, []byte("x"), "image/png")
+			assert.Error(t, err, key)
+		}
+	})
+}
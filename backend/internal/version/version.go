@@ -0,0 +1,62 @@
+// Package version exposes build information injected at link time:
+//
+//	go build -ldflags "-X github.com/truthordare/backend/internal/version.Version=1.2.0 \
+//	  -X github.com/truthordare/backend/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/truthordare/backend/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without ldflags report "dev" and fall back to the VCS details Go
+// embeds in the binary, when available.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set with -ldflags -X
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if info.Commit == "unknown" || info.Date == "unknown" {
+		if build, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range build.Settings {
+				switch {
+				case setting.Key == "vcs.revision" && info.Commit == "unknown":
+					info.Commit = shortCommit(setting.Value)
+				case setting.Key == "vcs.time" && info.Date == "unknown":
+					info.Date = setting.Value
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// shortCommit trims a full commit hash to the usual short form
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
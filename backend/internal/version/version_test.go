@@ -0,0 +1,20 @@
+package version
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+
+	Version, Commit, Date = "1.2.3", "abc1234", "2026-01-02T03:04:05Z"
+	info := Get()
+	if info.Version != "1.2.3" || info.Commit != "abc1234" || info.Date != "2026-01-02T03:04:05Z" {
+		t.Errorf("Expected ldflags values to be reported, got %+v", info)
+	}
+	if info.GoVersion == "" {
+		t.Error("Expected the Go version to be reported")
+	}
+
+	if got := shortCommit("0123456789abcdef"); got != "0123456" {
+		t.Errorf("Expected short commit 0123456, got %s", got)
+	}
+}